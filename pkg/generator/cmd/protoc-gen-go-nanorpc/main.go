@@ -0,0 +1,170 @@
+// Package main implements protoc-gen-go-nanorpc, a protoc plugin that
+// generates Go constants for the NanoRPC request paths declared with the
+// (nanorpc).request_path method option, and a RegisterPaths function
+// adding them to a nanorpc.HashCache.
+//
+// For every service with unary methods declaring a request path it also
+// generates a typed client, <Service>Client, calling them through a
+// client.Requester, and a <Service>Server interface registered as their
+// handlers by Register<Service>Server.
+//
+//	protoc --go-nanorpc_out=. --go-nanorpc_opt=paths=source_relative foo.proto
+//
+// Generation fails, listing the offending methods, when paths declared
+// across the compilation unit have colliding FNV-1a hashes, instead of
+// leaving the collision to be found at runtime. With the
+// validate_paths=true option, it also fails when two methods declare the
+// same request path.
+//
+//	protoc --go-nanorpc_out=. --go-nanorpc_opt=validate_paths=true *.proto
+//
+// With the hash_consts=true option, it also writes <name>_nanorpc_hash.pb.go
+// with a <Service>_<Method>_PathHash constant for every path, and a
+// RegisterPathHashes function adding them to a nanorpc.HashCache without
+// hashing at runtime. It implies validate_paths=true.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"go/format"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"protomcp.org/nanorpc/pkg/generator"
+)
+
+// options are the parameters of the plugin.
+type options struct {
+	validatePaths bool
+	hashConsts    bool
+}
+
+func main() {
+	var flags flag.FlagSet
+	var opts options
+	flags.BoolVar(&opts.validatePaths, "validate_paths", false,
+		"fail on duplicated request paths too, not only path hash collisions")
+	flags.BoolVar(&opts.hashConsts, "hash_consts", false,
+		"generate path_hash constants, validating the paths")
+
+	protogen.Options{ParamFunc: flags.Set}.Run(func(plugin *protogen.Plugin) error {
+		return run(plugin, opts)
+	})
+}
+
+func run(plugin *protogen.Plugin, opts options) error {
+	if err := validatePaths(plugin, opts.validatePaths || opts.hashConsts); err != nil {
+		return err
+	}
+
+	gen := new(generator.Generator)
+	if err := gen.WithTemplates(nil, generator.Templates); err != nil {
+		return err
+	}
+
+	for _, file := range plugin.Files {
+		if !file.Generate {
+			continue
+		}
+
+		if err := generateFile(plugin, gen, file); err != nil {
+			return err
+		}
+		if opts.hashConsts {
+			if err := generateHashes(plugin, gen, file); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validatePaths checks the request paths declared across all the files of
+// the compilation unit, including those imported but not generated, for
+// hash collisions, and duplicates if strict.
+func validatePaths(plugin *protogen.Plugin, strict bool) error {
+	var pc generator.PathChecker
+	for _, file := range plugin.Files {
+		pc.Add(generator.ServicePaths(file.Desc)...)
+	}
+
+	if strict {
+		return pc.Err()
+	}
+	return pc.CollisionErr()
+}
+
+// generateFile writes <name>_nanorpc.pb.go for a proto file declaring
+// request paths.
+func generateFile(plugin *protogen.Plugin, gen *generator.Generator, file *protogen.File) error {
+	paths := generator.ServicePaths(file.Desc)
+	if len(paths) == 0 {
+		return nil
+	}
+
+	out := plugin.NewGeneratedFile(file.GeneratedFilenamePrefix+"_nanorpc.pb.go", file.GoImportPath)
+
+	var buf bytes.Buffer
+	err := gen.GeneratePaths(&buf, generator.PathsFile{
+		Source:    file.Desc.Path(),
+		GoPackage: string(file.GoPackageName),
+		Paths:     paths,
+		Services:  generator.Services(file.Desc, newGoTypeFunc(out, file)),
+	})
+	if err != nil {
+		return err
+	}
+	return writeSource(out, buf.Bytes())
+}
+
+// generateHashes writes <name>_nanorpc_hash.pb.go for a proto file
+// declaring request paths.
+func generateHashes(plugin *protogen.Plugin, gen *generator.Generator, file *protogen.File) error {
+	paths := generator.ServicePaths(file.Desc)
+	if len(paths) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	err := gen.GenerateHashes(&buf, generator.PathsFile{
+		Source:    file.Desc.Path(),
+		GoPackage: string(file.GoPackageName),
+		Paths:     paths,
+	})
+	if err != nil {
+		return err
+	}
+
+	out := plugin.NewGeneratedFile(file.GeneratedFilenamePrefix+"_nanorpc_hash.pb.go", file.GoImportPath)
+	return writeSource(out, buf.Bytes())
+}
+
+// writeSource writes gofmt'ed Go source into a generated file.
+func writeSource(out *protogen.GeneratedFile, src []byte) error {
+	src, err := format.Source(src)
+	if err != nil {
+		return err
+	}
+
+	_, err = out.Write(src)
+	return err
+}
+
+// newGoTypeFunc returns a [generator.GoTypeFunc] naming the request and
+// response messages of the services of a file, importing their packages
+// into out when needed.
+func newGoTypeFunc(out *protogen.GeneratedFile, file *protogen.File) generator.GoTypeFunc {
+	idents := make(map[protoreflect.FullName]protogen.GoIdent)
+	for _, service := range file.Services {
+		for _, method := range service.Methods {
+			idents[method.Input.Desc.FullName()] = method.Input.GoIdent
+			idents[method.Output.Desc.FullName()] = method.Output.GoIdent
+		}
+	}
+
+	return func(msg protoreflect.MessageDescriptor) string {
+		return out.QualifiedGoIdent(idents[msg.FullName()])
+	}
+}
@@ -0,0 +1,92 @@
+// Package main implements protoc-gen-nanorpc-nanopb, a protoc plugin that
+// generates C99 helpers calling NanoRPC services from firmware using the
+// nanopb runtime, next to the message types generated by nanopb's own
+// protoc-gen-nanopb.
+//
+//	protoc --nanopb_out=. --nanorpc-nanopb_out=. foo.proto
+//
+// For every proto file with methods declaring a (nanorpc).request_path it
+// writes <name>.nanorpc.h, with the path and its precomputed FNV-1a hash
+// of every method, so firmware never hashes paths nor carries their
+// strings. Unary methods get a request builder and a response decoder,
+// and server streaming methods, mapped to subscriptions, a subscription
+// and unsubscription builder, an update decoder and an update callback
+// type with its dispatcher.
+//
+// The helpers are static inline functions writing and reading the
+// length-prefixed frames of nanorpc.proto through nanopb streams, so the
+// nanopb output of nanorpc.proto must be built in too.
+//
+// Generation fails, listing the offending methods, when paths declared
+// across the compilation unit are duplicated or have colliding hashes.
+package main
+
+import (
+	"bytes"
+
+	"google.golang.org/protobuf/compiler/protogen"
+
+	"protomcp.org/nanorpc/pkg/generator"
+)
+
+func main() {
+	protogen.Options{}.Run(run)
+}
+
+func run(plugin *protogen.Plugin) error {
+	if err := validatePaths(plugin); err != nil {
+		return err
+	}
+
+	gen := new(generator.Generator)
+	if err := gen.WithTemplates(nil, generator.Templates); err != nil {
+		return err
+	}
+
+	for _, file := range plugin.Files {
+		if !file.Generate {
+			continue
+		}
+
+		if err := generateFile(plugin, gen, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validatePaths checks the request paths declared across all the files of
+// the compilation unit, including those imported but not generated.
+func validatePaths(plugin *protogen.Plugin) error {
+	var pc generator.PathChecker
+	for _, file := range plugin.Files {
+		pc.Add(generator.ServicePaths(file.Desc)...)
+	}
+	return pc.Err()
+}
+
+// generateFile writes <name>.nanorpc.h for a proto file declaring request
+// paths.
+func generateFile(plugin *protogen.Plugin, gen *generator.Generator, file *protogen.File) error {
+	methods := generator.NanopbMethods(file.Desc)
+	if len(methods) == 0 {
+		return nil
+	}
+
+	header := generator.NanopbHeader(file.Desc)
+
+	var buf bytes.Buffer
+	err := gen.GenerateNanopb(&buf, generator.NanopbFile{
+		Source:   file.Desc.Path(),
+		Guard:    generator.NanopbGuard(header),
+		Includes: generator.NanopbIncludes(file.Desc),
+		Methods:  methods,
+	})
+	if err != nil {
+		return err
+	}
+
+	out := plugin.NewGeneratedFile(header, "")
+	_, err = out.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,94 @@
+// Package main implements protoc-gen-nanorpc-wireshark, a protoc plugin
+// that generates a Wireshark dissector, in Lua, of the NanoRPC frames.
+//
+//	protoc --nanorpc-wireshark_out=. foo.proto
+//
+// It writes nanorpc.lua, decoding the length-prefixed requests and
+// responses field by field from the messages of the nanorpc.proto of the
+// compilation unit, so new fields show up by regenerating it. Enum values
+// are shown by name, ping and pong payloads as handshakes, and path
+// hashes with the path declared by a (nanorpc).request_path of any file
+// of the compilation unit.
+//
+// Copy it to the Wireshark personal Lua plugins directory, then set the
+// server port in the NanoRPC protocol preferences, or pick NANORPC in
+// Decode As, for TCP or UDP. Packed repeated fields are shown as unknown.
+//
+// Generation fails, listing the offending methods, when paths declared
+// across the compilation unit are duplicated or have colliding hashes.
+package main
+
+import (
+	"bytes"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"protomcp.org/nanorpc/pkg/generator"
+)
+
+// outputName is the name of the generated dissector.
+const outputName = "nanorpc.lua"
+
+func main() {
+	protogen.Options{}.Run(run)
+}
+
+func run(plugin *protogen.Plugin) error {
+	if !generates(plugin) {
+		return nil
+	}
+
+	files := make([]protoreflect.FileDescriptor, 0, len(plugin.Files))
+	var pc generator.PathChecker
+	for _, file := range plugin.Files {
+		files = append(files, file.Desc)
+		pc.Add(generator.ServicePaths(file.Desc)...)
+	}
+	if err := pc.Err(); err != nil {
+		return err
+	}
+
+	source, err := generator.FindWiresharkSource(files)
+	if err != nil {
+		return err
+	}
+
+	var paths []generator.ServicePath
+	for _, file := range files {
+		paths = append(paths, generator.ServicePaths(file)...)
+	}
+
+	wf, err := generator.NewWiresharkFile(source, paths)
+	if err != nil {
+		return err
+	}
+	return generate(plugin, wf)
+}
+
+// generates tells if any file of the compilation unit is to be generated.
+func generates(plugin *protogen.Plugin) bool {
+	for _, file := range plugin.Files {
+		if file.Generate {
+			return true
+		}
+	}
+	return false
+}
+
+// generate writes the dissector.
+func generate(plugin *protogen.Plugin, wf generator.WiresharkFile) error {
+	gen := new(generator.Generator)
+	if err := gen.WithTemplates(nil, generator.Templates); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := gen.GenerateWireshark(&buf, wf); err != nil {
+		return err
+	}
+
+	out := plugin.NewGeneratedFile(outputName, "")
+	_, err := out.Write(buf.Bytes())
+	return err
+}
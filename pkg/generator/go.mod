@@ -5,10 +5,10 @@ go 1.25.0
 require (
 	darvaza.org/core v0.21.2
 	github.com/amery/protogen v0.3.11
+	google.golang.org/protobuf v1.36.11
 )
 
 require (
 	golang.org/x/net v0.57.0 // indirect
 	golang.org/x/text v0.40.0 // indirect
-	google.golang.org/protobuf v1.36.11 // indirect
 )
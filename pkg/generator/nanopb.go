@@ -0,0 +1,163 @@
+package generator
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// NanopbFile is the data rendered by the nanopb template, a C99 header
+// of helpers calling the services of a proto file from firmware using
+// the nanopb runtime.
+type NanopbFile struct {
+	// Source is the name of the proto file the methods come from.
+	Source string
+	// Guard is the include guard of the header.
+	Guard string
+	// Includes are the nanopb headers declaring the messages used.
+	Includes []string
+	// Methods are the methods declaring a request path.
+	Methods []NanopbMethod
+}
+
+// NanopbMethod is a service method declaring a request path, rendered as
+// C99 helpers. Unary methods get a request builder and a response decoder,
+// server streaming ones a subscription builder, an update decoder and
+// callback, and an unsubscription builder.
+type NanopbMethod struct {
+	// Name is the C prefix of the helpers, <package>_<Service>_<Method>.
+	Name string
+	// Method is the full name of the method.
+	Method string
+	// Path is the request path, as a C string literal.
+	Path string
+	// Input is the C type of the request message.
+	Input string
+	// Output is the C type of the response message.
+	Output string
+	// Hash is the FNV-1a hash of the request path.
+	Hash uint32
+	// Subscription tells if the method is server streaming, mapped to a
+	// NanoRPC subscription.
+	Subscription bool
+}
+
+// NanopbMethods returns the unary and server streaming methods of the
+// services of a file declaring request paths, in declaration order.
+func NanopbMethods(file protoreflect.FileDescriptor) []NanopbMethod {
+	var out []NanopbMethod
+
+	services := file.Services()
+	for i := range services.Len() {
+		methods := services.Get(i).Methods()
+		for j := range methods.Len() {
+			method := methods.Get(j)
+			if m, ok := newNanopbMethod(method); ok {
+				out = append(out, m)
+			}
+		}
+	}
+	return out
+}
+
+func newNanopbMethod(method protoreflect.MethodDescriptor) (NanopbMethod, bool) {
+	path, ok := RequestPath(method)
+	if !ok || method.IsStreamingClient() {
+		return NanopbMethod{}, false
+	}
+
+	return NanopbMethod{
+		Name:         NanopbName(method.FullName()),
+		Method:       string(method.FullName()),
+		Path:         cString(path),
+		Input:        NanopbName(method.Input().FullName()),
+		Output:       NanopbName(method.Output().FullName()),
+		Hash:         PathHash(path),
+		Subscription: method.IsStreamingServer(),
+	}, true
+}
+
+// NanopbName returns the C name nanopb gives to a declaration, its full
+// name with dots replaced by underscores, e.g. sensors_Reading for
+// sensors.Reading, or sensors_v1_Outer_Inner for a nested message.
+func NanopbName(name protoreflect.FullName) string {
+	return strings.ReplaceAll(string(name), ".", "_")
+}
+
+// NanopbIncludes returns the nanopb headers, <name>.pb.h, declaring the
+// messages used by the methods of a file declaring request paths, sorted.
+func NanopbIncludes(file protoreflect.FileDescriptor) []string {
+	seen := make(map[string]bool)
+
+	services := file.Services()
+	for i := range services.Len() {
+		methods := services.Get(i).Methods()
+		for j := range methods.Len() {
+			method := methods.Get(j)
+			if _, ok := newNanopbMethod(method); ok {
+				seen[nanopbHeader(method.Input().ParentFile())] = true
+				seen[nanopbHeader(method.Output().ParentFile())] = true
+			}
+		}
+	}
+
+	out := make([]string, 0, len(seen))
+	for h := range seen {
+		out = append(out, h)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// NanopbHeader returns the name of the header of the helpers generated
+// for a proto file, <name>.nanorpc.h.
+func NanopbHeader(file protoreflect.FileDescriptor) string {
+	return strings.TrimSuffix(file.Path(), ".proto") + ".nanorpc.h"
+}
+
+// NanopbGuard returns the include guard of a header.
+func NanopbGuard(header string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(header) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String() + "_INCLUDED"
+}
+
+// nanopbHeader returns the header nanopb generates for a proto file.
+func nanopbHeader(file protoreflect.FileDescriptor) string {
+	return strings.TrimSuffix(file.Path(), ".proto") + ".pb.h"
+}
+
+// GenerateNanopb renders the C99 helpers of the methods of a file.
+func (gen *Generator) GenerateNanopb(out io.Writer, file NanopbFile) error {
+	return gen.T("nanopb", out, file)
+}
+
+// cString returns s as a C string literal, escaping what isn't printable
+// ASCII in octal.
+func cString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c > 0x7e || c == '?':
+			// ? too, to never form trigraphs
+			_, _ = fmt.Fprintf(&b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,94 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestNanopbMethods(t *testing.T) {
+	streaming := newTestMethod("WatchTemperature", "/sensors/temperature/watch")
+	streaming.ServerStreaming = proto.Bool(true)
+	upload := newTestMethod("Upload", "/sensors/upload")
+	upload.ClientStreaming = proto.Bool(true)
+
+	file := newTestFileWith(t,
+		newTestMethod("GetTemperature", "/sensors/temperature"),
+		newTestMethod("Reboot", ""),
+		streaming,
+		upload,
+	)
+
+	core.AssertSliceEqual(t, []NanopbMethod{
+		{
+			Name:   "sensors_SensorService_GetTemperature",
+			Method: "sensors.SensorService.GetTemperature",
+			Path:   `"/sensors/temperature"`,
+			Input:  "sensors_Empty",
+			Output: "sensors_Empty",
+			Hash:   PathHash("/sensors/temperature"),
+		},
+		{
+			Name:         "sensors_SensorService_WatchTemperature",
+			Method:       "sensors.SensorService.WatchTemperature",
+			Path:         `"/sensors/temperature/watch"`,
+			Input:        "sensors_Empty",
+			Output:       "sensors_Empty",
+			Hash:         PathHash("/sensors/temperature/watch"),
+			Subscription: true,
+		},
+	}, NanopbMethods(file), "methods")
+
+	core.AssertSliceEqual(t, []string{"sensors.pb.h"}, NanopbIncludes(file), "includes")
+	core.AssertEqual(t, "sensors.nanorpc.h", NanopbHeader(file), "header")
+	core.AssertEqual(t, "SENSORS_NANORPC_H_INCLUDED", NanopbGuard(NanopbHeader(file)), "guard")
+}
+
+func TestCString(t *testing.T) {
+	core.AssertEqual(t, `"/a/b"`, cString("/a/b"), "plain")
+	core.AssertEqual(t, `"/\"q\"\\"`, cString(`/"q"\`), "quotes")
+	core.AssertEqual(t, `"/caf\303\251\077"`, cString("/café?"), "escaped")
+}
+
+func TestGenerator_GenerateNanopb(t *testing.T) {
+	gen := &Generator{}
+	core.AssertMustNoError(t, gen.WithTemplates(nil, Templates), "WithTemplates")
+
+	streaming := newTestMethod("WatchTemperature", "/sensors/temperature/watch")
+	streaming.ServerStreaming = proto.Bool(true)
+	file := newTestFileWith(t, newTestMethod("GetTemperature", "/sensors/temperature"), streaming)
+
+	var buf bytes.Buffer
+	err := gen.GenerateNanopb(&buf, NanopbFile{
+		Source:   "sensors.proto",
+		Guard:    NanopbGuard(NanopbHeader(file)),
+		Includes: NanopbIncludes(file),
+		Methods:  NanopbMethods(file),
+	})
+	core.AssertMustNoError(t, err, "GenerateNanopb")
+
+	src := buf.String()
+	for _, want := range []string{
+		"#ifndef SENSORS_NANORPC_H_INCLUDED\n",
+		"#include \"sensors.pb.h\"\n",
+		"#define sensors_SensorService_GetTemperature_PATH \"/sensors/temperature\"\n",
+		fmt.Sprintf("#define sensors_SensorService_GetTemperature_PATH_HASH 0x%08xu\n", PathHash("/sensors/temperature")),
+		"static inline bool sensors_SensorService_GetTemperature_request(pb_ostream_t *stream, " +
+			"int32_t request_id, const sensors_Empty *req)",
+		"static inline bool sensors_SensorService_GetTemperature_response(pb_istream_t *stream, " +
+			"NanoRPCResponse *res, sensors_Empty *out)",
+		"typedef void (*sensors_SensorService_WatchTemperature_update_cb)(int32_t request_id, " +
+			"const sensors_Empty *update, void *ctx);",
+		"static inline bool sensors_SensorService_WatchTemperature_subscribe(",
+		"static inline bool sensors_SensorService_WatchTemperature_unsubscribe(",
+		"static inline bool sensors_SensorService_WatchTemperature_dispatch(",
+		"#endif /* SENSORS_NANORPC_H_INCLUDED */\n",
+	} {
+		core.AssertTrue(t, strings.Contains(src, want), "generated %q", want)
+	}
+	core.AssertFalse(t, strings.Contains(src, "WatchTemperature_request"), "request of a subscription")
+}
@@ -0,0 +1,138 @@
+package generator
+
+import (
+	"io"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// RequestPathOption is the field number of the (nanorpc) method option,
+// a NanoRPCMethodOptions message carrying the request_path.
+const RequestPathOption protowire.Number = 5020
+
+// requestPathField is the field number of request_path within
+// NanoRPCMethodOptions.
+const requestPathField protowire.Number = 1
+
+// ServicePath is a NanoRPC request path declared on a service method
+// with the (nanorpc).request_path option.
+type ServicePath struct {
+	// Name is the Go constant holding the path, <Service>_<Method>_Path.
+	Name string
+	// Method is the full name of the declaring method.
+	Method string
+	// Path is the request path.
+	Path string
+}
+
+// HashName returns the name of the Go constant holding the path_hash of
+// the path, <Service>_<Method>_PathHash.
+func (p ServicePath) HashName() string {
+	return p.Name + "Hash"
+}
+
+// Hash returns the path_hash of the path, see [PathHash].
+func (p ServicePath) Hash() uint32 {
+	return PathHash(p.Path)
+}
+
+// PathsFile is the data rendered by the paths template.
+type PathsFile struct {
+	// Source is the name of the proto file the paths come from.
+	Source string
+	// GoPackage is the name of the Go package of the generated file.
+	GoPackage string
+	// Paths are the request paths declared in Source.
+	Paths []ServicePath
+	// Services are the services of Source rendered as typed clients and
+	// server interfaces, see [Services].
+	Services []Service
+}
+
+// RequestPath returns the (nanorpc).request_path option of a method.
+// The option is read from the encoded options, so it's found whether or
+// not the nanorpc extension is linked into the generator.
+func RequestPath(method protoreflect.MethodDescriptor) (string, bool) {
+	opts, ok := method.Options().(*descriptorpb.MethodOptions)
+	if !ok || opts == nil {
+		return "", false
+	}
+
+	b, err := proto.Marshal(opts)
+	if err != nil {
+		return "", false
+	}
+
+	if opt, ok := findBytesField(b, RequestPathOption); ok {
+		if path, ok := findBytesField(opt, requestPathField); ok && len(path) > 0 {
+			return string(path), true
+		}
+	}
+	return "", false
+}
+
+// ServicePaths returns the request paths declared by the methods of the
+// services in a file, in declaration order.
+func ServicePaths(file protoreflect.FileDescriptor) []ServicePath {
+	var out []ServicePath
+
+	services := file.Services()
+	for i := range services.Len() {
+		service := services.Get(i)
+		methods := service.Methods()
+		for j := range methods.Len() {
+			method := methods.Get(j)
+			if path, ok := RequestPath(method); ok {
+				out = append(out, ServicePath{
+					Name:   pathName(service, method),
+					Method: string(method.FullName()),
+					Path:   path,
+				})
+			}
+		}
+	}
+	return out
+}
+
+// GeneratePaths renders the Go constants of the request paths of a file,
+// their RegisterPaths function, and the typed clients and server
+// interfaces of its services. The output isn't gofmt'ed.
+func (gen *Generator) GeneratePaths(out io.Writer, file PathsFile) error {
+	return gen.T("paths", out, file)
+}
+
+// GenerateHashes renders the Go constants of the path_hash of the request
+// paths of a file, and their RegisterPathHashes function. The output isn't
+// gofmt'ed.
+func (gen *Generator) GenerateHashes(out io.Writer, file PathsFile) error {
+	return gen.T("hashes", out, file)
+}
+
+// findBytesField returns the value of the last length-delimited field num
+// in the encoded message b, the one that prevails when decoding.
+func findBytesField(b []byte, num protowire.Number) ([]byte, bool) {
+	var value []byte
+	var found bool
+
+	for len(b) > 0 {
+		n, typ, l := protowire.ConsumeTag(b)
+		if l < 0 {
+			return nil, false
+		}
+		b = b[l:]
+
+		l = protowire.ConsumeFieldValue(n, typ, b)
+		if l < 0 {
+			return nil, false
+		}
+		if n == num && typ == protowire.BytesType {
+			value, _ = protowire.ConsumeBytes(b[:l])
+			found = true
+		}
+		b = b[l:]
+	}
+	return value, found
+}
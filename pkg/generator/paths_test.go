@@ -0,0 +1,147 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+	"testing"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// newMethodOptions encodes path as the (nanorpc).request_path option,
+// unknown to the descriptor as it would be to protoc plugins.
+func newMethodOptions(path string) *descriptorpb.MethodOptions {
+	var opt []byte
+	opt = protowire.AppendTag(opt, requestPathField, protowire.BytesType)
+	opt = protowire.AppendString(opt, path)
+
+	var raw []byte
+	raw = protowire.AppendTag(raw, 1, protowire.VarintType) // deprecated
+	raw = protowire.AppendVarint(raw, 0)
+	raw = protowire.AppendTag(raw, RequestPathOption, protowire.BytesType)
+	raw = protowire.AppendBytes(raw, opt)
+
+	opts := &descriptorpb.MethodOptions{}
+	opts.ProtoReflect().SetUnknown(raw)
+	return opts
+}
+
+func newTestMethod(name, path string) *descriptorpb.MethodDescriptorProto {
+	m := &descriptorpb.MethodDescriptorProto{
+		Name:       proto.String(name),
+		InputType:  proto.String(".sensors.Empty"),
+		OutputType: proto.String(".sensors.Empty"),
+	}
+	if path != "" {
+		m.Options = newMethodOptions(path)
+	}
+	return m
+}
+
+func newTestFile(t *testing.T) protoreflect.FileDescriptor {
+	t.Helper()
+
+	return newTestFileWith(t,
+		newTestMethod("GetTemperature", "/sensors/temperature"),
+		newTestMethod("Reboot", ""),
+		newTestMethod("GetHumidity", "/sensors/humidity"),
+	)
+}
+
+// newTestFileWith builds sensors.proto with a SensorService of the given
+// methods.
+func newTestFileWith(t *testing.T, methods ...*descriptorpb.MethodDescriptorProto) protoreflect.FileDescriptor {
+	t.Helper()
+
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("sensors.proto"),
+		Package: proto.String("sensors"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("Empty")},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{
+			{
+				Name:   proto.String("SensorService"),
+				Method: methods,
+			},
+		},
+	}, nil)
+	core.AssertMustNoError(t, err, "NewFile")
+	return fd
+}
+
+func TestServicePaths(t *testing.T) {
+	paths := ServicePaths(newTestFile(t))
+
+	core.AssertSliceEqual(t, []ServicePath{
+		{
+			Name:   "SensorService_GetTemperature_Path",
+			Method: "sensors.SensorService.GetTemperature",
+			Path:   "/sensors/temperature",
+		},
+		{
+			Name:   "SensorService_GetHumidity_Path",
+			Method: "sensors.SensorService.GetHumidity",
+			Path:   "/sensors/humidity",
+		},
+	}, paths, "paths")
+}
+
+func TestGenerator_GeneratePaths(t *testing.T) {
+	gen := &Generator{}
+	core.AssertMustNoError(t, gen.WithTemplates(nil, Templates), "WithTemplates")
+
+	var buf bytes.Buffer
+	err := gen.GeneratePaths(&buf, PathsFile{
+		Source:    "sensors.proto",
+		GoPackage: "sensors",
+		Paths:     ServicePaths(newTestFile(t)),
+	})
+	core.AssertMustNoError(t, err, "GeneratePaths")
+
+	src, err := format.Source(buf.Bytes())
+	core.AssertMustNoError(t, err, "gofmt")
+
+	for _, want := range []string{
+		"package sensors\n",
+		`SensorService_GetTemperature_Path = "/sensors/temperature"`,
+		"func RegisterPaths(hc *nanorpc.HashCache) error {",
+		"\t\tSensorService_GetHumidity_Path,\n",
+	} {
+		core.AssertTrue(t, strings.Contains(string(src), want), "generated %q", want)
+	}
+}
+
+func TestGenerator_GenerateHashes(t *testing.T) {
+	gen := &Generator{}
+	core.AssertMustNoError(t, gen.WithTemplates(nil, Templates), "WithTemplates")
+
+	var buf bytes.Buffer
+	err := gen.GenerateHashes(&buf, PathsFile{
+		Source:    "sensors.proto",
+		GoPackage: "sensors",
+		Paths:     ServicePaths(newTestFile(t)),
+	})
+	core.AssertMustNoError(t, err, "GenerateHashes")
+
+	src, err := format.Source(buf.Bytes())
+	core.AssertMustNoError(t, err, "gofmt")
+
+	hash := fmt.Sprintf("0x%08x", PathHash("/sensors/temperature"))
+	for _, want := range []string{
+		"package sensors\n",
+		"SensorService_GetTemperature_PathHash uint32 = " + hash,
+		"func RegisterPathHashes(hc *nanorpc.HashCache) error {",
+		"\t\t{SensorService_GetHumidity_Path, SensorService_GetHumidity_PathHash},\n",
+	} {
+		core.AssertTrue(t, strings.Contains(string(src), want), "generated %q", want)
+	}
+}
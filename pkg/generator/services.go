@@ -0,0 +1,79 @@
+package generator
+
+import (
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Service is a protobuf service whose unary methods declare NanoRPC
+// request paths, rendered as a typed client and a server interface.
+type Service struct {
+	// Name is the name of the service, prefixing the generated types.
+	Name string
+	// Methods are the unary methods declaring a request path.
+	Methods []ServiceMethod
+}
+
+// ServiceMethod is a unary service method mapped to a request path.
+type ServiceMethod struct {
+	// Name is the name of the method.
+	Name string
+	// Method is the full name of the method.
+	Method string
+	// PathName is the Go constant holding the path, see [ServicePath].
+	PathName string
+	// Input is the Go type of the request message.
+	Input string
+	// Output is the Go type of the response message.
+	Output string
+}
+
+// GoTypeFunc returns the name of the Go type of a message as referred to
+// from the generated file, qualified when it's in another package.
+type GoTypeFunc func(protoreflect.MessageDescriptor) string
+
+// Services returns the services of a file with unary methods declaring
+// request paths, in declaration order. Streaming methods only get their
+// path constants.
+func Services(file protoreflect.FileDescriptor, goType GoTypeFunc) []Service {
+	var out []Service
+
+	services := file.Services()
+	for i := range services.Len() {
+		service := services.Get(i)
+		if methods := serviceMethods(service, goType); len(methods) > 0 {
+			out = append(out, Service{
+				Name:    string(service.Name()),
+				Methods: methods,
+			})
+		}
+	}
+	return out
+}
+
+func serviceMethods(service protoreflect.ServiceDescriptor, goType GoTypeFunc) []ServiceMethod {
+	var out []ServiceMethod
+
+	methods := service.Methods()
+	for i := range methods.Len() {
+		method := methods.Get(i)
+		if method.IsStreamingClient() || method.IsStreamingServer() {
+			continue
+		}
+
+		if _, ok := RequestPath(method); ok {
+			out = append(out, ServiceMethod{
+				Name:     string(method.Name()),
+				Method:   string(method.FullName()),
+				PathName: pathName(service, method),
+				Input:    goType(method.Input()),
+				Output:   goType(method.Output()),
+			})
+		}
+	}
+	return out
+}
+
+// pathName returns the name of the Go constant of the path of a method.
+func pathName(service protoreflect.ServiceDescriptor, method protoreflect.MethodDescriptor) string {
+	return string(service.Name()) + "_" + string(method.Name()) + "_Path"
+}
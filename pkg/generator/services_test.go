@@ -0,0 +1,84 @@
+package generator
+
+import (
+	"bytes"
+	"go/format"
+	"strings"
+	"testing"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// testGoType names messages by their proto name.
+func testGoType(msg protoreflect.MessageDescriptor) string {
+	return string(msg.Name())
+}
+
+func TestServices(t *testing.T) {
+	streaming := newTestMethod("WatchTemperature", "/sensors/temperature/watch")
+	streaming.ServerStreaming = proto.Bool(true)
+
+	file := newTestFileWith(t,
+		newTestMethod("GetTemperature", "/sensors/temperature"),
+		newTestMethod("Reboot", ""),
+		streaming,
+	)
+
+	services := Services(file, testGoType)
+	if !core.AssertEqual(t, 1, len(services), "services") {
+		return
+	}
+	core.AssertEqual(t, "SensorService", services[0].Name, "name")
+	core.AssertSliceEqual(t, []ServiceMethod{
+		{
+			Name:     "GetTemperature",
+			Method:   "sensors.SensorService.GetTemperature",
+			PathName: "SensorService_GetTemperature_Path",
+			Input:    "Empty",
+			Output:   "Empty",
+		},
+	}, services[0].Methods, "methods")
+
+	core.AssertEqual(t, 2, len(ServicePaths(file)), "paths")
+}
+
+func TestServices_none(t *testing.T) {
+	file := newTestFileWith(t, newTestMethod("Reboot", ""))
+	core.AssertEqual(t, 0, len(Services(file, testGoType)), "services")
+}
+
+func TestGenerator_GeneratePaths_services(t *testing.T) {
+	gen := &Generator{}
+	core.AssertMustNoError(t, gen.WithTemplates(nil, Templates), "WithTemplates")
+
+	file := newTestFile(t)
+
+	var buf bytes.Buffer
+	err := gen.GeneratePaths(&buf, PathsFile{
+		Source:    "sensors.proto",
+		GoPackage: "sensors",
+		Paths:     ServicePaths(file),
+		Services:  Services(file, testGoType),
+	})
+	core.AssertMustNoError(t, err, "GeneratePaths")
+
+	src, err := format.Source(buf.Bytes())
+	core.AssertMustNoError(t, err, "gofmt")
+
+	for _, want := range []string{
+		"\t\"protomcp.org/nanorpc/pkg/nanorpc/client\"\n",
+		"func NewSensorServiceClient(c client.Requester) *SensorServiceClient {",
+		"func (x *SensorServiceClient) GetHumidity(ctx context.Context, req *Empty) (*Empty, error) {",
+		"client.GetResponse(ctx, x.c, SensorService_GetTemperature_Path, req, out)",
+		"\tGetTemperature(context.Context, *Empty) (*Empty, error)\n",
+		"func RegisterSensorServiceServer(r server.HandlerRegistry, srv SensorServiceServer) error {",
+		"r.RegisterHandlerFunc(SensorService_GetHumidity_Path,",
+		"srv.GetHumidity(ctx, req)",
+		"server.DescribeTypes(r, SensorService_GetHumidity_Path, new(Empty), new(Empty))",
+	} {
+		core.AssertTrue(t, strings.Contains(string(src), want), "generated %q", want)
+	}
+	core.AssertFalse(t, strings.Contains(string(src), "Reboot"), "method without path")
+}
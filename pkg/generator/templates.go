@@ -1,6 +1,7 @@
 package generator
 
 import (
+	"embed"
 	"errors"
 	"fmt"
 	"io"
@@ -8,6 +9,12 @@ import (
 	"text/template"
 )
 
+// Templates are the templates used by the NanoRPC generators, to be
+// loaded with [Generator.WithTemplates].
+//
+//go:embed templates
+var Templates embed.FS
+
 // WithTemplates loads embedded templates/**.gotmpl into
 // an existing [template.Template]
 func (gen *Generator) WithTemplates(root *template.Template, templates fs.FS) error {
@@ -0,0 +1,107 @@
+package generator
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+// PathHash returns the FNV-1a hash of a request path, as sent in the
+// path_hash of NanoRPC requests.
+func PathHash(path string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(path))
+	return h.Sum32()
+}
+
+// PathConflict describes two methods whose requests can't be told apart
+// at runtime, as they declare the same path or paths with the same hash.
+type PathConflict struct {
+	// First is the path declared first.
+	First ServicePath
+	// Second is the conflicting path declared later.
+	Second ServicePath
+	// Hash is the hash shared by both paths.
+	Hash uint32
+}
+
+// IsCollision tells if the conflict is between different paths whose
+// hashes collide, rather than the same path declared twice.
+func (c PathConflict) IsCollision() bool {
+	return c.First.Path != c.Second.Path
+}
+
+func (c PathConflict) String() string {
+	if c.First.Path == c.Second.Path {
+		return fmt.Sprintf("path %q declared by both %s and %s",
+			c.First.Path, c.First.Method, c.Second.Method)
+	}
+	return fmt.Sprintf("paths %q of %s and %q of %s both hash to 0x%08x",
+		c.First.Path, c.First.Method, c.Second.Path, c.Second.Method, c.Hash)
+}
+
+// PathConflictError reports the conflicts found by a [PathChecker].
+type PathConflictError struct {
+	Conflicts []PathConflict
+}
+
+func (e *PathConflictError) Error() string {
+	var buf strings.Builder
+
+	_, _ = fmt.Fprintf(&buf, "%d request path conflict(s):", len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		_, _ = buf.WriteString("\n\t")
+		_, _ = buf.WriteString(c.String())
+	}
+	return buf.String()
+}
+
+// PathChecker accumulates the request paths declared across the files of
+// a compilation unit, finding those declared by more than one method and
+// those whose hashes collide.
+type PathChecker struct {
+	byHash    map[uint32]ServicePath
+	conflicts []PathConflict
+}
+
+// Add accounts the paths declared by a file.
+func (pc *PathChecker) Add(paths ...ServicePath) {
+	if pc.byHash == nil {
+		pc.byHash = make(map[uint32]ServicePath)
+	}
+
+	for _, p := range paths {
+		hash := PathHash(p.Path)
+		if first, ok := pc.byHash[hash]; ok {
+			pc.conflicts = append(pc.conflicts, PathConflict{First: first, Second: p, Hash: hash})
+			continue
+		}
+		pc.byHash[hash] = p
+	}
+}
+
+// Err returns a [*PathConflictError] listing the conflicts found, or nil
+// if none.
+func (pc *PathChecker) Err() error {
+	if len(pc.conflicts) == 0 {
+		return nil
+	}
+	return &PathConflictError{Conflicts: pc.conflicts}
+}
+
+// CollisionErr returns a [*PathConflictError] listing the hash collisions
+// found between different paths, which requests addressed by path_hash
+// can't tell apart, or nil if none. Paths declared twice aren't included.
+func (pc *PathChecker) CollisionErr() error {
+	var collisions []PathConflict
+	for _, c := range pc.conflicts {
+		if c.IsCollision() {
+			collisions = append(collisions, c)
+		}
+	}
+
+	if len(collisions) == 0 {
+		return nil
+	}
+	return &PathConflictError{Conflicts: collisions}
+}
@@ -0,0 +1,120 @@
+package generator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"darvaza.org/core"
+)
+
+// colliding paths, both hashing to 0xcdc6c35b
+const (
+	pathCollisionA = "/sensors/671089"
+	pathCollisionB = "/sensors/1032304"
+)
+
+// Compile-time verification that test case types implement TestCase interface
+var _ core.TestCase = pathCheckerTestCase{}
+
+// pathCheckerTestCase represents a test case for PathChecker, adding
+// each file's paths in turn
+type pathCheckerTestCase struct {
+	name  string
+	files [][]ServicePath
+	want  []PathConflict
+}
+
+func (tc pathCheckerTestCase) Name() string {
+	return tc.name
+}
+
+func (tc pathCheckerTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	var pc PathChecker
+	for _, paths := range tc.files {
+		pc.Add(paths...)
+	}
+
+	err := pc.Err()
+	if len(tc.want) == 0 {
+		core.AssertNoError(t, err, "Err")
+		return
+	}
+
+	var pce *PathConflictError
+	core.AssertMustTrue(t, errors.As(err, &pce), "PathConflictError")
+	core.AssertSliceEqual(t, tc.want, pce.Conflicts, "conflicts")
+	for _, c := range tc.want {
+		core.AssertTrue(t, strings.Contains(err.Error(), c.String()), "report %q", c)
+	}
+}
+
+func newPathCheckerTestCase(name string, want []PathConflict, files ...[]ServicePath) pathCheckerTestCase {
+	return pathCheckerTestCase{
+		name:  name,
+		files: files,
+		want:  want,
+	}
+}
+
+func newServicePath(method, path string) ServicePath {
+	return ServicePath{Name: strings.ReplaceAll(method, ".", "_") + "_Path", Method: method, Path: path}
+}
+
+func pathCheckerTestCases() []pathCheckerTestCase {
+	temp := newServicePath("sensors.SensorService.GetTemperature", "/sensors/temperature")
+	hum := newServicePath("sensors.SensorService.GetHumidity", "/sensors/humidity")
+	dup := newServicePath("climate.ClimateService.GetTemperature", "/sensors/temperature")
+	collA := newServicePath("sensors.SensorService.GetA", pathCollisionA)
+	collB := newServicePath("climate.ClimateService.GetB", pathCollisionB)
+
+	return []pathCheckerTestCase{
+		newPathCheckerTestCase("empty", nil),
+		newPathCheckerTestCase("unique", nil, []ServicePath{temp, hum}, []ServicePath{collA}),
+		newPathCheckerTestCase("duplicate_in_file", []PathConflict{
+			{First: temp, Second: dup, Hash: PathHash(temp.Path)},
+		}, []ServicePath{temp, hum, dup}),
+		newPathCheckerTestCase("duplicate_across_files", []PathConflict{
+			{First: temp, Second: dup, Hash: PathHash(temp.Path)},
+		}, []ServicePath{temp, hum}, []ServicePath{dup}),
+		newPathCheckerTestCase("hash_collision", []PathConflict{
+			{First: collA, Second: collB, Hash: 0xcdc6c35b},
+		}, []ServicePath{collA}, []ServicePath{collB}),
+		newPathCheckerTestCase("all_conflicts", []PathConflict{
+			{First: temp, Second: dup, Hash: PathHash(temp.Path)},
+			{First: collA, Second: collB, Hash: 0xcdc6c35b},
+		}, []ServicePath{temp, collA}, []ServicePath{dup, collB}),
+	}
+}
+
+func TestPathChecker(t *testing.T) {
+	core.RunTestCases(t, pathCheckerTestCases())
+}
+
+// TestPathChecker_CollisionErr verifies only hash collisions between
+// different paths are reported.
+func TestPathChecker_CollisionErr(t *testing.T) {
+	temp := newServicePath("sensors.SensorService.GetTemperature", "/sensors/temperature")
+	dup := newServicePath("climate.ClimateService.GetTemperature", "/sensors/temperature")
+	collA := newServicePath("sensors.SensorService.GetA", pathCollisionA)
+	collB := newServicePath("climate.ClimateService.GetB", pathCollisionB)
+
+	var pc PathChecker
+	pc.Add(temp, dup)
+	core.AssertError(t, pc.Err(), "Err")
+	core.AssertNoError(t, pc.CollisionErr(), "duplicate path")
+
+	pc.Add(collA, collB)
+	var pce *PathConflictError
+	core.AssertMustTrue(t, errors.As(pc.CollisionErr(), &pce), "PathConflictError")
+	core.AssertSliceEqual(t, []PathConflict{
+		{First: collA, Second: collB, Hash: 0xcdc6c35b},
+	}, pce.Conflicts, "collisions")
+}
+
+func TestPathHash(t *testing.T) {
+	core.AssertEqual(t, uint32(0x811c9dc5), PathHash(""), "offset basis")
+	core.AssertEqual(t, PathHash(pathCollisionA), PathHash(pathCollisionB), "collision")
+}
@@ -0,0 +1,266 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Messages of nanorpc.proto the dissector decodes frames as.
+const (
+	wiresharkRequest  = "NanoRPCRequest"
+	wiresharkResponse = "NanoRPCResponse"
+)
+
+// WiresharkFile is the data rendered by the wireshark template, a Lua
+// dissector of NanoRPC frames decoding the messages of nanorpc.proto
+// field by field, so it follows the proto as it grows.
+type WiresharkFile struct {
+	// Source is the name of the proto file the messages come from.
+	Source string
+	// Messages are the messages of Source, nested ones included.
+	Messages []WiresharkMessage
+	// Enums are the enums of Source, nested ones included.
+	Enums []WiresharkEnum
+	// Paths are the request paths known to resolve path hashes.
+	Paths []WiresharkPath
+}
+
+// WiresharkMessage is a message decoded by the dissector.
+type WiresharkMessage struct {
+	// Name is the full name of the message.
+	Name string
+	// Fields are the fields of the message, in declaration order.
+	Fields []WiresharkField
+}
+
+// WiresharkField is a field of a message, rendered as a Wireshark
+// ProtoField filtered as nanorpc.<message>.<field>.
+type WiresharkField struct {
+	// Name is the name of the field.
+	Name string
+	// Abbrev is the display filter name of the field.
+	Abbrev string
+	// Type is the ProtoField constructor, e.g. uint32.
+	Type string
+	// Kind is how the value is decoded, the proto kind of the field.
+	Kind string
+	// Base is the display base of integers, empty for other types.
+	Base string
+	// Enum is the full name of the enum of the field, if any.
+	Enum string
+	// Message is the full name of the message of the field, if any.
+	Message string
+	// Number is the field number.
+	Number int32
+	// Wire is the wire type the field is encoded with.
+	Wire int8
+}
+
+// WiresharkEnum is an enum whose names the dissector shows.
+type WiresharkEnum struct {
+	// Name is the full name of the enum.
+	Name string
+	// Values are the values of the enum.
+	Values []WiresharkEnumValue
+}
+
+// WiresharkEnumValue is a named value of an enum.
+type WiresharkEnumValue struct {
+	// Name is the name of the value.
+	Name string
+	// Number is the value.
+	Number int32
+}
+
+// WiresharkPath is a request path shown next to its path_hash.
+type WiresharkPath struct {
+	// Path is the request path, as a Lua string literal.
+	Path string
+	// Hash is the FNV-1a hash of the request path.
+	Hash uint32
+}
+
+// wiresharkKind tells how a field of a proto kind is rendered and decoded.
+type wiresharkKind struct {
+	typ  string
+	wire protowire.Type
+}
+
+var wiresharkKinds = map[protoreflect.Kind]wiresharkKind{
+	protoreflect.BoolKind:     {"bool", protowire.VarintType},
+	protoreflect.EnumKind:     {"int32", protowire.VarintType},
+	protoreflect.Int32Kind:    {"int32", protowire.VarintType},
+	protoreflect.Sint32Kind:   {"int32", protowire.VarintType},
+	protoreflect.Uint32Kind:   {"uint32", protowire.VarintType},
+	protoreflect.Int64Kind:    {"int64", protowire.VarintType},
+	protoreflect.Sint64Kind:   {"int64", protowire.VarintType},
+	protoreflect.Uint64Kind:   {"uint64", protowire.VarintType},
+	protoreflect.Sfixed32Kind: {"int32", protowire.Fixed32Type},
+	protoreflect.Fixed32Kind:  {"uint32", protowire.Fixed32Type},
+	protoreflect.FloatKind:    {"float", protowire.Fixed32Type},
+	protoreflect.Sfixed64Kind: {"int64", protowire.Fixed64Type},
+	protoreflect.Fixed64Kind:  {"uint64", protowire.Fixed64Type},
+	protoreflect.DoubleKind:   {"double", protowire.Fixed64Type},
+	protoreflect.StringKind:   {"string", protowire.BytesType},
+	protoreflect.BytesKind:    {"bytes", protowire.BytesType},
+	protoreflect.MessageKind:  {"bytes", protowire.BytesType},
+}
+
+// NewWiresharkFile returns the dissector of the frames of file, the
+// nanorpc.proto of the compilation unit, resolving the hashes of paths.
+// It fails if file doesn't declare the request and response messages.
+func NewWiresharkFile(file protoreflect.FileDescriptor, paths []ServicePath) (WiresharkFile, error) {
+	msgs := file.Messages()
+	if msgs.ByName(wiresharkRequest) == nil || msgs.ByName(wiresharkResponse) == nil {
+		return WiresharkFile{}, fmt.Errorf("%s: %s or %s not found",
+			file.Path(), wiresharkRequest, wiresharkResponse)
+	}
+
+	out := WiresharkFile{
+		Source: file.Path(),
+		Paths:  wiresharkPaths(paths),
+	}
+	for i := range file.Enums().Len() {
+		out.Enums = append(out.Enums, newWiresharkEnum(file.Enums().Get(i)))
+	}
+	for i := range msgs.Len() {
+		if err := out.addMessage(msgs.Get(i)); err != nil {
+			return WiresharkFile{}, err
+		}
+	}
+	return out, nil
+}
+
+// addMessage adds a message, its nested messages and its enums.
+func (wf *WiresharkFile) addMessage(msg protoreflect.MessageDescriptor) error {
+	m := WiresharkMessage{Name: string(msg.FullName())}
+
+	fields := msg.Fields()
+	for i := range fields.Len() {
+		f, err := newWiresharkField(msg, fields.Get(i))
+		if err != nil {
+			return err
+		}
+		m.Fields = append(m.Fields, f)
+	}
+	wf.Messages = append(wf.Messages, m)
+
+	for i := range msg.Enums().Len() {
+		wf.Enums = append(wf.Enums, newWiresharkEnum(msg.Enums().Get(i)))
+	}
+	for i := range msg.Messages().Len() {
+		if err := wf.addMessage(msg.Messages().Get(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newWiresharkField(msg protoreflect.MessageDescriptor, field protoreflect.FieldDescriptor) (WiresharkField, error) {
+	kind, ok := wiresharkKinds[field.Kind()]
+	if !ok {
+		return WiresharkField{}, fmt.Errorf("%s: %s fields not supported", field.FullName(), field.Kind())
+	}
+
+	out := WiresharkField{
+		Name:   string(field.Name()),
+		Abbrev: "nanorpc." + wiresharkAbbrev(msg) + "." + string(field.Name()),
+		Type:   kind.typ,
+		Kind:   field.Kind().String(),
+		Number: int32(field.Number()),
+		Wire:   int8(kind.wire),
+	}
+	switch {
+	case field.Enum() != nil:
+		out.Base = "base.DEC"
+		out.Enum = string(field.Enum().FullName())
+	case field.Message() != nil:
+		out.Message = string(field.Message().FullName())
+	case strings.HasSuffix(out.Name, "_hash"):
+		out.Base = "base.HEX"
+	case strings.HasPrefix(kind.typ, "int") || strings.HasPrefix(kind.typ, "uint"):
+		out.Base = "base.DEC"
+	}
+	return out, nil
+}
+
+func newWiresharkEnum(enum protoreflect.EnumDescriptor) WiresharkEnum {
+	out := WiresharkEnum{Name: string(enum.FullName())}
+
+	values := enum.Values()
+	for i := range values.Len() {
+		v := values.Get(i)
+		out.Values = append(out.Values, WiresharkEnumValue{
+			Name:   string(v.Name()),
+			Number: int32(v.Number()),
+		})
+	}
+	return out
+}
+
+// wiresharkAbbrev returns the display filter name of a message, its name
+// in lower case without the NanoRPC prefix, e.g. request for
+// NanoRPCRequest, with nested messages joined by underscores.
+func wiresharkAbbrev(msg protoreflect.MessageDescriptor) string {
+	name := strings.TrimPrefix(string(msg.FullName()), string(msg.ParentFile().Package())+".")
+	name = strings.TrimPrefix(name, "NanoRPC")
+	return strings.ToLower(strings.ReplaceAll(name, ".", "_"))
+}
+
+// wiresharkPaths returns the hashes of paths, sorted by path.
+func wiresharkPaths(paths []ServicePath) []WiresharkPath {
+	sorted := make([]ServicePath, len(paths))
+	copy(sorted, paths)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Path < sorted[j].Path })
+
+	out := make([]WiresharkPath, 0, len(sorted))
+	for _, p := range sorted {
+		out = append(out, WiresharkPath{Path: luaString(p.Path), Hash: p.Hash()})
+	}
+	return out
+}
+
+// FindWiresharkSource returns the file declaring the NanoRPC request and
+// response messages among files, usually the imported nanorpc.proto.
+func FindWiresharkSource(files []protoreflect.FileDescriptor) (protoreflect.FileDescriptor, error) {
+	for _, file := range files {
+		if file.Package() != "" {
+			continue
+		}
+		if file.Messages().ByName(wiresharkRequest) != nil {
+			return file, nil
+		}
+	}
+	return nil, errors.New("nanorpc.proto not found in the compilation unit")
+}
+
+// GenerateWireshark renders the Lua dissector of the NanoRPC frames.
+func (gen *Generator) GenerateWireshark(out io.Writer, file WiresharkFile) error {
+	return gen.T("wireshark", out, file)
+}
+
+// luaString returns s as a Lua string literal, escaping what isn't
+// printable ASCII in decimal.
+func luaString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c > 0x7e:
+			_, _ = fmt.Fprintf(&b, "\\%03d", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
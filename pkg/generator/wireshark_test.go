@@ -0,0 +1,166 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func newTestField(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type,
+	typeName string) *descriptorpb.FieldDescriptorProto {
+	f := &descriptorpb.FieldDescriptorProto{
+		Name:   proto.String(name),
+		Number: proto.Int32(number),
+		Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:   typ.Enum(),
+	}
+	if typeName != "" {
+		f.TypeName = proto.String(typeName)
+	}
+	return f
+}
+
+// newTestFrameFile builds a reduced nanorpc.proto, with the request and
+// response messages.
+func newTestFrameFile(t *testing.T) protoreflect.FileDescriptor {
+	t.Helper()
+
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:   proto.String("nanorpc.proto"),
+		Syntax: proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("NanoRPCRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					newTestField("request_id", 1, descriptorpb.FieldDescriptorProto_TYPE_INT32, ""),
+					newTestField("request_type", 2, descriptorpb.FieldDescriptorProto_TYPE_ENUM,
+						".NanoRPCRequest.Type"),
+					newTestField("path_hash", 3, descriptorpb.FieldDescriptorProto_TYPE_UINT32, ""),
+					newTestField("data", 10, descriptorpb.FieldDescriptorProto_TYPE_BYTES, ""),
+				},
+				EnumType: []*descriptorpb.EnumDescriptorProto{
+					{
+						Name: proto.String("Type"),
+						Value: []*descriptorpb.EnumValueDescriptorProto{
+							{Name: proto.String("TYPE_UNSPECIFIED"), Number: proto.Int32(0)},
+							{Name: proto.String("TYPE_PING"), Number: proto.Int32(1)},
+						},
+					},
+				},
+			},
+			{
+				Name: proto.String("NanoRPCResponse"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					newTestField("timestamps", 5, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE,
+						".NanoRPCTimestamps"),
+					newTestField("sequence", 6, descriptorpb.FieldDescriptorProto_TYPE_UINT64, ""),
+					newTestField("path", 9, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				},
+			},
+			{
+				Name: proto.String("NanoRPCTimestamps"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					newTestField("received_us", 1, descriptorpb.FieldDescriptorProto_TYPE_UINT64, ""),
+				},
+			},
+		},
+	}, nil)
+	core.AssertMustNoError(t, err, "NewFile")
+	return fd
+}
+
+func TestNewWiresharkFile(t *testing.T) {
+	paths := ServicePaths(newTestFile(t))
+	wf, err := NewWiresharkFile(newTestFrameFile(t), paths)
+	core.AssertMustNoError(t, err, "NewWiresharkFile")
+
+	core.AssertEqual(t, "nanorpc.proto", wf.Source, "source")
+	core.AssertMustEqual(t, 3, len(wf.Messages), "messages")
+	core.AssertSliceEqual(t, []WiresharkField{
+		{
+			Name: "request_id", Abbrev: "nanorpc.request.request_id",
+			Type: "int32", Kind: "int32", Base: "base.DEC", Number: 1,
+		},
+		{
+			Name: "request_type", Abbrev: "nanorpc.request.request_type",
+			Type: "int32", Kind: "enum", Base: "base.DEC", Enum: "NanoRPCRequest.Type", Number: 2,
+		},
+		{
+			Name: "path_hash", Abbrev: "nanorpc.request.path_hash",
+			Type: "uint32", Kind: "uint32", Base: "base.HEX", Number: 3,
+		},
+		{
+			Name: "data", Abbrev: "nanorpc.request.data",
+			Type: "bytes", Kind: "bytes", Number: 10, Wire: 2,
+		},
+	}, wf.Messages[0].Fields, "request fields")
+
+	timestamps := wf.Messages[1].Fields[0]
+	core.AssertEqual(t, "NanoRPCTimestamps", timestamps.Message, "message")
+	core.AssertEqual(t, int8(2), timestamps.Wire, "message wire")
+
+	core.AssertMustEqual(t, 1, len(wf.Enums), "enums")
+	core.AssertEqual(t, "NanoRPCRequest.Type", wf.Enums[0].Name, "enum")
+	core.AssertSliceEqual(t, []WiresharkEnumValue{
+		{Name: "TYPE_UNSPECIFIED", Number: 0},
+		{Name: "TYPE_PING", Number: 1},
+	}, wf.Enums[0].Values, "enum values")
+
+	core.AssertSliceEqual(t, []WiresharkPath{
+		{Path: `"/sensors/humidity"`, Hash: PathHash("/sensors/humidity")},
+		{Path: `"/sensors/temperature"`, Hash: PathHash("/sensors/temperature")},
+	}, wf.Paths, "paths sorted")
+}
+
+func TestNewWiresharkFile_notFrames(t *testing.T) {
+	_, err := NewWiresharkFile(newTestFile(t), nil)
+	core.AssertError(t, err, "sensors.proto")
+
+	_, err = FindWiresharkSource([]protoreflect.FileDescriptor{newTestFile(t)})
+	core.AssertError(t, err, "not found")
+
+	frames := newTestFrameFile(t)
+	file, err := FindWiresharkSource([]protoreflect.FileDescriptor{newTestFile(t), frames})
+	core.AssertNoError(t, err, "found")
+	core.AssertEqual(t, frames.Path(), file.Path(), "source")
+}
+
+func TestLuaString(t *testing.T) {
+	core.AssertEqual(t, `"/a/b"`, luaString("/a/b"), "plain")
+	core.AssertEqual(t, `"/\"q\"\\"`, luaString(`/"q"\`), "quotes")
+	core.AssertEqual(t, `"/caf\195\169\010"`, luaString("/café\n"), "escaped")
+}
+
+func TestGenerator_GenerateWireshark(t *testing.T) {
+	gen := &Generator{}
+	core.AssertMustNoError(t, gen.WithTemplates(nil, Templates), "WithTemplates")
+
+	wf, err := NewWiresharkFile(newTestFrameFile(t), ServicePaths(newTestFile(t)))
+	core.AssertMustNoError(t, err, "NewWiresharkFile")
+
+	var buf bytes.Buffer
+	core.AssertMustNoError(t, gen.GenerateWireshark(&buf, wf), "GenerateWireshark")
+
+	src := buf.String()
+	for _, want := range []string{
+		"-- source: nanorpc.proto\n",
+		"local nanorpc = Proto(\"nanorpc\", \"NanoRPC\")\n",
+		"    [\"NanoRPCRequest.Type\"] = {\n        [0] = \"TYPE_UNSPECIFIED\",\n        [1] = \"TYPE_PING\",\n",
+		"field = ProtoField.int32(\"nanorpc.request.request_type\", \"request_type\", " +
+			"base.DEC, enums[\"NanoRPCRequest.Type\"]),\n",
+		"field = ProtoField.uint32(\"nanorpc.request.path_hash\", \"path_hash\", base.HEX),\n",
+		"field = ProtoField.bytes(\"nanorpc.request.data\", \"data\"),\n",
+		"message = \"NanoRPCTimestamps\",\n",
+		fmt.Sprintf("[0x%08x] = \"/sensors/temperature\",\n", PathHash("/sensors/temperature")),
+		"function nanorpc.dissector(tvb, pinfo, tree)\n",
+	} {
+		core.AssertTrue(t, strings.Contains(src, want), "generated %q", want)
+	}
+}
@@ -0,0 +1,27 @@
+package nanorpc
+
+import "protomcp.org/nanorpc/pkg/nanorpc/cbor"
+
+// DecodeResponseCBOR attempts to decode the CBOR payload of a NanoRPC
+// response into out, reporting whether there was one.
+func DecodeResponseCBOR(res *NanoRPCResponse, out any) (bool, error) {
+	err := ResponseAsError(res)
+	switch {
+	case err != nil:
+		return false, err
+	case len(res.Data) == 0:
+		return false, nil
+	default:
+		return true, cbor.Unmarshal(res.Data, out)
+	}
+}
+
+// DecodeRequestCBOR attempts to decode the CBOR payload of a NanoRPC
+// request into out, reporting whether there was one.
+func DecodeRequestCBOR(req *NanoRPCRequest, out any) (bool, error) {
+	if req != nil && len(req.Data) > 0 {
+		return true, cbor.Unmarshal(req.Data, out)
+	}
+
+	return false, nil
+}
@@ -0,0 +1,197 @@
+package cbor
+
+import (
+	"reflect"
+
+	"darvaza.org/core"
+)
+
+// assign stores a decoded item in v.
+func assign(v reflect.Value, x any) error {
+	if x == nil {
+		v.SetZero()
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		return assignInterface(v, x)
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return assign(v.Elem(), x)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return assignInt(v, x)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return assignUint(v, x)
+	case reflect.Float32, reflect.Float64:
+		return assignFloat(v, x)
+	case reflect.Slice, reflect.Array:
+		return assignArray(v, x)
+	case reflect.Map:
+		return assignMap(v, x)
+	case reflect.Struct:
+		return assignStruct(v, x)
+	default:
+		return assignValue(v, x)
+	}
+}
+
+func mismatch(v reflect.Value, x any) error {
+	return core.QuietWrap(ErrTypeMismatch, "cbor: can't store %T in %s", x, v.Type())
+}
+
+// assignValue stores items whose generic type is assignable to v, such
+// as bool and string.
+func assignValue(v reflect.Value, x any) error {
+	xv := reflect.ValueOf(x)
+	if !xv.Type().AssignableTo(v.Type()) {
+		if !xv.Type().ConvertibleTo(v.Type()) || xv.Kind() != v.Kind() {
+			return mismatch(v, x)
+		}
+		xv = xv.Convert(v.Type())
+	}
+	v.Set(xv)
+	return nil
+}
+
+func assignInterface(v reflect.Value, x any) error {
+	xv := reflect.ValueOf(x)
+	if !xv.Type().AssignableTo(v.Type()) {
+		return mismatch(v, x)
+	}
+	v.Set(xv)
+	return nil
+}
+
+func assignInt(v reflect.Value, x any) error {
+	var n int64
+	switch i := x.(type) {
+	case int64:
+		n = i
+	case uint64:
+		if i > uint64(1<<63-1) {
+			return mismatch(v, x)
+		}
+		n = int64(i)
+	default:
+		return mismatch(v, x)
+	}
+
+	if v.OverflowInt(n) {
+		return mismatch(v, x)
+	}
+	v.SetInt(n)
+	return nil
+}
+
+func assignUint(v reflect.Value, x any) error {
+	n, ok := x.(uint64)
+	if !ok || v.OverflowUint(n) {
+		return mismatch(v, x)
+	}
+	v.SetUint(n)
+	return nil
+}
+
+func assignFloat(v reflect.Value, x any) error {
+	var f float64
+	switch n := x.(type) {
+	case float64:
+		f = n
+	case uint64:
+		f = float64(n)
+	case int64:
+		f = float64(n)
+	default:
+		return mismatch(v, x)
+	}
+	v.SetFloat(f)
+	return nil
+}
+
+// assignArray stores byte strings and arrays in slices and arrays.
+func assignArray(v reflect.Value, x any) error {
+	var n int
+	switch a := x.(type) {
+	case []byte:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return mismatch(v, x)
+		}
+		n = len(a)
+	case []any:
+		n = len(a)
+	default:
+		return mismatch(v, x)
+	}
+
+	switch {
+	case v.Kind() == reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), n, n))
+	case n > v.Len():
+		return mismatch(v, x)
+	default:
+		v.SetZero()
+	}
+	return assignItems(v, x)
+}
+
+// assignItems stores every byte or item in the element of v of the same
+// index.
+func assignItems(v reflect.Value, x any) error {
+	if b, ok := x.([]byte); ok {
+		for i, c := range b {
+			v.Index(i).SetUint(uint64(c))
+		}
+		return nil
+	}
+
+	for i, item := range x.([]any) {
+		if err := assign(v.Index(i), item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func assignMap(v reflect.Value, x any) error {
+	xv := reflect.ValueOf(x)
+	if xv.Kind() != reflect.Map {
+		return mismatch(v, x)
+	}
+
+	t := v.Type()
+	m := reflect.MakeMapWithSize(t, xv.Len())
+	iter := xv.MapRange()
+	for iter.Next() {
+		key, value := reflect.New(t.Key()).Elem(), reflect.New(t.Elem()).Elem()
+		if err := assign(key, iter.Key().Interface()); err != nil {
+			return err
+		}
+		if err := assign(value, iter.Value().Interface()); err != nil {
+			return err
+		}
+		m.SetMapIndex(key, value)
+	}
+	v.Set(m)
+	return nil
+}
+
+// assignStruct stores the entries of a text-keyed map in the fields
+// named by them.
+func assignStruct(v reflect.Value, x any) error {
+	m, ok := x.(map[string]any)
+	if !ok {
+		return mismatch(v, x)
+	}
+
+	for _, f := range structFields(v.Type()) {
+		if item, ok := m[f.name]; ok {
+			if err := assign(v.Field(f.index), item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package cbor
+
+import (
+	"encoding/hex"
+	"errors"
+	"math"
+	"testing"
+
+	"darvaza.org/core"
+)
+
+var _ core.TestCase = marshalTestCase{}
+
+// marshalTestCase verifies Go values encode as the examples of RFC 8949
+// Appendix A.
+type marshalTestCase struct {
+	value any
+	name  string
+	hex   string
+}
+
+func (tc marshalTestCase) Name() string { return tc.name }
+
+func (tc marshalTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	data, err := Marshal(tc.value)
+	core.AssertMustNoError(t, err, "Marshal")
+	core.AssertEqual(t, tc.hex, hex.EncodeToString(data), "encoding")
+}
+
+func newMarshalTestCase(name string, value any, hexData string) marshalTestCase {
+	return marshalTestCase{name: name, value: value, hex: hexData}
+}
+
+func marshalTestCases() []marshalTestCase {
+	return []marshalTestCase{
+		newMarshalTestCase("zero", 0, "00"),
+		newMarshalTestCase("uint8 argument", uint8(25), "1819"),
+		newMarshalTestCase("uint16 argument", 1000, "1903e8"),
+		newMarshalTestCase("uint32 argument", 1000000, "1a000f4240"),
+		newMarshalTestCase("uint64 argument", uint64(math.MaxUint64), "1bffffffffffffffff"),
+		newMarshalTestCase("negative", -1000, "3903e7"),
+		newMarshalTestCase("float64", 1.1, "fb3ff199999999999a"),
+		newMarshalTestCase("float32", float32(100000), "fa47c35000"),
+		newMarshalTestCase("false", false, "f4"),
+		newMarshalTestCase("nil", nil, "f6"),
+		newMarshalTestCase("bytes", []byte{1, 2, 3, 4}, "4401020304"),
+		newMarshalTestCase("text", "IETF", "6449455446"),
+		newMarshalTestCase("array", []any{1, []int{2, 3}, [2]int{4, 5}}, "8301820203820405"),
+		newMarshalTestCase("map", map[string]any{"b": []int{2, 3}, "a": 1}, "a26161016162820203"),
+		newMarshalTestCase("struct", struct {
+			A int    `cbor:"a"`
+			B string `json:"b,omitempty"`
+			C string `cbor:"-"`
+			d int
+		}{A: 1, C: "skipped", d: 2}, "a1616101"),
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	core.RunTestCases(t, marshalTestCases())
+}
+
+var _ core.TestCase = unmarshalTestCase{}
+
+// unmarshalTestCase verifies the examples of RFC 8949 Appendix A decode
+// into their generic Go values.
+type unmarshalTestCase struct {
+	want any
+	name string
+	hex  string
+}
+
+func (tc unmarshalTestCase) Name() string { return tc.name }
+
+func (tc unmarshalTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	data, err := hex.DecodeString(tc.hex)
+	core.AssertMustNoError(t, err, "hex")
+
+	var got any
+	core.AssertMustNoError(t, Unmarshal(data, &got), "Unmarshal")
+	core.AssertDeepEqual(t, tc.want, got, "value")
+}
+
+func newUnmarshalTestCase(name, hexData string, want any) unmarshalTestCase {
+	return unmarshalTestCase{name: name, hex: hexData, want: want}
+}
+
+func unmarshalTestCases() []unmarshalTestCase {
+	return []unmarshalTestCase{
+		newUnmarshalTestCase("uint", "1903e8", uint64(1000)),
+		newUnmarshalTestCase("negative", "3903e7", int64(-1000)),
+		newUnmarshalTestCase("half float", "f93e00", 1.5),
+		newUnmarshalTestCase("half float subnormal", "f90001", 5.960464477539063e-8),
+		newUnmarshalTestCase("half float infinity", "f97c00", math.Inf(1)),
+		newUnmarshalTestCase("float32", "fa47c35000", 100000.0),
+		newUnmarshalTestCase("true", "f5", true),
+		newUnmarshalTestCase("undefined", "f7", nil),
+		newUnmarshalTestCase("tagged", "c11a514b67b0", uint64(1363896240)),
+		newUnmarshalTestCase("text", "6449455446", "IETF"),
+		newUnmarshalTestCase("indefinite bytes", "5f42010243030405ff", []byte{1, 2, 3, 4, 5}),
+		newUnmarshalTestCase("indefinite text", "7f657374726561646d696e67ff", "streaming"),
+		newUnmarshalTestCase("indefinite array", "9f018202039f0405ffff",
+			[]any{uint64(1), []any{uint64(2), uint64(3)}, []any{uint64(4), uint64(5)}}),
+		newUnmarshalTestCase("text keys", "a26161016162820203",
+			map[string]any{"a": uint64(1), "b": []any{uint64(2), uint64(3)}}),
+		newUnmarshalTestCase("int keys", "a201020304",
+			map[any]any{uint64(1): uint64(2), uint64(3): uint64(4)}),
+		newUnmarshalTestCase("indefinite map", "bf6346756ef563416d7421ff",
+			map[string]any{"Fun": true, "Amt": int64(-2)}),
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	core.RunTestCases(t, unmarshalTestCases())
+}
+
+type testReading struct {
+	Labels map[string]string `cbor:"labels,omitempty"`
+	Sensor string            `cbor:"sensor"`
+	Raw    []byte            `cbor:"raw"`
+	Values []float32         `cbor:"values"`
+	Next   *testReading      `cbor:"next"`
+	Count  uint16            `json:"count"`
+	Delta  int8              `cbor:"delta"`
+	Valid  bool              `cbor:"valid"`
+}
+
+func TestUnmarshal_struct(t *testing.T) {
+	in := testReading{
+		Sensor: "temp",
+		Raw:    []byte{0xde, 0xad},
+		Values: []float32{21.5, -3},
+		Next:   &testReading{Sensor: "humidity"},
+		Count:  300,
+		Delta:  -7,
+		Valid:  true,
+	}
+	data, err := Marshal(in)
+	core.AssertMustNoError(t, err, "Marshal")
+
+	var out testReading
+	core.AssertMustNoError(t, Unmarshal(data, &out), "Unmarshal")
+	core.AssertDeepEqual(t, in, out, "round trip")
+}
+
+var _ core.TestCase = unmarshalErrorTestCase{}
+
+// unmarshalErrorTestCase verifies malformed data and values that don't
+// fit fail with the expected error.
+type unmarshalErrorTestCase struct {
+	target func() any
+	want   error
+	name   string
+	hex    string
+}
+
+func (tc unmarshalErrorTestCase) Name() string { return tc.name }
+
+func (tc unmarshalErrorTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	data, err := hex.DecodeString(tc.hex)
+	core.AssertMustNoError(t, err, "hex")
+
+	err = Unmarshal(data, tc.target())
+	core.AssertTrue(t, errors.Is(err, tc.want), "%v: %v", tc.want, err)
+}
+
+func newUnmarshalErrorTestCase(name, hexData string, target func() any,
+	want error) unmarshalErrorTestCase {
+	return unmarshalErrorTestCase{name: name, hex: hexData, target: target, want: want}
+}
+
+func unmarshalErrorTestCases() []unmarshalErrorTestCase {
+	anyTarget := func() any { return new(any) }
+
+	return []unmarshalErrorTestCase{
+		newUnmarshalErrorTestCase("empty", "", anyTarget, ErrInvalidData),
+		newUnmarshalErrorTestCase("truncated", "1903", anyTarget, ErrInvalidData),
+		newUnmarshalErrorTestCase("left over", "0101", anyTarget, ErrInvalidData),
+		newUnmarshalErrorTestCase("huge array", "9b00000000ffffffff", anyTarget, ErrInvalidData),
+		newUnmarshalErrorTestCase("unterminated", "9f01", anyTarget, ErrInvalidData),
+		newUnmarshalErrorTestCase("array key", "a18001", anyTarget, ErrInvalidData),
+		newUnmarshalErrorTestCase("overflow", "190100", func() any { return new(uint8) }, ErrTypeMismatch),
+		newUnmarshalErrorTestCase("negative uint", "20", func() any { return new(uint) }, ErrTypeMismatch),
+		newUnmarshalErrorTestCase("text as int", "6161", func() any { return new(int) }, ErrTypeMismatch),
+		newUnmarshalErrorTestCase("not a pointer", "00", func() any { return 0 }, ErrUnsupportedType),
+	}
+}
+
+func TestUnmarshal_errors(t *testing.T) {
+	core.RunTestCases(t, unmarshalErrorTestCases())
+}
+
+func TestUnmarshal_depth(t *testing.T) {
+	data := make([]byte, maxDepth+1)
+	for i := range data {
+		data[i] = majorArray | 1
+	}
+	data = append(data, 0)
+
+	var out any
+	err := Unmarshal(data, &out)
+	core.AssertTrue(t, errors.Is(err, ErrInvalidData), "ErrInvalidData: %v", err)
+}
+
+func TestMarshal_unsupported(t *testing.T) {
+	_, err := Marshal(make(chan int))
+	core.AssertTrue(t, errors.Is(err, ErrUnsupportedType), "ErrUnsupportedType: %v", err)
+}
@@ -0,0 +1,344 @@
+package cbor
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+
+	"darvaza.org/core"
+)
+
+const (
+	// maxDepth bounds the nesting of arrays, maps and tags decoded.
+	maxDepth = 64
+
+	infoIndefinite = 31
+	breakCode      = majorSimple | infoIndefinite
+)
+
+// Unmarshal decodes a single CBOR item from data into the value v points
+// to, failing with [ErrInvalidData] if data is malformed or has bytes left
+// over, and [ErrTypeMismatch] if an item can't be stored where it goes.
+// Map entries without a struct field to store them are ignored.
+func Unmarshal(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return core.QuietWrap(ErrUnsupportedType, "cbor: Unmarshal needs a non-nil pointer, got %T", v)
+	}
+
+	d := decoder{data: data}
+	x, err := d.item()
+	switch {
+	case err != nil:
+		return err
+	case d.off != len(data):
+		return invalidData("%d bytes left over", len(data)-d.off)
+	default:
+		return assign(rv.Elem(), x)
+	}
+}
+
+func invalidData(format string, args ...any) error {
+	return core.QuietWrap(ErrInvalidData, "cbor: "+format, args...)
+}
+
+type decoder struct {
+	data  []byte
+	off   int
+	depth int
+}
+
+// initial reads the initial byte of an item, split into its major type
+// and additional information.
+func (d *decoder) initial() (major, info byte, err error) {
+	if d.off >= len(d.data) {
+		return 0, 0, invalidData("unexpected end of data")
+	}
+	b := d.data[d.off]
+	d.off++
+	return b & 0xe0, b & 0x1f, nil
+}
+
+// next reads the following n bytes.
+func (d *decoder) next(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.off) {
+		return nil, invalidData("unexpected end of data")
+	}
+	b := d.data[d.off : d.off+int(n)]
+	d.off += int(n)
+	return b, nil
+}
+
+// arg reads the argument of an item given its additional information.
+func (d *decoder) arg(info byte) (uint64, error) {
+	if info < 24 {
+		return uint64(info), nil
+	}
+	if info > 27 {
+		return 0, invalidData("invalid additional information %d", info)
+	}
+
+	b, err := d.next(1 << (info - 24))
+	if err != nil {
+		return 0, err
+	}
+	switch len(b) {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	default:
+		return binary.BigEndian.Uint64(b), nil
+	}
+}
+
+// item decodes the next item into its generic Go value.
+func (d *decoder) item() (any, error) {
+	major, info, err := d.initial()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case majorUint:
+		return d.arg(info)
+	case majorNegInt:
+		return d.negInt(info)
+	case majorBytes:
+		return d.bytes(major, info)
+	case majorText:
+		b, err := d.bytes(major, info)
+		return string(b), err
+	case majorSimple:
+		return d.simple(info)
+	default:
+		return d.nested(major, info)
+	}
+}
+
+func (d *decoder) negInt(info byte) (any, error) {
+	n, err := d.arg(info)
+	switch {
+	case err != nil:
+		return nil, err
+	case n > math.MaxInt64:
+		return nil, invalidData("negative integer -1-%d overflows int64", n)
+	default:
+		return -1 - int64(n), nil
+	}
+}
+
+// bytes reads a byte or text string, joining the chunks of
+// indefinite-length ones.
+func (d *decoder) bytes(major, info byte) ([]byte, error) {
+	if info == infoIndefinite {
+		return d.chunks(major)
+	}
+
+	n, err := d.arg(info)
+	if err != nil {
+		return nil, err
+	}
+	b, err := d.next(n)
+	return append([]byte{}, b...), err
+}
+
+// chunks joins the definite-length strings of the given major type up to
+// the break code.
+func (d *decoder) chunks(major byte) ([]byte, error) {
+	out := []byte{}
+	for !d.atBreak() {
+		b, err := d.chunk(major)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b...)
+	}
+	return out, nil
+}
+
+func (d *decoder) chunk(major byte) ([]byte, error) {
+	m, info, err := d.initial()
+	switch {
+	case err != nil:
+		return nil, err
+	case m != major || info == infoIndefinite:
+		return nil, invalidData("invalid chunk of indefinite-length string")
+	default:
+		return d.bytes(major, info)
+	}
+}
+
+// atBreak consumes the break code ending an indefinite-length item, if
+// next.
+func (d *decoder) atBreak() bool {
+	if d.off < len(d.data) && d.data[d.off] == breakCode {
+		d.off++
+		return true
+	}
+	return false
+}
+
+func (d *decoder) simple(info byte) (any, error) {
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23: // null, undefined
+		return nil, nil
+	case 25, 26, 27:
+		return d.float(info)
+	default:
+		return nil, invalidData("unsupported simple value %d", info)
+	}
+}
+
+func (d *decoder) float(info byte) (any, error) {
+	n, err := d.arg(info)
+	switch {
+	case err != nil:
+		return nil, err
+	case info == 25:
+		return float16(uint16(n)), nil
+	case info == 26:
+		return float64(math.Float32frombits(uint32(n))), nil
+	default:
+		return math.Float64frombits(n), nil
+	}
+}
+
+// float16 converts an IEEE 754 half-precision float.
+func float16(h uint16) float64 {
+	sign := 1.0
+	if h&0x8000 != 0 {
+		sign = -1
+	}
+
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+	switch {
+	case exp == 0:
+		return sign * math.Ldexp(mant, -24)
+	case exp != 0x1f:
+		return sign * math.Ldexp(mant+0x400, exp-25)
+	case mant == 0:
+		return math.Inf(int(sign))
+	default:
+		return math.NaN()
+	}
+}
+
+// nested decodes arrays, maps and tags, bounding their nesting.
+func (d *decoder) nested(major, info byte) (any, error) {
+	if d.depth >= maxDepth {
+		return nil, invalidData("nesting deeper than %d", maxDepth)
+	}
+	d.depth++
+	defer func() { d.depth-- }()
+
+	switch major {
+	case majorArray:
+		return d.array(info)
+	case majorMap:
+		return d.mapItem(info)
+	default: // majorTag, whose number is ignored
+		if _, err := d.arg(info); err != nil {
+			return nil, err
+		}
+		return d.item()
+	}
+}
+
+// count reads the number of entries of an array or map, -1 if of
+// indefinite length. Every entry takes at least one byte, so larger
+// counts than the bytes left are rejected before allocating for them.
+func (d *decoder) count(info byte) (int, error) {
+	if info == infoIndefinite {
+		return -1, nil
+	}
+
+	n, err := d.arg(info)
+	switch {
+	case err != nil:
+		return 0, err
+	case n > uint64(len(d.data)-d.off):
+		return 0, invalidData("unexpected end of data")
+	default:
+		return int(n), nil
+	}
+}
+
+// more tells if there is another entry to read in an array or map of n
+// entries, n being -1 if of indefinite length.
+func (d *decoder) more(i, n int) bool {
+	if n < 0 {
+		return !d.atBreak()
+	}
+	return i < n
+}
+
+func (d *decoder) array(info byte) (any, error) {
+	n, err := d.count(info)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]any, 0, max(n, 0))
+	for i := 0; d.more(i, n); i++ {
+		x, err := d.item()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, x)
+	}
+	return out, nil
+}
+
+func (d *decoder) mapItem(info byte) (any, error) {
+	n, err := d.count(info)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[any]any, max(n, 0))
+	for i := 0; d.more(i, n); i++ {
+		if err := d.entry(out); err != nil {
+			return nil, err
+		}
+	}
+	return textKeys(out), nil
+}
+
+// entry decodes a key and value into m.
+func (d *decoder) entry(m map[any]any) error {
+	key, err := d.item()
+	if err != nil {
+		return err
+	}
+	if t := reflect.TypeOf(key); t != nil && !t.Comparable() {
+		return invalidData("invalid map key of type %T", key)
+	}
+
+	value, err := d.item()
+	if err != nil {
+		return err
+	}
+	m[key] = value
+	return nil
+}
+
+// textKeys converts a map whose keys are all text into a map[string]any.
+func textKeys(m map[any]any) any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		s, ok := k.(string)
+		if !ok {
+			return m
+		}
+		out[s] = v
+	}
+	return out
+}
@@ -0,0 +1,23 @@
+// Package cbor encodes and decodes Go values as CBOR (RFC 8949), the
+// compact binary encoding of many embedded devices that can't afford a
+// protobuf runtime, without depending on a third-party library.
+//
+// Marshal and Unmarshal follow the conventions of encoding/json. Structs
+// are encoded as maps keyed by field name, taken from the "cbor" tag of
+// the field, or its "json" tag, so the same types serve both encodings:
+//
+//	type Reading struct {
+//		Sensor string  `cbor:"sensor"`
+//		Value  float64 `cbor:"value"`
+//		Unit   string  `cbor:"unit,omitempty"`
+//	}
+//
+//	data, err := cbor.Marshal(Reading{Sensor: "temp", Value: 21.5})
+//
+// Map keys are written in the bytewise order of their encoding, so equal
+// values always encode the same. Decoding accepts indefinite-length items,
+// half-precision floats and tagged items, whose tag is ignored. Decoded
+// into an empty interface, items become bool, uint64, int64, float64,
+// string, []byte, []any, and map[string]any, or map[any]any when not every
+// key is text.
+package cbor
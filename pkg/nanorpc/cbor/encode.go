@@ -0,0 +1,223 @@
+package cbor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"slices"
+
+	"darvaza.org/core"
+)
+
+// Major types, in the top three bits of the initial byte of every item.
+const (
+	majorUint   byte = 0 << 5
+	majorNegInt byte = 1 << 5
+	majorBytes  byte = 2 << 5
+	majorText   byte = 3 << 5
+	majorArray  byte = 4 << 5
+	majorMap    byte = 5 << 5
+	majorTag    byte = 6 << 5
+	majorSimple byte = 7 << 5
+)
+
+// Initial bytes of simple values and floats.
+const (
+	simpleFalse = majorSimple | 20
+	simpleTrue  = majorSimple | 21
+	simpleNull  = majorSimple | 22
+	float32Head = majorSimple | 26
+	float64Head = majorSimple | 27
+)
+
+// Marshal returns the CBOR encoding of v, failing with
+// [ErrUnsupportedType] if it holds values CBOR can't represent.
+func Marshal(v any) ([]byte, error) {
+	var e encoder
+	if err := e.value(reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+type encoder struct {
+	buf []byte
+}
+
+// head appends the initial byte of an item of the given major type, and
+// its argument n in the fewest bytes possible.
+func (e *encoder) head(major byte, n uint64) {
+	switch {
+	case n < 24:
+		e.buf = append(e.buf, major|byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, major|24, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, major|25), uint16(n))
+	case n <= math.MaxUint32:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, major|26), uint32(n))
+	default:
+		e.buf = binary.BigEndian.AppendUint64(append(e.buf, major|27), n)
+	}
+}
+
+func (e *encoder) value(v reflect.Value) error {
+	if !v.IsValid() {
+		e.buf = append(e.buf, simpleNull)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		e.bool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.int(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.head(majorUint, v.Uint())
+	case reflect.Float32:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, float32Head), math.Float32bits(float32(v.Float())))
+	case reflect.Float64:
+		e.buf = binary.BigEndian.AppendUint64(append(e.buf, float64Head), math.Float64bits(v.Float()))
+	case reflect.String:
+		e.head(majorText, uint64(v.Len()))
+		e.buf = append(e.buf, v.String()...)
+	case reflect.Pointer, reflect.Interface:
+		return e.elem(v)
+	default:
+		return e.composite(v)
+	}
+	return nil
+}
+
+func (e *encoder) bool(b bool) {
+	if b {
+		e.buf = append(e.buf, simpleTrue)
+	} else {
+		e.buf = append(e.buf, simpleFalse)
+	}
+}
+
+func (e *encoder) int(n int64) {
+	if n < 0 {
+		e.head(majorNegInt, uint64(-1-n))
+	} else {
+		e.head(majorUint, uint64(n))
+	}
+}
+
+// elem encodes what a pointer or interface holds, null if nil.
+func (e *encoder) elem(v reflect.Value) error {
+	if v.IsNil() {
+		e.buf = append(e.buf, simpleNull)
+		return nil
+	}
+	return e.value(v.Elem())
+}
+
+func (e *encoder) composite(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.IsNil() {
+			e.buf = append(e.buf, simpleNull)
+			return nil
+		}
+		return e.array(v)
+	case reflect.Array:
+		return e.array(v)
+	case reflect.Map:
+		if v.IsNil() {
+			e.buf = append(e.buf, simpleNull)
+			return nil
+		}
+		return e.mapValue(v)
+	case reflect.Struct:
+		return e.structValue(v)
+	default:
+		return core.QuietWrap(ErrUnsupportedType, "cbor: unsupported type %s", v.Type())
+	}
+}
+
+// array encodes slices and arrays, byte strings if of bytes.
+func (e *encoder) array(v reflect.Value) error {
+	n := v.Len()
+	if v.Type().Elem().Kind() == reflect.Uint8 {
+		e.head(majorBytes, uint64(n))
+		for i := range n {
+			e.buf = append(e.buf, byte(v.Index(i).Uint()))
+		}
+		return nil
+	}
+
+	e.head(majorArray, uint64(n))
+	for i := range n {
+		if err := e.value(v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mapValue encodes a map with its keys in the bytewise order of their
+// encoding.
+func (e *encoder) mapValue(v reflect.Value) error {
+	entries := make([]mapEntry, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		entry, err := encodeEntry(iter.Key(), iter.Value())
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+	}
+	e.entries(entries)
+	return nil
+}
+
+func (e *encoder) structValue(v reflect.Value) error {
+	entries := make([]mapEntry, 0, v.NumField())
+	for _, f := range structFields(v.Type()) {
+		fv := v.Field(f.index)
+		if f.omitEmpty && fv.IsZero() {
+			continue
+		}
+
+		entry, err := encodeEntry(reflect.ValueOf(f.name), fv)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+	}
+	e.entries(entries)
+	return nil
+}
+
+// entries appends the map holding the given entries, sorted.
+func (e *encoder) entries(entries []mapEntry) {
+	slices.SortFunc(entries, func(a, b mapEntry) int {
+		return bytes.Compare(a.key, b.key)
+	})
+
+	e.head(majorMap, uint64(len(entries)))
+	for _, entry := range entries {
+		e.buf = append(e.buf, entry.key...)
+		e.buf = append(e.buf, entry.value...)
+	}
+}
+
+// mapEntry is an encoded key and value of a map.
+type mapEntry struct {
+	key   []byte
+	value []byte
+}
+
+func encodeEntry(key, value reflect.Value) (mapEntry, error) {
+	var k, v encoder
+	if err := k.value(key); err != nil {
+		return mapEntry{}, err
+	}
+	if err := v.value(value); err != nil {
+		return mapEntry{}, err
+	}
+	return mapEntry{key: k.buf, value: v.buf}, nil
+}
@@ -0,0 +1,16 @@
+package cbor
+
+import "errors"
+
+var (
+	// ErrUnsupportedType indicates a Go value CBOR can't represent, such
+	// as a channel or a function.
+	ErrUnsupportedType = errors.New("unsupported type")
+
+	// ErrInvalidData indicates malformed or truncated CBOR data.
+	ErrInvalidData = errors.New("invalid CBOR data")
+
+	// ErrTypeMismatch indicates a CBOR item that can't be stored in the
+	// Go value given to Unmarshal.
+	ErrTypeMismatch = errors.New("type mismatch")
+)
@@ -0,0 +1,60 @@
+package cbor
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// field is an exported struct field encoded as a map entry.
+type field struct {
+	name      string
+	index     int
+	omitEmpty bool
+}
+
+var fieldCache sync.Map // reflect.Type -> []field
+
+// structFields returns the fields of a struct type encoded, named by
+// their "cbor" tag, their "json" tag, or themselves. Fields tagged "-"
+// and unexported ones are skipped.
+func structFields(t reflect.Type) []field {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]field)
+	}
+
+	fields := make([]field, 0, t.NumField())
+	for i := range t.NumField() {
+		if f, ok := newField(t.Field(i), i); ok {
+			fields = append(fields, f)
+		}
+	}
+
+	cached, _ := fieldCache.LoadOrStore(t, fields)
+	return cached.([]field)
+}
+
+func newField(sf reflect.StructField, index int) (field, bool) {
+	if !sf.IsExported() {
+		return field{}, false
+	}
+
+	tag, ok := sf.Tag.Lookup("cbor")
+	if !ok {
+		tag = sf.Tag.Get("json")
+	}
+	if tag == "-" {
+		return field{}, false
+	}
+
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = sf.Name
+	}
+	return field{
+		name:      name,
+		index:     index,
+		omitEmpty: slices.Contains(strings.Split(opts, ","), "omitempty"),
+	}, true
+}
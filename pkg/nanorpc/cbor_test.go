@@ -0,0 +1,81 @@
+package nanorpc
+
+import (
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc/cbor"
+)
+
+var _ core.TestCase = decodeResponseCBORTestCase{}
+
+type decodeResponseCBORTestCase struct {
+	res     *NanoRPCResponse
+	name    string
+	want    string
+	present bool
+	wantErr bool
+}
+
+func (tc decodeResponseCBORTestCase) Name() string { return tc.name }
+
+func (tc decodeResponseCBORTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	var out string
+	present, err := DecodeResponseCBOR(tc.res, &out)
+	if tc.wantErr {
+		core.AssertError(t, err, "error")
+		return
+	}
+	core.AssertNoError(t, err, "error")
+	core.AssertEqual(t, tc.present, present, "present")
+	core.AssertEqual(t, tc.want, out, "value")
+}
+
+func newDecodeResponseCBORTestCase(name string, res *NanoRPCResponse, present bool, want string,
+	wantErr bool) decodeResponseCBORTestCase {
+	return decodeResponseCBORTestCase{
+		name:    name,
+		res:     res,
+		present: present,
+		want:    want,
+		wantErr: wantErr,
+	}
+}
+
+func decodeResponseCBORTestCases() []decodeResponseCBORTestCase {
+	data, _ := cbor.Marshal("hello")
+	ok := NanoRPCResponse_STATUS_OK
+
+	return []decodeResponseCBORTestCase{
+		newDecodeResponseCBORTestCase("data", &NanoRPCResponse{ResponseStatus: ok, Data: data},
+			true, "hello", false),
+		newDecodeResponseCBORTestCase("empty", &NanoRPCResponse{ResponseStatus: ok}, false, "", false),
+		newDecodeResponseCBORTestCase("error status",
+			&NanoRPCResponse{ResponseStatus: NanoRPCResponse_STATUS_NOT_FOUND}, false, "", true),
+		newDecodeResponseCBORTestCase("nil", nil, false, "", true),
+		newDecodeResponseCBORTestCase("invalid", &NanoRPCResponse{ResponseStatus: ok, Data: []byte{0x19}},
+			true, "", true),
+	}
+}
+
+func TestDecodeResponseCBOR(t *testing.T) {
+	core.RunTestCases(t, decodeResponseCBORTestCases())
+}
+
+func TestDecodeRequestCBOR(t *testing.T) {
+	data, err := cbor.Marshal(map[string]int{"level": 3})
+	core.AssertMustNoError(t, err, "Marshal")
+
+	var out map[string]int
+	present, err := DecodeRequestCBOR(&NanoRPCRequest{Data: data}, &out)
+	core.AssertMustNoError(t, err, "DecodeRequestCBOR")
+	core.AssertTrue(t, present, "present")
+	core.AssertEqual(t, 3, out["level"], "level")
+
+	present, err = DecodeRequestCBOR(nil, &out)
+	core.AssertNoError(t, err, "nil request")
+	core.AssertFalse(t, present, "nil request present")
+}
@@ -0,0 +1,170 @@
+package client
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// SubscribeAcked enqueues a NanoRPC subscription request asking for
+// acknowledged updates, for state that must not be silently lost. Every
+// update passed to cb is acknowledged with a TYPE_ACK, and the server
+// sends again those found missing, so cb is passed the numbered updates
+// in order, without gaps nor duplicates. When the server no longer holds
+// the missing updates, it sends the latest one flagged as a snapshot
+// instead. Calls to cb don't overlap.
+//
+// Updates sent ahead of the acknowledgement, history or retained, are
+// passed as they come, and acknowledged subscriptions aren't filtered.
+// On paths without replay on the server, or servers predating
+// acknowledged updates, it's a plain [Client.Subscribe]. Gaps and
+// duplicates are counted in [Stats].
+func (c *Client) SubscribeAcked(path string, cb RequestCallback) (int32, error) {
+	if c == nil {
+		return 0, core.ErrNilReceiver
+	}
+	if cb == nil {
+		return 0, ErrMissingCallback
+	}
+
+	// assemble header
+	m := &nanorpc.NanoRPCRequest{
+		RequestType:  nanorpc.NanoRPCRequest_TYPE_SUBSCRIBE,
+		PathOneof:    c.getPathOneOf(path),
+		Acknowledged: true,
+	}
+
+	ar := &ackedReceiver{c: c, cb: cb, path: path}
+	return c.enqueue(m, nil, ar.callback)
+}
+
+// ackedReceiver adapts the [RequestCallback] of an acknowledged
+// subscription, putting the updates back in order as their callbacks may
+// run concurrently, acknowledging them, and reporting gaps.
+type ackedReceiver struct {
+	c       *Client
+	cb      RequestCallback
+	pending map[uint64]*nanorpc.NanoRPCResponse
+	early   []*nanorpc.NanoRPCResponse // updates ahead of the acknowledgement
+	path    string
+	base    uint64 // sequence of the acknowledgement
+	last    uint64 // sequence of the last update passed
+	mu      sync.Mutex
+	acked   bool // the acknowledgement was passed
+	gap     bool // the gap after last was reported
+}
+
+// callback is the [RequestCallback] of the subscription.
+func (ar *ackedReceiver) callback(ctx context.Context, id int32, resp *nanorpc.NanoRPCResponse) error {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	switch {
+	case resp == nil, ar.acked && resp.ResponseType != nanorpc.NanoRPCResponse_TYPE_UPDATE:
+		return ar.cb(ctx, id, resp)
+	case resp.ResponseType != nanorpc.NanoRPCResponse_TYPE_UPDATE:
+		return ar.unsafeEstablish(ctx, id, resp)
+	case !ar.acked:
+		ar.early = append(ar.early, resp)
+		return nil
+	default:
+		return ar.unsafeReceive(ctx, id, resp)
+	}
+}
+
+// unsafeEstablish passes the acknowledgement, which carries the sequence
+// the acknowledged updates follow, and then the updates held back
+// waiting for it.
+func (ar *ackedReceiver) unsafeEstablish(ctx context.Context, id int32, resp *nanorpc.NanoRPCResponse) error {
+	ar.acked = true
+	ar.base, ar.last = resp.Sequence, resp.Sequence
+	if err := ar.cb(ctx, id, resp); err != nil {
+		return err
+	}
+
+	early := ar.early
+	ar.early = nil
+	slices.SortStableFunc(early, func(a, b *nanorpc.NanoRPCResponse) int {
+		return cmp.Compare(a.Sequence, b.Sequence)
+	})
+	for _, update := range early {
+		if err := ar.unsafeReceive(ctx, id, update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unsafeReceive passes or holds back an update, depending on its
+// sequence.
+func (ar *ackedReceiver) unsafeReceive(ctx context.Context, id int32, resp *nanorpc.NanoRPCResponse) error {
+	seq := resp.Sequence
+	switch {
+	case ar.base == 0 || seq <= ar.base:
+		// not acknowledged, unnumbered or ahead of the acknowledgement
+		return ar.cb(ctx, id, resp)
+	case seq <= ar.last:
+		ar.c.stats.observeDuplicate()
+		return nil
+	case resp.Snapshot:
+		// the updates before it are lost for good
+		for s := range ar.pending {
+			if s < seq {
+				delete(ar.pending, s)
+			}
+		}
+		ar.last = seq - 1
+	}
+
+	if ar.pending == nil {
+		ar.pending = make(map[uint64]*nanorpc.NanoRPCResponse)
+	}
+	ar.pending[seq] = resp
+	return ar.unsafeDeliver(ctx, id)
+}
+
+// unsafeDeliver passes the updates following the last one passed and
+// acknowledges them. Acknowledging the last one again reports a gap, once.
+func (ar *ackedReceiver) unsafeDeliver(ctx context.Context, id int32) error {
+	var err error
+	from := ar.last
+	for err == nil {
+		update, ok := ar.pending[ar.last+1]
+		if !ok {
+			break
+		}
+
+		delete(ar.pending, ar.last+1)
+		ar.last++
+		err = ar.cb(ctx, id, update)
+	}
+
+	if ar.last != from {
+		ar.gap = false
+		ar.unsafeAck(id)
+	}
+	if len(ar.pending) > 0 && !ar.gap {
+		ar.gap = true
+		ar.c.stats.observeGap()
+		ar.unsafeAck(id)
+	}
+	return err
+}
+
+// unsafeAck acknowledges the updates passed so far. Failures mean the
+// session is gone, and the subscription with it.
+func (ar *ackedReceiver) unsafeAck(id int32) {
+	m := &nanorpc.NanoRPCRequest{
+		RequestId:   id,
+		RequestType: nanorpc.NanoRPCRequest_TYPE_ACK,
+		PathOneof:   ar.c.getPathOneOf(ar.path),
+		AckSequence: ar.last,
+	}
+
+	_, _ = ar.c.enqueuePriority(m, nil, nil, PriorityHigh)
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/mock/server"
+)
+
+func newSequencedUpdate(id int32, seq uint64, snapshot bool) *nanorpc.NanoRPCResponse {
+	update := newResponse(id, respUpdate, statusOK)
+	update.Sequence = seq
+	update.Snapshot = snapshot
+	return update
+}
+
+// mustRecvAck receives a TYPE_ACK and checks the sequence acknowledged.
+func mustRecvAck(t *testing.T, srv *server.Conn, id int32, want uint64) {
+	t.Helper()
+
+	req := srv.Recv()
+	core.AssertEqual(t, reqAck, req.RequestType, "request_type")
+	core.AssertEqual(t, id, req.RequestId, "request_id")
+	core.AssertEqual(t, "/state", req.GetPath(), "path")
+	core.AssertEqual(t, want, req.AckSequence, "ack_sequence")
+}
+
+// mustRecvSequence receives an update and checks its sequence.
+func mustRecvSequence(t *testing.T, events <-chan cbEvent, want uint64) {
+	t.Helper()
+
+	ev := mustRecvEvent(t, events, "update")
+	core.AssertEqual(t, want, ev.resp.Sequence, "sequence")
+}
+
+func TestClient_SubscribeAcked(t *testing.T) {
+	c, srv := newConnectedSession(t)
+
+	events := make(chan cbEvent, 8)
+	id, err := c.SubscribeAcked("/state", recordingCallback(events))
+	core.AssertMustNoError(t, err, "SubscribeAcked")
+
+	req := srv.Recv()
+	core.AssertEqual(t, reqSubscribe, req.RequestType, "request_type")
+	core.AssertTrue(t, req.Acknowledged, "acknowledged")
+
+	// a retained update ahead of the acknowledgement is passed after it
+	srv.Reply(newSequencedUpdate(id, 7, false))
+	ack := newResponse(id, respResponse, statusOK)
+	ack.Sequence = 10
+	srv.Reply(ack)
+	mustRecvSequence(t, events, 10)
+	mustRecvSequence(t, events, 7)
+
+	srv.Reply(newSequencedUpdate(id, 11, false))
+	mustRecvSequence(t, events, 11)
+	mustRecvAck(t, srv, id, 11)
+
+	// 12 missing, reported acknowledging 11 again
+	srv.Reply(newSequencedUpdate(id, 13, false))
+	mustRecvAck(t, srv, id, 11)
+
+	srv.Reply(newSequencedUpdate(id, 12, false))
+	mustRecvSequence(t, events, 12)
+	mustRecvSequence(t, events, 13)
+	mustRecvAck(t, srv, id, 13)
+
+	// resent twice, dropped
+	srv.Reply(newSequencedUpdate(id, 13, false))
+
+	// a snapshot skips what the server no longer holds
+	srv.Reply(newSequencedUpdate(id, 20, true))
+	mustRecvSequence(t, events, 20)
+	mustRecvAck(t, srv, id, 20)
+
+	deadline := time.Now().Add(recvTimeout)
+	for c.Stats().DuplicateUpdates == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	stats := c.Stats()
+	core.AssertEqual(t, uint64(1), stats.UpdateGaps, "gaps")
+	core.AssertEqual(t, uint64(1), stats.DuplicateUpdates, "duplicates")
+}
+
+func TestClient_SubscribeAcked_unnumbered(t *testing.T) {
+	c, srv := newConnectedSession(t)
+
+	events := make(chan cbEvent, 8)
+	id, err := c.SubscribeAcked("/state", recordingCallback(events))
+	core.AssertMustNoError(t, err, "SubscribeAcked")
+	_ = srv.Recv()
+
+	// a server without replay on the path, a plain subscription
+	srv.Reply(newResponse(id, respResponse, statusOK))
+	mustRecvSequence(t, events, 0)
+	srv.Reply(newSequencedUpdate(id, 0, false))
+	mustRecvSequence(t, events, 0)
+	srv.Reply(newSequencedUpdate(id, 0, false))
+	mustRecvSequence(t, events, 0)
+
+	stats := c.Stats()
+	core.AssertEqual(t, uint64(0), stats.UpdateGaps, "gaps")
+	core.AssertEqual(t, uint64(0), stats.DuplicateUpdates, "duplicates")
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"runtime/debug"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+var (
+	_ error            = ConnectionError{}
+	_ core.Unwrappable = ConnectionError{}
+	_ error            = SessionError{}
+	_ core.Unwrappable = SessionError{}
+	_ error            = CallbackPanicError{}
+	_ core.Unwrappable = CallbackPanicError{}
+	_ error            = StorageError{}
+	_ core.Unwrappable = StorageError{}
+)
+
+// ConnectionError reports a failure of the reconnect loop, dialling the
+// server or keeping the connection, see [Client.Errors].
+type ConnectionError struct {
+	Err error
+	// Addr is the remote address, nil when the connection couldn't be
+	// made.
+	Addr net.Addr
+}
+
+func (e ConnectionError) Error() string {
+	if e.Addr == nil {
+		return fmt.Sprintf("nanorpc: connection: %v", e.Err)
+	}
+	return fmt.Sprintf("nanorpc: connection to %s: %v", e.Addr, e.Err)
+}
+
+func (e ConnectionError) Unwrap() error {
+	return e.Err
+}
+
+// SessionError reports a failure reading or writing the frames of an
+// established session, see [Client.Errors].
+type SessionError struct {
+	Err  error
+	Addr net.Addr
+}
+
+func (e SessionError) Error() string {
+	return fmt.Sprintf("nanorpc: session with %s: %v", e.Addr, e.Err)
+}
+
+func (e SessionError) Unwrap() error {
+	return e.Err
+}
+
+// CallbackPanicError reports a [RequestCallback] that panicked. The panic
+// is recovered so the session carries on, see [Client.Errors].
+type CallbackPanicError struct {
+	// Value is what the callback panicked with.
+	Value any
+	// Stack is the stack trace of the panic.
+	Stack []byte
+	// RequestID identifies the request the callback was called for.
+	RequestID int32
+}
+
+func (e CallbackPanicError) Error() string {
+	return fmt.Sprintf("nanorpc: callback of request %d panicked: %v", e.RequestID, e.Value)
+}
+
+// Unwrap returns the value the callback panicked with, if it's an error.
+func (e CallbackPanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// StorageError reports a failure of the Storage of the [Config] loading or
+// deleting the requests of [Client.RequestStored], see [Client.Errors].
+type StorageError struct {
+	Err error
+	// ID identifies the request, zero when loading them.
+	ID uint64
+}
+
+func (e StorageError) Error() string {
+	if e.ID == 0 {
+		return fmt.Sprintf("nanorpc: storage: %v", e.Err)
+	}
+	return fmt.Sprintf("nanorpc: storage of request %d: %v", e.ID, e.Err)
+}
+
+func (e StorageError) Unwrap() error {
+	return e.Err
+}
+
+// Errors returns the channel where the [Client] reports the failures of
+// its background work as [ConnectionError], [SessionError],
+// [CallbackPanicError] and [StorageError] values, so applications can react to degraded
+// connectivity. It holds the latest Config.ErrorQueueSize errors, older
+// ones are dropped when nobody reads them, and is never closed.
+func (c *Client) Errors() <-chan error {
+	if c == nil {
+		return nil
+	}
+	return c.errs
+}
+
+// reportError queues err on the [Client.Errors] channel, dropping the
+// oldest errors queued if full.
+func (c *Client) reportError(err error) {
+	if c == nil || c.errs == nil || err == nil {
+		return
+	}
+
+	for {
+		select {
+		case c.errs <- err:
+			return
+		default:
+		}
+
+		select {
+		case <-c.errs:
+			// drop oldest
+		default:
+		}
+	}
+}
+
+// callback calls cb recovering any panic, which is reported as a
+// [CallbackPanicError].
+func (cs *Session) callback(ctx context.Context, cb RequestCallback, id int32,
+	resp *nanorpc.NanoRPCResponse) error {
+	defer func() {
+		if v := recover(); v != nil {
+			cs.LogError(nil, nil, "callback of request %d panicked: %v", id, v)
+			cs.c.reportError(CallbackPanicError{Value: v, Stack: debug.Stack(), RequestID: id})
+		}
+	}()
+
+	return cb(ctx, id, resp)
+}
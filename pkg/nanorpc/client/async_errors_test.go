@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/utils/testutils"
+)
+
+var errCallbackPanic = errors.New("callback exploded")
+
+func TestClient_Errors_dropOldest(t *testing.T) {
+	c := &Client{errs: make(chan error, 2)}
+	errs := []error{errors.New("first"), errors.New("second"), errors.New("third")}
+	for _, err := range errs {
+		c.reportError(err)
+	}
+	c.reportError(nil)
+
+	core.AssertEqual(t, 2, len(c.Errors()), "queued")
+	core.AssertSame(t, errs[1], <-c.Errors(), "oldest kept")
+	core.AssertSame(t, errs[2], <-c.Errors(), "newest")
+}
+
+func TestClient_Errors_connection(t *testing.T) {
+	c := newClientForTest(t)
+	testErr := errors.New("connection refused")
+	for _, conn := range []net.Conn{nil, &testutils.MockConn{Remote: "127.0.0.1:8080"}} {
+		err := c.onReconnectError(context.Background(), conn, testErr)
+		core.AssertSame(t, testErr, err, "onReconnectError")
+
+		var ce ConnectionError
+		err = mustRecvError(t, c.Errors(), "connection error")
+		core.AssertTrue(t, errors.As(err, &ce), "ConnectionError")
+		core.AssertErrorIs(t, err, testErr, "cause")
+		core.AssertEqual(t, conn == nil, ce.Addr == nil, "addr")
+	}
+}
+
+// TestClient_Errors_callbackPanic verifies a panicking callback is
+// reported and doesn't end the session.
+func TestClient_Errors_callbackPanic(t *testing.T) {
+	c, srv := newConnectedSession(t)
+
+	id, err := c.Request("/echo", nil, func(context.Context, int32, *nanorpc.NanoRPCResponse) error {
+		panic(errCallbackPanic)
+	})
+	core.AssertMustNoError(t, err, "Request")
+	srv.Recv()
+	srv.Reply(newResponse(id, respResponse, statusOK))
+
+	var pe CallbackPanicError
+	err = mustRecvError(t, c.Errors(), "panic")
+	core.AssertTrue(t, errors.As(err, &pe), "CallbackPanicError")
+	core.AssertErrorIs(t, err, errCallbackPanic, "panic value")
+	core.AssertEqual(t, id, pe.RequestID, "request_id")
+	core.AssertNotEqual(t, 0, len(pe.Stack), "stack")
+
+	events := make(chan cbEvent, 1)
+	id, err = c.Request("/echo", nil, recordingCallback(events))
+	core.AssertMustNoError(t, err, "Request after panic")
+	srv.Recv()
+	srv.Reply(newResponse(id, respResponse, statusOK))
+	mustRecvEvent(t, events, "response after panic")
+}
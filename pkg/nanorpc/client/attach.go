@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"net"
+
+	"darvaza.org/core"
+)
+
+// Attach runs a session over conn, a connection established outside the
+// reconnect loop such as one returned by [DialUDP]. The [Config] dial, TLS
+// and timeout settings don't apply to it, and it isn't re-established:
+// the session ends, closing conn, when ctx is cancelled or conn fails.
+// OnConnect and OnDisconnect are called as for dialled connections.
+//
+// Attach fails with [ErrSessionAttached] while another session is active.
+func (c *Client) Attach(ctx context.Context, conn net.Conn) error {
+	switch {
+	case c == nil:
+		return core.ErrNilReceiver
+	case conn == nil:
+		return ErrMissingConn
+	}
+
+	cs := newAttachedSession(ctx, c, conn)
+	if err := c.setSession(cs); err != nil {
+		return err
+	}
+
+	if err := cs.Spawn(); err != nil {
+		c.endSession(cs)
+		return err
+	}
+
+	go c.runAttached(ctx, cs, conn)
+	return nil
+}
+
+// runAttached waits for an attached session to end, mirroring
+// onReconnectSession and onReconnectDisconnect.
+func (c *Client) runAttached(ctx context.Context, cs *Session, conn net.Conn) {
+	// closing conn unblocks the session's reader
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	defer c.endSession(cs)
+	defer func() { _ = cs.Close(ctx) }()
+	defer func() { _ = conn.Close() }()
+
+	c.LogDebug(conn.RemoteAddr(), nil, "attached")
+	c.setState(StateConnected, nil)
+
+	if fn := c.getOnConnect(); fn != nil {
+		if err := fn(ctx, cs); err != nil {
+			c.LogError(conn.RemoteAddr(), err, nil, "OnConnect")
+			return
+		}
+	}
+
+	c.replayStored()
+
+	_ = cs.Wait()
+	c.setState(StateDisconnected, nil)
+
+	if fn := c.getOnDisconnect(); fn != nil {
+		_ = fn(ctx)
+	}
+}
+
+// newAttachedSession creates a [Session] exchanging messages over conn
+// directly. Without a reconnect.Client behind it, deadlines are left to
+// the connection.
+func newAttachedSession(ctx context.Context, c *Client, conn net.Conn) *Session {
+	cs := newClientSession(ctx, c, c.queueSize, conn)
+	cs.rc = nil
+	cs.ss.Conn = conn
+	cs.ss.SetReadDeadline = nil
+	cs.ss.SetWriteDeadline = nil
+	cs.ss.UnsetReadDeadline = nil
+	cs.ss.UnsetWriteDeadline = nil
+	return cs
+}
@@ -0,0 +1,120 @@
+package client
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"darvaza.org/core"
+	"darvaza.org/x/net/reconnect"
+)
+
+const (
+	// DefaultBackoffInitial is the delay before the first attempt to
+	// reconnect when [ExponentialBackoff].Initial is not set.
+	DefaultBackoffInitial = time.Second
+	// DefaultBackoffMax is the longest delay between attempts to
+	// reconnect when [ExponentialBackoff].Max is not set.
+	DefaultBackoffMax = 30 * time.Second
+	// DefaultBackoffMultiplier is how much the delay grows after every
+	// attempt when [ExponentialBackoff].Multiplier is not set.
+	DefaultBackoffMultiplier = 2.0
+)
+
+// BackoffPolicy decides how long a [Client] waits before every attempt to
+// reconnect, see Config.Backoff. Implementations must be safe for
+// concurrent use when shared by several clients.
+type BackoffPolicy interface {
+	// Delay returns how long to wait before the given attempt, counted
+	// from 1 since the last connection made, or false to give up.
+	Delay(attempt int) (time.Duration, bool)
+}
+
+var _ BackoffPolicy = ExponentialBackoff{}
+
+// ExponentialBackoff is a [BackoffPolicy] multiplying the delay after
+// every attempt, up to Max, so a server that's down isn't hammered.
+//
+// Jitter, between 0 and 1, is the fraction of every delay that is random:
+// with 0.5 the delay falls anywhere between half and the whole of it.
+// Fleets of clients should use some, so they don't reconnect in lockstep
+// when the server comes back after an outage.
+//
+// MaxAttempts, when positive, gives up after that many attempts in a row
+// fail to connect.
+type ExponentialBackoff struct {
+	Initial     time.Duration
+	Max         time.Duration
+	Multiplier  float64
+	Jitter      float64
+	MaxAttempts int
+}
+
+// Delay implements [BackoffPolicy].
+func (b ExponentialBackoff) Delay(attempt int) (time.Duration, bool) {
+	if b.MaxAttempts > 0 && attempt > b.MaxAttempts {
+		return 0, false
+	}
+
+	initial, limit, multiplier := b.get()
+	d := float64(initial) * math.Pow(multiplier, float64(max(attempt, 1)-1))
+	d = min(d, float64(limit))
+	if jitter := min(b.Jitter, 1); jitter > 0 {
+		d -= d * jitter * rand.Float64()
+	}
+	return time.Duration(d), true
+}
+
+// get returns the initial delay, the longest delay and the multiplier,
+// defaults applied.
+func (b ExponentialBackoff) get() (initial, limit time.Duration, multiplier float64) {
+	initial, limit, multiplier = b.Initial, b.Max, b.Multiplier
+	if initial <= 0 {
+		initial = DefaultBackoffInitial
+	}
+	if limit <= 0 {
+		limit = DefaultBackoffMax
+	}
+	if multiplier < 1 {
+		multiplier = DefaultBackoffMultiplier
+	}
+	return initial, limit, multiplier
+}
+
+// newReconnectWaiter returns the [reconnect.Waiter] of the reconnect
+// loop, following Config.Backoff, or calling wait without one.
+func (c *Client) newReconnectWaiter(wait reconnect.Waiter) reconnect.Waiter {
+	return func(ctx context.Context) error {
+		attempt := int(c.attempts.Add(1))
+		start := time.Now()
+
+		err := c.waitReconnect(ctx, attempt, wait)
+		if err == nil && c.metrics != nil {
+			c.metrics.ObserveReconnectAttempt(attempt, time.Since(start))
+		}
+		return err
+	}
+}
+
+// waitReconnect waits before the given attempt to reconnect.
+func (c *Client) waitReconnect(ctx context.Context, attempt int, wait reconnect.Waiter) error {
+	if c.backoff == nil {
+		return wait(ctx)
+	}
+
+	d, ok := c.backoff.Delay(attempt)
+	if !ok {
+		return core.QuietWrap(ErrTooManyAttempts, "%d attempts", attempt-1)
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
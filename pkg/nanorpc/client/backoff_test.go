@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+)
+
+var _ core.TestCase = backoffTestCase{}
+
+type backoffTestCase struct {
+	name    string
+	policy  ExponentialBackoff
+	attempt int
+	want    time.Duration
+	ok      bool
+}
+
+func (tc backoffTestCase) Name() string { return tc.name }
+
+func (tc backoffTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	d, ok := tc.policy.Delay(tc.attempt)
+	core.AssertEqual(t, tc.ok, ok, "ok")
+	core.AssertEqual(t, tc.want, d, "delay")
+}
+
+func newBackoffTestCase(name string, policy ExponentialBackoff, attempt int,
+	want time.Duration, ok bool) backoffTestCase {
+	return backoffTestCase{name: name, policy: policy, attempt: attempt, want: want, ok: ok}
+}
+
+func backoffTestCases() []backoffTestCase {
+	limited := ExponentialBackoff{Initial: 100 * time.Millisecond, Max: time.Second, MaxAttempts: 5}
+	return []backoffTestCase{
+		newBackoffTestCase("defaults", ExponentialBackoff{}, 1, DefaultBackoffInitial, true),
+		newBackoffTestCase("doubles", ExponentialBackoff{}, 3, 4*DefaultBackoffInitial, true),
+		newBackoffTestCase("capped", ExponentialBackoff{}, 10, DefaultBackoffMax, true),
+		newBackoffTestCase("multiplier", ExponentialBackoff{Multiplier: 3}, 3, 9*DefaultBackoffInitial, true),
+		newBackoffTestCase("last attempt", limited, 5, time.Second, true),
+		newBackoffTestCase("too many attempts", limited, 6, 0, false),
+	}
+}
+
+func TestExponentialBackoff_Delay(t *testing.T) {
+	core.RunTestCases(t, backoffTestCases())
+}
+
+func TestExponentialBackoff_Delay_jitter(t *testing.T) {
+	b := ExponentialBackoff{Initial: time.Second, Jitter: 0.5}
+	for range 100 {
+		d, _ := b.Delay(1)
+		core.AssertTrue(t, d >= 500*time.Millisecond && d <= time.Second, "delay %v within jitter", d)
+	}
+}
+
+// TestClient_newReconnectWaiter verifies attempts are counted, reported,
+// and limited by the backoff policy.
+func TestClient_newReconnectWaiter(t *testing.T) {
+	c := newClientForTest(t)
+	sink := new(recordingSink)
+	c.metrics = sink
+	c.backoff = ExponentialBackoff{Initial: time.Millisecond, MaxAttempts: 2}
+
+	wait := c.newReconnectWaiter(nil)
+	ctx := context.Background()
+	core.AssertNoError(t, wait(ctx), "first attempt")
+	core.AssertNoError(t, wait(ctx), "second attempt")
+	core.AssertErrorIs(t, wait(ctx), ErrTooManyAttempts, "third attempt")
+	core.AssertSliceEqual(t, []int{1, 2}, sink.snapshot().attempts, "attempts")
+
+	// reset on success
+	c.attempts.Store(0)
+	core.AssertNoError(t, wait(ctx), "attempt after reconnecting")
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// Call makes a request and blocks until its response is decoded into resp,
+// ctx is done, or the RequestTimeout of the [Config] passes. A nil resp
+// discards the response data, and a response without data resets resp, as
+// that's how an empty message is encoded.
+//
+// A response with an error status returns a [nanorpc.Error], with its
+// status, message and detail, which [nanorpc.IsNotFound],
+// [nanorpc.IsNotAuthorized] and [errors.As] classify.
+//
+// Giving up returns ctx.Err(), or [context.DeadlineExceeded] on
+// RequestTimeout, dropping the request from the queue, and the session
+// ending before the response arrives returns [nanorpc.ErrNoResponse].
+func (c *Client) Call(ctx context.Context, path string, req, resp proto.Message) error {
+	if c == nil {
+		return core.ErrNilReceiver
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	ch := make(chan error, 1)
+	cb := func(ctx context.Context, _ int32, res *nanorpc.NanoRPCResponse) error {
+		ch <- callResponseError(ctx, res, resp)
+		return nil
+	}
+
+	if _, err := c.RequestContext(ctx, path, req, cb); err != nil {
+		return err
+	}
+
+	// the callback is told when ctx is done too, once dequeued
+	err := <-ch
+	if nanorpc.IsNoResponse(err) && ctx.Err() != nil {
+		// given up on by ctx rather than the session
+		return ctx.Err()
+	}
+	return err
+}
+
+// callResponseError decodes the response to a [Client.Call] into resp,
+// returning why the call failed.
+func callResponseError(ctx context.Context, res *nanorpc.NanoRPCResponse, resp proto.Message) error {
+	switch {
+	case res == nil && errors.Is(ctx.Err(), context.DeadlineExceeded):
+		// expired
+		return ctx.Err()
+	case res == nil:
+		return nanorpc.ErrNoResponse
+	case core.IsNil(resp):
+		return nanorpc.ResponseAsError(res)
+	}
+
+	_, present, err := nanorpc.DecodeResponseData(res, resp)
+	if err == nil && !present {
+		proto.Reset(resp)
+	}
+	return err
+}
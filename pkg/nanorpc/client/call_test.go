@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// goCall runs a [Client.Call] decoding into resp, reporting its result.
+func goCall(ctx context.Context, c *Client, resp proto.Message) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Call(ctx, "/echo", nil, resp)
+	}()
+	return done
+}
+
+func TestClient_Call(t *testing.T) {
+	c, srv := newConnectedSession(t)
+
+	// decoded
+	var out nanorpc.NanoRPCResponse
+	done := goCall(context.Background(), c, &out)
+	req := srv.Recv()
+	core.AssertEqual(t, reqRequest, req.RequestType, "request_type")
+
+	data, err := proto.Marshal(&nanorpc.NanoRPCResponse{ResponseMessage: "hi"})
+	core.AssertMustNoError(t, err, "Marshal")
+	res := newResponse(req.RequestId, respResponse, statusOK)
+	res.Data = data
+	srv.Reply(res)
+	core.AssertMustNoError(t, <-done, "Call")
+	core.AssertEqual(t, "hi", out.ResponseMessage, "decoded")
+
+	// no data resets out
+	done = goCall(context.Background(), c, &out)
+	req = srv.Recv()
+	srv.Reply(newResponse(req.RequestId, respResponse, statusOK))
+	core.AssertMustNoError(t, <-done, "Call empty")
+	core.AssertEqual(t, "", out.ResponseMessage, "reset")
+
+	// error status
+	done = goCall(context.Background(), c, nil)
+	req = srv.Recv()
+	res = newResponse(req.RequestId, respResponse, statusNotFound)
+	res.ResponseMessage = "no sensor 7"
+	res.Data = []byte("7")
+	srv.Reply(res)
+	err = <-done
+	core.AssertTrue(t, nanorpc.IsNotFound(err), "IsNotFound: %v", err)
+
+	var e *nanorpc.Error
+	core.AssertMustTrue(t, errors.As(err, &e), "Error")
+	core.AssertEqual(t, "no sensor 7", e.Msg, "message")
+	core.AssertEqual(t, "7", string(e.Detail), "detail")
+}
+
+func TestClient_Call_cancelled(t *testing.T) {
+	c, srv := newConnectedSession(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := goCall(ctx, c, nil)
+	srv.Recv()
+
+	cancel()
+	core.AssertErrorIs(t, <-done, context.Canceled, "Call")
+	assertQueueEmpty(t, c)
+}
+
+func TestClient_Call_timeout(t *testing.T) {
+	c, srv := newConnectedSession(t)
+	c.requestTimeout = testRequestTimeout
+
+	done := goCall(context.Background(), c, nil)
+	srv.Recv()
+
+	core.AssertErrorIs(t, <-done, context.DeadlineExceeded, "Call")
+	assertQueueEmpty(t, c)
+}
+
+func TestClient_Call_metadata(t *testing.T) {
+	c, srv := newConnectedSession(t)
+
+	ctx := WithMetadata(context.Background(), "authorization", "Bearer x")
+	ctx = WithMetadata(ctx, "trace-id", "42")
+	done := goCall(ctx, c, nil)
+	req := srv.Recv()
+
+	md := nanorpc.Metadata(req.GetMetadata())
+	token, _ := md.Get("authorization")
+	core.AssertEqual(t, "Bearer x", token, "authorization")
+	trace, _ := md.Get("trace-id")
+	core.AssertEqual(t, "42", trace, "trace-id")
+	core.AssertEqual(t, 2, len(MetadataFromContext(ctx)), "context")
+
+	srv.Reply(newResponse(req.RequestId, respResponse, statusOK))
+	core.AssertMustNoError(t, <-done, "Call")
+}
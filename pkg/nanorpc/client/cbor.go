@@ -0,0 +1,44 @@
+package client
+
+import (
+	"context"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// RequestCBOR enqueues a NanoRPC request carrying v encoded as CBOR, or no
+// data if v is nil, converting path as [Client.Request] does. The request
+// declares its content type, see [Client.RequestEncoded].
+func (c *Client) RequestCBOR(path string, v any, cb RequestCallback) (int32, error) {
+	return c.RequestEncoded(path, nanorpc.ContentTypeCBOR, v, cb)
+}
+
+// GetResponseCBOR makes a [Client.RequestCBOR] and waits for the response,
+// decoding its CBOR payload into out.
+func (c *Client) GetResponseCBOR(ctx context.Context, path string, req, out any) error {
+	if c == nil {
+		return core.ErrNilReceiver
+	}
+	if core.IsNil(out) {
+		return ErrMissingOut
+	}
+
+	ch := make(chan error, 1)
+	cb := func(_ context.Context, _ int32, res *nanorpc.NanoRPCResponse) error {
+		defer close(ch)
+
+		present, err := nanorpc.DecodeResponseCBOR(res, out)
+		if err == nil && !present {
+			err = nanorpc.ErrNoResponse
+		}
+		ch <- err
+		return nil
+	}
+
+	if _, err := c.RequestCBOR(path, req, cb); err != nil {
+		return err
+	}
+	return waitGetResponse(ctx, ch)
+}
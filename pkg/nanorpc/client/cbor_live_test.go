@@ -0,0 +1,88 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/cbor"
+)
+
+type liveReading struct {
+	Sensor string  `cbor:"sensor"`
+	Value  float64 `cbor:"value"`
+}
+
+// TestLiveClient_GetResponseCBOR verifies requests carry their value as
+// CBOR, and the CBOR response is decoded into out.
+func TestLiveClient_GetResponseCBOR(t *testing.T) {
+	f := newLiveFixture(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), liveTimeout)
+	defer cancel()
+
+	var out liveReading
+	done := make(chan error, 1)
+	go func() {
+		done <- f.c.GetResponseCBOR(ctx, "/sensors/read", liveReading{Sensor: "temp"}, &out)
+	}()
+
+	req := f.conn.Recv()
+	var in liveReading
+	present, err := nanorpc.DecodeRequestCBOR(req, &in)
+	core.AssertMustNoError(t, err, "DecodeRequestCBOR")
+	core.AssertMustTrue(t, present, "request data")
+	core.AssertEqual(t, "temp", in.Sensor, "sensor")
+	core.AssertEqual(t, nanorpc.ContentTypeCBOR, nanorpc.Metadata(req.Metadata).ContentType(), "content type")
+
+	in.Value = 21.5
+	res := newLiveResponse(req.RequestId, nanorpc.NanoRPCResponse_TYPE_RESPONSE, nanorpc.NanoRPCResponse_STATUS_OK)
+	res.Data, err = cbor.Marshal(in)
+	core.AssertMustNoError(t, err, "Marshal")
+	f.conn.Reply(res)
+
+	core.AssertMustNoError(t, <-done, "GetResponseCBOR")
+	core.AssertEqual(t, in, out, "response")
+}
+
+// TestLiveClient_GetResponseEncoded verifies requests declare their content
+// type, and responses are decoded by the one they declare or, if none,
+// that of the request.
+func TestLiveClient_GetResponseEncoded(t *testing.T) {
+	f := newLiveFixture(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), liveTimeout)
+	defer cancel()
+
+	for _, declared := range []string{"", nanorpc.ContentTypeCBOR} {
+		var out liveReading
+		done := make(chan error, 1)
+		go func() {
+			done <- f.c.GetResponseEncoded(ctx, "/sensors/read", nanorpc.ContentTypeJSON,
+				liveReading{Sensor: "temp"}, &out)
+		}()
+
+		req := f.conn.Recv()
+		var in map[string]any
+		present, err := nanorpc.DecodeRequestContent(req, &in)
+		core.AssertMustNoError(t, err, "DecodeRequestContent")
+		core.AssertMustTrue(t, present, "request data")
+		core.AssertEqual(t, "temp", in["Sensor"], "sensor")
+
+		res := newLiveResponse(req.RequestId, nanorpc.NanoRPCResponse_TYPE_RESPONSE, nanorpc.NanoRPCResponse_STATUS_OK)
+		reading := liveReading{Sensor: "temp", Value: 21.5}
+		ct := nanorpc.ContentTypeJSON
+		if declared != "" {
+			ct = declared
+			res.Metadata = nanorpc.NewMetadata(nanorpc.MetadataContentType, declared)
+		}
+		res.Data, err = nanorpc.Marshal(ct, reading)
+		core.AssertMustNoError(t, err, "Marshal")
+		f.conn.Reply(res)
+
+		core.AssertMustNoError(t, <-done, "GetResponseEncoded %q", declared)
+		core.AssertEqual(t, reading, out, "response %q", declared)
+	}
+}
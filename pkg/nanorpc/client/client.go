@@ -3,7 +3,9 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"darvaza.org/core"
@@ -21,18 +23,52 @@ type Client struct {
 	rc           *reconnect.Client
 	cs           *Session
 	connected    chan struct{}
+	errs         chan error
+	states       chan StateEvent
 	reqCounter   *RequestCounter
 	hc           *nanorpc.HashCache
 	getPathOneOf func(string) nanorpc.PathOneOf
 	logger       slog.Logger
+	tlsConfig    *tls.Config
+	outbox       *outbox
+	will         atomic.Pointer[lastWill]
+	metrics      MetricsSink
+	backoff      BackoffPolicy
+	codec        nanorpc.Codec
+	wireLog      *utils.WireLog
+	stats        clientStats
+
+	requestInterceptors  []RequestInterceptor
+	responseInterceptors []ResponseInterceptor
+
+	callOnConnect     func(context.Context, reconnect.WorkGroup) error
+	callOnDisconnect  func(context.Context) error
+	callOnError       func(context.Context, error) error
+	callOnStateChange func(StateEvent)
+
+	idleReadTimeout     time.Duration
+	requestTimeout      time.Duration
+	keepAlive           time.Duration
+	connects            atomic.Uint64
+	attempts            atomic.Uint64
+	maxInflight         int
+	maxMessageSize      int
+	compressThreshold   int
+	inflightPolicy      InflightPolicy
+	state               State
+	mu                  sync.Mutex
+	queueSize           uint
+	missedPongThreshold uint32
+	measureEncoding     bool
+	handshake           bool
+}
 
-	callOnConnect    func(context.Context, reconnect.WorkGroup) error
-	callOnDisconnect func(context.Context) error
-	callOnError      func(context.Context, error) error
-
-	idleReadTimeout time.Duration
-	mu              sync.Mutex
-	queueSize       uint
+// getMaxMessageSize returns the largest response sessions read.
+func (c *Client) getMaxMessageSize() int {
+	if c.maxMessageSize > 0 {
+		return c.maxMessageSize
+	}
+	return nanorpc.DefaultMaxMessageSize
 }
 
 func (c *Client) getOnConnect() func(context.Context, reconnect.WorkGroup) error {
@@ -58,6 +94,10 @@ func (c *Client) getOnError() func(context.Context, error) error {
 // New creates a new [Client] using given [Config].
 // If Config.HashCache is nil, the global package-level hashCache will be used.
 func (cfg *Config) New() (*Client, error) {
+	if cfg == nil {
+		return nil, core.ErrNilReceiver
+	}
+
 	var c = new(Client)
 
 	ro, err := cfg.Export()
@@ -83,20 +123,42 @@ func (c *Client) init(cfg *Config, rc *reconnect.Client) error {
 		return core.Wrap(err, "RequestCounter")
 	}
 
+	tlsConfig, err := cfg.ExportTLS()
+	if err != nil {
+		return err
+	}
+
 	c.WorkGroup = rc
 	c.rc = rc
 
 	c.connected = make(chan struct{})
+	c.errs = make(chan error, max(cfg.ErrorQueueSize, 1))
+	c.states = make(chan StateEvent, max(cfg.StateQueueSize, 1))
 	c.queueSize = cfg.QueueSize
 	c.reqCounter = reqCounter
+	c.tlsConfig = tlsConfig
 	c.idleReadTimeout = cfg.IdleTimeout
+	c.requestTimeout = cfg.RequestTimeout
+	c.keepAlive = cfg.KeepAlive
+	c.missedPongThreshold = uint32(cfg.MissedPongThreshold)
+	c.measureEncoding = cfg.MeasureEncoding
+	c.initHandshake(cfg)
+	c.maxInflight = int(cfg.MaxInflight)
+	c.maxMessageSize = int(cfg.MaxMessageSize)
+	c.compressThreshold = int(cfg.CompressionThreshold)
+	c.codec = cfg.Codec
+	c.inflightPolicy = cfg.InflightPolicy
 
 	c.hc = cfg.getHashCache()
 	c.getPathOneOf = cfg.newGetPathOneOf(c.hc)
+	if err := cfg.registerPaths(c.hc); err != nil {
+		return err
+	}
 
-	c.callOnConnect = cfg.OnConnect
-	c.callOnDisconnect = cfg.OnDisconnect
-	c.callOnError = cfg.OnError
+	c.initHooks(cfg)
+	if err := c.initOutbox(cfg); err != nil {
+		return err
+	}
 
 	// Set logger from config, add component field if provided
 	c.logger = cfg.Logger
@@ -107,6 +169,23 @@ func (c *Client) init(cfg *Config, rc *reconnect.Client) error {
 	return nil
 }
 
+// initHooks stores the event callbacks, interceptors, metrics sink and
+// backoff policy of the [Config].
+func (c *Client) initHooks(cfg *Config) {
+	c.callOnConnect = cfg.OnConnect
+	c.callOnDisconnect = cfg.OnDisconnect
+	c.callOnError = cfg.OnError
+	c.callOnStateChange = cfg.OnStateChange
+	if !core.IsNil(cfg.MetricsSink) {
+		c.metrics = cfg.MetricsSink
+	}
+	if !core.IsNil(cfg.Backoff) {
+		c.backoff = cfg.Backoff
+	}
+	c.requestInterceptors, c.responseInterceptors = cfg.exportInterceptors()
+	c.wireLog = cfg.WireLog
+}
+
 // NewClient creates a new [Client] with default options.
 // Uses the global package-level hashCache for path hashing.
 func NewClient(ctx context.Context, address string) (*Client, error) {
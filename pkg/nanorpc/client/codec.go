@@ -0,0 +1,52 @@
+package client
+
+import (
+	"google.golang.org/protobuf/proto"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// getCodec returns the framing of the messages of the [Client], see
+// Config.Codec.
+func (c *Client) getCodec() nanorpc.Codec {
+	if c.codec != nil {
+		return c.codec
+	}
+	return nanorpc.LengthPrefixCodec{}
+}
+
+// decodeResponse unframes, decodes and decompresses a response. Malformed
+// frames fail, never panic.
+func (c *Client) decodeResponse(frame []byte) (_ *nanorpc.NanoRPCResponse, err error) {
+	defer recoverDecode(&err)
+
+	msg, err := c.getCodec().Unframe(frame)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(nanorpc.NanoRPCResponse)
+	if err = proto.Unmarshal(msg, resp); err != nil {
+		return nil, err
+	}
+	return resp, nanorpc.DecompressResponse(resp, c.getMaxMessageSize())
+}
+
+// recoverDecode reports a panic decoding a malformed frame as
+// [nanorpc.ErrInvalidFrame], so it fails the connection and not the
+// program.
+func recoverDecode(err *error) {
+	if v := recover(); v != nil {
+		*err = core.QuietWrap(nanorpc.ErrInvalidFrame, "decode panicked: %v", v)
+	}
+}
+
+// frame returns a wrapped request framed by the codec of the [Client].
+func (c *Client) frame(wrapped []byte) ([]byte, error) {
+	if nanorpc.IsLengthPrefixed(c.codec) {
+		return wrapped, nil
+	}
+	return nanorpc.AppendFramed(c.codec, nil, wrapped)
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// TestClient_codec checks requests are written, and responses read, in
+// the frames of Config.Codec.
+func TestClient_codec(t *testing.T) {
+	cfg := Config{
+		Context: context.Background(),
+		Remote:  "127.0.0.1:1",
+		Codec:   nanorpc.SLIPCodec{},
+	}
+	c, err := cfg.New()
+	core.AssertMustNoError(t, err, "cfg.New")
+
+	for _, measure := range []bool{false, true} {
+		c.measureEncoding = measure
+
+		var buf bytes.Buffer
+		req := clientRequest{r: &nanorpc.NanoRPCRequest{RequestId: 3, RequestType: reqRequest}}
+		core.AssertMustNoError(t, c.encodeRequest(&buf, req), "encodeRequest")
+
+		frame := buf.Bytes()
+		core.AssertEqual(t, byte(0xC0), frame[len(frame)-1], "END")
+		msg, err := nanorpc.SLIPCodec{}.Unframe(frame[:len(frame)-1])
+		core.AssertMustNoError(t, err, "Unframe")
+
+		out := new(nanorpc.NanoRPCRequest)
+		core.AssertMustNoError(t, nanorpc.UnmarshalRequestTo(msg, out), "UnmarshalRequestTo")
+		core.AssertEqual(t, int32(3), out.RequestId, "request_id")
+	}
+
+	wrapped, err := nanorpc.EncodeResponse(&nanorpc.NanoRPCResponse{RequestId: 4}, nil)
+	core.AssertMustNoError(t, err, "EncodeResponse")
+	frame, err := nanorpc.AppendFramed(c.codec, nil, wrapped)
+	core.AssertMustNoError(t, err, "AppendFramed")
+
+	resp, err := c.decodeResponse(frame[:len(frame)-1])
+	core.AssertMustNoError(t, err, "decodeResponse")
+	core.AssertEqual(t, int32(4), resp.RequestId, "response")
+
+	_, err = c.decodeResponse([]byte{0xDB, 1})
+	core.AssertErrorIs(t, err, nanorpc.ErrInvalidFrame, "invalid")
+}
+
+// panicCodec is a [nanorpc.Codec] panicking on Unframe, standing for
+// decoding bugs triggered by malformed frames.
+type panicCodec struct{ nanorpc.LengthPrefixCodec }
+
+func (panicCodec) Unframe([]byte) ([]byte, error) { panic("malformed") }
+
+func TestClient_decodeResponseRecovers(t *testing.T) {
+	c := &Client{codec: panicCodec{}}
+	resp, err := c.decodeResponse([]byte{0})
+	core.AssertErrorIs(t, err, nanorpc.ErrInvalidFrame, "error")
+	core.AssertNil(t, resp, "response")
+}
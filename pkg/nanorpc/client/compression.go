@@ -0,0 +1,66 @@
+package client
+
+import (
+	"io"
+
+	"google.golang.org/protobuf/proto"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// onMarshal writes the frame of a request, compressing its data first as
+// Config.CompressionThreshold says, and logging it as Config.WireLog does.
+func (cs *Session) onMarshal(r clientRequest, w io.Writer) error {
+	cs.logRequest(r)
+	if threshold := cs.compressThreshold(); threshold > 0 {
+		if err := compressRequest(&r, threshold); err != nil {
+			return err
+		}
+	}
+	return cs.c.encodeRequest(w, r)
+}
+
+// compressThreshold returns the Config.CompressionThreshold of the
+// session, or zero if the server didn't negotiate compression.
+func (cs *Session) compressThreshold() int {
+	threshold := cs.c.compressThreshold
+	if threshold <= 0 {
+		return 0
+	}
+
+	remote := cs.hello.Load()
+	if remote == nil {
+		return 0
+	}
+
+	_, features := nanorpc.Negotiate(nanorpc.NewHello(clientFeatures), remote)
+	if !features.Has(nanorpc.FeatureCompression) {
+		return 0
+	}
+	return threshold
+}
+
+// compressRequest compresses the data of a request, marshalling its
+// payload first. The request queued is left as it is, so interceptors and
+// callbacks see it uncompressed.
+func compressRequest(r *clientRequest, threshold int) error {
+	data := r.r.GetData()
+	if r.d != nil {
+		b, err := proto.Marshal(r.d)
+		if err != nil {
+			return err
+		}
+		data = b
+	}
+	if len(data) < threshold {
+		return nil
+	}
+
+	req, _ := proto.Clone(r.r).(*nanorpc.NanoRPCRequest)
+	req.Data = data
+	if err := nanorpc.CompressRequest(req, threshold); err != nil {
+		return err
+	}
+	r.r, r.d = req, nil
+	return nil
+}
@@ -0,0 +1,54 @@
+package client_test
+
+import (
+	"bytes"
+	"testing"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/client"
+	"protomcp.org/nanorpc/pkg/nanorpc/mock/server"
+)
+
+// TestLiveClient_Compression verifies the client compresses requests once
+// the server negotiated compression, and decompresses responses.
+func TestLiveClient_Compression(t *testing.T) {
+	srv := server.New(t)
+	c := newLiveClient(t, srv, client.Config{Handshake: true, CompressionThreshold: 64})
+	core.AssertMustNoError(t, c.Connect(), "Connect")
+	conn := srv.Accept()
+
+	ping := conn.Recv()
+	hello, ok := nanorpc.RequestHello(ping)
+	core.AssertMustTrue(t, ok, "handshake sent")
+	core.AssertTrue(t, nanorpc.Features(hello.Features).Has(nanorpc.FeatureCompression), "compression")
+
+	data, err := proto.Marshal(nanorpc.NewHello(nanorpc.FeatureCompression))
+	core.AssertMustNoError(t, err, "Marshal")
+	res := newLiveResponse(ping.RequestId, nanorpc.NanoRPCResponse_TYPE_PONG, nanorpc.NanoRPCResponse_STATUS_OK)
+	res.Data = data
+	conn.Reply(res)
+	_, features := waitHandshake(t, c)
+	core.AssertMustTrue(t, features.Has(nanorpc.FeatureCompression), "negotiated")
+
+	payload := bytes.Repeat([]byte("sample "), 64)
+	events := make(chan cbEvent, 4)
+	id, err := c.Request("/echo", wrapperspb.Bytes(payload), liveRecordingCallback(events))
+	core.AssertMustNoError(t, err, "Request")
+
+	req := conn.Recv()
+	core.AssertMustTrue(t, req.Compressed, "request compressed")
+	core.AssertMustNoError(t, nanorpc.DecompressRequest(req, 0), "DecompressRequest")
+
+	res = newLiveResponse(id, nanorpc.NanoRPCResponse_TYPE_RESPONSE, nanorpc.NanoRPCResponse_STATUS_OK)
+	res.Data = req.Data
+	core.AssertMustNoError(t, nanorpc.CompressResponse(res, 64), "CompressResponse")
+	conn.Reply(res)
+
+	ev := mustRecvLiveEvent(t, events, "request")
+	core.AssertFalse(t, ev.resp.Compressed, "response decompressed")
+	core.AssertSliceEqual(t, req.Data, ev.resp.Data, "response data")
+}
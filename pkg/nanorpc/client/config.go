@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
 	"time"
 
 	"darvaza.org/core"
@@ -11,6 +12,7 @@ import (
 	"darvaza.org/x/net/reconnect"
 
 	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/utils"
 )
 
 // hashCache is the default hash cache for the client package.
@@ -19,28 +21,134 @@ import (
 var hashCache = new(nanorpc.HashCache)
 
 // Config describes how the [Client] will operate
+//
+// The connection is made over TLS when TLSConfig, any of the TLS file
+// paths or RequireTLS is set; see [Config.ExportTLS].
+//
+// RegisterPaths takes the RegisterPaths functions generated by
+// protoc-gen-go-nanorpc, called on the HashCache by [Config.New] so
+// responses to hashed paths resolve to their names from the start.
+//
+// RequestInterceptors and ResponseInterceptors are called, in order, with
+// every request sent and every response received; see
+// [RequestInterceptor] and [ResponseInterceptor].
+//
+// MetricsSink, when set, receives the in-flight requests, queue depth,
+// reconnections and attempts, ping round-trip times and per-path latency
+// of the [Client]; see [MetricsSink].
+//
+// Backoff, when set, decides how long to wait before every attempt to
+// reconnect instead of WaitReconnect, see [ExponentialBackoff]. Its attempt
+// count is reset by every connection made.
+//
+// RequestTimeout, when positive, bounds the wait for the response to every
+// request and ping with a callback, see [Client.RequestContext]. Zero
+// waits until the session ends.
+//
+// MeasureEncoding accounts the time spent marshalling every request and
+// the size of its frame, by path, in [Stats].
+//
+// QueueSize is how many requests wait to be written before more wait for
+// room, highest [Priority] first; those of [PriorityHigh] are queued
+// regardless. Zero queues one.
+//
+// ErrorQueueSize is how many background failures [Client.Errors] holds
+// before dropping the oldest, and StateQueueSize how many state changes
+// [Client.StateEvents] holds. OnStateChange, when set, is called with
+// every state change too, from the goroutine making it, so it must not
+// block.
+//
+// MaxInflight, when positive, caps the requests and pending subscriptions
+// awaiting their response, for servers that can only buffer a handful.
+// Requests beyond it wait or fail as InflightPolicy says; see
+// [InflightPolicy]. Zero doesn't limit them.
+//
+// MaxMessageSize is the largest response read, length prefix excluded.
+// A larger one ends the session with [nanorpc.ErrMessageTooLarge] before
+// it's buffered. Zero uses [nanorpc.DefaultMaxMessageSize].
+//
+// MissedPongThreshold, when positive, has the [Client] ping the server
+// every KeepAlive, besides using it for TCP keep-alives, and drop the
+// connection with [ErrMissedPongs] to reconnect once that many pings in a
+// row go unanswered; see [Client.Healthy]. Zero doesn't ping.
+//
+// Handshake has every session start with a TYPE_PING announcing the
+// protocol version and features of the [Client], so they can be
+// negotiated with the server; see [Client.Handshake]. Servers predating
+// the handshake answer it as a plain ping.
+//
+// Storage, when set, persists the requests of [Client.RequestStored] until
+// answered, replaying them on every connection. StorageDir, when Storage
+// isn't set, uses a [FileStorage] in that directory.
+//
+// WillPath, when set, is the last will of the [Client]: the server
+// publishes WillData on it when the session ends, so other subscribers
+// learn the [Client] went away. It's registered by the handshake of every
+// session, which it turns on; see [Client.ClearWill] to leave without it.
+//
+// CompressionThreshold, when positive, compresses the data of requests of
+// at least that many bytes, once the handshake negotiated compression
+// with the server. Compressed responses are decompressed regardless.
+//
+// Codec frames the messages on the connection, e.g. [nanorpc.COBSCodec]
+// or [nanorpc.SLIPCodec] to match a serial link, and must match the
+// server's. Nil uses the length prefix of NanoRPC.
+//
+// WireLog, when set, logs every request sent and response received at
+// debug level, decoded, as [utils.WireLog] says.
 type Config struct {
-	Context         context.Context
-	Logger          slog.Logger
-	WaitReconnect   reconnect.Waiter
-	HashCache       *nanorpc.HashCache
-	OnConnect       func(context.Context, reconnect.WorkGroup) error
-	OnDisconnect    func(context.Context) error
-	OnError         func(context.Context, error) error
-	Remote          string
-	DialTimeout     time.Duration `default:"2s"`
-	ReadTimeout     time.Duration `default:"2s"`
-	IdleTimeout     time.Duration `default:"10s"`
-	WriteTimeout    time.Duration `default:"2s"`
-	ReconnectDelay  time.Duration `default:"5s"`
-	KeepAlive       time.Duration `default:"5s"`
-	QueueSize       uint
-	AlwaysHashPaths bool
+	Context              context.Context
+	Logger               slog.Logger
+	WaitReconnect        reconnect.Waiter
+	HashCache            *nanorpc.HashCache
+	TLSConfig            *tls.Config
+	MetricsSink          MetricsSink
+	Backoff              BackoffPolicy
+	Storage              Storage
+	Codec                nanorpc.Codec
+	WireLog              *utils.WireLog
+	OnConnect            func(context.Context, reconnect.WorkGroup) error
+	OnDisconnect         func(context.Context) error
+	OnError              func(context.Context, error) error
+	OnStateChange        func(StateEvent)
+	Remote               string
+	TLSCertFile          string
+	TLSKeyFile           string
+	TLSCAFile            string
+	StorageDir           string
+	WillPath             string
+	WillData             []byte
+	RegisterPaths        []func(*nanorpc.HashCache) error
+	RequestInterceptors  []RequestInterceptor
+	ResponseInterceptors []ResponseInterceptor
+	DialTimeout          time.Duration `default:"2s"`
+	ReadTimeout          time.Duration `default:"2s"`
+	IdleTimeout          time.Duration `default:"10s"`
+	WriteTimeout         time.Duration `default:"2s"`
+	ReconnectDelay       time.Duration `default:"5s"`
+	KeepAlive            time.Duration `default:"5s"`
+	RequestTimeout       time.Duration
+	QueueSize            uint
+	ErrorQueueSize       uint `default:"16"`
+	StateQueueSize       uint `default:"16"`
+	MaxInflight          uint
+	MaxMessageSize       uint
+	MissedPongThreshold  uint
+	CompressionThreshold uint
+	InflightPolicy       InflightPolicy
+	AlwaysHashPaths      bool
+	MeasureEncoding      bool
+	RequireTLS           bool
+	Handshake            bool
 }
 
 // SetDefaults fills gaps in [Config].
 // If HashCache is nil, assigns the global package-level hashCache.
 func (cfg *Config) SetDefaults() error {
+	if cfg == nil {
+		return core.ErrNilReceiver
+	}
+
 	if err := config.Set(cfg); err != nil {
 		return err
 	}
@@ -67,6 +175,10 @@ func (cfg *Config) SetDefaults() error {
 
 // Export generates a [reconnect.Config]
 func (cfg *Config) Export() (*reconnect.Config, error) {
+	if cfg == nil {
+		return nil, core.ErrNilReceiver
+	}
+
 	// Validate remote address using reconnect package which supports both TCP and Unix sockets
 	if err := reconnect.ValidateRemote(cfg.Remote); err != nil {
 		return nil, core.Wrap(err, "Remote")
@@ -102,6 +214,19 @@ func (cfg *Config) getHashCache() *nanorpc.HashCache {
 	return hashCache
 }
 
+func (cfg *Config) registerPaths(hc *nanorpc.HashCache) error {
+	for _, fn := range cfg.RegisterPaths {
+		if fn == nil {
+			continue
+		}
+
+		if err := fn(hc); err != nil {
+			return core.Wrap(err, "RegisterPaths")
+		}
+	}
+	return nil
+}
+
 func (cfg *Config) newGetPathOneOf(hc *nanorpc.HashCache) func(string) nanorpc.PathOneOf {
 	if cfg.AlwaysHashPaths {
 		// use path_hash
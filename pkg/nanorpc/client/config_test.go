@@ -202,6 +202,38 @@ func TestClientConfig_getHashCache(t *testing.T) {
 	core.AssertEqual(t, customHC, hc, "hash_cache")
 }
 
+// TestClientConfig_RegisterPaths tests that generated RegisterPaths
+// functions are called on the client's HashCache by New.
+func TestClientConfig_RegisterPaths(t *testing.T) {
+	hc := &nanorpc.HashCache{}
+	register := func(hc *nanorpc.HashCache) error {
+		return hc.Register("/sensors/temperature")
+	}
+
+	c, err := (&Config{
+		Remote:        "localhost:8080",
+		HashCache:     hc,
+		RegisterPaths: []func(*nanorpc.HashCache) error{register, nil},
+	}).New()
+	core.AssertMustNoError(t, err, "New")
+	core.AssertEqual(t, hc, c.hc, "hash_cache")
+
+	hash, err := (&nanorpc.HashCache{}).Hash("/sensors/temperature")
+	core.AssertMustNoError(t, err, "hash")
+	path, ok := hc.Path(hash)
+	core.AssertTrue(t, ok, "registered")
+	core.AssertEqual(t, "/sensors/temperature", path, "path")
+
+	_, err = (&Config{
+		Remote:    "localhost:8080",
+		HashCache: &nanorpc.HashCache{},
+		RegisterPaths: []func(*nanorpc.HashCache) error{
+			func(*nanorpc.HashCache) error { return nanorpc.ErrHashCollision },
+		},
+	}).New()
+	core.AssertErrorIs(t, err, nanorpc.ErrHashCollision, "failed registration")
+}
+
 // GetPathOneOfTestCase represents a test case for newGetPathOneOf
 type GetPathOneOfTestCase struct {
 	hc       *nanorpc.HashCache
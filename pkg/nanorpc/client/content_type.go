@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// RequestEncoded enqueues a NanoRPC request carrying v encoded in the given
+// content type, see [nanorpc.Marshal], or no data if v is nil, converting
+// path as [Client.Request] does. Content types other than protobuf, the
+// default, are declared in the metadata of the request, so servers decode
+// it accordingly.
+func (c *Client) RequestEncoded(path, contentType string, v any, cb RequestCallback) (int32, error) {
+	if c == nil {
+		return 0, core.ErrNilReceiver
+	}
+
+	// assemble header
+	ct := nanorpc.ParseContentType(contentType)
+	m := &nanorpc.NanoRPCRequest{
+		RequestType: nanorpc.NanoRPCRequest_TYPE_REQUEST,
+		PathOneof:   c.getPathOneOf(path),
+	}
+	if ct != nanorpc.ContentTypeProtobuf {
+		m.Metadata = nanorpc.NewMetadata(nanorpc.MetadataContentType, ct)
+	}
+
+	if !core.IsNil(v) {
+		data, err := nanorpc.Marshal(ct, v)
+		if err != nil {
+			return 0, core.Wrapf(err, "failed to marshal %s request", ct)
+		}
+		m.Data = data
+	}
+
+	return c.enqueue(m, nil, cb)
+}
+
+// GetResponseEncoded makes a [Client.RequestEncoded] and waits for the
+// response, decoding its payload into out by the content type it declares
+// or, if none, that of the request.
+func (c *Client) GetResponseEncoded(ctx context.Context, path, contentType string, req, out any) error {
+	if c == nil {
+		return core.ErrNilReceiver
+	}
+	if core.IsNil(out) {
+		return ErrMissingOut
+	}
+
+	ch := make(chan error, 1)
+	cb := func(_ context.Context, _ int32, res *nanorpc.NanoRPCResponse) error {
+		defer close(ch)
+
+		present, err := decodeResponseAs(res, contentType, out)
+		if err == nil && !present {
+			err = nanorpc.ErrNoResponse
+		}
+		ch <- err
+		return nil
+	}
+
+	if _, err := c.RequestEncoded(path, contentType, req, cb); err != nil {
+		return err
+	}
+	return waitGetResponse(ctx, ch)
+}
+
+// decodeResponseAs decodes the payload of a response into out by the
+// content type it declares, or contentType if none, reporting whether
+// there was one.
+func decodeResponseAs(res *nanorpc.NanoRPCResponse, contentType string, out any) (bool, error) {
+	if err := nanorpc.ResponseAsError(res); err != nil {
+		return false, err
+	}
+
+	md := nanorpc.Metadata(res.Metadata)
+	if _, ok := md.Get(nanorpc.MetadataContentType); ok {
+		contentType = md.ContentType()
+	}
+
+	if len(res.Data) == 0 {
+		return false, nil
+	}
+	return true, nanorpc.Unmarshal(contentType, res.Data, out)
+}
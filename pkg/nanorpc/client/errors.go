@@ -1,6 +1,10 @@
 package client
 
-import "darvaza.org/core"
+import (
+	"errors"
+
+	"darvaza.org/core"
+)
 
 // Invalid-argument sentinels for the client package. Each wraps
 // [core.ErrInvalid], so a caller can match a specific cause or the whole
@@ -34,6 +38,12 @@ var (
 	// ErrMissingClient indicates a nil client was passed to a helper.
 	ErrMissingClient = core.QuietWrap(core.ErrInvalid, "client missing")
 
+	// ErrMissingRemotes indicates a [PoolConfig] without remotes.
+	ErrMissingRemotes = core.QuietWrap(core.ErrInvalid, "remotes missing")
+
+	// ErrMissingConn indicates a nil connection was passed to Attach.
+	ErrMissingConn = core.QuietWrap(core.ErrInvalid, "connection missing")
+
 	// ErrMissingOut indicates a nil out message was passed to a helper.
 	ErrMissingOut = core.QuietWrap(core.ErrInvalid, "out missing")
 
@@ -42,8 +52,33 @@ var (
 
 	// ErrNilOut indicates the newOut factory returned a nil message.
 	ErrNilOut = core.QuietWrap(core.ErrInvalid, "newOut returned nil")
+
+	// ErrMissingStorage indicates a request to store without a Storage
+	// configured, or a [FileStorage] without a directory.
+	ErrMissingStorage = core.QuietWrap(core.ErrInvalid, "storage missing")
+
+	// ErrInvalidTLSConfig indicates TLS settings that cannot be used to
+	// dial the server.
+	ErrInvalidTLSConfig = core.QuietWrap(core.ErrInvalid, "invalid TLS configuration")
 )
 
+// ErrTooManyInflight indicates a request was rejected because
+// Config.MaxInflight requests already await their response, see
+// [InflightFail].
+var ErrTooManyInflight = errors.New("too many requests in flight")
+
+// ErrTooManyAttempts indicates the [Client] gave up reconnecting after
+// the attempts allowed by its [BackoffPolicy].
+var ErrTooManyAttempts = errors.New("too many reconnection attempts")
+
+// ErrMissedPongs indicates a session was dropped because the server
+// stopped answering keep-alive pings, see Config.MissedPongThreshold.
+var ErrMissedPongs = errors.New("server stopped answering pings")
+
+// ErrNoHealthyClient indicates a [ClientPool] had no connected and healthy
+// client to send a request through.
+var ErrNoHealthyClient = errors.New("no healthy client")
+
 // IsInvalid reports whether err is an invalid-argument error. It matches
 // [core.ErrInvalid] — the base the package's sentinels wrap, and itself an
 // alias of [fs.ErrInvalid] / [os.ErrInvalid] — anywhere in the chain.
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// clientFeatures are the optional protocol features the [Client] announces
+// when it handshakes.
+const clientFeatures = nanorpc.FeatureStreaming | nanorpc.FeatureCompression |
+	nanorpc.FeatureExtendedStatus
+
+// Handshake returns the protocol version and the features both the
+// [Client] and the server support, once the server answered the handshake
+// of the current session; see Config.Handshake. Servers predating the
+// handshake give version 0 and no features.
+func (c *Client) Handshake() (version uint32, features nanorpc.Features, ok bool) {
+	if c == nil {
+		return 0, 0, false
+	}
+
+	cs, err := c.getSession()
+	if err != nil {
+		return 0, 0, false
+	}
+
+	remote := cs.hello.Load()
+	if remote == nil {
+		return 0, 0, false
+	}
+
+	version, features = nanorpc.Negotiate(nanorpc.NewHello(clientFeatures), remote)
+	return version, features, true
+}
+
+// sendHello sends the handshake of the session, if the [Config] asks for
+// it or has a last will to register.
+func (cs *Session) sendHello() error {
+	if !cs.c.handshake {
+		return nil
+	}
+
+	req := &nanorpc.NanoRPCRequest{
+		RequestType: nanorpc.NanoRPCRequest_TYPE_PING,
+	}
+	return cs.send(req, cs.c.newHello(), cs.onHello,
+		sendOptions{priority: PriorityHigh})
+}
+
+// onHello records the handshake of the server. Pongs without one come
+// from servers predating the handshake, recorded as version 0. A nil
+// response means the handshake was given up on.
+func (cs *Session) onHello(_ context.Context, _ int32, resp *nanorpc.NanoRPCResponse) error {
+	if resp == nil {
+		return nil
+	}
+
+	hello, ok := nanorpc.ResponseHello(resp)
+	if !ok {
+		hello = new(nanorpc.NanoRPCHello)
+	}
+	cs.hello.Store(hello)
+	return nil
+}
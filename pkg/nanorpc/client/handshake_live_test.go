@@ -0,0 +1,90 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/client"
+	"protomcp.org/nanorpc/pkg/nanorpc/mock/server"
+)
+
+// newHandshakeClient connects a client handshaking with srv, returning the
+// handshake it sent.
+func newHandshakeClient(t *testing.T, srv *server.Server) (*client.Client, *server.Conn,
+	*nanorpc.NanoRPCRequest) {
+	t.Helper()
+
+	c := newLiveClient(t, srv, client.Config{Handshake: true})
+	core.AssertMustNoError(t, c.Connect(), "Connect")
+	conn := srv.Accept()
+
+	req := conn.Recv()
+	core.AssertEqual(t, nanorpc.NanoRPCRequest_TYPE_PING, req.RequestType, "request_type")
+	return c, conn, req
+}
+
+// waitHandshake waits until the client records the answer to its
+// handshake.
+func waitHandshake(t *testing.T, c *client.Client) (uint32, nanorpc.Features) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), liveTimeout)
+	defer cancel()
+
+	for {
+		if version, features, ok := c.Handshake(); ok {
+			return version, features
+		}
+
+		select {
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for the handshake")
+			return 0, 0
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
+
+// TestLiveClient_Handshake verifies the client announces its version and
+// features, and negotiates them with the answer of the server.
+func TestLiveClient_Handshake(t *testing.T) {
+	srv := server.New(t)
+	c, conn, req := newHandshakeClient(t, srv)
+
+	hello, ok := nanorpc.RequestHello(req)
+	core.AssertMustTrue(t, ok, "handshake sent")
+	core.AssertEqual(t, uint32(nanorpc.ProtocolVersion), hello.Version, "version")
+	core.AssertTrue(t, nanorpc.Features(hello.Features).Has(nanorpc.FeatureStreaming), "streaming")
+
+	data, err := proto.Marshal(nanorpc.NewHello(nanorpc.FeatureStreaming | nanorpc.FeatureAuth))
+	core.AssertMustNoError(t, err, "Marshal")
+	res := newLiveResponse(req.RequestId, nanorpc.NanoRPCResponse_TYPE_PONG, nanorpc.NanoRPCResponse_STATUS_OK)
+	res.Data = data
+	conn.Reply(res)
+
+	version, features := waitHandshake(t, c)
+	core.AssertEqual(t, uint32(nanorpc.ProtocolVersion), version, "version")
+	core.AssertEqual(t, nanorpc.FeatureStreaming, features, "features")
+}
+
+// TestLiveClient_Handshake_legacy verifies a server answering the
+// handshake as a plain ping gives version 0 and no features.
+func TestLiveClient_Handshake_legacy(t *testing.T) {
+	srv := server.New(t)
+	c, conn, req := newHandshakeClient(t, srv)
+
+	_, _, ok := c.Handshake()
+	core.AssertFalse(t, ok, "Handshake before the answer")
+
+	conn.Reply(newLiveResponse(req.RequestId, nanorpc.NanoRPCResponse_TYPE_PONG,
+		nanorpc.NanoRPCResponse_STATUS_OK))
+
+	version, features := waitHandshake(t, c)
+	core.AssertEqual(t, uint32(0), version, "version")
+	core.AssertEqual(t, nanorpc.Features(0), features, "features")
+}
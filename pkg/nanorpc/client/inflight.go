@@ -0,0 +1,174 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"darvaza.org/core"
+	"darvaza.org/slog"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/utils"
+)
+
+// InflightPolicy decides what happens to a request sent while
+// Config.MaxInflight requests already await their response.
+type InflightPolicy int
+
+const (
+	// InflightWait holds the request back until one in flight is
+	// resolved, its context is done, or the session ends. This is the
+	// default.
+	InflightWait InflightPolicy = iota
+	// InflightFail rejects the request with [ErrTooManyInflight].
+	InflightFail
+)
+
+// inflightWarnInterval is how long every new request must find
+// Config.MaxInflight requests in flight before the [Client] warns the
+// limit is saturated, and how often it repeats the warning.
+const inflightWarnInterval = 10 * time.Second
+
+// inflightGate holds back the requests exceeding Config.MaxInflight.
+// Guarded by the cs.mu of its [Session].
+type inflightGate struct {
+	// freed is closed, and cleared, whenever the queue shrinks or the
+	// session ends.
+	freed chan struct{}
+	// saturated is when requests started finding the limit reached.
+	saturated time.Time
+	// warned is when saturation was last logged.
+	warned time.Time
+	closed bool
+}
+
+// countsInflight tells if a queue entry awaits its response, and so
+// counts against Config.MaxInflight. Pings don't, so liveness checks
+// aren't held back.
+func countsInflight(x clientRequestQueue) bool {
+	return !x.Acknowledged && x.RequestType != nanorpc.NanoRPCRequest_TYPE_PING
+}
+
+// unsafeInflight counts the queue entries in flight. cs.mu must be held.
+func (cs *Session) unsafeInflight() int {
+	var n int
+	for _, x := range cs.cb {
+		if countsInflight(x) {
+			n++
+		}
+	}
+	return n
+}
+
+// registerLimited queues x once fewer than Config.MaxInflight requests are
+// in flight, waiting or failing as the InflightPolicy says. The wait is
+// bounded by the expiry of x, or ctx when it doesn't expire.
+func (cs *Session) registerLimited(ctx context.Context, x clientRequestQueue) error {
+	if x.Expiry != nil {
+		ctx = x.Expiry.ctx
+	}
+
+	for retry := false; ; retry = true {
+		freed, err := cs.tryRegister(x, retry)
+		if freed == nil || err != nil {
+			return err
+		}
+
+		if err := waitInflight(ctx, freed); err != nil {
+			return err
+		}
+	}
+}
+
+// tryRegister queues x unless the limit is reached, returning then the
+// channel to wait on before trying again, or the error to fail with.
+// Requests held back are accounted once, on their first try.
+func (cs *Session) tryRegister(x clientRequestQueue, retry bool) (<-chan struct{}, error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	limit := cs.c.getMaxInflight()
+	switch {
+	case cs.gate.closed:
+		return nil, nanorpc.ErrSessionClosed
+	case limit == 0 || !countsInflight(x) || cs.unsafeInflight() < limit:
+		cs.gate.saturated = time.Time{}
+		cs.unsafeRegisterCallback(x)
+		return nil, nil
+	}
+
+	if !retry {
+		cs.c.stats.observeSaturated()
+		cs.unsafeCheckSaturation(limit)
+	}
+	if cs.c.inflightPolicy == InflightFail {
+		return nil, core.QuietWrap(ErrTooManyInflight, "%d requests in flight", limit)
+	}
+
+	if cs.gate.freed == nil {
+		cs.gate.freed = make(chan struct{})
+	}
+	return cs.gate.freed, nil
+}
+
+func waitInflight(ctx context.Context, freed <-chan struct{}) error {
+	if ctx == nil {
+		<-freed
+		return nil
+	}
+
+	select {
+	case <-freed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// unsafeCheckSaturation warns when the limit has been reached by every
+// request for inflightWarnInterval. cs.mu must be held.
+func (cs *Session) unsafeCheckSaturation(limit int) {
+	now := time.Now()
+	g := &cs.gate
+	switch {
+	case g.saturated.IsZero():
+		g.saturated = now
+	case now.Sub(g.saturated) >= inflightWarnInterval && now.Sub(g.warned) >= inflightWarnInterval:
+		g.warned = now
+		cs.LogWarn(nil, slog.Fields{
+			utils.FieldMaxInflight: limit,
+		}, "requests in flight saturated for %s", now.Sub(g.saturated).Round(time.Second))
+	}
+}
+
+// unsafeReleaseInflight wakes the requests held back by the limit, and
+// fails them from now on once the session is closed. cs.mu must be held.
+func (cs *Session) unsafeReleaseInflight(closed bool) {
+	if closed {
+		cs.gate.closed = true
+	}
+	if cs.gate.freed != nil {
+		close(cs.gate.freed)
+		cs.gate.freed = nil
+	}
+}
+
+func (c *Client) getMaxInflight() int {
+	if c == nil {
+		return 0
+	}
+	return c.maxInflight
+}
+
+// inflight counts the requests of the current session in flight.
+func (c *Client) inflight() int {
+	cs, err := c.getSession()
+	if err != nil || cs == nil {
+		return 0
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	return cs.unsafeInflight()
+}
@@ -0,0 +1,122 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// requestAsync sends a request in the background, reporting its error.
+func requestAsync(c *Client, cb RequestCallback) <-chan error {
+	errs := make(chan error, 1)
+	go func() {
+		_, err := c.Request("/echo", nil, cb)
+		errs <- err
+	}()
+	return errs
+}
+
+func assertHeldBack(t *testing.T, errs <-chan error, name string) {
+	t.Helper()
+
+	select {
+	case err := <-errs:
+		t.Fatalf("%s: not held back: %v", name, err)
+	case <-time.After(testRequestTimeout):
+	}
+}
+
+func mustRecvError(t *testing.T, errs <-chan error, name string) error {
+	t.Helper()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-time.After(recvTimeout):
+		t.Fatalf("%s: timed out", name)
+		return nil
+	}
+}
+
+func TestClient_MaxInflight_fail(t *testing.T) {
+	c, srv := newConnectedSession(t)
+	c.maxInflight = 1
+	c.inflightPolicy = InflightFail
+	events := make(chan cbEvent, 2)
+
+	id, err := c.Request("/echo", nil, recordingCallback(events))
+	core.AssertMustNoError(t, err, "first")
+	srv.Recv()
+
+	_, err = c.Request("/echo", nil, recordingCallback(events))
+	core.AssertErrorIs(t, err, ErrTooManyInflight, "second")
+
+	stats := c.Stats()
+	core.AssertEqual(t, 1, stats.Inflight, "inflight")
+	core.AssertEqual(t, uint64(1), stats.Saturated, "saturated")
+
+	srv.Reply(newResponse(id, respResponse, statusOK))
+	mustRecvEvent(t, events, "first")
+	core.AssertEqual(t, 0, c.Stats().Inflight, "inflight after response")
+
+	_, err = c.Request("/echo", nil, recordingCallback(events))
+	core.AssertMustNoError(t, err, "third")
+	srv.Recv()
+}
+
+func TestClient_MaxInflight_wait(t *testing.T) {
+	c, srv := newConnectedSession(t)
+	c.maxInflight = 1
+	events := make(chan cbEvent, 4)
+
+	id, err := c.Request("/echo", nil, recordingCallback(events))
+	core.AssertMustNoError(t, err, "first")
+	srv.Recv()
+
+	// held back until the first is answered
+	errs := requestAsync(c, recordingCallback(events))
+	assertHeldBack(t, errs, "second")
+	srv.Reply(newResponse(id, respResponse, statusOK))
+	core.AssertEqual(t, id, mustRecvEvent(t, events, "first").id, "first id")
+	core.AssertNoError(t, mustRecvError(t, errs, "second"), "second")
+	second := srv.Recv()
+	core.AssertEqual(t, uint64(1), c.Stats().Saturated, "saturated")
+
+	// the context of the request bounds the wait
+	ctx, cancel := context.WithTimeout(context.Background(), testRequestTimeout)
+	defer cancel()
+	_, err = c.RequestContext(ctx, "/echo", nil, recordingCallback(events))
+	core.AssertErrorIs(t, err, context.DeadlineExceeded, "expired wait")
+
+	// and so does the session
+	errs = requestAsync(c, recordingCallback(events))
+	assertHeldBack(t, errs, "third")
+	cs, err := c.getSession()
+	core.AssertMustNoError(t, err, "getSession")
+	core.AssertNoError(t, cs.Close(context.Background()), "Close")
+	core.AssertErrorIs(t, mustRecvError(t, errs, "third"), nanorpc.ErrSessionClosed, "closed")
+
+	ev := mustRecvEvent(t, events, "second closed")
+	core.AssertEqual(t, second.RequestId, ev.id, "second id")
+	core.AssertNil(t, ev.resp, "second response")
+}
+
+func TestCountsInflight(t *testing.T) {
+	core.AssertTrue(t, countsInflight(clientRequestQueue{
+		RequestType: nanorpc.NanoRPCRequest_TYPE_REQUEST,
+	}), "request")
+	core.AssertTrue(t, countsInflight(clientRequestQueue{
+		RequestType: nanorpc.NanoRPCRequest_TYPE_SUBSCRIBE,
+	}), "pending subscription")
+	core.AssertFalse(t, countsInflight(clientRequestQueue{
+		RequestType:  nanorpc.NanoRPCRequest_TYPE_SUBSCRIBE,
+		Acknowledged: true,
+	}), "active subscription")
+	core.AssertFalse(t, countsInflight(clientRequestQueue{
+		RequestType: nanorpc.NanoRPCRequest_TYPE_PING,
+	}), "ping")
+}
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// RequestInterceptor is called with every request a [Client] sends, after
+// its RequestId is assigned and before it's encoded, so it can observe the
+// request or mutate it, e.g. to attach credentials. An error aborts the
+// send and is returned to the caller.
+type RequestInterceptor func(req *nanorpc.NanoRPCRequest) error
+
+// ResponseInterceptor is called with every response a [Client] receives,
+// before it's routed to its callback, so it can observe the response or
+// mutate it. req is the request it answers, without its data, and sentAt
+// when that request was sent; req is nil and sentAt zero when the response
+// matches no outstanding request.
+//
+// Response interceptors run on the session's read loop and should return
+// quickly. An error ends the session, like an error from a callback.
+type ResponseInterceptor func(ctx context.Context, req *nanorpc.NanoRPCRequest,
+	resp *nanorpc.NanoRPCResponse, sentAt time.Time) error
+
+// exportInterceptors returns the non-nil interceptors of the [Config].
+func (cfg *Config) exportInterceptors() ([]RequestInterceptor, []ResponseInterceptor) {
+	var reqs []RequestInterceptor
+	for _, fn := range cfg.RequestInterceptors {
+		if fn != nil {
+			reqs = append(reqs, fn)
+		}
+	}
+
+	var resps []ResponseInterceptor
+	for _, fn := range cfg.ResponseInterceptors {
+		if fn != nil {
+			resps = append(resps, fn)
+		}
+	}
+	return reqs, resps
+}
+
+// interceptRequest passes req through the client's request interceptors,
+// in the order they were configured.
+func (cs *Session) interceptRequest(req *nanorpc.NanoRPCRequest) error {
+	if cs.c == nil {
+		return nil
+	}
+
+	for _, fn := range cs.c.requestInterceptors {
+		if err := fn(req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// interceptResponse passes resp through the client's response
+// interceptors, in the order they were configured.
+func (cs *Session) interceptResponse(ctx context.Context, resp *nanorpc.NanoRPCResponse) error {
+	if cs.c == nil || len(cs.c.responseInterceptors) == 0 {
+		return nil
+	}
+
+	req, sentAt := cs.lookupRequest(resp)
+	for _, fn := range cs.c.responseInterceptors {
+		if err := fn(ctx, req, resp, sentAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookupRequest finds the request resp answers, preferring the same queue
+// entry popRequestCallback will route it to.
+func (cs *Session) lookupRequest(resp *nanorpc.NanoRPCResponse) (*nanorpc.NanoRPCRequest, time.Time) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	subIdx, otherIdx := cs.unsafeIndexCallbacks(resp.RequestId)
+
+	idx := otherIdx
+	if idx < 0 || resp.ResponseType == nanorpc.NanoRPCResponse_TYPE_UPDATE {
+		idx = subIdx
+	}
+	if idx < 0 {
+		return nil, time.Time{}
+	}
+	return cs.cb[idx].Request, cs.cb[idx].SentAt
+}
+
+// requestHeader copies req without its data, to be kept while the request
+// is outstanding.
+func requestHeader(req *nanorpc.NanoRPCRequest) *nanorpc.NanoRPCRequest {
+	return &nanorpc.NanoRPCRequest{
+		RequestId:    req.RequestId,
+		RequestType:  req.RequestType,
+		PathOneof:    req.PathOneof,
+		ResumeAfter:  req.ResumeAfter,
+		History:      req.History,
+		Acknowledged: req.Acknowledged,
+		Metadata:     req.Metadata,
+	}
+}
@@ -0,0 +1,133 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// interceptedEvent records one ResponseInterceptor invocation.
+type interceptedEvent struct {
+	req    *nanorpc.NanoRPCRequest
+	resp   *nanorpc.NanoRPCResponse
+	sentAt time.Time
+}
+
+func TestConfig_exportInterceptors(t *testing.T) {
+	reqFn := func(*nanorpc.NanoRPCRequest) error { return nil }
+	respFn := func(context.Context, *nanorpc.NanoRPCRequest,
+		*nanorpc.NanoRPCResponse, time.Time) error {
+		return nil
+	}
+
+	cfg := &Config{
+		RequestInterceptors:  []RequestInterceptor{nil, reqFn, nil},
+		ResponseInterceptors: []ResponseInterceptor{respFn, nil},
+	}
+
+	reqs, resps := cfg.exportInterceptors()
+	core.AssertEqual(t, 1, len(reqs), "request interceptors")
+	core.AssertEqual(t, 1, len(resps), "response interceptors")
+
+	reqs, resps = new(Config).exportInterceptors()
+	core.AssertEqual(t, 0, len(reqs), "no request interceptors")
+	core.AssertEqual(t, 0, len(resps), "no response interceptors")
+}
+
+// TestSession_interceptors_roundTrip drives a request through both chains:
+// the request interceptors run in order and their changes reach the wire,
+// and the response interceptor sees the answered request and can rewrite
+// the response before the callback.
+func TestSession_interceptors_roundTrip(t *testing.T) {
+	c, srv := newConnectedSession(t)
+
+	var order []string
+	c.requestInterceptors = []RequestInterceptor{
+		func(req *nanorpc.NanoRPCRequest) error {
+			order = append(order, "first")
+			req.PathOneof = &nanorpc.NanoRPCRequest_Path{Path: "/auth" + req.GetPath()}
+			return nil
+		},
+		func(*nanorpc.NanoRPCRequest) error {
+			order = append(order, "second")
+			return nil
+		},
+	}
+
+	seen := make(chan interceptedEvent, 4)
+	c.responseInterceptors = []ResponseInterceptor{
+		func(_ context.Context, req *nanorpc.NanoRPCRequest,
+			resp *nanorpc.NanoRPCResponse, sentAt time.Time) error {
+			seen <- interceptedEvent{req: req, resp: resp, sentAt: sentAt}
+			resp.ResponseStatus = statusNotFound
+			return nil
+		},
+	}
+
+	events := make(chan cbEvent, 4)
+	id, err := c.Request("/echo", nil, recordingCallback(events))
+	core.AssertMustNoError(t, err, "Request")
+	core.AssertSliceEqual(t, []string{"first", "second"}, order, "order")
+
+	req := srv.Recv()
+	core.AssertEqual(t, "/auth/echo", req.GetPath(), "path")
+	srv.Reply(newResponse(req.RequestId, respResponse, statusOK))
+
+	ev := mustRecvEvent(t, events, "request")
+	core.AssertEqual(t, id, ev.id, "callback_id")
+	core.AssertEqual(t, statusNotFound, ev.resp.ResponseStatus, "response_status")
+
+	got := <-seen
+	if core.AssertNotNil(t, got.req, "intercepted request") {
+		core.AssertEqual(t, "/auth/echo", got.req.GetPath(), "intercepted path")
+		core.AssertEqual(t, id, got.req.RequestId, "intercepted request_id")
+	}
+	core.AssertFalse(t, got.sentAt.IsZero(), "sentAt")
+}
+
+// TestSession_interceptRequest_error verifies a failing request
+// interceptor aborts the send before the callback is registered.
+func TestSession_interceptRequest_error(t *testing.T) {
+	c, _ := newConnectedSession(t)
+	cs, err := c.getSession()
+	core.AssertMustNoError(t, err, "getSession")
+
+	errDenied := errors.New("denied")
+	c.requestInterceptors = []RequestInterceptor{
+		func(*nanorpc.NanoRPCRequest) error { return errDenied },
+	}
+
+	events := make(chan cbEvent, 1)
+	_, err = c.Request("/echo", nil, recordingCallback(events))
+	core.AssertErrorIs(t, err, errDenied, "Request")
+	core.AssertFalse(t, cs.IsActive(), "callback registered")
+}
+
+// TestSession_interceptResponse_unmatched verifies a response matching no
+// outstanding request still reaches the interceptors, without a request.
+func TestSession_interceptResponse_unmatched(t *testing.T) {
+	c := newClientForTest(t)
+	cs := &Session{c: c}
+
+	var got []interceptedEvent
+	c.responseInterceptors = []ResponseInterceptor{
+		func(_ context.Context, req *nanorpc.NanoRPCRequest,
+			resp *nanorpc.NanoRPCResponse, sentAt time.Time) error {
+			got = append(got, interceptedEvent{req: req, resp: resp, sentAt: sentAt})
+			return nil
+		},
+	}
+
+	resp := newResponse(42, respResponse, statusOK)
+	core.AssertNoError(t, cs.handleResponse(context.Background(), resp), "handleResponse")
+	if core.AssertEqual(t, 1, len(got), "calls") {
+		core.AssertNil(t, got[0].req, "request")
+		core.AssertSame(t, resp, got[0].resp, "response")
+		core.AssertTrue(t, got[0].sentAt.IsZero(), "sentAt")
+	}
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// keepAlive tracks the pings a [Session] sends to tell a dead connection
+// from a quiet one.
+type keepAlive struct {
+	// due is set while a ping awaits its pong.
+	due atomic.Bool
+	// missed counts the pings in a row left unanswered.
+	missed atomic.Uint32
+}
+
+// Healthy reports whether the [Client] is connected and, with
+// Config.MissedPongThreshold set, the server answered its last keep-alive
+// ping. Like [Client.IsConnected], it's a point-in-time snapshot.
+func (c *Client) Healthy() bool {
+	if c == nil {
+		return false
+	}
+
+	cs, err := c.getSession()
+	return err == nil && cs.ka.missed.Load() == 0
+}
+
+// keepAliveTicker returns a channel ticking every Config.KeepAlive, or
+// nil if the [Config] doesn't ask for pings, and the function stopping
+// it. Pings are sent by runOutbound.
+func (cs *Session) keepAliveTicker() (<-chan time.Time, func()) {
+	if cs.c == nil || cs.c.keepAlive <= 0 || cs.c.missedPongThreshold == 0 {
+		return nil, func() {}
+	}
+
+	t := time.NewTicker(cs.c.keepAlive)
+	return t.C, t.Stop
+}
+
+// sendKeepAlive counts the previous ping as missed if still unanswered,
+// and sends a new one. It fails with [ErrMissedPongs] once
+// Config.MissedPongThreshold pings in a row go unanswered, ending the
+// session so the [Client] reconnects.
+func (cs *Session) sendKeepAlive() error {
+	if cs.ka.due.Load() {
+		n := cs.ka.missed.Add(1)
+		if n >= cs.c.missedPongThreshold {
+			return core.QuietWrap(ErrMissedPongs, "%d pings unanswered", n)
+		}
+	}
+
+	cs.ka.due.Store(true)
+	req := &nanorpc.NanoRPCRequest{
+		RequestType: nanorpc.NanoRPCRequest_TYPE_PING,
+	}
+	return cs.send(req, nil, cs.onPong, sendOptions{priority: PriorityHigh})
+}
+
+// onPong records the server is alive. A nil response means the ping was
+// given up on, and doesn't count.
+func (cs *Session) onPong(_ context.Context, _ int32, resp *nanorpc.NanoRPCResponse) error {
+	if resp != nil {
+		cs.ka.due.Store(false)
+		cs.ka.missed.Store(0)
+	}
+	return nil
+}
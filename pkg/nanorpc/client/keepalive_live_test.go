@@ -0,0 +1,74 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/client"
+	"protomcp.org/nanorpc/pkg/nanorpc/mock/server"
+)
+
+// newKeepAliveClient connects a client pinging srv every interval, dropping
+// the connection after two pongs missed in a row.
+func newKeepAliveClient(t *testing.T, srv *server.Server,
+	interval time.Duration) (*client.Client, *server.Conn) {
+	t.Helper()
+
+	c := newLiveClient(t, srv, client.Config{
+		KeepAlive:           interval,
+		MissedPongThreshold: 2,
+	})
+	core.AssertMustNoError(t, c.Connect(), "Connect")
+	conn := srv.Accept()
+
+	ctx, cancel := context.WithTimeout(context.Background(), liveTimeout)
+	defer cancel()
+	core.AssertMustNoError(t, c.WaitConnected(ctx), "WaitConnected")
+	return c, conn
+}
+
+// TestLiveClient_KeepAlive_answered verifies the client pings the server
+// every KeepAlive and stays healthy while the pings are answered.
+func TestLiveClient_KeepAlive_answered(t *testing.T) {
+	srv := server.New(t)
+	c, conn := newKeepAliveClient(t, srv, 50*time.Millisecond)
+
+	for range 3 {
+		req := conn.Recv()
+		core.AssertEqual(t, nanorpc.NanoRPCRequest_TYPE_PING, req.RequestType, "request_type")
+		conn.Reply(newLiveResponse(req.RequestId, nanorpc.NanoRPCResponse_TYPE_PONG,
+			nanorpc.NanoRPCResponse_STATUS_OK))
+	}
+
+	core.AssertTrue(t, c.Healthy(), "Healthy")
+}
+
+// TestLiveClient_KeepAlive_missed verifies the client drops a connection
+// whose pings go unanswered, reporting ErrMissedPongs.
+func TestLiveClient_KeepAlive_missed(t *testing.T) {
+	srv := server.New(t)
+	c, conn := newKeepAliveClient(t, srv, 20*time.Millisecond)
+
+	conn.Recv()
+	conn.Recv()
+	core.AssertFalse(t, c.Healthy(), "Healthy after a missed pong")
+
+	ctx, cancel := context.WithTimeout(context.Background(), liveTimeout)
+	defer cancel()
+
+	for {
+		select {
+		case err := <-c.Errors():
+			if errors.Is(err, client.ErrMissedPongs) {
+				return
+			}
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for ErrMissedPongs")
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// DefaultLivenessWindow is the silence allowed on a watched subscription
+// when [LivenessOptions].Window is not set.
+const DefaultLivenessWindow = 30 * time.Second
+
+// LivenessOptions configures [WatchSubscription].
+type LivenessOptions struct {
+	// OnStale is called, when defined, every time Window elapses without
+	// the subscription receiving anything from the server.
+	OnStale func(w *SubscriptionWatch, silence time.Duration)
+
+	// Window is how long the subscription may stay silent before it is
+	// considered stale. Servers publishing rarely should send empty
+	// TYPE_UPDATE heartbeats more often than this.
+	Window time.Duration
+
+	// Resubscribe, when set, replaces a stale subscription with a new one
+	// after calling OnStale.
+	Resubscribe bool
+}
+
+// subscriptionClient is the view of the [Client] a [SubscriptionWatch]
+// needs.
+type subscriptionClient interface {
+	Subscriber
+	Unsubscriber
+}
+
+// SubscriptionWatch monitors the liveness of a subscription, telling apart
+// "no changes" from a silently broken subscription. Any message the server
+// sends for the subscription — acknowledgement, update or empty heartbeat
+// update — counts as a sign of life.
+type SubscriptionWatch struct {
+	c     subscriptionClient
+	msg   proto.Message
+	cb    RequestCallback
+	timer *time.Timer
+	last  time.Time
+	opts  LivenessOptions
+	path  string
+	id    int32
+	done  bool
+	mu    sync.Mutex
+}
+
+// WatchSubscription subscribes to path like [Client.Subscribe] and
+// monitors the subscription's liveness. cb receives every subscription
+// event, as with [Client.Subscribe].
+func WatchSubscription(c Subscriber, path string, msg proto.Message, cb RequestCallback,
+	opts LivenessOptions) (*SubscriptionWatch, error) {
+	sc, ok := c.(subscriptionClient)
+	switch {
+	case !ok || core.IsNil(c):
+		return nil, ErrMissingClient
+	case cb == nil:
+		return nil, ErrMissingCallback
+	}
+
+	if opts.Window <= 0 {
+		opts.Window = DefaultLivenessWindow
+	}
+
+	w := &SubscriptionWatch{
+		c:    sc,
+		msg:  msg,
+		cb:   cb,
+		opts: opts,
+		path: path,
+		last: time.Now(),
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.unsafeSubscribe(); err != nil {
+		return nil, err
+	}
+	w.timer = time.AfterFunc(opts.Window, w.onTimeout)
+	return w, nil
+}
+
+// ID returns the request ID of the current subscription, which changes
+// when it is resubscribed.
+func (w *SubscriptionWatch) ID() int32 {
+	if w == nil {
+		return 0
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.id
+}
+
+// LastSeen returns when the server last sent something for the
+// subscription.
+func (w *SubscriptionWatch) LastSeen() time.Time {
+	if w == nil {
+		return time.Time{}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.last
+}
+
+// Stop ends the monitoring and unsubscribes.
+func (w *SubscriptionWatch) Stop() error {
+	if w == nil {
+		return core.ErrNilReceiver
+	}
+
+	w.mu.Lock()
+	if w.done {
+		w.mu.Unlock()
+		return nil
+	}
+	w.done = true
+	w.timer.Stop()
+	id := w.id
+	w.mu.Unlock()
+
+	return w.c.Unsubscribe(w.path, id, ignoreResponse)
+}
+
+func (w *SubscriptionWatch) unsafeSubscribe() error {
+	id, err := w.c.Subscribe(w.path, w.msg, w.callback)
+	if err != nil {
+		return err
+	}
+	w.id = id
+	return nil
+}
+
+// callback records the sign of life and forwards the event.
+func (w *SubscriptionWatch) callback(ctx context.Context, id int32, resp *nanorpc.NanoRPCResponse) error {
+	if resp != nil {
+		w.mu.Lock()
+		w.last = time.Now()
+		if !w.done {
+			w.timer.Reset(w.opts.Window)
+		}
+		w.mu.Unlock()
+	}
+	return w.cb(ctx, id, resp)
+}
+
+func (w *SubscriptionWatch) onTimeout() {
+	w.mu.Lock()
+	if w.done {
+		w.mu.Unlock()
+		return
+	}
+	silence := time.Since(w.last)
+	w.mu.Unlock()
+
+	if fn := w.opts.OnStale; fn != nil {
+		fn(w, silence)
+	}
+
+	if w.opts.Resubscribe {
+		w.resubscribe()
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.done {
+		w.timer.Reset(w.opts.Window)
+	}
+}
+
+// resubscribe replaces the stale subscription. The old one is dropped on a
+// best-effort basis, and a failed attempt is retried when the window
+// elapses again. The client is called without holding the lock, as it may
+// deliver events to the callback before returning.
+func (w *SubscriptionWatch) resubscribe() {
+	w.mu.Lock()
+	done, old := w.done, w.id
+	w.mu.Unlock()
+
+	if done {
+		return
+	}
+
+	_ = w.c.Unsubscribe(w.path, old, ignoreResponse)
+	id, err := w.c.Subscribe(w.path, w.msg, w.callback)
+	if err != nil {
+		return
+	}
+
+	w.mu.Lock()
+	done = w.done
+	if !done {
+		w.id = id
+	}
+	w.mu.Unlock()
+
+	if done {
+		// stopped meanwhile, drop the replacement too
+		_ = w.c.Unsubscribe(w.path, id, ignoreResponse)
+	}
+}
+
+// ignoreResponse is a [RequestCallback] that ignores the response.
+func ignoreResponse(context.Context, int32, *nanorpc.NanoRPCResponse) error {
+	return nil
+}
@@ -0,0 +1,172 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/utils/testutils"
+)
+
+// fakeSubscriptionClient records subscriptions and lets tests deliver
+// events to their callbacks.
+type fakeSubscriptionClient struct {
+	callbacks    map[int32]RequestCallback
+	unsubscribed []int32
+	next         int32
+	mu           sync.Mutex
+	// acknowledge makes Unsubscribe deliver a response to the dropped
+	// callback before returning, as a client may do.
+	acknowledge bool
+}
+
+func (f *fakeSubscriptionClient) Subscribe(_ string, _ proto.Message, cb RequestCallback) (int32, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.callbacks == nil {
+		f.callbacks = make(map[int32]RequestCallback)
+	}
+	f.next++
+	f.callbacks[f.next] = cb
+	return f.next, nil
+}
+
+func (f *fakeSubscriptionClient) Unsubscribe(_ string, id int32, _ RequestCallback) error {
+	f.mu.Lock()
+	cb := f.callbacks[id]
+	delete(f.callbacks, id)
+	f.unsubscribed = append(f.unsubscribed, id)
+	f.mu.Unlock()
+
+	if f.acknowledge && cb != nil {
+		resp := &nanorpc.NanoRPCResponse{
+			RequestId:      id,
+			ResponseType:   nanorpc.NanoRPCResponse_TYPE_RESPONSE,
+			ResponseStatus: nanorpc.NanoRPCResponse_STATUS_OK,
+		}
+		return cb(context.Background(), id, resp)
+	}
+	return nil
+}
+
+func (f *fakeSubscriptionClient) deliver(t *testing.T, id int32) {
+	t.Helper()
+
+	f.mu.Lock()
+	cb := f.callbacks[id]
+	f.mu.Unlock()
+
+	if core.AssertNotNil(t, cb, "callback %d", id) {
+		resp := &nanorpc.NanoRPCResponse{
+			RequestId:      id,
+			ResponseType:   nanorpc.NanoRPCResponse_TYPE_UPDATE,
+			ResponseStatus: nanorpc.NanoRPCResponse_STATUS_OK,
+		}
+		core.AssertNoError(t, cb(context.Background(), id, resp), "deliver")
+	}
+}
+
+func (f *fakeSubscriptionClient) subscriptions() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.callbacks)
+}
+
+// TestWatchSubscription_Stale verifies OnStale fires after a silent window
+// and not while updates keep arriving.
+func TestWatchSubscription_Stale(t *testing.T) {
+	stale := make(chan time.Duration, 1)
+
+	fake := &fakeSubscriptionClient{}
+	var events int
+	w, err := WatchSubscription(fake, "/sensors", nil,
+		func(context.Context, int32, *nanorpc.NanoRPCResponse) error {
+			events++
+			return nil
+		},
+		LivenessOptions{
+			Window: 100 * time.Millisecond,
+			OnStale: func(_ *SubscriptionWatch, silence time.Duration) {
+				select {
+				case stale <- silence:
+				default:
+				}
+			},
+		})
+	core.AssertMustNoError(t, err, "watch")
+	defer func() { _ = w.Stop() }()
+
+	for range 4 {
+		time.Sleep(20 * time.Millisecond)
+		fake.deliver(t, w.ID())
+	}
+	core.AssertEqual(t, 4, events, "events forwarded")
+	select {
+	case <-stale:
+		t.Fatal("stale reported while updates arrived")
+	default:
+	}
+
+	select {
+	case silence := <-stale:
+		core.AssertTrue(t, silence >= 100*time.Millisecond, "silence")
+	case <-time.After(time.Second):
+		t.Fatal("stale not reported")
+	}
+}
+
+// TestWatchSubscription_Resubscribe verifies a stale subscription is
+// replaced and Stop unsubscribes the current one.
+func TestWatchSubscription_Resubscribe(t *testing.T) {
+	fake := &fakeSubscriptionClient{}
+	w, err := WatchSubscription(fake, "/sensors", nil, ignoreResponse,
+		LivenessOptions{Window: 20 * time.Millisecond, Resubscribe: true})
+	core.AssertMustNoError(t, err, "watch")
+
+	first := w.ID()
+	testutils.AssertWaitForCondition(t, func() bool {
+		return w.ID() != first
+	}, time.Second, "resubscribed")
+	core.AssertEqual(t, 1, fake.subscriptions(), "single live subscription")
+
+	core.AssertNoError(t, w.Stop(), "stop")
+	core.AssertEqual(t, 0, fake.subscriptions(), "unsubscribed")
+	core.AssertNoError(t, w.Stop(), "second stop")
+}
+
+// TestWatchSubscription_ResubscribeCallback verifies the client may call
+// back into the watch while it resubscribes or stops.
+func TestWatchSubscription_ResubscribeCallback(t *testing.T) {
+	fake := &fakeSubscriptionClient{acknowledge: true}
+	w, err := WatchSubscription(fake, "/sensors", nil, ignoreResponse,
+		LivenessOptions{Window: 20 * time.Millisecond, Resubscribe: true})
+	core.AssertMustNoError(t, err, "watch")
+
+	first := w.ID()
+	testutils.AssertWaitForCondition(t, func() bool {
+		return w.ID() != first
+	}, time.Second, "resubscribed")
+
+	core.AssertNoError(t, w.Stop(), "stop")
+	core.AssertEqual(t, 0, fake.subscriptions(), "unsubscribed")
+}
+
+// TestWatchSubscription_Invalid verifies argument validation.
+func TestWatchSubscription_Invalid(t *testing.T) {
+	var nilClient *Client
+	_, err := WatchSubscription(nilClient, "/x", nil, ignoreResponse, LivenessOptions{})
+	core.AssertErrorIs(t, err, ErrMissingClient, "nil client")
+
+	_, err = WatchSubscription(&fakeSubscriptionClient{}, "/x", nil, nil, LivenessOptions{})
+	core.AssertErrorIs(t, err, ErrMissingCallback, "nil callback")
+
+	var w *SubscriptionWatch
+	core.AssertErrorIs(t, w.Stop(), core.ErrNilReceiver, "nil stop")
+	core.AssertEqual(t, int32(0), w.ID(), "nil id")
+}
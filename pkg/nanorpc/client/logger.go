@@ -11,6 +11,9 @@ import (
 
 // getLogger returns the base logger for the client, creating one if needed
 func (c *Client) getLogger() slog.Logger {
+	if c == nil {
+		return discard.New()
+	}
 	if c.logger == nil {
 		// Create a simple discard logger if none provided
 		c.logger = discard.New()
@@ -107,6 +110,9 @@ func (c *Client) LogError(addr net.Addr, err error, fields slog.Fields, msg stri
 
 // getLogger returns the configured session logger or lazily initializes one
 func (cs *Session) getLogger() slog.Logger {
+	if cs == nil {
+		return discard.New()
+	}
 	if cs.logger == nil {
 		// Fallback initialization if logger wasn't set during creation
 		logger := utils.WithComponent(cs.c.getLogger(), utils.ComponentSession)
@@ -0,0 +1,34 @@
+package client
+
+import (
+	"context"
+	"slices"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// metadataKey is the context key of the metadata of outgoing requests.
+type metadataKey struct{}
+
+// WithMetadata returns a copy of ctx whose requests, made through
+// [Client.RequestContext] or [Client.Call], carry the given key-value
+// pairs in their metadata, after those ctx already carries. A key left
+// without value gets an empty one.
+func WithMetadata(ctx context.Context, kv ...string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	md := append(slices.Clip(MetadataFromContext(ctx)), nanorpc.NewMetadata(kv...)...)
+	return context.WithValue(ctx, metadataKey{}, md)
+}
+
+// MetadataFromContext returns the metadata the requests made with ctx
+// carry, see [WithMetadata].
+func MetadataFromContext(ctx context.Context) nanorpc.Metadata {
+	if ctx == nil {
+		return nil
+	}
+	md, _ := ctx.Value(metadataKey{}).(nanorpc.Metadata)
+	return md
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"time"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// MetricsSink receives the metrics of a [Client], see Config.MetricsSink.
+// Implementations must be safe for concurrent use, and quick, as they are
+// called inline by the session.
+type MetricsSink interface {
+	// SetInFlight records the number of requests, pings, unsubscriptions
+	// and subscriptions awaiting their response.
+	SetInFlight(n int)
+
+	// SetQueueDepth records the number of entries in the callback queue,
+	// those in flight and the acknowledged subscriptions.
+	SetQueueDepth(n int)
+
+	// ObserveReconnect records a connection made after the first.
+	ObserveReconnect()
+
+	// ObserveReconnectAttempt records an attempt to reconnect, counted
+	// from 1 since the last connection made, after waiting as
+	// Config.Backoff or Config.WaitReconnect said.
+	ObserveReconnectAttempt(attempt int, wait time.Duration)
+
+	// ObservePing records the round-trip time of an answered ping.
+	ObservePing(rtt time.Duration)
+
+	// ObserveRequest records a request or subscription answered with
+	// status, and its round-trip time. Requests sent by hash are recorded
+	// with their path when the HashCache knows it, or with an empty path.
+	ObserveRequest(path string, status nanorpc.NanoRPCResponse_Status, rtt time.Duration)
+}
+
+// observeReconnect counts a connection, reporting those after the first.
+func (c *Client) observeReconnect() {
+	if c.connects.Add(1) > 1 && c.metrics != nil {
+		c.metrics.ObserveReconnect()
+	}
+}
+
+// unsafeObserveMetrics reports the round-trip time of the queue entry at
+// idx. cs.mu must be held.
+func (cs *Session) unsafeObserveMetrics(idx int, resp *nanorpc.NanoRPCResponse) {
+	m := cs.c.metrics
+	x := cs.cb[idx]
+	if m == nil || x.SentAt.IsZero() {
+		return
+	}
+
+	rtt := time.Since(x.SentAt)
+	if x.RequestType == nanorpc.NanoRPCRequest_TYPE_PING {
+		m.ObservePing(rtt)
+		return
+	}
+	m.ObserveRequest(cs.c.requestPath(x.Request), resp.GetResponseStatus(), rtt)
+}
+
+// requestPath returns the path of a request, resolving its hash when
+// possible.
+func (c *Client) requestPath(req *nanorpc.NanoRPCRequest) string {
+	if path := req.GetPath(); path != "" {
+		return path
+	}
+	if hash := req.GetPathHash(); hash != 0 && c.hc != nil {
+		path, _ := c.hc.Path(hash)
+		return path
+	}
+	return ""
+}
+
+// unsafeReportQueue reports the size of the callback queue, and wakes
+// the requests held back by Config.MaxInflight. cs.mu must be held.
+func (cs *Session) unsafeReportQueue() {
+	cs.unsafeReleaseInflight(false)
+	if cs.c == nil || cs.c.metrics == nil {
+		return
+	}
+
+	var n int
+	for _, x := range cs.cb {
+		if !x.Acknowledged {
+			n++
+		}
+	}
+	cs.c.metrics.SetInFlight(n)
+	cs.c.metrics.SetQueueDepth(len(cs.cb))
+}
@@ -0,0 +1,149 @@
+package client
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+var _ MetricsSink = (*recordingSink)(nil)
+
+// sinkRequest is a request recorded by a recordingSink.
+type sinkRequest struct {
+	path   string
+	status nanorpc.NanoRPCResponse_Status
+}
+
+// recordingSink is a [MetricsSink] remembering what it was told.
+type recordingSink struct {
+	requests   []sinkRequest
+	inFlight   []int
+	depth      []int
+	attempts   []int
+	pings      int
+	reconnects int
+	mu         sync.Mutex
+}
+
+func (s *recordingSink) SetInFlight(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inFlight = append(s.inFlight, n)
+}
+
+func (s *recordingSink) SetQueueDepth(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.depth = append(s.depth, n)
+}
+
+func (s *recordingSink) ObserveReconnect() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reconnects++
+}
+
+func (s *recordingSink) ObserveReconnectAttempt(attempt int, _ time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts = append(s.attempts, attempt)
+}
+
+func (s *recordingSink) ObservePing(time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pings++
+}
+
+func (s *recordingSink) ObserveRequest(path string, status nanorpc.NanoRPCResponse_Status, _ time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, sinkRequest{path: path, status: status})
+}
+
+func (s *recordingSink) snapshot() recordingSink {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return recordingSink{
+		requests:   s.requests,
+		inFlight:   s.inFlight,
+		depth:      s.depth,
+		attempts:   s.attempts,
+		pings:      s.pings,
+		reconnects: s.reconnects,
+	}
+}
+
+// TestClient_MetricsSink drives a request, a request by hash, a
+// subscription and a ping through the run loop, and checks what the sink
+// was told.
+func TestClient_MetricsSink(t *testing.T) {
+	c, srv := newConnectedSession(t)
+	sink := new(recordingSink)
+	c.metrics = sink
+
+	hash, err := c.hc.Hash("/hashed")
+	core.AssertMustNoError(t, err, "Hash")
+
+	events := make(chan cbEvent, 4)
+	_, err = c.Request("/echo", nil, recordingCallback(events))
+	core.AssertMustNoError(t, err, "Request")
+	srv.Reply(newResponse(srv.Recv().RequestId, respResponse, statusOK))
+	mustRecvEvent(t, events, "request")
+
+	_, err = c.RequestByHash(hash, nil, recordingCallback(events))
+	core.AssertMustNoError(t, err, "RequestByHash")
+	srv.Reply(newResponse(srv.Recv().RequestId, respResponse, statusNotFound))
+	mustRecvEvent(t, events, "request by hash")
+
+	_, err = c.Subscribe("/events", nil, recordingCallback(events))
+	core.AssertMustNoError(t, err, "Subscribe")
+	srv.Reply(newResponse(srv.Recv().RequestId, respResponse, statusOK))
+	mustRecvEvent(t, events, "subscribe")
+
+	ch := c.Pong()
+	srv.Reply(newResponse(srv.Recv().RequestId, respPong, statusOK))
+	core.AssertNoError(t, <-ch, "Pong")
+
+	got := sink.snapshot()
+	core.AssertSliceEqual(t, []sinkRequest{
+		{path: "/echo", status: statusOK},
+		{path: "/hashed", status: statusNotFound},
+		{path: "/events", status: statusOK},
+	}, got.requests, "requests")
+	core.AssertEqual(t, 1, got.pings, "pings")
+
+	// the acknowledged subscription stays queued but not in flight
+	core.AssertSliceEqual(t, []int{1, 0, 1, 0, 1, 0, 1, 0}, got.inFlight, "in flight")
+	core.AssertSliceEqual(t, []int{1, 0, 1, 0, 1, 1, 2, 1}, got.depth, "queue depth")
+}
+
+func TestClient_observeReconnect(t *testing.T) {
+	c := newClientForTest(t)
+	sink := new(recordingSink)
+	c.metrics = sink
+
+	c.observeReconnect()
+	core.AssertEqual(t, 0, sink.snapshot().reconnects, "first connection")
+
+	c.observeReconnect()
+	c.observeReconnect()
+	core.AssertEqual(t, 2, sink.snapshot().reconnects, "reconnections")
+}
+
+func TestConfig_MetricsSink(t *testing.T) {
+	sink := new(recordingSink)
+	cfg := Config{Remote: "127.0.0.1:1", MetricsSink: sink}
+	c, err := cfg.New()
+	core.AssertMustNoError(t, err, "New")
+	core.AssertSame(t, MetricsSink(sink), c.metrics, "metrics")
+
+	cfg.MetricsSink = (*recordingSink)(nil)
+	c, err = cfg.New()
+	core.AssertMustNoError(t, err, "New typed nil")
+	core.AssertNil(t, c.metrics, "typed nil")
+}
@@ -0,0 +1,195 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"sync"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// Divergence describes a mirrored request whose secondary response
+// differs from the primary one in status or data, see [Mirror].
+type Divergence struct {
+	// Primary is the response of the primary server, nil if its session
+	// ended first.
+	Primary *nanorpc.NanoRPCResponse
+	// Secondary is the response of the secondary server, nil if its
+	// session ended first or the request couldn't be sent to it.
+	Secondary *nanorpc.NanoRPCResponse
+	// Err is why the request couldn't be sent to the secondary server.
+	Err error
+	// Path is the path of the request.
+	Path string
+	// RequestID is the RequestId given by the primary server.
+	RequestID int32
+}
+
+// MirrorStats counts the requests mirrored by a [Mirror].
+type MirrorStats struct {
+	// Mirrored counts the requests sent to both servers.
+	Mirrored uint64
+	// Matched counts the mirrored requests answered alike by both.
+	Matched uint64
+	// Diverged counts the mirrored requests answered differently, or that
+	// couldn't be sent to the secondary server.
+	Diverged uint64
+}
+
+var _ Requester = (*Mirror)(nil)
+
+// Mirror duplicates requests to a secondary server, e.g. a new
+// implementation being validated before cutover, and compares the status
+// and data of its responses against the primary's. Callers only see the
+// primary responses, as they arrive; secondary responses are only
+// compared.
+type Mirror struct {
+	primary      Requester
+	secondary    Requester
+	onDivergence func(Divergence)
+	stats        MirrorStats
+	mu           sync.Mutex
+}
+
+// NewMirror creates a [Mirror] sending requests to primary and secondary,
+// typically two [Client]s, calling onDivergence, if not nil, for every
+// mirrored request answered differently.
+func NewMirror(primary, secondary Requester, onDivergence func(Divergence)) (*Mirror, error) {
+	if core.IsNil(primary) || core.IsNil(secondary) {
+		return nil, ErrMissingClient
+	}
+
+	m := &Mirror{
+		primary:      primary,
+		secondary:    secondary,
+		onDivergence: onDivergence,
+	}
+	return m, nil
+}
+
+// Request sends a request to the primary server, calling cb with its
+// response, and mirrors it to the secondary server once the primary
+// accepted it.
+func (m *Mirror) Request(path string, msg proto.Message, cb RequestCallback) (int32, error) {
+	if m == nil {
+		return 0, core.ErrNilReceiver
+	}
+	if cb == nil {
+		return 0, ErrMissingCallback
+	}
+
+	p := &mirrorPair{path: path}
+	id, err := m.primary.Request(path, msg, func(ctx context.Context, id int32, resp *nanorpc.NanoRPCResponse) error {
+		m.settle(p.setPrimary(id, resp))
+		return cb(ctx, id, resp)
+	})
+	if err != nil {
+		return id, err
+	}
+
+	_, err = m.secondary.Request(path, msg, func(_ context.Context, _ int32, resp *nanorpc.NanoRPCResponse) error {
+		m.settle(p.setSecondary(resp, nil))
+		return nil
+	})
+	if err != nil {
+		m.settle(p.setSecondary(nil, err))
+	}
+	return id, nil
+}
+
+// Stats returns the counts of the requests mirrored so far.
+func (m *Mirror) Stats() MirrorStats {
+	if m == nil {
+		return MirrorStats{}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.stats
+}
+
+// settle accounts a mirrored request once both sides are known.
+func (m *Mirror) settle(d *Divergence, done bool) {
+	if !done {
+		return
+	}
+
+	m.mu.Lock()
+	m.stats.Mirrored++
+	if d == nil {
+		m.stats.Matched++
+	} else {
+		m.stats.Diverged++
+	}
+	m.mu.Unlock()
+
+	if d != nil && m.onDivergence != nil {
+		m.onDivergence(*d)
+	}
+}
+
+// mirrorPair collects both sides of a mirrored request.
+type mirrorPair struct {
+	primary   *nanorpc.NanoRPCResponse
+	secondary *nanorpc.NanoRPCResponse
+	err       error
+	path      string
+	mu        sync.Mutex
+	id        int32
+	sides     int
+}
+
+// setPrimary records the primary response, returning the divergence, if
+// any, once both sides are known.
+func (p *mirrorPair) setPrimary(id int32, resp *nanorpc.NanoRPCResponse) (*Divergence, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.id, p.primary = id, resp
+	return p.unsafeCompare()
+}
+
+// setSecondary records the secondary response, or why it couldn't be
+// requested, returning the divergence, if any, once both sides are known.
+func (p *mirrorPair) setSecondary(resp *nanorpc.NanoRPCResponse, err error) (*Divergence, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.secondary, p.err = resp, err
+	return p.unsafeCompare()
+}
+
+func (p *mirrorPair) unsafeCompare() (*Divergence, bool) {
+	p.sides++
+	switch {
+	case p.sides < 2:
+		return nil, false
+	case p.err == nil && sameResponse(p.primary, p.secondary):
+		return nil, true
+	default:
+		return &Divergence{
+			Primary:   p.primary,
+			Secondary: p.secondary,
+			Err:       p.err,
+			Path:      p.path,
+			RequestID: p.id,
+		}, true
+	}
+}
+
+// sameResponse reports whether two responses have the same status and
+// data.
+func sameResponse(a, b *nanorpc.NanoRPCResponse) bool {
+	switch {
+	case a == nil || b == nil:
+		return a == b
+	case a.ResponseStatus != b.ResponseStatus:
+		return false
+	default:
+		return sha256.Sum256(a.Data) == sha256.Sum256(b.Data)
+	}
+}
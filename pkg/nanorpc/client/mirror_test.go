@@ -0,0 +1,140 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+var errMirrorSend = errors.New("send failed")
+
+// fakeRequester is a [Requester] answering every request with resp, or
+// failing with err.
+type fakeRequester struct {
+	resp  *nanorpc.NanoRPCResponse
+	err   error
+	paths []string
+	id    int32
+}
+
+func (r *fakeRequester) Request(path string, _ proto.Message, cb RequestCallback) (int32, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	r.id++
+	r.paths = append(r.paths, path)
+	return r.id, cb(context.Background(), r.id, r.resp)
+}
+
+func newMirrorResponse(status nanorpc.NanoRPCResponse_Status, data string) *nanorpc.NanoRPCResponse {
+	resp := newResponse(0, respResponse, status)
+	if data != "" {
+		resp.Data = []byte(data)
+	}
+	return resp
+}
+
+var _ core.TestCase = mirrorTestCase{}
+
+type mirrorTestCase struct {
+	primary   *fakeRequester
+	secondary *fakeRequester
+	wantErr   error
+	name      string
+	diverged  bool
+}
+
+func (tc mirrorTestCase) Name() string { return tc.name }
+
+func (tc mirrorTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	var divergences []Divergence
+	m, err := NewMirror(tc.primary, tc.secondary, func(d Divergence) {
+		divergences = append(divergences, d)
+	})
+	core.AssertMustNoError(t, err, "NewMirror")
+
+	var calls int
+	_, err = m.Request("/echo", nil, func(_ context.Context, _ int32, resp *nanorpc.NanoRPCResponse) error {
+		calls++
+		core.AssertSame(t, tc.primary.resp, resp, "response")
+		return nil
+	})
+	core.AssertErrorIs(t, err, tc.wantErr, "Request")
+
+	stats := m.Stats()
+	if tc.wantErr != nil {
+		core.AssertEqual(t, MirrorStats{}, stats, "stats")
+		core.AssertEqual(t, 0, len(tc.secondary.paths), "secondary requests")
+		return
+	}
+
+	core.AssertEqual(t, 1, calls, "callback calls")
+	core.AssertEqual(t, uint64(1), stats.Mirrored, "mirrored")
+	if !tc.diverged {
+		core.AssertEqual(t, uint64(1), stats.Matched, "matched")
+		core.AssertEqual(t, 0, len(divergences), "divergences")
+		return
+	}
+
+	core.AssertEqual(t, uint64(1), stats.Diverged, "diverged")
+	if core.AssertEqual(t, 1, len(divergences), "divergences") {
+		d := divergences[0]
+		core.AssertEqual(t, "/echo", d.Path, "path")
+		core.AssertEqual(t, int32(1), d.RequestID, "request_id")
+		core.AssertSame(t, tc.primary.resp, d.Primary, "primary")
+		core.AssertErrorIs(t, d.Err, tc.secondary.err, "secondary error")
+	}
+}
+
+func newMirrorTestCase(name string, primary, secondary *fakeRequester,
+	wantErr error, diverged bool) mirrorTestCase {
+	return mirrorTestCase{
+		primary:   primary,
+		secondary: secondary,
+		wantErr:   wantErr,
+		name:      name,
+		diverged:  diverged,
+	}
+}
+
+func mirrorTestCases() []mirrorTestCase {
+	answer := func(status nanorpc.NanoRPCResponse_Status, data string) *fakeRequester {
+		return &fakeRequester{resp: newMirrorResponse(status, data)}
+	}
+
+	return []mirrorTestCase{
+		newMirrorTestCase("matched", answer(statusOK, "a"), answer(statusOK, "a"), nil, false),
+		newMirrorTestCase("matched without data", answer(statusOK, ""), answer(statusOK, ""), nil, false),
+		newMirrorTestCase("status", answer(statusOK, "a"), answer(statusNotFound, "a"), nil, true),
+		newMirrorTestCase("data", answer(statusOK, "a"), answer(statusOK, "b"), nil, true),
+		newMirrorTestCase("secondary ended", answer(statusOK, "a"), &fakeRequester{}, nil, true),
+		newMirrorTestCase("secondary failed", answer(statusOK, "a"),
+			&fakeRequester{err: errMirrorSend}, nil, true),
+		newMirrorTestCase("primary failed", &fakeRequester{err: errMirrorSend},
+			answer(statusOK, "a"), errMirrorSend, false),
+	}
+}
+
+func TestMirror_Request(t *testing.T) {
+	core.RunTestCases(t, mirrorTestCases())
+}
+
+func TestNewMirror(t *testing.T) {
+	_, err := NewMirror(nil, &fakeRequester{}, nil)
+	core.AssertErrorIs(t, err, ErrMissingClient, "primary")
+	_, err = NewMirror(&fakeRequester{}, (*Client)(nil), nil)
+	core.AssertErrorIs(t, err, ErrMissingClient, "secondary")
+
+	m, err := NewMirror(&fakeRequester{}, &fakeRequester{}, nil)
+	core.AssertMustNoError(t, err, "NewMirror")
+	_, err = m.Request("/echo", nil, nil)
+	core.AssertErrorIs(t, err, ErrMissingCallback, "callback")
+}
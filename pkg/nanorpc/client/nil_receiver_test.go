@@ -0,0 +1,254 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+var _ core.TestCase = nilReceiverTestCase{}
+
+// nilReceiverTestCase calls an exported method on a nil receiver. Methods
+// returning an error must report core.ErrNilReceiver; the others are
+// adapted through zeroResult and must return zero values. Neither may
+// panic.
+type nilReceiverTestCase struct {
+	call func() error
+	name string
+}
+
+func (tc nilReceiverTestCase) Name() string { return tc.name }
+
+func (tc nilReceiverTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	err := core.Catch(tc.call)
+	core.AssertErrorIs(t, err, core.ErrNilReceiver, tc.name)
+}
+
+func newNilReceiverTestCase(name string, call func() error) nilReceiverTestCase {
+	return nilReceiverTestCase{call: call, name: name}
+}
+
+// errNonZero reports a method on a nil receiver returned a non-zero value.
+var errNonZero = errors.New("non-zero result on nil receiver")
+
+// zeroResult adapts a non-error method, mapping "returned zero values" to
+// core.ErrNilReceiver.
+func zeroResult(isZero bool) error {
+	if isZero {
+		return core.ErrNilReceiver
+	}
+	return errNonZero
+}
+
+// secondResult discards the first result of a (value, error) method.
+func secondResult[T any](_ T, err error) error { return err }
+
+func nilConfigTestCases() []nilReceiverTestCase {
+	var cfg *Config
+	return []nilReceiverTestCase{
+		newNilReceiverTestCase("Config.New", func() error { return secondResult(cfg.New()) }),
+		newNilReceiverTestCase("Config.SetDefaults", cfg.SetDefaults),
+		newNilReceiverTestCase("Config.Export", func() error { return secondResult(cfg.Export()) }),
+		newNilReceiverTestCase("Config.ExportTLS", func() error { return secondResult(cfg.ExportTLS()) }),
+	}
+}
+
+func nilClientRequestTestCases() []nilReceiverTestCase {
+	var c *Client
+	return []nilReceiverTestCase{
+		newNilReceiverTestCase("Client.Request", func() error { return secondResult(c.Request("/x", nil, ignoreResponse)) }),
+		newNilReceiverTestCase("Client.RequestCBOR", func() error {
+			return secondResult(c.RequestCBOR("/x", nil, ignoreResponse))
+		}),
+		newNilReceiverTestCase("Client.RequestRaw", func() error {
+			return secondResult(c.RequestRaw("/x", nil, ignoreResponse))
+		}),
+		newNilReceiverTestCase("Client.SubscribeRawByHash", func() error {
+			return secondResult(c.SubscribeRawByHash(1, nil, ignoreResponse))
+		}),
+		newNilReceiverTestCase("Client.GetResponseCBOR", func() error {
+			return c.GetResponseCBOR(context.Background(), "/x", nil, new(any))
+		}),
+		newNilReceiverTestCase("Client.RequestByHash", func() error {
+			return secondResult(c.RequestByHash(1, nil, ignoreResponse))
+		}),
+		newNilReceiverTestCase("Client.RequestWithHash", func() error {
+			return secondResult(c.RequestWithHash("/x", nil, ignoreResponse))
+		}),
+		newNilReceiverTestCase("Client.Call", func() error {
+			return c.Call(context.Background(), "/x", nil, nil)
+		}),
+		newNilReceiverTestCase("Client.RequestPriority", func() error {
+			return secondResult(c.RequestPriority("/x", nil, PriorityLow, ignoreResponse))
+		}),
+		newNilReceiverTestCase("Client.RequestStored", func() error {
+			return secondResult(c.RequestStored("/x", nil))
+		}),
+		newNilReceiverTestCase("Client.RequestContext", func() error {
+			return secondResult(c.RequestContext(context.Background(), "/x", nil, ignoreResponse))
+		}),
+		newNilReceiverTestCase("Client.RequestStream", func() error {
+			return secondResult(c.RequestStream("/x", nil, nil))
+		}),
+		newNilReceiverTestCase("Client.Subscribe", func() error {
+			return secondResult(c.Subscribe("/x", nil, ignoreResponse))
+		}),
+		newNilReceiverTestCase("Client.SubscribeByHash", func() error {
+			return secondResult(c.SubscribeByHash(1, nil, ignoreResponse))
+		}),
+		newNilReceiverTestCase("Client.SubscribeWithHash", func() error {
+			return secondResult(c.SubscribeWithHash("/x", nil, ignoreResponse))
+		}),
+		newNilReceiverTestCase("Client.SubscribeAfter", func() error {
+			return secondResult(c.SubscribeAfter("/x", nil, 1, ignoreResponse))
+		}),
+		newNilReceiverTestCase("Client.SubscribePattern", func() error {
+			return secondResult(c.SubscribePattern("/x/+", nil, ignoreResponse))
+		}),
+		newNilReceiverTestCase("Client.SubscribeHistory", func() error {
+			return secondResult(c.SubscribeHistory("/x", nil, 1, ignoreResponse))
+		}),
+		newNilReceiverTestCase("Client.SubscribeAcked", func() error {
+			return secondResult(c.SubscribeAcked("/x", ignoreResponse))
+		}),
+		newNilReceiverTestCase("Client.ClearWill", func() error {
+			return c.ClearWill(context.Background())
+		}),
+		newNilReceiverTestCase("Client.Unsubscribe", func() error { return c.Unsubscribe("/x", 1, ignoreResponse) }),
+		newNilReceiverTestCase("Client.UnsubscribeByHash", func() error {
+			return c.UnsubscribeByHash(1, 1, ignoreResponse)
+		}),
+		newNilReceiverTestCase("Client.UnsubscribeWithHash", func() error {
+			return c.UnsubscribeWithHash("/x", 1, ignoreResponse)
+		}),
+		newNilReceiverTestCase("Client.Ping", func() error { return zeroResult(!c.Ping()) }),
+		newNilReceiverTestCase("Client.Pong", func() error { return <-c.Pong() }),
+		newNilReceiverTestCase("Mirror.Request", func() error {
+			var m *Mirror
+			return secondResult(m.Request("/x", nil, ignoreResponse))
+		}),
+		newNilReceiverTestCase("Mirror.Stats", func() error {
+			var m *Mirror
+			return zeroResult(m.Stats() == MirrorStats{})
+		}),
+	}
+}
+
+func nilClientLifecycleTestCases() []nilReceiverTestCase {
+	var c *Client
+	return []nilReceiverTestCase{
+		newNilReceiverTestCase("Client.Connect", c.Connect),
+		newNilReceiverTestCase("Client.Attach", func() error { return c.Attach(context.Background(), nil) }),
+		newNilReceiverTestCase("Client.Shutdown", func() error { return c.Shutdown(context.Background()) }),
+		newNilReceiverTestCase("Client.WaitConnected", func() error { return c.WaitConnected(context.Background()) }),
+		newNilReceiverTestCase("Client.Connected", func() error {
+			return zeroResult(c.Connected() == nil && !c.IsConnected())
+		}),
+		newNilReceiverTestCase("Client.Errors", func() error { return zeroResult(c.Errors() == nil) }),
+		newNilReceiverTestCase("Client.Healthy", func() error { return zeroResult(!c.Healthy()) }),
+		newNilReceiverTestCase("Client.Outstanding", func() error { return zeroResult(c.Outstanding() == nil) }),
+		newNilReceiverTestCase("Client.Handshake", func() error {
+			_, _, ok := c.Handshake()
+			return zeroResult(!ok)
+		}),
+		newNilReceiverTestCase("Client.StateEvents", func() error {
+			return zeroResult(c.StateEvents() == nil && c.State() == StateDisconnected)
+		}),
+		newNilReceiverTestCase("Client.LogError", func() error {
+			c.LogError(nil, nil, nil, "ignored")
+			_, ok := c.WithDebug(nil)
+			return zeroResult(!ok)
+		}),
+	}
+}
+
+func nilClientPoolTestCases() []nilReceiverTestCase {
+	var cfg *PoolConfig
+	var p *ClientPool
+	return []nilReceiverTestCase{
+		newNilReceiverTestCase("PoolConfig.New", func() error { return secondResult(cfg.New()) }),
+		newNilReceiverTestCase("ClientPool.Clients", func() error { return zeroResult(p.Clients() == nil) }),
+		newNilReceiverTestCase("ClientPool.Healthy", func() error { return zeroResult(p.Healthy() == nil) }),
+		newNilReceiverTestCase("ClientPool.Pick", func() error { return secondResult(p.Pick("/x")) }),
+		newNilReceiverTestCase("ClientPool.Request", func() error {
+			return secondResult(p.Request("/x", nil, ignoreResponse))
+		}),
+		newNilReceiverTestCase("ClientPool.Subscribe", func() error {
+			return secondResult(p.Subscribe("/x", nil, ignoreResponse))
+		}),
+		newNilReceiverTestCase("ClientPool.Unsubscribe", func() error { return p.Unsubscribe("/x", 1, ignoreResponse) }),
+		newNilReceiverTestCase("ClientPool.Connect", p.Connect),
+		newNilReceiverTestCase("ClientPool.Shutdown", func() error { return p.Shutdown(context.Background()) }),
+	}
+}
+
+func nilSessionTestCases() []nilReceiverTestCase {
+	var cs *Session
+	return []nilReceiverTestCase{
+		newNilReceiverTestCase("Session.Spawn", cs.Spawn),
+		newNilReceiverTestCase("Session.Close", func() error { return cs.Close(context.Background()) }),
+		newNilReceiverTestCase("Session.Send", func() error {
+			return cs.Send(&nanorpc.NanoRPCRequest{}, nil, nil)
+		}),
+		newNilReceiverTestCase("Session.IsActive", func() error { return zeroResult(!cs.IsActive()) }),
+		newNilReceiverTestCase("Session.Outstanding", func() error { return zeroResult(cs.Outstanding() == nil) }),
+		newNilReceiverTestCase("Session.LogInfo", func() error {
+			cs.LogInfo(nil, "ignored")
+			_, ok := cs.WithWarn(nil)
+			return zeroResult(!ok)
+		}),
+	}
+}
+
+func nilSubscriptionWatchTestCases() []nilReceiverTestCase {
+	var w *SubscriptionWatch
+	return []nilReceiverTestCase{
+		newNilReceiverTestCase("SubscriptionWatch.Stop", w.Stop),
+		newNilReceiverTestCase("SubscriptionWatch.ID", func() error {
+			return zeroResult(w.ID() == 0 && w.LastSeen().IsZero())
+		}),
+	}
+}
+
+func nilResumableSubscriptionTestCases() []nilReceiverTestCase {
+	var rs *ResumableSubscription
+	return []nilReceiverTestCase{
+		newNilReceiverTestCase("ResumableSubscription.Subscribe", func() error {
+			return secondResult(rs.Subscribe(nil))
+		}),
+		newNilReceiverTestCase("ResumableSubscription.ID", func() error {
+			return zeroResult(rs.ID() == 0 && rs.Sequence() == 0)
+		}),
+	}
+}
+
+func nilFileStorageTestCases() []nilReceiverTestCase {
+	var s *FileStorage
+	return []nilReceiverTestCase{
+		newNilReceiverTestCase("FileStorage.Store", func() error { return s.Store(StoredRequest{}) }),
+		newNilReceiverTestCase("FileStorage.Load", func() error { return secondResult(s.Load()) }),
+		newNilReceiverTestCase("FileStorage.Delete", func() error { return s.Delete(1) }),
+	}
+}
+
+// TestNilReceivers exercises the nil-receiver contract of every exported
+// type in the package. A nil [RequestCounter] deliberately falls back to
+// random ids, covered by its own tests.
+func TestNilReceivers(t *testing.T) {
+	t.Run("Config", func(t *testing.T) { core.RunTestCases(t, nilConfigTestCases()) })
+	t.Run("ClientRequest", func(t *testing.T) { core.RunTestCases(t, nilClientRequestTestCases()) })
+	t.Run("ClientLifecycle", func(t *testing.T) { core.RunTestCases(t, nilClientLifecycleTestCases()) })
+	t.Run("ClientPool", func(t *testing.T) { core.RunTestCases(t, nilClientPoolTestCases()) })
+	t.Run("Session", func(t *testing.T) { core.RunTestCases(t, nilSessionTestCases()) })
+	t.Run("SubscriptionWatch", func(t *testing.T) { core.RunTestCases(t, nilSubscriptionWatchTestCases()) })
+	t.Run("ResumableSubscription", func(t *testing.T) {
+		core.RunTestCases(t, nilResumableSubscriptionTestCases())
+	})
+	t.Run("FileStorage", func(t *testing.T) { core.RunTestCases(t, nilFileStorageTestCases()) })
+}
@@ -0,0 +1,187 @@
+package client
+
+import (
+	"context"
+	"sync"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// RequestStored saves a request in the Storage of the [Config] before
+// sending it, returning the ID it's stored as. Requests stored are sent
+// in order, as soon as connected, and again on every connection until the
+// server answers them, so they are delivered at least once even when the
+// link, or the process, goes down in between. The answer, whatever its
+// status, removes them from the Storage.
+//
+// Without Storage configured it fails with [ErrMissingStorage].
+func (c *Client) RequestStored(path string, msg proto.Message) (uint64, error) {
+	if c == nil {
+		return 0, core.ErrNilReceiver
+	}
+	if c.outbox == nil {
+		return 0, ErrMissingStorage
+	}
+
+	var data []byte
+	if msg != nil {
+		var err error
+		if data, err = proto.Marshal(msg); err != nil {
+			return 0, core.Wrap(err, "failed to marshal stored request")
+		}
+	}
+
+	id, err := c.outbox.add(path, data)
+	if err != nil {
+		return 0, err
+	}
+
+	c.outbox.replay()
+	return id, nil
+}
+
+// outbox sends the requests saved in a [Storage], deduplicated by ID so a
+// request awaiting its answer isn't sent again.
+type outbox struct {
+	c  *Client
+	st Storage
+
+	// sending serialises replays, keeping the requests in order.
+	sending  sync.Mutex
+	mu       sync.Mutex
+	inflight map[uint64]bool
+	next     uint64
+}
+
+// newOutbox creates the outbox of a [Client], continuing the IDs of the
+// requests already stored.
+func newOutbox(c *Client, st Storage) (*outbox, error) {
+	stored, err := st.Load()
+	if err != nil {
+		return nil, core.Wrap(err, "Storage")
+	}
+
+	ob := &outbox{
+		c:        c,
+		st:       st,
+		inflight: make(map[uint64]bool),
+		next:     1,
+	}
+	if n := len(stored); n > 0 {
+		ob.next = stored[n-1].ID + 1
+	}
+	return ob, nil
+}
+
+// add saves a request under the next ID.
+func (ob *outbox) add(path string, data []byte) (uint64, error) {
+	ob.mu.Lock()
+	id := ob.next
+	ob.next++
+	ob.mu.Unlock()
+
+	err := ob.st.Store(StoredRequest{ID: id, Path: path, Data: data})
+	return id, err
+}
+
+// replay sends, in order, the requests stored not awaiting their answer,
+// until one can't be sent.
+func (ob *outbox) replay() {
+	ob.sending.Lock()
+	defer ob.sending.Unlock()
+
+	stored, err := ob.st.Load()
+	if err != nil {
+		ob.c.reportError(StorageError{Err: err})
+		return
+	}
+
+	for _, r := range stored {
+		if !ob.claim(r.ID) {
+			continue
+		}
+		if err := ob.send(r); err != nil {
+			// not connected, retried on the next connection
+			ob.release(r.ID)
+			return
+		}
+	}
+}
+
+// claim marks a request as awaiting its answer, unless already.
+func (ob *outbox) claim(id uint64) bool {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	if ob.inflight[id] {
+		return false
+	}
+	ob.inflight[id] = true
+	return true
+}
+
+func (ob *outbox) release(id uint64) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	delete(ob.inflight, id)
+}
+
+func (ob *outbox) send(r StoredRequest) error {
+	m := &nanorpc.NanoRPCRequest{
+		RequestType: nanorpc.NanoRPCRequest_TYPE_REQUEST,
+		PathOneof:   ob.c.getPathOneOf(r.Path),
+		Data:        r.Data,
+	}
+
+	_, err := ob.c.enqueue(m, nil, func(_ context.Context, _ int32, res *nanorpc.NanoRPCResponse) error {
+		ob.onAnswer(r.ID, res)
+		return nil
+	})
+	return err
+}
+
+// onAnswer removes a request from the [Storage] once answered. A nil
+// response, the session ending first, leaves it to be sent again.
+func (ob *outbox) onAnswer(id uint64, res *nanorpc.NanoRPCResponse) {
+	if res != nil {
+		if err := ob.st.Delete(id); err != nil {
+			ob.c.reportError(StorageError{Err: err, ID: id})
+		}
+	}
+	ob.release(id)
+}
+
+// replayStored sends the requests stored while disconnected.
+func (c *Client) replayStored() {
+	if c.outbox != nil {
+		c.outbox.replay()
+	}
+}
+
+// initOutbox sets up the [Storage] of the [Config], if any.
+func (c *Client) initOutbox(cfg *Config) error {
+	st, err := cfg.getStorage()
+	if st == nil || err != nil {
+		return err
+	}
+
+	c.outbox, err = newOutbox(c, st)
+	return err
+}
+
+// getStorage returns the Storage of the [Config], or a [FileStorage] in
+// StorageDir when only that is set.
+func (cfg *Config) getStorage() (Storage, error) {
+	switch {
+	case !core.IsNil(cfg.Storage):
+		return cfg.Storage, nil
+	case cfg.StorageDir != "":
+		return NewFileStorage(cfg.StorageDir)
+	default:
+		return nil, nil
+	}
+}
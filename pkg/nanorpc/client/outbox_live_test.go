@@ -0,0 +1,121 @@
+package client_test
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/client"
+	"protomcp.org/nanorpc/pkg/nanorpc/mock/server"
+)
+
+var _ client.Storage = (*memStorage)(nil)
+
+// memStorage is a [client.Storage] in memory, reporting deletions.
+type memStorage struct {
+	deleted chan uint64
+	stored  []client.StoredRequest
+	mu      sync.Mutex
+}
+
+func newMemStorage() *memStorage {
+	return &memStorage{deleted: make(chan uint64, 8)}
+}
+
+func (s *memStorage) Store(r client.StoredRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stored = append(s.stored, r)
+	return nil
+}
+
+func (s *memStorage) Load() ([]client.StoredRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.stored), nil
+}
+
+func (s *memStorage) Delete(id uint64) error {
+	s.mu.Lock()
+	s.stored = slices.DeleteFunc(s.stored, func(r client.StoredRequest) bool { return r.ID == id })
+	s.mu.Unlock()
+
+	s.deleted <- id
+	return nil
+}
+
+func (s *memStorage) mustDeleted(t *testing.T, want uint64) {
+	t.Helper()
+	select {
+	case id := <-s.deleted:
+		core.AssertEqual(t, want, id, "deleted")
+	case <-time.After(liveTimeout):
+		t.Fatalf("timed out waiting for request %d to be deleted", want)
+	}
+}
+
+// recvStored receives a request, checks its path and data, and answers it.
+func recvStored(t *testing.T, conn *server.Conn, path string, want proto.Message) {
+	t.Helper()
+
+	req := conn.Recv()
+	core.AssertEqual(t, nanorpc.NanoRPCRequest_TYPE_REQUEST, req.RequestType, "request_type")
+	core.AssertEqual(t, path, req.GetPath(), "path")
+
+	got := &nanorpc.NanoRPCResponse{}
+	core.AssertMustNoError(t, proto.Unmarshal(req.Data, got), "Unmarshal")
+	core.AssertTrue(t, proto.Equal(want, got), "data")
+
+	conn.Reply(newLiveResponse(req.RequestId, nanorpc.NanoRPCResponse_TYPE_RESPONSE,
+		nanorpc.NanoRPCResponse_STATUS_OK))
+}
+
+// TestLiveClient_RequestStored verifies requests stored while disconnected
+// are sent in order once connected, later ones right away, and all are
+// deleted once answered.
+func TestLiveClient_RequestStored(t *testing.T) {
+	st := newMemStorage()
+	srv := server.New(t)
+	c := newLiveClient(t, srv, client.Config{Storage: st})
+
+	first := &nanorpc.NanoRPCResponse{ResponseMessage: "first"}
+	second := &nanorpc.NanoRPCResponse{ResponseMessage: "second"}
+	id1, err := c.RequestStored("/telemetry", first)
+	core.AssertMustNoError(t, err, "RequestStored first")
+	id2, err := c.RequestStored("/telemetry", second)
+	core.AssertMustNoError(t, err, "RequestStored second")
+	core.AssertEqual(t, id1+1, id2, "ids")
+
+	core.AssertMustNoError(t, c.Connect(), "Connect")
+	conn := srv.Accept()
+
+	recvStored(t, conn, "/telemetry", first)
+	st.mustDeleted(t, id1)
+	recvStored(t, conn, "/telemetry", second)
+	st.mustDeleted(t, id2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), liveTimeout)
+	defer cancel()
+	core.AssertMustNoError(t, c.WaitConnected(ctx), "WaitConnected")
+
+	third := &nanorpc.NanoRPCResponse{ResponseMessage: "third"}
+	id3, err := c.RequestStored("/telemetry", third)
+	core.AssertMustNoError(t, err, "RequestStored third")
+	recvStored(t, conn, "/telemetry", third)
+	st.mustDeleted(t, id3)
+}
+
+func TestClient_RequestStored_noStorage(t *testing.T) {
+	f := newLiveFixture(t)
+
+	_, err := f.c.RequestStored("/telemetry", nil)
+	core.AssertErrorIs(t, err, client.ErrMissingStorage, "RequestStored")
+}
@@ -0,0 +1,328 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+var (
+	_ Requester    = (*ClientPool)(nil)
+	_ Subscriber   = (*ClientPool)(nil)
+	_ Unsubscriber = (*ClientPool)(nil)
+)
+
+// PoolRouting tells how a [ClientPool] chooses the [Client] of each
+// request.
+type PoolRouting int
+
+const (
+	// PoolRoundRobin spreads requests over the healthy clients in turn.
+	// The default.
+	PoolRoundRobin PoolRouting = iota
+	// PoolSticky sends every request of a path to the same client, chosen
+	// by the hash of the path, while it stays healthy.
+	PoolSticky
+)
+
+// PoolConfig describes a [ClientPool].
+//
+// Config is the template of every [Client], its Remote replaced by each of
+// Remotes in turn.
+//
+// HealthInterval, when positive, pings every connected client that often,
+// taking those that don't answer within HealthTimeout out of rotation until
+// they do. HealthTimeout defaults to HealthInterval. Either way, clients
+// are only used while connected.
+type PoolConfig struct {
+	Config         Config
+	Remotes        []string
+	HealthInterval time.Duration
+	HealthTimeout  time.Duration
+	Routing        PoolRouting
+}
+
+// ClientPool manages a [Client] per remote, routing requests and
+// subscriptions to the healthy ones, e.g. for tools managing a fleet of
+// devices, or replicated servers.
+type ClientPool struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	members []*poolMember
+	subs    map[poolSubscription]*Client
+	hc      *nanorpc.HashCache
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	next    atomic.Uint32
+	health  time.Duration
+	timeout time.Duration
+	routing PoolRouting
+	started bool
+}
+
+// poolMember is a [Client] of a [ClientPool] and its health.
+type poolMember struct {
+	c      *Client
+	failed atomic.Bool
+}
+
+// healthy tells if the client is connected and answered the last health
+// check.
+func (m *poolMember) healthy() bool {
+	return m.c.IsConnected() && !m.failed.Load()
+}
+
+// poolSubscription identifies a subscription made through a [ClientPool].
+type poolSubscription struct {
+	path string
+	id   int32
+}
+
+// New creates a [ClientPool] with a [Client] for each remote. Clients
+// aren't connected until [ClientPool.Connect].
+func (cfg *PoolConfig) New() (*ClientPool, error) {
+	if cfg == nil {
+		return nil, core.ErrNilReceiver
+	}
+	if len(cfg.Remotes) == 0 {
+		return nil, ErrMissingRemotes
+	}
+
+	ctx := cfg.Config.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	p := &ClientPool{
+		subs:    make(map[poolSubscription]*Client),
+		hc:      cfg.Config.getHashCache(),
+		health:  cfg.HealthInterval,
+		timeout: cfg.HealthTimeout,
+		routing: cfg.Routing,
+	}
+	if p.timeout <= 0 {
+		p.timeout = p.health
+	}
+	p.ctx, p.cancel = context.WithCancel(ctx)
+
+	for _, remote := range cfg.Remotes {
+		ccfg := cfg.Config
+		ccfg.Remote = remote
+
+		c, err := ccfg.New()
+		if err != nil {
+			p.cancel()
+			return nil, core.Wrapf(err, "remote %q", remote)
+		}
+		p.members = append(p.members, &poolMember{c: c})
+	}
+
+	return p, nil
+}
+
+// Clients returns the clients of the pool, in the order of their remotes.
+func (p *ClientPool) Clients() []*Client {
+	if p == nil {
+		return nil
+	}
+
+	out := make([]*Client, len(p.members))
+	for i, m := range p.members {
+		out[i] = m.c
+	}
+	return out
+}
+
+// Connect connects every client of the pool, and starts the health
+// checks.
+func (p *ClientPool) Connect() error {
+	if p == nil {
+		return core.ErrNilReceiver
+	}
+
+	var errs []error
+	for _, m := range p.members {
+		errs = append(errs, m.c.Connect())
+	}
+
+	p.mu.Lock()
+	if p.health > 0 && !p.started {
+		p.started = true
+		p.wg.Add(1)
+		go p.runHealthChecks()
+	}
+	p.mu.Unlock()
+
+	return errors.Join(errs...)
+}
+
+// Shutdown stops the health checks and every client of the pool, see
+// [Client.Shutdown].
+func (p *ClientPool) Shutdown(ctx context.Context) error {
+	if p == nil {
+		return core.ErrNilReceiver
+	}
+
+	p.cancel()
+	p.wg.Wait()
+
+	var errs []error
+	for _, m := range p.members {
+		errs = append(errs, m.c.Shutdown(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+// Healthy returns the clients of the pool currently in rotation.
+func (p *ClientPool) Healthy() []*Client {
+	if p == nil {
+		return nil
+	}
+
+	var out []*Client
+	for _, m := range p.members {
+		if m.healthy() {
+			out = append(out, m.c)
+		}
+	}
+	return out
+}
+
+// Pick returns the healthy client a request to the given path would be
+// sent to, or [ErrNoHealthyClient].
+func (p *ClientPool) Pick(path string) (*Client, error) {
+	if p == nil {
+		return nil, core.ErrNilReceiver
+	}
+
+	var start uint32
+	if p.routing == PoolSticky {
+		// paths that can't be hashed go to the first healthy client
+		start, _ = p.hc.Hash(path)
+	} else {
+		start = p.next.Add(1) - 1
+	}
+
+	n := uint32(len(p.members))
+	for i := range n {
+		if m := p.members[(start+i)%n]; m.healthy() {
+			return m.c, nil
+		}
+	}
+	return nil, ErrNoHealthyClient
+}
+
+// Request sends a request through a healthy client, see [Client.Request].
+// The returned ID is that of the chosen client.
+func (p *ClientPool) Request(path string, msg proto.Message, cb RequestCallback) (int32, error) {
+	c, err := p.Pick(path)
+	if err != nil {
+		return 0, err
+	}
+	return c.Request(path, msg, cb)
+}
+
+// Subscribe subscribes to a path through a healthy client, see
+// [Client.Subscribe]. The subscription stays with that client until
+// [ClientPool.Unsubscribe].
+func (p *ClientPool) Subscribe(path string, msg proto.Message, cb RequestCallback) (int32, error) {
+	c, err := p.Pick(path)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := c.Subscribe(path, msg, cb)
+	if err != nil {
+		return id, err
+	}
+
+	p.mu.Lock()
+	p.subs[poolSubscription{path: path, id: id}] = c
+	p.mu.Unlock()
+	return id, nil
+}
+
+// Unsubscribe cancels a subscription made through the pool, by the client
+// holding it, see [Client.Unsubscribe]. cb is required, and fires once
+// when the server acknowledges the unsubscribe.
+func (p *ClientPool) Unsubscribe(path string, requestID int32, cb RequestCallback) error {
+	if p == nil {
+		return core.ErrNilReceiver
+	}
+
+	key := poolSubscription{path: path, id: requestID}
+
+	p.mu.Lock()
+	c, ok := p.subs[key]
+	p.mu.Unlock()
+
+	if !ok {
+		return core.QuietWrap(ErrNoSubscription, "request_id %d", requestID)
+	}
+	if err := c.Unsubscribe(path, requestID, cb); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	delete(p.subs, key)
+	p.mu.Unlock()
+	return nil
+}
+
+// runHealthChecks pings the connected clients every health interval until
+// the pool is shut down.
+func (p *ClientPool) runHealthChecks() {
+	defer p.wg.Done()
+
+	t := time.NewTicker(p.health)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-t.C:
+			p.checkHealth()
+		}
+	}
+}
+
+// checkHealth pings every connected client at once, recording which
+// answered in time.
+func (p *ClientPool) checkHealth() {
+	var wg sync.WaitGroup
+	for _, m := range p.members {
+		if !m.c.IsConnected() {
+			// checked again once reconnected
+			m.failed.Store(false)
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.failed.Store(!p.ping(m.c))
+		}()
+	}
+	wg.Wait()
+}
+
+// ping tells if a client answers a ping within the health timeout.
+func (p *ClientPool) ping(c *Client) bool {
+	ctx, cancel := context.WithTimeout(p.ctx, p.timeout)
+	defer cancel()
+
+	select {
+	case err := <-c.Pong():
+		return err == nil
+	case <-ctx.Done():
+		return false
+	}
+}
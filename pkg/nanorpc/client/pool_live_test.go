@@ -0,0 +1,141 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/client"
+	"protomcp.org/nanorpc/pkg/nanorpc/mock/server"
+)
+
+// newLivePool builds a connected pool over the given mock servers,
+// returning it with the server side of each client connection.
+func newLivePool(t *testing.T, routing client.PoolRouting, srvs ...*server.Server) (*client.ClientPool,
+	[]*server.Conn) {
+	t.Helper()
+
+	cfg := client.PoolConfig{
+		Config:  client.Config{Context: context.Background()},
+		Routing: routing,
+	}
+	for _, srv := range srvs {
+		cfg.Remotes = append(cfg.Remotes, srv.Addr())
+	}
+
+	p, err := cfg.New()
+	core.AssertMustNoError(t, err, "cfg.New")
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), liveTimeout)
+		defer cancel()
+		_ = p.Shutdown(ctx)
+	})
+	core.AssertMustNoError(t, p.Connect(), "Connect")
+
+	conns := make([]*server.Conn, len(srvs))
+	for i, srv := range srvs {
+		conns[i] = srv.Accept()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), liveTimeout)
+	defer cancel()
+	for _, c := range p.Clients() {
+		core.AssertMustNoError(t, c.WaitConnected(ctx), "WaitConnected")
+	}
+	return p, conns
+}
+
+// TestClientPool_roundRobin verifies requests alternate between clients,
+// and the disconnected ones are skipped.
+func TestClientPool_roundRobin(t *testing.T) {
+	srvs := []*server.Server{server.New(t), server.New(t)}
+	p, conns := newLivePool(t, client.PoolRoundRobin, srvs...)
+
+	events := make(chan cbEvent, 4)
+	for range 2 {
+		_, err := p.Request("/echo", nil, liveRecordingCallback(events))
+		core.AssertMustNoError(t, err, "Request")
+	}
+	for _, conn := range conns {
+		core.AssertEqual(t, "/echo", conn.Recv().GetPath(), "path")
+	}
+
+	core.AssertMustNoError(t, srvs[0].Close(), "Close")
+	waitDisconnected(t, p.Clients()[0])
+	core.AssertEqual(t, 1, len(p.Healthy()), "healthy")
+
+	for range 2 {
+		c, err := p.Pick("/echo")
+		core.AssertMustNoError(t, err, "Pick")
+		core.AssertTrue(t, c == p.Clients()[1], "healthy client picked")
+	}
+}
+
+// TestClientPool_sticky verifies the requests and subscriptions of a path
+// go to the same client, and unsubscribing to the one holding it.
+func TestClientPool_sticky(t *testing.T) {
+	p, conns := newLivePool(t, client.PoolSticky, server.New(t), server.New(t))
+
+	first, err := p.Pick("/sensors/temp")
+	core.AssertMustNoError(t, err, "Pick")
+	idx := 0
+	if first == p.Clients()[1] {
+		idx = 1
+	}
+
+	events := make(chan cbEvent, 4)
+	_, err = p.Request("/sensors/temp", nil, liveRecordingCallback(events))
+	core.AssertMustNoError(t, err, "Request")
+	core.AssertEqual(t, nanorpc.NanoRPCRequest_TYPE_REQUEST, conns[idx].Recv().RequestType, "request")
+
+	id, err := p.Subscribe("/sensors/temp", nil, liveRecordingCallback(events))
+	core.AssertMustNoError(t, err, "Subscribe")
+	core.AssertEqual(t, nanorpc.NanoRPCRequest_TYPE_SUBSCRIBE, conns[idx].Recv().RequestType, "subscribe")
+	conns[idx].Reply(newLiveResponse(id, nanorpc.NanoRPCResponse_TYPE_RESPONSE,
+		nanorpc.NanoRPCResponse_STATUS_OK))
+	mustRecvLiveEvent(t, events, "subscribe acknowledgement")
+
+	core.AssertMustNoError(t, p.Unsubscribe("/sensors/temp", id, liveRecordingCallback(events)), "Unsubscribe")
+	core.AssertEqual(t, nanorpc.NanoRPCRequest_TYPE_REQUEST, conns[idx].Recv().RequestType, "unsubscribe")
+
+	err = p.Unsubscribe("/sensors/temp", id, liveRecordingCallback(events))
+	core.AssertErrorIs(t, err, client.ErrNoSubscription, "unsubscribe again")
+}
+
+func TestClientPool_noHealthyClient(t *testing.T) {
+	cfg := client.PoolConfig{
+		Config:  client.Config{Context: context.Background()},
+		Remotes: []string{server.New(t).Addr()},
+	}
+	p, err := cfg.New()
+	core.AssertMustNoError(t, err, "cfg.New")
+
+	_, err = p.Request("/echo", nil, nil)
+	core.AssertErrorIs(t, err, client.ErrNoHealthyClient, "Request")
+}
+
+func TestPoolConfig_New_missingRemotes(t *testing.T) {
+	var cfg client.PoolConfig
+
+	_, err := cfg.New()
+	core.AssertErrorIs(t, err, client.ErrMissingRemotes, "New")
+	core.AssertTrue(t, client.IsInvalid(err), "IsInvalid")
+}
+
+// waitDisconnected waits until c notices its session ended.
+func waitDisconnected(t *testing.T, c *client.Client) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), liveTimeout)
+	defer cancel()
+	for c.IsConnected() {
+		select {
+		case <-ctx.Done():
+			t.Fatal("client still connected")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package client
+
+import (
+	"context"
+	"sync"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// Priority orders the requests waiting in the outbound queue of a
+// [Session]. Higher priorities are written first, and requests of the same
+// priority in the order they were sent.
+type Priority int
+
+const (
+	// PriorityLow is for bulk requests that can wait, like telemetry.
+	PriorityLow Priority = -1
+	// PriorityNormal is the priority of requests unless told otherwise.
+	PriorityNormal Priority = 0
+	// PriorityHigh is for requests that mustn't be held back, like pings.
+	// They jump the queue, and are queued even when it's full, so
+	// keep-alives aren't starved behind large writes on a slow link.
+	PriorityHigh Priority = 1
+)
+
+// priorityLevels is the number of [Priority] levels.
+const priorityLevels = 3
+
+// level returns the index of the queue of a priority, out of range ones
+// clamped.
+func (p Priority) level() int {
+	switch {
+	case p < PriorityLow:
+		return 0
+	case p > PriorityHigh:
+		return priorityLevels - 1
+	default:
+		return int(p - PriorityLow)
+	}
+}
+
+// RequestPriority enqueues a NanoRPC request like [Client.Request], at the
+// given priority.
+func (c *Client) RequestPriority(path string, msg proto.Message, p Priority,
+	cb RequestCallback) (int32, error) {
+	if c == nil {
+		return 0, core.ErrNilReceiver
+	}
+
+	// assemble header
+	m := &nanorpc.NanoRPCRequest{
+		RequestType: nanorpc.NanoRPCRequest_TYPE_REQUEST,
+		PathOneof:   c.getPathOneOf(path),
+	}
+
+	return c.enqueuePriority(m, msg, cb, p)
+}
+
+// outboundQueue holds the requests of a [Session] until they are handed,
+// highest priority first, to the [reconnect.StreamSession] writing them.
+// It holds up to size requests; more wait for room, unless they are of
+// [PriorityHigh].
+type outboundQueue struct {
+	mu     sync.Mutex
+	queues [priorityLevels][]clientRequest
+	// ready is signalled whenever a request is queued.
+	ready chan struct{}
+	// space is closed, and cleared, whenever a request is taken.
+	space  chan struct{}
+	done   chan struct{}
+	size   int
+	count  int
+	closed bool
+}
+
+func newOutboundQueue(size uint) *outboundQueue {
+	return &outboundQueue{
+		ready: make(chan struct{}, 1),
+		done:  make(chan struct{}),
+		size:  max(int(size), 1),
+	}
+}
+
+// push queues a request, waiting for room unless of [PriorityHigh].
+func (q *outboundQueue) push(r clientRequest, p Priority) error {
+	for {
+		space, err := q.tryPush(r, p)
+		if space == nil || err != nil {
+			return err
+		}
+
+		select {
+		case <-space:
+		case <-q.done:
+			return nanorpc.ErrSessionClosed
+		}
+	}
+}
+
+// tryPush queues a request if there is room, returning otherwise the
+// channel to wait on before trying again.
+func (q *outboundQueue) tryPush(r clientRequest, p Priority) (<-chan struct{}, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	switch {
+	case q.closed:
+		return nil, nanorpc.ErrSessionClosed
+	case q.count >= q.size && p < PriorityHigh:
+		if q.space == nil {
+			q.space = make(chan struct{})
+		}
+		return q.space, nil
+	}
+
+	l := p.level()
+	q.queues[l] = append(q.queues[l], r)
+	q.count++
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+	return nil, nil
+}
+
+// pop takes the oldest request of the highest priority queued, if any.
+func (q *outboundQueue) pop() (clientRequest, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for l := priorityLevels - 1; l >= 0; l-- {
+		if len(q.queues[l]) > 0 {
+			r := q.queues[l][0]
+			q.queues[l][0] = clientRequest{}
+			q.queues[l] = q.queues[l][1:]
+			q.unsafeTaken()
+			return r, true
+		}
+	}
+	return clientRequest{}, false
+}
+
+// unsafeTaken accounts a request taken, waking those waiting for room.
+// q.mu must be held.
+func (q *outboundQueue) unsafeTaken() {
+	q.count--
+	if q.space != nil {
+		close(q.space)
+		q.space = nil
+	}
+}
+
+// close discards the requests still queued, failing those waiting for
+// room and any later with [nanorpc.ErrSessionClosed].
+func (q *outboundQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.closed {
+		q.closed = true
+		q.queues = [priorityLevels][]clientRequest{}
+		q.count = 0
+		close(q.done)
+	}
+}
+
+// runOutbound hands the queued requests to the [reconnect.StreamSession],
+// highest priority first, until the session ends. It sends the keep-alive
+// pings too, so when missing pongs ends the session nothing else is
+// handing it requests as it closes.
+func (cs *Session) runOutbound(ctx context.Context) error {
+	defer cs.out.close()
+
+	tick, stop := cs.keepAliveTicker()
+	defer stop()
+
+	for {
+		if err := cs.flushOutbound(); err != nil {
+			return err
+		}
+
+		select {
+		case <-cs.out.ready:
+		case <-tick:
+			if err := cs.sendKeepAlive(); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// flushOutbound hands the queued requests to the
+// [reconnect.StreamSession], highest priority first.
+func (cs *Session) flushOutbound() error {
+	for {
+		r, ok := cs.out.pop()
+		if !ok {
+			return nil
+		}
+		if err := cs.ss.Send(r); err != nil {
+			return err
+		}
+	}
+}
+
+// sendOutbound queues a request to be written at the given priority.
+// Sessions built without an outbound queue write them in order.
+func (cs *Session) sendOutbound(r clientRequest, p Priority) error {
+	if cs.out == nil {
+		return cs.ss.Send(r)
+	}
+	return cs.out.push(r, p)
+}
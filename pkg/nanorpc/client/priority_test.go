@@ -0,0 +1,82 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// newPriorityRequest builds a queue entry identified by id.
+func newPriorityRequest(id int32) clientRequest {
+	return clientRequest{r: &nanorpc.NanoRPCRequest{RequestId: id}}
+}
+
+// assertPopOrder pops every queued request, checking their ids.
+func assertPopOrder(t *testing.T, q *outboundQueue, want ...int32) {
+	t.Helper()
+
+	var got []int32
+	for {
+		r, ok := q.pop()
+		if !ok {
+			break
+		}
+		got = append(got, r.r.RequestId)
+	}
+	core.AssertSliceEqual(t, want, got, "order")
+}
+
+func TestOutboundQueue_order(t *testing.T) {
+	q := newOutboundQueue(8)
+
+	for id, p := range map[int32]Priority{
+		1: PriorityLow,
+		2: PriorityNormal,
+		3: PriorityHigh,
+	} {
+		core.AssertMustNoError(t, q.push(newPriorityRequest(id), p), "push %d", id)
+	}
+	core.AssertMustNoError(t, q.push(newPriorityRequest(4), PriorityNormal), "push 4")
+	core.AssertMustNoError(t, q.push(newPriorityRequest(5), Priority(9)), "push 5")
+
+	assertPopOrder(t, q, 3, 5, 2, 4, 1)
+}
+
+func TestOutboundQueue_full(t *testing.T) {
+	q := newOutboundQueue(1)
+	core.AssertMustNoError(t, q.push(newPriorityRequest(1), PriorityNormal), "push 1")
+
+	// high priority jumps a full queue
+	core.AssertMustNoError(t, q.push(newPriorityRequest(2), PriorityHigh), "push 2")
+
+	// others wait for room
+	done := make(chan error, 1)
+	go func() { done <- q.push(newPriorityRequest(3), PriorityLow) }()
+
+	select {
+	case err := <-done:
+		t.Fatalf("push to a full queue didn't wait: %v", err)
+	case <-time.After(testRequestTimeout):
+	}
+
+	_, _ = q.pop()
+	_, _ = q.pop()
+	core.AssertNoError(t, <-done, "push 3")
+	assertPopOrder(t, q, 3)
+}
+
+func TestOutboundQueue_close(t *testing.T) {
+	q := newOutboundQueue(1)
+	core.AssertMustNoError(t, q.push(newPriorityRequest(1), PriorityNormal), "push 1")
+
+	done := make(chan error, 1)
+	go func() { done <- q.push(newPriorityRequest(2), PriorityNormal) }()
+
+	q.close()
+	core.AssertErrorIs(t, <-done, nanorpc.ErrSessionClosed, "waiting")
+	core.AssertErrorIs(t, q.push(newPriorityRequest(3), PriorityHigh), nanorpc.ErrSessionClosed, "closed")
+	assertPopOrder(t, q)
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// RequestRaw enqueues a NanoRPC request carrying data as-is, for payloads
+// not encoded as Protocol Buffers, converting path as [Client.Request]
+// does.
+func (c *Client) RequestRaw(path string, data []byte, cb RequestCallback) (int32, error) {
+	return c.enqueueRaw(nanorpc.NanoRPCRequest_TYPE_REQUEST, path, 0, data, cb)
+}
+
+// RequestRawByHash enqueues a NanoRPC request carrying data as-is, using a
+// given path_hash.
+func (c *Client) RequestRawByHash(path uint32, data []byte, cb RequestCallback) (int32, error) {
+	return c.enqueueRaw(nanorpc.NanoRPCRequest_TYPE_REQUEST, "", path, data, cb)
+}
+
+// SubscribeRaw enqueues a NanoRPC subscription request carrying data
+// as-is, converting path as [Client.Subscribe] does.
+func (c *Client) SubscribeRaw(path string, data []byte, cb RequestCallback) (int32, error) {
+	return c.enqueueRaw(nanorpc.NanoRPCRequest_TYPE_SUBSCRIBE, path, 0, data, cb)
+}
+
+// SubscribeRawByHash enqueues a NanoRPC subscription request carrying data
+// as-is, using a given path_hash.
+func (c *Client) SubscribeRawByHash(path uint32, data []byte, cb RequestCallback) (int32, error) {
+	return c.enqueueRaw(nanorpc.NanoRPCRequest_TYPE_SUBSCRIBE, "", path, data, cb)
+}
+
+// enqueueRaw sends a request carrying data as-is, to path, or to hash if
+// path is empty.
+func (c *Client) enqueueRaw(rt nanorpc.NanoRPCRequest_Type, path string, hash uint32,
+	data []byte, cb RequestCallback) (int32, error) {
+	if c == nil {
+		return 0, core.ErrNilReceiver
+	}
+
+	// assemble header
+	m := &nanorpc.NanoRPCRequest{
+		RequestType: rt,
+		Data:        data,
+	}
+
+	if path != "" {
+		m.PathOneof = c.getPathOneOf(path)
+	} else {
+		m.PathOneof = &nanorpc.NanoRPCRequest_PathHash{PathHash: hash}
+	}
+
+	return c.enqueue(m, nil, cb)
+}
@@ -0,0 +1,33 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// TestLiveClient_RequestRaw verifies raw requests carry their data as-is,
+// to the string path or the given hash.
+func TestLiveClient_RequestRaw(t *testing.T) {
+	f := newLiveFixture(t)
+	cb := func(context.Context, int32, *nanorpc.NanoRPCResponse) error { return nil }
+
+	_, err := f.c.RequestRaw("/echo", []byte(`{"value":21}`), cb)
+	core.AssertMustNoError(t, err, "RequestRaw")
+
+	req := f.conn.Recv()
+	core.AssertEqual(t, nanorpc.NanoRPCRequest_TYPE_REQUEST, req.RequestType, "request_type")
+	core.AssertEqual(t, "/echo", req.GetPath(), "path")
+	core.AssertEqual(t, `{"value":21}`, string(req.Data), "data")
+
+	_, err = f.c.SubscribeRawByHash(0x1234abcd, nil, cb)
+	core.AssertMustNoError(t, err, "SubscribeRawByHash")
+
+	req = f.conn.Recv()
+	core.AssertEqual(t, nanorpc.NanoRPCRequest_TYPE_SUBSCRIBE, req.RequestType, "request_type")
+	core.AssertEqual(t, uint32(0x1234abcd), req.GetPathHash(), "path_hash")
+	core.AssertEqual(t, 0, len(req.Data), "data")
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net"
 
+	"darvaza.org/core"
 	"darvaza.org/x/net/reconnect"
 )
 
@@ -14,6 +15,7 @@ func (c *Client) preInit(cfg *reconnect.Config) error {
 	cfg.OnSession = c.onReconnectSession
 	cfg.OnDisconnect = c.onReconnectDisconnect
 	cfg.OnError = c.onReconnectError
+	cfg.WaitReconnect = c.newStateWaiter(c.newReconnectWaiter(cfg.WaitReconnect))
 	return nil
 }
 
@@ -23,9 +25,17 @@ func (c *Client) preInit(cfg *reconnect.Config) error {
 
 func (c *Client) onReconnectConnect(ctx context.Context, conn net.Conn) error {
 	c.LogDebug(conn.RemoteAddr(), nil, "connected")
+	c.observeReconnect()
+	// reset on success
+	c.attempts.Store(0)
 
 	cs := newClientSession(ctx, c, c.queueSize, conn)
-	return c.setSession(cs)
+	if err := c.setSession(cs); err != nil {
+		return err
+	}
+
+	c.setState(StateConnected, nil)
+	return nil
 }
 
 func (c *Client) onReconnectSession(ctx context.Context) error {
@@ -56,10 +66,13 @@ func (c *Client) onReconnectSession(ctx context.Context) error {
 		}
 	}
 
+	c.replayStored()
 	return cs.Wait()
 }
 
 func (c *Client) onReconnectDisconnect(ctx context.Context, conn net.Conn) error {
+	c.setState(StateDisconnected, nil)
+
 	fn := c.getOnDisconnect()
 
 	if fn == nil {
@@ -76,14 +89,16 @@ func (c *Client) onReconnectDisconnect(ctx context.Context, conn net.Conn) error
 func (c *Client) onReconnectError(ctx context.Context, conn net.Conn, err error) error {
 	var addr net.Addr
 
-	if fn := c.getOnError(); fn != nil {
-		return fn(ctx, err)
-	}
-
 	if conn != nil {
 		// conn is nil when connection failed
 		addr = conn.RemoteAddr()
 	}
+	c.reportError(ConnectionError{Err: err, Addr: addr})
+
+	if fn := c.getOnError(); fn != nil {
+		return fn(ctx, err)
+	}
+
 	c.LogError(addr, err, nil, "error")
 
 	return err
@@ -148,6 +163,10 @@ func (c *Client) setSession(cs *Session) error {
 
 // Connect initiates the nanorpc reconnecting connection.
 func (c *Client) Connect() error {
+	if c == nil {
+		return core.ErrNilReceiver
+	}
+	c.setState(StateConnecting, nil)
 	return c.rc.Connect()
 }
 
@@ -159,6 +178,9 @@ func (c *Client) Connect() error {
 // promoted from the embedded [reconnect.WorkGroup]; this wrapper documents
 // that contract for the client's lifecycle surface.
 func (c *Client) Shutdown(ctx context.Context) error {
+	if c == nil {
+		return core.ErrNilReceiver
+	}
 	return c.WorkGroup.Shutdown(ctx)
 }
 
@@ -167,6 +189,10 @@ func (c *Client) Shutdown(ctx context.Context) error {
 // signals only the next readiness edge — callers waiting across a
 // reconnect cycle must fetch a fresh channel via Connected.
 func (c *Client) Connected() <-chan struct{} {
+	if c == nil {
+		return nil
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -177,6 +203,10 @@ func (c *Client) Connected() <-chan struct{} {
 // session. It is a point-in-time snapshot; the state can change between
 // the call returning and the next operation.
 func (c *Client) IsConnected() bool {
+	if c == nil {
+		return false
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -188,6 +218,10 @@ func (c *Client) IsConnected() bool {
 // is bounded by the caller's ctx — pass a deadline if you want to limit
 // how long callers will tolerate reconnection.
 func (c *Client) WaitConnected(ctx context.Context) error {
+	if c == nil {
+		return core.ErrNilReceiver
+	}
+
 	ch := c.Connected()
 
 	// Prefer a ready connection over an already-cancelled ctx: a lone
@@ -13,7 +13,7 @@ import (
 // SubscribeCallback is a function given to [Subscribe] to be called on every
 // subscription event. The server's TYPE_RESPONSE acknowledgement surfaces
 // with err == [nanorpc.ErrSubscriptionEstablished] on STATUS_OK or a
-// [nanorpc.ResponseError] for any other status; TYPE_UPDATE deliveries pass
+// [nanorpc.Error] for any other status; TYPE_UPDATE deliveries pass
 // the decoded payload with err == nil, or [nanorpc.ErrNoResponse] when the
 // update carried no data. Decode and factory errors surface through err.
 //
@@ -47,7 +47,7 @@ func (c *Client) Ping() bool {
 		RequestType: nanorpc.NanoRPCRequest_TYPE_PING,
 	}
 
-	_, err := c.enqueue(m, nil, nil)
+	_, err := c.enqueuePriority(m, nil, nil, PriorityHigh)
 	return err == nil
 }
 
@@ -75,7 +75,7 @@ func (c *Client) Pong() <-chan error {
 		return nil
 	}
 
-	_, err := c.enqueue(m, nil, cb)
+	_, err := c.enqueuePriority(m, nil, cb, PriorityHigh)
 	if err != nil {
 		h(err)
 	}
@@ -86,6 +86,10 @@ func (c *Client) Pong() <-chan error {
 // Request enqueues a NanoRPC request optionally converting path to path_hash
 // if [ClientOptions].AlwaysHashPaths was set.
 func (c *Client) Request(path string, msg proto.Message, cb RequestCallback) (int32, error) {
+	if c == nil {
+		return 0, core.ErrNilReceiver
+	}
+
 	// assemble header
 	m := &nanorpc.NanoRPCRequest{
 		RequestType: nanorpc.NanoRPCRequest_TYPE_REQUEST,
@@ -110,11 +114,15 @@ func (c *Client) RequestByHash(path uint32, msg proto.Message, cb RequestCallbac
 
 // RequestWithHash enqueues a NanoRPC request using the hash of the given path
 func (c *Client) RequestWithHash(path string, msg proto.Message, cb RequestCallback) (int32, error) {
+	if c == nil {
+		return 0, core.ErrNilReceiver
+	}
+
 	hash, err := c.hc.Hash(path)
 	if err != nil {
 		// Fall back to string path on hash collision
 		if logger, ok := c.getErrorLogger(err); ok {
-			logger.WithField(utils.FieldPath, path).
+			logger.WithField(utils.FieldPath, utils.LogPath(path)).
 				Print("Falling back to string path to maintain compatibility")
 		}
 		return c.Request(path, msg, cb)
@@ -126,6 +134,10 @@ func (c *Client) RequestWithHash(path string, msg proto.Message, cb RequestCallb
 // optionally converting path to path_hash
 // if [ClientOptions].AlwaysHashPaths was set.
 func (c *Client) Subscribe(path string, msg proto.Message, cb RequestCallback) (int32, error) {
+	if c == nil {
+		return 0, core.ErrNilReceiver
+	}
+
 	// assemble header
 	m := &nanorpc.NanoRPCRequest{
 		RequestType: nanorpc.NanoRPCRequest_TYPE_SUBSCRIBE,
@@ -148,13 +160,36 @@ func (c *Client) SubscribeByHash(path uint32, msg proto.Message, cb RequestCallb
 	return c.enqueue(m, msg, cb)
 }
 
+// SubscribePattern enqueues a NanoRPC subscription request to every path
+// matching pattern, where a + segment matches any one segment and a
+// trailing * the rest, as in /sensors/+/temperature. Updates carry the
+// path they were published on in their Path field. Patterns are always
+// sent as strings, as servers can't match their hashes.
+func (c *Client) SubscribePattern(pattern string, msg proto.Message, cb RequestCallback) (int32, error) {
+	if c == nil {
+		return 0, core.ErrNilReceiver
+	}
+
+	// assemble header
+	m := &nanorpc.NanoRPCRequest{
+		RequestType: nanorpc.NanoRPCRequest_TYPE_SUBSCRIBE,
+		PathOneof:   nanorpc.GetPathOneOfString(pattern),
+	}
+
+	return c.enqueue(m, msg, cb)
+}
+
 // SubscribeWithHash enqueues a NanoRPC request using the hash of the given path.
 func (c *Client) SubscribeWithHash(path string, msg proto.Message, cb RequestCallback) (int32, error) {
+	if c == nil {
+		return 0, core.ErrNilReceiver
+	}
+
 	hash, err := c.hc.Hash(path)
 	if err != nil {
 		// Fall back to string path on hash collision
 		if logger, ok := c.getErrorLogger(err); ok {
-			logger.WithField(utils.FieldPath, path).
+			logger.WithField(utils.FieldPath, utils.LogPath(path)).
 				Print("Falling back to string path to maintain compatibility")
 		}
 		return c.Subscribe(path, msg, cb)
@@ -172,6 +207,10 @@ func (c *Client) SubscribeWithHash(path string, msg proto.Message, cb RequestCal
 // requestID, or [ErrSubscriptionPending] when it is not yet acknowledged.
 // cb fires once when the server acknowledges the unsubscribe.
 func (c *Client) Unsubscribe(path string, requestID int32, cb RequestCallback) error {
+	if c == nil {
+		return core.ErrNilReceiver
+	}
+
 	// assemble header
 	m := &nanorpc.NanoRPCRequest{
 		RequestType: nanorpc.NanoRPCRequest_TYPE_REQUEST,
@@ -214,11 +253,15 @@ func (c *Client) UnsubscribeByHash(pathHash uint32, requestID int32, cb RequestC
 // requestID, or [ErrSubscriptionPending] when it is not yet acknowledged.
 // cb fires once when the server acknowledges the unsubscribe.
 func (c *Client) UnsubscribeWithHash(path string, requestID int32, cb RequestCallback) error {
+	if c == nil {
+		return core.ErrNilReceiver
+	}
+
 	hash, err := c.hc.Hash(path)
 	if err != nil {
 		// Fall back to string path on hash collision
 		if logger, ok := c.getErrorLogger(err); ok {
-			logger.WithField(utils.FieldPath, path).
+			logger.WithField(utils.FieldPath, utils.LogPath(path)).
 				Print("Falling back to string path to maintain compatibility")
 		}
 		return c.Unsubscribe(path, requestID, cb)
@@ -227,12 +270,23 @@ func (c *Client) UnsubscribeWithHash(path string, requestID int32, cb RequestCal
 }
 
 func (c *Client) enqueue(m *nanorpc.NanoRPCRequest, msg proto.Message, cb RequestCallback) (int32, error) {
+	return c.enqueuePriority(m, msg, cb, PriorityNormal)
+}
+
+// enqueuePriority sends a request through the current session, queued at
+// the given priority.
+func (c *Client) enqueuePriority(m *nanorpc.NanoRPCRequest, msg proto.Message, cb RequestCallback,
+	p Priority) (int32, error) {
+	if c == nil {
+		return 0, core.ErrNilReceiver
+	}
+
 	cs, err := c.getSession()
 	if err != nil {
 		return 0, err
 	}
 
-	err = cs.Send(m, msg, cb)
+	err = cs.send(m, msg, cb, sendOptions{priority: p})
 	return m.RequestId, err
 }
 
@@ -310,7 +364,7 @@ func newSubscribeCallback[A proto.Message](cb SubscribeCallback[A], newOut func(
 
 // dispatchSubscribeResponse routes a single response to the typed callback:
 // the TYPE_RESPONSE acknowledgement surfaces as a fresh out plus either the
-// status's [nanorpc.ResponseError] or [nanorpc.ErrSubscriptionEstablished];
+// status's [nanorpc.Error] or [nanorpc.ErrSubscriptionEstablished];
 // TYPE_UPDATE payloads are decoded into a fresh out.
 func dispatchSubscribeResponse[A proto.Message](ctx context.Context, id int32,
 	res *nanorpc.NanoRPCResponse, cb SubscribeCallback[A],
@@ -352,7 +406,7 @@ func isSubscribeACK(res *nanorpc.NanoRPCResponse) bool {
 }
 
 // subscribeACKErr maps the acknowledgement status to the error surfaced
-// through the subscription callback: a [nanorpc.ResponseError] for any
+// through the subscription callback: a [nanorpc.Error] for any
 // non-OK status, or the [nanorpc.ErrSubscriptionEstablished] sentinel on
 // success.
 func subscribeACKErr(res *nanorpc.NanoRPCResponse) error {
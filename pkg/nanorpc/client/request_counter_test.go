@@ -1,6 +1,7 @@
 package client
 
 import (
+	"math"
 	"testing"
 
 	"darvaza.org/core"
@@ -188,3 +189,11 @@ func testStartingPoint(t *testing.T) {
 func TestRequestCounter_StartingPoint(t *testing.T) {
 	t.Run("starting_point", testStartingPoint)
 }
+
+func TestRequestCounter_wraparound(t *testing.T) {
+	counter := &RequestCounter{}
+	counter.counter.Store(math.MaxInt32 - 1)
+
+	core.AssertEqual(t, int32(math.MaxInt32), counter.Next(), "last")
+	core.AssertEqual(t, int32(1), counter.Next(), "wrapped")
+}
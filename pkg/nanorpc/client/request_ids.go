@@ -0,0 +1,117 @@
+package client
+
+import (
+	"time"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// requestIDs tracks the request IDs of a [Session] allocated to requests
+// not queued yet, e.g. held back by Config.MaxInflight, so they aren't
+// handed out again meanwhile. Queued ones are outstanding by being in the
+// queue.
+type requestIDs struct {
+	reserved map[int32]struct{}
+}
+
+func (ids *requestIDs) reserve(id int32) {
+	if ids.reserved == nil {
+		ids.reserved = make(map[int32]struct{})
+	}
+	ids.reserved[id] = struct{}{}
+}
+
+func (ids *requestIDs) release(id int32) {
+	delete(ids.reserved, id)
+}
+
+func (ids *requestIDs) isReserved(id int32) bool {
+	_, ok := ids.reserved[id]
+	return ok
+}
+
+// nextRequestID allocates a request ID from the [RequestCounter] of the
+// [Client], skipping those outstanding, and reserves it until released by
+// releaseRequestID. The counter wraps from [math.MaxInt32] back to 1, so
+// after a long uptime it comes around to the IDs of long-lived
+// subscriptions, which are skipped rather than reused.
+func (cs *Session) nextRequestID() int32 {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	for {
+		next := cs.c.reqCounter.Next()
+		if !cs.unsafeIsOutstanding(next) {
+			cs.ids.reserve(next)
+			return next
+		}
+	}
+}
+
+// releaseRequestID ends the reservation of a request ID, once its request
+// is queued, or failed to be.
+func (cs *Session) releaseRequestID(id int32) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	cs.ids.release(id)
+}
+
+// unsafeIsOutstanding tells if a request ID is queued awaiting its
+// response, or reserved. cs.mu must be held.
+func (cs *Session) unsafeIsOutstanding(id int32) bool {
+	_, queued := cs.unsafeIndexRequestCallback(id)
+	return queued || cs.ids.isReserved(id)
+}
+
+// OutstandingRequest describes a request awaiting its response, or an
+// active subscription, see [Client.Outstanding].
+type OutstandingRequest struct {
+	// SentAt is when the request was queued.
+	SentAt time.Time
+	// Path is the path of the request, if known.
+	Path string
+	// RequestType is the type of the request.
+	RequestType nanorpc.NanoRPCRequest_Type
+	// RequestID is the ID responses to the request bear.
+	RequestID int32
+	// Acknowledged tells a subscription was confirmed by the server.
+	Acknowledged bool
+}
+
+// Outstanding returns the requests of the current session awaiting their
+// response, and its active subscriptions, in the order they were queued.
+func (c *Client) Outstanding() []OutstandingRequest {
+	if c == nil {
+		return nil
+	}
+
+	cs, err := c.getSession()
+	if err != nil || cs == nil {
+		return nil
+	}
+	return cs.Outstanding()
+}
+
+// Outstanding returns the requests of the session awaiting their response,
+// and its active subscriptions, in the order they were queued.
+func (cs *Session) Outstanding() []OutstandingRequest {
+	if cs == nil {
+		return nil
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	out := make([]OutstandingRequest, 0, len(cs.cb))
+	for _, x := range cs.cb {
+		out = append(out, OutstandingRequest{
+			SentAt:       x.SentAt,
+			Path:         cs.c.requestPath(x.Request),
+			RequestType:  x.RequestType,
+			RequestID:    x.RequestID,
+			Acknowledged: x.Acknowledged,
+		})
+	}
+	return out
+}
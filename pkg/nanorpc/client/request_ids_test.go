@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+func TestSession_nextRequestID(t *testing.T) {
+	c := &Client{reqCounter: &RequestCounter{}}
+	c.reqCounter.counter.Store(math.MaxInt32 - 1)
+
+	cs := &Session{c: c}
+	for _, id := range []int32{math.MaxInt32, 1} {
+		cs.cb = append(cs.cb, clientRequestQueue{
+			RequestID:   id,
+			RequestType: nanorpc.NanoRPCRequest_TYPE_SUBSCRIBE,
+		})
+	}
+	cs.ids.reserve(2)
+
+	// wraps around, skipping queued and reserved IDs
+	id := cs.nextRequestID()
+	core.AssertEqual(t, int32(3), id, "next")
+	core.AssertTrue(t, cs.ids.isReserved(id), "reserved")
+
+	cs.releaseRequestID(2)
+	cs.releaseRequestID(id)
+	core.AssertFalse(t, cs.ids.isReserved(id), "released")
+	core.AssertEqual(t, 0, len(cs.ids.reserved), "reservations")
+}
+
+func TestClient_Outstanding(t *testing.T) {
+	c, srv := newConnectedSession(t)
+	events := make(chan cbEvent, 4)
+	cb := func(_ context.Context, id int32, resp *nanorpc.NanoRPCResponse) error {
+		events <- cbEvent{resp: resp, id: id}
+		return nil
+	}
+
+	subID, err := c.Subscribe("/events", nil, cb)
+	core.AssertMustNoError(t, err, "Subscribe")
+	srv.Recv()
+	reqID, err := c.Request("/echo", nil, cb)
+	core.AssertMustNoError(t, err, "Request")
+	srv.Recv()
+
+	out := c.Outstanding()
+	core.AssertMustEqual(t, 2, len(out), "outstanding")
+	core.AssertEqual(t, subID, out[0].RequestID, "subscription id")
+	core.AssertEqual(t, "/events", out[0].Path, "subscription path")
+	core.AssertEqual(t, nanorpc.NanoRPCRequest_TYPE_SUBSCRIBE, out[0].RequestType, "subscription type")
+	core.AssertEqual(t, reqID, out[1].RequestID, "request id")
+	core.AssertFalse(t, out[1].SentAt.IsZero(), "sent at")
+	assertReservations(t, c, 0)
+
+	srv.Reply(newResponse(reqID, respResponse, statusOK))
+	mustRecvEvent(t, events, "answered")
+	out = c.Outstanding()
+	core.AssertMustEqual(t, 1, len(out), "after response")
+	core.AssertEqual(t, subID, out[0].RequestID, "subscription left")
+}
+
+func assertReservations(t *testing.T, c *Client, want int) {
+	t.Helper()
+
+	cs, err := c.getSession()
+	core.AssertMustNoError(t, err, "getSession")
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	core.AssertEqual(t, want, len(cs.ids.reserved), "reservations")
+}
@@ -0,0 +1,157 @@
+package client
+
+import (
+	"context"
+	"sync"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// ResumeSubscriber is a view of the [Client] that only allows
+// [Client.SubscribeAfter] calls
+type ResumeSubscriber interface {
+	SubscribeAfter(string, proto.Message, uint64, RequestCallback) (int32, error)
+}
+
+// SubscribeAfter enqueues a NanoRPC subscription request resuming after
+// the update with the given sequence. On paths where the server keeps a
+// replay buffer, the acknowledgement is followed by the updates published
+// since, or by the latest one flagged as a snapshot when the gap can no
+// longer be covered. A zero sequence is a plain [Client.Subscribe].
+func (c *Client) SubscribeAfter(path string, msg proto.Message, after uint64, cb RequestCallback) (int32, error) {
+	if c == nil {
+		return 0, core.ErrNilReceiver
+	}
+
+	// assemble header
+	m := &nanorpc.NanoRPCRequest{
+		RequestType: nanorpc.NanoRPCRequest_TYPE_SUBSCRIBE,
+		PathOneof:   c.getPathOneOf(path),
+		ResumeAfter: after,
+	}
+
+	return c.enqueue(m, msg, cb)
+}
+
+// SubscribeHistory enqueues a NanoRPC subscription request asking for the
+// last n updates published on the path, delivered right after the
+// acknowledgement on paths where the server keeps a replay buffer, or for
+// the retained update where it keeps one instead. A zero n is a plain
+// [Client.Subscribe], still sent the retained update if any.
+func (c *Client) SubscribeHistory(path string, msg proto.Message, n uint32, cb RequestCallback) (int32, error) {
+	if c == nil {
+		return 0, core.ErrNilReceiver
+	}
+
+	// assemble header
+	m := &nanorpc.NanoRPCRequest{
+		RequestType: nanorpc.NanoRPCRequest_TYPE_SUBSCRIBE,
+		PathOneof:   c.getPathOneOf(path),
+		History:     n,
+	}
+
+	return c.enqueue(m, msg, cb)
+}
+
+// ResumableSubscription remembers the sequence of the last update received
+// on a subscription, so it can be resubscribed after a reconnect without
+// losing the updates published in between. Updates carrying
+// Snapshot replace whatever state the callback had built, as the updates
+// before them were lost.
+//
+// Subscribe is typically called from [Config].OnConnect.
+type ResumableSubscription struct {
+	msg  proto.Message
+	cb   RequestCallback
+	path string
+	last uint64
+	id   int32
+	mu   sync.Mutex
+}
+
+// NewResumableSubscription creates a [ResumableSubscription] to path.
+// msg is the optional filter and cb receives every subscription event, as
+// with [Client.Subscribe].
+func NewResumableSubscription(path string, msg proto.Message, cb RequestCallback) (*ResumableSubscription, error) {
+	if cb == nil {
+		return nil, ErrMissingCallback
+	}
+
+	return &ResumableSubscription{
+		msg:  msg,
+		cb:   cb,
+		path: path,
+	}, nil
+}
+
+// Subscribe subscribes on c, resuming after the last sequence seen by any
+// previous subscription.
+func (rs *ResumableSubscription) Subscribe(c ResumeSubscriber) (int32, error) {
+	switch {
+	case rs == nil:
+		return 0, core.ErrNilReceiver
+	case core.IsNil(c):
+		return 0, ErrMissingClient
+	}
+
+	id, err := c.SubscribeAfter(rs.path, rs.msg, rs.Sequence(), rs.callback)
+	if err != nil {
+		return 0, err
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	rs.id = id
+	return id, nil
+}
+
+// ID returns the request ID of the current subscription.
+func (rs *ResumableSubscription) ID() int32 {
+	if rs == nil {
+		return 0
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.id
+}
+
+// Sequence returns the sequence of the last update received, or zero if
+// the server doesn't number the updates of the path.
+func (rs *ResumableSubscription) Sequence() uint64 {
+	if rs == nil {
+		return 0
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.last
+}
+
+// callback records the sequence and forwards the event.
+func (rs *ResumableSubscription) callback(ctx context.Context, id int32, resp *nanorpc.NanoRPCResponse) error {
+	if seq := resp.GetSequence(); seq != 0 {
+		rs.mu.Lock()
+		rs.unsafeObserve(resp, seq)
+		rs.mu.Unlock()
+	}
+	return rs.cb(ctx, id, resp)
+}
+
+// unsafeObserve advances the last sequence. An acknowledgement only counts
+// when nothing was seen yet or the gap was not covered, as otherwise the
+// catch-up updates that follow it are still pending.
+func (rs *ResumableSubscription) unsafeObserve(resp *nanorpc.NanoRPCResponse, seq uint64) {
+	switch resp.ResponseType {
+	case nanorpc.NanoRPCResponse_TYPE_UPDATE:
+		rs.last = seq
+	case nanorpc.NanoRPCResponse_TYPE_RESPONSE:
+		if rs.last == 0 || resp.Snapshot {
+			rs.last = seq
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// fakeResumeSubscriber records the sequence each subscription resumes
+// after and keeps the latest callback.
+type fakeResumeSubscriber struct {
+	cb    RequestCallback
+	after []uint64
+}
+
+func (f *fakeResumeSubscriber) SubscribeAfter(_ string, _ proto.Message, after uint64,
+	cb RequestCallback) (int32, error) {
+	f.after = append(f.after, after)
+	f.cb = cb
+	return int32(len(f.after)), nil
+}
+
+func (f *fakeResumeSubscriber) deliver(t *testing.T, rt nanorpc.NanoRPCResponse_Type, seq uint64, snapshot bool) {
+	t.Helper()
+
+	resp := &nanorpc.NanoRPCResponse{
+		RequestId:      int32(len(f.after)),
+		ResponseType:   rt,
+		ResponseStatus: nanorpc.NanoRPCResponse_STATUS_OK,
+		Sequence:       seq,
+		Snapshot:       snapshot,
+	}
+	core.AssertNoError(t, f.cb(context.Background(), resp.RequestId, resp), "deliver")
+}
+
+var _ core.TestCase = resumeTestCase{}
+
+// resumeTestCase delivers events to a fresh [ResumableSubscription],
+// resubscribes and checks the sequence it resumed after.
+type resumeTestCase struct {
+	deliver  func(t *testing.T, f *fakeResumeSubscriber)
+	name     string
+	expected uint64
+}
+
+func (tc resumeTestCase) Name() string { return tc.name }
+
+func (tc resumeTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	var events int
+	rs, err := NewResumableSubscription("/sensors", nil,
+		func(context.Context, int32, *nanorpc.NanoRPCResponse) error {
+			events++
+			return nil
+		})
+	core.AssertMustNoError(t, err, "NewResumableSubscription")
+
+	f := &fakeResumeSubscriber{}
+	_, err = rs.Subscribe(f)
+	core.AssertMustNoError(t, err, "Subscribe")
+	tc.deliver(t, f)
+
+	id, err := rs.Subscribe(f)
+	core.AssertMustNoError(t, err, "resubscribe")
+	core.AssertEqual(t, int32(2), id, "ID")
+	core.AssertEqual(t, id, rs.ID(), "ID()")
+	core.AssertSliceEqual(t, []uint64{0, tc.expected}, f.after, "resumed after")
+	core.AssertEqual(t, tc.expected, rs.Sequence(), "Sequence")
+	core.AssertTrue(t, events > 0, "events forwarded")
+}
+
+func newResumeTestCase(name string, expected uint64,
+	deliver func(t *testing.T, f *fakeResumeSubscriber)) resumeTestCase {
+	return resumeTestCase{deliver: deliver, name: name, expected: expected}
+}
+
+func resumeTestCases() []resumeTestCase {
+	const ack, update = nanorpc.NanoRPCResponse_TYPE_RESPONSE, nanorpc.NanoRPCResponse_TYPE_UPDATE
+	return []resumeTestCase{
+		newResumeTestCase("acknowledged only", 10, func(t *testing.T, f *fakeResumeSubscriber) {
+			f.deliver(t, ack, 10, false)
+		}),
+		newResumeTestCase("updates", 12, func(t *testing.T, f *fakeResumeSubscriber) {
+			f.deliver(t, ack, 10, false)
+			f.deliver(t, update, 11, false)
+			f.deliver(t, update, 12, false)
+		}),
+		newResumeTestCase("unnumbered path", 0, func(t *testing.T, f *fakeResumeSubscriber) {
+			f.deliver(t, ack, 0, false)
+			f.deliver(t, update, 0, false)
+		}),
+	}
+}
+
+func TestResumableSubscription(t *testing.T) {
+	core.RunTestCases(t, resumeTestCases())
+}
+
+// TestResumableSubscription_CatchUp verifies a resumed acknowledgement
+// only moves the sequence forward when the gap wasn't covered.
+func TestResumableSubscription_CatchUp(t *testing.T) {
+	const ack, update = nanorpc.NanoRPCResponse_TYPE_RESPONSE, nanorpc.NanoRPCResponse_TYPE_UPDATE
+
+	rs, err := NewResumableSubscription("/sensors", nil, ignoreResponse)
+	core.AssertMustNoError(t, err, "NewResumableSubscription")
+
+	f := &fakeResumeSubscriber{}
+	_, _ = rs.Subscribe(f)
+	f.deliver(t, ack, 10, false)
+
+	// covered: catch-up updates follow the acknowledgement
+	_, _ = rs.Subscribe(f)
+	f.deliver(t, ack, 15, false)
+	core.AssertEqual(t, uint64(10), rs.Sequence(), "covered ack")
+	f.deliver(t, update, 11, false)
+	core.AssertEqual(t, uint64(11), rs.Sequence(), "catch-up update")
+
+	// not covered: the snapshot replaces the state
+	_, _ = rs.Subscribe(f)
+	f.deliver(t, ack, 30, true)
+	core.AssertEqual(t, uint64(30), rs.Sequence(), "snapshot ack")
+	core.AssertSliceEqual(t, []uint64{0, 10, 11}, f.after, "resumed after")
+}
+
+func TestNewResumableSubscription_MissingCallback(t *testing.T) {
+	_, err := NewResumableSubscription("/sensors", nil, nil)
+	core.AssertErrorIs(t, err, ErrMissingCallback, "error")
+}
+
+func TestClient_SubscribeHistory(t *testing.T) {
+	c, srv := newConnectedSession(t)
+
+	_, err := c.SubscribeHistory("/sensors", nil, 5, ignoreResponse)
+	core.AssertMustNoError(t, err, "SubscribeHistory")
+
+	req := srv.Recv()
+	core.AssertEqual(t, nanorpc.NanoRPCRequest_TYPE_SUBSCRIBE, req.RequestType, "request_type")
+	core.AssertEqual(t, uint32(5), req.History, "history")
+}
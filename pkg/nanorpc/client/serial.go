@@ -0,0 +1,20 @@
+package client
+
+import (
+	"io"
+	"net"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// DialSerial returns a connection for [Client.Attach] over a serial port,
+// or any [io.ReadWriteCloser] of a link like UART or RS-485, named name.
+// Messages are framed with COBS on the link, see [nanorpc.SerialConn],
+// so Config.Codec should be left unset. The port is closed along with
+// the connection.
+func DialSerial(rwc io.ReadWriteCloser, name string) (net.Conn, error) {
+	if rwc == nil {
+		return nil, ErrMissingConn
+	}
+	return nanorpc.NewSerialConn(rwc, name), nil
+}
@@ -0,0 +1,57 @@
+package client_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/client"
+)
+
+// TestLiveClient_AttachSerial drives a request over a connection from
+// [client.DialSerial], answered by a device at the other end of the link.
+func TestLiveClient_AttachSerial(t *testing.T) {
+	port, link := net.Pipe()
+	defer link.Close()
+	device := nanorpc.NewSerialConn(link, "host")
+
+	c, err := (&client.Config{
+		Context: context.Background(),
+		Remote:  "127.0.0.1:1",
+	}).New()
+	core.AssertMustNoError(t, err, "cfg.New")
+
+	ctx, cancel := context.WithTimeout(context.Background(), liveTimeout)
+	defer cancel()
+
+	_, err = client.DialSerial(nil, "/dev/ttyTEST")
+	core.AssertErrorIs(t, err, client.ErrMissingConn, "nil port")
+
+	conn, err := client.DialSerial(port, "/dev/ttyTEST")
+	core.AssertMustNoError(t, err, "DialSerial")
+	core.AssertMustNoError(t, c.Attach(ctx, conn), "Attach")
+	core.AssertMustNoError(t, c.WaitConnected(ctx), "WaitConnected")
+
+	events := make(chan cbEvent, 1)
+	id, err := c.Request("/echo", nil, liveRecordingCallback(events))
+	core.AssertMustNoError(t, err, "Request")
+
+	buf := make([]byte, 1024)
+	n, err := device.Read(buf)
+	core.AssertMustNoError(t, err, "read request")
+	req, _, err := nanorpc.DecodeRequest(buf[:n])
+	core.AssertMustNoError(t, err, "decode request")
+
+	data, err := nanorpc.EncodeResponse(newLiveResponse(req.RequestId,
+		nanorpc.NanoRPCResponse_TYPE_RESPONSE, nanorpc.NanoRPCResponse_STATUS_OK), nil)
+	core.AssertMustNoError(t, err, "encode response")
+	_, err = device.Write(data)
+	core.AssertMustNoError(t, err, "write response")
+
+	ev := mustRecvLiveEvent(t, events, "request")
+	core.AssertEqual(t, id, ev.id, "request_id")
+	core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_OK, ev.resp.ResponseStatus, "status")
+}
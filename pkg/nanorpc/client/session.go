@@ -2,9 +2,10 @@ package client
 
 import (
 	"context"
-	"io"
 	"net"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"darvaza.org/core"
 	"darvaza.org/slog"
@@ -24,9 +25,13 @@ type clientRequest struct {
 
 type clientRequestQueue struct {
 	Callback     RequestCallback
+	Request      *nanorpc.NanoRPCRequest
+	Expiry       *requestExpiry
+	SentAt       time.Time
 	RequestType  nanorpc.NanoRPCRequest_Type
 	RequestID    int32
 	Acknowledged bool
+	Stream       bool
 }
 
 // Session represents a connection to a NanoRPC server.
@@ -39,18 +44,30 @@ type Session struct {
 	ss     *reconnect.StreamSession[*nanorpc.NanoRPCResponse, clientRequest]
 	logger slog.Logger
 
-	cb []clientRequestQueue
-	mu sync.Mutex
+	out   *outboundQueue
+	cb    []clientRequestQueue
+	gate  inflightGate
+	ka    keepAlive
+	ids   requestIDs
+	hello atomic.Pointer[nanorpc.NanoRPCHello] // of the server, see sendHello
+	mu    sync.Mutex
 }
 
 // Spawn starts the required workers to handle the session
 func (cs *Session) Spawn() error {
+	if cs == nil {
+		return core.ErrNilReceiver
+	}
+
 	if err := cs.ss.Spawn(); err != nil {
 		return err
 	}
 
 	cs.ss.Go(cs.run)
-	return nil
+	if cs.out != nil {
+		cs.ss.Go(cs.runOutbound)
+	}
+	return cs.sendHello()
 }
 
 func (cs *Session) run(ctx context.Context) error {
@@ -64,7 +81,7 @@ func (cs *Session) run(ctx context.Context) error {
 func (cs *Session) doRunPass(ctx context.Context) error {
 	select {
 	case resp := <-cs.ss.Recv():
-		return cs.handleResponse(resp)
+		return cs.handleResponse(ctx, resp)
 	case <-ctx.Done():
 		return ctx.Err()
 	}
@@ -76,20 +93,33 @@ func (cs *Session) doRunPass(ctx context.Context) error {
 
 // IsActive indicates the session has registered callbacks
 func (cs *Session) IsActive() bool {
+	if cs == nil {
+		return false
+	}
+
 	cs.mu.Lock()
 	defer cs.mu.Unlock()
 
 	return len(cs.cb) > 0
 }
 
-func (cs *Session) handleResponse(resp *nanorpc.NanoRPCResponse) error {
-	if resp != nil && resp.RequestId > 0 {
+func (cs *Session) handleResponse(ctx context.Context, resp *nanorpc.NanoRPCResponse) error {
+	if resp == nil {
+		return nil
+	}
+
+	cs.logResponse(resp)
+	if err := cs.interceptResponse(ctx, resp); err != nil {
+		return err
+	}
+
+	if resp.RequestId > 0 {
 		reqID := resp.RequestId
 
 		if cb := cs.popRequestCallback(resp); cb != nil {
 			// report
 			cs.ss.Go(func(ctx context.Context) error {
-				return cb(ctx, reqID, resp)
+				return cs.callback(ctx, cb, reqID, resp)
 			})
 		}
 	}
@@ -98,7 +128,9 @@ func (cs *Session) handleResponse(resp *nanorpc.NanoRPCResponse) error {
 }
 
 // popRequestCallback locates the callback for an incoming response. A
-// TYPE_UPDATE always routes to the SUBSCRIBE entry and leaves it queued.
+// TYPE_UPDATE routes to the SUBSCRIBE entry and leaves it queued, unless
+// it's the final update of a subscription ended by the server; without
+// SUBSCRIBE entry it's a chunk for the queued entry of a streamed request.
 // Any other response prefers a non-SUBSCRIBE entry (plain request, ping,
 // or unsubscribe acknowledgement) and removes it; when that entry was
 // shadowing a SUBSCRIBE entry for the same request ID, the SUBSCRIBE
@@ -108,17 +140,17 @@ func (cs *Session) handleResponse(resp *nanorpc.NanoRPCResponse) error {
 func (cs *Session) popRequestCallback(resp *nanorpc.NanoRPCResponse) RequestCallback {
 	cs.mu.Lock()
 	defer cs.mu.Unlock()
+	defer cs.unsafeReportQueue()
 
 	subIdx, otherIdx := cs.unsafeIndexCallbacks(resp.RequestId)
 
 	if resp.ResponseType == nanorpc.NanoRPCResponse_TYPE_UPDATE {
-		if subIdx < 0 {
-			return nil
-		}
-		return cs.cb[subIdx].Callback
+		return cs.unsafeResolveUpdate(subIdx, otherIdx, resp)
 	}
 
 	if otherIdx >= 0 {
+		cs.unsafeObserve(otherIdx, resp)
+		cs.cb[otherIdx].Expiry.release()
 		cb := cs.cb[otherIdx].Callback
 		cs.unsafeRemoveResolved(subIdx, otherIdx)
 		return cb
@@ -130,6 +162,31 @@ func (cs *Session) popRequestCallback(resp *nanorpc.NanoRPCResponse) RequestCall
 	return cs.unsafeResolveSubscribeResponse(subIdx, resp)
 }
 
+// unsafeResolveUpdate routes a TYPE_UPDATE to the SUBSCRIBE entry at
+// subIdx. A final update (see [nanorpc.IsFinalUpdate]) moves the
+// subscription to Terminated, so its entry is dropped after the callback
+// is picked; a pending unsubscribe entry stays until its own
+// acknowledgement arrives. Without SUBSCRIBE entry, the update is a chunk
+// for the streamed request at otherIdx, which stays queued until the
+// TYPE_RESPONSE ending the stream. cs.mu must be held.
+func (cs *Session) unsafeResolveUpdate(subIdx, otherIdx int, resp *nanorpc.NanoRPCResponse) RequestCallback {
+	switch {
+	case subIdx >= 0:
+		// subscription update
+	case otherIdx >= 0 && cs.cb[otherIdx].Stream:
+		return cs.cb[otherIdx].Callback
+	default:
+		return nil
+	}
+
+	cb := cs.cb[subIdx].Callback
+	if nanorpc.IsFinalUpdate(resp) {
+		// Active -> Terminated: ended by the server
+		cs.cb = append(cs.cb[:subIdx], cs.cb[subIdx+1:]...)
+	}
+	return cb
+}
+
 // unsafeResolveSubscribeResponse handles a non-update response that matched
 // only a SUBSCRIBE entry, following the subscription lifecycle in
 // NANORPC_PROTOCOL.md §6.1. A Pending subscription takes exactly one
@@ -150,6 +207,7 @@ func (cs *Session) unsafeResolveSubscribeResponse(subIdx int,
 		return nil
 	}
 
+	cs.unsafeObserve(subIdx, resp)
 	cb := cs.cb[subIdx].Callback
 	if nanorpc.ResponseAsError(resp) != nil {
 		// Pending -> Terminated: subscribe rejected, drop the entry
@@ -174,13 +232,20 @@ func (cs *Session) unsafeResolveSubscribeResponse(subIdx int,
 // Callbacks fire without cs.mu held, so a callback may re-enter the session
 // safely.
 func (cs *Session) Close(ctx context.Context) error {
+	if cs == nil {
+		return core.ErrNilReceiver
+	}
+
 	cs.mu.Lock()
 	pending := cs.cb
 	cs.cb = nil
+	cs.unsafeReportQueue()
+	cs.unsafeReleaseInflight(true)
 	cs.mu.Unlock()
 
 	for _, x := range pending {
-		_ = x.Callback(ctx, x.RequestID, nil)
+		x.Expiry.release()
+		_ = cs.callback(ctx, x.Callback, x.RequestID, nil)
 	}
 	return nil
 }
@@ -192,14 +257,39 @@ func (cs *Session) Close(ctx context.Context) error {
 //
 // A nil req is rejected with [ErrNilRequest]. TYPE_REQUEST and
 // TYPE_SUBSCRIBE require a non-nil cb, else [ErrMissingCallback];
-// TYPE_PING does not; other request types yield [ErrInvalidRequestType].
+// TYPE_PING does not, and TYPE_ACK, never answered, takes none; other
+// request types yield [ErrInvalidRequestType].
 //
 // A TYPE_REQUEST carrying a positive RequestID is the unsubscribe
 // form (see [Client.Unsubscribe]); Send rejects it with
 // [ErrNoSubscription] when no subscription matches the RequestID, or
 // [ErrSubscriptionPending] when the subscription is not yet
 // acknowledged.
+//
+// With Config.MaxInflight set, a request beyond it waits for another to be
+// resolved, or fails with [ErrTooManyInflight], as Config.InflightPolicy
+// says.
 func (cs *Session) Send(req *nanorpc.NanoRPCRequest, payload proto.Message, cb RequestCallback) error {
+	if cs == nil {
+		return core.ErrNilReceiver
+	}
+
+	return cs.send(req, payload, cb, sendOptions{})
+}
+
+// sendOptions are the per-request settings of [Session.send].
+type sendOptions struct {
+	// ctx bounds the wait for the response, see [Client.RequestContext].
+	ctx context.Context
+	// stream marks a TYPE_REQUEST whose callback also takes the
+	// TYPE_UPDATE chunks of a streamed response.
+	stream bool
+	// priority orders the request in the outbound queue.
+	priority Priority
+}
+
+// send implements [Session.Send].
+func (cs *Session) send(req *nanorpc.NanoRPCRequest, payload proto.Message, cb RequestCallback, opts sendOptions) error {
 	if err := validateSendArgs(req, cb); err != nil {
 		return err
 	}
@@ -210,24 +300,57 @@ func (cs *Session) Send(req *nanorpc.NanoRPCRequest, payload proto.Message, cb R
 		}
 	}
 
-	cs.normaliseRequestID(req)
+	if cs.normaliseRequestID(req) {
+		// queued by the time send returns, if at all
+		defer cs.releaseRequestID(req.RequestId)
+	}
+
+	if err := cs.interceptRequest(req); err != nil {
+		return err
+	}
 
 	if cb != nil {
 		// remember callback
-		cs.registerCallback(clientRequestQueue{
-			RequestID:   req.RequestId,
-			RequestType: req.RequestType,
-			Callback:    cb,
-		})
+		if err := cs.registerRequest(req, cb, opts); err != nil {
+			return err
+		}
+	}
+
+	return cs.sendOutbound(clientRequest{req, payload}, opts.priority)
+}
+
+// registerRequest queues the callback of a request, expiring it as the
+// context of the request, or the RequestTimeout of the [Client], says.
+func (cs *Session) registerRequest(req *nanorpc.NanoRPCRequest, cb RequestCallback, opts sendOptions) error {
+	expiry := cs.newRequestExpiry(opts.ctx, req.RequestType)
+	if err := expiry.err(); err != nil {
+		expiry.release()
+		return err
+	}
+
+	err := cs.registerLimited(opts.ctx, clientRequestQueue{
+		Request:     requestHeader(req),
+		RequestID:   req.RequestId,
+		RequestType: req.RequestType,
+		Callback:    cb,
+		SentAt:      time.Now(),
+		Expiry:      expiry,
+		Stream:      opts.stream,
+	})
+	if err != nil {
+		expiry.release()
+		return err
 	}
 
-	return cs.ss.Send(clientRequest{req, payload})
+	// what's left once admitted, as the server counts from receiving it
+	req.TimeoutMs = expiry.timeoutMs()
+	return nil
 }
 
 // validateSendArgs rejects a Send call whose request is nil, whose type
 // is unknown, or whose callback is missing on the types that need one. It
 // runs first in Send so every later step (isUnsubscribeShape,
-// normaliseRequestID, registerCallback) can dereference req unguarded.
+// normaliseRequestID, registerRequest) can dereference req unguarded.
 func validateSendArgs(req *nanorpc.NanoRPCRequest, cb RequestCallback) error {
 	if req == nil {
 		return ErrNilRequest
@@ -243,6 +366,12 @@ func validateSendArgs(req *nanorpc.NanoRPCRequest, cb RequestCallback) error {
 			return ErrMissingCallback
 		}
 		return nil
+	case nanorpc.NanoRPCRequest_TYPE_ACK:
+		// never answered
+		if cb != nil {
+			return core.QuietWrap(ErrInvalidRequestType, "TYPE_ACK with callback")
+		}
+		return nil
 	default:
 		// invalid type
 		return core.QuietWrap(ErrInvalidRequestType, "%v", int(req.RequestType))
@@ -259,21 +388,24 @@ func isUnsubscribeShape(req *nanorpc.NanoRPCRequest) bool {
 
 // normaliseRequestID assigns a fresh id when the caller supplied zero and
 // folds a negative id to zero so the server sees no client-side sentinel.
-func (cs *Session) normaliseRequestID(req *nanorpc.NanoRPCRequest) {
+// It tells if the id was assigned, and reserved by nextRequestID.
+func (cs *Session) normaliseRequestID(req *nanorpc.NanoRPCRequest) bool {
 	switch {
 	case req.RequestId < 0:
 		req.RequestId = 0
 	case req.RequestId == 0:
 		req.RequestId = cs.nextRequestID()
+		return true
 	default:
 		// keep caller-supplied positive RequestId
 	}
+	return false
 }
 
 // checkUnsubscribeTarget verifies that an unsubscribe targets an
 // acknowledged subscription before its callback is registered. The check
-// and the later registerCallback do not share a single lock hold; a
-// subsequent removal between this check and registerCallback only causes
+// and the later registerRequest do not share a single lock hold; a
+// subsequent removal between this check and registerRequest only causes
 // the unsubscribe ack to fire the registered callback against an
 // already-empty queue, which is the same observable outcome as a clean
 // unsubscribe.
@@ -291,25 +423,12 @@ func (cs *Session) checkUnsubscribeTarget(reqID int32) error {
 	return nil
 }
 
-// registerCallback appends a queue entry under cs.mu.
-func (cs *Session) registerCallback(x clientRequestQueue) {
-	cs.mu.Lock()
+// unsafeRegisterCallback appends a queue entry, and starts its expiry.
+// cs.mu must be held.
+func (cs *Session) unsafeRegisterCallback(x clientRequestQueue) {
 	cs.cb = append(cs.cb, x)
-	cs.mu.Unlock()
-}
-
-func (cs *Session) nextRequestID() int32 {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
-
-	for {
-		next := cs.c.reqCounter.Next()
-
-		_, dupe := cs.unsafeIndexRequestCallback(next)
-		if !dupe {
-			return next
-		}
-	}
+	x.Expiry.start(cs)
+	cs.unsafeReportQueue()
 }
 
 // unsafeIndexRequestCallback finds the position of the callback for a RequestID.
@@ -403,6 +522,7 @@ func (cs *Session) onUnsetWriteDeadline() error {
 
 func (cs *Session) onError(err error) {
 	cs.LogError(err, nil, "session run loop: %v", err)
+	cs.c.reportError(SessionError{Err: err, Addr: cs.ra})
 }
 
 //
@@ -411,21 +531,17 @@ func (cs *Session) onError(err error) {
 
 func newClientSession(ctx context.Context, c *Client, queueSize uint, conn net.Conn) *Session {
 	ss := &reconnect.StreamSession[*nanorpc.NanoRPCResponse, clientRequest]{
-		QueueSize: queueSize,
+		// requests wait in cs.out, by priority
+		QueueSize: 1,
 		Conn:      c.rc,
 		Context:   ctx,
 
-		Split: nanorpc.Split,
-		MarshalTo: func(r clientRequest, w io.Writer) error {
-			_, err := nanorpc.EncodeRequestTo(w, r.r, r.d)
-			return err
-		},
-		Unmarshal: func(data []byte) (*nanorpc.NanoRPCResponse, error) {
-			resp, _, err := nanorpc.DecodeResponse(data)
-			return resp, err
-		},
+		Split:     c.getCodec().Split(c.getMaxMessageSize()),
+		Unmarshal: c.decodeResponse,
 	}
 
+	c.useTLS(ss, conn)
+
 	// Create session logger with fields added once
 	sessionLogger := utils.WithComponent(c.getLogger(), utils.ComponentSession)
 	sessionLogger = utils.WithRemoteAddr(sessionLogger, conn.RemoteAddr())
@@ -437,9 +553,11 @@ func newClientSession(ctx context.Context, c *Client, queueSize uint, conn net.C
 		logger: sessionLogger,
 
 		ss:        ss,
+		out:       newOutboundQueue(queueSize),
 		WorkGroup: ss,
 	}
 
+	cs.ss.MarshalTo = cs.onMarshal
 	cs.ss.SetReadDeadline = cs.onSetReadDeadline
 	cs.ss.SetWriteDeadline = cs.onSetWriteDeadline
 	cs.ss.UnsetReadDeadline = cs.onUnsetReadDeadline
@@ -153,6 +153,29 @@ func trySignal(ch chan struct{}) {
 	}
 }
 
+// liveStarted returns an OnConnect callback for a client built by
+// newLiveClient, and a function waiting for it. WaitConnected returns once
+// the session is published, before it's started, so tests sending right
+// after it wait for OnConnect too, as newLiveFixture does.
+func liveStarted(t *testing.T) (func(context.Context, reconnect.WorkGroup) error, func()) {
+	t.Helper()
+
+	connects := make(chan struct{}, 1)
+	onConnect := func(context.Context, reconnect.WorkGroup) error {
+		trySignal(connects)
+		return nil
+	}
+	wait := func() {
+		t.Helper()
+		select {
+		case <-connects:
+		case <-time.After(liveTimeout):
+			t.Fatal("timed out waiting for the OnConnect callback")
+		}
+	}
+	return onConnect, wait
+}
+
 // TestLiveClient_Request_roundTrip drives a request over a real connection:
 // dialling fires onReconnectConnect (session build) and onReconnectSession
 // (Spawn + user OnConnect), and the idle pre-read deadline hook runs before
@@ -326,3 +349,38 @@ func mustRecvError(t *testing.T, ch <-chan error, what string) error {
 		return nil
 	}
 }
+
+// TestLiveClient_MaxMessageSize verifies a response larger than
+// Config.MaxMessageSize ends the session, reported through Errors.
+func TestLiveClient_MaxMessageSize(t *testing.T) {
+	srv := server.New(t)
+	onConnect, waitStarted := liveStarted(t)
+	c := newLiveClient(t, srv, client.Config{MaxMessageSize: 64, OnConnect: onConnect})
+	core.AssertMustNoError(t, c.Connect(), "Connect")
+	conn := srv.Accept()
+
+	ctx, cancel := context.WithTimeout(context.Background(), liveTimeout)
+	defer cancel()
+	core.AssertMustNoError(t, c.WaitConnected(ctx), "WaitConnected")
+	waitStarted()
+
+	id, err := c.Request("/echo", nil, liveRecordingCallback(make(chan cbEvent, 4)))
+	core.AssertMustNoError(t, err, "Request")
+	conn.Recv()
+
+	resp := newLiveResponse(id, nanorpc.NanoRPCResponse_TYPE_RESPONSE, nanorpc.NanoRPCResponse_STATUS_OK)
+	resp.Data = make([]byte, 128)
+	conn.Reply(resp)
+
+	for {
+		select {
+		case err := <-c.Errors():
+			var se client.SessionError
+			if errors.As(err, &se) && errors.Is(err, nanorpc.ErrMessageTooLarge) {
+				return
+			}
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for ErrMessageTooLarge")
+		}
+	}
+}
@@ -20,6 +20,7 @@ const (
 	reqRequest     = nanorpc.NanoRPCRequest_TYPE_REQUEST
 	reqSubscribe   = nanorpc.NanoRPCRequest_TYPE_SUBSCRIBE
 	reqPing        = nanorpc.NanoRPCRequest_TYPE_PING
+	reqAck         = nanorpc.NanoRPCRequest_TYPE_ACK
 
 	respResponse = nanorpc.NanoRPCResponse_TYPE_RESPONSE
 	respUpdate   = nanorpc.NanoRPCResponse_TYPE_UPDATE
@@ -151,6 +152,12 @@ func routingTestCases() []routingTestCase {
 		newRoutingTestCase("double_unsubscribe_first_wins",
 			core.S(subAcknowledged(9), req(9), req(9)), 9, respResponse,
 			1, core.S(req(9))),
+		newRoutingErrTestCase("final_update_drops_subscribe",
+			core.S(subAcknowledged(9)), 9, respUpdate, statusNotFound,
+			0, nil),
+		newRoutingErrTestCase("final_update_keeps_pending_unsubscribe",
+			core.S(subAcknowledged(9), req(9)), 9, respUpdate, statusNotFound,
+			0, core.S(req(9))),
 		newRoutingTestCase("update_without_subscribe_returns_nil",
 			core.S(req(9)), 9, respUpdate,
 			-1, core.S(req(9))),
@@ -278,6 +285,8 @@ func sendRejectTestCases() []sendRejectTestCase {
 			nil, reqRequest, 0, nil, ErrMissingCallback),
 		newSendRejectTestCase("subscribe_missing_callback",
 			nil, reqSubscribe, 0, nil, ErrMissingCallback),
+		newSendRejectTestCase("ack_with_callback",
+			nil, reqAck, 5, discardCallback(), ErrInvalidRequestType),
 		newSendRejectTestCase("unsubscribe_no_subscription",
 			nil, reqRequest, 5, discardCallback(), ErrNoSubscription),
 		newSendRejectTestCase("unsubscribe_pending",
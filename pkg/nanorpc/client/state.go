@@ -0,0 +1,134 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"darvaza.org/x/net/reconnect"
+)
+
+// State is the connection state of a [Client], see [Client.StateEvents].
+type State int
+
+const (
+	// StateDisconnected is the state of a [Client] without session, before
+	// Connect or once a session ends. The zero value.
+	StateDisconnected State = iota
+	// StateConnecting is entered by [Client.Connect], until the first
+	// connection is made.
+	StateConnecting
+	// StateConnected is entered when a session is established.
+	StateConnected
+	// StateReconnecting is entered when the [Client] tries to connect
+	// again after a failure or disconnection, once Config.WaitReconnect
+	// allows it.
+	StateReconnecting
+	// StateGaveUp is entered when Config.WaitReconnect stops the
+	// reconnect loop. The [Client] won't connect again.
+	StateGaveUp
+)
+
+func (s State) String() string {
+	switch s {
+	case StateDisconnected:
+		return "disconnected"
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateReconnecting:
+		return "reconnecting"
+	case StateGaveUp:
+		return "gave up"
+	default:
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+}
+
+// StateEvent reports a change of the connection state of a [Client].
+type StateEvent struct {
+	// Time is when the state changed.
+	Time time.Time
+	// Err is why, when the change was caused by a failure.
+	Err error
+	// State is the new state.
+	State State
+}
+
+// State returns the current connection state of the [Client].
+func (c *Client) State() State {
+	if c == nil {
+		return StateDisconnected
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.state
+}
+
+// StateEvents returns the channel where the [Client] reports the changes
+// of its connection state, so applications can update their UI or alert
+// without polling. It holds the latest Config.StateQueueSize events, older
+// ones are dropped when nobody reads them, and is never closed. See also
+// Config.OnStateChange.
+func (c *Client) StateEvents() <-chan StateEvent {
+	if c == nil {
+		return nil
+	}
+	return c.states
+}
+
+// setState records a state change, reporting it to the
+// [Client.StateEvents] channel and Config.OnStateChange.
+func (c *Client) setState(s State, err error) {
+	c.mu.Lock()
+	c.state = s
+	fn := c.callOnStateChange
+	c.mu.Unlock()
+
+	ev := StateEvent{Time: time.Now(), Err: err, State: s}
+	c.reportState(ev)
+	if fn != nil {
+		fn(ev)
+	}
+}
+
+// reportState queues ev on the [Client.StateEvents] channel, dropping the
+// oldest events queued if full.
+func (c *Client) reportState(ev StateEvent) {
+	if c.states == nil {
+		return
+	}
+
+	for {
+		select {
+		case c.states <- ev:
+			return
+		default:
+		}
+
+		select {
+		case <-c.states:
+			// drop oldest
+		default:
+		}
+	}
+}
+
+// newStateWaiter wraps the Config.WaitReconnect of the reconnect loop to
+// tell reconnecting from giving up. Stopping because the [Client] is shut
+// down isn't giving up.
+func (c *Client) newStateWaiter(wait reconnect.Waiter) reconnect.Waiter {
+	return func(ctx context.Context) error {
+		err := wait(ctx)
+		switch {
+		case err == nil:
+			c.setState(StateReconnecting, nil)
+		case ctx.Err() == nil:
+			c.setState(StateGaveUp, err)
+		}
+		return err
+	}
+}
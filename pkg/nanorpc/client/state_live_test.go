@@ -0,0 +1,62 @@
+package client_test
+
+import (
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+	"darvaza.org/x/net/reconnect"
+
+	"protomcp.org/nanorpc/pkg/nanorpc/client"
+	"protomcp.org/nanorpc/pkg/nanorpc/mock/server"
+)
+
+// mustRecvState waits for the next state change, failing on timeout.
+func mustRecvState(t *testing.T, ch <-chan client.StateEvent, want client.State) client.StateEvent {
+	t.Helper()
+
+	select {
+	case ev := <-ch:
+		core.AssertEqual(t, want, ev.State, "state")
+		return ev
+	case <-time.After(liveTimeout):
+		t.Fatalf("timed out waiting for the %s state", want)
+		return client.StateEvent{}
+	}
+}
+
+// TestLiveClient_StateEvents follows a client through its connection
+// lifecycle until the reconnect waiter gives up, as reported by both
+// StateEvents and OnStateChange.
+func TestLiveClient_StateEvents(t *testing.T) {
+	srv := server.New(t)
+
+	changes := make(chan client.StateEvent, 8)
+	c := newLiveClient(t, srv, client.Config{
+		WaitReconnect: reconnect.NewDoNotReconnectWaiter(nil),
+		OnStateChange: func(ev client.StateEvent) { changes <- ev },
+	})
+	core.AssertEqual(t, client.StateDisconnected, c.State(), "initial state")
+
+	core.AssertMustNoError(t, c.Connect(), "Connect")
+	srv.Accept()
+
+	for _, ch := range []<-chan client.StateEvent{c.StateEvents(), changes} {
+		mustRecvState(t, ch, client.StateConnecting)
+		mustRecvState(t, ch, client.StateConnected)
+	}
+	core.AssertEqual(t, client.StateConnected, c.State(), "connected state")
+
+	core.AssertMustNoError(t, srv.Close(), "server close")
+
+	for _, ch := range []<-chan client.StateEvent{c.StateEvents(), changes} {
+		mustRecvState(t, ch, client.StateDisconnected)
+		ev := mustRecvState(t, ch, client.StateGaveUp)
+		core.AssertError(t, ev.Err, "give up cause")
+	}
+}
+
+func TestState_String(t *testing.T) {
+	core.AssertEqual(t, "gave up", client.StateGaveUp.String(), "GaveUp")
+	core.AssertEqual(t, "State(42)", client.State(42).String(), "unknown")
+}
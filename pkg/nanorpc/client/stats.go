@@ -0,0 +1,233 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"maps"
+	"sync"
+	"time"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// Stats summarises the latency of the responses received by a [Client].
+// Round-trip times are measured for every ping and request; when the
+// server attaches timestamps they are further split into server
+// processing time and network time.
+//
+// When Config.MeasureEncoding is set, Encoding also accounts the cost of
+// encoding the requests sent, per path, to tell which messages are too
+// heavy for the link.
+type Stats struct {
+	// Encoding is the cost of encoding requests by path. Requests sent by
+	// a hash unknown to the HashCache are accounted under an empty path.
+	Encoding map[string]EncodingStats
+	// RoundTrip is the accumulated time between sending requests and
+	// receiving their responses.
+	RoundTrip time.Duration
+	// ServerTime is the accumulated server processing time reported by
+	// timestamped responses.
+	ServerTime time.Duration
+	// NetworkTime is the accumulated round-trip time of timestamped
+	// responses not spent processing on the server.
+	NetworkTime time.Duration
+	// LastRoundTrip is the round-trip time of the latest response.
+	LastRoundTrip time.Duration
+	// LastServerTime is the server processing time of the latest
+	// timestamped response.
+	LastServerTime time.Duration
+	// Responses counts the responses with a measured round-trip time.
+	Responses uint64
+	// Timestamped counts the responses carrying server timestamps.
+	Timestamped uint64
+	// Saturated counts the requests that found Config.MaxInflight
+	// requests in flight, and waited or failed.
+	Saturated uint64
+	// UpdateGaps counts the gaps found in the updates of acknowledged
+	// subscriptions, see [Client.SubscribeAcked].
+	UpdateGaps uint64
+	// DuplicateUpdates counts the updates of acknowledged subscriptions
+	// received again, and dropped.
+	DuplicateUpdates uint64
+	// Inflight is the number of requests and pending subscriptions
+	// currently awaiting their response, pings aside.
+	Inflight int
+}
+
+// AvgRoundTrip returns the mean round-trip time.
+func (s Stats) AvgRoundTrip() time.Duration {
+	return average(s.RoundTrip, s.Responses)
+}
+
+// AvgServerTime returns the mean server processing time of timestamped
+// responses.
+func (s Stats) AvgServerTime() time.Duration {
+	return average(s.ServerTime, s.Timestamped)
+}
+
+// AvgNetworkTime returns the mean network time of timestamped responses.
+func (s Stats) AvgNetworkTime() time.Duration {
+	return average(s.NetworkTime, s.Timestamped)
+}
+
+// EncodingStats accounts the requests encoded for a path.
+type EncodingStats struct {
+	// Time is the accumulated time spent marshalling the requests.
+	Time time.Duration
+	// Bytes is the accumulated size of the encoded frames.
+	Bytes uint64
+	// Requests counts the requests encoded.
+	Requests uint64
+	// MaxBytes is the size of the largest frame.
+	MaxBytes int
+}
+
+// AvgTime returns the mean time spent marshalling a request.
+func (s EncodingStats) AvgTime() time.Duration {
+	return average(s.Time, s.Requests)
+}
+
+// AvgBytes returns the mean size of the encoded frames.
+func (s EncodingStats) AvgBytes() int {
+	if s.Requests == 0 {
+		return 0
+	}
+	return int(s.Bytes / s.Requests)
+}
+
+func average(total time.Duration, n uint64) time.Duration {
+	if n == 0 {
+		return 0
+	}
+	return total / time.Duration(n)
+}
+
+// clientStats accumulates [Stats] safely for concurrent use.
+type clientStats struct {
+	s  Stats
+	mu sync.Mutex
+}
+
+// observe accounts a response to a request sent at sent.
+func (cs *clientStats) observe(sent time.Time, resp *nanorpc.NanoRPCResponse) {
+	if sent.IsZero() {
+		return
+	}
+	rtt := time.Since(sent)
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	cs.s.Responses++
+	cs.s.RoundTrip += rtt
+	cs.s.LastRoundTrip = rtt
+
+	if server, ok := nanorpc.ServerTime(resp); ok && server <= rtt {
+		cs.s.Timestamped++
+		cs.s.ServerTime += server
+		cs.s.NetworkTime += rtt - server
+		cs.s.LastServerTime = server
+	}
+}
+
+// observeSaturated counts a request held back by Config.MaxInflight.
+func (cs *clientStats) observeSaturated() {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	cs.s.Saturated++
+}
+
+// observeGap counts a gap in the updates of an acknowledged subscription.
+func (cs *clientStats) observeGap() {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	cs.s.UpdateGaps++
+}
+
+// observeDuplicate counts an update of an acknowledged subscription
+// received again.
+func (cs *clientStats) observeDuplicate() {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	cs.s.DuplicateUpdates++
+}
+
+// observeEncoding accounts a request to path encoded into a frame of size
+// bytes in the given time.
+func (cs *clientStats) observeEncoding(path string, d time.Duration, size int) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.s.Encoding == nil {
+		cs.s.Encoding = make(map[string]EncodingStats)
+	}
+
+	es := cs.s.Encoding[path]
+	es.Time += d
+	es.Bytes += uint64(size)
+	es.Requests++
+	es.MaxBytes = max(es.MaxBytes, size)
+	cs.s.Encoding[path] = es
+}
+
+func (cs *clientStats) get() Stats {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	out := cs.s
+	out.Encoding = maps.Clone(cs.s.Encoding)
+	return out
+}
+
+// Stats returns the latency statistics of the responses received so far,
+// and the requests currently in flight.
+func (c *Client) Stats() Stats {
+	if c == nil {
+		return Stats{}
+	}
+
+	out := c.stats.get()
+	out.Inflight = c.inflight()
+	return out
+}
+
+// unsafeObserve accounts resp against the queue entry at idx.
+// cs.mu must be held.
+func (cs *Session) unsafeObserve(idx int, resp *nanorpc.NanoRPCResponse) {
+	if cs.c != nil {
+		cs.c.stats.observe(cs.cb[idx].SentAt, resp)
+		cs.unsafeObserveMetrics(idx, resp)
+	}
+}
+
+// encodeRequest writes the frame of a request, accounting its cost when
+// Config.MeasureEncoding is set. The frame is then encoded in memory, as
+// it is for codecs other than the length prefix, so the time measured
+// excludes writing it out.
+func (c *Client) encodeRequest(w io.Writer, r clientRequest) error {
+	measure := c.measureEncoding && r.r.GetRequestType() != nanorpc.NanoRPCRequest_TYPE_PING
+	if !measure && nanorpc.IsLengthPrefixed(c.codec) {
+		_, err := nanorpc.EncodeRequestTo(w, r.r, r.d)
+		return err
+	}
+
+	var buf bytes.Buffer
+	start := time.Now()
+	if _, err := nanorpc.EncodeRequestTo(&buf, r.r, r.d); err != nil {
+		return err
+	}
+
+	frame, err := c.frame(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	if measure {
+		c.stats.observeEncoding(c.requestPath(r.r), time.Since(start), len(frame))
+	}
+
+	_, err = w.Write(frame)
+	return err
+}
@@ -0,0 +1,142 @@
+package client
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+var _ core.TestCase = statsAverageTestCase{}
+
+type statsAverageTestCase struct {
+	name    string
+	stats   Stats
+	rtt     time.Duration
+	server  time.Duration
+	network time.Duration
+}
+
+func (tc statsAverageTestCase) Name() string { return tc.name }
+
+func (tc statsAverageTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	core.AssertEqual(t, tc.rtt, tc.stats.AvgRoundTrip(), "round-trip")
+	core.AssertEqual(t, tc.server, tc.stats.AvgServerTime(), "server")
+	core.AssertEqual(t, tc.network, tc.stats.AvgNetworkTime(), "network")
+}
+
+func statsAverageTestCases() []statsAverageTestCase {
+	return []statsAverageTestCase{
+		{name: "empty"},
+		{
+			name: "untimed",
+			stats: Stats{
+				RoundTrip: 30 * time.Millisecond,
+				Responses: 3,
+			},
+			rtt: 10 * time.Millisecond,
+		},
+		{
+			name: "timestamped",
+			stats: Stats{
+				RoundTrip:   40 * time.Millisecond,
+				ServerTime:  6 * time.Millisecond,
+				NetworkTime: 14 * time.Millisecond,
+				Responses:   4,
+				Timestamped: 2,
+			},
+			rtt:     10 * time.Millisecond,
+			server:  3 * time.Millisecond,
+			network: 7 * time.Millisecond,
+		},
+	}
+}
+
+func TestStats_Averages(t *testing.T) {
+	core.RunTestCases(t, statsAverageTestCases())
+}
+
+// TestClient_Stats drives a timestamped and a plain response through the
+// run loop and checks how each is accounted.
+func TestClient_Stats(t *testing.T) {
+	c, srv := newConnectedSession(t)
+	events := make(chan cbEvent, 4)
+
+	_, err := c.Request("/echo", nil, recordingCallback(events))
+	core.AssertMustNoError(t, err, "Request")
+	req := srv.Recv()
+
+	time.Sleep(5 * time.Millisecond)
+	resp := newResponse(req.RequestId, respResponse, statusOK)
+	resp.Timestamps = &nanorpc.NanoRPCTimestamps{ReceivedUs: 1000, ProcessedUs: 2000}
+	srv.Reply(resp)
+	mustRecvEvent(t, events, "timestamped")
+
+	_, err = c.Request("/echo", nil, recordingCallback(events))
+	core.AssertMustNoError(t, err, "Request")
+	req = srv.Recv()
+	srv.Reply(newResponse(req.RequestId, respResponse, statusOK))
+	mustRecvEvent(t, events, "plain")
+
+	stats := c.Stats()
+	core.AssertEqual(t, uint64(2), stats.Responses, "responses")
+	core.AssertEqual(t, uint64(1), stats.Timestamped, "timestamped")
+	core.AssertEqual(t, time.Millisecond, stats.ServerTime, "server time")
+	core.AssertEqual(t, time.Millisecond, stats.LastServerTime, "last server time")
+	core.AssertTrue(t, stats.NetworkTime >= 4*time.Millisecond, "network time")
+	core.AssertTrue(t, stats.RoundTrip >= stats.ServerTime+stats.NetworkTime, "round-trip")
+
+	var nilClient *Client
+	core.AssertDeepEqual(t, Stats{}, nilClient.Stats(), "nil client")
+}
+
+// TestClient_encodeRequest checks the encoding of requests is accounted
+// per path only when measured, and never for pings.
+func TestClient_encodeRequest(t *testing.T) {
+	c := newClientForTest(t)
+	payload := &nanorpc.NanoRPCTimestamps{ReceivedUs: 1000}
+	newRequest := func(reqType nanorpc.NanoRPCRequest_Type) clientRequest {
+		return clientRequest{
+			r: &nanorpc.NanoRPCRequest{
+				RequestId:   1,
+				RequestType: reqType,
+				PathOneof:   nanorpc.GetPathOneOfString("/echo"),
+			},
+			d: payload,
+		}
+	}
+
+	var buf bytes.Buffer
+	core.AssertMustNoError(t, c.encodeRequest(&buf, newRequest(reqRequest)), "unmeasured")
+	core.AssertNil(t, c.Stats().Encoding, "unmeasured stats")
+
+	c.measureEncoding = true
+	buf.Reset()
+	core.AssertMustNoError(t, c.encodeRequest(&buf, newRequest(reqRequest)), "measured")
+	size := buf.Len()
+	req, _, err := nanorpc.DecodeRequest(buf.Bytes())
+	core.AssertMustNoError(t, err, "DecodeRequest")
+	core.AssertEqual(t, "/echo", req.GetPath(), "path")
+
+	core.AssertMustNoError(t, c.encodeRequest(&buf, newRequest(reqRequest)), "measured again")
+	core.AssertMustNoError(t, c.encodeRequest(&buf, newRequest(reqPing)), "ping")
+
+	stats := c.Stats()
+	core.AssertEqual(t, 1, len(stats.Encoding), "paths")
+	es := stats.Encoding["/echo"]
+	core.AssertEqual(t, uint64(2), es.Requests, "requests")
+	core.AssertEqual(t, uint64(2*size), es.Bytes, "bytes")
+	core.AssertEqual(t, size, es.MaxBytes, "max bytes")
+	core.AssertEqual(t, size, es.AvgBytes(), "average bytes")
+	core.AssertEqual(t, es.Time/2, es.AvgTime(), "average time")
+
+	// Stats returns a copy
+	stats.Encoding["/echo"] = EncodingStats{}
+	core.AssertEqual(t, uint64(2), c.Stats().Encoding["/echo"].Requests, "copy")
+	core.AssertEqual(t, 0, EncodingStats{}.AvgBytes(), "empty")
+}
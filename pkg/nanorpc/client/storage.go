@@ -0,0 +1,182 @@
+package client
+
+import (
+	"cmp"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+var _ Storage = (*FileStorage)(nil)
+
+// StoredRequest is a request held by a [Storage] until the server answers
+// it, see [Client.RequestStored].
+type StoredRequest struct {
+	Path string
+	Data []byte
+	// ID orders the stored requests, and identifies them.
+	ID uint64
+}
+
+// Storage persists the requests of [Client.RequestStored] until the server
+// answers them, so they survive disconnections and restarts.
+//
+// Store saves a request, replacing any of the same ID. Load returns the
+// requests saved, ordered by ID. Delete forgets a request, whether saved
+// or not. Implementations must be safe for concurrent use.
+type Storage interface {
+	Store(StoredRequest) error
+	Load() ([]StoredRequest, error)
+	Delete(id uint64) error
+}
+
+// storedRequestExt is the extension of the files of a [FileStorage].
+const storedRequestExt = ".req"
+
+// FileStorage is a [Storage] keeping every request in a file of its
+// directory, named after its ID. Files are written whole before being
+// renamed in place, so a crash never leaves a partial request behind.
+type FileStorage struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// NewFileStorage creates a [FileStorage] in dir, creating the directory
+// if needed.
+func NewFileStorage(dir string) (*FileStorage, error) {
+	if dir == "" {
+		return nil, core.QuietWrap(ErrMissingStorage, "directory missing")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileStorage{dir: dir}, nil
+}
+
+func (s *FileStorage) fileName(id uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%016x%s", id, storedRequestExt))
+}
+
+// Store writes a request to its file, replacing it if present.
+func (s *FileStorage) Store(r StoredRequest) error {
+	if s == nil {
+		return core.ErrNilReceiver
+	}
+
+	data, err := proto.Marshal(&nanorpc.NanoRPCRequest{
+		RequestType: nanorpc.NanoRPCRequest_TYPE_REQUEST,
+		PathOneof:   &nanorpc.NanoRPCRequest_Path{Path: r.Path},
+		Data:        r.Data,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return writeFileSync(s.fileName(r.ID), data)
+}
+
+// writeFileSync writes data to a temporary file, flushed to disk before
+// being renamed to name.
+func writeFileSync(name string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(name), "*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
+
+// Load reads the requests stored, ordered by ID. Files of other names are
+// ignored.
+func (s *FileStorage) Load() ([]StoredRequest, error) {
+	if s == nil {
+		return nil, core.ErrNilReceiver
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]StoredRequest, 0, len(entries))
+	for _, e := range entries {
+		r, ok, err := s.loadEntry(e)
+		switch {
+		case err != nil:
+			return nil, err
+		case ok:
+			out = append(out, r)
+		}
+	}
+
+	slices.SortFunc(out, func(a, b StoredRequest) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return out, nil
+}
+
+// loadEntry reads the request stored in a directory entry, if it's one.
+func (s *FileStorage) loadEntry(e fs.DirEntry) (StoredRequest, bool, error) {
+	hex, ok := strings.CutSuffix(e.Name(), storedRequestExt)
+	if !ok || !e.Type().IsRegular() {
+		return StoredRequest{}, false, nil
+	}
+	id, err := strconv.ParseUint(hex, 16, 64)
+	if err != nil {
+		return StoredRequest{}, false, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.dir, e.Name()))
+	if err != nil {
+		return StoredRequest{}, false, err
+	}
+
+	var m nanorpc.NanoRPCRequest
+	if err := proto.Unmarshal(data, &m); err != nil {
+		return StoredRequest{}, false, core.Wrapf(err, "stored request %d", id)
+	}
+	return StoredRequest{ID: id, Path: m.GetPath(), Data: m.Data}, true, nil
+}
+
+// Delete removes the file of a request, if present.
+func (s *FileStorage) Delete(id uint64) error {
+	if s == nil {
+		return core.ErrNilReceiver
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := os.Remove(s.fileName(id))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"darvaza.org/core"
+)
+
+// storedIDs returns the IDs of the requests stored, in the order loaded.
+func storedIDs(t *testing.T, s Storage) []uint64 {
+	t.Helper()
+
+	stored, err := s.Load()
+	core.AssertMustNoError(t, err, "Load")
+
+	var ids []uint64
+	for _, r := range stored {
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
+
+func TestFileStorage(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewFileStorage(dir)
+	core.AssertMustNoError(t, err, "NewFileStorage")
+
+	for _, id := range []uint64{17, 2, 300} {
+		core.AssertMustNoError(t, s.Store(StoredRequest{ID: id, Path: "/old"}), "Store %d", id)
+	}
+	// same ID replaces
+	core.AssertMustNoError(t, s.Store(StoredRequest{ID: 2, Path: "/telemetry", Data: []byte{1, 2}}), "Store 2")
+	// other files are ignored
+	core.AssertMustNoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0o600), "WriteFile")
+
+	stored, err := s.Load()
+	core.AssertMustNoError(t, err, "Load")
+	core.AssertMustEqual(t, 3, len(stored), "stored")
+	core.AssertEqual(t, "/telemetry", stored[0].Path, "path")
+	core.AssertSliceEqual(t, []byte{1, 2}, stored[0].Data, "data")
+
+	core.AssertNoError(t, s.Delete(17), "Delete")
+	core.AssertNoError(t, s.Delete(17), "Delete missing")
+
+	// survives reopening
+	s, err = NewFileStorage(dir)
+	core.AssertMustNoError(t, err, "reopen")
+	core.AssertSliceEqual(t, []uint64{2, 300}, storedIDs(t, s), "ids")
+}
+
+func TestNewFileStorage_noDir(t *testing.T) {
+	_, err := NewFileStorage("")
+	core.AssertErrorIs(t, err, ErrMissingStorage, "NewFileStorage")
+}
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"context"
+	"sync"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// StreamCallback handles the responses to [Client.RequestStream]. It's
+// called with every chunk, a TYPE_UPDATE, and final false, and then with
+// the TYPE_RESPONSE ending the stream, or nil if the session ended first,
+// and final true. Calls don't overlap, and numbered chunks are passed in
+// order.
+type StreamCallback func(ctx context.Context, id int32, resp *nanorpc.NanoRPCResponse, final bool) error
+
+// RequestStream enqueues a NanoRPC request whose response may be streamed
+// as a series of TYPE_UPDATE chunks followed by the TYPE_RESPONSE ending
+// it, optionally converting path to path_hash if
+// [ClientOptions].AlwaysHashPaths was set.
+func (c *Client) RequestStream(path string, msg proto.Message, cb StreamCallback) (int32, error) {
+	if c == nil {
+		return 0, core.ErrNilReceiver
+	}
+	if cb == nil {
+		return 0, ErrMissingCallback
+	}
+
+	cs, err := c.getSession()
+	if err != nil {
+		return 0, err
+	}
+
+	// assemble header
+	m := &nanorpc.NanoRPCRequest{
+		RequestType: nanorpc.NanoRPCRequest_TYPE_REQUEST,
+		PathOneof:   c.getPathOneOf(path),
+	}
+
+	err = cs.send(m, msg, newStreamReceiver(cb).callback, sendOptions{stream: true})
+	return m.RequestId, err
+}
+
+// streamReceiver adapts a [StreamCallback], putting the chunks back in
+// order as their callbacks may run concurrently. The response ending the
+// stream carries the number of chunks sent in its sequence, so it's held
+// back until all of them are passed.
+type streamReceiver struct {
+	cb      StreamCallback
+	pending map[uint64]*nanorpc.NanoRPCResponse
+	final   *nanorpc.NanoRPCResponse
+	next    uint64
+	done    bool
+	mu      sync.Mutex
+}
+
+func newStreamReceiver(cb StreamCallback) *streamReceiver {
+	return &streamReceiver{cb: cb, next: 1}
+}
+
+// callback is the [RequestCallback] of the streamed request.
+func (sr *streamReceiver) callback(ctx context.Context, id int32, resp *nanorpc.NanoRPCResponse) error {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	switch {
+	case sr.done:
+		return nil
+	case resp == nil:
+		// session ended
+		sr.done = true
+		return sr.cb(ctx, id, nil, true)
+	case resp.ResponseType != nanorpc.NanoRPCResponse_TYPE_UPDATE:
+		sr.final = resp
+	case resp.Sequence == 0:
+		// unnumbered chunk
+		return sr.cb(ctx, id, resp, false)
+	default:
+		sr.unsafeQueue(resp)
+	}
+	return sr.unsafeDeliver(ctx, id)
+}
+
+func (sr *streamReceiver) unsafeQueue(chunk *nanorpc.NanoRPCResponse) {
+	if sr.pending == nil {
+		sr.pending = make(map[uint64]*nanorpc.NanoRPCResponse)
+	}
+	sr.pending[chunk.Sequence] = chunk
+}
+
+// unsafeDeliver passes the chunks following those already passed, and
+// then the final response once no chunk is missing.
+func (sr *streamReceiver) unsafeDeliver(ctx context.Context, id int32) error {
+	for {
+		chunk, ok := sr.pending[sr.next]
+		if !ok {
+			break
+		}
+
+		delete(sr.pending, sr.next)
+		sr.next++
+		if err := sr.cb(ctx, id, chunk, false); err != nil {
+			return err
+		}
+	}
+
+	if sr.final != nil && sr.final.Sequence < sr.next {
+		sr.done = true
+		return sr.cb(ctx, id, sr.final, true)
+	}
+	return nil
+}
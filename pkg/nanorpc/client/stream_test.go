@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// streamEvent is a call to a recording [StreamCallback].
+type streamEvent struct {
+	resp  *nanorpc.NanoRPCResponse
+	final bool
+}
+
+func recordingStreamCallback(ch chan streamEvent) StreamCallback {
+	return func(_ context.Context, _ int32, resp *nanorpc.NanoRPCResponse, final bool) error {
+		ch <- streamEvent{resp: resp, final: final}
+		return nil
+	}
+}
+
+func newChunk(id int32, sequence uint64, data string) *nanorpc.NanoRPCResponse {
+	resp := newResponse(id, respUpdate, statusOK)
+	resp.Sequence = sequence
+	resp.Data = []byte(data)
+	return resp
+}
+
+func newStreamEnd(id int32, chunks uint64) *nanorpc.NanoRPCResponse {
+	resp := newResponse(id, respResponse, statusOK)
+	resp.Sequence = chunks
+	return resp
+}
+
+// TestStreamReceiver verifies chunks arriving out of order, and the end of
+// the stream arriving before them, are passed on in order.
+func TestStreamReceiver(t *testing.T) {
+	events := make(chan streamEvent, 8)
+	sr := newStreamReceiver(recordingStreamCallback(events))
+	ctx := context.Background()
+
+	for _, resp := range []*nanorpc.NanoRPCResponse{
+		newChunk(1, 2, "b"),
+		newStreamEnd(1, 3),
+		newChunk(1, 0, "unnumbered"),
+		newChunk(1, 1, "a"),
+		newChunk(1, 3, "c"),
+		newStreamEnd(1, 3),
+		nil,
+	} {
+		core.AssertNoError(t, sr.callback(ctx, 1, resp), "callback")
+	}
+	close(events)
+
+	var got []string
+	for ev := range events {
+		switch {
+		case ev.final:
+			got = append(got, "end")
+		default:
+			got = append(got, string(ev.resp.Data))
+		}
+	}
+	core.AssertSliceEqual(t, []string{"unnumbered", "a", "b", "c", "end"}, got, "events")
+}
+
+func TestStreamReceiver_sessionEnded(t *testing.T) {
+	events := make(chan streamEvent, 4)
+	sr := newStreamReceiver(recordingStreamCallback(events))
+
+	core.AssertNoError(t, sr.callback(context.Background(), 1, newChunk(1, 2, "b")), "chunk")
+	core.AssertNoError(t, sr.callback(context.Background(), 1, nil), "session ended")
+
+	if core.AssertEqual(t, 1, len(events), "events") {
+		ev := <-events
+		core.AssertNil(t, ev.resp, "response")
+		core.AssertTrue(t, ev.final, "final")
+	}
+}
+
+// TestClient_RequestStream drives a streamed response through the run
+// loop, and checks plain requests still ignore chunks.
+func TestClient_RequestStream(t *testing.T) {
+	c, srv := newConnectedSession(t)
+	events := make(chan streamEvent, 4)
+
+	id, err := c.RequestStream("/dump", nil, recordingStreamCallback(events))
+	core.AssertMustNoError(t, err, "RequestStream")
+	req := srv.Recv()
+	core.AssertEqual(t, id, req.RequestId, "request_id")
+
+	srv.Reply(newChunk(id, 1, "a"))
+	srv.Reply(newChunk(id, 2, "b"))
+	srv.Reply(newStreamEnd(id, 2))
+
+	for i, want := range []string{"a", "b", ""} {
+		select {
+		case ev := <-events:
+			core.AssertEqual(t, want, string(ev.resp.Data), "data %d", i)
+			core.AssertEqual(t, i == 2, ev.final, "final %d", i)
+		case <-time.After(recvTimeout):
+			t.Fatalf("timed out waiting for event %d", i)
+		}
+	}
+	cs, err := c.getSession()
+	core.AssertMustNoError(t, err, "getSession")
+	core.AssertFalse(t, cs.IsActive(), "dequeued")
+
+	plain := make(chan cbEvent, 4)
+	id, err = c.Request("/echo", nil, recordingCallback(plain))
+	core.AssertMustNoError(t, err, "Request")
+	srv.Recv()
+	srv.Reply(newChunk(id, 1, "ignored"))
+	srv.Reply(newResponse(id, respResponse, statusOK))
+
+	ev := mustRecvEvent(t, plain, "response")
+	core.AssertEqual(t, respResponse, ev.resp.ResponseType, "type")
+	core.AssertEqual(t, 0, len(plain), "chunks")
+
+	_, err = c.RequestStream("/dump", nil, nil)
+	core.AssertErrorIs(t, err, ErrMissingCallback, "missing callback")
+}
@@ -0,0 +1,183 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// SubscribeChanSize is how many updates the channel of [SubscribeChan]
+// buffers before dropping the oldest.
+const SubscribeChanSize = 16
+
+// ChanSubscriber is a view of the [Client] that allows the
+// [Client.Subscribe] and [Client.Unsubscribe] calls [SubscribeChan] makes.
+type ChanSubscriber interface {
+	Subscriber
+	Unsubscriber
+}
+
+// SubscribeChan makes a subscription request and returns a channel
+// receiving every update decoded, so consumers can range over them instead
+// of writing callbacks:
+//
+//	updates, stop, err := client.SubscribeChan[*pb.Reading](c, "/sensors/temp", nil)
+//	if err != nil {
+//		return err
+//	}
+//	defer stop()
+//
+//	for reading := range updates {
+//		show(reading)
+//	}
+//
+// The channel holds up to [SubscribeChanSize] updates, dropping the oldest
+// when the consumer falls behind, as the session can't wait for it.
+// Updates without data, or that can't be decoded, are skipped. It's closed
+// once stop is called, unsubscribing, or when the subscription ends:
+// refused by the server, ended by it with a final update, or by the
+// session closing. A must be a concrete message type, else [ErrNilOut].
+func SubscribeChan[A proto.Message](c ChanSubscriber, path string, req proto.Message) (<-chan A, func(), error) {
+	if core.IsNil(c) {
+		return nil, nil, ErrMissingClient
+	}
+
+	newOut, err := newMessageFactory[A]()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s := &chanSubscription[A]{
+		c:    c,
+		path: path,
+		ch:   make(chan A, SubscribeChanSize),
+	}
+	cb := newSubscribeCallback(s.onEvent, newOut)
+	if _, err := c.Subscribe(path, req, s.wrap(cb)); err != nil {
+		return nil, nil, err
+	}
+	return s.ch, s.stop, nil
+}
+
+// newMessageFactory returns a factory of new messages of type A.
+func newMessageFactory[A proto.Message]() (func() (A, error), error) {
+	var zero A
+	if any(zero) == nil {
+		return nil, core.QuietWrap(ErrNilOut, "%T isn't a concrete message type", (*A)(nil))
+	}
+
+	mt := zero.ProtoReflect().Type()
+	return func() (A, error) {
+		out, _ := mt.New().Interface().(A)
+		return out, nil
+	}, nil
+}
+
+// chanSubscription feeds the updates of a subscription to a channel.
+type chanSubscription[A proto.Message] struct {
+	c      Unsubscriber
+	ch     chan A
+	path   string
+	mu     sync.Mutex
+	id     int32
+	acked  bool
+	closed bool
+}
+
+// wrap closes the channel when the session ends, before handing responses
+// to the typed callback.
+func (s *chanSubscription[A]) wrap(cb RequestCallback) RequestCallback {
+	return func(ctx context.Context, id int32, res *nanorpc.NanoRPCResponse) error {
+		if res == nil {
+			s.close()
+			return nil
+		}
+		return cb(ctx, id, res)
+	}
+}
+
+func (s *chanSubscription[A]) onEvent(_ context.Context, id int32, out A, err error) error {
+	var re *nanorpc.Error
+
+	switch {
+	case err == nil:
+		s.push(out)
+	case errors.Is(err, nanorpc.ErrSubscriptionEstablished):
+		s.onEstablished(id)
+	case errors.As(err, &re):
+		// refused, or ended by the server
+		s.close()
+	default:
+		// update without data or undecodable, skipped
+	}
+	return nil
+}
+
+// push queues an update, dropping the oldest if full.
+func (s *chanSubscription[A]) push(out A) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for !s.closed {
+		select {
+		case s.ch <- out:
+			return
+		default:
+			select {
+			case <-s.ch:
+			default:
+			}
+		}
+	}
+}
+
+// onEstablished records the request ID of the subscription, and cancels
+// it if stop was called before the server acknowledged it.
+func (s *chanSubscription[A]) onEstablished(id int32) {
+	s.mu.Lock()
+	s.id, s.acked = id, true
+	stopped := s.closed
+	s.mu.Unlock()
+
+	if stopped {
+		s.unsubscribe(id)
+	}
+}
+
+// stop closes the channel and cancels the subscription, once.
+func (s *chanSubscription[A]) stop() {
+	s.mu.Lock()
+	id, acked := s.id, s.acked
+	closed := s.closed
+	s.unsafeClose()
+	s.mu.Unlock()
+
+	if acked && !closed {
+		s.unsubscribe(id)
+	}
+}
+
+func (s *chanSubscription[A]) unsubscribe(id int32) {
+	_ = s.c.Unsubscribe(s.path, id, func(context.Context, int32, *nanorpc.NanoRPCResponse) error {
+		return nil
+	})
+}
+
+func (s *chanSubscription[A]) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.unsafeClose()
+}
+
+func (s *chanSubscription[A]) unsafeClose() {
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
@@ -0,0 +1,127 @@
+package client_test
+
+import (
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/client"
+)
+
+// subscribeChanLive subscribes through [client.SubscribeChan] and
+// acknowledges the subscription, returning the channel, stop and the
+// request ID. The payload type is *nanorpc.NanoRPCResponse purely because
+// it is a convenient proto.Message.
+func subscribeChanLive(t *testing.T, f *liveFixture) (<-chan *nanorpc.NanoRPCResponse, func(), int32) {
+	t.Helper()
+
+	updates, stop, err := client.SubscribeChan[*nanorpc.NanoRPCResponse](f.c, "/sensors/temp", nil)
+	core.AssertMustNoError(t, err, "SubscribeChan")
+	t.Cleanup(stop)
+
+	sub := f.conn.Recv()
+	core.AssertEqual(t, nanorpc.NanoRPCRequest_TYPE_SUBSCRIBE, sub.RequestType, "subscribe_type")
+	f.conn.Reply(newLiveResponse(sub.RequestId, nanorpc.NanoRPCResponse_TYPE_RESPONSE,
+		nanorpc.NanoRPCResponse_STATUS_OK))
+
+	return updates, stop, sub.RequestId
+}
+
+// replyLiveUpdate sends a TYPE_UPDATE carrying a payload message whose
+// ResponseMessage is msg.
+func replyLiveUpdate(t *testing.T, f *liveFixture, id int32, msg string) {
+	t.Helper()
+
+	data, err := proto.Marshal(&nanorpc.NanoRPCResponse{ResponseMessage: msg})
+	core.AssertMustNoError(t, err, "Marshal")
+
+	res := newLiveResponse(id, nanorpc.NanoRPCResponse_TYPE_UPDATE, nanorpc.NanoRPCResponse_STATUS_OK)
+	res.Data = data
+	f.conn.Reply(res)
+}
+
+// mustRecvChan waits for the next value on ch, reporting whether it was
+// still open and failing on timeout.
+func mustRecvChan[T any](t *testing.T, ch <-chan T, what string) (T, bool) {
+	t.Helper()
+	select {
+	case v, ok := <-ch:
+		return v, ok
+	case <-time.After(liveTimeout):
+		t.Fatalf("timed out waiting for the %s", what)
+		var zero T
+		return zero, false
+	}
+}
+
+// TestLiveClient_SubscribeChan_updates verifies updates arrive decoded and
+// in order, empty ones are skipped, and stop unsubscribes and closes the
+// channel.
+func TestLiveClient_SubscribeChan_updates(t *testing.T) {
+	f := newLiveFixture(t)
+	updates, stop, id := subscribeChanLive(t, f)
+
+	// callbacks run concurrently, so updates are awaited one at a time
+	replyLiveUpdate(t, f, id, "first")
+	upd, ok := mustRecvChan(t, updates, "update")
+	core.AssertMustTrue(t, ok, "channel open")
+	core.AssertEqual(t, "first", upd.GetResponseMessage(), "update")
+
+	f.conn.Reply(newLiveResponse(id, nanorpc.NanoRPCResponse_TYPE_UPDATE, nanorpc.NanoRPCResponse_STATUS_OK))
+	replyLiveUpdate(t, f, id, "second")
+	upd, ok = mustRecvChan(t, updates, "update")
+	core.AssertMustTrue(t, ok, "channel open")
+	core.AssertEqual(t, "second", upd.GetResponseMessage(), "update")
+
+	stop()
+	stop()
+
+	unsub := f.conn.Recv()
+	core.AssertEqual(t, nanorpc.NanoRPCRequest_TYPE_REQUEST, unsub.RequestType, "unsubscribe_type")
+	core.AssertEqual(t, id, unsub.RequestId, "unsubscribe_id")
+
+	_, ok = mustRecvChan(t, updates, "close")
+	core.AssertFalse(t, ok, "channel closed")
+}
+
+// TestLiveClient_SubscribeChan_finalUpdate verifies the channel closes when
+// the server ends the subscription.
+func TestLiveClient_SubscribeChan_finalUpdate(t *testing.T) {
+	f := newLiveFixture(t)
+	updates, _, id := subscribeChanLive(t, f)
+
+	f.conn.Reply(newLiveResponse(id, nanorpc.NanoRPCResponse_TYPE_UPDATE,
+		nanorpc.NanoRPCResponse_STATUS_INTERNAL_ERROR))
+
+	_, ok := mustRecvChan(t, updates, "close")
+	core.AssertFalse(t, ok, "channel closed")
+}
+
+// TestLiveClient_SubscribeChan_refused verifies the channel closes when the
+// server refuses the subscription.
+func TestLiveClient_SubscribeChan_refused(t *testing.T) {
+	f := newLiveFixture(t)
+
+	updates, stop, err := client.SubscribeChan[*nanorpc.NanoRPCResponse](f.c, "/missing", nil)
+	core.AssertMustNoError(t, err, "SubscribeChan")
+	defer stop()
+
+	sub := f.conn.Recv()
+	f.conn.Reply(newLiveResponse(sub.RequestId, nanorpc.NanoRPCResponse_TYPE_RESPONSE,
+		nanorpc.NanoRPCResponse_STATUS_NOT_FOUND))
+
+	_, ok := mustRecvChan(t, updates, "close")
+	core.AssertFalse(t, ok, "channel closed")
+}
+
+func TestSubscribeChan_errors(t *testing.T) {
+	_, _, err := client.SubscribeChan[*nanorpc.NanoRPCResponse](nil, "/sensors/temp", nil)
+	core.AssertErrorIs(t, err, client.ErrMissingClient, "nil client")
+
+	f := newLiveFixture(t)
+	_, _, err = client.SubscribeChan[proto.Message](f.c, "/sensors/temp", nil)
+	core.AssertErrorIs(t, err, client.ErrNilOut, "interface type")
+}
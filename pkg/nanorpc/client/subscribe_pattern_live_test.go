@@ -0,0 +1,49 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/client"
+	"protomcp.org/nanorpc/pkg/nanorpc/mock/server"
+)
+
+// TestLiveClient_SubscribePattern verifies patterns are sent as strings
+// even when hashing paths, and updates carry their path.
+func TestLiveClient_SubscribePattern(t *testing.T) {
+	srv := server.New(t)
+	c := newLiveClient(t, srv, client.Config{AlwaysHashPaths: true})
+	core.AssertMustNoError(t, c.Connect(), "Connect")
+	conn := srv.Accept()
+
+	ctx, cancel := context.WithTimeout(context.Background(), liveTimeout)
+	defer cancel()
+	core.AssertMustNoError(t, c.WaitConnected(ctx), "WaitConnected")
+
+	paths := make(chan string, 1)
+	_, err := c.SubscribePattern("/sensors/+/temperature", nil,
+		func(_ context.Context, _ int32, res *nanorpc.NanoRPCResponse) error {
+			if res.GetResponseType() == nanorpc.NanoRPCResponse_TYPE_UPDATE {
+				paths <- res.Path
+			}
+			return nil
+		})
+	core.AssertMustNoError(t, err, "SubscribePattern")
+
+	req := conn.Recv()
+	core.AssertEqual(t, nanorpc.NanoRPCRequest_TYPE_SUBSCRIBE, req.RequestType, "request_type")
+	core.AssertEqual(t, "/sensors/+/temperature", req.GetPath(), "path")
+
+	conn.Reply(newLiveResponse(req.RequestId, nanorpc.NanoRPCResponse_TYPE_RESPONSE,
+		nanorpc.NanoRPCResponse_STATUS_OK))
+	update := newLiveResponse(req.RequestId, nanorpc.NanoRPCResponse_TYPE_UPDATE,
+		nanorpc.NanoRPCResponse_STATUS_OK)
+	update.Path = "/sensors/7/temperature"
+	conn.Reply(update)
+
+	path, _ := mustRecvChan(t, paths, "update")
+	core.AssertEqual(t, "/sensors/7/temperature", path, "update path")
+}
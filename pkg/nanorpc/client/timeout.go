@@ -0,0 +1,155 @@
+package client
+
+import (
+	"context"
+	"math"
+	"slices"
+	"time"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// RequestContext enqueues a NanoRPC request like [Client.Request], giving
+// up on its response when ctx is done or, if sooner, after the
+// RequestTimeout of the [Config]. A request given up on is dropped from the
+// queue, and cb called with ctx and a nil response, for which
+// [nanorpc.ResponseAsError] returns [nanorpc.ErrNoResponse]. A response
+// arriving later is ignored. The request carries the metadata of ctx, see
+// [WithMetadata], and the time left to its deadline, so the server can
+// stop handling it once the client gives up.
+func (c *Client) RequestContext(ctx context.Context, path string, msg proto.Message,
+	cb RequestCallback) (int32, error) {
+	if c == nil {
+		return 0, core.ErrNilReceiver
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	cs, err := c.getSession()
+	if err != nil {
+		return 0, err
+	}
+
+	// assemble header
+	m := &nanorpc.NanoRPCRequest{
+		RequestType: nanorpc.NanoRPCRequest_TYPE_REQUEST,
+		PathOneof:   c.getPathOneOf(path),
+		Metadata:    MetadataFromContext(ctx).Clone(),
+	}
+
+	err = cs.send(m, msg, cb, sendOptions{ctx: ctx})
+	return m.RequestId, err
+}
+
+// requestExpiry gives up on the response to a queued request once its
+// context is done.
+type requestExpiry struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	stop   func() bool
+}
+
+// newRequestExpiry bounds the wait for the response to a request by ctx
+// and the RequestTimeout of the [Client]. Subscriptions, and requests
+// bound by neither, don't expire.
+func (cs *Session) newRequestExpiry(ctx context.Context,
+	reqType nanorpc.NanoRPCRequest_Type) *requestExpiry {
+	if reqType == nanorpc.NanoRPCRequest_TYPE_SUBSCRIBE {
+		return nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	timeout := cs.c.getRequestTimeout()
+	switch {
+	case timeout > 0:
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		return &requestExpiry{ctx: ctx, cancel: cancel}
+	case ctx.Done() == nil:
+		// never done
+		return nil
+	default:
+		return &requestExpiry{ctx: ctx, cancel: func() {}}
+	}
+}
+
+// err returns why the request was given up on before being sent.
+func (e *requestExpiry) err() error {
+	if e == nil {
+		return nil
+	}
+	return e.ctx.Err()
+}
+
+// start watches the context of the queued request. cs.mu must be held.
+func (e *requestExpiry) start(cs *Session) {
+	if e != nil {
+		e.stop = context.AfterFunc(e.ctx, func() { cs.expire(e) })
+	}
+}
+
+// timeoutMs returns the time left before the request is given up on in
+// milliseconds, rounded up, or zero if it's never given up on.
+func (e *requestExpiry) timeoutMs() uint32 {
+	if e == nil {
+		return 0
+	}
+
+	deadline, ok := e.ctx.Deadline()
+	if !ok {
+		return 0
+	}
+
+	ms := (time.Until(deadline) + time.Millisecond - 1) / time.Millisecond
+	switch {
+	case ms < 1:
+		return 1
+	case ms > math.MaxUint32:
+		return math.MaxUint32
+	default:
+		return uint32(ms)
+	}
+}
+
+// release stops watching the context of the request, once resolved.
+func (e *requestExpiry) release() {
+	if e == nil {
+		return
+	}
+	if e.stop != nil {
+		e.stop()
+	}
+	e.cancel()
+}
+
+// expire drops the queue entry of a request given up on, and tells its
+// callback.
+func (cs *Session) expire(e *requestExpiry) {
+	cs.mu.Lock()
+	idx := slices.IndexFunc(cs.cb, func(x clientRequestQueue) bool { return x.Expiry == e })
+	if idx < 0 {
+		// resolved meanwhile
+		cs.mu.Unlock()
+		return
+	}
+
+	x := cs.cb[idx]
+	cs.cb = append(cs.cb[:idx], cs.cb[idx+1:]...)
+	cs.unsafeReportQueue()
+	cs.mu.Unlock()
+
+	e.cancel()
+	_ = cs.callback(e.ctx, x.Callback, x.RequestID, nil)
+}
+
+func (c *Client) getRequestTimeout() time.Duration {
+	if c == nil {
+		return 0
+	}
+	return c.requestTimeout
+}
@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+const testRequestTimeout = 20 * time.Millisecond
+
+// ctxCallback reports the context each call gets alongside its response.
+func ctxCallback(events chan cbEvent, ctxs chan context.Context) RequestCallback {
+	return func(ctx context.Context, id int32, resp *nanorpc.NanoRPCResponse) error {
+		ctxs <- ctx
+		events <- cbEvent{resp: resp, id: id}
+		return nil
+	}
+}
+
+func assertQueueEmpty(t *testing.T, c *Client) {
+	t.Helper()
+
+	cs, err := c.getSession()
+	core.AssertMustNoError(t, err, "getSession")
+	core.AssertFalse(t, cs.IsActive(), "queued callbacks")
+}
+
+func TestClient_RequestContext_cancelled(t *testing.T) {
+	c, srv := newConnectedSession(t)
+	events := make(chan cbEvent, 2)
+	ctxs := make(chan context.Context, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	id, err := c.RequestContext(ctx, "/echo", nil, ctxCallback(events, ctxs))
+	core.AssertMustNoError(t, err, "RequestContext")
+	srv.Recv()
+
+	cancel()
+	ev := mustRecvEvent(t, events, "cancelled")
+	core.AssertEqual(t, id, ev.id, "callback_id")
+	core.AssertNil(t, ev.resp, "response")
+	core.AssertErrorIs(t, (<-ctxs).Err(), context.Canceled, "ctx")
+	assertQueueEmpty(t, c)
+
+	// a late response is ignored
+	srv.Reply(newResponse(id, respResponse, statusOK))
+	_, err = c.RequestContext(ctx, "/echo", nil, ctxCallback(events, ctxs))
+	core.AssertErrorIs(t, err, context.Canceled, "already cancelled")
+	core.AssertEqual(t, 0, len(events), "late response")
+	assertQueueEmpty(t, c)
+}
+
+func TestClient_RequestTimeout(t *testing.T) {
+	c, srv := newConnectedSession(t)
+	c.requestTimeout = testRequestTimeout
+	events := make(chan cbEvent, 4)
+	ctxs := make(chan context.Context, 4)
+
+	// answered in time
+	id, err := c.Request("/echo", nil, ctxCallback(events, ctxs))
+	core.AssertMustNoError(t, err, "Request")
+	srv.Recv()
+	srv.Reply(newResponse(id, respResponse, statusOK))
+	ev := mustRecvEvent(t, events, "answered")
+	core.AssertNotNil(t, ev.resp, "answered response")
+	<-ctxs
+
+	// never answered
+	_, err = c.Request("/echo", nil, ctxCallback(events, ctxs))
+	core.AssertMustNoError(t, err, "Request")
+	srv.Recv()
+	ev = mustRecvEvent(t, events, "timed out")
+	core.AssertNil(t, ev.resp, "timed out response")
+	core.AssertErrorIs(t, (<-ctxs).Err(), context.DeadlineExceeded, "ctx")
+	core.AssertErrorIs(t, nanorpc.ResponseAsError(ev.resp), nanorpc.ErrNoResponse, "ResponseAsError")
+	assertQueueEmpty(t, c)
+
+	// subscriptions don't expire
+	_, err = c.Subscribe("/events", nil, ctxCallback(events, ctxs))
+	core.AssertMustNoError(t, err, "Subscribe")
+	srv.Recv()
+	time.Sleep(2 * testRequestTimeout)
+	core.AssertEqual(t, 0, len(events), "subscription expired")
+	cs, err := c.getSession()
+	core.AssertMustNoError(t, err, "getSession")
+	core.AssertTrue(t, cs.IsActive(), "subscription queued")
+}
+
+func TestClient_RequestContext_timeoutMs(t *testing.T) {
+	c, srv := newConnectedSession(t)
+	events := make(chan cbEvent, 4)
+	ctxs := make(chan context.Context, 4)
+
+	// without deadline
+	id, err := c.RequestContext(context.Background(), "/echo", nil, ctxCallback(events, ctxs))
+	core.AssertMustNoError(t, err, "RequestContext")
+	core.AssertEqual(t, uint32(0), srv.Recv().TimeoutMs, "no deadline")
+	srv.Reply(newResponse(id, respResponse, statusOK))
+	mustRecvEvent(t, events, "answered")
+
+	// from the deadline of ctx
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	id, err = c.RequestContext(ctx, "/echo", nil, ctxCallback(events, ctxs))
+	core.AssertMustNoError(t, err, "RequestContext")
+	ms := srv.Recv().TimeoutMs
+	core.AssertTrue(t, ms > 900 && ms <= 1000, "deadline: %d", ms)
+	srv.Reply(newResponse(id, respResponse, statusOK))
+	mustRecvEvent(t, events, "answered")
+
+	// from RequestTimeout, if sooner
+	c.requestTimeout = testRequestTimeout
+	_, err = c.RequestContext(ctx, "/echo", nil, ctxCallback(events, ctxs))
+	core.AssertMustNoError(t, err, "RequestContext")
+	ms = srv.Recv().TimeoutMs
+	core.AssertTrue(t, ms > 0 && ms <= 20, "RequestTimeout: %d", ms)
+}
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"crypto/tls"
+	"net"
+	"slices"
+	"strings"
+
+	"darvaza.org/core"
+	"darvaza.org/x/net/reconnect"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/utils"
+)
+
+// ExportTLS generates the [tls.Config] used to dial the server, or nil
+// when the connection is not encrypted.
+//
+// TLSConfig, when given, is used as the base and never modified.
+// TLSCAFile replaces its root CAs, and TLSCertFile and TLSKeyFile add a
+// client certificate for mTLS. RequireTLS alone encrypts the connection
+// verifying the server against the system roots. The server name defaults
+// to the host in Remote.
+func (cfg *Config) ExportTLS() (*tls.Config, error) {
+	switch {
+	case cfg == nil:
+		return nil, core.ErrNilReceiver
+	case !cfg.usesTLS():
+		return nil, nil
+	case (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == ""):
+		return nil, core.QuietWrap(ErrInvalidTLSConfig, "certificate and key must be given together")
+	}
+
+	out := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.TLSConfig != nil {
+		out = cfg.TLSConfig.Clone()
+	}
+
+	if err := cfg.loadTLSFiles(out); err != nil {
+		return nil, err
+	}
+
+	if out.ServerName == "" {
+		out.ServerName = tlsServerName(cfg.Remote)
+	}
+	return out, nil
+}
+
+func (cfg *Config) usesTLS() bool {
+	return cfg.RequireTLS || cfg.TLSConfig != nil ||
+		cfg.TLSCertFile != "" || cfg.TLSKeyFile != "" || cfg.TLSCAFile != ""
+}
+
+func (cfg *Config) loadTLSFiles(out *tls.Config) error {
+	if cfg.TLSCAFile != "" {
+		pool, err := utils.LoadCertPool(cfg.TLSCAFile)
+		if err != nil {
+			return core.Wrap(err, "TLSCAFile")
+		}
+		out.RootCAs = pool
+	}
+
+	if cfg.TLSCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return core.Wrap(err, "client certificate")
+		}
+		out.Certificates = append(slices.Clip(out.Certificates), cert)
+	}
+	return nil
+}
+
+// tlsServerName returns the host of a TCP remote, or an empty string for
+// UNIX sockets, which need an explicit ServerName.
+func tlsServerName(remote string) string {
+	if strings.HasPrefix(remote, "unix:") {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(remote)
+	if err != nil {
+		return ""
+	}
+	return host
+}
+
+// useTLS makes ss exchange messages over a TLS client on the dialled
+// connection when TLS is configured. A new TLS client, and handshake, is
+// used for every reconnection, while deadlines keep being applied to the
+// underlying connection through the [reconnect.Client].
+func (c *Client) useTLS(ss *reconnect.StreamSession[*nanorpc.NanoRPCResponse, clientRequest], conn net.Conn) {
+	if c.tlsConfig != nil {
+		ss.Conn = tls.Client(conn, c.tlsConfig)
+	}
+}
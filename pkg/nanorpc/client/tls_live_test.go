@@ -0,0 +1,55 @@
+package client_test
+
+import (
+	"context"
+	"crypto/tls"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/client"
+	"protomcp.org/nanorpc/pkg/nanorpc/mock/server"
+	"protomcp.org/nanorpc/pkg/nanorpc/utils/testutils"
+)
+
+// TestLiveClient_MutualTLS drives a request over an mTLS connection, with
+// the client configured from PEM files.
+func TestLiveClient_MutualTLS(t *testing.T) {
+	pki := testutils.NewTestPKI(t)
+	files, err := pki.WriteFiles(t.TempDir())
+	core.AssertMustNoError(t, err, "write files")
+
+	srv := server.NewTLS(t, &tls.Config{
+		Certificates: []tls.Certificate{pki.Server},
+		ClientCAs:    pki.CertPool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})
+
+	onConnect, waitStarted := liveStarted(t)
+	c := newLiveClient(t, srv, client.Config{
+		TLSCAFile:   files.CA,
+		TLSCertFile: files.ClientCert,
+		TLSKeyFile:  files.ClientKey,
+		OnConnect:   onConnect,
+	})
+	core.AssertMustNoError(t, c.Connect(), "Connect")
+	conn := srv.Accept()
+
+	ctx, cancel := context.WithTimeout(context.Background(), liveTimeout)
+	defer cancel()
+	core.AssertMustNoError(t, c.WaitConnected(ctx), "WaitConnected")
+	waitStarted()
+
+	events := make(chan cbEvent, 1)
+	id, err := c.Request("/echo", nil, liveRecordingCallback(events))
+	core.AssertMustNoError(t, err, "Request")
+
+	req := conn.Recv()
+	core.AssertEqual(t, id, req.RequestId, "request_id")
+	conn.Reply(newLiveResponse(id, nanorpc.NanoRPCResponse_TYPE_RESPONSE,
+		nanorpc.NanoRPCResponse_STATUS_OK))
+
+	ev := mustRecvLiveEvent(t, events, "request")
+	core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_OK, ev.resp.ResponseStatus, "status")
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc/utils/testutils"
+)
+
+var _ core.TestCase = exportTLSTestCase{}
+
+type exportTLSTestCase struct {
+	config     *Config
+	name       string
+	serverName string
+	certs      int
+	enabled    bool
+	wantErr    bool
+}
+
+func (tc exportTLSTestCase) Name() string { return tc.name }
+
+func (tc exportTLSTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	out, err := tc.config.ExportTLS()
+	if tc.wantErr {
+		core.AssertTrue(t, IsInvalid(err), "invalid: %v", err)
+		return
+	}
+	core.AssertMustNoError(t, err, "ExportTLS")
+
+	if !tc.enabled {
+		core.AssertNil(t, out, "tls config")
+		return
+	}
+	if core.AssertNotNil(t, out, "tls config") {
+		core.AssertEqual(t, tc.serverName, out.ServerName, "server name")
+		core.AssertEqual(t, tc.certs, len(out.Certificates), "client certificates")
+	}
+}
+
+func exportTLSTestCases(files testutils.TestPKIFiles) []exportTLSTestCase {
+	base := &tls.Config{ServerName: "gateway.example"}
+
+	return []exportTLSTestCase{
+		{name: "plain", config: &Config{Remote: "localhost:8080"}},
+		{name: "require", config: &Config{Remote: "localhost:8080", RequireTLS: true},
+			enabled: true, serverName: "localhost"},
+		{name: "unix", config: &Config{Remote: "unix:/tmp/x.sock", RequireTLS: true},
+			enabled: true},
+		{name: "base_config", config: &Config{Remote: "localhost:8080", TLSConfig: base},
+			enabled: true, serverName: "gateway.example"},
+		{name: "mtls", config: &Config{
+			Remote:      "127.0.0.1:8080",
+			TLSCAFile:   files.CA,
+			TLSCertFile: files.ClientCert,
+			TLSKeyFile:  files.ClientKey,
+		}, enabled: true, serverName: "127.0.0.1", certs: 1},
+		{name: "cert_without_key", config: &Config{Remote: "localhost:8080",
+			TLSCertFile: files.ClientCert}, wantErr: true},
+		{name: "bad_ca", config: &Config{Remote: "localhost:8080",
+			TLSCAFile: files.ClientKey}, wantErr: true},
+	}
+}
+
+func TestConfig_ExportTLS(t *testing.T) {
+	files, err := testutils.NewTestPKI(t).WriteFiles(t.TempDir())
+	core.AssertMustNoError(t, err, "write files")
+
+	core.RunTestCases(t, exportTLSTestCases(files))
+
+	var cfg *Config
+	_, err = cfg.ExportTLS()
+	core.AssertErrorIs(t, err, core.ErrNilReceiver, "nil config")
+}
@@ -0,0 +1,22 @@
+package client
+
+import (
+	"context"
+	"net"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// DialUDP connects to a NanoRPC server listening on UDP, returning a
+// connection for [Client.Attach] that sends every request as a single
+// datagram. UDP doesn't retransmit lost datagrams, so requests should be
+// idempotent and bounded by a timeout.
+func DialUDP(ctx context.Context, address string) (net.Conn, error) {
+	var d net.Dialer
+
+	conn, err := d.DialContext(ctx, "udp", address)
+	if err != nil {
+		return nil, err
+	}
+	return nanorpc.NewDatagramConn(conn), nil
+}
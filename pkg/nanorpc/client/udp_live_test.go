@@ -0,0 +1,83 @@
+package client_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/client"
+)
+
+// TestLiveClient_AttachUDP drives a request over a connection from
+// [client.DialUDP], answered datagram by datagram, and checks the session
+// ends when the Attach context is cancelled.
+func TestLiveClient_AttachUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	core.AssertMustNoError(t, err, "listen")
+	defer pc.Close()
+
+	disconnects := make(chan struct{}, 1)
+	c, err := (&client.Config{
+		Context: context.Background(),
+		Remote:  pc.LocalAddr().String(),
+		OnDisconnect: func(context.Context) error {
+			disconnects <- struct{}{}
+			return nil
+		},
+	}).New()
+	core.AssertMustNoError(t, err, "cfg.New")
+
+	ctx, cancel := context.WithTimeout(context.Background(), liveTimeout)
+	defer cancel()
+
+	conn, err := client.DialUDP(ctx, pc.LocalAddr().String())
+	core.AssertMustNoError(t, err, "DialUDP")
+
+	attachCtx, detach := context.WithCancel(ctx)
+	defer detach()
+	core.AssertErrorIs(t, c.Attach(attachCtx, nil), client.ErrMissingConn, "nil conn")
+	core.AssertMustNoError(t, c.Attach(attachCtx, conn), "Attach")
+	core.AssertMustNoError(t, c.WaitConnected(ctx), "WaitConnected")
+	core.AssertErrorIs(t, c.Attach(attachCtx, conn), client.ErrSessionAttached, "second Attach")
+
+	events := make(chan cbEvent, 1)
+	id, err := c.Request("/echo", nil, liveRecordingCallback(events))
+	core.AssertMustNoError(t, err, "Request")
+
+	replyUDP(t, pc, nanorpc.NanoRPCResponse_STATUS_OK)
+
+	ev := mustRecvLiveEvent(t, events, "request")
+	core.AssertEqual(t, id, ev.id, "request_id")
+	core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_OK, ev.resp.ResponseStatus, "status")
+
+	detach()
+	select {
+	case <-disconnects:
+	case <-time.After(liveTimeout):
+		t.Fatal("timed out waiting for OnDisconnect")
+	}
+}
+
+// replyUDP answers the next request datagram received on pc.
+func replyUDP(t *testing.T, pc net.PacketConn, st nanorpc.NanoRPCResponse_Status) {
+	t.Helper()
+
+	_ = pc.SetReadDeadline(time.Now().Add(liveTimeout))
+	buf := make([]byte, nanorpc.MaxDatagramSize)
+	n, addr, err := pc.ReadFrom(buf)
+	core.AssertMustNoError(t, err, "read request")
+
+	req, _, err := nanorpc.DecodeRequest(buf[:n])
+	core.AssertMustNoError(t, err, "decode request")
+
+	data, err := nanorpc.EncodeResponse(newLiveResponse(req.RequestId,
+		nanorpc.NanoRPCResponse_TYPE_RESPONSE, st), nil)
+	core.AssertMustNoError(t, err, "encode response")
+
+	_, err = pc.WriteTo(data, addr)
+	core.AssertMustNoError(t, err, "write response")
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// lastWill is what the server publishes when the session of the [Client]
+// ends, see Config.WillPath.
+type lastWill struct {
+	path string
+	data []byte
+}
+
+// ClearWill withdraws the last will of the [Config], handshaking again
+// without one and waiting for the server to answer, so closing the
+// [Client] afterwards isn't announced to other subscribers. Later
+// connections don't register it either. Without a session, or a will,
+// there is nothing to withdraw.
+func (c *Client) ClearWill(ctx context.Context) error {
+	if c == nil {
+		return core.ErrNilReceiver
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if c.will.Swap(nil) == nil {
+		return nil
+	}
+
+	cs, err := c.getSession()
+	if err != nil {
+		// not connected, nothing registered
+		return nil
+	}
+
+	ch := make(chan error, 1)
+	cb := func(ctx context.Context, id int32, res *nanorpc.NanoRPCResponse) error {
+		if res == nil {
+			ch <- nanorpc.ErrNoResponse
+		} else {
+			ch <- nil
+		}
+		return cs.onHello(ctx, id, res)
+	}
+
+	req := &nanorpc.NanoRPCRequest{
+		RequestType: nanorpc.NanoRPCRequest_TYPE_PING,
+	}
+	if err := cs.send(req, c.newHello(), cb, sendOptions{ctx: ctx, priority: PriorityHigh}); err != nil {
+		return err
+	}
+	return waitGetResponse(ctx, ch)
+}
+
+// newHello returns the handshake payload of the [Client], carrying its
+// last will unless cleared.
+func (c *Client) newHello() *nanorpc.NanoRPCHello {
+	hello := nanorpc.NewHello(clientFeatures)
+	if will := c.will.Load(); will != nil {
+		hello.WillPath = will.path
+		hello.WillData = will.data
+	}
+	return hello
+}
+
+// initHandshake sets whether sessions start with a handshake, as they do
+// to register a last will.
+func (c *Client) initHandshake(cfg *Config) {
+	c.handshake = cfg.Handshake || cfg.WillPath != ""
+	if cfg.WillPath != "" {
+		c.will.Store(&lastWill{path: cfg.WillPath, data: cfg.WillData})
+	}
+}
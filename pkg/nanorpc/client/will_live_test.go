@@ -0,0 +1,54 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/client"
+	"protomcp.org/nanorpc/pkg/nanorpc/mock/server"
+)
+
+// recvHello receives a handshake, answers it as a plain ping and returns
+// it.
+func recvHello(t *testing.T, conn *server.Conn) *nanorpc.NanoRPCHello {
+	t.Helper()
+
+	req := conn.Recv()
+	hello, ok := nanorpc.RequestHello(req)
+	core.AssertMustTrue(t, ok, "handshake sent")
+
+	conn.Reply(newLiveResponse(req.RequestId, nanorpc.NanoRPCResponse_TYPE_PONG,
+		nanorpc.NanoRPCResponse_STATUS_OK))
+	return hello
+}
+
+// TestLiveClient_Will verifies the last will is registered by the
+// handshake, which it turns on, and withdrawn by ClearWill.
+func TestLiveClient_Will(t *testing.T) {
+	srv := server.New(t)
+	c := newLiveClient(t, srv, client.Config{
+		WillPath: "/devices/7/online",
+		WillData: []byte("offline"),
+	})
+	core.AssertMustNoError(t, c.Connect(), "Connect")
+	conn := srv.Accept()
+
+	hello := recvHello(t, conn)
+	core.AssertEqual(t, "/devices/7/online", hello.WillPath, "will path")
+	core.AssertEqual(t, "offline", string(hello.WillData), "will data")
+
+	ctx, cancel := context.WithTimeout(context.Background(), liveTimeout)
+	defer cancel()
+	core.AssertMustNoError(t, c.WaitConnected(ctx), "WaitConnected")
+
+	done := make(chan error, 1)
+	go func() { done <- c.ClearWill(ctx) }()
+
+	hello = recvHello(t, conn)
+	core.AssertEqual(t, "", hello.WillPath, "cleared will path")
+	core.AssertNoError(t, <-done, "ClearWill")
+	core.AssertNoError(t, c.ClearWill(ctx), "ClearWill again")
+}
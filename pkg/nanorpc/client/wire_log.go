@@ -0,0 +1,42 @@
+package client
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/utils"
+)
+
+// logRequest logs a request about to be sent, see Config.WireLog.
+func (cs *Session) logRequest(r clientRequest) {
+	wl := cs.c.wireLog
+	if wl == nil || cs.logger == nil {
+		return
+	}
+
+	if _, ok := cs.logger.Debug().WithEnabled(); ok {
+		data := r.r.GetData()
+		if r.d != nil {
+			// payload given apart
+			data, _ = proto.Marshal(r.d)
+		}
+		wl.LogRequest(cs.logger, utils.DirectionSent, r.r, data)
+	}
+}
+
+// logResponse logs a response received, see Config.WireLog, with the
+// time since the request it answers was sent.
+func (cs *Session) logResponse(resp *nanorpc.NanoRPCResponse) {
+	if cs.c == nil || cs.c.wireLog == nil {
+		return
+	}
+
+	req, sentAt := cs.lookupRequest(resp)
+	var elapsed time.Duration
+	if !sentAt.IsZero() {
+		elapsed = time.Since(sentAt)
+	}
+	cs.c.wireLog.LogResponse(cs.logger, utils.DirectionReceived, req, resp, elapsed)
+}
@@ -0,0 +1,56 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+
+	"darvaza.org/core"
+	"darvaza.org/slog/handlers/mock"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/client"
+	"protomcp.org/nanorpc/pkg/nanorpc/mock/server"
+	"protomcp.org/nanorpc/pkg/nanorpc/utils"
+)
+
+// TestLiveClient_WireLog verifies the client logs the requests it sends
+// and the responses it receives, timed.
+func TestLiveClient_WireLog(t *testing.T) {
+	logger := mock.NewLogger()
+	srv := server.New(t)
+	c := newLiveClient(t, srv, client.Config{
+		Logger:  logger,
+		WireLog: &utils.WireLog{PayloadBytes: 2},
+	})
+	core.AssertMustNoError(t, c.Connect(), "Connect")
+	conn := srv.Accept()
+
+	ctx, cancel := context.WithTimeout(context.Background(), liveTimeout)
+	defer cancel()
+	core.AssertMustNoError(t, c.WaitConnected(ctx), "WaitConnected")
+
+	events := make(chan cbEvent, 1)
+	id, err := c.RequestRaw("/echo", []byte("abc"), liveRecordingCallback(events))
+	core.AssertMustNoError(t, err, "RequestRaw")
+	conn.Recv()
+	conn.Reply(newLiveResponse(id, nanorpc.NanoRPCResponse_TYPE_RESPONSE, nanorpc.NanoRPCResponse_STATUS_OK))
+	mustRecvLiveEvent(t, events, "response")
+
+	var msgs []mock.Message
+	for _, msg := range logger.GetMessages() {
+		if _, ok := msg.Fields[utils.FieldDirection]; ok {
+			msgs = append(msgs, msg)
+		}
+	}
+	core.AssertMustEqual(t, 2, len(msgs), "wire messages")
+
+	sent, received := msgs[0].Fields, msgs[1].Fields
+	core.AssertEqual[any](t, utils.DirectionSent, sent[utils.FieldDirection], "sent")
+	core.AssertEqual[any](t, "/echo", sent[utils.FieldPath], "request path")
+	core.AssertEqual[any](t, "61 62 ...", sent[utils.FieldPayload], "request payload")
+
+	core.AssertEqual[any](t, utils.DirectionReceived, received[utils.FieldDirection], "received")
+	core.AssertEqual[any](t, "/echo", received[utils.FieldPath], "response path")
+	_, ok := received[utils.FieldDuration]
+	core.AssertTrue(t, ok, "duration")
+}
@@ -0,0 +1,199 @@
+package cluster
+
+import (
+	"slices"
+
+	"darvaza.org/core"
+	"darvaza.org/slog"
+	"darvaza.org/slog/handlers/discard"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/server"
+	"protomcp.org/nanorpc/pkg/nanorpc/utils"
+)
+
+// Message is an update replicated between the nodes of a cluster.
+type Message struct {
+	// Data is the update, as given to PublishByHash.
+	Data []byte `json:"data,omitempty"`
+	// Origin names the node it was published on.
+	Origin string `json:"origin"`
+	// PathHash is the hash of the path it was published on.
+	PathHash uint32 `json:"path_hash"`
+}
+
+// Broker carries messages between the nodes of a cluster. Send delivers
+// a message to the named nodes, or to every other node when nodes is
+// empty, and the receiving ones hand it to their [Bridge.Deliver].
+// Implementations must be safe for concurrent use.
+type Broker interface {
+	Send(nodes []string, msg Message) error
+}
+
+// Local delivers updates to the subscribers connected to this node, as
+// [server.DefaultMessageHandler] does.
+type Local interface {
+	PublishByHash(pathHash uint32, data []byte) error
+}
+
+// Config describes a [Bridge].
+//
+// Local delivers the updates to the subscribers connected to this node,
+// known to the others as Node, and Broker replicates them to the other
+// nodes. With a Store, shared by the nodes saving their subscriptions in
+// it, see [server.WithStore], updates are only sent to the nodes holding
+// subscribers of the path, otherwise to all of them. Subscriptions to
+// patterns are saved under the hash of the pattern, not of the paths it
+// matches, so deployments using them leave Store unset.
+//
+// HashCache defaults to a new one, and Logger to discarding.
+type Config struct {
+	Local     Local
+	Broker    Broker
+	Store     server.SubscriptionStore
+	Logger    slog.Logger
+	HashCache *nanorpc.HashCache
+	Node      string
+}
+
+// New creates a [Bridge] from the [Config]. A missing Local, Broker or
+// Node fail with [ErrMissingLocal], [ErrMissingBroker] or
+// [ErrMissingNode].
+func (cfg *Config) New() (*Bridge, error) {
+	switch {
+	case cfg == nil:
+		return nil, core.ErrNilReceiver
+	case core.IsNil(cfg.Local):
+		return nil, ErrMissingLocal
+	case core.IsNil(cfg.Broker):
+		return nil, ErrMissingBroker
+	case cfg.Node == "":
+		return nil, ErrMissingNode
+	}
+
+	b := &Bridge{
+		local:  cfg.Local,
+		broker: cfg.Broker,
+		logger: utils.WithComponent(cfg.Logger, utils.ComponentClusterBridge),
+		hc:     cfg.HashCache,
+		node:   cfg.Node,
+	}
+	if !core.IsNil(cfg.Store) {
+		b.store = cfg.Store
+	}
+	if b.logger == nil {
+		b.logger = discard.New()
+	}
+	if b.hc == nil {
+		b.hc = new(nanorpc.HashCache)
+	}
+	return b, nil
+}
+
+var _ server.Publisher = (*Bridge)(nil)
+
+// Bridge publishes updates on this node and replicates them to the other
+// nodes of a cluster, see [Config].
+type Bridge struct {
+	local  Local
+	broker Broker
+	store  server.SubscriptionStore
+	logger slog.Logger
+	hc     *nanorpc.HashCache
+	node   string
+}
+
+// Node returns the name of this node.
+func (b *Bridge) Node() string {
+	if b == nil {
+		return ""
+	}
+	return b.node
+}
+
+// Publish publishes an update on a path, see [Bridge.PublishByHash].
+func (b *Bridge) Publish(path string, data []byte) error {
+	if b == nil {
+		return core.ErrNilReceiver
+	}
+
+	pathHash, err := b.hc.Hash(path)
+	if err != nil {
+		return core.Wrapf(err, "failed to hash path %q", path)
+	}
+	return b.PublishByHash(pathHash, data)
+}
+
+// PublishByHash delivers an update to the subscribers of a path hash
+// connected to this node, and replicates it to the other nodes. Both are
+// attempted, returning the first error.
+func (b *Bridge) PublishByHash(pathHash uint32, data []byte) error {
+	if b == nil {
+		return core.ErrNilReceiver
+	}
+
+	err := b.local.PublishByHash(pathHash, data)
+	if rerr := b.replicate(pathHash, data); err == nil {
+		err = rerr
+	}
+	return err
+}
+
+// Deliver delivers an update replicated by another node to the
+// subscribers connected to this one, without replicating it again.
+// Brokers call it with the messages they receive. Messages published on
+// this node, echoed by brokers broadcasting to every node, are ignored.
+func (b *Bridge) Deliver(msg Message) error {
+	switch {
+	case b == nil:
+		return core.ErrNilReceiver
+	case msg.Origin == b.node:
+		return nil
+	}
+	return b.local.PublishByHash(msg.PathHash, msg.Data)
+}
+
+// replicate hands an update to the broker for the nodes that need it.
+func (b *Bridge) replicate(pathHash uint32, data []byte) error {
+	nodes, all, err := b.remoteNodes(pathHash)
+	switch {
+	case err != nil:
+		b.logWarn(err, pathHash, "failed to find subscribed nodes")
+		return err
+	case !all && len(nodes) == 0:
+		return nil
+	}
+
+	msg := Message{Data: data, Origin: b.node, PathHash: pathHash}
+	if err := b.broker.Send(nodes, msg); err != nil {
+		b.logWarn(err, pathHash, "failed to replicate update")
+		return err
+	}
+	return nil
+}
+
+// remoteNodes returns the other nodes holding subscribers of a path
+// hash, or all of them without store.
+func (b *Bridge) remoteNodes(pathHash uint32) (nodes []string, all bool, err error) {
+	if b.store == nil {
+		return nil, true, nil
+	}
+
+	nodes, err = server.SubscribedNodes(b.store, pathHash)
+	if err != nil {
+		return nil, false, err
+	}
+	nodes = slices.DeleteFunc(nodes, func(node string) bool { return node == b.node })
+	return nodes, false, nil
+}
+
+func (b *Bridge) logWarn(err error, pathHash uint32, msg string) {
+	if b == nil {
+		return
+	}
+
+	if l, ok := b.logger.Warn().WithEnabled(); ok {
+		l = utils.WithError(l, err)
+		l.WithField(utils.FieldPathHash, pathHash).Print(msg)
+	}
+}
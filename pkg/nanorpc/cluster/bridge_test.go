@@ -0,0 +1,162 @@
+package cluster
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc/server"
+)
+
+// recordingLocal is a [Local] recording the updates published.
+type recordingLocal struct {
+	err     error
+	updates []Message
+	mu      sync.Mutex
+}
+
+func (l *recordingLocal) PublishByHash(pathHash uint32, data []byte) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.updates = append(l.updates, Message{PathHash: pathHash, Data: data})
+	return l.err
+}
+
+func (l *recordingLocal) count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.updates)
+}
+
+// loopBroker is a [Broker] delivering messages to the bridges of a
+// cluster in-process, echoing them to the sender as broadcasting brokers
+// do.
+type loopBroker struct {
+	bridges map[string]*Bridge
+	sent    [][]string
+}
+
+func (b *loopBroker) Send(nodes []string, msg Message) error {
+	b.sent = append(b.sent, nodes)
+	for node, bridge := range b.bridges {
+		if len(nodes) == 0 || core.SliceContains(nodes, node) {
+			_ = bridge.Deliver(msg)
+		}
+	}
+	return nil
+}
+
+// newTestCluster creates a bridge per node sharing a [loopBroker], and
+// store if not nil.
+func newTestCluster(t *testing.T, store server.SubscriptionStore,
+	nodes ...string) (*loopBroker, map[string]*recordingLocal) {
+	t.Helper()
+
+	broker := &loopBroker{bridges: make(map[string]*Bridge)}
+	locals := make(map[string]*recordingLocal)
+	for _, node := range nodes {
+		locals[node] = &recordingLocal{}
+		b, err := (&Config{Local: locals[node], Broker: broker, Store: store, Node: node}).New()
+		core.AssertMustNoError(t, err, "New %s", node)
+		broker.bridges[node] = b
+	}
+	return broker, locals
+}
+
+func TestBridge_PublishByHash(t *testing.T) {
+	broker, locals := newTestCluster(t, nil, "node1", "node2", "node3")
+
+	err := broker.bridges["node1"].Publish("/sensors/temperature", []byte("21.5"))
+	core.AssertMustNoError(t, err, "Publish")
+
+	core.AssertMustEqual(t, 1, len(broker.sent), "sent")
+	core.AssertEqual(t, 0, len(broker.sent[0]), "to every node")
+	for node, local := range locals {
+		// the echo to node1 is ignored
+		core.AssertEqual(t, 1, local.count(), "%s updates", node)
+	}
+	core.AssertEqual(t, "21.5", string(locals["node3"].updates[0].Data), "data")
+	core.AssertEqual(t, locals["node1"].updates[0].PathHash, locals["node2"].updates[0].PathHash, "path hash")
+}
+
+// TestBridge_store verifies updates only reach the nodes with
+// subscribers when a store is shared.
+func TestBridge_store(t *testing.T) {
+	store := server.NewMemoryStore()
+	broker, locals := newTestCluster(t, store, "node1", "node2", "node3")
+	for _, r := range core.S(
+		server.SubscriptionRecord{SessionID: "a", Node: "node1", RequestID: 1, PathHash: 7},
+		server.SubscriptionRecord{SessionID: "b", Node: "node3", RequestID: 1, PathHash: 7},
+	) {
+		core.AssertMustNoError(t, store.AddSubscription(r), "AddSubscription")
+	}
+
+	core.AssertMustNoError(t, broker.bridges["node1"].PublishByHash(7, []byte("x")), "subscribed")
+	core.AssertMustEqual(t, 1, len(broker.sent), "sent")
+	core.AssertSliceEqual(t, core.S("node3"), broker.sent[0], "nodes")
+	core.AssertEqual(t, 0, locals["node2"].count(), "node2 updates")
+	core.AssertEqual(t, 1, locals["node3"].count(), "node3 updates")
+
+	core.AssertMustNoError(t, broker.bridges["node3"].PublishByHash(9, []byte("x")), "not subscribed")
+	core.AssertEqual(t, 1, len(broker.sent), "not sent")
+	core.AssertEqual(t, 2, locals["node3"].count(), "published locally")
+}
+
+// failingBroker is a [Broker] failing every message.
+type failingBroker struct{}
+
+var errBrokerDown = errors.New("broker down")
+
+func (failingBroker) Send([]string, Message) error { return errBrokerDown }
+
+func TestBridge_errors(t *testing.T) {
+	errLocal := errors.New("subscriber gone")
+	local := &recordingLocal{err: errLocal}
+	b, err := (&Config{Local: local, Broker: failingBroker{}, Node: "node1"}).New()
+	core.AssertMustNoError(t, err, "New")
+
+	// both attempted, the first error returned
+	core.AssertErrorIs(t, b.PublishByHash(7, nil), errLocal, "local first")
+	local.err = nil
+	core.AssertErrorIs(t, b.PublishByHash(7, nil), errBrokerDown, "broker")
+	core.AssertEqual(t, 2, local.count(), "published locally")
+}
+
+func TestConfig_New(t *testing.T) {
+	local, broker := &recordingLocal{}, failingBroker{}
+	for _, tc := range []struct {
+		cfg  *Config
+		want error
+		name string
+	}{
+		{name: "nil", want: core.ErrNilReceiver},
+		{name: "local", cfg: &Config{Broker: broker, Node: "n"}, want: ErrMissingLocal},
+		{name: "broker", cfg: &Config{Local: local, Node: "n"}, want: ErrMissingBroker},
+		{name: "node", cfg: &Config{Local: local, Broker: broker}, want: ErrMissingNode},
+	} {
+		_, err := tc.cfg.New()
+		core.AssertErrorIs(t, err, tc.want, tc.name)
+	}
+
+	b, err := (&Config{Local: local, Broker: broker, Node: "n"}).New()
+	core.AssertMustNoError(t, err, "valid")
+	core.AssertEqual(t, "n", b.Node(), "Node")
+}
+
+func TestBridge_nilReceiver(t *testing.T) {
+	var b *Bridge
+	core.AssertErrorIs(t, b.Publish("/x", nil), core.ErrNilReceiver, "Publish")
+	core.AssertErrorIs(t, b.PublishByHash(1, nil), core.ErrNilReceiver, "PublishByHash")
+	core.AssertErrorIs(t, b.Deliver(Message{}), core.ErrNilReceiver, "Deliver")
+	core.AssertEqual(t, "", b.Node(), "Node")
+
+	var cb *ClientBroker
+	core.AssertErrorIs(t, cb.AddPeer("n", nil), core.ErrNilReceiver, "AddPeer")
+	core.AssertErrorIs(t, cb.RemovePeer("n"), core.ErrNilReceiver, "RemovePeer")
+	core.AssertErrorIs(t, cb.Send(nil, Message{}), core.ErrNilReceiver, "Send")
+	core.AssertEqual(t, 0, len(cb.Peers()), "Peers")
+}
@@ -0,0 +1,27 @@
+// Package cluster replicates the updates published on a NanoRPC server
+// to the other instances of a horizontally scaled deployment, so they
+// reach subscribers regardless of which instance holds their connection.
+//
+// A [Bridge] publishes every update locally and hands it to a [Broker]
+// carrying it to the other nodes, whose bridges deliver it to their own
+// subscribers without replicating it again. [ClientBroker] carries them
+// over NanoRPC itself, as requests to the [Bridge.Handler] of every peer;
+// other brokers, e.g. over a message queue, call [Bridge.Deliver] with the
+// messages they receive.
+//
+//	broker := cluster.NewClientBroker("", logger)
+//	_ = broker.AddPeer("node2", node2Client)
+//
+//	bridge, err := (&cluster.Config{
+//		Local:  handler,
+//		Broker: broker,
+//		Node:   "node1",
+//	}).New()
+//	_ = handler.RegisterHandler(cluster.DefaultPath, bridge.Handler())
+//
+//	err = bridge.Publish("/sensors/temperature", data)
+//
+// With a [server.SubscriptionStore] shared by the nodes, see
+// [server.WithStore], updates are only sent to the nodes holding
+// subscribers of the path.
+package cluster
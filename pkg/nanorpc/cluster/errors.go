@@ -0,0 +1,25 @@
+package cluster
+
+import "darvaza.org/core"
+
+// Invalid-argument sentinels for the cluster package. Each wraps
+// [core.ErrInvalid]. Call sites add dynamic context by wrapping the
+// sentinel, e.g. core.QuietWrap(ErrMissingPeer, "node %q", node).
+var (
+	// ErrMissingLocal indicates a [Config] without local publisher.
+	ErrMissingLocal = core.QuietWrap(core.ErrInvalid, "local publisher missing")
+
+	// ErrMissingBroker indicates a [Config] without broker.
+	ErrMissingBroker = core.QuietWrap(core.ErrInvalid, "broker missing")
+
+	// ErrMissingNode indicates a [Config] without node name, or a peer
+	// added without one.
+	ErrMissingNode = core.QuietWrap(core.ErrInvalid, "node name missing")
+
+	// ErrMissingPeer indicates a nil peer was added to a [ClientBroker].
+	ErrMissingPeer = core.QuietWrap(core.ErrInvalid, "peer missing")
+)
+
+// ErrUnknownNode indicates a message was sent to, or a peer removed
+// from, a node a [ClientBroker] doesn't know.
+var ErrUnknownNode = core.Wrap(core.ErrNotExists, "unknown node")
@@ -0,0 +1,191 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"sync"
+
+	"darvaza.org/core"
+	"darvaza.org/slog"
+	"darvaza.org/slog/handlers/discard"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/client"
+	"protomcp.org/nanorpc/pkg/nanorpc/server"
+	"protomcp.org/nanorpc/pkg/nanorpc/utils"
+)
+
+// DefaultPath is the request path [ClientBroker] sends messages to when
+// none is given, where the [Bridge.Handler] of every node is registered.
+const DefaultPath = "/_cluster/publish"
+
+// Handler returns a [server.RequestHandler] delivering the messages sent
+// by the [ClientBroker] of other nodes, see [Bridge.Deliver], to register
+// on [DefaultPath]. Malformed messages are answered STATUS_BAD_REQUEST.
+// It doesn't check who's asking; protect the path with an
+// [server.Interceptor] or [server.RequireAuth].
+func (b *Bridge) Handler() server.RequestHandler {
+	return server.RequestHandlerFunc(func(_ context.Context, rc *server.RequestContext) error {
+		var msg Message
+		if err := rc.UnmarshalRequestJSON(&msg); err != nil {
+			return rc.SendBadRequest(err.Error())
+		}
+
+		if err := b.Deliver(msg); err != nil {
+			// failures of single subscribers are the local
+			// handler's to report
+			b.logWarn(err, msg.PathHash, "failed to deliver replicated update")
+		}
+		return rc.SendOK(nil)
+	})
+}
+
+// RawRequester sends requests carrying raw data, as [client.Client] does.
+type RawRequester interface {
+	RequestRaw(path string, data []byte, cb client.RequestCallback) (int32, error)
+}
+
+var _ Broker = (*ClientBroker)(nil)
+
+// ClientBroker is a [Broker] carrying messages over NanoRPC, as JSON
+// requests to the [Bridge.Handler] of the other nodes, through a client
+// connected to each of them. Requests are sent without waiting for the
+// answer; peers refusing them are logged.
+type ClientBroker struct {
+	peers  map[string]RawRequester
+	logger slog.Logger
+	path   string
+	mu     sync.RWMutex
+}
+
+// NewClientBroker creates a [ClientBroker] without peers, sending to
+// path, or [DefaultPath] if empty.
+func NewClientBroker(path string, logger slog.Logger) *ClientBroker {
+	if path == "" {
+		path = DefaultPath
+	}
+
+	logger = utils.WithComponent(logger, utils.ComponentClusterBridge)
+	if logger == nil {
+		logger = discard.New()
+	}
+
+	return &ClientBroker{
+		peers:  make(map[string]RawRequester),
+		logger: logger,
+		path:   path,
+	}
+}
+
+// AddPeer sets the client messages to node are sent through, replacing
+// any previous one.
+func (cb *ClientBroker) AddPeer(node string, peer RawRequester) error {
+	switch {
+	case cb == nil:
+		return core.ErrNilReceiver
+	case node == "":
+		return ErrMissingNode
+	case core.IsNil(peer):
+		return core.QuietWrap(ErrMissingPeer, "node %q", node)
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.peers == nil {
+		cb.peers = make(map[string]RawRequester)
+	}
+	cb.peers[node] = peer
+	return nil
+}
+
+// RemovePeer forgets the client of node. Unknown nodes fail with
+// [ErrUnknownNode].
+func (cb *ClientBroker) RemovePeer(node string) error {
+	if cb == nil {
+		return core.ErrNilReceiver
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if _, ok := cb.peers[node]; !ok {
+		return core.QuietWrap(ErrUnknownNode, "node %q", node)
+	}
+	delete(cb.peers, node)
+	return nil
+}
+
+// Peers returns the sorted names of the nodes with a client.
+func (cb *ClientBroker) Peers() []string {
+	if cb == nil {
+		return nil
+	}
+
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+
+	return core.SortedKeys(cb.peers)
+}
+
+// Send sends a message to the named nodes, or to every peer when none
+// are named. Nodes without client fail with [ErrUnknownNode], without
+// stopping the message from reaching the others.
+func (cb *ClientBroker) Send(nodes []string, msg Message) error {
+	if cb == nil {
+		return core.ErrNilReceiver
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	peers, errs := cb.targets(nodes)
+	for node, peer := range peers {
+		if _, err := peer.RequestRaw(cb.path, data, cb.answered(node, msg.PathHash)); err != nil {
+			errs = append(errs, core.Wrapf(err, "node %q", node))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// targets returns the clients of the named nodes, or all of them, and
+// the errors of the nodes without.
+func (cb *ClientBroker) targets(nodes []string) (map[string]RawRequester, []error) {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+
+	if len(nodes) == 0 {
+		return maps.Clone(cb.peers), nil
+	}
+
+	var errs []error
+	out := make(map[string]RawRequester, len(nodes))
+	for _, node := range nodes {
+		if peer, ok := cb.peers[node]; ok {
+			out[node] = peer
+		} else {
+			errs = append(errs, core.QuietWrap(ErrUnknownNode, "node %q", node))
+		}
+	}
+	return out, errs
+}
+
+// answered returns the callback logging the refusals of node.
+func (cb *ClientBroker) answered(node string, pathHash uint32) client.RequestCallback {
+	return func(_ context.Context, _ int32, res *nanorpc.NanoRPCResponse) error {
+		if err := nanorpc.ResponseAsError(res); err != nil {
+			if l, ok := cb.logger.Warn().WithEnabled(); ok {
+				l = utils.WithError(l, err)
+				l.WithFields(slog.Fields{
+					utils.FieldPathHash: pathHash,
+					utils.FieldNode:     node,
+				}).Print("peer failed to take replicated update")
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,125 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/client"
+	"protomcp.org/nanorpc/pkg/nanorpc/nanorpctest"
+	"protomcp.org/nanorpc/pkg/nanorpc/server"
+)
+
+const (
+	testTimeout = 2 * time.Second
+	testPath    = "/sensors/temperature"
+)
+
+// startNode serves a message handler with the [Bridge.Handler] of a node
+// registered on [DefaultPath].
+func startNode(t *testing.T, node string, broker Broker) (*Bridge, *nanorpctest.InprocListener) {
+	t.Helper()
+
+	handler := server.NewDefaultMessageHandler(nil)
+	bridge, err := (&Config{Local: handler, Broker: broker, Node: node}).New()
+	core.AssertMustNoError(t, err, "New %s", node)
+	core.AssertMustNoError(t, handler.RegisterHandler(DefaultPath, bridge.Handler()), "register")
+
+	ln := nanorpctest.NewInprocListener(node)
+	srv := server.NewDefaultServer(ln, handler, nil)
+	go func() { _ = srv.Serve(context.Background()) }()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+		defer cancel()
+		_ = srv.Shutdown(ctx)
+	})
+	return bridge, ln
+}
+
+// attachClient connects a new client to a node.
+func attachClient(ctx context.Context, t *testing.T, ln *nanorpctest.InprocListener) *client.Client {
+	t.Helper()
+
+	c, err := (&client.Config{Context: ctx, Remote: "127.0.0.1:1"}).New()
+	core.AssertMustNoError(t, err, "client")
+	core.AssertMustNoError(t, ln.Attach(ctx, c), "Attach")
+	core.AssertMustNoError(t, c.WaitConnected(ctx), "WaitConnected")
+	return c
+}
+
+// TestClientBroker verifies an update published on a node reaches the
+// subscribers of another through NanoRPC.
+func TestClientBroker(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	broker := NewClientBroker("", nil)
+	node1, _ := startNode(t, "node1", broker)
+	_, ln2 := startNode(t, "node2", NewClientBroker("", nil))
+
+	responses := make(chan *nanorpc.NanoRPCResponse, 4)
+	subscriber := attachClient(ctx, t, ln2)
+	_, err := subscriber.SubscribeRaw(testPath, nil, func(_ context.Context, _ int32,
+		res *nanorpc.NanoRPCResponse) error {
+		responses <- res
+		return nil
+	})
+	core.AssertMustNoError(t, err, "SubscribeRaw")
+	awaitResponse(ctx, t, responses, nanorpc.NanoRPCResponse_TYPE_RESPONSE)
+
+	core.AssertMustNoError(t, broker.AddPeer("node2", attachClient(ctx, t, ln2)), "AddPeer")
+	core.AssertSliceEqual(t, core.S("node2"), broker.Peers(), "Peers")
+	core.AssertMustNoError(t, node1.Publish(testPath, []byte("21.5")), "Publish")
+
+	update := awaitResponse(ctx, t, responses, nanorpc.NanoRPCResponse_TYPE_UPDATE)
+	core.AssertEqual(t, "21.5", string(update.Data), "data")
+
+	err = broker.Send(core.S("node3"), Message{Origin: "node1"})
+	core.AssertErrorIs(t, err, ErrUnknownNode, "unknown node")
+	core.AssertMustNoError(t, broker.RemovePeer("node2"), "RemovePeer")
+	core.AssertErrorIs(t, broker.RemovePeer("node2"), ErrUnknownNode, "RemovePeer again")
+}
+
+func awaitResponse(ctx context.Context, t *testing.T, responses <-chan *nanorpc.NanoRPCResponse,
+	want nanorpc.NanoRPCResponse_Type) *nanorpc.NanoRPCResponse {
+	t.Helper()
+
+	select {
+	case res := <-responses:
+		core.AssertMustEqual(t, want, res.ResponseType, "response type")
+		return res
+	case <-ctx.Done():
+		t.Fatalf("timed out waiting for %s", want)
+		return nil
+	}
+}
+
+func TestClientBroker_AddPeer(t *testing.T) {
+	broker := NewClientBroker("", nil)
+	core.AssertErrorIs(t, broker.AddPeer("", &client.Client{}), ErrMissingNode, "no node")
+	core.AssertErrorIs(t, broker.AddPeer("node2", nil), ErrMissingPeer, "nil peer")
+	var c *client.Client
+	core.AssertErrorIs(t, broker.AddPeer("node2", c), ErrMissingPeer, "nil client")
+}
+
+// TestBridge_Handler verifies malformed messages are refused.
+func TestBridge_Handler(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	_, ln := startNode(t, "node1", NewClientBroker("", nil))
+	c := attachClient(ctx, t, ln)
+
+	responses := make(chan *nanorpc.NanoRPCResponse, 1)
+	_, err := c.RequestRaw(DefaultPath, []byte("not json"), func(_ context.Context, _ int32,
+		res *nanorpc.NanoRPCResponse) error {
+		responses <- res
+		return nil
+	})
+	core.AssertMustNoError(t, err, "RequestRaw")
+	res := awaitResponse(ctx, t, responses, nanorpc.NanoRPCResponse_TYPE_RESPONSE)
+	core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_BAD_REQUEST, res.ResponseStatus, "status")
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/client"
+)
+
+// bench is the set of connections a workload runs over.
+type bench struct {
+	clients []*client.Client
+	opts    options
+}
+
+// operation is a measured step of a workload, run over c.
+type operation func(ctx context.Context, c *client.Client) error
+
+// dial connects -conns clients to the server, waiting up to the timeout.
+func dial(ctx context.Context, opts options) (*bench, error) {
+	b := &bench{opts: opts}
+	for range opts.conns {
+		c, err := b.connect(ctx)
+		if err != nil {
+			b.close()
+			return nil, err
+		}
+		b.clients = append(b.clients, c)
+	}
+	return b, nil
+}
+
+func (b *bench) connect(ctx context.Context) (*client.Client, error) {
+	cfg := client.Config{
+		Context:         context.Background(),
+		Remote:          b.opts.remote,
+		DialTimeout:     b.opts.timeout,
+		AlwaysHashPaths: b.opts.hash,
+	}
+
+	c, err := cfg.New()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Connect(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, b.opts.timeout)
+	defer cancel()
+	if err := c.WaitConnected(ctx); err != nil {
+		_ = c.Shutdown(ctx)
+		return nil, fmt.Errorf("connecting to %s: %w", b.opts.remote, err)
+	}
+	return c, nil
+}
+
+// close shuts the clients down, waiting up to the timeout.
+func (b *bench) close() {
+	ctx, cancel := context.WithTimeout(context.Background(), b.opts.timeout)
+	defer cancel()
+
+	for _, c := range b.clients {
+		_ = c.Shutdown(ctx)
+	}
+}
+
+// drive runs op on every worker until -n operations are done or, if
+// zero, for -d, measuring each. Operations started before -d ends are
+// waited for, so none is left behind.
+func (b *bench) drive(ctx context.Context, op operation) *report {
+	until, cancel := b.limit(ctx)
+	defer cancel()
+
+	var claimed atomic.Int64
+	samples := make([][]time.Duration, b.opts.workers)
+	failed := make([]int, b.opts.workers)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for i := range b.opts.workers {
+		c := b.clients[i%len(b.clients)]
+		wg.Go(func() {
+			samples[i], failed[i] = b.work(ctx, until, c, op, &claimed)
+		})
+	}
+	wg.Wait()
+
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+	return newReport(samples, failed, elapsed, &before, &after)
+}
+
+// limit returns the context of a run of -d, unless -n is given.
+func (b *bench) limit(ctx context.Context) (context.Context, context.CancelFunc) {
+	if b.opts.requests > 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, b.opts.duration)
+}
+
+// work runs op until no operations are left to claim or until is done,
+// returning the latencies of those succeeding and how many failed.
+func (b *bench) work(ctx, until context.Context, c *client.Client, op operation,
+	claimed *atomic.Int64) ([]time.Duration, int) {
+	var latencies []time.Duration
+	var failed int
+
+	for until.Err() == nil {
+		if b.opts.requests > 0 && claimed.Add(1) > int64(b.opts.requests) {
+			break
+		}
+
+		start := time.Now()
+		err := op(ctx, c)
+		switch {
+		case err == nil:
+			latencies = append(latencies, time.Since(start))
+		case ctx.Err() == nil:
+			failed++
+		}
+	}
+	return latencies, failed
+}
+
+// roundTrip makes a request with send and waits up to the timeout for its
+// response, failing if it isn't STATUS_OK.
+func (b *bench) roundTrip(ctx context.Context, send func(cb client.RequestCallback) error) error {
+	ch := make(chan *nanorpc.NanoRPCResponse, 1)
+	cb := func(_ context.Context, _ int32, res *nanorpc.NanoRPCResponse) error {
+		select {
+		case ch <- res:
+		default:
+		}
+		return nil
+	}
+	if err := send(cb); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, b.opts.timeout)
+	defer cancel()
+
+	select {
+	case res := <-ch:
+		return nanorpc.ResponseAsError(res)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
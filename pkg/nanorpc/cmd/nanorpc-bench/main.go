@@ -0,0 +1,122 @@
+// Package main implements nanorpc-bench, a command driving request,
+// subscription and publishing workloads against a NanoRPC server to
+// measure its throughput and latency.
+//
+//	nanorpc-bench [flags] request <path>
+//	nanorpc-bench [flags] subscribe <path>
+//	nanorpc-bench [flags] publish [-subscribers n] <path>
+//
+// request makes requests carrying -size bytes, and subscribe subscribes
+// and unsubscribes, measuring both round trips. publish publishes updates
+// of -size bytes through the admin handler of the server package, which
+// the server must register under the -admin prefix, e.g. /admin/publish,
+// to -subscribers sessions counting what they receive.
+//
+// The -c workers share -conns connections, each waiting for one
+// operation at a time, until -n operations are done or, if zero, for -d.
+// The report gives the throughput, the latency percentiles, the failed
+// operations, and the allocations of this process per operation.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// errUsage indicates the command line is incomplete or invalid.
+var errUsage = errors.New("invalid usage")
+
+// options are the global command line flags.
+type options struct {
+	remote   string
+	admin    string
+	duration time.Duration
+	timeout  time.Duration
+	conns    int
+	workers  int
+	requests int
+	size     int
+	hash     bool
+}
+
+// workload runs a subcommand with its arguments, returning its report.
+type workload func(ctx context.Context, b *bench, args []string) (*report, error)
+
+var workloads = map[string]workload{
+	"request":   runRequest,
+	"subscribe": runSubscribe,
+	"publish":   runPublish,
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.remote, "remote", "localhost:8080", "server address")
+	flag.StringVar(&opts.admin, "admin", "/admin", "path prefix of the admin handlers")
+	flag.DurationVar(&opts.duration, "d", 10*time.Second, "how long to run, unless -n is given")
+	flag.DurationVar(&opts.timeout, "timeout", 5*time.Second, "connection and response timeout")
+	flag.IntVar(&opts.conns, "conns", 1, "connections to the server")
+	flag.IntVar(&opts.workers, "c", 8, "concurrent workers")
+	flag.IntVar(&opts.requests, "n", 0, "operations to run, zero to run for -d")
+	flag.IntVar(&opts.size, "size", 64, "payload size in bytes")
+	flag.BoolVar(&opts.hash, "hash", false, "send paths as hashes")
+	flag.Parse()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	err := run(ctx, opts, flag.Args(), os.Stdout)
+	switch {
+	case errors.Is(err, errUsage):
+		fmt.Fprintln(os.Stderr, "nanorpc-bench:", err)
+		flag.Usage()
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "nanorpc-bench:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, opts options, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: missing workload", errUsage)
+	}
+
+	wl, ok := workloads[args[0]]
+	if !ok {
+		return fmt.Errorf("%w: unknown workload %q", errUsage, args[0])
+	}
+	if opts.conns < 1 || opts.workers < 1 || opts.requests < 0 || opts.size < 0 {
+		return fmt.Errorf("%w: -conns and -c must be positive, -n and -size not negative", errUsage)
+	}
+
+	b, err := dial(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer b.close()
+
+	r, err := wl(ctx, b, args[1:])
+	if err != nil {
+		return err
+	}
+	r.name = args[0]
+	return r.write(w)
+}
+
+// parsePath parses the only argument left, the path of the workload.
+func parsePath(fs *flag.FlagSet, args []string) (string, error) {
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return "", fmt.Errorf("%w: %w", errUsage, err)
+	}
+	if fs.NArg() != 1 {
+		return "", fmt.Errorf("%w: %s needs a path", errUsage, fs.Name())
+	}
+	return fs.Arg(0), nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc/server"
+	"protomcp.org/nanorpc/pkg/nanorpc/utils/testutils/e2e"
+)
+
+const testTimeout = 2 * time.Second
+
+// newTestServer starts a server with an echo handler and the admin
+// publishing handler, returning the options to reach it.
+func newTestServer(t *testing.T) options {
+	t.Helper()
+
+	ts := e2e.NewTestServer(t, nil)
+	ts.Handle("/echo", func(_ context.Context, rc *server.RequestContext) error {
+		return rc.SendOK(rc.Request.Data)
+	})
+	h := ts.Handler
+	core.AssertMustNoError(t, h.RegisterHandler("/admin/publish", h.AdminPublishHandler()), "publish")
+
+	return options{
+		remote:   ts.Addr(),
+		admin:    "/admin",
+		timeout:  testTimeout,
+		conns:    2,
+		workers:  4,
+		requests: 40,
+		size:     16,
+	}
+}
+
+// runWorkload runs a workload against the server, returning its report.
+func runWorkload(t *testing.T, opts options, args ...string) string {
+	t.Helper()
+
+	var out bytes.Buffer
+	core.AssertMustNoError(t, run(context.Background(), opts, args, &out), "%q", args)
+	return out.String()
+}
+
+func assertLine(t *testing.T, out, want string) {
+	t.Helper()
+	core.AssertTrue(t, strings.Contains(out, want+"\n"), "%q in %q", want, out)
+}
+
+func TestRun_request(t *testing.T) {
+	opts := newTestServer(t)
+	out := runWorkload(t, opts, "request", "/echo")
+	assertLine(t, out, "workload:   request")
+	core.AssertTrue(t, strings.Contains(out, "operations: 40 ok, 0 failed"), "operations: %q", out)
+
+	opts.hash = true
+	out = runWorkload(t, opts, "request", "/missing")
+	core.AssertTrue(t, strings.Contains(out, "operations: 0 ok, 40 failed"), "not found: %q", out)
+}
+
+func TestRun_requestDuration(t *testing.T) {
+	opts := newTestServer(t)
+	opts.requests = 0
+	opts.duration = 100 * time.Millisecond
+
+	out := runWorkload(t, opts, "request", "/echo")
+	core.AssertTrue(t, strings.Contains(out, " 0 failed"), "failed: %q", out)
+	core.AssertFalse(t, strings.Contains(out, "operations: 0 ok"), "ok: %q", out)
+}
+
+func TestRun_subscribe(t *testing.T) {
+	out := runWorkload(t, newTestServer(t), "subscribe", "/sensors")
+	core.AssertTrue(t, strings.Contains(out, "operations: 40 ok, 0 failed"), "operations: %q", out)
+}
+
+func TestRun_publish(t *testing.T) {
+	out := runWorkload(t, newTestServer(t), "publish", "-subscribers", "3", "/sensors")
+	core.AssertTrue(t, strings.Contains(out, "operations: 40 ok, 0 failed"), "operations: %q", out)
+	core.AssertTrue(t, strings.Contains(out, "updates:    120 received"), "updates: %q", out)
+}
+
+func TestRun_usage(t *testing.T) {
+	opts := newTestServer(t)
+	for _, args := range [][]string{
+		nil,
+		{"unknown"},
+		{"request"},
+		{"subscribe", "/a", "/b"},
+		{"publish", "-subscribers", "-1", "/a"},
+		{"publish", "-bogus", "/a"},
+	} {
+		err := run(context.Background(), opts, args, &bytes.Buffer{})
+		core.AssertErrorIs(t, err, errUsage, "%q", args)
+	}
+
+	opts.workers = 0
+	err := run(context.Background(), opts, []string{"request", "/echo"}, &bytes.Buffer{})
+	core.AssertErrorIs(t, err, errUsage, "no workers")
+}
+
+func TestReport_percentile(t *testing.T) {
+	samples := [][]time.Duration{{3, 1}, {4, 2}, {5}}
+	var stats runtime.MemStats
+	r := newReport(samples, []int{1, 0, 0}, time.Second, &stats, &stats)
+
+	core.AssertEqual(t, 6, r.ops(), "ops")
+	core.AssertEqual(t, time.Duration(1), r.percentile(0), "p0")
+	core.AssertEqual(t, time.Duration(3), r.percentile(50), "p50")
+	core.AssertEqual(t, time.Duration(5), r.percentile(90), "p90")
+	core.AssertEqual(t, time.Duration(5), r.percentile(100), "max")
+	core.AssertEqual(t, time.Duration(0), new(report).percentile(50), "empty")
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"slices"
+	"time"
+)
+
+// report is the outcome of a workload.
+type report struct {
+	name      string
+	latencies []time.Duration // sorted
+	elapsed   time.Duration
+	mallocs   uint64
+	bytes     uint64
+	updates   uint64 // received by subscribers, publish only
+	failed    int
+	publish   bool
+}
+
+func newReport(samples [][]time.Duration, failed []int, elapsed time.Duration,
+	before, after *runtime.MemStats) *report {
+	r := &report{
+		elapsed: elapsed,
+		mallocs: after.Mallocs - before.Mallocs,
+		bytes:   after.TotalAlloc - before.TotalAlloc,
+	}
+	for i := range samples {
+		r.latencies = append(r.latencies, samples[i]...)
+		r.failed += failed[i]
+	}
+	slices.Sort(r.latencies)
+	return r
+}
+
+// ops returns the number of operations run, failed or not.
+func (r *report) ops() int {
+	return len(r.latencies) + r.failed
+}
+
+// percentile returns the latency p percent of the operations didn't
+// exceed, by nearest rank, or zero if none succeeded.
+func (r *report) percentile(p float64) time.Duration {
+	n := len(r.latencies)
+	if n == 0 {
+		return 0
+	}
+	i := int(math.Ceil(p/100*float64(n))) - 1
+	return r.latencies[min(max(i, 0), n-1)]
+}
+
+// perSecond returns n over the elapsed time.
+func (r *report) perSecond(n uint64) float64 {
+	if r.elapsed <= 0 {
+		return 0
+	}
+	return float64(n) / r.elapsed.Seconds()
+}
+
+// perOp returns n over the operations run.
+func (r *report) perOp(n uint64) float64 {
+	if r.ops() == 0 {
+		return 0
+	}
+	return float64(n) / float64(r.ops())
+}
+
+// write writes the report, a line per measure.
+func (r *report) write(w io.Writer) error {
+	ok := len(r.latencies)
+	lines := []string{
+		fmt.Sprintf("workload:   %s", r.name),
+		fmt.Sprintf("operations: %d ok, %d failed in %s", ok, r.failed, r.elapsed.Round(time.Millisecond)),
+		fmt.Sprintf("throughput: %.1f ops/s", r.perSecond(uint64(ok))),
+		fmt.Sprintf("latency:    p50 %s, p90 %s, p99 %s, max %s",
+			r.round(r.percentile(50)), r.round(r.percentile(90)),
+			r.round(r.percentile(99)), r.round(r.percentile(100))),
+		fmt.Sprintf("allocs:     %.1f allocs/op, %.0f B/op", r.perOp(r.mallocs), r.perOp(r.bytes)),
+	}
+	if r.publish {
+		lines = append(lines, fmt.Sprintf("updates:    %d received, %.1f/s", r.updates, r.perSecond(r.updates)))
+	}
+
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (*report) round(d time.Duration) time.Duration {
+	return d.Round(time.Microsecond)
+}
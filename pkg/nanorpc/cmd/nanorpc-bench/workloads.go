@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/client"
+	"protomcp.org/nanorpc/pkg/nanorpc/server"
+)
+
+// runRequest makes requests carrying -size bytes to a path.
+func runRequest(ctx context.Context, b *bench, args []string) (*report, error) {
+	path, err := parsePath(flag.NewFlagSet("request", flag.ContinueOnError), args)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, b.opts.size)
+	return b.drive(ctx, func(ctx context.Context, c *client.Client) error {
+		return b.request(ctx, c, path, data)
+	}), nil
+}
+
+// request makes a request and waits for its response.
+func (b *bench) request(ctx context.Context, c *client.Client, path string, data []byte) error {
+	return b.roundTrip(ctx, func(cb client.RequestCallback) error {
+		_, err := c.RequestRaw(path, data, cb)
+		return err
+	})
+}
+
+// runSubscribe subscribes to a path and unsubscribes, waiting for the
+// server to acknowledge both.
+func runSubscribe(ctx context.Context, b *bench, args []string) (*report, error) {
+	path, err := parsePath(flag.NewFlagSet("subscribe", flag.ContinueOnError), args)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.drive(ctx, func(ctx context.Context, c *client.Client) error {
+		id, err := b.subscribe(ctx, c, path, nil)
+		if err != nil {
+			return err
+		}
+		return b.roundTrip(ctx, func(cb client.RequestCallback) error {
+			return c.Unsubscribe(path, id, cb)
+		})
+	}), nil
+}
+
+// subscribe subscribes to a path, passing its updates to onUpdate if
+// given, and waits for the acknowledgement.
+func (b *bench) subscribe(ctx context.Context, c *client.Client, path string,
+	onUpdate func()) (int32, error) {
+	var id int32
+	err := b.roundTrip(ctx, func(cb client.RequestCallback) error {
+		var err error
+		id, err = c.SubscribeRaw(path, nil, func(ctx context.Context, id int32, res *nanorpc.NanoRPCResponse) error {
+			if res != nil && res.ResponseType == nanorpc.NanoRPCResponse_TYPE_UPDATE {
+				if onUpdate != nil {
+					onUpdate()
+				}
+				return nil
+			}
+			return cb(ctx, id, res)
+		})
+		return err
+	})
+	return id, err
+}
+
+// runPublish publishes updates of -size bytes to a path through the admin
+// handler, counting those received by -subscribers subscriptions spread
+// over the connections.
+func runPublish(ctx context.Context, b *bench, args []string) (*report, error) {
+	fs := flag.NewFlagSet("publish", flag.ContinueOnError)
+	subscribers := fs.Int("subscribers", 1, "subscriptions receiving the updates")
+	path, err := parsePath(fs, args)
+	switch {
+	case err != nil:
+		return nil, err
+	case *subscribers < 0:
+		return nil, fmt.Errorf("%w: -subscribers can't be negative", errUsage)
+	}
+
+	var received atomic.Uint64
+	for i := range *subscribers {
+		c := b.clients[i%len(b.clients)]
+		if _, err := b.subscribe(ctx, c, path, func() { received.Add(1) }); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	body, err := json.Marshal(server.AdminPublishRequest{Path: path, Data: make([]byte, b.opts.size)})
+	if err != nil {
+		return nil, err
+	}
+
+	admin := b.opts.admin + "/publish"
+	r := b.drive(ctx, func(ctx context.Context, c *client.Client) error {
+		return b.request(ctx, c, admin, body)
+	})
+
+	want := uint64(len(r.latencies)) * uint64(*subscribers)
+	r.publish = true
+	r.updates = b.awaitUpdates(ctx, &received, want)
+	return r, nil
+}
+
+// awaitUpdates waits up to the timeout for the subscribers to receive
+// want updates, returning how many they did.
+func (b *bench) awaitUpdates(ctx context.Context, received *atomic.Uint64, want uint64) uint64 {
+	ctx, cancel := context.WithTimeout(ctx, b.opts.timeout)
+	defer cancel()
+
+	tick := time.NewTicker(10 * time.Millisecond)
+	defer tick.Stop()
+
+	for received.Load() < want {
+		select {
+		case <-tick.C:
+		case <-ctx.Done():
+			return received.Load()
+		}
+	}
+	return received.Load()
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/client"
+)
+
+// cli is a connected client and where to write what it receives.
+type cli struct {
+	c    *client.Client
+	out  io.Writer
+	opts options
+}
+
+// close shuts the client down, waiting up to the timeout.
+func (cl *cli) close() {
+	ctx, cancel := context.WithTimeout(context.Background(), cl.opts.timeout)
+	defer cancel()
+	_ = cl.c.Shutdown(ctx)
+}
+
+// call makes a request and waits up to the timeout for its response,
+// failing if it isn't STATUS_OK.
+func (cl *cli) call(ctx context.Context, tg target, data []byte) (*nanorpc.NanoRPCResponse, error) {
+	ch := make(chan *nanorpc.NanoRPCResponse, 1)
+	if _, err := tg.request(cl.c, data, newResponseCallback(ch)); err != nil {
+		return nil, err
+	}
+	return cl.wait(ctx, ch)
+}
+
+// wait waits up to the timeout for a response, failing if it isn't
+// STATUS_OK.
+func (cl *cli) wait(ctx context.Context, ch <-chan *nanorpc.NanoRPCResponse) (*nanorpc.NanoRPCResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, cl.opts.timeout)
+	defer cancel()
+
+	select {
+	case res := <-ch:
+		return res, nanorpc.ResponseAsError(res)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// print writes a line with the data of a response, prefixed by its path
+// if it has one.
+func (cl *cli) print(res *nanorpc.NanoRPCResponse) error {
+	var err error
+	if res.Path != "" {
+		_, err = fmt.Fprintln(cl.out, res.Path, formatData(res.Data))
+	} else {
+		_, err = fmt.Fprintln(cl.out, formatData(res.Data))
+	}
+	return err
+}
+
+// newResponseCallback returns a [client.RequestCallback] passing the
+// responses to ch, dropping them if full.
+func newResponseCallback(ch chan<- *nanorpc.NanoRPCResponse) client.RequestCallback {
+	return func(_ context.Context, _ int32, res *nanorpc.NanoRPCResponse) error {
+		select {
+		case ch <- res:
+		default:
+		}
+		return nil
+	}
+}
+
+// newSubscriptionCallback returns a [client.RequestCallback] passing the
+// updates of a subscription to updates, and anything else, like its
+// acknowledgement, to acks, dropping them if full.
+func newSubscriptionCallback(acks, updates chan<- *nanorpc.NanoRPCResponse) client.RequestCallback {
+	onAck := newResponseCallback(acks)
+	onUpdate := newResponseCallback(updates)
+	return func(ctx context.Context, id int32, res *nanorpc.NanoRPCResponse) error {
+		if res.GetResponseType() == nanorpc.NanoRPCResponse_TYPE_UPDATE {
+			return onUpdate(ctx, id, res)
+		}
+		return onAck(ctx, id, res)
+	}
+}
+
+// target is the path of a request, a string or, if empty, a hash.
+type target struct {
+	path string
+	hash uint32
+}
+
+// parseTarget parses a path starting with a slash, or a path hash.
+func parseTarget(s string) (target, error) {
+	if strings.HasPrefix(s, "/") {
+		return target{path: s}, nil
+	}
+
+	hash, err := strconv.ParseUint(s, 0, 32)
+	if err != nil {
+		return target{}, fmt.Errorf("%w: invalid path %q", errUsage, s)
+	}
+	return target{hash: uint32(hash)}, nil
+}
+
+func (tg target) String() string {
+	if tg.path != "" {
+		return tg.path
+	}
+	return fmt.Sprintf("%#08x", tg.hash)
+}
+
+func (tg target) request(c *client.Client, data []byte, cb client.RequestCallback) (int32, error) {
+	if tg.path != "" {
+		return c.RequestRaw(tg.path, data, cb)
+	}
+	return c.RequestRawByHash(tg.hash, data, cb)
+}
+
+func (tg target) subscribe(c *client.Client, data []byte, cb client.RequestCallback) (int32, error) {
+	if tg.path != "" {
+		return c.SubscribeRaw(tg.path, data, cb)
+	}
+	return c.SubscribeRawByHash(tg.hash, data, cb)
+}
+
+func (tg target) unsubscribe(c *client.Client, id int32, cb client.RequestCallback) error {
+	if tg.path != "" {
+		return c.Unsubscribe(tg.path, id, cb)
+	}
+	return c.UnsubscribeByHash(tg.hash, id, cb)
+}
+
+// parsePayload returns the compacted JSON document of an optional
+// argument, or nil if none.
+func parsePayload(args []string) ([]byte, error) {
+	switch len(args) {
+	case 0:
+		return nil, nil
+	case 1:
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, []byte(args[0])); err != nil {
+			return nil, fmt.Errorf("%w: invalid JSON payload: %w", errUsage, err)
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("%w: too many arguments", errUsage)
+	}
+}
+
+// parseArgs parses a path and an optional payload.
+func parseArgs(args []string) (target, []byte, error) {
+	if len(args) == 0 {
+		return target{}, nil, fmt.Errorf("%w: missing path", errUsage)
+	}
+
+	tg, err := parseTarget(args[0])
+	if err != nil {
+		return target{}, nil, err
+	}
+
+	data, err := parsePayload(args[1:])
+	return tg, data, err
+}
+
+// formatData returns data as-is if it's JSON, or in hex otherwise.
+func formatData(data []byte) string {
+	if len(data) > 0 && json.Valid(data) {
+		return string(data)
+	}
+	return hex.EncodeToString(data)
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/server"
+)
+
+// cmdPing waits for the server to answer a ping, writing how long it took
+// and, with -timing, how that time splits between server and network.
+func cmdPing(ctx context.Context, cl *cli, args []string) error {
+	fs, timing := newTimingFlagSet("ping")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("%w: too many arguments", errUsage)
+	}
+
+	stamped := cl.c.Stats().Timestamped
+	rtt, err := cl.ping(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintln(cl.out, "pong", rtt.Round(time.Microsecond)); err != nil || !*timing {
+		return err
+	}
+
+	// the client accounts the pong like any other response
+	stats := cl.c.Stats()
+	if stats.Timestamped == stamped {
+		return cl.printTiming(0, 0, false)
+	}
+	return cl.printTiming(stats.LastServerTime, stats.LastRoundTrip-stats.LastServerTime, true)
+}
+
+// ping waits for the server to answer a ping, returning how long it took.
+func (cl *cli) ping(ctx context.Context) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, cl.opts.timeout)
+	defer cancel()
+
+	start := time.Now()
+	select {
+	case err := <-cl.c.Pong():
+		return time.Since(start), err
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// cmdRequest makes a request, writing the data of its response and, with
+// -timing, how its round-trip time splits between server and network.
+func cmdRequest(ctx context.Context, cl *cli, args []string) error {
+	fs, timing := newTimingFlagSet("request")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+
+	tg, data, err := parseArgs(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	res, err := cl.call(ctx, tg, data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", tg, err)
+	}
+	rtt := time.Since(start)
+
+	if err := cl.print(res); err != nil || !*timing {
+		return err
+	}
+
+	server, ok := nanorpc.ServerTime(res)
+	ok = ok && server <= rtt
+	return cl.printTiming(server, rtt-server, ok)
+}
+
+// newTimingFlagSet returns the flags of a command accepting -timing.
+func newTimingFlagSet(name string) (*flag.FlagSet, *bool) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	timing := fs.Bool("timing", false, "write server and network time")
+	return fs, timing
+}
+
+// printTiming writes the server processing and network time of a
+// response, or that the server didn't report them if !ok.
+func (cl *cli) printTiming(server, network time.Duration, ok bool) error {
+	var err error
+	if ok {
+		_, err = fmt.Fprintln(cl.out, "server", server.Round(time.Microsecond),
+			"network", network.Round(time.Microsecond))
+	} else {
+		_, err = fmt.Fprintln(cl.out, "server time not reported")
+	}
+	return err
+}
+
+// cmdSubscribe subscribes to a path, writing the data of every update
+// until interrupted or, if given, -count updates are received.
+func cmdSubscribe(ctx context.Context, cl *cli, args []string) error {
+	fs := flag.NewFlagSet("subscribe", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	count := fs.Int("count", 0, "updates to receive, zero for unlimited")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+
+	tg, data, err := parseArgs(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	acks := make(chan *nanorpc.NanoRPCResponse, 1)
+	updates := make(chan *nanorpc.NanoRPCResponse, 64)
+	id, err := tg.subscribe(cl.c, data, newSubscriptionCallback(acks, updates))
+	if err == nil {
+		_, err = cl.wait(ctx, acks)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", tg, err)
+	}
+
+	err = cl.updates(ctx, updates, *count)
+	cl.unsubscribe(tg, id)
+	return err
+}
+
+// updates writes the data of the updates received until ctx is cancelled
+// or, if positive, count updates are received.
+func (cl *cli) updates(ctx context.Context, ch <-chan *nanorpc.NanoRPCResponse, count int) error {
+	for n := 0; count <= 0 || n < count; n++ {
+		select {
+		case res := <-ch:
+			if err := nanorpc.ResponseAsError(res); err != nil {
+				return err
+			}
+			if err := cl.print(res); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	return nil
+}
+
+// unsubscribe cancels a subscription, waiting up to the timeout for the
+// server to acknowledge it.
+func (cl *cli) unsubscribe(tg target, id int32) {
+	ch := make(chan *nanorpc.NanoRPCResponse, 1)
+	if err := tg.unsubscribe(cl.c, id, newResponseCallback(ch)); err == nil {
+		_, _ = cl.wait(context.Background(), ch)
+	}
+}
+
+// cmdUnsubscribe forces a session off a path, through the handler of
+// [server.DefaultMessageHandler.AdminUnsubscribeHandler].
+func cmdUnsubscribe(ctx context.Context, cl *cli, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("%w: unsubscribe needs a session and a path", errUsage)
+	}
+
+	return cl.admin(ctx, "/unsubscribe", server.AdminUnsubscribeRequest{
+		SessionID: args[0],
+		Path:      args[1],
+	})
+}
+
+// cmdPublish publishes an update to the subscribers of a path, through the
+// handler of [server.DefaultMessageHandler.AdminPublishHandler].
+func cmdPublish(ctx context.Context, cl *cli, args []string) error {
+	tg, data, err := parseArgs(args)
+	switch {
+	case err != nil:
+		return err
+	case tg.path == "":
+		return fmt.Errorf("%w: publish needs a string path", errUsage)
+	}
+
+	return cl.admin(ctx, "/publish", server.AdminPublishRequest{
+		Path: tg.path,
+		Data: data,
+	})
+}
+
+// cmdRoutes writes the routes of the server a line each, through the
+// handler of [server.DefaultMessageHandler.ReflectionHandler].
+func cmdRoutes(ctx context.Context, cl *cli, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("%w: too many arguments", errUsage)
+	}
+
+	var routes []server.RouteInfo
+	if err := cl.adminCall(ctx, "/routes", nil, &routes); err != nil {
+		return err
+	}
+
+	for _, r := range routes {
+		line := fmt.Sprintf("%s %#08x", r.Path, r.Hash)
+		if r.RequestType != "" {
+			line += fmt.Sprintf(" %s %s", r.RequestType, r.ResponseType)
+		}
+		if _, err := fmt.Fprintln(cl.out, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// admin sends v as JSON to an admin handler.
+func (cl *cli) admin(ctx context.Context, name string, v any) error {
+	return cl.adminCall(ctx, name, v, nil)
+}
+
+// adminCall sends v as JSON, unless nil, to an admin handler, decoding
+// the JSON data of its response into out, unless nil.
+func (cl *cli) adminCall(ctx context.Context, name string, v, out any) error {
+	var data []byte
+	if v != nil {
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return err
+		}
+	}
+
+	tg := target{path: cl.opts.admin + name}
+	res, err := cl.call(ctx, tg, data)
+	if err == nil && out != nil {
+		err = json.Unmarshal(res.Data, out)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", tg, err)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+// Package main implements nanorpc-cli, a command-line client to poke
+// NanoRPC servers and devices from a shell.
+//
+//	nanorpc-cli [flags] ping [-timing]
+//	nanorpc-cli [flags] request [-timing] <path> [json]
+//	nanorpc-cli [flags] subscribe [-count n] <path> [json]
+//	nanorpc-cli [flags] unsubscribe <session> <path>
+//	nanorpc-cli [flags] publish <path> [json]
+//	nanorpc-cli [flags] routes
+//
+// Paths starting with a slash are sent as strings, or hashed if -hash is
+// given. Anything else is taken as a path hash, in decimal or, prefixed
+// by 0x, hexadecimal. Payloads are JSON documents, sent compacted.
+//
+// Response and update data is written a line each, as-is when it's JSON
+// or in hex otherwise, updates of pattern subscriptions prefixed by their
+// path. subscribe runs until interrupted, or -count updates are received.
+//
+// With -timing, ping and request also write the server processing time
+// and the network time, when the server timestamps its responses.
+//
+// routes writes the paths the server serves a line each, with their hash
+// and, if known, request and response message types, e.g. for shell
+// completion.
+//
+// unsubscribe, publish and routes are served by the admin handlers of the
+// server package, which the server must register under the -admin prefix,
+// e.g. /admin/unsubscribe, /admin/publish and /admin/routes.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"protomcp.org/nanorpc/pkg/nanorpc/client"
+)
+
+// DefaultAdminPrefix is where the admin handlers are expected by default.
+const DefaultAdminPrefix = "/admin"
+
+// errUsage indicates the command line is incomplete or invalid.
+var errUsage = errors.New("invalid usage")
+
+// options are the global command line flags.
+type options struct {
+	remote  string
+	admin   string
+	caFile  string
+	cert    string
+	key     string
+	timeout time.Duration
+	hash    bool
+}
+
+// command runs a subcommand with its arguments.
+type command func(ctx context.Context, cl *cli, args []string) error
+
+var commands = map[string]command{
+	"ping":        cmdPing,
+	"request":     cmdRequest,
+	"subscribe":   cmdSubscribe,
+	"unsubscribe": cmdUnsubscribe,
+	"publish":     cmdPublish,
+	"routes":      cmdRoutes,
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.remote, "remote", "localhost:8080", "server address")
+	flag.StringVar(&opts.admin, "admin", DefaultAdminPrefix, "path prefix of the admin handlers")
+	flag.StringVar(&opts.caFile, "ca", "", "CA certificate file, enables TLS")
+	flag.StringVar(&opts.cert, "cert", "", "client certificate file")
+	flag.StringVar(&opts.key, "key", "", "client key file")
+	flag.DurationVar(&opts.timeout, "timeout", 5*time.Second, "connection and response timeout")
+	flag.BoolVar(&opts.hash, "hash", false, "send string paths as hashes")
+	flag.Parse()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	err := run(ctx, opts, flag.Args(), os.Stdout)
+	switch {
+	case errors.Is(err, errUsage):
+		fmt.Fprintln(os.Stderr, "nanorpc-cli:", err)
+		flag.Usage()
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "nanorpc-cli:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, opts options, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: missing command", errUsage)
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("%w: unknown command %q", errUsage, args[0])
+	}
+
+	cl, err := dial(ctx, opts, w)
+	if err != nil {
+		return err
+	}
+	defer cl.close()
+
+	return cmd(ctx, cl, args[1:])
+}
+
+// dial connects a client to the server, waiting up to the timeout.
+func dial(ctx context.Context, opts options, w io.Writer) (*cli, error) {
+	cfg := client.Config{
+		Context:         context.Background(),
+		Remote:          opts.remote,
+		TLSCAFile:       opts.caFile,
+		TLSCertFile:     opts.cert,
+		TLSKeyFile:      opts.key,
+		DialTimeout:     opts.timeout,
+		AlwaysHashPaths: opts.hash,
+	}
+
+	c, err := cfg.New()
+	if err != nil {
+		return nil, err
+	}
+
+	cl := &cli{c: c, out: w, opts: opts}
+	if err := c.Connect(); err != nil {
+		cl.close()
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.timeout)
+	defer cancel()
+	if err := c.WaitConnected(ctx); err != nil {
+		cl.close()
+		return nil, fmt.Errorf("connecting to %s: %w", opts.remote, err)
+	}
+	return cl, nil
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/server"
+	"protomcp.org/nanorpc/pkg/nanorpc/utils/testutils/e2e"
+)
+
+const testTimeout = 2 * time.Second
+
+// syncBuffer is a [bytes.Buffer] safe to read while a command writes it.
+type syncBuffer struct {
+	buf bytes.Buffer
+	mu  sync.Mutex
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// newTestServer starts a server with an echo handler and the admin
+// handlers, returning the options to reach it. Responses are timestamped.
+func newTestServer(t *testing.T) (*e2e.TestServer, options) {
+	t.Helper()
+
+	ts := e2e.NewTestServer(t, &e2e.Options{
+		ServerOptions: []server.ServerOption{
+			server.WithSessionConfig(server.SessionConfig{Timestamps: true}),
+		},
+	})
+	ts.Handle("/echo", func(_ context.Context, rc *server.RequestContext) error {
+		return rc.SendOK(rc.Request.Data)
+	})
+	h := ts.Handler
+	core.AssertMustNoError(t, h.RegisterHandler("/admin/publish", h.AdminPublishHandler()), "publish")
+	core.AssertMustNoError(t, h.RegisterHandler("/admin/unsubscribe", h.AdminUnsubscribeHandler()), "unsubscribe")
+	core.AssertMustNoError(t, h.RegisterHandler("/admin/routes", h.ReflectionHandler()), "routes")
+
+	return ts, options{remote: ts.Addr(), admin: DefaultAdminPrefix, timeout: testTimeout}
+}
+
+// runCommand runs the command against the server, returning what it wrote.
+func runCommand(t *testing.T, opts options, args ...string) (string, error) {
+	t.Helper()
+
+	var out syncBuffer
+	err := run(context.Background(), opts, args, &out)
+	return out.String(), err
+}
+
+func TestRun_ping(t *testing.T) {
+	_, opts := newTestServer(t)
+
+	out, err := runCommand(t, opts, "ping")
+	core.AssertMustNoError(t, err, "ping")
+	core.AssertTrue(t, strings.HasPrefix(out, "pong "), "output %q", out)
+}
+
+func TestRun_request(t *testing.T) {
+	_, opts := newTestServer(t)
+
+	out, err := runCommand(t, opts, "request", "/echo", `{ "value": 21 }`)
+	core.AssertMustNoError(t, err, "request")
+	core.AssertEqual(t, "{\"value\":21}\n", out, "output")
+
+	opts.hash = true
+	out, err = runCommand(t, opts, "request", "/echo")
+	core.AssertMustNoError(t, err, "request hashed")
+	core.AssertEqual(t, "\n", out, "output hashed")
+
+	_, err = runCommand(t, opts, "request", "/missing")
+	core.AssertError(t, err, "request missing")
+}
+
+func TestRun_timing(t *testing.T) {
+	_, opts := newTestServer(t)
+
+	for _, args := range [][]string{
+		{"ping", "-timing"},
+		{"request", "-timing", "/echo", `{"value":21}`},
+	} {
+		out, err := runCommand(t, opts, args...)
+		core.AssertMustNoError(t, err, "%q", args)
+
+		lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+		core.AssertMustEqual(t, 2, len(lines), "%q lines %q", args, out)
+		core.AssertTrue(t, strings.HasPrefix(lines[1], "server "), "%q timing %q", args, lines[1])
+		core.AssertContains(t, lines[1], " network ", "%q timing", args)
+	}
+}
+
+func TestRun_subscribePublish(t *testing.T) {
+	_, opts := newTestServer(t)
+
+	var out syncBuffer
+	done := make(chan error, 1)
+	go func() {
+		done <- run(context.Background(), opts, []string{"subscribe", "-count", "1", "/events"}, &out)
+	}()
+
+	// publish until the subscription is made and receives it
+	timeout := time.After(testTimeout)
+	for {
+		_, err := runCommand(t, opts, "publish", "/events", `{"value":21}`)
+		core.AssertMustNoError(t, err, "publish")
+
+		select {
+		case err := <-done:
+			core.AssertMustNoError(t, err, "subscribe")
+			core.AssertEqual(t, "{\"value\":21}\n", out.String(), "output")
+			return
+		case <-timeout:
+			t.Fatal("timed out waiting for the update")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestRun_unsubscribe(t *testing.T) {
+	_, opts := newTestServer(t)
+
+	_, err := runCommand(t, opts, "unsubscribe", "unknown", "/events")
+	core.AssertError(t, err, "unsubscribe")
+}
+
+func TestRun_routes(t *testing.T) {
+	ts, opts := newTestServer(t)
+	err := server.RegisterTyped(ts.Handler, "/hello",
+		func(_ context.Context, req *nanorpc.NanoRPCHello) (*nanorpc.NanoRPCHello, error) {
+			return req, nil
+		})
+	core.AssertMustNoError(t, err, "RegisterTyped")
+
+	out, err := runCommand(t, opts, "routes")
+	core.AssertMustNoError(t, err, "routes")
+
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	core.AssertMustEqual(t, 5, len(lines), "lines %q", out)
+	core.AssertTrue(t, strings.HasPrefix(lines[0], "/admin/publish 0x"), "first %q", lines[0])
+	core.AssertTrue(t, strings.HasSuffix(lines[4], " NanoRPCHello NanoRPCHello"), "typed %q", lines[4])
+}
+
+func TestRun_usage(t *testing.T) {
+	_, opts := newTestServer(t)
+
+	for _, args := range [][]string{
+		nil,
+		{"unknown"},
+		{"ping", "extra"},
+		{"ping", "-unknown"},
+		{"request"},
+		{"request", "events"},
+		{"request", "/echo", "{"},
+		{"publish", "0x1234"},
+		{"routes", "extra"},
+	} {
+		_, err := runCommand(t, opts, args...)
+		core.AssertErrorIs(t, err, errUsage, "%q", args)
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// defaultReplayWait is how long replay waits for more responses.
+const defaultReplayWait = 2 * time.Second
+
+// maxRecordSize is the longest record a capture may have, a frame of the
+// largest message in hex and its JSON mapping.
+const maxRecordSize = 8 * nanorpc.DefaultMaxMessageSize
+
+// cmdProxy forwards the connections accepted on a local address to the
+// server, writing the frames going through until interrupted.
+func cmdProxy(ctx context.Context, d *dumper, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("%w: proxy needs the listen and upstream addresses", errUsage)
+	}
+
+	l, err := net.Listen("tcp", args[0])
+	if err != nil {
+		return err
+	}
+	if err := d.proxy(ctx, l, args[1]); err != nil {
+		return err
+	}
+	return d.Err()
+}
+
+// proxy serves the connections accepted on l until ctx is done, closing l.
+func (d *dumper) proxy(ctx context.Context, l net.Listener, upstream string) error {
+	stop := context.AfterFunc(ctx, func() { _ = l.Close() })
+	defer stop()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.forward(ctx, conn, upstream)
+		}()
+	}
+}
+
+// forward relays a connection to the server until either end closes it,
+// or ctx is done.
+func (d *dumper) forward(ctx context.Context, conn net.Conn, upstream string) {
+	s := d.newStream()
+
+	var dialer net.Dialer
+	up, err := dialer.DialContext(ctx, "tcp", upstream)
+	if err != nil {
+		_ = conn.Close()
+		s.fail(dirRequest, nil, err)
+		return
+	}
+
+	var once sync.Once
+	closeBoth := func() {
+		once.Do(func() {
+			_ = conn.Close()
+			_ = up.Close()
+		})
+	}
+	stop := context.AfterFunc(ctx, closeBoth)
+	defer stop()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = s.pump(up, conn, dirRequest)
+		closeBoth()
+	}()
+
+	_ = s.pump(conn, up, dirResponse)
+	closeBoth()
+	<-done
+}
+
+// cmdDecode writes the frames of a raw stream, requests unless
+// -responses is given.
+func cmdDecode(_ context.Context, d *dumper, args []string) error {
+	fs := flag.NewFlagSet("decode", flag.ContinueOnError)
+	responses := fs.Bool("responses", false, "decode responses instead of requests")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	in, err := openInput(fs.Args())
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	dir := dirRequest
+	if *responses {
+		dir = dirResponse
+	}
+	if err := d.newStream().pump(io.Discard, in, dir); err != nil {
+		return err
+	}
+	return d.Err()
+}
+
+// cmdReplay sends the requests of a capture to the server, one connection
+// per captured one, writing the frames of both ways.
+func cmdReplay(ctx context.Context, d *dumper, args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	wait := fs.Duration("wait", defaultReplayWait, "how long to wait for more responses")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("%w: replay needs the server address", errUsage)
+	}
+
+	in, err := openInput(fs.Args()[1:])
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	conns, err := readCapture(in)
+	if err != nil {
+		return err
+	}
+
+	for _, frames := range conns {
+		if err := d.replay(ctx, fs.Arg(0), frames, *wait); err != nil {
+			return err
+		}
+	}
+	return d.Err()
+}
+
+// replay sends frames to the server over a new connection, writing the
+// responses until none arrives for wait.
+func (d *dumper) replay(ctx context.Context, remote string, frames [][]byte, wait time.Duration) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", remote)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	s := d.newStream()
+	for _, frame := range frames {
+		if _, err := conn.Write(frame); err != nil {
+			return err
+		}
+		s.decode(dirRequest, frame)
+	}
+
+	return s.pump(io.Discard, &idleReader{conn: conn, idle: wait}, dirResponse)
+}
+
+// idleReader reads from a connection, timing out once idle for too long.
+type idleReader struct {
+	conn net.Conn
+	idle time.Duration
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	if err := r.conn.SetReadDeadline(time.Now().Add(r.idle)); err != nil {
+		return 0, err
+	}
+	return r.conn.Read(p)
+}
+
+// readCapture returns the request frames of a capture, grouped by
+// connection in the order they first appear.
+func readCapture(r io.Reader) ([][][]byte, error) {
+	var order []uint64
+	byConn := make(map[uint64][][]byte)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxRecordSize)
+	for scanner.Scan() {
+		var rec record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, err
+		}
+		if rec.Dir != dirRequest || rec.Error != "" {
+			continue
+		}
+
+		frame, err := hex.DecodeString(rec.Frame)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := byConn[rec.Conn]; !ok {
+			order = append(order, rec.Conn)
+		}
+		byConn[rec.Conn] = append(byConn[rec.Conn], frame)
+	}
+
+	out := make([][][]byte, 0, len(order))
+	for _, conn := range order {
+		out = append(out, byConn[conn])
+	}
+	return out, scanner.Err()
+}
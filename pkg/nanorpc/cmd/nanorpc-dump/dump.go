@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// Directions of the frames of a record.
+const (
+	dirRequest  = "request"
+	dirResponse = "response"
+)
+
+// maxErrorFrame is how much of the undecodable bytes a record keeps.
+const maxErrorFrame = 64
+
+// record describes a frame seen on a connection.
+type record struct {
+	Time    time.Time       `json:"time"`
+	Message json.RawMessage `json:"message,omitempty"`
+	Dir     string          `json:"dir"`
+	Path    string          `json:"path,omitempty"`
+	Frame   string          `json:"frame"`
+	Error   string          `json:"error,omitempty"`
+	Conn    uint64          `json:"conn"`
+}
+
+// dumper writes the records of the frames decoded, resolving their paths.
+type dumper struct {
+	hc    *nanorpc.HashCache
+	enc   *json.Encoder
+	err   error // first failure to write a record
+	conns atomic.Uint64
+	mu    sync.Mutex
+}
+
+func newDumper(w io.Writer, hc *nanorpc.HashCache) *dumper {
+	return &dumper{hc: hc, enc: json.NewEncoder(w)}
+}
+
+// write writes a record, remembering the first failure.
+func (d *dumper) write(rec record) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.err == nil {
+		d.err = d.enc.Encode(rec)
+	}
+}
+
+// Err returns the first failure to write a record.
+func (d *dumper) Err() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.err
+}
+
+// newStream returns the decoding state of a new connection.
+func (d *dumper) newStream() *stream {
+	return &stream{
+		d:     d,
+		conn:  d.conns.Add(1),
+		paths: make(map[int32]pendingPath),
+	}
+}
+
+// pendingPath is the path of a request waiting for responses.
+type pendingPath struct {
+	path      string
+	subscribe bool
+}
+
+// stream decodes the frames of a connection, both ways, tracking the
+// paths of the requests to resolve those of their responses.
+type stream struct {
+	d     *dumper
+	paths map[int32]pendingPath
+	mu    sync.Mutex
+	conn  uint64
+}
+
+func (s *stream) newRecord(dir string, frame []byte) record {
+	return record{
+		Time:  time.Now(),
+		Conn:  s.conn,
+		Dir:   dir,
+		Frame: hex.EncodeToString(frame),
+	}
+}
+
+// decode writes the record of a frame going dir.
+func (s *stream) decode(dir string, frame []byte) {
+	var rec record
+	if dir == dirRequest {
+		rec = s.request(frame)
+	} else {
+		rec = s.response(frame)
+	}
+	s.d.write(rec)
+}
+
+func (s *stream) request(frame []byte) record {
+	rec := s.newRecord(dirRequest, frame)
+	req, _, err := nanorpc.DecodeRequest(frame)
+	if err != nil {
+		rec.Error = err.Error()
+		return rec
+	}
+
+	// string paths are learnt, so later hashes resolve
+	rec.Path, _, _ = s.d.hc.ResolvePath(req)
+	s.setPath(req, rec.Path)
+	rec.setMessage(req)
+	return rec
+}
+
+func (s *stream) response(frame []byte) record {
+	rec := s.newRecord(dirResponse, frame)
+	res, _, err := nanorpc.DecodeResponse(frame)
+	if err != nil {
+		rec.Error = err.Error()
+		return rec
+	}
+
+	rec.Path = s.getPath(res)
+	if res.Path != "" {
+		// updates of pattern subscriptions
+		rec.Path = res.Path
+	}
+	rec.setMessage(res)
+	return rec
+}
+
+// fail writes the record of undecodable bytes going dir.
+func (s *stream) fail(dir string, data []byte, err error) {
+	rec := s.newRecord(dir, data[:min(len(data), maxErrorFrame)])
+	rec.Error = err.Error()
+	s.d.write(rec)
+}
+
+func (s *stream) setPath(req *nanorpc.NanoRPCRequest, path string) {
+	if req.RequestId == 0 || req.RequestType == nanorpc.NanoRPCRequest_TYPE_PING {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.paths[req.RequestId] = pendingPath{
+		path:      path,
+		subscribe: req.RequestType == nanorpc.NanoRPCRequest_TYPE_SUBSCRIBE,
+	}
+}
+
+// getPath returns the path of the request a response answers, forgetting
+// it once the request is done.
+func (s *stream) getPath(res *nanorpc.NanoRPCResponse) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.paths[res.RequestId]
+	refused := res.ResponseStatus != nanorpc.NanoRPCResponse_STATUS_OK
+	answered := res.ResponseType == nanorpc.NanoRPCResponse_TYPE_RESPONSE && (!p.subscribe || refused)
+	if answered || nanorpc.IsFinalUpdate(res) {
+		delete(s.paths, res.RequestId)
+	}
+	return p.path
+}
+
+func (rec *record) setMessage(m proto.Message) {
+	b, err := protojson.Marshal(m)
+	if err != nil {
+		rec.Error = err.Error()
+		return
+	}
+	rec.Message = b
+}
@@ -0,0 +1,158 @@
+// Package main implements nanorpc-dump, a command capturing NanoRPC
+// traffic as JSON records, with paths resolved, and replaying it, to
+// debug field issues without writing Go.
+//
+//	nanorpc-dump [flags] proxy <listen> <upstream>
+//	nanorpc-dump [flags] decode [-responses] [file]
+//	nanorpc-dump [flags] replay [-wait d] <remote> [capture]
+//
+// proxy forwards the connections it accepts on listen to upstream,
+// writing a record for every frame going through, until interrupted.
+// decode reads the raw stream of length-prefixed frames one end wrote,
+// requests unless -responses is given. replay sends the requests of a
+// capture to remote, one connection per captured one, writing a record
+// for every response received until none arrives for -wait. Files
+// default to the standard input.
+//
+// Records are JSON objects, a line each, with the connection number, the
+// direction, the frame in hex and the message in the JSON mapping of
+// Protocol Buffers. Paths sent as hashes are resolved from the paths seen
+// as strings and those listed in the -paths file, a line each, and the
+// paths of responses from the requests they answer.
+//
+// pcap files aren't read. Extract the payload of each direction of a TCP
+// stream first, e.g. with Wireshark's Follow TCP Stream as raw data, and
+// decode it.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// errUsage indicates the command line is incomplete or invalid.
+var errUsage = errors.New("invalid usage")
+
+// options are the global command line flags.
+type options struct {
+	paths  string
+	output string
+}
+
+// command runs a subcommand with its arguments, writing records to d.
+type command func(ctx context.Context, d *dumper, args []string) error
+
+var commands = map[string]command{
+	"proxy":  cmdProxy,
+	"decode": cmdDecode,
+	"replay": cmdReplay,
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.paths, "paths", "", "file listing known paths, a line each")
+	flag.StringVar(&opts.output, "o", "", "output file, standard output if empty")
+	flag.Parse()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	err := run(ctx, opts, flag.Args(), os.Stdout)
+	switch {
+	case errors.Is(err, errUsage):
+		fmt.Fprintln(os.Stderr, "nanorpc-dump:", err)
+		flag.Usage()
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "nanorpc-dump:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, opts options, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: missing command", errUsage)
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("%w: unknown command %q", errUsage, args[0])
+	}
+
+	hc, err := loadPaths(opts.paths)
+	if err != nil {
+		return err
+	}
+
+	if opts.output == "" {
+		return cmd(ctx, newDumper(w, hc), args[1:])
+	}
+
+	f, err := os.Create(opts.output)
+	if err != nil {
+		return err
+	}
+	if err := cmd(ctx, newDumper(f, hc), args[1:]); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// loadPaths returns a [nanorpc.HashCache] knowing the paths listed in a
+// file, a line each, ignoring blank lines and those starting with #.
+func loadPaths(name string) (*nanorpc.HashCache, error) {
+	hc := new(nanorpc.HashCache)
+	if name == "" {
+		return hc, nil
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := hc.Register(line); err != nil {
+			return nil, err
+		}
+	}
+	return hc, scanner.Err()
+}
+
+// openInput opens the file named by the only argument left, or the
+// standard input if none.
+func openInput(args []string) (io.ReadCloser, error) {
+	switch len(args) {
+	case 0:
+		return io.NopCloser(os.Stdin), nil
+	case 1:
+		return os.Open(args[0])
+	default:
+		return nil, fmt.Errorf("%w: too many arguments", errUsage)
+	}
+}
+
+// parseFlags parses the flags of a subcommand.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+	return nil
+}
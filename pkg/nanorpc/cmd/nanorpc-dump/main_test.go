@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/server"
+	"protomcp.org/nanorpc/pkg/nanorpc/utils/testutils/e2e"
+)
+
+const testTimeout = 2 * time.Second
+
+// syncBuffer is a [bytes.Buffer] safe to read while a command writes it.
+type syncBuffer struct {
+	buf bytes.Buffer
+	mu  sync.Mutex
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// parseRecords decodes the records written by a command.
+func parseRecords(t *testing.T, out string) []record {
+	t.Helper()
+
+	var records []record
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		var rec record
+		core.AssertMustNoError(t, json.Unmarshal(scanner.Bytes(), &rec), "record %q", scanner.Text())
+		records = append(records, rec)
+	}
+	return records
+}
+
+// encodeFrames encodes requests to /echo, by string and then by hash.
+func encodeFrames(t *testing.T) []byte {
+	t.Helper()
+
+	hash, err := new(nanorpc.HashCache).Hash("/echo")
+	core.AssertMustNoError(t, err, "hash")
+
+	var out []byte
+	paths := []nanorpc.PathOneOf{nanorpc.GetPathOneOfString("/echo"), nanorpc.GetPathOneOfHash(hash)}
+	for i, path := range paths {
+		req := &nanorpc.NanoRPCRequest{
+			RequestId:   int32(i + 1),
+			RequestType: nanorpc.NanoRPCRequest_TYPE_REQUEST,
+			PathOneof:   path,
+			Data:        []byte("hi"),
+		}
+
+		frame, err := nanorpc.EncodeRequest(req, nil)
+		core.AssertMustNoError(t, err, "encode")
+		out = append(out, frame...)
+	}
+	return out
+}
+
+// assertEchoRecords checks the records of the frames of encodeFrames all
+// resolve to /echo, and tells how many went dir.
+func assertEchoRecords(t *testing.T, records []record, dir string) int {
+	t.Helper()
+
+	var n int
+	for _, rec := range records {
+		core.AssertEqual(t, "", rec.Error, "error")
+		core.AssertEqual(t, "/echo", rec.Path, "path")
+		if rec.Dir == dir {
+			n++
+		}
+	}
+	return n
+}
+
+func TestRun_decode(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "stream.bin")
+	stream := encodeFrames(t)
+	core.AssertMustNoError(t, os.WriteFile(name, append(stream, stream[:3]...), 0o600), "write")
+
+	var out syncBuffer
+	err := run(context.Background(), options{}, []string{"decode", name}, &out)
+	core.AssertMustNoError(t, err, "decode")
+
+	records := parseRecords(t, out.String())
+	core.AssertMustEqual(t, 3, len(records), "records")
+	core.AssertEqual(t, 2, assertEchoRecords(t, records[:2], dirRequest), "requests")
+	core.AssertTrue(t, strings.Contains(records[2].Error, "EOF"), "truncated: %q", records[2].Error)
+}
+
+func TestRun_decodePaths(t *testing.T) {
+	dir := t.TempDir()
+	paths := filepath.Join(dir, "paths.txt")
+	core.AssertMustNoError(t, os.WriteFile(paths, []byte("# known\n/echo\n"), 0o600), "write paths")
+
+	// the hashed request only
+	stream := encodeFrames(t)
+	_, n, err := nanorpc.DecodeSplit(stream)
+	core.AssertMustNoError(t, err, "split")
+	name := filepath.Join(dir, "stream.bin")
+	core.AssertMustNoError(t, os.WriteFile(name, stream[n:], 0o600), "write stream")
+
+	var out syncBuffer
+	err = run(context.Background(), options{paths: paths}, []string{"decode", name}, &out)
+	core.AssertMustNoError(t, err, "decode")
+	core.AssertEqual(t, 1, assertEchoRecords(t, parseRecords(t, out.String()), dirRequest), "requests")
+}
+
+// startProxy proxies an echo server, returning the addresses of the proxy
+// and the server, and the function stopping the proxy.
+func startProxy(t *testing.T, d *dumper) (string, string, func()) {
+	t.Helper()
+
+	ts := e2e.NewTestServer(t, nil)
+	ts.Handle("/echo", func(_ context.Context, rc *server.RequestContext) error {
+		return rc.SendOK(rc.Request.Data)
+	})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	core.AssertMustNoError(t, err, "listen")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- d.proxy(ctx, l, ts.Addr()) }()
+
+	return l.Addr().String(), ts.Addr(), func() {
+		cancel()
+		core.AssertNoError(t, <-done, "proxy")
+	}
+}
+
+// exchange sends the frames of encodeFrames over a new connection,
+// waiting for both responses.
+func exchange(t *testing.T, addr string) {
+	t.Helper()
+
+	conn, err := net.DialTimeout("tcp", addr, testTimeout)
+	core.AssertMustNoError(t, err, "dial")
+	defer conn.Close()
+
+	_, err = conn.Write(encodeFrames(t))
+	core.AssertMustNoError(t, err, "write")
+
+	core.AssertMustNoError(t, conn.SetReadDeadline(time.Now().Add(testTimeout)), "deadline")
+	scanner := bufio.NewScanner(conn)
+	scanner.Split(nanorpc.Split)
+	for range 2 {
+		core.AssertMustTrue(t, scanner.Scan(), "response: %v", scanner.Err())
+		res, _, err := nanorpc.DecodeResponse(scanner.Bytes())
+		core.AssertMustNoError(t, err, "decode response")
+		core.AssertEqual(t, "hi", string(res.Data), "data")
+	}
+}
+
+func TestDumper_proxy(t *testing.T) {
+	var out syncBuffer
+	addr, _, stop := startProxy(t, newDumper(&out, new(nanorpc.HashCache)))
+	exchange(t, addr)
+	stop()
+
+	records := parseRecords(t, out.String())
+	core.AssertMustEqual(t, 4, len(records), "records")
+	core.AssertEqual(t, 2, assertEchoRecords(t, records, dirResponse), "responses")
+	for _, rec := range records {
+		core.AssertEqual(t, uint64(1), rec.Conn, "conn")
+	}
+}
+
+func TestRun_replay(t *testing.T) {
+	var capture syncBuffer
+	addr, remote, stop := startProxy(t, newDumper(&capture, new(nanorpc.HashCache)))
+	exchange(t, addr)
+	stop()
+
+	name := filepath.Join(t.TempDir(), "capture.json")
+	core.AssertMustNoError(t, os.WriteFile(name, []byte(capture.String()), 0o600), "write")
+
+	var out syncBuffer
+	args := []string{"replay", "-wait", "200ms", remote, name}
+	core.AssertMustNoError(t, run(context.Background(), options{}, args, &out), "replay")
+
+	records := parseRecords(t, out.String())
+	core.AssertMustEqual(t, 4, len(records), "records")
+	core.AssertEqual(t, 2, assertEchoRecords(t, records, dirResponse), "responses")
+}
+
+func TestRun_usage(t *testing.T) {
+	for _, args := range [][]string{
+		nil,
+		{"unknown"},
+		{"proxy", "127.0.0.1:0"},
+		{"replay"},
+		{"decode", "-bogus"},
+		{"decode", "a", "b"},
+	} {
+		err := run(context.Background(), options{}, args, &syncBuffer{})
+		core.AssertErrorIs(t, err, errUsage, "%q", args)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// frames splits the bytes going one way into frames as they arrive,
+// giving up on the first malformed one.
+type frames struct {
+	buf    []byte
+	failed bool
+}
+
+// feed appends data, calling fn with every frame completed. It fails once,
+// on a malformed frame, ignoring what follows.
+func (f *frames) feed(data []byte, fn func(frame []byte)) error {
+	if f.failed {
+		return nil
+	}
+
+	f.buf = append(f.buf, data...)
+	for {
+		n, frame, err := nanorpc.Split(f.buf, false)
+		switch {
+		case err != nil:
+			f.failed = true
+			return err
+		case n == 0:
+			return nil
+		}
+
+		fn(frame)
+		f.buf = f.buf[n:]
+		if len(f.buf) == 0 {
+			f.buf = nil
+		}
+	}
+}
+
+// pump copies src to dst, writing the records of the frames going dir,
+// until src ends. The copy goes on after a malformed frame.
+func (s *stream) pump(dst io.Writer, src io.Reader, dir string) error {
+	var f frames
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			if _, err := dst.Write(buf[:n]); err != nil {
+				return err
+			}
+			s.feed(&f, dir, buf[:n])
+		}
+
+		switch {
+		case errors.Is(err, io.EOF), errors.Is(err, os.ErrDeadlineExceeded):
+			s.flush(&f, dir)
+			return nil
+		case err != nil:
+			return err
+		}
+	}
+}
+
+func (s *stream) feed(f *frames, dir string, data []byte) {
+	fn := func(frame []byte) { s.decode(dir, frame) }
+	if err := f.feed(data, fn); err != nil {
+		s.fail(dir, f.buf, err)
+	}
+}
+
+// flush writes the record of an incomplete frame left when the stream
+// ends.
+func (s *stream) flush(f *frames, dir string) {
+	if !f.failed && len(f.buf) > 0 {
+		s.fail(dir, f.buf, io.ErrUnexpectedEOF)
+	}
+}
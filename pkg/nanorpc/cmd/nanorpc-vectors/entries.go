@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"protomcp.org/nanorpc/pkg/nanorpc/vectors"
+)
+
+// Kinds of vectors.
+const (
+	kindRequest   = "request"
+	kindResponse  = "response"
+	kindMalformed = "malformed"
+)
+
+// entry is a vector as written.
+type entry struct {
+	Kind       string          `json:"kind"`
+	Name       string          `json:"name"`
+	Hex        string          `json:"hex"`
+	Message    json.RawMessage `json:"message,omitempty"`
+	DecodeOnly bool            `json:"decode_only,omitempty"`
+}
+
+// collect returns the request, response and, if all, malformed vectors,
+// canonical ones first followed by those of the matrix.
+func collect(all bool) ([]entry, error) {
+	requests, err := requestEntries(all)
+	if err != nil {
+		return nil, err
+	}
+	responses, err := responseEntries(all)
+	if err != nil {
+		return nil, err
+	}
+
+	out := append(requests, responses...)
+	if all {
+		for _, v := range vectors.MalformedFrames() {
+			out = append(out, entry{Kind: kindMalformed, Name: v.Name, Hex: v.Hex})
+		}
+	}
+	return out, nil
+}
+
+func requestEntries(all bool) ([]entry, error) {
+	matrix, err := vectors.RequestMatrix()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []entry
+	for _, v := range append(vectors.Requests(), matrix...) {
+		if v.DecodeOnly && !all {
+			continue
+		}
+
+		e, err := newEntry(kindRequest, v.Name, v.Hex, v.DecodeOnly, v.Message)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+func responseEntries(all bool) ([]entry, error) {
+	matrix, err := vectors.ResponseMatrix()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []entry
+	for _, v := range append(vectors.Responses(), matrix...) {
+		if v.DecodeOnly && !all {
+			continue
+		}
+
+		e, err := newEntry(kindResponse, v.Name, v.Hex, v.DecodeOnly, v.Message)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+// newEntry returns the entry of a vector, with its message in the JSON
+// mapping of Protocol Buffers.
+func newEntry(kind, name, frameHex string, decodeOnly bool, msg proto.Message) (entry, error) {
+	b, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(msg)
+	if err != nil {
+		return entry{}, err
+	}
+
+	// protojson randomises its whitespace
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, b); err != nil {
+		return entry{}, err
+	}
+
+	return entry{
+		Kind:       kind,
+		Name:       name,
+		Hex:        frameHex,
+		Message:    buf.Bytes(),
+		DecodeOnly: decodeOnly,
+	}, nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// writers are the output formats, by name.
+var writers = map[string]func(io.Writer, []entry) error{
+	"json": writeJSON,
+	"hex":  writeHex,
+	"c":    writeC,
+}
+
+func writeJSON(w io.Writer, entries []entry) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
+
+func writeHex(w io.Writer, entries []entry) error {
+	for _, e := range entries {
+		if _, err := fmt.Fprintf(w, "%s %s %s\n", e.Kind, e.Name, e.Hex); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const cHeader = `/* Code generated by nanorpc-vectors. DO NOT EDIT. */
+
+#ifndef NANORPC_VECTORS_H
+#define NANORPC_VECTORS_H
+
+#include <stdbool.h>
+#include <stddef.h>
+#include <stdint.h>
+
+enum nanorpc_vector_kind {
+	NANORPC_VECTOR_REQUEST,
+	NANORPC_VECTOR_RESPONSE,
+	NANORPC_VECTOR_MALFORMED,
+};
+
+struct nanorpc_vector {
+	enum nanorpc_vector_kind kind;
+	const char *name;
+	const uint8_t *frame;
+	size_t len;
+	bool decode_only;
+};
+
+`
+
+const cFooter = `};
+
+#define NANORPC_VECTORS_COUNT (sizeof(nanorpc_vectors) / sizeof(nanorpc_vectors[0]))
+
+#endif /* NANORPC_VECTORS_H */
+`
+
+// writeC writes a C99 header declaring the frames as arrays, and the
+// nanorpc_vectors table listing them.
+func writeC(w io.Writer, entries []entry) error {
+	if _, err := io.WriteString(w, cHeader); err != nil {
+		return err
+	}
+
+	for i, e := range entries {
+		if err := writeCFrame(w, i, e.Hex); err != nil {
+			return err
+		}
+	}
+
+	if _, err := io.WriteString(w, "\nstatic const struct nanorpc_vector nanorpc_vectors[] = {\n"); err != nil {
+		return err
+	}
+	for i, e := range entries {
+		_, err := fmt.Fprintf(w, "\t{ NANORPC_VECTOR_%s, %q, nanorpc_vector_%d, sizeof(nanorpc_vector_%d), %t },\n",
+			strings.ToUpper(e.Kind), e.Name, i, i, e.DecodeOnly)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w, cFooter)
+	return err
+}
+
+// writeCFrame writes a frame as the nanorpc_vector_<i> array.
+func writeCFrame(w io.Writer, i int, frameHex string) error {
+	frame, err := hex.DecodeString(frameHex)
+	if err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "static const uint8_t nanorpc_vector_%d[] = {", i)
+	for j, b := range frame {
+		if j%12 == 0 {
+			sb.WriteString("\n\t")
+		} else {
+			sb.WriteByte(' ')
+		}
+		fmt.Fprintf(&sb, "0x%02x,", b)
+	}
+	sb.WriteString("\n};\n")
+
+	_, err = io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,78 @@
+// Package main implements nanorpc-vectors, a command writing the wire
+// format vectors of the vectors package, the canonical encodings of
+// requests and responses for a matrix of message shapes, to lock down
+// the compatibility of implementations in other languages.
+//
+//	nanorpc-vectors [-format json|hex|c] [-all] [-o file]
+//
+// The json format lists every vector with its kind, name, frame in hex
+// and message in the JSON mapping of Protocol Buffers. The hex format
+// writes a line per vector with its kind, name and frame. The c format
+// writes a C99 header with the frames as arrays, for firmware tests.
+//
+// Only canonical frames are written unless -all is given, which adds the
+// DecodeOnly alternative encodings and the malformed frames every decoder
+// must reject.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// options are the command line flags.
+type options struct {
+	format string
+	output string
+	all    bool
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.format, "format", "json", "output format: json, hex or c")
+	flag.StringVar(&opts.output, "o", "", "output file, standard output if empty")
+	flag.BoolVar(&opts.all, "all", false, "include DecodeOnly and malformed frames")
+	flag.Parse()
+
+	if err := run(opts); err != nil {
+		fmt.Fprintln(os.Stderr, "nanorpc-vectors:", err)
+		os.Exit(1)
+	}
+}
+
+func run(opts options) error {
+	write, ok := writers[opts.format]
+	if !ok {
+		return fmt.Errorf("unknown format %q", opts.format)
+	}
+
+	entries, err := collect(opts.all)
+	if err != nil {
+		return err
+	}
+
+	if opts.output == "" {
+		return writeTo(os.Stdout, write, entries)
+	}
+
+	f, err := os.Create(opts.output)
+	if err != nil {
+		return err
+	}
+	if err := writeTo(f, write, entries); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeTo(w io.Writer, write func(io.Writer, []entry) error, entries []entry) error {
+	bw := bufio.NewWriter(w)
+	if err := write(bw, entries); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"darvaza.org/core"
+)
+
+// runOutput runs the command with the given options, returning what it
+// wrote.
+func runOutput(t *testing.T, opts options) string {
+	t.Helper()
+
+	opts.output = filepath.Join(t.TempDir(), "vectors")
+	core.AssertMustNoError(t, run(opts), "run")
+
+	b, err := os.ReadFile(opts.output)
+	core.AssertMustNoError(t, err, "ReadFile")
+	return string(b)
+}
+
+func TestRun_json(t *testing.T) {
+	var entries []entry
+	out := runOutput(t, options{format: "json"})
+	core.AssertMustNoError(t, json.Unmarshal([]byte(out), &entries), "Unmarshal")
+
+	kinds := make(map[string]int)
+	for _, e := range entries {
+		kinds[e.Kind]++
+		core.AssertFalse(t, e.DecodeOnly, "%s: decode only", e.Name)
+	}
+	core.AssertNotEqual(t, 0, kinds[kindRequest], "requests")
+	core.AssertNotEqual(t, 0, kinds[kindResponse], "responses")
+	core.AssertEqual(t, 0, kinds[kindMalformed], "malformed")
+
+	core.AssertEqual(t, "ping", entries[0].Name, "first")
+	core.AssertEqual(t, "0408011001", entries[0].Hex, "ping")
+
+	var msg bytes.Buffer
+	core.AssertMustNoError(t, json.Compact(&msg, entries[0].Message), "Compact")
+	core.AssertEqual(t, `{"request_id":1,"request_type":"TYPE_PING"}`, msg.String(), "message")
+}
+
+func TestRun_all(t *testing.T) {
+	out := runOutput(t, options{format: "hex", all: true})
+	core.AssertContains(t, out, "request ping 0408011001\n", "canonical")
+	core.AssertContains(t, out, "request decode_only/reordered ", "decode only")
+	core.AssertContains(t, out, "malformed truncated_prefix 80\n", "malformed")
+	core.AssertContains(t, out, "response matrix/update/not_found/id_1 ", "matrix")
+}
+
+func TestRun_c(t *testing.T) {
+	out := runOutput(t, options{format: "c"})
+	core.AssertContains(t, out, "static const uint8_t nanorpc_vector_0[] = {\n\t0x04, 0x08, 0x01, 0x10, 0x01,\n};",
+		"ping frame")
+	core.AssertContains(t, out,
+		`{ NANORPC_VECTOR_REQUEST, "ping", nanorpc_vector_0, sizeof(nanorpc_vector_0), false },`, "ping entry")
+	core.AssertTrue(t, strings.HasSuffix(out, "#endif /* NANORPC_VECTORS_H */\n"), "footer")
+}
+
+func TestRun_unknownFormat(t *testing.T) {
+	core.AssertError(t, run(options{format: "xml"}), "run")
+}
@@ -0,0 +1,134 @@
+package nanorpc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"darvaza.org/core"
+)
+
+var (
+	_ Codec = LengthPrefixCodec{}
+	_ Codec = COBSCodec{}
+	_ Codec = SLIPCodec{}
+)
+
+// Codec frames NanoRPC messages, the protobuf encoding of a
+// [NanoRPCRequest] or [NanoRPCResponse], so they can be told apart on a
+// stream. [LengthPrefixCodec] is the framing of NanoRPC, while
+// [COBSCodec] and [SLIPCodec] match those common on serial links.
+type Codec interface {
+	// Split returns a [bufio.SplitFunc] identifying frames carrying
+	// messages of up to maxSize bytes, failing with [ErrMessageTooLarge]
+	// on larger ones before buffering them whole. A maxSize of zero sets
+	// no limit.
+	Split(maxSize int) bufio.SplitFunc
+
+	// MaxFrameSize returns the size of the largest frame carrying a
+	// message of up to maxSize bytes, or zero if maxSize is zero.
+	MaxFrameSize(maxSize int) int
+
+	// Unframe returns the message carried by a frame identified by Split.
+	// It may decode it in place, overwriting the frame.
+	Unframe(frame []byte) ([]byte, error)
+
+	// AppendFrame appends the frame carrying msg to dst.
+	AppendFrame(dst, msg []byte) []byte
+}
+
+// IsLengthPrefixed tells if a codec frames messages as [EncodeRequest]
+// and [EncodeResponse] wrap them, so they can be written as they are.
+// A nil codec is the default [LengthPrefixCodec].
+func IsLengthPrefixed(c Codec) bool {
+	switch c.(type) {
+	case nil, LengthPrefixCodec, *LengthPrefixCodec:
+		return true
+	default:
+		return false
+	}
+}
+
+// AppendFramed appends a wrapped message, as [EncodeRequest] and
+// [EncodeResponse] encode them, to dst, framed by a codec instead.
+func AppendFramed(c Codec, dst, wrapped []byte) ([]byte, error) {
+	if IsLengthPrefixed(c) {
+		return append(dst, wrapped...), nil
+	}
+
+	msg, err := LengthPrefixCodec{}.Unframe(wrapped)
+	if err != nil {
+		return dst, err
+	}
+	return c.AppendFrame(dst, msg), nil
+}
+
+// LengthPrefixCodec frames messages prefixed by their length as a
+// varint, as NanoRPC does by default.
+type LengthPrefixCodec struct{}
+
+// Split returns a [bufio.SplitFunc] identifying length-prefixed frames,
+// see [SplitMax], which reports a close between frames as
+// [io.ErrUnexpectedEOF].
+func (LengthPrefixCodec) Split(maxSize int) bufio.SplitFunc {
+	return SplitMax(maxSize)
+}
+
+// MaxFrameSize returns the size of the largest frame carrying a message of
+// up to maxSize bytes.
+func (LengthPrefixCodec) MaxFrameSize(maxSize int) int {
+	if maxSize <= 0 {
+		return 0
+	}
+	return maxSize + binary.MaxVarintLen32
+}
+
+// Unframe returns the message following the length prefix.
+func (LengthPrefixCodec) Unframe(frame []byte) ([]byte, error) {
+	prefixLen, totalLen, err := DecodeSplit(frame)
+	if err != nil {
+		return nil, err
+	}
+	return frame[prefixLen:totalLen], nil
+}
+
+// AppendFrame appends msg prefixed by its length to dst.
+func (LengthPrefixCodec) AppendFrame(dst, msg []byte) []byte {
+	dst = protowire.AppendVarint(dst, uint64(len(msg)))
+	return append(dst, msg...)
+}
+
+// splitDelimited identifies frames ending with a delimiter, skipping empty
+// ones, failing with [ErrMessageTooLarge] on those longer than maxFrame,
+// delimiter included, unless zero.
+func splitDelimited(data []byte, atEOF bool, delim byte, maxFrame int) (int, []byte, error) {
+	// skipped along with the frame, as a nil token waits for more data
+	skip := countLeading(data, delim)
+	frame := data[skip:]
+	i := bytes.IndexByte(frame, delim)
+	switch {
+	case i > 0 && (maxFrame == 0 || i < maxFrame):
+		return skip + i + 1, frame[:i], nil
+	case maxFrame > 0 && (i >= maxFrame || len(frame) >= maxFrame):
+		err := core.QuietWrap(ErrMessageTooLarge, "message too large: frame over %d bytes", maxFrame)
+		return 0, nil, err
+	case atEOF && len(frame) > 0:
+		// truncated frame
+		return 0, nil, io.ErrUnexpectedEOF
+	default:
+		// more data needed
+		return skip, nil, nil
+	}
+}
+
+// countLeading returns how many times data starts with a byte.
+func countLeading(data []byte, b byte) int {
+	n := 0
+	for n < len(data) && data[n] == b {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,76 @@
+package nanorpc
+
+import (
+	"bufio"
+
+	"darvaza.org/core"
+)
+
+// cobsMaxBlock is the longest run of non-zero bytes of a COBS block.
+const cobsMaxBlock = 254
+
+// COBSCodec frames messages with Consistent Overhead Byte Stuffing,
+// removing their zero bytes so frames end with one. Empty frames are
+// skipped, so peers may send a zero to flush line noise.
+type COBSCodec struct{}
+
+// Split returns a [bufio.SplitFunc] identifying zero-terminated frames,
+// delimiter excluded.
+func (c COBSCodec) Split(maxSize int) bufio.SplitFunc {
+	maxFrame := c.MaxFrameSize(maxSize)
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		return splitDelimited(data, atEOF, 0, maxFrame)
+	}
+}
+
+// MaxFrameSize returns the size of the largest frame carrying a message of
+// up to maxSize bytes, an overhead byte for every 254 and the delimiter.
+func (COBSCodec) MaxFrameSize(maxSize int) int {
+	if maxSize <= 0 {
+		return 0
+	}
+	return maxSize + maxSize/cobsMaxBlock + 2
+}
+
+// Unframe decodes a frame in place, failing with [ErrInvalidFrame] if it
+// isn't valid COBS.
+func (COBSCodec) Unframe(frame []byte) ([]byte, error) {
+	// decoded data never overtakes the frame read
+	out := frame[:0]
+	for i := 0; i < len(frame); {
+		code := int(frame[i])
+		end := i + code
+		if code == 0 || end > len(frame) {
+			return nil, core.QuietWrap(ErrInvalidFrame, "invalid COBS block at %d", i)
+		}
+
+		out = append(out, frame[i+1:end]...)
+		if i = end; code <= cobsMaxBlock && i < len(frame) {
+			out = append(out, 0)
+		}
+	}
+	return out, nil
+}
+
+// AppendFrame appends the COBS encoding of msg, and the delimiter, to dst.
+func (COBSCodec) AppendFrame(dst, msg []byte) []byte {
+	code := len(dst)
+	dst = append(dst, 1)
+	for i, b := range msg {
+		switch {
+		case b == 0:
+			code = len(dst)
+			dst = append(dst, 1)
+		case dst[code] == cobsMaxBlock && i < len(msg)-1:
+			// full block, with more to come
+			dst[code]++
+			dst = append(dst, b)
+			code = len(dst)
+			dst = append(dst, 1)
+		default:
+			dst[code]++
+			dst = append(dst, b)
+		}
+	}
+	return append(dst, 0)
+}
@@ -0,0 +1,85 @@
+package nanorpc
+
+import (
+	"bufio"
+
+	"darvaza.org/core"
+)
+
+// SLIP special bytes, see RFC 1055.
+const (
+	slipEnd    = 0xC0
+	slipEsc    = 0xDB
+	slipEscEnd = 0xDC
+	slipEscEsc = 0xDD
+)
+
+// SLIPCodec frames messages as RFC 1055 SLIP does, escaping END and ESC
+// bytes so frames end with an END. Empty frames are skipped, so peers may
+// start frames with an END to flush line noise.
+type SLIPCodec struct{}
+
+// Split returns a [bufio.SplitFunc] identifying END-terminated frames,
+// delimiter excluded.
+func (c SLIPCodec) Split(maxSize int) bufio.SplitFunc {
+	maxFrame := c.MaxFrameSize(maxSize)
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		return splitDelimited(data, atEOF, slipEnd, maxFrame)
+	}
+}
+
+// MaxFrameSize returns the size of the largest frame carrying a message of
+// up to maxSize bytes, every byte escaped and the delimiter.
+func (SLIPCodec) MaxFrameSize(maxSize int) int {
+	if maxSize <= 0 {
+		return 0
+	}
+	return 2*maxSize + 1
+}
+
+// Unframe decodes a frame in place, failing with [ErrInvalidFrame] on
+// invalid escapes.
+func (SLIPCodec) Unframe(frame []byte) ([]byte, error) {
+	out := frame[:0]
+	for i := 0; i < len(frame); i++ {
+		b := frame[i]
+		if b == slipEsc {
+			i++
+			b = slipUnescape(frame, i)
+			if b == 0 {
+				return nil, core.QuietWrap(ErrInvalidFrame, "invalid SLIP escape at %d", i-1)
+			}
+		}
+		out = append(out, b)
+	}
+	return out, nil
+}
+
+// slipUnescape returns the byte escaped at frame[i], or zero if invalid.
+func slipUnescape(frame []byte, i int) byte {
+	switch {
+	case i >= len(frame):
+		return 0
+	case frame[i] == slipEscEnd:
+		return slipEnd
+	case frame[i] == slipEscEsc:
+		return slipEsc
+	default:
+		return 0
+	}
+}
+
+// AppendFrame appends msg, escaped, and the delimiter to dst.
+func (SLIPCodec) AppendFrame(dst, msg []byte) []byte {
+	for _, b := range msg {
+		switch b {
+		case slipEnd:
+			dst = append(dst, slipEsc, slipEscEnd)
+		case slipEsc:
+			dst = append(dst, slipEsc, slipEscEsc)
+		default:
+			dst = append(dst, b)
+		}
+	}
+	return append(dst, slipEnd)
+}
@@ -0,0 +1,220 @@
+package nanorpc
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"testing"
+
+	"darvaza.org/core"
+)
+
+// seq returns the bytes from..to, inclusive.
+func seq(from, to byte) []byte {
+	out := make([]byte, 0, int(to)-int(from)+1)
+	for b := int(from); b <= int(to); b++ {
+		out = append(out, byte(b))
+	}
+	return out
+}
+
+// cat concatenates byte slices.
+func cat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+var _ core.TestCase = codecFrameTestCase{}
+
+type codecFrameTestCase struct {
+	codec Codec
+	name  string
+	msg   []byte
+	frame []byte
+}
+
+func (tc codecFrameTestCase) Name() string { return tc.name }
+
+func (tc codecFrameTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	frame := tc.codec.AppendFrame([]byte("x"), tc.msg)
+	core.AssertSliceEqual(t, tc.frame, frame[1:], "AppendFrame")
+
+	// without the delimiter Split removes
+	token := frame[1:]
+	if !IsLengthPrefixed(tc.codec) {
+		token = token[:len(token)-1]
+	}
+	msg, err := tc.codec.Unframe(bytes.Clone(token))
+	core.AssertMustNoError(t, err, "Unframe")
+	core.AssertTrue(t, bytes.Equal(tc.msg, msg), "Unframe: %x", msg)
+	core.AssertTrue(t, len(tc.frame) <= tc.codec.MaxFrameSize(max(len(tc.msg), 1)), "MaxFrameSize")
+}
+
+func newCodecFrameTestCase(name string, codec Codec, msg, frame []byte) codecFrameTestCase {
+	return codecFrameTestCase{
+		name:  name,
+		codec: codec,
+		msg:   msg,
+		frame: frame,
+	}
+}
+
+func codecFrameTestCases() []codecFrameTestCase {
+	cobs, slip, lp := COBSCodec{}, SLIPCodec{}, LengthPrefixCodec{}
+
+	return []codecFrameTestCase{
+		newCodecFrameTestCase("length prefix", lp, []byte{1, 2}, []byte{2, 1, 2}),
+		newCodecFrameTestCase("length prefix empty", lp, nil, []byte{0}),
+
+		// examples of the COBS paper and Wikipedia
+		newCodecFrameTestCase("cobs empty", cobs, nil, []byte{1, 0}),
+		newCodecFrameTestCase("cobs zero", cobs, []byte{0}, []byte{1, 1, 0}),
+		newCodecFrameTestCase("cobs zeros", cobs, []byte{0, 0}, []byte{1, 1, 1, 0}),
+		newCodecFrameTestCase("cobs mixed", cobs,
+			[]byte{0x11, 0x22, 0, 0x33}, []byte{3, 0x11, 0x22, 2, 0x33, 0}),
+		newCodecFrameTestCase("cobs trailing zeros", cobs,
+			[]byte{0x11, 0, 0, 0}, []byte{2, 0x11, 1, 1, 1, 0}),
+		newCodecFrameTestCase("cobs full block", cobs,
+			seq(1, 0xfe), cat([]byte{0xff}, seq(1, 0xfe), []byte{0})),
+		newCodecFrameTestCase("cobs zero and full block", cobs,
+			cat([]byte{0}, seq(1, 0xfe)), cat([]byte{1, 0xff}, seq(1, 0xfe), []byte{0})),
+		newCodecFrameTestCase("cobs over a block", cobs,
+			seq(1, 0xff), cat([]byte{0xff}, seq(1, 0xfe), []byte{2, 0xff, 0})),
+		newCodecFrameTestCase("cobs full block and zero", cobs,
+			cat(seq(2, 0xff), []byte{0}), cat([]byte{0xff}, seq(2, 0xff), []byte{1, 1, 0})),
+		newCodecFrameTestCase("cobs block and zero", cobs,
+			cat(seq(3, 0xff), []byte{0, 1}), cat([]byte{0xfe}, seq(3, 0xff), []byte{2, 1, 0})),
+
+		newCodecFrameTestCase("slip plain", slip, []byte{1, 2}, []byte{1, 2, slipEnd}),
+		newCodecFrameTestCase("slip escaped", slip, []byte{slipEnd, 1, slipEsc},
+			[]byte{slipEsc, slipEscEnd, 1, slipEsc, slipEscEsc, slipEnd}),
+		newCodecFrameTestCase("slip empty", slip, nil, []byte{slipEnd}),
+	}
+}
+
+func TestCodec_frames(t *testing.T) {
+	core.RunTestCases(t, codecFrameTestCases())
+}
+
+var _ core.TestCase = codecSplitTestCase{}
+
+type codecSplitTestCase struct {
+	codec Codec
+	name  string
+}
+
+func (tc codecSplitTestCase) Name() string { return tc.name }
+
+func (tc codecSplitTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	msgs := [][]byte{[]byte("one"), {0, slipEnd, slipEsc, 0}, make([]byte, 600)}
+
+	var stream []byte
+	for _, msg := range msgs {
+		stream = tc.codec.AppendFrame(stream, msg)
+	}
+
+	got := scanFrames(t, tc.codec, stream, 1024)
+	core.AssertMustEqual(t, len(msgs), len(got), "frames")
+	for i, msg := range msgs {
+		core.AssertSliceEqual(t, msg, got[i], "frame %d", i)
+	}
+
+	// too large, before it's buffered whole
+	scanner := bufio.NewScanner(bytes.NewReader(stream))
+	scanner.Split(tc.codec.Split(100))
+	for scanner.Scan() {
+		// drop the frames that fit
+	}
+	core.AssertErrorIs(t, scanner.Err(), ErrMessageTooLarge, "too large")
+
+	// truncated
+	scanner = bufio.NewScanner(bytes.NewReader(stream[:len(stream)-1]))
+	scanner.Split(tc.codec.Split(0))
+	for scanner.Scan() {
+		// drop the complete frames
+	}
+	core.AssertErrorIs(t, scanner.Err(), io.ErrUnexpectedEOF, "truncated")
+}
+
+// scanFrames returns the messages framed in a stream.
+func scanFrames(t *testing.T, codec Codec, stream []byte, maxSize int) [][]byte {
+	t.Helper()
+
+	scanner := bufio.NewScanner(bytes.NewReader(stream))
+	if maxSize > 0 {
+		scanner.Buffer(nil, codec.MaxFrameSize(maxSize))
+	}
+	scanner.Split(codec.Split(maxSize))
+
+	var out [][]byte
+	for scanner.Scan() {
+		msg, err := codec.Unframe(scanner.Bytes())
+		core.AssertMustNoError(t, err, "Unframe")
+		out = append(out, bytes.Clone(msg))
+	}
+	if err := scanner.Err(); !IsLengthPrefixed(codec) || err != io.ErrUnexpectedEOF {
+		// length prefixes report a close between frames too
+		core.AssertMustNoError(t, err, "Scan")
+	}
+	return out
+}
+
+func codecSplitTestCases() []codecSplitTestCase {
+	return []codecSplitTestCase{
+		{name: "length prefix", codec: LengthPrefixCodec{}},
+		{name: "cobs", codec: COBSCodec{}},
+		{name: "slip", codec: SLIPCodec{}},
+	}
+}
+
+func TestCodec_split(t *testing.T) {
+	core.RunTestCases(t, codecSplitTestCases())
+}
+
+func TestCodec_emptyFrames(t *testing.T) {
+	stream := cat([]byte{0, 0}, COBSCodec{}.AppendFrame(nil, []byte("a")), []byte{0})
+	core.AssertEqual(t, 1, len(scanFrames(t, COBSCodec{}, stream, 0)), "cobs")
+
+	stream = cat([]byte{slipEnd}, SLIPCodec{}.AppendFrame(nil, []byte("a")))
+	core.AssertEqual(t, 1, len(scanFrames(t, SLIPCodec{}, stream, 0)), "slip")
+}
+
+func TestCodec_invalid(t *testing.T) {
+	for name, tc := range map[string]struct {
+		codec Codec
+		frame []byte
+	}{
+		"cobs short block": {COBSCodec{}, []byte{5, 1, 2}},
+		"cobs zero code":   {COBSCodec{}, []byte{2, 1, 0, 1}},
+		"slip bad escape":  {SLIPCodec{}, []byte{1, slipEsc, 1}},
+		"slip last escape": {SLIPCodec{}, []byte{1, slipEsc}},
+	} {
+		_, err := tc.codec.Unframe(tc.frame)
+		core.AssertErrorIs(t, err, ErrInvalidFrame, name)
+	}
+}
+
+func TestAppendFramed(t *testing.T) {
+	req := &NanoRPCRequest{RequestId: 1, RequestType: NanoRPCRequest_TYPE_PING}
+	wrapped, err := EncodeRequest(req, nil)
+	core.AssertMustNoError(t, err, "EncodeRequest")
+
+	got, err := AppendFramed(nil, nil, wrapped)
+	core.AssertMustNoError(t, err, "default")
+	core.AssertSliceEqual(t, wrapped, got, "default")
+
+	got, err = AppendFramed(COBSCodec{}, nil, wrapped)
+	core.AssertMustNoError(t, err, "cobs")
+	msg, err := COBSCodec{}.Unframe(got[:len(got)-1])
+	core.AssertMustNoError(t, err, "Unframe")
+
+	out := new(NanoRPCRequest)
+	core.AssertMustNoError(t, UnmarshalRequestTo(msg, out), "UnmarshalRequestTo")
+	core.AssertEqual(t, int32(1), out.RequestId, "request_id")
+
+	_, err = AppendFramed(COBSCodec{}, nil, wrapped[:1])
+	core.AssertErrorIs(t, err, io.ErrUnexpectedEOF, "truncated")
+}
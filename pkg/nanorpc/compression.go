@@ -0,0 +1,122 @@
+package nanorpc
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+
+	"darvaza.org/core"
+)
+
+// CompressRequest compresses the data of a request with DEFLATE, marking
+// it Compressed, when it's at least threshold bytes long and compressing
+// makes it smaller. A threshold of zero or less doesn't compress, and
+// requests already compressed are left as they are.
+func CompressRequest(req *NanoRPCRequest, threshold int) error {
+	if req == nil || req.Compressed {
+		return nil
+	}
+
+	data, ok, err := compress(req.Data, threshold)
+	if ok {
+		req.Data, req.Compressed = data, true
+	}
+	return err
+}
+
+// DecompressRequest restores the data of a request marked Compressed,
+// failing with [ErrMessageTooLarge] if it's longer than maxSize bytes, or
+// [ErrInvalidCompression] if it can't be decompressed. A maxSize of zero
+// uses [DefaultMaxMessageSize].
+func DecompressRequest(req *NanoRPCRequest, maxSize int) error {
+	if !req.GetCompressed() {
+		return nil
+	}
+
+	data, err := decompress(req.Data, maxSize)
+	if err != nil {
+		return err
+	}
+	req.Data, req.Compressed = data, false
+	return nil
+}
+
+// CompressResponse compresses the data of a response with DEFLATE,
+// marking it Compressed, when it's at least threshold bytes long and
+// compressing makes it smaller. A threshold of zero or less doesn't
+// compress, and responses already compressed are left as they are.
+func CompressResponse(res *NanoRPCResponse, threshold int) error {
+	if res == nil || res.Compressed {
+		return nil
+	}
+
+	data, ok, err := compress(res.Data, threshold)
+	if ok {
+		res.Data, res.Compressed = data, true
+	}
+	return err
+}
+
+// DecompressResponse restores the data of a response marked Compressed,
+// failing with [ErrMessageTooLarge] if it's longer than maxSize bytes, or
+// [ErrInvalidCompression] if it can't be decompressed. A maxSize of zero
+// uses [DefaultMaxMessageSize].
+func DecompressResponse(res *NanoRPCResponse, maxSize int) error {
+	if !res.GetCompressed() {
+		return nil
+	}
+
+	data, err := decompress(res.Data, maxSize)
+	if err != nil {
+		return err
+	}
+	res.Data, res.Compressed = data, false
+	return nil
+}
+
+// compress deflates data if at least threshold bytes long, and returns
+// it if smaller.
+func compress(data []byte, threshold int) ([]byte, bool, error) {
+	if threshold <= 0 || len(data) < threshold {
+		return nil, false, nil
+	}
+
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	if err != nil {
+		return nil, false, err
+	}
+	if _, err = w.Write(data); err == nil {
+		err = w.Close()
+	}
+
+	switch {
+	case err != nil:
+		return nil, false, err
+	case buf.Len() >= len(data):
+		// not worth it
+		return nil, false, nil
+	default:
+		return buf.Bytes(), true, nil
+	}
+}
+
+// decompress inflates data, up to maxSize bytes.
+func decompress(data []byte, maxSize int) ([]byte, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxMessageSize
+	}
+
+	r := flate.NewReader(bytes.NewReader(data))
+	defer r.Close()
+
+	out, err := io.ReadAll(io.LimitReader(r, int64(maxSize)+1))
+	switch {
+	case err != nil:
+		return nil, core.QuietWrap(ErrInvalidCompression, "invalid compressed data: %v", err)
+	case len(out) > maxSize:
+		return nil, core.QuietWrap(ErrMessageTooLarge, "decompressed data too large: limit %d", maxSize)
+	default:
+		return out, nil
+	}
+}
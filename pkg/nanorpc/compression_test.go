@@ -0,0 +1,80 @@
+package nanorpc
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"darvaza.org/core"
+)
+
+var _ core.TestCase = compressTestCase{}
+
+type compressTestCase struct {
+	name       string
+	data       []byte
+	threshold  int
+	compressed bool
+}
+
+func (tc compressTestCase) Name() string { return tc.name }
+
+func (tc compressTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	req := &NanoRPCRequest{Data: bytes.Clone(tc.data)}
+	core.AssertMustNoError(t, CompressRequest(req, tc.threshold), "CompressRequest")
+	core.AssertEqual(t, tc.compressed, req.Compressed, "request compressed")
+	core.AssertMustNoError(t, DecompressRequest(req, 0), "DecompressRequest")
+	core.AssertFalse(t, req.Compressed, "request decompressed")
+	core.AssertSliceEqual(t, tc.data, req.Data, "request data")
+
+	res := &NanoRPCResponse{Data: bytes.Clone(tc.data)}
+	core.AssertMustNoError(t, CompressResponse(res, tc.threshold), "CompressResponse")
+	core.AssertEqual(t, tc.compressed, res.Compressed, "response compressed")
+	core.AssertMustNoError(t, DecompressResponse(res, 0), "DecompressResponse")
+	core.AssertFalse(t, res.Compressed, "response decompressed")
+	core.AssertSliceEqual(t, tc.data, res.Data, "response data")
+}
+
+func newCompressTestCase(name string, data []byte, threshold int, compressed bool) compressTestCase {
+	return compressTestCase{
+		name:       name,
+		data:       data,
+		threshold:  threshold,
+		compressed: compressed,
+	}
+}
+
+func compressTestCases() []compressTestCase {
+	large := bytes.Repeat([]byte("telemetry "), 100)
+	random := []byte{0x9f, 0x31, 0xc4, 0x07, 0x5a, 0xe2, 0x88, 0x16}
+
+	return []compressTestCase{
+		newCompressTestCase("large", large, 256, true),
+		newCompressTestCase("below threshold", large[:100], 256, false),
+		newCompressTestCase("disabled", large, 0, false),
+		newCompressTestCase("incompressible", random, 1, false),
+		newCompressTestCase("empty", nil, 1, false),
+	}
+}
+
+func TestCompress(t *testing.T) {
+	core.RunTestCases(t, compressTestCases())
+}
+
+func TestDecompress_tooLarge(t *testing.T) {
+	res := &NanoRPCResponse{Data: bytes.Repeat([]byte{'x'}, 4096)}
+	core.AssertMustNoError(t, CompressResponse(res, 1), "CompressResponse")
+	core.AssertMustTrue(t, res.Compressed, "compressed")
+
+	err := DecompressResponse(res, 1024)
+	core.AssertTrue(t, errors.Is(err, ErrMessageTooLarge), "ErrMessageTooLarge: %v", err)
+}
+
+func TestDecompress_invalid(t *testing.T) {
+	req := &NanoRPCRequest{Data: []byte{0xff, 0xff, 0xff}, Compressed: true}
+
+	err := DecompressRequest(req, 0)
+	core.AssertTrue(t, errors.Is(err, ErrInvalidCompression), "ErrInvalidCompression: %v", err)
+}
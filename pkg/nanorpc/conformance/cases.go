@@ -0,0 +1,215 @@
+package conformance
+
+import (
+	"context"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// Request and response types, shortened.
+const (
+	typePing      = nanorpc.NanoRPCRequest_TYPE_PING
+	typeRequest   = nanorpc.NanoRPCRequest_TYPE_REQUEST
+	typeSubscribe = nanorpc.NanoRPCRequest_TYPE_SUBSCRIBE
+
+	typePong     = nanorpc.NanoRPCResponse_TYPE_PONG
+	typeResponse = nanorpc.NanoRPCResponse_TYPE_RESPONSE
+	typeUpdate   = nanorpc.NanoRPCResponse_TYPE_UPDATE
+)
+
+// suite lists the tests of the suite, in the order they run.
+var suite = []struct {
+	fn   func(t *testing.T, tg *Target, p *peer)
+	name string
+}{
+	{testPing, "ping"},
+	{testRequestPath, "request/path"},
+	{testRequestPathHash, "request/path_hash"},
+	{testRequestPipelined, "request/pipelined"},
+	{testNotFound, "status/not_found"},
+	{testNotFoundHash, "status/not_found_hash"},
+	{testInternalError, "status/internal_error"},
+	{testSubscribe, "subscribe/ack"},
+	{testUpdate, "subscribe/update"},
+	{testUpdateHash, "subscribe/update_hash"},
+	{testUpdateMany, "subscribe/many"},
+	{testUnsubscribe, "subscribe/unsubscribe"},
+}
+
+// payload is the data of requests and updates, a message with the
+// string field 1 set to "hi".
+var payload = []byte{0x0a, 0x02, 'h', 'i'}
+
+func request(id int32, typ nanorpc.NanoRPCRequest_Type, path string, data []byte) *nanorpc.NanoRPCRequest {
+	return &nanorpc.NanoRPCRequest{
+		RequestId:   id,
+		RequestType: typ,
+		PathOneof:   nanorpc.GetPathOneOfString(path),
+		Data:        data,
+	}
+}
+
+func requestHash(id int32, typ nanorpc.NanoRPCRequest_Type, path string, data []byte) *nanorpc.NanoRPCRequest {
+	req := request(id, typ, path, data)
+	req.PathOneof = nanorpc.GetPathOneOfHash(hashPath(path))
+	return req
+}
+
+// assertStatus checks the status of a response.
+func assertStatus(t *testing.T, status nanorpc.NanoRPCResponse_Status, res *nanorpc.NanoRPCResponse) {
+	t.Helper()
+	core.AssertEqual(t, status, res.ResponseStatus, "status of response to %d", res.RequestId)
+}
+
+// publish publishes the payload to the events path, skipping the test if
+// the target can't.
+func publish(t *testing.T, tg *Target) {
+	t.Helper()
+
+	if tg.Publish == nil {
+		t.Skip("Target.Publish not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), tg.Timeout)
+	defer cancel()
+	core.AssertMustNoError(t, tg.Publish(ctx, tg.EventsPath, payload), "Publish")
+}
+
+// sendOK sends a request, checking it's answered with STATUS_OK.
+func sendOK(t *testing.T, p *peer, req *nanorpc.NanoRPCRequest) {
+	t.Helper()
+
+	p.Send(t, req)
+	assertStatus(t, nanorpc.NanoRPCResponse_STATUS_OK, p.Await(t, req.RequestId, typeResponse))
+}
+
+func testPing(t *testing.T, _ *Target, p *peer) {
+	p.Send(t, &nanorpc.NanoRPCRequest{RequestId: 1, RequestType: typePing})
+	assertStatus(t, nanorpc.NanoRPCResponse_STATUS_OK, p.Await(t, 1, typePong))
+}
+
+func testRequestPath(t *testing.T, tg *Target, p *peer) {
+	testEcho(t, p, request(2, typeRequest, tg.EchoPath, payload))
+}
+
+func testRequestPathHash(t *testing.T, tg *Target, p *peer) {
+	testEcho(t, p, requestHash(3, typeRequest, tg.EchoPath, payload))
+}
+
+func testEcho(t *testing.T, p *peer, req *nanorpc.NanoRPCRequest) {
+	t.Helper()
+
+	p.Send(t, req)
+	res := p.Await(t, req.RequestId, typeResponse)
+	assertStatus(t, nanorpc.NanoRPCResponse_STATUS_OK, res)
+	core.AssertSliceEqual(t, req.Data, res.Data, "data")
+}
+
+// testRequestPipelined sends requests before reading any response, which
+// may come in any order.
+func testRequestPipelined(t *testing.T, tg *Target, p *peer) {
+	pending := make(map[int32][]byte)
+	for id := int32(10); id < 15; id++ {
+		data := append([]byte{0x10, byte(id)}, payload...)
+		pending[id] = data
+		p.Send(t, request(id, typeRequest, tg.EchoPath, data))
+	}
+
+	for len(pending) > 0 {
+		res := p.Next(t)
+		data, ok := pending[res.RequestId]
+		if !core.AssertTrue(t, ok && res.ResponseType == typeResponse,
+			"expected response, got %s to request %d", res.ResponseType, res.RequestId) {
+			continue
+		}
+		delete(pending, res.RequestId)
+		assertStatus(t, nanorpc.NanoRPCResponse_STATUS_OK, res)
+		core.AssertSliceEqual(t, data, res.Data, "data of request %d", res.RequestId)
+	}
+}
+
+func testNotFound(t *testing.T, tg *Target, p *peer) {
+	p.Send(t, request(20, typeRequest, tg.MissingPath, payload))
+	assertStatus(t, nanorpc.NanoRPCResponse_STATUS_NOT_FOUND, p.Await(t, 20, typeResponse))
+}
+
+func testNotFoundHash(t *testing.T, tg *Target, p *peer) {
+	p.Send(t, requestHash(21, typeRequest, tg.MissingPath, payload))
+	assertStatus(t, nanorpc.NanoRPCResponse_STATUS_NOT_FOUND, p.Await(t, 21, typeResponse))
+}
+
+func testInternalError(t *testing.T, tg *Target, p *peer) {
+	if tg.ErrorPath == "" {
+		t.Skip("Target.ErrorPath not set")
+	}
+
+	p.Send(t, request(22, typeRequest, tg.ErrorPath, payload))
+	assertStatus(t, nanorpc.NanoRPCResponse_STATUS_INTERNAL_ERROR, p.Await(t, 22, typeResponse))
+}
+
+func testSubscribe(t *testing.T, tg *Target, p *peer) {
+	sendOK(t, p, request(30, typeSubscribe, tg.EventsPath, nil))
+	sendOK(t, p, requestHash(31, typeSubscribe, tg.EventsPath, nil))
+}
+
+func testUpdate(t *testing.T, tg *Target, p *peer) {
+	testUpdateOf(t, tg, p, request(32, typeSubscribe, tg.EventsPath, nil))
+}
+
+func testUpdateHash(t *testing.T, tg *Target, p *peer) {
+	testUpdateOf(t, tg, p, requestHash(33, typeSubscribe, tg.EventsPath, nil))
+}
+
+// testUpdateOf checks a subscription receives what's published to its
+// path, bearing its request_id.
+func testUpdateOf(t *testing.T, tg *Target, p *peer, req *nanorpc.NanoRPCRequest) {
+	t.Helper()
+
+	sendOK(t, p, req)
+	publish(t, tg)
+
+	res := p.Await(t, req.RequestId, typeUpdate)
+	assertStatus(t, nanorpc.NanoRPCResponse_STATUS_OK, res)
+	core.AssertSliceEqual(t, payload, res.Data, "data")
+}
+
+// testUpdateMany checks every subscription to a path receives its own
+// update.
+func testUpdateMany(t *testing.T, tg *Target, p *peer) {
+	sendOK(t, p, request(34, typeSubscribe, tg.EventsPath, nil))
+	sendOK(t, p, request(35, typeSubscribe, tg.EventsPath, nil))
+	publish(t, tg)
+
+	pending := map[int32]bool{34: true, 35: true}
+	for len(pending) > 0 {
+		res := p.Next(t)
+		core.AssertTrue(t, res.ResponseType == typeUpdate && pending[res.RequestId],
+			"expected update, got %s to request %d", res.ResponseType, res.RequestId)
+		delete(pending, res.RequestId)
+	}
+}
+
+// testUnsubscribe checks a request with no data, to the path and with the
+// request_id of a subscription, ends it.
+func testUnsubscribe(t *testing.T, tg *Target, p *peer) {
+	sendOK(t, p, request(36, typeSubscribe, tg.EventsPath, nil))
+	sendOK(t, p, request(36, typeRequest, tg.EventsPath, nil))
+	if tg.Publish == nil {
+		return
+	}
+
+	// no update after the unsubscribe acknowledgement, before the pong
+	// of a later ping
+	publish(t, tg)
+	p.Send(t, &nanorpc.NanoRPCRequest{RequestId: 37, RequestType: typePing})
+	for {
+		res := p.Next(t)
+		if res.RequestId == 37 && res.ResponseType == typePong {
+			return
+		}
+		core.AssertFalse(t, res.RequestId == 36, "%s to request 36 after unsubscribing", res.ResponseType)
+	}
+}
@@ -0,0 +1,129 @@
+package conformance
+
+import (
+	"context"
+	"hash/fnv"
+	"net"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/vectors"
+)
+
+const (
+	// DefaultTimeout is how long the suite waits for every answer unless
+	// Target.Timeout says otherwise.
+	DefaultTimeout = 5 * time.Second
+
+	// DefaultMissingPath is the path assumed to have no handler unless
+	// Target.MissingPath says otherwise.
+	DefaultMissingPath = "/conformance/missing"
+)
+
+// Target is the NanoRPC server implementation under test.
+type Target struct {
+	// Dial connects to the implementation, once per test. Required.
+	Dial func(ctx context.Context) (net.Conn, error)
+
+	// Publish, if set, has the implementation publish data to the
+	// subscribers of a path. Tests of updates are skipped without it.
+	Publish func(ctx context.Context, path string, data []byte) error
+
+	// Codec frames the messages on the connection. Defaults to
+	// [nanorpc.LengthPrefixCodec].
+	Codec nanorpc.Codec
+
+	// EchoPath answers requests with STATUS_OK and their data.
+	// Defaults to [vectors.EchoPath].
+	EchoPath string
+
+	// EventsPath accepts subscriptions, and Publish publishes to it.
+	// Defaults to [vectors.EventsPath].
+	EventsPath string
+
+	// ErrorPath, if set, answers requests with STATUS_INTERNAL_ERROR.
+	// Tests of handler errors are skipped without it.
+	ErrorPath string
+
+	// MissingPath has no handler. Defaults to [DefaultMissingPath].
+	MissingPath string
+
+	// Timeout bounds the wait for every answer. Defaults to
+	// [DefaultTimeout].
+	Timeout time.Duration
+}
+
+// withDefaults returns a copy of the target with its defaults set.
+func (tg Target) withDefaults() Target {
+	if tg.Codec == nil {
+		tg.Codec = nanorpc.LengthPrefixCodec{}
+	}
+	tg.EchoPath = core.Coalesce(tg.EchoPath, vectors.EchoPath)
+	tg.EventsPath = core.Coalesce(tg.EventsPath, vectors.EventsPath)
+	tg.MissingPath = core.Coalesce(tg.MissingPath, DefaultMissingPath)
+	if tg.Timeout <= 0 {
+		tg.Timeout = DefaultTimeout
+	}
+	return tg
+}
+
+// Run runs the whole suite against a target.
+func Run(t *testing.T, target Target) {
+	t.Helper()
+
+	if target.Dial == nil {
+		t.Fatal("conformance: Target.Dial is required")
+	}
+	core.RunTestCases(t, TestCases(target))
+}
+
+// TestCases returns the tests of the suite against a target, to run them
+// selectively.
+func TestCases(target Target) []TestCase {
+	tg := target.withDefaults()
+
+	var out []TestCase
+	for _, c := range suite {
+		out = append(out, TestCase{target: &tg, name: c.name, fn: c.fn})
+	}
+	return out
+}
+
+var _ core.TestCase = TestCase{}
+
+// TestCase is a test of the suite, run over a new connection to the
+// target.
+type TestCase struct {
+	target *Target
+	fn     func(t *testing.T, tg *Target, p *peer)
+	name   string
+}
+
+// Name returns the name of the test.
+func (tc TestCase) Name() string { return tc.name }
+
+// Test dials the target and runs the test.
+func (tc TestCase) Test(t *testing.T) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), tc.target.Timeout)
+	defer cancel()
+
+	conn, err := tc.target.Dial(ctx)
+	core.AssertMustNoError(t, err, "Dial")
+
+	p := newPeer(conn, tc.target)
+	defer p.Close()
+
+	tc.fn(t, tc.target, p)
+}
+
+// hashPath returns the FNV-1a hash of a path, its path_hash.
+func hashPath(path string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(path))
+	return h.Sum32()
+}
@@ -0,0 +1,34 @@
+package conformance_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"protomcp.org/nanorpc/pkg/nanorpc/conformance"
+	"protomcp.org/nanorpc/pkg/nanorpc/server"
+	"protomcp.org/nanorpc/pkg/nanorpc/utils/testutils/e2e"
+	"protomcp.org/nanorpc/pkg/nanorpc/vectors"
+)
+
+// TestServer runs the suite against the Go server.
+func TestServer(t *testing.T) {
+	ts := e2e.NewTestServer(t, nil)
+	ts.Handle(vectors.EchoPath, func(_ context.Context, rc *server.RequestContext) error {
+		return rc.SendOK(rc.Request.Data)
+	})
+	ts.Handle("/error", func(_ context.Context, rc *server.RequestContext) error {
+		return rc.SendInternalError("failed")
+	})
+
+	conformance.Run(t, conformance.Target{
+		Dial: func(ctx context.Context) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "tcp", ts.Addr())
+		},
+		Publish: func(_ context.Context, path string, data []byte) error {
+			return ts.Handler.Publish(path, data)
+		},
+		ErrorPath: "/error",
+	})
+}
@@ -0,0 +1,23 @@
+// Package conformance is a black-box test suite of the NanoRPC protocol,
+// run against any server implementation reachable through a [net.Conn],
+// so alternative implementations, like C firmware or servers written in
+// other languages, can verify they are compatible with the Go client.
+//
+// The suite speaks the protocol over raw frames and covers ping/pong,
+// requests by string path and by path hash, pipelined requests, error
+// statuses, and the subscribe, update and unsubscribe semantics. The
+// implementation under test serves a few well-known paths, see [Target]:
+//
+//	func TestConformance(t *testing.T) {
+//	    conformance.Run(t, conformance.Target{
+//	        Dial: func(ctx context.Context) (net.Conn, error) {
+//	            var d net.Dialer
+//	            return d.DialContext(ctx, "tcp", "192.0.2.1:8080")
+//	        },
+//	        ErrorPath: "/error",
+//	    })
+//	}
+//
+// Update tests need a way to publish to subscribers and are skipped unless
+// Target.Publish is set.
+package conformance
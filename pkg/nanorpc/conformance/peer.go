@@ -0,0 +1,136 @@
+package conformance
+
+import (
+	"bufio"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// peer is the client end of a connection to the target, speaking raw
+// frames. Responses are read in the background as they arrive, so the
+// target is never blocked writing them.
+type peer struct {
+	conn  net.Conn
+	codec nanorpc.Codec
+	in    chan *nanorpc.NanoRPCResponse
+	done  chan struct{}
+	err   error // why in was closed
+	once  sync.Once
+
+	timeout time.Duration
+}
+
+func newPeer(conn net.Conn, tg *Target) *peer {
+	p := &peer{
+		conn:    conn,
+		codec:   tg.Codec,
+		in:      make(chan *nanorpc.NanoRPCResponse, 64),
+		done:    make(chan struct{}),
+		timeout: tg.Timeout,
+	}
+	go p.readLoop()
+	return p
+}
+
+// readLoop decodes responses until the connection fails.
+func (p *peer) readLoop() {
+	defer close(p.in)
+
+	scanner := bufio.NewScanner(p.conn)
+	scanner.Buffer(nil, p.codec.MaxFrameSize(nanorpc.DefaultMaxMessageSize))
+	scanner.Split(p.codec.Split(nanorpc.DefaultMaxMessageSize))
+
+	for scanner.Scan() {
+		res, err := p.decode(scanner.Bytes())
+		if err != nil {
+			p.err = err
+			return
+		}
+
+		select {
+		case p.in <- res:
+		case <-p.done:
+			return
+		}
+	}
+	p.err = core.Coalesce(scanner.Err(), net.ErrClosed)
+}
+
+func (p *peer) decode(frame []byte) (*nanorpc.NanoRPCResponse, error) {
+	msg, err := p.codec.Unframe(frame)
+	if err != nil {
+		return nil, err
+	}
+
+	res := new(nanorpc.NanoRPCResponse)
+	if err := proto.Unmarshal(msg, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Close closes the connection.
+func (p *peer) Close() {
+	p.once.Do(func() {
+		close(p.done)
+		_ = p.conn.Close()
+	})
+}
+
+// Send writes a request, failing the test if it can't.
+func (p *peer) Send(t *testing.T, req *nanorpc.NanoRPCRequest) {
+	t.Helper()
+
+	wrapped, err := nanorpc.EncodeRequest(req, nil)
+	core.AssertMustNoError(t, err, "EncodeRequest")
+	frame, err := nanorpc.AppendFramed(p.codec, nil, wrapped)
+	core.AssertMustNoError(t, err, "AppendFramed")
+
+	_ = p.conn.SetWriteDeadline(time.Now().Add(p.timeout))
+	_, err = p.conn.Write(frame)
+	core.AssertMustNoError(t, err, "write request %d", req.RequestId)
+}
+
+// Next returns the next response, failing the test if none arrives in
+// time or the connection fails.
+func (p *peer) Next(t *testing.T) *nanorpc.NanoRPCResponse {
+	t.Helper()
+
+	select {
+	case res, ok := <-p.in:
+		if !ok {
+			t.Fatalf("connection failed: %v", p.err)
+		}
+		return res
+	case <-time.After(p.timeout):
+		t.Fatalf("no response in %s", p.timeout)
+		return nil
+	}
+}
+
+// Await returns the next response of a type to a request, failing the
+// test if any other arrives first, except updates.
+func (p *peer) Await(t *testing.T, id int32, typ nanorpc.NanoRPCResponse_Type) *nanorpc.NanoRPCResponse {
+	t.Helper()
+
+	for {
+		res := p.Next(t)
+		switch {
+		case res.RequestId == id && res.ResponseType == typ:
+			return res
+		case res.ResponseType == nanorpc.NanoRPCResponse_TYPE_UPDATE:
+			// in-flight, or of other subscriptions
+		default:
+			t.Fatalf("expected %s to request %d, got %s to request %d",
+				typ, id, res.ResponseType, res.RequestId)
+		}
+	}
+}
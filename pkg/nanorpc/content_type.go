@@ -0,0 +1,116 @@
+package nanorpc
+
+import (
+	"encoding/json"
+	"strings"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"protomcp.org/nanorpc/pkg/nanorpc/cbor"
+)
+
+// MetadataContentType is the metadata key declaring the content type of
+// the data of a request or response. Data without one is protobuf.
+const MetadataContentType = "content-type"
+
+// Content types of the data of requests and responses.
+const (
+	// ContentTypeProtobuf is the content type of protobuf data, the
+	// default.
+	ContentTypeProtobuf = "application/x-protobuf"
+	// ContentTypeJSON is the content type of JSON data.
+	ContentTypeJSON = "application/json"
+	// ContentTypeCBOR is the content type of CBOR data, see the cbor
+	// package.
+	ContentTypeCBOR = "application/cbor"
+)
+
+// ContentType returns the content type the metadata declares, see
+// [ParseContentType].
+func (md Metadata) ContentType() string {
+	s, _ := md.Get(MetadataContentType)
+	return ParseContentType(s)
+}
+
+// ParseContentType returns a content type lowercase and without
+// parameters, turning the aliases of protobuf, and none, into
+// [ContentTypeProtobuf].
+func ParseContentType(s string) string {
+	s, _, _ = strings.Cut(s, ";")
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	switch s {
+	case "", "application/protobuf", "application/vnd.google.protobuf":
+		return ContentTypeProtobuf
+	default:
+		return s
+	}
+}
+
+// Unmarshal decodes data of the given content type into out. Protobuf
+// data needs out to be a [proto.Message], and JSON data is decoded with
+// protojson when it is one. Other content types fail with
+// [ErrUnsupportedContentType].
+func Unmarshal(contentType string, data []byte, out any) error {
+	msg, isMsg := out.(proto.Message)
+
+	switch ct := ParseContentType(contentType); {
+	case ct == ContentTypeProtobuf && isMsg:
+		return proto.Unmarshal(data, msg)
+	case ct == ContentTypeJSON && isMsg:
+		return protojson.Unmarshal(data, msg)
+	case ct == ContentTypeJSON:
+		return json.Unmarshal(data, out)
+	case ct == ContentTypeCBOR:
+		return cbor.Unmarshal(data, out)
+	default:
+		return core.QuietWrap(ErrUnsupportedContentType, "%s into %T", ct, out)
+	}
+}
+
+// Marshal encodes v as data of the given content type, the inverse of
+// [Unmarshal].
+func Marshal(contentType string, v any) ([]byte, error) {
+	msg, isMsg := v.(proto.Message)
+
+	switch ct := ParseContentType(contentType); {
+	case ct == ContentTypeProtobuf && isMsg:
+		return proto.Marshal(msg)
+	case ct == ContentTypeJSON && isMsg:
+		return protojson.Marshal(msg)
+	case ct == ContentTypeJSON:
+		return json.Marshal(v)
+	case ct == ContentTypeCBOR:
+		return cbor.Marshal(v)
+	default:
+		return nil, core.QuietWrap(ErrUnsupportedContentType, "%s from %T", ct, v)
+	}
+}
+
+// DecodeRequestContent attempts to decode the payload of a NanoRPC request
+// into out by the content type its metadata declares, reporting whether
+// there was one.
+func DecodeRequestContent(req *NanoRPCRequest, out any) (bool, error) {
+	if req != nil && len(req.Data) > 0 {
+		return true, Unmarshal(Metadata(req.Metadata).ContentType(), req.Data, out)
+	}
+
+	return false, nil
+}
+
+// DecodeResponseContent attempts to decode the payload of a NanoRPC
+// response into out by the content type its metadata declares, reporting
+// whether there was one.
+func DecodeResponseContent(res *NanoRPCResponse, out any) (bool, error) {
+	err := ResponseAsError(res)
+	switch {
+	case err != nil:
+		return false, err
+	case len(res.Data) == 0:
+		return false, nil
+	default:
+		return true, Unmarshal(Metadata(res.Metadata).ContentType(), res.Data, out)
+	}
+}
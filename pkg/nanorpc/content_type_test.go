@@ -0,0 +1,107 @@
+package nanorpc
+
+import (
+	"testing"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+)
+
+var _ core.TestCase = parseContentTypeTestCase{}
+
+type parseContentTypeTestCase struct {
+	name string
+	in   string
+	want string
+}
+
+func (tc parseContentTypeTestCase) Name() string { return tc.name }
+
+func (tc parseContentTypeTestCase) Test(t *testing.T) {
+	t.Helper()
+	core.AssertEqual(t, tc.want, ParseContentType(tc.in), "content type")
+}
+
+func newParseContentTypeTestCase(name, in, want string) parseContentTypeTestCase {
+	return parseContentTypeTestCase{name: name, in: in, want: want}
+}
+
+func parseContentTypeTestCases() []parseContentTypeTestCase {
+	return []parseContentTypeTestCase{
+		newParseContentTypeTestCase("none", "", ContentTypeProtobuf),
+		newParseContentTypeTestCase("protobuf alias", "application/protobuf", ContentTypeProtobuf),
+		newParseContentTypeTestCase("parameters", " Application/JSON; charset=utf-8", ContentTypeJSON),
+		newParseContentTypeTestCase("cbor", ContentTypeCBOR, ContentTypeCBOR),
+		newParseContentTypeTestCase("other", "text/plain", "text/plain"),
+	}
+}
+
+func TestParseContentType(t *testing.T) {
+	core.RunTestCases(t, parseContentTypeTestCases())
+
+	md := NewMetadata("Content-Type", "application/cbor")
+	core.AssertEqual(t, ContentTypeCBOR, md.ContentType(), "metadata")
+	core.AssertEqual(t, ContentTypeProtobuf, Metadata(nil).ContentType(), "no metadata")
+}
+
+func TestMarshal(t *testing.T) {
+	value := map[string]any{"sensor": "temp"}
+	for _, ct := range []string{ContentTypeJSON, ContentTypeCBOR} {
+		data, err := Marshal(ct, value)
+		core.AssertMustNoError(t, err, "Marshal %s", ct)
+
+		var out map[string]any
+		core.AssertMustNoError(t, Unmarshal(ct, data, &out), "Unmarshal %s", ct)
+		core.AssertEqual(t, "temp", out["sensor"], "%s value", ct)
+	}
+
+	hello := &NanoRPCHello{Version: 3, WillPath: "/will"}
+	for _, ct := range []string{ContentTypeProtobuf, ContentTypeJSON} {
+		data, err := Marshal(ct, hello)
+		core.AssertMustNoError(t, err, "Marshal %s message", ct)
+
+		out := new(NanoRPCHello)
+		core.AssertMustNoError(t, Unmarshal(ct, data, out), "Unmarshal %s message", ct)
+		core.AssertTrue(t, proto.Equal(hello, out), "%s message", ct)
+	}
+}
+
+func TestUnmarshal_unsupported(t *testing.T) {
+	var out map[string]any
+	err := Unmarshal(ContentTypeProtobuf, nil, &out)
+	core.AssertErrorIs(t, err, ErrUnsupportedContentType, "protobuf into map")
+	core.AssertTrue(t, IsBadRequest(err), "IsBadRequest")
+
+	err = Unmarshal("text/plain", []byte("x"), &out)
+	core.AssertErrorIs(t, err, ErrUnsupportedContentType, "unknown")
+
+	_, err = Marshal("text/plain", out)
+	core.AssertErrorIs(t, err, ErrUnsupportedContentType, "Marshal unknown")
+	core.AssertEqual(t, NanoRPCResponse_STATUS_BAD_REQUEST, StatusOf(err), "status")
+}
+
+func TestDecodeContent(t *testing.T) {
+	data, err := Marshal(ContentTypeCBOR, "hello")
+	core.AssertMustNoError(t, err, "Marshal")
+	md := NewMetadata(MetadataContentType, ContentTypeCBOR)
+
+	var s string
+	present, err := DecodeRequestContent(&NanoRPCRequest{Metadata: md, Data: data}, &s)
+	core.AssertMustNoError(t, err, "request")
+	core.AssertTrue(t, present, "request present")
+	core.AssertEqual(t, "hello", s, "request value")
+
+	present, err = DecodeRequestContent(&NanoRPCRequest{}, &s)
+	core.AssertNoError(t, err, "empty request")
+	core.AssertFalse(t, present, "empty request present")
+
+	s = ""
+	res := &NanoRPCResponse{ResponseStatus: NanoRPCResponse_STATUS_OK, Metadata: md, Data: data}
+	present, err = DecodeResponseContent(res, &s)
+	core.AssertMustNoError(t, err, "response")
+	core.AssertTrue(t, present, "response present")
+	core.AssertEqual(t, "hello", s, "response value")
+
+	_, err = DecodeResponseContent(&NanoRPCResponse{ResponseStatus: NanoRPCResponse_STATUS_NOT_FOUND}, &s)
+	core.AssertTrue(t, IsNotFound(err), "error status")
+}
@@ -0,0 +1,55 @@
+package nanorpc
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// MetadataCorrelationID is the metadata key carrying the correlation ID
+// of a request, echoed in its responses, so the logs of every hop it
+// takes can be stitched together.
+const MetadataCorrelationID = "correlation-id"
+
+// NewCorrelationID returns a random correlation ID, 16 hex digits.
+func NewCorrelationID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// CorrelationID returns the correlation ID the metadata carries, if any.
+func (md Metadata) CorrelationID() string {
+	s, _ := md.Get(MetadataCorrelationID)
+	return s
+}
+
+// EnsureCorrelationID returns the correlation ID of a request, giving it
+// a new one, see [NewCorrelationID], when its metadata carries none.
+func EnsureCorrelationID(req *NanoRPCRequest) string {
+	if req == nil {
+		return ""
+	}
+
+	id := Metadata(req.Metadata).CorrelationID()
+	if id == "" {
+		id = NewCorrelationID()
+		req.Metadata = Metadata(req.Metadata).Add(MetadataCorrelationID, id)
+	}
+	return id
+}
+
+// EchoCorrelationID copies the correlation ID of a request, if any, into
+// the metadata of its response, unless it carries one already.
+func EchoCorrelationID(req *NanoRPCRequest, res *NanoRPCResponse) {
+	if res == nil {
+		return
+	}
+
+	md := Metadata(res.Metadata)
+	if _, ok := md.Get(MetadataCorrelationID); ok {
+		return
+	}
+	if id := Metadata(req.GetMetadata()).CorrelationID(); id != "" {
+		res.Metadata = md.Add(MetadataCorrelationID, id)
+	}
+}
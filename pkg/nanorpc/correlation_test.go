@@ -0,0 +1,45 @@
+package nanorpc
+
+import (
+	"testing"
+
+	"darvaza.org/core"
+)
+
+func TestNewCorrelationID(t *testing.T) {
+	a, b := NewCorrelationID(), NewCorrelationID()
+	core.AssertEqual(t, 16, len(a), "length")
+	core.AssertNotEqual(t, a, b, "unique")
+}
+
+func TestEnsureCorrelationID(t *testing.T) {
+	req := &NanoRPCRequest{Metadata: NewMetadata("Correlation-ID", "abc")}
+	core.AssertEqual(t, "abc", EnsureCorrelationID(req), "accepted")
+	core.AssertEqual(t, 1, len(req.Metadata), "kept")
+
+	req = &NanoRPCRequest{}
+	id := EnsureCorrelationID(req)
+	core.AssertNotEqual(t, "", id, "generated")
+	core.AssertEqual(t, id, Metadata(req.Metadata).CorrelationID(), "attached")
+	core.AssertEqual(t, id, EnsureCorrelationID(req), "stable")
+
+	core.AssertEqual(t, "", EnsureCorrelationID(nil), "nil request")
+}
+
+func TestEchoCorrelationID(t *testing.T) {
+	req := &NanoRPCRequest{Metadata: NewMetadata(MetadataCorrelationID, "abc")}
+
+	res := &NanoRPCResponse{Metadata: NewMetadata(MetadataContentType, ContentTypeJSON)}
+	EchoCorrelationID(req, res)
+	core.AssertEqual(t, "abc", Metadata(res.Metadata).CorrelationID(), "echoed")
+	core.AssertEqual(t, ContentTypeJSON, Metadata(res.Metadata).ContentType(), "kept")
+
+	res = &NanoRPCResponse{Metadata: NewMetadata(MetadataCorrelationID, "xyz")}
+	EchoCorrelationID(req, res)
+	core.AssertSliceEqual(t, []string{"xyz"}, Metadata(res.Metadata).Values(MetadataCorrelationID), "not replaced")
+
+	res = &NanoRPCResponse{}
+	EchoCorrelationID(nil, res)
+	core.AssertNil(t, res.Metadata, "no request")
+	EchoCorrelationID(req, nil)
+}
@@ -0,0 +1,116 @@
+package nanorpc
+
+import (
+	"io"
+	"net"
+	"sync"
+
+	"darvaza.org/core"
+)
+
+// MaxDatagramSize is the largest wrapped NanoRPC message a
+// [DatagramConn] carries, the maximum payload of a UDP packet.
+const MaxDatagramSize = 65507
+
+// ErrDatagramTooLarge indicates a message doesn't fit a single datagram.
+var ErrDatagramTooLarge = core.QuietWrap(core.ErrInvalid, "message exceeds datagram size")
+
+var _ net.Conn = (*DatagramConn)(nil)
+
+// DatagramConn adapts a connection that preserves message boundaries,
+// such as a connected UDP socket, to the byte stream NanoRPC sessions
+// expect. Every wrapped message written is sent as its own datagram, and
+// received datagrams are read back-to-back. Datagrams not holding exactly
+// one wrapped message are discarded, so a corrupt packet never
+// desynchronises the stream.
+type DatagramConn struct {
+	net.Conn
+
+	rbuf []byte // unread part of the current datagram
+	wbuf []byte // incomplete message being written
+	rmu  sync.Mutex
+	wmu  sync.Mutex
+}
+
+// NewDatagramConn wraps a datagram connection. Returns nil if conn is nil.
+func NewDatagramConn(conn net.Conn) *DatagramConn {
+	if conn == nil {
+		return nil
+	}
+	return &DatagramConn{Conn: conn}
+}
+
+// Read reads the messages of received datagrams as a stream.
+func (dc *DatagramConn) Read(p []byte) (int, error) {
+	if dc == nil || dc.Conn == nil {
+		return 0, core.ErrNilReceiver
+	}
+
+	dc.rmu.Lock()
+	defer dc.rmu.Unlock()
+
+	for len(dc.rbuf) == 0 {
+		if err := dc.unsafeReadDatagram(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, dc.rbuf)
+	dc.rbuf = dc.rbuf[n:]
+	return n, nil
+}
+
+// unsafeReadDatagram reads the next datagram, leaving rbuf empty if it
+// doesn't hold exactly one wrapped message.
+func (dc *DatagramConn) unsafeReadDatagram() error {
+	buf := make([]byte, MaxDatagramSize)
+	n, err := dc.Conn.Read(buf)
+	if err != nil {
+		return err
+	}
+
+	if _, total, err := DecodeSplit(buf[:n]); err == nil && total == n {
+		dc.rbuf = buf[:n]
+	}
+	return nil
+}
+
+// Write buffers p and sends every complete wrapped message in it as a
+// datagram. Partial messages wait for the rest to be written.
+func (dc *DatagramConn) Write(p []byte) (int, error) {
+	if dc == nil || dc.Conn == nil {
+		return 0, core.ErrNilReceiver
+	}
+
+	dc.wmu.Lock()
+	defer dc.wmu.Unlock()
+
+	dc.wbuf = append(dc.wbuf, p...)
+	if err := dc.unsafeFlush(); err != nil {
+		dc.wbuf = nil
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// unsafeFlush sends the complete messages buffered by Write.
+func (dc *DatagramConn) unsafeFlush() error {
+	for len(dc.wbuf) > 0 {
+		_, total, err := DecodeSplit(dc.wbuf)
+		switch {
+		case total > MaxDatagramSize:
+			return ErrDatagramTooLarge
+		case err == io.ErrUnexpectedEOF:
+			// wait for the rest of the message
+			return nil
+		case err != nil:
+			return err
+		}
+
+		if _, err := dc.Conn.Write(dc.wbuf[:total]); err != nil {
+			return err
+		}
+		dc.wbuf = dc.wbuf[total:]
+	}
+	return nil
+}
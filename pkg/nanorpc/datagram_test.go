@@ -0,0 +1,104 @@
+package nanorpc
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"darvaza.org/core"
+)
+
+// newDatagramPipe returns a [DatagramConn] over one end of a [net.Pipe],
+// which like a datagram socket keeps the boundaries of every write.
+func newDatagramPipe(t *testing.T) (dc *DatagramConn, peer net.Conn) {
+	t.Helper()
+
+	a, b := net.Pipe()
+	t.Cleanup(func() {
+		_ = a.Close()
+		_ = b.Close()
+	})
+	return NewDatagramConn(a), b
+}
+
+func mustEncodeRequest(t *testing.T, id int32, path string) []byte {
+	t.Helper()
+
+	req := &NanoRPCRequest{
+		RequestId:   id,
+		RequestType: NanoRPCRequest_TYPE_PING,
+		PathOneof:   &NanoRPCRequest_Path{Path: path},
+	}
+	b, err := EncodeRequest(req, nil)
+	core.AssertMustNoError(t, err, "encode")
+	return b
+}
+
+func TestDatagramConn_WriteSplitsMessages(t *testing.T) {
+	dc, peer := newDatagramPipe(t)
+
+	first := mustEncodeRequest(t, 1, "/first")
+	second := mustEncodeRequest(t, 2, "/second")
+	stream := append(append([]byte{}, first...), second...)
+
+	go func() {
+		// first message and a half, then the rest
+		cut := len(first) + len(second)/2
+		_, _ = dc.Write(stream[:cut])
+		_, _ = dc.Write(stream[cut:])
+	}()
+
+	buf := make([]byte, MaxDatagramSize)
+	for _, want := range [][]byte{first, second} {
+		n, err := peer.Read(buf)
+		core.AssertMustNoError(t, err, "read datagram")
+		core.AssertSliceEqual(t, want, buf[:n], "datagram")
+	}
+}
+
+func TestDatagramConn_ReadDropsInvalid(t *testing.T) {
+	dc, peer := newDatagramPipe(t)
+
+	valid := mustEncodeRequest(t, 3, "/valid")
+	go func() {
+		_, _ = peer.Write([]byte{0xff, 0x01})               // truncated
+		_, _ = peer.Write(append(bytes.Clone(valid), 0x00)) // trailing garbage
+		_, _ = peer.Write(valid)
+	}()
+
+	buf := make([]byte, len(valid))
+	n, err := dc.Read(buf)
+	core.AssertMustNoError(t, err, "read")
+	core.AssertSliceEqual(t, valid, buf[:n], "message")
+
+	req, _, err := DecodeRequest(buf[:n])
+	core.AssertMustNoError(t, err, "decode")
+	core.AssertEqual(t, int32(3), req.RequestId, "request ID")
+}
+
+func TestDatagramConn_WriteTooLarge(t *testing.T) {
+	dc, _ := newDatagramPipe(t)
+
+	req := &NanoRPCRequest{
+		RequestId:   4,
+		RequestType: NanoRPCRequest_TYPE_PING,
+		PathOneof:   &NanoRPCRequest_Path{Path: string(bytes.Repeat([]byte{'x'}, MaxDatagramSize))},
+	}
+	b, err := EncodeRequest(req, nil)
+	core.AssertMustNoError(t, err, "encode")
+
+	_, err = dc.Write(b)
+	core.AssertErrorIs(t, err, ErrDatagramTooLarge, "too large")
+}
+
+func TestDatagramConn_NilReceiver(t *testing.T) {
+	var dc *DatagramConn
+
+	core.AssertNil(t, NewDatagramConn(nil), "NewDatagramConn(nil)")
+
+	_, err := dc.Read(nil)
+	core.AssertErrorIs(t, err, core.ErrNilReceiver, "Read")
+
+	_, err = dc.Write(nil)
+	core.AssertErrorIs(t, err, core.ErrNilReceiver, "Write")
+}
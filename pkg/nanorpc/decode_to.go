@@ -0,0 +1,170 @@
+package nanorpc
+
+import (
+	"errors"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+
+	"darvaza.org/core"
+)
+
+// Field numbers of [NanoRPCRequest].
+const (
+	reqFieldRequestID   protowire.Number = 1
+	reqFieldRequestType protowire.Number = 2
+	reqFieldPathHash    protowire.Number = 3
+	reqFieldPath        protowire.Number = 4
+	reqFieldResumeAfter protowire.Number = 5
+	reqFieldCompressed  protowire.Number = 6
+	reqFieldHistory     protowire.Number = 7
+	reqFieldAcked       protowire.Number = 8
+	reqFieldAckSequence protowire.Number = 9
+	reqFieldData        protowire.Number = 10
+	reqFieldTimeout     protowire.Number = 12
+)
+
+// errSlowPath tells a request has to be decoded by [proto.Unmarshal].
+var errSlowPath = errors.New("request needs proto.Unmarshal")
+
+// DecodeRequestTo decodes a wrapped NanoRPC request from a buffer into
+// out, like [DecodeRequest], but reusing out and the memory it holds so
+// decoding a stream of requests into the same message barely allocates:
+// the capacity of its Data is kept, and so is its path when unchanged.
+// It returns the length of the wrapped request. Requests it can't decode
+// itself, like those with unknown fields, are left to [proto.Unmarshal].
+//
+// The previous contents of out, including its Data, are overwritten, so
+// they must no longer be in use.
+func DecodeRequestTo(data []byte, out *NanoRPCRequest) (int, error) {
+	if out == nil {
+		return 0, core.QuietWrap(core.ErrInvalid, "nil request")
+	}
+
+	prefixLen, totalLen, err := DecodeSplit(data)
+	if err != nil {
+		return 0, err
+	}
+
+	return totalLen, UnmarshalRequestTo(data[prefixLen:totalLen], out)
+}
+
+// UnmarshalRequestTo decodes an unwrapped NanoRPC request, as a [Codec]
+// unframes it, into out, reusing its memory as [DecodeRequestTo] does.
+func UnmarshalRequestTo(msg []byte, out *NanoRPCRequest) error {
+	if out == nil {
+		return core.QuietWrap(core.ErrInvalid, "nil request")
+	}
+
+	if decodeRequestFields(msg, out) != nil {
+		// unknown fields, or malformed and left for proto to report
+		return proto.Unmarshal(msg, out)
+	}
+	return nil
+}
+
+// decodeRequestFields decodes the fields of a request into out, failing
+// on any it doesn't know.
+func decodeRequestFields(b []byte, out *NanoRPCRequest) error {
+	keep := requestMemory{data: out.Data[:0], path: out.PathOneof}
+	out.Reset()
+
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		n, err := decodeRequestField(out, &keep, num, typ, b)
+		if err != nil {
+			return err
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// requestMemory is what [DecodeRequestTo] reuses of the previous request.
+type requestMemory struct {
+	path isNanoRPCRequest_PathOneof
+	data []byte
+}
+
+// decodeRequestField decodes the value of a field, returning its length.
+func decodeRequestField(out *NanoRPCRequest, keep *requestMemory, num protowire.Number,
+	typ protowire.Type, b []byte) (int, error) {
+	switch typ {
+	case protowire.VarintType:
+		v, n := protowire.ConsumeVarint(b)
+		if n < 0 {
+			return 0, protowire.ParseError(n)
+		}
+		return n, setRequestVarint(out, keep, num, v)
+	case protowire.BytesType:
+		v, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return 0, protowire.ParseError(n)
+		}
+		return n, setRequestBytes(out, keep, num, v)
+	default:
+		return 0, errSlowPath
+	}
+}
+
+// setRequestVarint sets a varint field of a request.
+func setRequestVarint(out *NanoRPCRequest, keep *requestMemory, num protowire.Number, v uint64) error {
+	switch num {
+	case reqFieldRequestID:
+		out.RequestId = int32(v)
+	case reqFieldRequestType:
+		out.RequestType = NanoRPCRequest_Type(int32(v))
+	case reqFieldPathHash:
+		p, ok := keep.path.(*NanoRPCRequest_PathHash)
+		if !ok {
+			p = new(NanoRPCRequest_PathHash)
+		}
+		p.PathHash = uint32(v)
+		out.PathOneof = p
+	case reqFieldResumeAfter:
+		out.ResumeAfter = v
+	case reqFieldCompressed:
+		out.Compressed = protowire.DecodeBool(v)
+	case reqFieldHistory:
+		out.History = uint32(v)
+	case reqFieldAcked:
+		out.Acknowledged = protowire.DecodeBool(v)
+	case reqFieldAckSequence:
+		out.AckSequence = v
+	case reqFieldTimeout:
+		out.TimeoutMs = uint32(v)
+	default:
+		return errSlowPath
+	}
+	return nil
+}
+
+// setRequestBytes sets a length-delimited field of a request.
+func setRequestBytes(out *NanoRPCRequest, keep *requestMemory, num protowire.Number, v []byte) error {
+	switch num {
+	case reqFieldPath:
+		if !utf8.Valid(v) {
+			return errSlowPath
+		}
+
+		p, ok := keep.path.(*NanoRPCRequest_Path)
+		switch {
+		case !ok:
+			p = &NanoRPCRequest_Path{Path: string(v)}
+		case p.Path != string(v):
+			p.Path = string(v)
+		}
+		out.PathOneof = p
+	case reqFieldData:
+		out.Data = append(keep.data[:0], v...)
+	default:
+		return errSlowPath
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package nanorpc
+
+import (
+	"testing"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+var _ core.TestCase = decodeRequestToTestCase{}
+
+type decodeRequestToTestCase struct {
+	name string
+	data []byte
+}
+
+func (tc decodeRequestToTestCase) Name() string { return tc.name }
+
+func (tc decodeRequestToTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	want, wantLen, wantErr := DecodeRequest(tc.data)
+
+	// into a fresh request, and over a previous one
+	for _, out := range []*NanoRPCRequest{
+		new(NanoRPCRequest),
+		newPathRequest(99, "/previous", NanoRPCRequest_TYPE_SUBSCRIBE, []byte("previous")),
+	} {
+		n, err := DecodeRequestTo(tc.data, out)
+		if wantErr != nil {
+			core.AssertError(t, err, "DecodeRequestTo")
+			continue
+		}
+
+		core.AssertMustNoError(t, err, "DecodeRequestTo")
+		core.AssertEqual(t, wantLen, n, "length")
+		core.AssertTrue(t, proto.Equal(want, out), "equal: %v != %v", want, out)
+	}
+}
+
+// newPathRequest returns a request by path.
+func newPathRequest(id int32, path string, rt NanoRPCRequest_Type, data []byte) *NanoRPCRequest {
+	return &NanoRPCRequest{
+		RequestId:   id,
+		RequestType: rt,
+		PathOneof:   &NanoRPCRequest_Path{Path: path},
+		Data:        data,
+	}
+}
+
+func newDecodeRequestToTestCase(name string, req *NanoRPCRequest) decodeRequestToTestCase {
+	data, err := EncodeRequest(req, nil)
+	if err != nil {
+		panic(err)
+	}
+	return decodeRequestToTestCase{name: name, data: data}
+}
+
+// newUnknownFieldRequest encodes a request with a field it doesn't have.
+func newUnknownFieldRequest() []byte {
+	b, err := proto.Marshal(&NanoRPCRequest{RequestId: 3, Data: []byte("data")})
+	if err != nil {
+		panic(err)
+	}
+	b = protowire.AppendTag(b, 99, protowire.BytesType)
+	b = protowire.AppendBytes(b, []byte("unknown"))
+	return append(protowire.AppendVarint(nil, uint64(len(b))), b...)
+}
+
+func decodeRequestToTestCases() []decodeRequestToTestCase {
+	full := newPathRequest(-5, "/sensors/temp", NanoRPCRequest_TYPE_SUBSCRIBE, []byte("filter"))
+	full.ResumeAfter = 1 << 40
+	full.Compressed = true
+	full.History = 8
+	full.Acknowledged = true
+	full.AckSequence = 300
+	full.TimeoutMs = 1500
+
+	invalidPath := newPathRequest(1, "/ok", NanoRPCRequest_TYPE_REQUEST, nil)
+	invalidData, err := EncodeRequest(invalidPath, nil)
+	if err != nil {
+		panic(err)
+	}
+	invalidData[len(invalidData)-1] = 0xff // not UTF-8
+
+	return []decodeRequestToTestCase{
+		newDecodeRequestToTestCase("empty", &NanoRPCRequest{}),
+		newDecodeRequestToTestCase("ping", &NanoRPCRequest{RequestId: 1, RequestType: NanoRPCRequest_TYPE_PING}),
+		newDecodeRequestToTestCase("path hash", &NanoRPCRequest{
+			RequestId:   2,
+			RequestType: NanoRPCRequest_TYPE_REQUEST,
+			PathOneof:   &NanoRPCRequest_PathHash{PathHash: 0xdeadbeef},
+			Data:        []byte("data"),
+		}),
+		newDecodeRequestToTestCase("all fields", full),
+		{name: "unknown field", data: newUnknownFieldRequest()},
+		{name: "invalid path", data: invalidData},
+		{name: "truncated", data: []byte{5, 8, 1}},
+		{name: "malformed", data: []byte{2, 8, 0x80}},
+	}
+}
+
+func TestDecodeRequestTo(t *testing.T) {
+	core.RunTestCases(t, decodeRequestToTestCases())
+}
+
+func TestDecodeRequestTo_reuse(t *testing.T) {
+	first, err := EncodeRequest(newPathRequest(1, "/a", NanoRPCRequest_TYPE_REQUEST, []byte("long data")), nil)
+	core.AssertMustNoError(t, err, "EncodeRequest")
+	second, err := EncodeRequest(newPathRequest(2, "/a", NanoRPCRequest_TYPE_REQUEST, []byte("short")), nil)
+	core.AssertMustNoError(t, err, "EncodeRequest")
+
+	out := new(NanoRPCRequest)
+	_, err = DecodeRequestTo(first, out)
+	core.AssertMustNoError(t, err, "first")
+	data, path := out.Data, out.PathOneof
+
+	_, err = DecodeRequestTo(second, out)
+	core.AssertMustNoError(t, err, "second")
+	core.AssertEqual(t, int32(2), out.RequestId, "request_id")
+	core.AssertEqual(t, "short", string(out.Data), "data")
+	core.AssertEqual(t, &data[0], &out.Data[0], "data reused")
+	core.AssertTrue(t, path == out.PathOneof, "path reused")
+}
+
+func TestDecodeRequestTo_nil(t *testing.T) {
+	data, err := EncodeRequest(&NanoRPCRequest{RequestId: 1}, nil)
+	core.AssertMustNoError(t, err, "EncodeRequest")
+
+	_, err = DecodeRequestTo(data, nil)
+	core.AssertErrorIs(t, err, core.ErrInvalid, "nil")
+}
+
+// newBenchmarkRequest encodes a typical request of a gateway.
+func newBenchmarkRequest(b *testing.B) []byte {
+	b.Helper()
+
+	data, err := EncodeRequest(newPathRequest(42, "/devices/7/status",
+		NanoRPCRequest_TYPE_REQUEST, make([]byte, 64)), nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return data
+}
+
+func BenchmarkDecodeRequest(b *testing.B) {
+	data := newBenchmarkRequest(b)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, _, err := DecodeRequest(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeRequestTo(b *testing.B) {
+	data := newBenchmarkRequest(b)
+	out := new(NanoRPCRequest)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := DecodeRequestTo(data, out); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -10,4 +10,11 @@
 // The actual client and server implementations are in separate packages:
 //   - Client: protomcp.org/nanorpc/pkg/nanorpc/client
 //   - Server: protomcp.org/nanorpc/pkg/nanorpc/server
+//
+// # Nil receivers
+//
+// Methods on a nil receiver never panic, across this package and the
+// client and server packages. Methods returning an error report
+// [darvaza.org/core.ErrNilReceiver]; the others return zero values, and logging
+// helpers do nothing.
 package nanorpc
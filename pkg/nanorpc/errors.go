@@ -1,6 +1,7 @@
 package nanorpc
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/fs"
@@ -16,9 +17,41 @@ var (
 	// ErrInternalServerError indicates the server reported an internal error
 	ErrInternalServerError = errors.New("internal server error")
 
+	// ErrBadRequest indicates the server refused a malformed or invalid
+	// request
+	ErrBadRequest = errors.New("bad request")
+
+	// ErrUnavailable indicates the server is temporarily unable to
+	// handle the request
+	ErrUnavailable = core.NewTemporaryError(errors.New("service unavailable"))
+
+	// ErrTimeout indicates the server didn't complete the request in time
+	ErrTimeout = core.NewTimeoutError(errors.New("request timed out"))
+
+	// ErrTooManyRequests indicates the server refused the request for
+	// exceeding a rate or concurrency limit
+	ErrTooManyRequests = core.NewTemporaryError(errors.New("too many requests"))
+
+	// ErrUnsupportedContentType indicates data of a content type that
+	// can't be decoded, or not into the given value, see [Unmarshal].
+	// It's a bad request.
+	ErrUnsupportedContentType = core.Wrap(ErrBadRequest, "unsupported content type")
+
 	// ErrSessionClosed indicates the session has been closed
 	ErrSessionClosed = errors.New("session closed")
 
+	// ErrMessageTooLarge indicates a wrapped message declares a size
+	// larger than allowed, see [SplitMax].
+	ErrMessageTooLarge = errors.New("message too large")
+
+	// ErrInvalidFrame indicates a frame couldn't be decoded by its
+	// [Codec].
+	ErrInvalidFrame = errors.New("invalid frame")
+
+	// ErrInvalidCompression indicates the data of a message marked
+	// compressed couldn't be decompressed, see [DecompressRequest].
+	ErrInvalidCompression = errors.New("invalid compressed data")
+
 	// ErrHashCollision indicates two different paths hash to the same value
 	ErrHashCollision = errors.New("hash collision detected")
 
@@ -30,31 +63,116 @@ var (
 )
 
 var (
-	_ error            = (*ResponseError)(nil)
-	_ fmt.Stringer     = (*ResponseError)(nil)
-	_ core.Unwrappable = (*ResponseError)(nil)
+	_ error            = (*Error)(nil)
+	_ fmt.Stringer     = (*Error)(nil)
+	_ core.Unwrappable = (*Error)(nil)
 )
 
-// ResponseError represents a NanoRPC error response.
-type ResponseError struct {
-	Err    error
-	Msg    string
+// Error is a NanoRPC error response, its status, message and an optional
+// detail payload carried as the data of the response. Handlers send it,
+// see [NotFoundf], and clients get it back from [ResponseAsError].
+// [errors.Is] matches it against the sentinel of its status, e.g.
+// [fs.ErrNotExist] for STATUS_NOT_FOUND, whatever its cause.
+type Error struct {
+	// Err is the cause, or the sentinel of the status.
+	Err error
+	// Msg is the message of the response.
+	Msg string
+	// Detail is the data of the response, if any.
+	Detail []byte
+	// Status is the status of the response.
 	Status NanoRPCResponse_Status
 }
 
-func (e ResponseError) Error() string {
+// ResponseError is the former name of [Error].
+//
+// Deprecated: Use [Error].
+type ResponseError = Error
+
+// NewError returns an [Error] of the given status and message.
+func NewError(status NanoRPCResponse_Status, msg string) *Error {
+	return &Error{
+		Status: status,
+		Msg:    msg,
+		Err:    statusSentinel(status),
+	}
+}
+
+// Errorf returns an [Error] of the given status with a formatted message,
+// wrapping the errors given to %w.
+func Errorf(status NanoRPCResponse_Status, format string, args ...any) *Error {
+	err := fmt.Errorf(format, args...)
+	e := NewError(status, err.Error())
+	if wraps(err) {
+		e.Err = err
+	}
+	return e
+}
+
+// NotFoundf returns a STATUS_NOT_FOUND [Error] with a formatted message.
+func NotFoundf(format string, args ...any) *Error {
+	return Errorf(NanoRPCResponse_STATUS_NOT_FOUND, format, args...)
+}
+
+// NotAuthorizedf returns a STATUS_NOT_AUTHORIZED [Error] with a formatted
+// message.
+func NotAuthorizedf(format string, args ...any) *Error {
+	return Errorf(NanoRPCResponse_STATUS_NOT_AUTHORIZED, format, args...)
+}
+
+// InternalErrorf returns a STATUS_INTERNAL_ERROR [Error] with a formatted
+// message.
+func InternalErrorf(format string, args ...any) *Error {
+	return Errorf(NanoRPCResponse_STATUS_INTERNAL_ERROR, format, args...)
+}
+
+// BadRequestf returns a STATUS_BAD_REQUEST [Error] with a formatted
+// message.
+func BadRequestf(format string, args ...any) *Error {
+	return Errorf(NanoRPCResponse_STATUS_BAD_REQUEST, format, args...)
+}
+
+// Unavailablef returns a STATUS_UNAVAILABLE [Error] with a formatted
+// message.
+func Unavailablef(format string, args ...any) *Error {
+	return Errorf(NanoRPCResponse_STATUS_UNAVAILABLE, format, args...)
+}
+
+// Timeoutf returns a STATUS_TIMEOUT [Error] with a formatted message.
+func Timeoutf(format string, args ...any) *Error {
+	return Errorf(NanoRPCResponse_STATUS_TIMEOUT, format, args...)
+}
+
+// TooManyRequestsf returns a STATUS_TOO_MANY_REQUESTS [Error] with a
+// formatted message.
+func TooManyRequestsf(format string, args ...any) *Error {
+	return Errorf(NanoRPCResponse_STATUS_TOO_MANY_REQUESTS, format, args...)
+}
+
+// WithDetail sets the detail payload of the error, returning it.
+func (e *Error) WithDetail(detail []byte) *Error {
+	e.Detail = detail
+	return e
+}
+
+func (e Error) Error() string {
 	return e.String()
 }
 
-func (e ResponseError) Unwrap() error {
+func (e Error) Unwrap() error {
 	return e.Err
 }
 
-func (e ResponseError) String() string {
+// Is tells if target is the sentinel of the status of the error.
+func (e Error) Is(target error) bool {
+	return target != nil && target == statusSentinel(e.Status)
+}
+
+func (e Error) String() string {
 	var buf strings.Builder
 	status, ok := strings.CutPrefix(e.Status.String(), "STATUS_")
 	switch {
-	case !ok, e.Err == core.ErrUnknown:
+	case !ok, statusSentinel(e.Status) == core.ErrUnknown:
 		status = fmt.Sprintf("unknown status %d", e.Status)
 	case e.Status == NanoRPCResponse_STATUS_UNSPECIFIED:
 		status = fmt.Sprintf("%s: invalid status", status)
@@ -71,34 +189,122 @@ func (e ResponseError) String() string {
 	return buf.String()
 }
 
-// ResponseAsError extracts an error from the
-// status of a response.
+// ResponseAsError extracts an [Error] from the status of a response, with
+// the data of the response as detail, or nil for STATUS_OK.
 func ResponseAsError(res *NanoRPCResponse) error {
-	var err error
-
 	if res == nil {
 		return ErrNoResponse
 	}
+	if res.ResponseStatus == NanoRPCResponse_STATUS_OK {
+		return nil
+	}
+
+	e := NewError(res.ResponseStatus, res.ResponseMessage)
+	e.Detail = res.Data
+	return e
+}
+
+// AsError returns err as an [Error]: the one it wraps if any, or one of
+// the status of the sentinel it wraps, e.g. STATUS_NOT_FOUND for
+// [fs.ErrNotExist] and [core.ErrNotExists], STATUS_NOT_AUTHORIZED for
+// [fs.ErrPermission] and STATUS_TIMEOUT for [context.DeadlineExceeded],
+// or STATUS_INTERNAL_ERROR otherwise, with err as cause and message. A nil
+// err returns nil.
+func AsError(err error) *Error {
+	var e *Error
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &e):
+		return e
+	}
 
-	switch res.ResponseStatus {
+	e = NewError(sentinelStatus(err), err.Error())
+	e.Err = err
+	return e
+}
+
+// sentinelStatus returns the status of the sentinel err wraps, or
+// STATUS_INTERNAL_ERROR.
+func sentinelStatus(err error) NanoRPCResponse_Status {
+	switch {
+	case errors.Is(err, fs.ErrNotExist), errors.Is(err, core.ErrNotExists):
+		return NanoRPCResponse_STATUS_NOT_FOUND
+	case errors.Is(err, fs.ErrPermission):
+		return NanoRPCResponse_STATUS_NOT_AUTHORIZED
+	case errors.Is(err, ErrBadRequest):
+		return NanoRPCResponse_STATUS_BAD_REQUEST
+	case errors.Is(err, ErrUnavailable):
+		return NanoRPCResponse_STATUS_UNAVAILABLE
+	case errors.Is(err, ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return NanoRPCResponse_STATUS_TIMEOUT
+	case errors.Is(err, ErrTooManyRequests):
+		return NanoRPCResponse_STATUS_TOO_MANY_REQUESTS
+	default:
+		return NanoRPCResponse_STATUS_INTERNAL_ERROR
+	}
+}
+
+// StatusOf returns the status a response failing with err has, see
+// [AsError], or STATUS_OK if err is nil.
+func StatusOf(err error) NanoRPCResponse_Status {
+	if e := AsError(err); e != nil {
+		return e.Status
+	}
+	return NanoRPCResponse_STATUS_OK
+}
+
+// statusSentinel returns the error matching a status.
+func statusSentinel(status NanoRPCResponse_Status) error {
+	switch status {
 	case NanoRPCResponse_STATUS_OK:
 		return nil
 	case NanoRPCResponse_STATUS_NOT_FOUND:
-		err = fs.ErrNotExist
+		return fs.ErrNotExist
 	case NanoRPCResponse_STATUS_NOT_AUTHORIZED:
-		err = fs.ErrPermission
+		return fs.ErrPermission
 	case NanoRPCResponse_STATUS_INTERNAL_ERROR:
-		err = ErrInternalServerError
+		return ErrInternalServerError
+	case NanoRPCResponse_STATUS_BAD_REQUEST:
+		return ErrBadRequest
+	case NanoRPCResponse_STATUS_UNAVAILABLE:
+		return ErrUnavailable
+	case NanoRPCResponse_STATUS_TIMEOUT:
+		return ErrTimeout
+	case NanoRPCResponse_STATUS_TOO_MANY_REQUESTS:
+		return ErrTooManyRequests
 	case NanoRPCResponse_STATUS_UNSPECIFIED:
-		err = core.ErrInvalid
+		return core.ErrInvalid
+	default:
+		return core.ErrUnknown
+	}
+}
+
+// LegacyStatus returns the status peers predating [FeatureExtendedStatus]
+// understand in place of status: STATUS_INTERNAL_ERROR for the statuses
+// added with it, from STATUS_BAD_REQUEST to STATUS_TOO_MANY_REQUESTS, and
+// status itself otherwise.
+func LegacyStatus(status NanoRPCResponse_Status) NanoRPCResponse_Status {
+	switch status {
+	case NanoRPCResponse_STATUS_BAD_REQUEST,
+		NanoRPCResponse_STATUS_UNAVAILABLE,
+		NanoRPCResponse_STATUS_TIMEOUT,
+		NanoRPCResponse_STATUS_TOO_MANY_REQUESTS:
+		return NanoRPCResponse_STATUS_INTERNAL_ERROR
 	default:
-		err = core.ErrUnknown
+		return status
 	}
+}
 
-	return &ResponseError{
-		Status: res.ResponseStatus,
-		Msg:    res.ResponseMessage,
-		Err:    err,
+// wraps tells if err wraps other errors.
+func wraps(err error) bool {
+	switch w := err.(type) {
+	case interface{ Unwrap() error }:
+		return w.Unwrap() != nil
+	case interface{ Unwrap() []error }:
+		return len(w.Unwrap()) > 0
+	default:
+		return false
 	}
 }
 
@@ -112,6 +318,29 @@ func IsNotAuthorized(err error) bool {
 	return core.IsError(err, fs.ErrPermission)
 }
 
+// IsBadRequest checks if the error represents a STATUS_BAD_REQUEST response.
+func IsBadRequest(err error) bool {
+	return core.IsError(err, ErrBadRequest)
+}
+
+// IsUnavailable checks if the error represents a STATUS_UNAVAILABLE response.
+func IsUnavailable(err error) bool {
+	return core.IsError(err, ErrUnavailable)
+}
+
+// IsTimeout checks if the error represents a STATUS_TIMEOUT response.
+// Requests timing out on the client side give [context.DeadlineExceeded]
+// instead.
+func IsTimeout(err error) bool {
+	return core.IsError(err, ErrTimeout)
+}
+
+// IsTooManyRequests checks if the error represents a
+// STATUS_TOO_MANY_REQUESTS response.
+func IsTooManyRequests(err error) bool {
+	return core.IsError(err, ErrTooManyRequests)
+}
+
 // IsNoResponse checks if the error represents no response being received.
 // This error is also used to notify the connection was closed.
 func IsNoResponse(err error) bool {
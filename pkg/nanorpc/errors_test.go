@@ -0,0 +1,166 @@
+package nanorpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"testing"
+
+	"darvaza.org/core"
+)
+
+var _ core.TestCase = asErrorTestCase{}
+
+type asErrorTestCase struct {
+	err    error
+	cause  error
+	name   string
+	msg    string
+	status NanoRPCResponse_Status
+}
+
+func (tc asErrorTestCase) Name() string { return tc.name }
+
+func (tc asErrorTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	e := AsError(tc.err)
+	core.AssertMustNotNil(t, e, "AsError")
+	core.AssertEqual(t, tc.status, e.Status, "status")
+	core.AssertEqual(t, tc.msg, e.Msg, "message")
+	core.AssertEqual(t, tc.status, StatusOf(tc.err), "StatusOf")
+	core.AssertErrorIs(t, e, tc.cause, "cause")
+}
+
+func newAsErrorTestCase(name string, err error, status NanoRPCResponse_Status, msg string) asErrorTestCase {
+	return asErrorTestCase{name: name, err: err, cause: err, status: status, msg: msg}
+}
+
+func asErrorTestCases() []asErrorTestCase {
+	notFound := NotFoundf("sensor %d", 7)
+	return core.S(
+		newAsErrorTestCase("error", notFound, NanoRPCResponse_STATUS_NOT_FOUND, "sensor 7"),
+		asErrorTestCase{
+			name:   "wrapped error",
+			err:    fmt.Errorf("read: %w", notFound),
+			cause:  notFound,
+			status: NanoRPCResponse_STATUS_NOT_FOUND,
+			msg:    "sensor 7",
+		},
+		newAsErrorTestCase("fs not exist", fmt.Errorf("open: %w", fs.ErrNotExist),
+			NanoRPCResponse_STATUS_NOT_FOUND, "open: file does not exist"),
+		newAsErrorTestCase("core not exists", core.ErrNotExists,
+			NanoRPCResponse_STATUS_NOT_FOUND, "does not exist"),
+		newAsErrorTestCase("permission", fs.ErrPermission,
+			NanoRPCResponse_STATUS_NOT_AUTHORIZED, "permission denied"),
+		newAsErrorTestCase("bad request", fmt.Errorf("field: %w", ErrBadRequest),
+			NanoRPCResponse_STATUS_BAD_REQUEST, "field: bad request"),
+		newAsErrorTestCase("deadline", context.DeadlineExceeded,
+			NanoRPCResponse_STATUS_TIMEOUT, "context deadline exceeded"),
+		newAsErrorTestCase("other", io.EOF, NanoRPCResponse_STATUS_INTERNAL_ERROR, "EOF"),
+	)
+}
+
+func TestAsError(t *testing.T) {
+	core.RunTestCases(t, asErrorTestCases())
+
+	core.AssertNil(t, AsError(nil), "nil")
+	core.AssertEqual(t, NanoRPCResponse_STATUS_OK, StatusOf(nil), "nil status")
+}
+
+func TestErrorf(t *testing.T) {
+	err := Errorf(NanoRPCResponse_STATUS_NOT_AUTHORIZED, "token: %w", io.ErrUnexpectedEOF)
+	core.AssertEqual(t, "nanorpc: NOT_AUTHORIZED: token: unexpected EOF", err.Error(), "text")
+	core.AssertErrorIs(t, err, io.ErrUnexpectedEOF, "cause")
+	core.AssertTrue(t, IsNotAuthorized(err), "IsNotAuthorized")
+	core.AssertFalse(t, IsNotFound(err), "IsNotFound")
+
+	err = InternalErrorf("disk full").WithDetail([]byte("sda1"))
+	core.AssertErrorIs(t, err, ErrInternalServerError, "sentinel")
+	core.AssertEqual(t, "sda1", string(err.Detail), "detail")
+
+	var re *ResponseError
+	core.AssertTrue(t, errors.As(error(err), &re), "former name")
+}
+
+func TestResponseAsError_detail(t *testing.T) {
+	err := ResponseAsError(&NanoRPCResponse{
+		ResponseStatus:  NanoRPCResponse_STATUS_NOT_FOUND,
+		ResponseMessage: "no sensor 7",
+		Data:            []byte(`{"sensor":7}`),
+	})
+
+	var e *Error
+	core.AssertMustTrue(t, errors.As(err, &e), "Error")
+	core.AssertEqual(t, NanoRPCResponse_STATUS_NOT_FOUND, e.Status, "status")
+	core.AssertEqual(t, "no sensor 7", e.Msg, "message")
+	core.AssertEqual(t, `{"sensor":7}`, string(e.Detail), "detail")
+	core.AssertTrue(t, IsNotFound(err), "IsNotFound")
+}
+
+var _ core.TestCase = statusErrorTestCase{}
+
+// statusErrorTestCase verifies the error of a response status matches
+// its sentinel and Is helper, and its legacy status.
+type statusErrorTestCase struct {
+	sentinel error
+	is       func(error) bool
+	name     string
+	status   NanoRPCResponse_Status
+	legacy   NanoRPCResponse_Status
+}
+
+func (tc statusErrorTestCase) Name() string { return tc.name }
+
+func (tc statusErrorTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	err := ResponseAsError(&NanoRPCResponse{ResponseStatus: tc.status})
+	core.AssertErrorIs(t, err, tc.sentinel, "sentinel")
+	core.AssertTrue(t, tc.is(err), "Is")
+	core.AssertEqual(t, tc.status, StatusOf(err), "StatusOf")
+	core.AssertEqual(t, tc.status, StatusOf(tc.sentinel), "StatusOf sentinel")
+	core.AssertEqual(t, tc.legacy, LegacyStatus(tc.status), "LegacyStatus")
+}
+
+func newStatusErrorTestCase(status NanoRPCResponse_Status, sentinel error, is func(error) bool,
+	legacy NanoRPCResponse_Status) statusErrorTestCase {
+	return statusErrorTestCase{
+		name:     status.String(),
+		status:   status,
+		sentinel: sentinel,
+		is:       is,
+		legacy:   legacy,
+	}
+}
+
+func statusErrorTestCases() []statusErrorTestCase {
+	internal := NanoRPCResponse_STATUS_INTERNAL_ERROR
+	isInternal := func(err error) bool { return errors.Is(err, ErrInternalServerError) }
+	return []statusErrorTestCase{
+		newStatusErrorTestCase(NanoRPCResponse_STATUS_NOT_FOUND, fs.ErrNotExist, IsNotFound,
+			NanoRPCResponse_STATUS_NOT_FOUND),
+		newStatusErrorTestCase(NanoRPCResponse_STATUS_NOT_AUTHORIZED, fs.ErrPermission, IsNotAuthorized,
+			NanoRPCResponse_STATUS_NOT_AUTHORIZED),
+		newStatusErrorTestCase(internal, ErrInternalServerError, isInternal, internal),
+		newStatusErrorTestCase(NanoRPCResponse_STATUS_BAD_REQUEST, ErrBadRequest, IsBadRequest, internal),
+		newStatusErrorTestCase(NanoRPCResponse_STATUS_UNAVAILABLE, ErrUnavailable, IsUnavailable, internal),
+		newStatusErrorTestCase(NanoRPCResponse_STATUS_TIMEOUT, ErrTimeout, IsTimeout, internal),
+		newStatusErrorTestCase(NanoRPCResponse_STATUS_TOO_MANY_REQUESTS, ErrTooManyRequests,
+			IsTooManyRequests, internal),
+	}
+}
+
+func TestResponseAsError_status(t *testing.T) {
+	core.RunTestCases(t, statusErrorTestCases())
+
+	err := ResponseAsError(&NanoRPCResponse{ResponseStatus: NanoRPCResponse_STATUS_UNAVAILABLE})
+	core.AssertFalse(t, IsTooManyRequests(err), "distinct temporary errors")
+	core.AssertFalse(t, IsTimeout(ErrNoResponse), "distinct timeout errors")
+	core.AssertEqual(t, "nanorpc: BAD_REQUEST: missing field", BadRequestf("missing field").Error(), "text")
+
+	unknown := ResponseAsError(&NanoRPCResponse{ResponseStatus: 42})
+	core.AssertErrorIs(t, unknown, core.ErrUnknown, "unknown")
+}
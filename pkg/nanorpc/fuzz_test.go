@@ -0,0 +1,155 @@
+package nanorpc_test
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/vectors"
+)
+
+// vectorFrames returns the reference frames of the vectors package,
+// malformed ones included, to seed the corpus of fuzz tests.
+func vectorFrames(f *testing.F) [][]byte {
+	f.Helper()
+
+	var out [][]byte
+	add := func(frame []byte, err error) {
+		if err != nil {
+			f.Fatal(err)
+		}
+		out = append(out, frame)
+	}
+
+	for _, v := range vectors.Requests() {
+		add(v.Frame())
+	}
+	for _, v := range vectors.Responses() {
+		add(v.Frame())
+	}
+	for _, v := range vectors.MalformedFrames() {
+		add(v.Frame())
+	}
+	return out
+}
+
+// FuzzDecodeRequest checks decoding arbitrary frames as requests never
+// panics, that [nanorpc.DecodeRequestTo] agrees with
+// [nanorpc.DecodeRequest], and that decoded requests encode back to an
+// equal one.
+func FuzzDecodeRequest(f *testing.F) {
+	for _, frame := range vectorFrames(f) {
+		f.Add(frame)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		req, n, err := nanorpc.DecodeRequest(data)
+
+		out := new(nanorpc.NanoRPCRequest)
+		m, errTo := nanorpc.DecodeRequestTo(data, out)
+		if (err == nil) != (errTo == nil) {
+			t.Fatalf("DecodeRequest: %v, DecodeRequestTo: %v", err, errTo)
+		}
+		if err != nil {
+			return
+		}
+		if n != m || !proto.Equal(req, out) {
+			t.Fatalf("DecodeRequestTo decoded %v (%d bytes), expected %v (%d bytes)", out, m, req, n)
+		}
+
+		_ = nanorpc.DecompressRequest(proto.Clone(req).(*nanorpc.NanoRPCRequest), nanorpc.DefaultMaxMessageSize)
+
+		wrapped, err := nanorpc.EncodeRequest(req, nil)
+		if err != nil {
+			t.Fatalf("EncodeRequest: %v", err)
+		}
+		again, _, err := nanorpc.DecodeRequest(wrapped)
+		if err != nil || !proto.Equal(req, again) {
+			t.Fatalf("round trip: %v, %v", again, err)
+		}
+	})
+}
+
+// FuzzDecodeResponse checks decoding arbitrary frames as responses never
+// panics, and that decoded responses encode back to an equal one.
+func FuzzDecodeResponse(f *testing.F) {
+	for _, frame := range vectorFrames(f) {
+		f.Add(frame)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		res, _, err := nanorpc.DecodeResponse(data)
+		if err != nil {
+			return
+		}
+
+		_ = nanorpc.DecompressResponse(proto.Clone(res).(*nanorpc.NanoRPCResponse), nanorpc.DefaultMaxMessageSize)
+
+		wrapped, err := nanorpc.EncodeResponse(res, nil)
+		if err != nil {
+			t.Fatalf("EncodeResponse: %v", err)
+		}
+		again, _, err := nanorpc.DecodeResponse(wrapped)
+		if err != nil || !proto.Equal(res, again) {
+			t.Fatalf("round trip: %v, %v", again, err)
+		}
+	})
+}
+
+// fuzzCodecs are the codecs FuzzSplit reads streams with.
+var fuzzCodecs = []nanorpc.Codec{
+	nanorpc.LengthPrefixCodec{},
+	nanorpc.COBSCodec{},
+	nanorpc.SLIPCodec{},
+}
+
+// FuzzSplit checks splitting arbitrary streams into frames, and unframing
+// them, never panics for every [nanorpc.Codec], with and without a size
+// limit.
+func FuzzSplit(f *testing.F) {
+	frames := vectorFrames(f)
+	stream := bytes.Join(frames, nil)
+	f.Add(stream, uint16(0))
+	f.Add(stream, uint16(16))
+	for _, c := range fuzzCodecs[1:] {
+		var framed []byte
+		for _, frame := range frames {
+			// as written by sessions using the codec
+			framed, _ = nanorpc.AppendFramed(c, framed, frame)
+		}
+		f.Add(framed, uint16(0))
+		f.Add(framed, uint16(16))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte, maxSize uint16) {
+		for _, c := range fuzzCodecs {
+			scanFuzzFrames(t, c, data, int(maxSize))
+		}
+	})
+}
+
+// scanFuzzFrames splits and unframes a stream as sessions read it.
+func scanFuzzFrames(t *testing.T, c nanorpc.Codec, data []byte, maxSize int) {
+	t.Helper()
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	if maxSize > 0 {
+		scanner.Buffer(nil, c.MaxFrameSize(maxSize))
+	}
+	scanner.Split(c.Split(maxSize))
+
+	for scanner.Scan() {
+		_, err := c.Unframe(scanner.Bytes())
+		switch {
+		case err == nil:
+		case nanorpc.IsLengthPrefixed(c):
+			t.Fatalf("%T: Unframe of a split frame: %v", c, err)
+		case !errors.Is(err, nanorpc.ErrInvalidFrame):
+			t.Fatalf("%T: Unframe: %v", c, err)
+		}
+	}
+}
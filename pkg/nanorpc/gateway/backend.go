@@ -0,0 +1,138 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/client"
+	"protomcp.org/nanorpc/pkg/nanorpc/server"
+)
+
+// Backend answers the requests of a [Gateway], sending body, JSON or
+// empty, as the data of a NanoRPC request to path and waiting for its
+// response until ctx is cancelled.
+type Backend interface {
+	Request(ctx context.Context, path string, body []byte) (*nanorpc.NanoRPCResponse, error)
+}
+
+// BackendFunc is a function implementing [Backend].
+type BackendFunc func(ctx context.Context, path string, body []byte) (*nanorpc.NanoRPCResponse, error)
+
+// Request calls the function.
+func (fn BackendFunc) Request(ctx context.Context, path string, body []byte) (*nanorpc.NanoRPCResponse, error) {
+	return fn(ctx, path, body)
+}
+
+// EncodedRequester sends requests encoded in a given content type, as
+// [client.Client] does.
+type EncodedRequester interface {
+	RequestEncoded(path, contentType string, v any, cb client.RequestCallback) (int32, error)
+}
+
+// ClientBackend returns a [Backend] sending the requests through a
+// NanoRPC client.
+func ClientBackend(c EncodedRequester) Backend {
+	return BackendFunc(func(ctx context.Context, path string, body []byte) (*nanorpc.NanoRPCResponse, error) {
+		if core.IsNil(c) {
+			return nil, core.ErrNilReceiver
+		}
+
+		var v any
+		if len(body) > 0 {
+			v = json.RawMessage(body)
+		}
+
+		ch := make(chan *nanorpc.NanoRPCResponse, 1)
+		_, err := c.RequestEncoded(path, nanorpc.ContentTypeJSON, v, func(_ context.Context, _ int32,
+			res *nanorpc.NanoRPCResponse) error {
+			offer(ch, res)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return await(ctx, ch)
+	})
+}
+
+// HandlerBackend returns a [Backend] passing the requests to the message
+// handler of a server in the same process, without a network round trip.
+// Requests come from a session of ID "gateway" which hasn't authenticated,
+// so paths requiring it, see [server.RequireAuth], refuse them.
+func HandlerBackend(h server.MessageHandler) Backend {
+	var lastID atomic.Int32
+	return BackendFunc(func(ctx context.Context, path string, body []byte) (*nanorpc.NanoRPCResponse, error) {
+		if core.IsNil(h) {
+			return nil, core.ErrNilReceiver
+		}
+
+		req := &nanorpc.NanoRPCRequest{
+			RequestId:   lastID.Add(1),
+			RequestType: nanorpc.NanoRPCRequest_TYPE_REQUEST,
+			PathOneof:   nanorpc.GetPathOneOfString(path),
+			Metadata:    nanorpc.NewMetadata(nanorpc.MetadataContentType, nanorpc.ContentTypeJSON),
+			Data:        body,
+		}
+
+		session := &session{responses: make(chan *nanorpc.NanoRPCResponse, 1)}
+		if err := h.HandleMessage(ctx, session, req); err != nil {
+			select {
+			case res := <-session.responses:
+				return res, nil
+			default:
+				return nil, err
+			}
+		}
+		return await(ctx, session.responses)
+	})
+}
+
+var _ server.Session = (*session)(nil)
+
+// session is the [server.Session] of the requests of a [HandlerBackend],
+// taking their response.
+type session struct {
+	responses chan *nanorpc.NanoRPCResponse
+}
+
+func (*session) ID() string                   { return "gateway" }
+func (*session) RemoteAddr() string           { return "gateway" }
+func (*session) Handle(context.Context) error { return core.ErrNotImplemented }
+func (*session) Close() error                 { return nil }
+
+// SendResponse takes the response of the request, ignoring the chunks
+// streamed before it.
+func (s *session) SendResponse(req *nanorpc.NanoRPCRequest, res *nanorpc.NanoRPCResponse) error {
+	if req != nil && res.RequestId == 0 {
+		res.RequestId = req.RequestId
+	}
+	offer(s.responses, res)
+	return nil
+}
+
+// offer passes on the first response of a request, ignoring streamed
+// chunks.
+func offer(ch chan<- *nanorpc.NanoRPCResponse, res *nanorpc.NanoRPCResponse) {
+	if res.GetResponseType() == nanorpc.NanoRPCResponse_TYPE_UPDATE {
+		return
+	}
+
+	select {
+	case ch <- res:
+	default:
+	}
+}
+
+// await waits for the response of a request.
+func await(ctx context.Context, ch <-chan *nanorpc.NanoRPCResponse) (*nanorpc.NanoRPCResponse, error) {
+	select {
+	case res := <-ch:
+		return res, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
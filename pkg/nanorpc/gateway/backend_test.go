@@ -0,0 +1,92 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc/client"
+	"protomcp.org/nanorpc/pkg/nanorpc/nanorpctest"
+	"protomcp.org/nanorpc/pkg/nanorpc/server"
+)
+
+const testTimeout = 2 * time.Second
+
+// startTestServer serves a message handler, returning a client connected
+// to it.
+func startTestServer(ctx context.Context, t *testing.T, handler *server.DefaultMessageHandler) *client.Client {
+	t.Helper()
+
+	ln := nanorpctest.NewInprocListener("gateway")
+	srv := server.NewDefaultServer(ln, handler, nil)
+	go func() { _ = srv.Serve(context.Background()) }()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+		defer cancel()
+		_ = srv.Shutdown(ctx)
+	})
+
+	c, err := (&client.Config{Context: ctx, Remote: "127.0.0.1:1"}).New()
+	core.AssertMustNoError(t, err, "client")
+	core.AssertMustNoError(t, ln.Attach(ctx, c), "Attach")
+	core.AssertMustNoError(t, c.WaitConnected(ctx), "WaitConnected")
+	return c
+}
+
+// TestClientBackend verifies requests reach a server through a client.
+func TestClientBackend(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	c := startTestServer(ctx, t, newTestHandler(t))
+	gw := newTestGateway(t, Config{Backend: ClientBackend(c)})
+	rec := post(gw, http.MethodPost, "/api/echo", `{"on": true}`)
+	core.AssertEqual(t, http.StatusOK, rec.Code, "code")
+	core.AssertEqual(t, `{"on":true}`, strings.TrimSpace(rec.Body.String()), "body")
+
+	rec = post(gw, http.MethodPost, "/api/missing", "")
+	core.AssertEqual(t, http.StatusNotFound, rec.Code, "not found")
+}
+
+// TestHandlerBackend_cancel verifies requests left unanswered end with
+// their context.
+func TestHandlerBackend_cancel(t *testing.T) {
+	handler := server.NewDefaultMessageHandler(nil)
+	err := handler.RegisterHandler("/never", server.RequestHandlerFunc(
+		func(context.Context, *server.RequestContext) error { return nil }))
+	core.AssertMustNoError(t, err, "register")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = HandlerBackend(handler).Request(ctx, "/never", nil)
+	core.AssertErrorIs(t, err, context.DeadlineExceeded, "Request")
+}
+
+// TestClientSource verifies subscriptions through a client receive the
+// updates published on the server until cancelled.
+func TestClientSource(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	handler := server.NewDefaultMessageHandler(nil)
+	c := startTestServer(ctx, t, handler)
+
+	updates := make(chan string, 4)
+	stop, err := ClientSource(c).Subscribe(ctx, "/sensors/temp", func(data []byte) {
+		updates <- string(data)
+	})
+	core.AssertMustNoError(t, err, "Subscribe")
+
+	core.AssertMustNoError(t, handler.Publish("/sensors/temp", []byte("21.5")), "Publish")
+	select {
+	case data := <-updates:
+		core.AssertEqual(t, "21.5", data, "update")
+	case <-ctx.Done():
+		t.Fatal("no update")
+	}
+	stop()
+}
@@ -0,0 +1,37 @@
+// Package gateway exposes NanoRPC request paths over HTTP, so web
+// backends can call device RPCs with plain JSON, without embedding a
+// NanoRPC client.
+//
+// A [Gateway] is an [http.Handler] answering POST /api/{path} with the
+// response of the NanoRPC request to /{path}, carrying the JSON body of
+// the HTTP request as data. Requests are answered by a [Backend]: a
+// connected client, see [ClientBackend], or the message handler of a
+// server in the same process, see [HandlerBackend]. Response statuses
+// become HTTP status codes, see [HTTPStatus].
+//
+//	gw, err := (&gateway.Config{
+//		Backend: gateway.ClientBackend(c),
+//	}).New()
+//	if err == nil {
+//		http.Handle(gateway.DefaultPrefix, gw)
+//	}
+//
+// Requests carry the application/json content type in their metadata,
+// so handlers decoding them with [server.RequestContext.UnmarshalRequest]
+// and answering with [server.RequestContext.SendEncoded] speak JSON to
+// the gateway and protobuf to everyone else.
+//
+// An [EventStream] is an [http.Handler] answering GET /events/{path} with
+// a text/event-stream of the updates of a subscription to /{path}, so web
+// UIs watch device paths live with an EventSource, without WebSocket or
+// protobuf decoding. Subscriptions are made by a [Source]: a connected
+// client, see [ClientSource], or the event bus of a server in the same
+// process, see [BusSource].
+//
+//	es, err := (&gateway.StreamConfig{
+//		Source: gateway.ClientSource(c),
+//	}).New()
+//	if err == nil {
+//		http.Handle(gateway.DefaultStreamPrefix, es)
+//	}
+package gateway
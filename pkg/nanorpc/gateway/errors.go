@@ -0,0 +1,17 @@
+package gateway
+
+import "darvaza.org/core"
+
+// Invalid-argument sentinels for the gateway package. Each wraps
+// [core.ErrInvalid]. Call sites add dynamic context by wrapping the
+// sentinel, e.g. core.QuietWrap(ErrInvalidBody, "%d bytes", n).
+var (
+	// ErrMissingBackend indicates a [Config] without backend.
+	ErrMissingBackend = core.QuietWrap(core.ErrInvalid, "backend missing")
+
+	// ErrMissingSource indicates a [StreamConfig] without source.
+	ErrMissingSource = core.QuietWrap(core.ErrInvalid, "source missing")
+
+	// ErrInvalidBody indicates a request body that isn't JSON.
+	ErrInvalidBody = core.QuietWrap(core.ErrInvalid, "request body isn't JSON")
+)
@@ -0,0 +1,246 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"darvaza.org/core"
+	"darvaza.org/slog"
+	"darvaza.org/slog/handlers/discard"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/utils"
+)
+
+const (
+	// DefaultPrefix is the URL path prefix of the requests a [Gateway]
+	// answers when none is given.
+	DefaultPrefix = "/api/"
+
+	// DefaultMaxBodySize is the size limit of request bodies when none
+	// is given.
+	DefaultMaxBodySize = 1 << 20
+)
+
+// Config describes a [Gateway].
+//
+// Backend answers the requests. Prefix is the URL path prefix mapped
+// to the root of the NanoRPC paths, [DefaultPrefix] if empty, and
+// MaxBodySize limits the request bodies, [DefaultMaxBodySize] if not
+// positive. Logger defaults to discarding.
+type Config struct {
+	Backend     Backend
+	Logger      slog.Logger
+	Prefix      string
+	MaxBodySize int64
+}
+
+// New creates a [Gateway] from the [Config]. A missing Backend fails
+// with [ErrMissingBackend].
+func (cfg *Config) New() (*Gateway, error) {
+	switch {
+	case cfg == nil:
+		return nil, core.ErrNilReceiver
+	case core.IsNil(cfg.Backend):
+		return nil, ErrMissingBackend
+	}
+
+	logger := utils.WithComponent(cfg.Logger, utils.ComponentGateway)
+	if logger == nil {
+		logger = discard.New()
+	}
+
+	maxBodySize := cfg.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultMaxBodySize
+	}
+
+	return &Gateway{
+		backend:     cfg.Backend,
+		logger:      logger,
+		prefix:      cleanPrefix(cfg.Prefix, DefaultPrefix),
+		maxBodySize: maxBodySize,
+	}, nil
+}
+
+// cleanPrefix returns prefix beginning and ending with a slash, or def
+// if empty.
+func cleanPrefix(prefix, def string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return def
+	}
+	return "/" + prefix + "/"
+}
+
+var _ http.Handler = (*Gateway)(nil)
+
+// Gateway is an [http.Handler] answering POST {prefix}{path} with the
+// response of the NanoRPC request to /{path}, see [Config].
+//
+// The request body, if any, must be JSON, and is the data of the NanoRPC
+// request. Successful responses are answered 200 OK with their data, of
+// the content type their metadata declares, or JSON if it's valid JSON.
+// Failed ones are answered with the code [HTTPStatus] gives their status
+// and an [ErrorBody]. Other methods are answered 405 Method Not Allowed,
+// and paths out of the prefix 404 Not Found.
+type Gateway struct {
+	backend     Backend
+	logger      slog.Logger
+	prefix      string
+	maxBodySize int64
+}
+
+// ErrorBody is the JSON body of the failed requests of a [Gateway]. Status
+// is the name of the NanoRPC status without its STATUS_ prefix, e.g.
+// NOT_FOUND, and Detail the data of the response when it's JSON.
+type ErrorBody struct {
+	Status  string          `json:"status"`
+	Message string          `json:"message,omitempty"`
+	Detail  json.RawMessage `json:"detail,omitempty"`
+}
+
+// ServeHTTP answers an HTTP request with the response of the NanoRPC
+// request it maps to.
+func (gw *Gateway) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if gw == nil {
+		writeError(rw, nanorpc.NewError(nanorpc.NanoRPCResponse_STATUS_UNAVAILABLE, "gateway unavailable"))
+		return
+	}
+
+	path, ok := checkRequest(rw, req, gw.prefix, http.MethodPost)
+	if !ok {
+		return
+	}
+
+	body, code, err := gw.readBody(rw, req)
+	if err != nil {
+		writeErrorCode(rw, code, &ErrorBody{Status: "BAD_REQUEST", Message: err.Error()})
+		return
+	}
+
+	res, err := gw.backend.Request(req.Context(), path, body)
+	if err != nil {
+		gw.logWarn(err, path, "failed to request")
+		writeError(rw, nanorpc.AsError(err))
+		return
+	}
+	writeResponse(rw, res)
+}
+
+// checkRequest returns the NanoRPC path of an HTTP request within the
+// prefix, answering 404 Not Found those out of it and 405 Method Not
+// Allowed those of other methods.
+func checkRequest(rw http.ResponseWriter, req *http.Request, prefix, method string) (string, bool) {
+	rest, ok := strings.CutPrefix(req.URL.Path, prefix)
+	switch {
+	case !ok, rest == "":
+		writeError(rw, nanorpc.NewError(nanorpc.NanoRPCResponse_STATUS_NOT_FOUND, "not found"))
+		return "", false
+	case req.Method != method:
+		rw.Header().Set("Allow", method)
+		writeErrorCode(rw, http.StatusMethodNotAllowed, &ErrorBody{
+			Status:  "BAD_REQUEST",
+			Message: "method not allowed",
+		})
+		return "", false
+	default:
+		return "/" + rest, true
+	}
+}
+
+// readBody reads the body of a request, failing with the HTTP status code
+// to answer if too large or not JSON.
+func (gw *Gateway) readBody(rw http.ResponseWriter, req *http.Request) ([]byte, int, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(rw, req.Body, gw.maxBodySize))
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		return nil, http.StatusRequestEntityTooLarge, err
+	case err != nil:
+		return nil, http.StatusBadRequest, err
+	case len(body) > 0 && !json.Valid(body):
+		return nil, http.StatusBadRequest, ErrInvalidBody
+	default:
+		return body, http.StatusOK, nil
+	}
+}
+
+func (gw *Gateway) logWarn(err error, path, msg string) {
+	if l, ok := gw.logger.Warn().WithEnabled(); ok {
+		l = utils.WithError(l, err)
+		l.WithField(utils.FieldPath, utils.LogPath(path)).Print(msg)
+	}
+}
+
+// writeResponse answers with a NanoRPC response.
+func writeResponse(rw http.ResponseWriter, res *nanorpc.NanoRPCResponse) {
+	if err := nanorpc.ResponseAsError(res); err != nil {
+		writeError(rw, nanorpc.AsError(err))
+		return
+	}
+
+	if len(res.Data) > 0 {
+		rw.Header().Set("Content-Type", responseContentType(res))
+	}
+	rw.WriteHeader(http.StatusOK)
+	_, _ = rw.Write(res.Data)
+}
+
+// responseContentType returns the content type of the data of a response,
+// as declared by its metadata, or JSON when it declares none and the data
+// is valid JSON.
+func responseContentType(res *nanorpc.NanoRPCResponse) string {
+	ct := nanorpc.Metadata(res.Metadata).ContentType()
+	if ct == nanorpc.ContentTypeProtobuf && json.Valid(res.Data) {
+		return nanorpc.ContentTypeJSON
+	}
+	return ct
+}
+
+// writeError answers with the [ErrorBody] of a NanoRPC error.
+func writeError(rw http.ResponseWriter, e *nanorpc.Error) {
+	body := &ErrorBody{
+		Status:  strings.TrimPrefix(e.Status.String(), "STATUS_"),
+		Message: e.Msg,
+	}
+	if json.Valid(e.Detail) {
+		body.Detail = e.Detail
+	}
+	writeErrorCode(rw, HTTPStatus(e.Status), body)
+}
+
+func writeErrorCode(rw http.ResponseWriter, code int, body *ErrorBody) {
+	rw.Header().Set("Content-Type", nanorpc.ContentTypeJSON)
+	rw.WriteHeader(code)
+	_ = json.NewEncoder(rw).Encode(body)
+}
+
+// HTTPStatus returns the HTTP status code of a NanoRPC status. Statuses
+// a server shouldn't answer, STATUS_UNSPECIFIED and unknown ones, are
+// 502 Bad Gateway.
+func HTTPStatus(status nanorpc.NanoRPCResponse_Status) int {
+	switch status {
+	case nanorpc.NanoRPCResponse_STATUS_OK:
+		return http.StatusOK
+	case nanorpc.NanoRPCResponse_STATUS_NOT_FOUND:
+		return http.StatusNotFound
+	case nanorpc.NanoRPCResponse_STATUS_NOT_AUTHORIZED:
+		return http.StatusForbidden
+	case nanorpc.NanoRPCResponse_STATUS_INTERNAL_ERROR:
+		return http.StatusInternalServerError
+	case nanorpc.NanoRPCResponse_STATUS_BAD_REQUEST:
+		return http.StatusBadRequest
+	case nanorpc.NanoRPCResponse_STATUS_UNAVAILABLE:
+		return http.StatusServiceUnavailable
+	case nanorpc.NanoRPCResponse_STATUS_TIMEOUT:
+		return http.StatusGatewayTimeout
+	case nanorpc.NanoRPCResponse_STATUS_TOO_MANY_REQUESTS:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusBadGateway
+	}
+}
@@ -0,0 +1,187 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/server"
+)
+
+// newTestHandler returns a message handler with paths echoing their
+// request, failing and answering raw data.
+func newTestHandler(t *testing.T) *server.DefaultMessageHandler {
+	t.Helper()
+
+	handler := server.NewDefaultMessageHandler(nil)
+	for path, fn := range map[string]server.RequestHandlerFunc{
+		"/echo": func(_ context.Context, rc *server.RequestContext) error {
+			var v map[string]any
+			if err := rc.UnmarshalRequest(&v); err != nil {
+				return rc.SendBadRequest(err.Error())
+			}
+			return rc.SendEncoded(v)
+		},
+		"/busy": func(_ context.Context, rc *server.RequestContext) error {
+			return rc.SendError(nanorpc.NanoRPCResponse_STATUS_TOO_MANY_REQUESTS, "slow down")
+		},
+		"/raw": func(_ context.Context, rc *server.RequestContext) error {
+			return rc.SendOK([]byte{0xff, 0x00})
+		},
+		"/empty": func(_ context.Context, rc *server.RequestContext) error {
+			return rc.SendOK(nil)
+		},
+	} {
+		core.AssertMustNoError(t, handler.RegisterHandler(path, fn), "register %s", path)
+	}
+	return handler
+}
+
+func newTestGateway(t *testing.T, cfg Config) *Gateway {
+	t.Helper()
+
+	if cfg.Backend == nil {
+		cfg.Backend = HandlerBackend(newTestHandler(t))
+	}
+	gw, err := cfg.New()
+	core.AssertMustNoError(t, err, "New")
+	return gw
+}
+
+// post serves a request, returning the recorded answer.
+func post(gw http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	gw.ServeHTTP(rec, req)
+	return rec
+}
+
+var _ core.TestCase = serveTestCase{}
+
+type serveTestCase struct {
+	name        string
+	method      string
+	target      string
+	body        string
+	contentType string
+	want        string
+	code        int
+}
+
+func (tc serveTestCase) Name() string { return tc.name }
+
+func (tc serveTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	gw := newTestGateway(t, Config{MaxBodySize: 32})
+	rec := post(gw, tc.method, tc.target, tc.body)
+	core.AssertEqual(t, tc.code, rec.Code, "code")
+	core.AssertEqual(t, tc.contentType, rec.Header().Get("Content-Type"), "content type")
+	if tc.want != "" {
+		core.AssertEqual(t, tc.want, strings.TrimSpace(rec.Body.String()), "body")
+	}
+}
+
+func newServeTestCase(name, target, body string, code int, contentType, want string) serveTestCase {
+	return serveTestCase{
+		name:        name,
+		method:      http.MethodPost,
+		target:      target,
+		body:        body,
+		code:        code,
+		contentType: contentType,
+		want:        want,
+	}
+}
+
+func serveTestCases() []serveTestCase {
+	const ct = nanorpc.ContentTypeJSON
+	return core.S(
+		newServeTestCase("echo", "/api/echo", `{"x": 1}`, http.StatusOK, ct, `{"x":1}`),
+		newServeTestCase("raw", "/api/raw", "", http.StatusOK, nanorpc.ContentTypeProtobuf, "\xff\x00"),
+		newServeTestCase("empty", "/api/empty", "", http.StatusOK, "", ""),
+		newServeTestCase("not found", "/api/missing", "", http.StatusNotFound, ct, ""),
+		newServeTestCase("out of prefix", "/echo", "", http.StatusNotFound, ct,
+			`{"status":"NOT_FOUND","message":"not found"}`),
+		newServeTestCase("prefix only", "/api/", "", http.StatusNotFound, ct, ""),
+		newServeTestCase("bad request", "/api/echo", "", http.StatusBadRequest, ct, ""),
+		newServeTestCase("too many", "/api/busy", "", http.StatusTooManyRequests, ct,
+			`{"status":"TOO_MANY_REQUESTS","message":"slow down"}`),
+		newServeTestCase("not JSON", "/api/echo", "x", http.StatusBadRequest, ct, ""),
+		newServeTestCase("too large", "/api/echo", `{"x": "`+strings.Repeat("x", 32)+`"}`,
+			http.StatusRequestEntityTooLarge, ct, ""),
+		serveTestCase{name: "method", method: http.MethodGet, target: "/api/echo",
+			code: http.StatusMethodNotAllowed, contentType: ct},
+	)
+}
+
+func TestGateway_ServeHTTP(t *testing.T) {
+	core.RunTestCases(t, serveTestCases())
+}
+
+func TestGateway_prefix(t *testing.T) {
+	var paths []string
+	backend := BackendFunc(func(_ context.Context, path string, _ []byte) (*nanorpc.NanoRPCResponse, error) {
+		paths = append(paths, path)
+		return &nanorpc.NanoRPCResponse{ResponseStatus: nanorpc.NanoRPCResponse_STATUS_OK}, nil
+	})
+
+	gw := newTestGateway(t, Config{Backend: backend, Prefix: "rpc"})
+	for _, target := range core.S("/rpc/devices/42/reboot", "/api/echo", "/rpcx") {
+		post(gw, http.MethodPost, target, "")
+	}
+	core.AssertSliceEqual(t, core.S("/devices/42/reboot"), paths, "paths")
+}
+
+// TestGateway_backendError verifies failures of the backend are answered
+// with their status.
+func TestGateway_backendError(t *testing.T) {
+	backend := BackendFunc(func(context.Context, string, []byte) (*nanorpc.NanoRPCResponse, error) {
+		return nil, context.DeadlineExceeded
+	})
+
+	gw := newTestGateway(t, Config{Backend: backend})
+	rec := post(gw, http.MethodPost, "/api/slow", "")
+	core.AssertEqual(t, http.StatusGatewayTimeout, rec.Code, "code")
+
+	var body ErrorBody
+	core.AssertMustNoError(t, json.Unmarshal(rec.Body.Bytes(), &body), "body")
+	core.AssertEqual(t, "TIMEOUT", body.Status, "status")
+}
+
+func TestHTTPStatus(t *testing.T) {
+	for status, want := range map[nanorpc.NanoRPCResponse_Status]int{
+		nanorpc.NanoRPCResponse_STATUS_OK:                http.StatusOK,
+		nanorpc.NanoRPCResponse_STATUS_NOT_FOUND:         http.StatusNotFound,
+		nanorpc.NanoRPCResponse_STATUS_NOT_AUTHORIZED:    http.StatusForbidden,
+		nanorpc.NanoRPCResponse_STATUS_INTERNAL_ERROR:    http.StatusInternalServerError,
+		nanorpc.NanoRPCResponse_STATUS_BAD_REQUEST:       http.StatusBadRequest,
+		nanorpc.NanoRPCResponse_STATUS_UNAVAILABLE:       http.StatusServiceUnavailable,
+		nanorpc.NanoRPCResponse_STATUS_TIMEOUT:           http.StatusGatewayTimeout,
+		nanorpc.NanoRPCResponse_STATUS_TOO_MANY_REQUESTS: http.StatusTooManyRequests,
+		nanorpc.NanoRPCResponse_STATUS_UNSPECIFIED:       http.StatusBadGateway,
+		nanorpc.NanoRPCResponse_Status(99):               http.StatusBadGateway,
+	} {
+		core.AssertEqual(t, want, HTTPStatus(status), "%v", status)
+	}
+}
+
+func TestConfig_New(t *testing.T) {
+	var cfg *Config
+	_, err := cfg.New()
+	core.AssertErrorIs(t, err, core.ErrNilReceiver, "nil")
+	_, err = (&Config{}).New()
+	core.AssertErrorIs(t, err, ErrMissingBackend, "backend")
+}
+
+func TestGateway_nilReceiver(t *testing.T) {
+	var gw *Gateway
+	rec := post(gw, http.MethodPost, "/api/echo", "")
+	core.AssertEqual(t, http.StatusServiceUnavailable, rec.Code, "code")
+}
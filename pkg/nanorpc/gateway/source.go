@@ -0,0 +1,132 @@
+package gateway
+
+import (
+	"context"
+	"sync/atomic"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/client"
+	"protomcp.org/nanorpc/pkg/nanorpc/server"
+)
+
+// UpdateFunc receives the data of the updates of a subscription.
+type UpdateFunc func(data []byte)
+
+// Source feeds an [EventStream] the updates of a path. Subscribe returns
+// once the subscription is accepted, with the function ending it, or
+// fails with the reason it was refused or ctx was cancelled first. fn
+// must not block.
+type Source interface {
+	Subscribe(ctx context.Context, path string, fn UpdateFunc) (func(), error)
+}
+
+// SourceFunc is a function implementing [Source].
+type SourceFunc func(ctx context.Context, path string, fn UpdateFunc) (func(), error)
+
+// Subscribe calls the function.
+func (fn SourceFunc) Subscribe(ctx context.Context, path string, update UpdateFunc) (func(), error) {
+	return fn(ctx, path, update)
+}
+
+// RawSubscriber subscribes to paths without encoding the request, and
+// ends the subscriptions, as [client.Client] does.
+type RawSubscriber interface {
+	SubscribeRaw(path string, data []byte, cb client.RequestCallback) (int32, error)
+	Unsubscribe(path string, requestID int32, cb client.RequestCallback) error
+}
+
+// ClientSource returns a [Source] subscribing through a NanoRPC client.
+func ClientSource(c RawSubscriber) Source {
+	return SourceFunc(func(ctx context.Context, path string, fn UpdateFunc) (func(), error) {
+		if core.IsNil(c) {
+			return nil, core.ErrNilReceiver
+		}
+
+		sub := &clientSubscription{
+			client: c,
+			path:   path,
+			fn:     fn,
+			acks:   make(chan *nanorpc.NanoRPCResponse, 1),
+		}
+		if _, err := c.SubscribeRaw(path, nil, sub.callback); err != nil {
+			return nil, err
+		}
+		return sub.await(ctx)
+	})
+}
+
+// Subscription states of a clientSubscription.
+const (
+	subscriptionPending int32 = iota
+	subscriptionAccepted
+	subscriptionAbandoned
+)
+
+// clientSubscription is a subscription of a [ClientSource], ended when
+// accepted after its request was abandoned.
+type clientSubscription struct {
+	client RawSubscriber
+	fn     UpdateFunc
+	acks   chan *nanorpc.NanoRPCResponse
+	path   string
+	id     atomic.Int32
+	state  atomic.Int32
+}
+
+func (sub *clientSubscription) callback(_ context.Context, id int32, res *nanorpc.NanoRPCResponse) error {
+	switch {
+	case res.GetResponseType() == nanorpc.NanoRPCResponse_TYPE_UPDATE:
+		sub.fn(res.Data)
+	case nanorpc.ResponseAsError(res) != nil:
+		offer(sub.acks, res)
+	default:
+		sub.id.Store(id)
+		if !sub.state.CompareAndSwap(subscriptionPending, subscriptionAccepted) {
+			// abandoned
+			go sub.cancel()
+		}
+		offer(sub.acks, res)
+	}
+	return nil
+}
+
+// await waits for the subscription to be accepted or refused.
+func (sub *clientSubscription) await(ctx context.Context) (func(), error) {
+	res, err := await(ctx, sub.acks)
+	switch {
+	case err == nil:
+		if err := nanorpc.ResponseAsError(res); err != nil {
+			return nil, err
+		}
+		return sub.cancel, nil
+	case sub.state.CompareAndSwap(subscriptionPending, subscriptionAbandoned):
+		return nil, err
+	default:
+		// accepted meanwhile
+		sub.cancel()
+		return nil, err
+	}
+}
+
+func (sub *clientSubscription) cancel() {
+	_ = sub.client.Unsubscribe(sub.path, sub.id.Load(), nil)
+}
+
+// BusSource returns a [Source] subscribing to the updates published on an
+// event bus, see [server.WithEventBus]. Paths are subscription patterns,
+// see [server.EventBus].
+func BusSource(bus server.EventBus) Source {
+	return SourceFunc(func(_ context.Context, path string, fn UpdateFunc) (func(), error) {
+		if core.IsNil(bus) {
+			return nil, core.ErrNilReceiver
+		}
+
+		id, err := bus.Subscribe(path, func(_ string, data []byte) { fn(data) })
+		if err != nil {
+			return nil, err
+		}
+		return func() { _ = bus.Unsubscribe(id) }, nil
+	})
+}
@@ -0,0 +1,232 @@
+package gateway
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"darvaza.org/core"
+	"darvaza.org/slog"
+	"darvaza.org/slog/handlers/discard"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/utils"
+)
+
+const (
+	// DefaultStreamPrefix is the URL path prefix of the requests an
+	// [EventStream] answers when none is given.
+	DefaultStreamPrefix = "/events/"
+
+	// DefaultKeepAlive is how often idle event streams send a comment,
+	// keeping proxies from closing them, when not given.
+	DefaultKeepAlive = 15 * time.Second
+
+	// DefaultStreamBuffer is how many updates an event stream holds for
+	// a slow HTTP client when not given.
+	DefaultStreamBuffer = 64
+)
+
+// ContentTypeEventStream is the media type of server-sent events.
+const ContentTypeEventStream = "text/event-stream"
+
+// StreamConfig describes an [EventStream].
+//
+// Source subscribes to the paths. Prefix is the URL path prefix mapped
+// to the root of the NanoRPC paths, [DefaultStreamPrefix] if empty.
+// KeepAlive is how often idle streams send a comment, [DefaultKeepAlive]
+// if not positive, and Buffer how many updates are held for a slow HTTP
+// client before its stream is closed, [DefaultStreamBuffer] if not
+// positive. Logger defaults to discarding.
+type StreamConfig struct {
+	Source    Source
+	Logger    slog.Logger
+	Prefix    string
+	KeepAlive time.Duration
+	Buffer    int
+}
+
+// New creates an [EventStream] from the [StreamConfig]. A missing Source
+// fails with [ErrMissingSource].
+func (cfg *StreamConfig) New() (*EventStream, error) {
+	switch {
+	case cfg == nil:
+		return nil, core.ErrNilReceiver
+	case core.IsNil(cfg.Source):
+		return nil, ErrMissingSource
+	}
+
+	logger := utils.WithComponent(cfg.Logger, utils.ComponentGateway)
+	if logger == nil {
+		logger = discard.New()
+	}
+
+	es := &EventStream{
+		source:    cfg.Source,
+		logger:    logger,
+		prefix:    cleanPrefix(cfg.Prefix, DefaultStreamPrefix),
+		keepAlive: cfg.KeepAlive,
+		buffer:    cfg.Buffer,
+	}
+	if es.keepAlive <= 0 {
+		es.keepAlive = DefaultKeepAlive
+	}
+	if es.buffer <= 0 {
+		es.buffer = DefaultStreamBuffer
+	}
+	return es, nil
+}
+
+var _ http.Handler = (*EventStream)(nil)
+
+// EventStream is an [http.Handler] answering GET {prefix}{path} with a
+// text/event-stream of the updates of a subscription to /{path}, see
+// [StreamConfig], for browsers to watch with an EventSource.
+//
+// Every update is a message event whose data is that of the update, or,
+// when not UTF-8, a binary event whose data is the update encoded in
+// base64. Subscriptions refused are answered as a [Gateway] answers
+// failed requests, and streams end when the HTTP client goes away or
+// falls behind by more updates than buffered, reconnecting on its own.
+type EventStream struct {
+	source    Source
+	logger    slog.Logger
+	prefix    string
+	keepAlive time.Duration
+	buffer    int
+}
+
+// ServeHTTP streams the updates of the path an HTTP request maps to.
+func (es *EventStream) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if es == nil {
+		writeError(rw, nanorpc.NewError(nanorpc.NanoRPCResponse_STATUS_UNAVAILABLE, "gateway unavailable"))
+		return
+	}
+
+	path, ok := checkRequest(rw, req, es.prefix, http.MethodGet)
+	if !ok {
+		return
+	}
+
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		writeError(rw, nanorpc.NewError(nanorpc.NanoRPCResponse_STATUS_INTERNAL_ERROR, "streaming unsupported"))
+		return
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+
+	q := newStreamQueue(es.buffer)
+	stop, err := es.source.Subscribe(ctx, path, q.push)
+	if err != nil {
+		es.logWarn(err, path, "failed to subscribe")
+		writeError(rw, nanorpc.AsError(err))
+		return
+	}
+	defer stop()
+
+	es.stream(ctx, rw, flusher, q)
+	if q.overflowed() {
+		es.logWarn(nil, path, "event stream fell behind")
+	}
+}
+
+// stream writes the updates of a subscription until the HTTP client goes
+// away or falls behind.
+func (es *EventStream) stream(ctx context.Context, rw http.ResponseWriter, flusher http.Flusher, q *streamQueue) {
+	rw.Header().Set("Content-Type", ContentTypeEventStream)
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(es.keepAlive)
+	defer ticker.Stop()
+
+	for {
+		var err error
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.overflow:
+			return
+		case <-ticker.C:
+			_, err = io.WriteString(rw, ": keep-alive\n\n")
+		case data := <-q.updates:
+			_, err = io.WriteString(rw, formatEvent(data))
+		}
+		if err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+func (es *EventStream) logWarn(err error, path, msg string) {
+	if l, ok := es.logger.Warn().WithEnabled(); ok {
+		if err != nil {
+			l = utils.WithError(l, err)
+		}
+		l.WithField(utils.FieldPath, utils.LogPath(path)).Print(msg)
+	}
+}
+
+var eventLines = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// formatEvent returns the server-sent event of an update.
+func formatEvent(data []byte) string {
+	var buf strings.Builder
+	if !utf8.Valid(data) {
+		buf.WriteString("event: binary\ndata: ")
+		buf.WriteString(base64.StdEncoding.EncodeToString(data))
+		buf.WriteString("\n\n")
+		return buf.String()
+	}
+
+	for _, line := range strings.Split(eventLines.Replace(string(data)), "\n") {
+		buf.WriteString("data: ")
+		buf.WriteString(line)
+		buf.WriteString("\n")
+	}
+	buf.WriteString("\n")
+	return buf.String()
+}
+
+// streamQueue holds the updates of an [EventStream] until written,
+// closing overflow when full.
+type streamQueue struct {
+	updates  chan []byte
+	overflow chan struct{}
+	once     sync.Once
+}
+
+func newStreamQueue(size int) *streamQueue {
+	return &streamQueue{
+		updates:  make(chan []byte, size),
+		overflow: make(chan struct{}),
+	}
+}
+
+// push queues an update without blocking the source.
+func (q *streamQueue) push(data []byte) {
+	select {
+	case q.updates <- slices.Clone(data):
+	default:
+		q.once.Do(func() { close(q.overflow) })
+	}
+}
+
+func (q *streamQueue) overflowed() bool {
+	select {
+	case <-q.overflow:
+		return true
+	default:
+		return false
+	}
+}
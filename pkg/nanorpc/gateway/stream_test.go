@@ -0,0 +1,140 @@
+package gateway
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/server"
+)
+
+var _ core.TestCase = formatEventTestCase{}
+
+type formatEventTestCase struct {
+	name string
+	data string
+	want string
+}
+
+func (tc formatEventTestCase) Name() string { return tc.name }
+
+func (tc formatEventTestCase) Test(t *testing.T) {
+	t.Helper()
+	core.AssertEqual(t, tc.want, formatEvent([]byte(tc.data)), "event")
+}
+
+func newFormatEventTestCase(name, data, want string) formatEventTestCase {
+	return formatEventTestCase{name: name, data: data, want: want}
+}
+
+func formatEventTestCases() []formatEventTestCase {
+	return core.S(
+		newFormatEventTestCase("json", `{"on":true}`, "data: {\"on\":true}\n\n"),
+		newFormatEventTestCase("lines", "a\nb\r\nc\rd", "data: a\ndata: b\ndata: c\ndata: d\n\n"),
+		newFormatEventTestCase("empty", "", "data: \n\n"),
+		newFormatEventTestCase("binary", "\xff\x00", "event: binary\ndata: /wA=\n\n"),
+	)
+}
+
+func TestFormatEvent(t *testing.T) {
+	core.RunTestCases(t, formatEventTestCases())
+}
+
+func newTestStream(t *testing.T, cfg StreamConfig) *EventStream {
+	t.Helper()
+
+	es, err := cfg.New()
+	core.AssertMustNoError(t, err, "New")
+	return es
+}
+
+// TestEventStream verifies updates published on the bus reach an HTTP
+// client as events, until it goes away.
+func TestEventStream(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	bus := server.NewMemoryBus()
+	srv := httptest.NewServer(newTestStream(t, StreamConfig{Source: BusSource(bus)}))
+	defer srv.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/events/sensors/temp", nil)
+	core.AssertMustNoError(t, err, "request")
+	res, err := srv.Client().Do(req)
+	core.AssertMustNoError(t, err, "GET")
+	defer res.Body.Close()
+	core.AssertEqual(t, http.StatusOK, res.StatusCode, "code")
+	core.AssertEqual(t, ContentTypeEventStream, res.Header.Get("Content-Type"), "content type")
+
+	core.AssertMustNoError(t, bus.Publish("/sensors/other", []byte("x")), "other")
+	core.AssertMustNoError(t, bus.Publish("/sensors/temp", []byte("21.5")), "Publish")
+	core.AssertMustNoError(t, bus.Publish("/sensors/temp", []byte("22\n23")), "Publish")
+
+	r := bufio.NewReader(res.Body)
+	core.AssertEqual(t, "data: 21.5\n\n", readEvent(t, r), "first")
+	core.AssertEqual(t, "data: 22\ndata: 23\n\n", readEvent(t, r), "second")
+}
+
+// readEvent reads the next event of a stream.
+func readEvent(t *testing.T, r *bufio.Reader) string {
+	t.Helper()
+
+	var buf strings.Builder
+	for {
+		line, err := r.ReadString('\n')
+		core.AssertMustNoError(t, err, "read")
+		buf.WriteString(line)
+		if line == "\n" {
+			return buf.String()
+		}
+	}
+}
+
+// TestEventStream_refused verifies refused subscriptions are answered
+// with the HTTP code of their status.
+func TestEventStream_refused(t *testing.T) {
+	source := SourceFunc(func(context.Context, string, UpdateFunc) (func(), error) {
+		return nil, nanorpc.NewError(nanorpc.NanoRPCResponse_STATUS_NOT_AUTHORIZED, "denied")
+	})
+
+	es := newTestStream(t, StreamConfig{Source: source})
+	rec := post(es, http.MethodGet, "/events/secret", "")
+	core.AssertEqual(t, http.StatusForbidden, rec.Code, "code")
+	core.AssertEqual(t, `{"status":"NOT_AUTHORIZED","message":"denied"}`,
+		strings.TrimSpace(rec.Body.String()), "body")
+
+	rec = post(es, http.MethodPost, "/events/secret", "")
+	core.AssertEqual(t, http.StatusMethodNotAllowed, rec.Code, "method")
+	core.AssertEqual(t, http.MethodGet, rec.Header().Get("Allow"), "Allow")
+}
+
+// TestEventStream_overflow verifies streams falling behind end, ending
+// their subscription.
+func TestEventStream_overflow(t *testing.T) {
+	var stopped bool
+	source := SourceFunc(func(_ context.Context, _ string, fn UpdateFunc) (func(), error) {
+		for range 3 {
+			fn([]byte("x"))
+		}
+		return func() { stopped = true }, nil
+	})
+
+	es := newTestStream(t, StreamConfig{Source: source, Buffer: 1})
+	rec := post(es, http.MethodGet, "/events/fast", "")
+	core.AssertEqual(t, http.StatusOK, rec.Code, "code")
+	core.AssertTrue(t, stopped, "stopped")
+}
+
+func TestStreamConfig_New(t *testing.T) {
+	var cfg *StreamConfig
+	_, err := cfg.New()
+	core.AssertErrorIs(t, err, core.ErrNilReceiver, "nil")
+	_, err = (&StreamConfig{}).New()
+	core.AssertErrorIs(t, err, ErrMissingSource, "source")
+}
@@ -0,0 +1,106 @@
+package nanorpc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ProtocolVersion is the version of the protocol spoken by this package,
+// announced by the handshake. Peers predating the handshake are version 0.
+const ProtocolVersion = 1
+
+// Features is a set of optional protocol features, as announced by the
+// Features field of [NanoRPCHello].
+type Features uint32
+
+const (
+	// FeatureStreaming is support for responses streamed as numbered
+	// TYPE_UPDATE chunks to a TYPE_REQUEST.
+	FeatureStreaming Features = 1 << iota
+	// FeatureCompression is support for compressed request and
+	// response data.
+	FeatureCompression
+	// FeatureAuth is support for authenticating sessions.
+	FeatureAuth
+	// FeatureExtendedStatus is support for the response statuses after
+	// STATUS_INTERNAL_ERROR, see [LegacyStatus].
+	FeatureExtendedStatus
+)
+
+var featureNames = []string{"streaming", "compression", "auth", "extended-status"}
+
+// Has reports whether all the features of want are in the set.
+func (f Features) Has(want Features) bool {
+	return f&want == want
+}
+
+// String lists the features in the set separated by "|", or "none".
+// Unknown features are printed in hexadecimal.
+func (f Features) String() string {
+	if f == 0 {
+		return "none"
+	}
+
+	var names []string
+	for i, name := range featureNames {
+		if bit := Features(1) << i; f&bit != 0 {
+			names = append(names, name)
+			f &^= bit
+		}
+	}
+	if f != 0 {
+		names = append(names, fmt.Sprintf("%#x", uint32(f)))
+	}
+	return strings.Join(names, "|")
+}
+
+// NewHello returns the handshake payload announcing [ProtocolVersion]
+// and the given features.
+func NewHello(features Features) *NanoRPCHello {
+	return &NanoRPCHello{
+		Version:  ProtocolVersion,
+		Features: uint32(features),
+	}
+}
+
+// RequestHello extracts the handshake payload of a TYPE_PING request.
+// Pings without a valid one, as sent by peers predating the handshake,
+// yield false.
+func RequestHello(req *NanoRPCRequest) (*NanoRPCHello, bool) {
+	if req.GetRequestType() != NanoRPCRequest_TYPE_PING {
+		return nil, false
+	}
+
+	hello, ok, err := DecodeRequestData(req, new(NanoRPCHello))
+	if !ok || err != nil || hello.Version == 0 {
+		return nil, false
+	}
+	return hello, true
+}
+
+// ResponseHello extracts the handshake payload of a TYPE_PONG response.
+// Pongs without a valid one, as sent by peers predating the handshake,
+// yield false.
+func ResponseHello(res *NanoRPCResponse) (*NanoRPCHello, bool) {
+	if res.GetResponseType() != NanoRPCResponse_TYPE_PONG {
+		return nil, false
+	}
+
+	hello, ok, err := DecodeResponseData(res, new(NanoRPCHello))
+	if !ok || err != nil || hello.Version == 0 {
+		return nil, false
+	}
+	return hello, true
+}
+
+// Negotiate returns the protocol version and the features both peers
+// support, given their handshake payloads. A nil payload stands for a
+// peer predating the handshake, giving version 0 and no features.
+func Negotiate(local, remote *NanoRPCHello) (uint32, Features) {
+	if local.GetVersion() == 0 || remote.GetVersion() == 0 {
+		return 0, 0
+	}
+
+	version := min(local.GetVersion(), remote.GetVersion())
+	return version, Features(local.GetFeatures() & remote.GetFeatures())
+}
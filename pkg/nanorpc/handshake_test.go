@@ -0,0 +1,147 @@
+package nanorpc
+
+import (
+	"testing"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+)
+
+var _ core.TestCase = featuresStringTestCase{}
+
+type featuresStringTestCase struct {
+	name     string
+	want     string
+	features Features
+}
+
+func (tc featuresStringTestCase) Name() string { return tc.name }
+
+func (tc featuresStringTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	core.AssertEqual(t, tc.want, tc.features.String(), "String")
+}
+
+func newFeaturesStringTestCase(name string, features Features, want string) featuresStringTestCase {
+	return featuresStringTestCase{name: name, features: features, want: want}
+}
+
+func featuresStringTestCases() []featuresStringTestCase {
+	return []featuresStringTestCase{
+		newFeaturesStringTestCase("none", 0, "none"),
+		newFeaturesStringTestCase("streaming", FeatureStreaming, "streaming"),
+		newFeaturesStringTestCase("several", FeatureStreaming|FeatureAuth, "streaming|auth"),
+		newFeaturesStringTestCase("extended status", FeatureAuth|FeatureExtendedStatus, "auth|extended-status"),
+		newFeaturesStringTestCase("unknown", FeatureCompression|1<<8, "compression|0x100"),
+	}
+}
+
+func TestFeatures_String(t *testing.T) {
+	core.RunTestCases(t, featuresStringTestCases())
+}
+
+func TestFeatures_Has(t *testing.T) {
+	f := FeatureStreaming | FeatureCompression
+
+	core.AssertTrue(t, f.Has(FeatureStreaming), "streaming")
+	core.AssertTrue(t, f.Has(FeatureStreaming|FeatureCompression), "both")
+	core.AssertFalse(t, f.Has(FeatureStreaming|FeatureAuth), "auth")
+	core.AssertTrue(t, f.Has(0), "empty")
+}
+
+var _ core.TestCase = negotiateTestCase{}
+
+type negotiateTestCase struct {
+	local    *NanoRPCHello
+	remote   *NanoRPCHello
+	name     string
+	version  uint32
+	features Features
+}
+
+func (tc negotiateTestCase) Name() string { return tc.name }
+
+func (tc negotiateTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	version, features := Negotiate(tc.local, tc.remote)
+	core.AssertEqual(t, tc.version, version, "version")
+	core.AssertEqual(t, tc.features, features, "features")
+}
+
+func newNegotiateTestCase(name string, local, remote *NanoRPCHello, version uint32,
+	features Features) negotiateTestCase {
+	return negotiateTestCase{
+		name:     name,
+		local:    local,
+		remote:   remote,
+		version:  version,
+		features: features,
+	}
+}
+
+func negotiateTestCases() []negotiateTestCase {
+	all := NewHello(FeatureStreaming | FeatureCompression | FeatureAuth)
+	newer := &NanoRPCHello{Version: ProtocolVersion + 1, Features: uint32(FeatureAuth | 1<<8)}
+
+	return []negotiateTestCase{
+		newNegotiateTestCase("same", all, all, ProtocolVersion,
+			FeatureStreaming|FeatureCompression|FeatureAuth),
+		newNegotiateTestCase("common features", all, NewHello(FeatureStreaming), ProtocolVersion,
+			FeatureStreaming),
+		newNegotiateTestCase("newer peer", all, newer, ProtocolVersion, FeatureAuth),
+		newNegotiateTestCase("legacy peer", all, nil, 0, 0),
+		newNegotiateTestCase("no handshake", nil, all, 0, 0),
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	core.RunTestCases(t, negotiateTestCases())
+}
+
+func TestRequestHello(t *testing.T) {
+	data, err := proto.Marshal(NewHello(FeatureStreaming))
+	core.AssertNoError(t, err, "Marshal")
+
+	hello, ok := RequestHello(&NanoRPCRequest{
+		RequestType: NanoRPCRequest_TYPE_PING,
+		Data:        data,
+	})
+	if core.AssertTrue(t, ok, "handshake") {
+		core.AssertEqual(t, uint32(ProtocolVersion), hello.Version, "version")
+		core.AssertEqual(t, uint32(FeatureStreaming), hello.Features, "features")
+	}
+
+	_, ok = RequestHello(&NanoRPCRequest{RequestType: NanoRPCRequest_TYPE_PING})
+	core.AssertFalse(t, ok, "legacy ping")
+
+	_, ok = RequestHello(&NanoRPCRequest{RequestType: NanoRPCRequest_TYPE_REQUEST, Data: data})
+	core.AssertFalse(t, ok, "request")
+
+	_, ok = RequestHello(&NanoRPCRequest{RequestType: NanoRPCRequest_TYPE_PING, Data: []byte{0xff}})
+	core.AssertFalse(t, ok, "garbage")
+}
+
+func TestResponseHello(t *testing.T) {
+	data, err := proto.Marshal(NewHello(FeatureAuth))
+	core.AssertNoError(t, err, "Marshal")
+
+	hello, ok := ResponseHello(&NanoRPCResponse{
+		ResponseType:   NanoRPCResponse_TYPE_PONG,
+		ResponseStatus: NanoRPCResponse_STATUS_OK,
+		Data:           data,
+	})
+	if core.AssertTrue(t, ok, "handshake") {
+		core.AssertEqual(t, uint32(FeatureAuth), hello.Features, "features")
+	}
+
+	_, ok = ResponseHello(&NanoRPCResponse{
+		ResponseType:   NanoRPCResponse_TYPE_PONG,
+		ResponseStatus: NanoRPCResponse_STATUS_OK,
+	})
+	core.AssertFalse(t, ok, "legacy pong")
+
+	_, ok = ResponseHello(nil)
+	core.AssertFalse(t, ok, "nil")
+}
@@ -1,73 +1,203 @@
 package nanorpc
 
 import (
+	"container/list"
 	"errors"
 	"hash/fnv"
 	"sync"
+	"sync/atomic"
 
 	"darvaza.org/core"
 )
 
 // HashCache stores and computes path_hash values
 // for [NanoRPCRequest]s.
+//
+// By default it grows with every new path hashed. With
+// [HashCache.SetMaxEntries], the least recently used paths are evicted
+// to make room, e.g. on long-running gateways handling many ephemeral
+// paths. Paths added by [HashCache.Register], [HashCache.RegisterHash]
+// or [HashCache.Pin] are never evicted, so the hashes of known endpoints
+// always resolve. Collisions with evicted paths aren't detected.
 type HashCache struct {
-	path map[uint32]string
-	hash map[string]uint32
-	mu   sync.RWMutex
+	path  map[uint32]string
+	hash  map[string]uint32
+	elems map[string]*list.Element // evictable paths, by path
+	lru   *list.List               // evictable paths, most recent first
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+
+	maxEntries int
+	mu         sync.RWMutex
+}
+
+// HashCacheStats reports the use of a [HashCache], see [HashCache.Stats].
+type HashCacheStats struct {
+	// Hits counts the lookups by [HashCache.Hash] and [HashCache.Path]
+	// that found the path known.
+	Hits uint64
+	// Misses counts the lookups that didn't.
+	Misses uint64
+	// Evictions counts the paths evicted to stay within the max entries.
+	Evictions uint64
+	// Entries is the number of paths known.
+	Entries int
 }
 
 // Hash returns the path_hash for a given path,
 // and stores it if new. Returns an error if a hash collision is detected.
 func (hc *HashCache) Hash(path string) (uint32, error) {
+	if hc == nil {
+		return 0, core.ErrNilReceiver
+	}
+
 	if v, ok := hc.getHash(path); ok {
+		hc.hits.Add(1)
 		return v, nil
 	}
-	return hc.computeHash(path)
+
+	hc.misses.Add(1)
+	return hc.computeHash(path, false)
+}
+
+// Pin returns the path_hash for a given path like [HashCache.Hash], and
+// keeps the path from being evicted. It's meant for the paths of handlers,
+// so requests addressed by their hash always resolve.
+func (hc *HashCache) Pin(path string) (uint32, error) {
+	if hc == nil {
+		return 0, core.ErrNilReceiver
+	}
+	return hc.computeHash(path, true)
 }
 
 // Path returns a known path for a given path_hash.
 func (hc *HashCache) Path(value uint32) (string, bool) {
-	hc.mu.RLock()
-	defer hc.mu.RUnlock()
+	if hc == nil {
+		return "", false
+	}
 
+	hc.mu.RLock()
 	s, ok := hc.path[value]
-	return s, ok
+	touch := ok && hc.maxEntries > 0
+	hc.mu.RUnlock()
+
+	if !ok {
+		hc.misses.Add(1)
+		return "", false
+	}
+
+	hc.hits.Add(1)
+	if touch {
+		hc.touch(s)
+	}
+	return s, true
 }
 
-func (hc *HashCache) getHash(path string) (uint32, bool) {
+// Register computes and stores the path_hash of every given path, so
+// requests addressed by hash can be resolved back to them. It is called by
+// the RegisterPaths functions generated by protoc-gen-go-nanorpc. Returns
+// the first hash collision, after registering the paths preceding it.
+// Registered paths are never evicted.
+func (hc *HashCache) Register(paths ...string) error {
+	if hc == nil {
+		return core.ErrNilReceiver
+	}
+
+	for _, path := range paths {
+		if _, err := hc.Pin(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RegisterHash stores a path with its precomputed path_hash, as the
+// RegisterPathHashes functions generated by protoc-gen-go-nanorpc do, so
+// the path isn't hashed at runtime. The hash is trusted, only checked
+// against those already known: a path registered with a different hash is
+// invalid, and a hash taken by another path a collision. Registered paths
+// are never evicted.
+func (hc *HashCache) RegisterHash(path string, value uint32) error {
+	if hc == nil {
+		return core.ErrNilReceiver
+	}
+
+	if v, ok := hc.getHash(path); ok && v != value {
+		return core.Wrapf(core.ErrInvalid, "path %q hashes to 0x%08x, not 0x%08x",
+			path, v, value)
+	}
+	return hc.store(path, value, true)
+}
+
+// SetMaxEntries limits the paths known to n, evicting the least recently
+// used ones to make room, pinned paths aside. Zero or less doesn't limit
+// them, the default.
+func (hc *HashCache) SetMaxEntries(n int) error {
+	if hc == nil {
+		return core.ErrNilReceiver
+	}
+
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	hc.maxEntries = max(n, 0)
+	hc.unsafeEvict()
+	return nil
+}
+
+// Stats returns the hit, miss and eviction counters of the cache, and the
+// number of paths it knows.
+func (hc *HashCache) Stats() HashCacheStats {
+	if hc == nil {
+		return HashCacheStats{}
+	}
+
 	hc.mu.RLock()
-	defer hc.mu.RUnlock()
+	entries := len(hc.hash)
+	hc.mu.RUnlock()
 
+	return HashCacheStats{
+		Hits:      hc.hits.Load(),
+		Misses:    hc.misses.Load(),
+		Evictions: hc.evictions.Load(),
+		Entries:   entries,
+	}
+}
+
+func (hc *HashCache) getHash(path string) (uint32, bool) {
+	hc.mu.RLock()
 	v, ok := hc.hash[path]
+	touch := ok && hc.maxEntries > 0
+	hc.mu.RUnlock()
+
+	if touch {
+		hc.touch(path)
+	}
 	return v, ok
 }
 
-func (hc *HashCache) computeHash(path string) (uint32, error) {
+// touch marks an evictable path as the most recently used.
+func (hc *HashCache) touch(path string) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	if e, ok := hc.elems[path]; ok {
+		hc.lru.MoveToFront(e)
+	}
+}
+
+func (hc *HashCache) computeHash(path string, pinned bool) (uint32, error) {
 	h := fnv.New32a()
 	n, err := h.Write([]byte(path))
 
 	switch {
 	case n == len(path):
-		hc.mu.Lock()
-		defer hc.mu.Unlock()
-
-		if hc.hash == nil {
-			hc.hash = make(map[string]uint32)
-			hc.path = make(map[uint32]string)
-		}
-
 		value := h.Sum32()
-
-		// Check for hash collision
-		if existingPath, exists := hc.path[value]; exists && existingPath != path {
-			// Hash collision detected
-			return 0, core.Wrapf(ErrHashCollision,
-				"paths %q and %q both hash to 0x%08x",
-				existingPath, path, value)
+		if err := hc.store(path, value, pinned); err != nil {
+			return 0, err
 		}
-
-		hc.hash[path] = value
-		hc.path[value] = path
 		return value, nil
 	case err == nil:
 		err = errors.New("failed to write to fnv-1a hasher")
@@ -78,12 +208,78 @@ func (hc *HashCache) computeHash(path string) (uint32, error) {
 	return 0, err
 }
 
+// store records the hash of a path, failing if it's taken by another.
+// Pinned paths are never evicted, and pinning a known path keeps it.
+func (hc *HashCache) store(path string, value uint32, pinned bool) error {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	if hc.hash == nil {
+		hc.hash = make(map[string]uint32)
+		hc.path = make(map[uint32]string)
+		hc.elems = make(map[string]*list.Element)
+		hc.lru = list.New()
+	}
+
+	// Check for hash collision
+	if existingPath, exists := hc.path[value]; exists && existingPath != path {
+		return core.Wrapf(ErrHashCollision,
+			"paths %q and %q both hash to 0x%08x",
+			existingPath, path, value)
+	}
+
+	if _, known := hc.hash[path]; known {
+		hc.unsafeTouch(path, pinned)
+		return nil
+	}
+
+	hc.hash[path] = value
+	hc.path[value] = path
+	if !pinned {
+		hc.elems[path] = hc.lru.PushFront(path)
+		hc.unsafeEvict()
+	}
+	return nil
+}
+
+// unsafeTouch marks a known path as the most recently used, or pins it.
+// hc.mu must be held.
+func (hc *HashCache) unsafeTouch(path string, pin bool) {
+	e, ok := hc.elems[path]
+	switch {
+	case !ok:
+		// pinned already
+	case pin:
+		hc.lru.Remove(e)
+		delete(hc.elems, path)
+	default:
+		hc.lru.MoveToFront(e)
+	}
+}
+
+// unsafeEvict drops the least recently used paths beyond the max entries.
+// hc.mu must be held.
+func (hc *HashCache) unsafeEvict() {
+	for hc.maxEntries > 0 && len(hc.hash) > hc.maxEntries && hc.lru.Len() > 0 {
+		e := hc.lru.Back()
+		path, _ := hc.lru.Remove(e).(string)
+
+		delete(hc.path, hc.hash[path])
+		delete(hc.hash, path)
+		delete(hc.elems, path)
+		hc.evictions.Add(1)
+	}
+}
+
 // ResolvePath extracts the path and hash from a request.
 // For string paths, it computes and caches the hash.
 // For hash paths, it attempts to resolve to the original string.
 // Returns (path, pathHash, error) where error indicates a hash collision.
 func (hc *HashCache) ResolvePath(req *NanoRPCRequest) (string, uint32, error) {
-	if req == nil {
+	switch {
+	case hc == nil:
+		return "", 0, core.ErrNilReceiver
+	case req == nil:
 		return "", 0, nil
 	}
 
@@ -421,4 +421,148 @@ func TestHashCache_CollisionDetection(t *testing.T) {
 	t.Run("resolve_path_collision", func(t *testing.T) {
 		testResolvePathCollision(t, path1, path2)
 	})
+
+	t.Run("register_collision", func(t *testing.T) {
+		hc := &HashCache{}
+		setupCollisionScenario(t, hc, path1, path2)
+
+		err := hc.Register("/first", path1, "/last")
+		core.AssertErrorIs(t, err, ErrHashCollision, "collision error")
+
+		_, ok := hc.getHash("/first")
+		core.AssertTrue(t, ok, "registered before collision")
+		_, ok = hc.getHash("/last")
+		core.AssertFalse(t, ok, "registered after collision")
+	})
+}
+
+// TestHashCache_Register verifies registered paths resolve from their hash
+// before any request uses them.
+func TestHashCache_Register(t *testing.T) {
+	hc := &HashCache{}
+	paths := []string{"/sensors/temperature", "/sensors/humidity"}
+
+	core.AssertNoError(t, hc.Register(paths...), "register")
+	core.AssertNoError(t, hc.Register(), "register nothing")
+
+	for _, path := range paths {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(path))
+
+		req := &NanoRPCRequest{PathOneof: GetPathOneOfHash(h.Sum32())}
+		out, ok := hc.DehashRequest(req)
+		core.AssertTrue(t, ok, "dehash %s", path)
+		core.AssertEqual(t, path, out.GetPath(), "path")
+	}
+}
+
+// TestHashCache_RegisterHash verifies precomputed hashes resolve to their
+// paths, and are checked against those already known.
+func TestHashCache_RegisterHash(t *testing.T) {
+	hc := &HashCache{}
+
+	core.AssertNoError(t, hc.RegisterHash("/sensors/temperature", 0x1234), "register")
+	core.AssertNoError(t, hc.RegisterHash("/sensors/temperature", 0x1234), "register again")
+
+	path, ok := hc.Path(0x1234)
+	core.AssertTrue(t, ok, "Path ok")
+	core.AssertEqual(t, "/sensors/temperature", path, "Path")
+
+	value, err := hc.Hash("/sensors/temperature")
+	core.AssertNoError(t, err, "Hash")
+	core.AssertEqual(t, uint32(0x1234), value, "Hash")
+
+	err = hc.RegisterHash("/sensors/temperature", 0x5678)
+	core.AssertErrorIs(t, err, core.ErrInvalid, "different hash")
+
+	err = hc.RegisterHash("/sensors/humidity", 0x1234)
+	core.AssertErrorIs(t, err, ErrHashCollision, "hash taken")
+}
+
+// TestHashCache_NilReceiver verifies a nil cache reports
+// core.ErrNilReceiver from error-returning methods and zero values
+// elsewhere, without panicking.
+func TestHashCache_NilReceiver(t *testing.T) {
+	var hc *HashCache
+	req := &NanoRPCRequest{PathOneof: GetPathOneOfHash(1)}
+
+	_, err := hc.Hash("/x")
+	core.AssertErrorIs(t, err, core.ErrNilReceiver, "Hash")
+
+	path, ok := hc.Path(1)
+	core.AssertFalse(t, ok, "Path ok")
+	core.AssertEqual(t, "", path, "Path")
+
+	_, _, err = hc.ResolvePath(req)
+	core.AssertErrorIs(t, err, core.ErrNilReceiver, "ResolvePath")
+
+	err = hc.Register("/x")
+	core.AssertErrorIs(t, err, core.ErrNilReceiver, "Register")
+
+	err = hc.RegisterHash("/x", 1)
+	core.AssertErrorIs(t, err, core.ErrNilReceiver, "RegisterHash")
+
+	_, err = hc.Pin("/x")
+	core.AssertErrorIs(t, err, core.ErrNilReceiver, "Pin")
+
+	err = hc.SetMaxEntries(1)
+	core.AssertErrorIs(t, err, core.ErrNilReceiver, "SetMaxEntries")
+	core.AssertEqual(t, HashCacheStats{}, hc.Stats(), "Stats")
+
+	out, ok := hc.DehashRequest(req)
+	core.AssertFalse(t, ok, "DehashRequest ok")
+	core.AssertSame(t, req, out, "DehashRequest request")
+}
+
+// TestHashCache_SetMaxEntries verifies the least recently used paths are
+// evicted beyond the limit, and pinned paths never are.
+func TestHashCache_SetMaxEntries(t *testing.T) {
+	hc := &HashCache{}
+	core.AssertMustNoError(t, hc.Register("/registered"), "Register")
+	core.AssertMustNoError(t, hc.SetMaxEntries(3), "SetMaxEntries")
+
+	for _, path := range []string{"/a", "/b"} {
+		_, err := hc.Hash(path)
+		core.AssertMustNoError(t, err, "Hash %s", path)
+	}
+
+	// use /a, so /b is the least recently used
+	_, _ = hc.Hash("/a")
+	_, err := hc.Hash("/c")
+	core.AssertMustNoError(t, err, "Hash /c")
+
+	for path, known := range map[string]bool{"/registered": true, "/a": true, "/b": false, "/c": true} {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(path))
+		_, ok := hc.Path(h.Sum32())
+		core.AssertEqual(t, known, ok, "%s known", path)
+	}
+
+	stats := hc.Stats()
+	core.AssertEqual(t, 3, stats.Entries, "entries")
+	core.AssertEqual(t, uint64(1), stats.Evictions, "evictions")
+
+	// shrinking evicts right away, pinned paths aside
+	core.AssertMustNoError(t, hc.SetMaxEntries(1), "shrink")
+	core.AssertEqual(t, 1, hc.Stats().Entries, "entries after shrinking")
+	_, ok := hc.Path(mustHash(t, hc, "/registered"))
+	core.AssertTrue(t, ok, "pinned path kept")
+}
+
+func TestHashCache_Stats(t *testing.T) {
+	hc := &HashCache{}
+
+	_, _ = hc.Hash("/a") // miss
+	_, _ = hc.Hash("/a") // hit
+	_, _ = hc.Path(1)    // miss
+
+	core.AssertEqual(t, HashCacheStats{Hits: 1, Misses: 2, Entries: 1}, hc.Stats(), "stats")
+}
+
+func mustHash(t *testing.T, hc *HashCache, path string) uint32 {
+	t.Helper()
+
+	value, err := hc.Hash(path)
+	core.AssertMustNoError(t, err, "Hash %s", path)
+	return value
 }
@@ -0,0 +1,84 @@
+package nanorpc
+
+import (
+	"slices"
+	"strings"
+)
+
+// Metadata is the metadata of a request or a response, key-value pairs
+// carried apart from its data, like auth tokens, trace IDs or content-type
+// hints. Keys are lowercase and may repeat. The metadata of a message
+// converts to it, as in Metadata(req.GetMetadata()).
+type Metadata []*NanoRPCMetadata
+
+// NewMetadata returns the metadata of the given key-value pairs. A key
+// left without value gets an empty one.
+func NewMetadata(kv ...string) Metadata {
+	var md Metadata
+	for i := 0; i < len(kv); i += 2 {
+		var value string
+		if i+1 < len(kv) {
+			value = kv[i+1]
+		}
+		md = md.Add(kv[i], value)
+	}
+	return md
+}
+
+// Get returns the first value of a key, telling if there is any.
+func (md Metadata) Get(key string) (string, bool) {
+	key = strings.ToLower(key)
+	for _, kv := range md {
+		if kv.GetKey() == key {
+			return kv.GetValue(), true
+		}
+	}
+	return "", false
+}
+
+// Values returns all the values of a key, in order.
+func (md Metadata) Values(key string) []string {
+	var out []string
+	key = strings.ToLower(key)
+	for _, kv := range md {
+		if kv.GetKey() == key {
+			out = append(out, kv.GetValue())
+		}
+	}
+	return out
+}
+
+// Add appends a key-value pair, returning the updated metadata.
+func (md Metadata) Add(key, value string) Metadata {
+	return append(md, &NanoRPCMetadata{
+		Key:   strings.ToLower(key),
+		Value: value,
+	})
+}
+
+// Set replaces the values of a key with the given one, returning the
+// updated metadata.
+func (md Metadata) Set(key, value string) Metadata {
+	return md.Delete(key).Add(key, value)
+}
+
+// Delete removes the values of a key, returning the updated metadata.
+func (md Metadata) Delete(key string) Metadata {
+	key = strings.ToLower(key)
+	return slices.DeleteFunc(md, func(kv *NanoRPCMetadata) bool {
+		return kv.GetKey() == key
+	})
+}
+
+// Clone returns a copy of the metadata not sharing pairs with it.
+func (md Metadata) Clone() Metadata {
+	if md == nil {
+		return nil
+	}
+
+	out := make(Metadata, len(md))
+	for i, kv := range md {
+		out[i] = &NanoRPCMetadata{Key: kv.GetKey(), Value: kv.GetValue()}
+	}
+	return out
+}
@@ -0,0 +1,51 @@
+package nanorpc
+
+import (
+	"testing"
+
+	"darvaza.org/core"
+)
+
+func TestMetadata(t *testing.T) {
+	md := NewMetadata("Authorization", "Bearer x", "trace-id", "1", "trace-id", "2", "empty")
+
+	v, ok := md.Get("authorization")
+	core.AssertTrue(t, ok, "found")
+	core.AssertEqual(t, "Bearer x", v, "case-insensitive")
+	core.AssertSliceEqual(t, []string{"1", "2"}, md.Values("Trace-ID"), "values")
+	v, ok = md.Get("empty")
+	core.AssertTrue(t, ok, "key without value")
+	core.AssertEqual(t, "", v, "empty value")
+	_, ok = md.Get("missing")
+	core.AssertFalse(t, ok, "missing")
+
+	clone := md.Clone()
+	md = md.Set("trace-id", "3")
+	core.AssertSliceEqual(t, []string{"3"}, md.Values("trace-id"), "set")
+	core.AssertSliceEqual(t, []string{"1", "2"}, clone.Values("trace-id"), "clone")
+
+	md = md.Delete("AUTHORIZATION")
+	_, ok = md.Get("authorization")
+	core.AssertFalse(t, ok, "deleted")
+	core.AssertEqual(t, 2, len(md), "remaining")
+	core.AssertNil(t, Metadata(nil).Clone(), "nil clone")
+}
+
+func TestMetadata_wire(t *testing.T) {
+	req := &NanoRPCRequest{
+		RequestId:   1,
+		RequestType: NanoRPCRequest_TYPE_REQUEST,
+		PathOneof:   GetPathOneOfString("/echo"),
+		Metadata:    NewMetadata("content-type", "application/json"),
+		Data:        []byte("{}"),
+	}
+	b, err := EncodeRequest(req, nil)
+	core.AssertMustNoError(t, err, "EncodeRequest")
+
+	var out NanoRPCRequest
+	_, err = DecodeRequestTo(b, &out)
+	core.AssertMustNoError(t, err, "DecodeRequestTo")
+	v, _ := Metadata(out.GetMetadata()).Get("content-type")
+	core.AssertEqual(t, "application/json", v, "metadata")
+	core.AssertEqual(t, "{}", string(out.Data), "data")
+}
@@ -5,6 +5,7 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"io"
 	"net"
 	"sync"
@@ -51,6 +52,23 @@ func New(t core.T) *Server {
 	ln, err := net.Listen("tcp", "127.0.0.1:0")
 	core.AssertMustNoError(t, err, "listen")
 
+	return newServer(t, ln)
+}
+
+// NewTLS is like [New] but serves every connection over TLS using cfg,
+// for exercising TLS and mTLS clients.
+func NewTLS(t core.T, cfg *tls.Config) *Server {
+	t.Helper()
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", cfg)
+	core.AssertMustNoError(t, err, "listen")
+
+	return newServer(t, ln)
+}
+
+func newServer(t core.T, ln net.Listener) *Server {
+	t.Helper()
+
 	s := &Server{
 		t:     t,
 		ln:    ln,
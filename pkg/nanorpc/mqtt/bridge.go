@@ -0,0 +1,271 @@
+package mqtt
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"sync"
+
+	"darvaza.org/core"
+	"darvaza.org/slog"
+	"darvaza.org/slog/handlers/discard"
+
+	"protomcp.org/nanorpc/pkg/nanorpc/server"
+	"protomcp.org/nanorpc/pkg/nanorpc/utils"
+)
+
+// MessageHandler receives the messages of an MQTT subscription.
+type MessageHandler func(topic string, payload []byte)
+
+// Client is the MQTT client of a [Bridge], usually an adapter of the
+// MQTT library of the application, connected and reconnecting on its
+// own. Subscribe calls fn with the messages published on the topics
+// matching filter until Unsubscribe. Implementations must be safe for
+// concurrent use.
+type Client interface {
+	Publish(topic string, payload []byte) error
+	Subscribe(filter string, fn MessageHandler) error
+	Unsubscribe(filter string) error
+}
+
+// Config describes a [Bridge].
+//
+// Client is the MQTT side, and Bus the NanoRPC one, attached to the
+// message handler with [server.WithEventBus] so the updates published on
+// it reach the NanoRPC subscribers, and those to forward to MQTT are
+// published on it. Rules are applied independently, so messages matching
+// several are forwarded by each. Logger defaults to discarding.
+type Config struct {
+	Client Client
+	Bus    server.EventBus
+	Logger slog.Logger
+	Rules  []Rule
+}
+
+// New creates a [Bridge] from the [Config]. A missing Client or Bus fail
+// with [ErrMissingClient] or [ErrMissingBus], and malformed rules with
+// [ErrInvalidRule].
+func (cfg *Config) New() (*Bridge, error) {
+	switch {
+	case cfg == nil:
+		return nil, core.ErrNilReceiver
+	case core.IsNil(cfg.Client):
+		return nil, ErrMissingClient
+	case core.IsNil(cfg.Bus):
+		return nil, ErrMissingBus
+	}
+
+	rules := make([]*rule, 0, len(cfg.Rules))
+	for _, r := range cfg.Rules {
+		parsed, err := newRule(r)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, parsed)
+	}
+
+	logger := utils.WithComponent(cfg.Logger, utils.ComponentMQTTBridge)
+	if logger == nil {
+		logger = discard.New()
+	}
+
+	return &Bridge{
+		client: cfg.Client,
+		bus:    cfg.Bus,
+		logger: logger,
+		rules:  rules,
+		echoes: make(map[string][]byte),
+	}, nil
+}
+
+// Bridge forwards messages between MQTT topics and NanoRPC paths, as its
+// rules say, see [Config]. Messages forwarded by rules applying both
+// ways aren't forwarded back when they return, from the event bus or
+// from brokers delivering its own messages to a client, as MQTT 3.1.1
+// brokers do.
+type Bridge struct {
+	client  Client
+	bus     server.EventBus
+	logger  slog.Logger
+	rules   []*rule
+	echoes  map[string][]byte // forwarded, expected back
+	busIDs  []uint64
+	filters []string
+	mu      sync.Mutex
+	echoMu  sync.Mutex
+	started bool
+}
+
+// Start subscribes to the paths and topics of the rules. On failure
+// those already subscribed are undone. A started bridge fails with
+// [ErrStarted] until closed.
+func (b *Bridge) Start() error {
+	if b == nil {
+		return core.ErrNilReceiver
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.started {
+		return ErrStarted
+	}
+
+	for _, r := range b.rules {
+		if err := b.unsafeSubscribe(r); err != nil {
+			_ = b.unsafeUnsubscribe()
+			return err
+		}
+	}
+	b.started = true
+	return nil
+}
+
+// Close ends the subscriptions of the bridge, which can be started again.
+func (b *Bridge) Close() error {
+	if b == nil {
+		return core.ErrNilReceiver
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.started = false
+	return b.unsafeUnsubscribe()
+}
+
+func (b *Bridge) unsafeSubscribe(r *rule) error {
+	if r.Direction != Inbound {
+		id, err := b.bus.Subscribe(r.Path, func(path string, data []byte) {
+			b.outbound(r, path, data)
+		})
+		if err != nil {
+			return core.Wrapf(err, "failed to subscribe to %q", r.Path)
+		}
+		b.busIDs = append(b.busIDs, id)
+	}
+
+	if r.Direction != Outbound {
+		err := b.client.Subscribe(r.Topic, func(topic string, payload []byte) {
+			b.inbound(r, topic, payload)
+		})
+		if err != nil {
+			return core.Wrapf(err, "failed to subscribe to %q", r.Topic)
+		}
+		b.filters = append(b.filters, r.Topic)
+	}
+	return nil
+}
+
+func (b *Bridge) unsafeUnsubscribe() error {
+	var errs []error
+	for _, id := range b.busIDs {
+		errs = append(errs, b.bus.Unsubscribe(id))
+	}
+	for _, filter := range b.filters {
+		errs = append(errs, b.client.Unsubscribe(filter))
+	}
+	b.busIDs, b.filters = nil, nil
+	return errors.Join(errs...)
+}
+
+// Topic translates a path with the first rule publishing it on MQTT.
+func (b *Bridge) Topic(path string) (string, bool) {
+	if b == nil {
+		return "", false
+	}
+
+	for _, r := range b.rules {
+		if r.Direction != Inbound {
+			if topic, ok := r.toTopic(path); ok {
+				return topic, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Path translates a topic with the first rule publishing it on NanoRPC.
+func (b *Bridge) Path(topic string) (string, bool) {
+	if b == nil {
+		return "", false
+	}
+
+	for _, r := range b.rules {
+		if r.Direction != Outbound {
+			if path, ok := r.toPath(topic); ok {
+				return path, true
+			}
+		}
+	}
+	return "", false
+}
+
+// outbound publishes on MQTT an update of the event bus.
+func (b *Bridge) outbound(r *rule, path string, data []byte) {
+	topic, ok := r.toTopic(path)
+	if !ok || b.returned(busKey(path), data) {
+		return
+	}
+
+	key := mqttKey(topic)
+	b.expect(r, key, data)
+	if err := b.client.Publish(topic, data); err != nil {
+		b.returned(key, data)
+		b.logWarn(err, path, topic, "failed to publish on MQTT")
+	}
+}
+
+// inbound publishes on the event bus an MQTT message.
+func (b *Bridge) inbound(r *rule, topic string, payload []byte) {
+	path, ok := r.toPath(topic)
+	if !ok || b.returned(mqttKey(topic), payload) {
+		return
+	}
+
+	key := busKey(path)
+	b.expect(r, key, payload)
+	if err := b.bus.Publish(path, payload); err != nil {
+		b.returned(key, payload)
+		b.logWarn(err, path, topic, "failed to publish on the event bus")
+	}
+}
+
+func busKey(path string) string   { return "bus:" + path }
+func mqttKey(topic string) string { return "mqtt:" + topic }
+
+// expect records a message forwarded by a rule applying both ways, to
+// not forward it back when it returns.
+func (b *Bridge) expect(r *rule, key string, data []byte) {
+	if r.Direction != Both {
+		return
+	}
+
+	b.echoMu.Lock()
+	defer b.echoMu.Unlock()
+
+	b.echoes[key] = slices.Clone(data)
+}
+
+// returned tells if a message is one forwarded, forgetting it.
+func (b *Bridge) returned(key string, data []byte) bool {
+	b.echoMu.Lock()
+	defer b.echoMu.Unlock()
+
+	expected, ok := b.echoes[key]
+	if ok && bytes.Equal(expected, data) {
+		delete(b.echoes, key)
+		return true
+	}
+	return false
+}
+
+func (b *Bridge) logWarn(err error, path, topic, msg string) {
+	if l, ok := b.logger.Warn().WithEnabled(); ok {
+		l = utils.WithError(l, err)
+		l.WithFields(slog.Fields{
+			utils.FieldPath:  utils.LogPath(path),
+			utils.FieldTopic: topic,
+		}).Print(msg)
+	}
+}
@@ -0,0 +1,210 @@
+package mqtt
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc/server"
+)
+
+// mockBroker is a [Client] delivering its messages to its own
+// subscriptions, as MQTT 3.1.1 brokers do, recording those published.
+type mockBroker struct {
+	subs      map[string]MessageHandler
+	published []string // topic=payload
+	mu        sync.Mutex
+}
+
+func newMockBroker() *mockBroker {
+	return &mockBroker{subs: make(map[string]MessageHandler)}
+}
+
+func (m *mockBroker) Publish(topic string, payload []byte) error {
+	m.mu.Lock()
+	m.published = append(m.published, topic+"="+string(payload))
+	var fns []MessageHandler
+	for filter, fn := range m.subs {
+		if topicMatches(filter, topic) {
+			fns = append(fns, fn)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, fn := range fns {
+		fn(topic, payload)
+	}
+	return nil
+}
+
+func (m *mockBroker) Subscribe(filter string, fn MessageHandler) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.subs[filter] = fn
+	return nil
+}
+
+func (m *mockBroker) Unsubscribe(filter string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.subs, filter)
+	return nil
+}
+
+func (m *mockBroker) sent() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]string(nil), m.published...)
+}
+
+// topicMatches tells if an MQTT topic filter matches a topic.
+func topicMatches(filter, topic string) bool {
+	_, ok := capture(strings.Split(filter, "/"), strings.Split(topic, "/"), isTopicParam, topicRest)
+	return ok
+}
+
+// recordBus records the updates published on a bus.
+func recordBus(t *testing.T, bus server.EventBus) func() []string {
+	t.Helper()
+
+	var mu sync.Mutex
+	var updates []string
+	_, err := bus.Subscribe("", func(path string, data []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		updates = append(updates, path+"="+string(data))
+	})
+	core.AssertMustNoError(t, err, "bus.Subscribe")
+
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), updates...)
+	}
+}
+
+func newTestBridge(t *testing.T, rules ...Rule) (*Bridge, *mockBroker, *server.MemoryBus) {
+	t.Helper()
+
+	broker, bus := newMockBroker(), server.NewMemoryBus()
+	b, err := (&Config{Client: broker, Bus: bus, Rules: rules}).New()
+	core.AssertMustNoError(t, err, "New")
+	core.AssertMustNoError(t, b.Start(), "Start")
+	t.Cleanup(func() { _ = b.Close() })
+	return b, broker, bus
+}
+
+// TestBridge_both verifies messages cross both ways once, without
+// returning to where they came from.
+func TestBridge_both(t *testing.T) {
+	_, broker, bus := newTestBridge(t, Rule{Path: "/devices/{id}/status", Topic: "fleet/+/status"})
+	updates := recordBus(t, bus)
+
+	core.AssertMustNoError(t, broker.Publish("fleet/42/status", []byte("up")), "MQTT publish")
+	core.AssertSliceEqual(t, core.S("/devices/42/status=up"), updates(), "bus")
+	core.AssertSliceEqual(t, core.S("fleet/42/status=up"), broker.sent(), "not forwarded back")
+
+	core.AssertMustNoError(t, bus.Publish("/devices/7/status", []byte("down")), "bus publish")
+	core.AssertSliceEqual(t, core.S("fleet/42/status=up", "fleet/7/status=down"), broker.sent(), "MQTT")
+	core.AssertEqual(t, 2, len(updates()), "not forwarded back")
+
+	// unmatched messages stay on their side
+	core.AssertMustNoError(t, bus.Publish("/other", []byte("x")), "bus other")
+	core.AssertMustNoError(t, broker.Publish("other", []byte("x")), "MQTT other")
+	core.AssertEqual(t, 3, len(broker.sent()), "MQTT unchanged")
+	core.AssertEqual(t, 3, len(updates()), "bus unchanged")
+}
+
+func TestBridge_direction(t *testing.T) {
+	b, broker, bus := newTestBridge(t,
+		Rule{Path: "/commands/*", Topic: "cmd/#", Direction: Outbound},
+		Rule{Path: "/telemetry/*", Topic: "tm/#", Direction: Inbound},
+	)
+	updates := recordBus(t, bus)
+
+	core.AssertMustNoError(t, bus.Publish("/commands/42/reboot", []byte("1")), "outbound")
+	core.AssertMustNoError(t, broker.Publish("cmd/42/reboot", []byte("2")), "not inbound")
+	core.AssertMustNoError(t, broker.Publish("tm/42/temp", []byte("21")), "inbound")
+	core.AssertMustNoError(t, bus.Publish("/telemetry/42/temp", []byte("22")), "not outbound")
+
+	core.AssertSliceEqual(t, core.S("cmd/42/reboot=1", "cmd/42/reboot=2", "tm/42/temp=21"),
+		broker.sent(), "MQTT")
+	core.AssertSliceEqual(t, core.S("/commands/42/reboot=1", "/telemetry/42/temp=21", "/telemetry/42/temp=22"),
+		updates(), "bus")
+
+	topic, ok := b.Topic("/commands/42/reboot")
+	core.AssertTrue(t, ok && topic == "cmd/42/reboot", "Topic")
+	_, ok = b.Topic("/telemetry/42/temp")
+	core.AssertFalse(t, ok, "Topic inbound only")
+	path, ok := b.Path("tm/42/temp")
+	core.AssertTrue(t, ok && path == "/telemetry/42/temp", "Path")
+	_, ok = b.Path("cmd/42/reboot")
+	core.AssertFalse(t, ok, "Path outbound only")
+}
+
+func TestBridge_Start(t *testing.T) {
+	b, broker, bus := newTestBridge(t, Rule{Path: "/status", Topic: "status"})
+	core.AssertErrorIs(t, b.Start(), ErrStarted, "started")
+
+	core.AssertMustNoError(t, b.Close(), "Close")
+	core.AssertEqual(t, 0, len(broker.subs), "MQTT unsubscribed")
+	core.AssertMustNoError(t, bus.Publish("/status", []byte("x")), "bus publish")
+	core.AssertEqual(t, 0, len(broker.sent()), "bus unsubscribed")
+
+	core.AssertMustNoError(t, b.Start(), "restart")
+}
+
+func TestConfig_New(t *testing.T) {
+	broker, bus := newMockBroker(), server.NewMemoryBus()
+	for _, tc := range []struct {
+		cfg  *Config
+		want error
+		name string
+	}{
+		{name: "nil", want: core.ErrNilReceiver},
+		{name: "client", cfg: &Config{Bus: bus}, want: ErrMissingClient},
+		{name: "bus", cfg: &Config{Client: broker}, want: ErrMissingBus},
+		{name: "rule", cfg: &Config{Client: broker, Bus: bus, Rules: core.S(Rule{Path: "x"})},
+			want: ErrInvalidRule},
+	} {
+		_, err := tc.cfg.New()
+		core.AssertErrorIs(t, err, tc.want, tc.name)
+	}
+}
+
+func TestBridge_nilReceiver(t *testing.T) {
+	var b *Bridge
+	core.AssertErrorIs(t, b.Start(), core.ErrNilReceiver, "Start")
+	core.AssertErrorIs(t, b.Close(), core.ErrNilReceiver, "Close")
+	_, ok := b.Topic("/x")
+	core.AssertFalse(t, ok, "Topic")
+	_, ok = b.Path("x")
+	core.AssertFalse(t, ok, "Path")
+}
+
+var _ Client = failingClient{}
+
+// failingClient is a [Client] whose subscriptions fail.
+type failingClient struct{ *mockBroker }
+
+func (failingClient) Subscribe(string, MessageHandler) error { return errors.New("offline") }
+
+// TestBridge_Start_failure verifies subscriptions are undone when one
+// fails.
+func TestBridge_Start_failure(t *testing.T) {
+	bus := server.NewMemoryBus()
+	client := failingClient{newMockBroker()}
+	b, err := (&Config{Client: client, Bus: bus, Rules: core.S(Rule{Path: "/status", Topic: "status"})}).New()
+	core.AssertMustNoError(t, err, "New")
+	core.AssertError(t, b.Start(), "Start")
+
+	core.AssertMustNoError(t, bus.Publish("/status", []byte("x")), "bus publish")
+	core.AssertEqual(t, 0, len(client.sent()), "bus unsubscribed")
+	core.AssertMustNoError(t, b.Close(), "Close")
+}
@@ -0,0 +1,25 @@
+// Package mqtt bridges NanoRPC paths and MQTT topics, so device fleets
+// already speaking MQTT and NanoRPC clients see each other's messages.
+//
+// A [Bridge] publishes the messages of the MQTT topics its rules match as
+// updates on the translated NanoRPC paths, and the other way around. The
+// NanoRPC side is a [server.EventBus] attached to the message handler
+// with [server.WithEventBus], and the MQTT side a [Client], adapting
+// whichever MQTT library the application uses.
+//
+//	bus := server.NewMemoryBus()
+//	srv := server.NewDefaultServer(listener, handler, logger,
+//		server.WithEventBus(bus))
+//
+//	bridge, err := (&mqtt.Config{
+//		Client: mqttClient,
+//		Bus:    bus,
+//		Rules: []mqtt.Rule{
+//			{Path: "/devices/{id}/status", Topic: "fleet/+/status"},
+//			{Path: "/commands/*", Topic: "cmd/#", Direction: mqtt.Outbound},
+//		},
+//	}).New()
+//	if err == nil {
+//		err = bridge.Start()
+//	}
+package mqtt
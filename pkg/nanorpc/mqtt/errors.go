@@ -0,0 +1,21 @@
+package mqtt
+
+import "darvaza.org/core"
+
+// Invalid-argument sentinels for the mqtt package. Each wraps
+// [core.ErrInvalid]. Call sites add dynamic context by wrapping the
+// sentinel, e.g. core.QuietWrap(ErrInvalidRule, "topic %q", topic).
+var (
+	// ErrInvalidRule indicates a [Rule] that can't translate paths to
+	// topics and back.
+	ErrInvalidRule = core.QuietWrap(core.ErrInvalid, "invalid bridge rule")
+
+	// ErrMissingClient indicates a [Config] without MQTT client.
+	ErrMissingClient = core.QuietWrap(core.ErrInvalid, "MQTT client missing")
+
+	// ErrMissingBus indicates a [Config] without event bus.
+	ErrMissingBus = core.QuietWrap(core.ErrInvalid, "event bus missing")
+
+	// ErrStarted indicates [Bridge.Start] was called on a started bridge.
+	ErrStarted = core.QuietWrap(core.ErrInvalid, "bridge already started")
+)
@@ -0,0 +1,163 @@
+package mqtt
+
+import (
+	"strings"
+
+	"darvaza.org/core"
+)
+
+// Direction tells which way a [Rule] forwards messages.
+type Direction int
+
+const (
+	// Both forwards messages both ways. The default.
+	Both Direction = iota
+	// Outbound only publishes the NanoRPC updates on MQTT.
+	Outbound
+	// Inbound only publishes the MQTT messages as NanoRPC updates.
+	Inbound
+)
+
+// Rule maps NanoRPC paths to MQTT topics.
+//
+// Path is a NanoRPC subscription pattern, with `+` or `{name}` segments
+// matching any one segment and a trailing `*` matching the rest, and Topic
+// an MQTT topic filter, with `+` and trailing `#` wildcards. Both need
+// the same number of single segment wildcards, and a trailing one on
+// both or neither; what a wildcard matches on one side fills the
+// wildcard of the same position on the other. E.g. `/devices/{id}/status`
+// and `fleet/+/status` map `/devices/42/status` to `fleet/42/status`.
+type Rule struct {
+	Path      string
+	Topic     string
+	Direction Direction
+}
+
+// rule is a parsed [Rule].
+type rule struct {
+	Rule
+
+	path  []string
+	topic []string
+}
+
+// newRule parses a [Rule], failing with [ErrInvalidRule].
+func newRule(r Rule) (*rule, error) {
+	path, topic := strings.Split(r.Path, "/"), strings.Split(r.Topic, "/")
+
+	var err error
+	switch {
+	case !strings.HasPrefix(r.Path, "/"):
+		err = core.QuietWrap(ErrInvalidRule, "path %q must be absolute", r.Path)
+	case r.Topic == "":
+		err = core.QuietWrap(ErrInvalidRule, "topic missing for %q", r.Path)
+	case r.Direction < Both || r.Direction > Inbound:
+		err = core.QuietWrap(ErrInvalidRule, "direction %d", r.Direction)
+	case !validPattern(path, isPathParam, pathRest) || !validPattern(topic, isTopicParam, topicRest):
+		err = core.QuietWrap(ErrInvalidRule, "misplaced wildcard in %q or %q", r.Path, r.Topic)
+	case !sameWildcards(path, topic):
+		err = core.QuietWrap(ErrInvalidRule, "wildcards of %q and %q differ", r.Path, r.Topic)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rule{Rule: r, path: path, topic: topic}, nil
+}
+
+const (
+	pathRest  = "*"
+	topicRest = "#"
+)
+
+func isPathParam(s string) bool {
+	return s == "+" || (len(s) > 2 && s[0] == '{' && s[len(s)-1] == '}')
+}
+
+func isTopicParam(s string) bool { return s == "+" }
+
+// validPattern tells if the wildcard matching the rest is only the last
+// segment, and other wildcards take whole segments.
+func validPattern(segments []string, isParam func(string) bool, rest string) bool {
+	for i, s := range segments {
+		switch {
+		case s == rest:
+			if i != len(segments)-1 {
+				return false
+			}
+		case isParam(s):
+		case strings.ContainsAny(s, "+#*{}"):
+			return false
+		}
+	}
+	return true
+}
+
+// sameWildcards tells if both patterns have the same number of single
+// segment wildcards, and a trailing one on both or neither.
+func sameWildcards(path, topic []string) bool {
+	count := func(segments []string, isParam func(string) bool) (n int) {
+		for _, s := range segments {
+			if isParam(s) {
+				n++
+			}
+		}
+		return n
+	}
+
+	pathRests := path[len(path)-1] == pathRest
+	topicRests := topic[len(topic)-1] == topicRest
+	return pathRests == topicRests && count(path, isPathParam) == count(topic, isTopicParam)
+}
+
+// toTopic translates a path matching the rule.
+func (r *rule) toTopic(path string) (string, bool) {
+	values, ok := capture(r.path, strings.Split(path, "/"), isPathParam, pathRest)
+	if !ok {
+		return "", false
+	}
+	return fill(r.topic, values, isTopicParam, topicRest), true
+}
+
+// toPath translates a topic matching the rule.
+func (r *rule) toPath(topic string) (string, bool) {
+	values, ok := capture(r.topic, strings.Split(topic, "/"), isTopicParam, topicRest)
+	if !ok {
+		return "", false
+	}
+	return fill(r.path, values, isPathParam, pathRest), true
+}
+
+// capture returns what the wildcards of a pattern match, in order.
+func capture(pattern, segments []string, isParam func(string) bool, rest string) ([]string, bool) {
+	var values []string
+	for i, p := range pattern {
+		switch {
+		case p == rest:
+			return append(values, strings.Join(segments[min(i, len(segments)):], "/")), true
+		case i >= len(segments):
+			return nil, false
+		case isParam(p):
+			values = append(values, segments[i])
+		case p != segments[i]:
+			return nil, false
+		}
+	}
+	return values, len(segments) == len(pattern)
+}
+
+// fill replaces the wildcards of a pattern with values, in order. An
+// empty rest is dropped with its separator.
+func fill(pattern, values []string, isParam func(string) bool, rest string) string {
+	out := make([]string, 0, len(pattern))
+	for _, p := range pattern {
+		switch {
+		case p == rest && values[0] == "":
+		case p == rest || isParam(p):
+			out = append(out, values[0])
+			values = values[1:]
+		default:
+			out = append(out, p)
+		}
+	}
+	return strings.Join(out, "/")
+}
@@ -0,0 +1,87 @@
+package mqtt
+
+import (
+	"testing"
+
+	"darvaza.org/core"
+)
+
+var _ core.TestCase = translateTestCase{}
+
+type translateTestCase struct {
+	rule  Rule
+	name  string
+	path  string
+	topic string
+}
+
+func (tc translateTestCase) Name() string { return tc.name }
+
+func (tc translateTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	r, err := newRule(tc.rule)
+	core.AssertMustNoError(t, err, "newRule")
+
+	topic, ok := r.toTopic(tc.path)
+	core.AssertTrue(t, ok, "toTopic matched")
+	core.AssertEqual(t, tc.topic, topic, "toTopic")
+
+	path, ok := r.toPath(tc.topic)
+	core.AssertTrue(t, ok, "toPath matched")
+	core.AssertEqual(t, tc.path, path, "toPath")
+}
+
+func newTranslateTestCase(name, rulePath, ruleTopic, path, topic string) translateTestCase {
+	return translateTestCase{
+		rule:  Rule{Path: rulePath, Topic: ruleTopic},
+		name:  name,
+		path:  path,
+		topic: topic,
+	}
+}
+
+func translateTestCases() []translateTestCase {
+	return core.S(
+		newTranslateTestCase("exact", "/status", "fleet/status", "/status", "fleet/status"),
+		newTranslateTestCase("param", "/devices/{id}/status", "fleet/+/status",
+			"/devices/42/status", "fleet/42/status"),
+		newTranslateTestCase("plus", "/devices/+/+", "+/fleet/+", "/devices/42/temp", "42/fleet/temp"),
+		newTranslateTestCase("rest", "/sensors/*", "site/sensors/#",
+			"/sensors/hall/temp", "site/sensors/hall/temp"),
+		newTranslateTestCase("empty rest", "/sensors/*", "site/sensors/#", "/sensors", "site/sensors"),
+	)
+}
+
+func TestRule_translate(t *testing.T) {
+	core.RunTestCases(t, translateTestCases())
+}
+
+func TestRule_mismatch(t *testing.T) {
+	r, err := newRule(Rule{Path: "/devices/{id}/status", Topic: "fleet/+/status"})
+	core.AssertMustNoError(t, err, "newRule")
+
+	for _, path := range core.S("/devices/42", "/devices/42/status/x", "/device/42/status") {
+		_, ok := r.toTopic(path)
+		core.AssertFalse(t, ok, "toTopic %q", path)
+	}
+	for _, topic := range core.S("fleet/42", "fleet/42/status/x", "other/42/status") {
+		_, ok := r.toPath(topic)
+		core.AssertFalse(t, ok, "toPath %q", topic)
+	}
+}
+
+func TestRule_invalid(t *testing.T) {
+	for _, r := range core.S(
+		Rule{Path: "devices", Topic: "devices"},
+		Rule{Path: "/devices"},
+		Rule{Path: "/devices/+", Topic: "devices"},
+		Rule{Path: "/devices/*", Topic: "devices/+"},
+		Rule{Path: "/devices/*/status", Topic: "devices/#/status"},
+		Rule{Path: "/devices/x+", Topic: "devices/x+"},
+		Rule{Path: "/devices", Topic: "devices", Direction: Inbound + 1},
+	) {
+		_, err := newRule(r)
+		core.AssertErrorIs(t, err, ErrInvalidRule, "%q %q", r.Path, r.Topic)
+	}
+}
@@ -3,6 +3,7 @@ package nanorpc
 //go:generate ./nanorpc.sh
 
 import (
+	"bufio"
 	"bytes"
 	"io"
 	"math"
@@ -15,10 +16,21 @@ import (
 	"darvaza.org/core"
 )
 
+// DefaultMaxMessageSize is the largest wrapped message, length prefix
+// excluded, sessions read unless configured otherwise.
+const DefaultMaxMessageSize = 64 * 1024
+
 // DecodeResponse attempts to decode a wrapped NanoRPC response
 // from a buffer.
 func DecodeResponse(data []byte) (*NanoRPCResponse, int, error) {
-	_, to, err := DecodeSplit(data)
+	return DecodeResponseMax(data, 0)
+}
+
+// DecodeResponseMax attempts to decode a wrapped NanoRPC response
+// from a buffer, failing with [ErrMessageTooLarge] if its length prefix
+// declares more than maxSize bytes. A maxSize of zero sets no limit.
+func DecodeResponseMax(data []byte, maxSize int) (*NanoRPCResponse, int, error) {
+	_, to, err := DecodeSplitMax(data, maxSize)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -49,7 +61,14 @@ func DecodeResponseData[T proto.Message](res *NanoRPCResponse, out T) (T, bool,
 // DecodeRequest attempts to decode a wrapped NanoRPC request
 // from a buffer
 func DecodeRequest(data []byte) (*NanoRPCRequest, int, error) {
-	_, to, err := DecodeSplit(data)
+	return DecodeRequestMax(data, 0)
+}
+
+// DecodeRequestMax attempts to decode a wrapped NanoRPC request
+// from a buffer, failing with [ErrMessageTooLarge] if its length prefix
+// declares more than maxSize bytes. A maxSize of zero sets no limit.
+func DecodeRequestMax(data []byte, maxSize int) (*NanoRPCRequest, int, error) {
+	_, to, err := DecodeSplitMax(data, maxSize)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -129,9 +148,34 @@ func EncodeResponse(res *NanoRPCResponse, data proto.Message) ([]byte, error) {
 	return buf.Bytes(), err
 }
 
+// AppendResponse appends a wrapped NanoRPC response to dst, as
+// [EncodeResponse] encodes it, so callers can reuse their buffers.
+func AppendResponse(dst []byte, res *NanoRPCResponse) ([]byte, error) {
+	o := proto.MarshalOptions{}
+	size := o.Size(res)
+	dst = protowire.AppendVarint(dst, uint64(size))
+
+	o.UseCachedSize = true
+	return o.MarshalAppend(dst, res)
+}
+
 // Split identifies a NanoRPC wrapped message from a buffer.
 func Split(data []byte, atEOF bool) (advance int, msg []byte, err error) {
-	_, n, err := DecodeSplit(data)
+	return split(data, atEOF, 0)
+}
+
+// SplitMax returns a [bufio.SplitFunc] identifying NanoRPC wrapped
+// messages like [Split], but failing with [ErrMessageTooLarge] as soon as
+// a length prefix declares more than maxSize bytes, before buffering the
+// message. A maxSize of zero sets no limit.
+func SplitMax(maxSize int) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		return split(data, atEOF, maxSize)
+	}
+}
+
+func split(data []byte, atEOF bool, maxSize int) (advance int, msg []byte, err error) {
+	_, n, err := DecodeSplitMax(data, maxSize)
 
 	switch {
 	case err == io.ErrUnexpectedEOF && !atEOF:
@@ -153,7 +197,8 @@ func DecodeSplit(data []byte) (prefixLen, totalLen int, err error) {
 		return 0, 0, err
 	}
 
-	if size > math.MaxInt32 {
+	if size > uint64(math.MaxInt32-prefixLen) {
+		// totalLen must fit an int on 32-bit platforms too
 		err = core.Wrapf(os.ErrInvalid, "size out of range: %v", size)
 		return prefixLen, 0, err
 	}
@@ -165,3 +210,16 @@ func DecodeSplit(data []byte) (prefixLen, totalLen int, err error) {
 
 	return prefixLen, totalLen, err
 }
+
+// DecodeSplitMax identifies the size of the wrapped message like
+// [DecodeSplit], failing with [ErrMessageTooLarge] if it's larger than
+// maxSize, length prefix excluded. A maxSize of zero sets no limit.
+func DecodeSplitMax(data []byte, maxSize int) (prefixLen, totalLen int, err error) {
+	prefixLen, totalLen, err = DecodeSplit(data)
+	if size := totalLen - prefixLen; maxSize > 0 && size > maxSize {
+		err = core.QuietWrap(ErrMessageTooLarge, "message too large: %d bytes, limit %d", size, maxSize)
+		return prefixLen, 0, err
+	}
+
+	return prefixLen, totalLen, err
+}
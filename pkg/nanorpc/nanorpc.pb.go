@@ -76,6 +76,8 @@
 // ├─────────────────────────┼──────────────────────────┤
 // │ Unknown path            │ STATUS_NOT_FOUND         │
 // │ Hash collision          │ STATUS_INTERNAL_ERROR    │
+// │ Invalid request data    │ STATUS_BAD_REQUEST       │
+// │ Handler timeout         │ STATUS_TIMEOUT           │
 // │ Handler error           │ STATUS_INTERNAL_ERROR    │
 // │ Invalid message         │ Connection closed        │
 // └─────────────────────────┴──────────────────────────┘
@@ -119,6 +121,7 @@ const (
 	NanoRPCRequest_TYPE_PING        NanoRPCRequest_Type = 1 // Health check request
 	NanoRPCRequest_TYPE_REQUEST     NanoRPCRequest_Type = 2 // RPC call or unsubscribe (empty data)
 	NanoRPCRequest_TYPE_SUBSCRIBE   NanoRPCRequest_Type = 3 // Subscribe to updates with optional filter
+	NanoRPCRequest_TYPE_ACK         NanoRPCRequest_Type = 4 // Acknowledge updates of an acknowledged subscription
 )
 
 // Enum value maps for NanoRPCRequest_Type.
@@ -128,12 +131,14 @@ var (
 		1: "TYPE_PING",
 		2: "TYPE_REQUEST",
 		3: "TYPE_SUBSCRIBE",
+		4: "TYPE_ACK",
 	}
 	NanoRPCRequest_Type_value = map[string]int32{
 		"TYPE_UNSPECIFIED": 0,
 		"TYPE_PING":        1,
 		"TYPE_REQUEST":     2,
 		"TYPE_SUBSCRIBE":   3,
+		"TYPE_ACK":         4,
 	}
 )
 
@@ -219,11 +224,15 @@ func (NanoRPCResponse_Type) EnumDescriptor() ([]byte, []int) {
 type NanoRPCResponse_Status int32
 
 const (
-	NanoRPCResponse_STATUS_UNSPECIFIED    NanoRPCResponse_Status = 0 // Invalid/unset status
-	NanoRPCResponse_STATUS_OK             NanoRPCResponse_Status = 1 // Success
-	NanoRPCResponse_STATUS_NOT_FOUND      NanoRPCResponse_Status = 2 // Path/handler not found
-	NanoRPCResponse_STATUS_NOT_AUTHORIZED NanoRPCResponse_Status = 3 // Authorisation failure
-	NanoRPCResponse_STATUS_INTERNAL_ERROR NanoRPCResponse_Status = 4 // Server error
+	NanoRPCResponse_STATUS_UNSPECIFIED       NanoRPCResponse_Status = 0 // Invalid/unset status
+	NanoRPCResponse_STATUS_OK                NanoRPCResponse_Status = 1 // Success
+	NanoRPCResponse_STATUS_NOT_FOUND         NanoRPCResponse_Status = 2 // Path/handler not found
+	NanoRPCResponse_STATUS_NOT_AUTHORIZED    NanoRPCResponse_Status = 3 // Authorisation failure
+	NanoRPCResponse_STATUS_INTERNAL_ERROR    NanoRPCResponse_Status = 4 // Server error
+	NanoRPCResponse_STATUS_BAD_REQUEST       NanoRPCResponse_Status = 5 // Malformed or invalid request
+	NanoRPCResponse_STATUS_UNAVAILABLE       NanoRPCResponse_Status = 6 // Service temporarily unavailable
+	NanoRPCResponse_STATUS_TIMEOUT           NanoRPCResponse_Status = 7 // Request not completed in time
+	NanoRPCResponse_STATUS_TOO_MANY_REQUESTS NanoRPCResponse_Status = 8 // Rate or concurrency limit exceeded
 )
 
 // Enum value maps for NanoRPCResponse_Status.
@@ -234,13 +243,21 @@ var (
 		2: "STATUS_NOT_FOUND",
 		3: "STATUS_NOT_AUTHORIZED",
 		4: "STATUS_INTERNAL_ERROR",
+		5: "STATUS_BAD_REQUEST",
+		6: "STATUS_UNAVAILABLE",
+		7: "STATUS_TIMEOUT",
+		8: "STATUS_TOO_MANY_REQUESTS",
 	}
 	NanoRPCResponse_Status_value = map[string]int32{
-		"STATUS_UNSPECIFIED":    0,
-		"STATUS_OK":             1,
-		"STATUS_NOT_FOUND":      2,
-		"STATUS_NOT_AUTHORIZED": 3,
-		"STATUS_INTERNAL_ERROR": 4,
+		"STATUS_UNSPECIFIED":       0,
+		"STATUS_OK":                1,
+		"STATUS_NOT_FOUND":         2,
+		"STATUS_NOT_AUTHORIZED":    3,
+		"STATUS_INTERNAL_ERROR":    4,
+		"STATUS_BAD_REQUEST":       5,
+		"STATUS_UNAVAILABLE":       6,
+		"STATUS_TIMEOUT":           7,
+		"STATUS_TOO_MANY_REQUESTS": 8,
 	}
 )
 
@@ -295,8 +312,37 @@ type NanoRPCRequest struct {
 	//	*NanoRPCRequest_PathHash
 	//	*NanoRPCRequest_Path
 	PathOneof isNanoRPCRequest_PathOneof `protobuf_oneof:"path_oneof"`
+	// For TYPE_SUBSCRIBE: sequence of the last update the client received on
+	// this path before losing its previous subscription. When non-zero, the
+	// server replays the newer updates it still holds, or sends a snapshot
+	// when it cannot cover the gap. Zero subscribes without catch-up.
+	ResumeAfter uint64 `protobuf:"varint,5,opt,name=resume_after,json=resumeAfter,proto3" json:"resume_after,omitempty"`
+	// Set when data is compressed with DEFLATE (RFC 1951). Only sent to
+	// peers that announced compression in their handshake.
+	Compressed bool `protobuf:"varint,6,opt,name=compressed,proto3" json:"compressed,omitempty"`
+	// For TYPE_SUBSCRIBE: how many of the latest updates on the path to
+	// replay right after the acknowledgement, bounded by what the server
+	// keeps. Ignored when resume_after is set.
+	History uint32 `protobuf:"varint,7,opt,name=history,proto3" json:"history,omitempty"`
+	// For TYPE_SUBSCRIBE: asks for acknowledged updates. The server numbers
+	// them and sends again those the client doesn't acknowledge with a
+	// TYPE_ACK. Needs a replay buffer on the path, otherwise ignored.
+	Acknowledged bool `protobuf:"varint,8,opt,name=acknowledged,proto3" json:"acknowledged,omitempty"`
+	// For TYPE_ACK: sequence of the last update received in order on the
+	// subscription identified by request_id and path. Acknowledging the same
+	// sequence twice reports the updates after it missing.
+	AckSequence uint64 `protobuf:"varint,9,opt,name=ack_sequence,json=ackSequence,proto3" json:"ack_sequence,omitempty"`
+	// Key-value pairs carried apart from the data, e.g. auth tokens, trace
+	// IDs or content-type hints. Keys may repeat. Peers ignore those they
+	// don't know, and those predating metadata ignore the field.
+	Metadata []*NanoRPCMetadata `protobuf:"bytes,11,rep,name=metadata,proto3" json:"metadata,omitempty"`
+	// Milliseconds the client waits for the response, counted from when it
+	// sent the request; zero for no limit. Servers cancel the handler once
+	// it passes, so deadlines carry through gateways. Relative, as devices
+	// can't be expected to share a clock.
+	TimeoutMs uint32 `protobuf:"varint,12,opt,name=timeout_ms,json=timeoutMs,proto3" json:"timeout_ms,omitempty"`
 	// Request payload data. Usage varies by request type:
-	// - TYPE_PING: unused (should be empty)
+	// - TYPE_PING: handshake (NanoRPCHello) or empty
 	// - TYPE_REQUEST: RPC parameters or empty for unsubscribe
 	// - TYPE_SUBSCRIBE: filter criteria or empty for all updates
 	// Uses nanopb callback type for zero-copy handling on embedded systems.
@@ -370,6 +416,55 @@ func (x *NanoRPCRequest) GetPath() string {
 	return ""
 }
 
+func (x *NanoRPCRequest) GetResumeAfter() uint64 {
+	if x != nil {
+		return x.ResumeAfter
+	}
+	return 0
+}
+
+func (x *NanoRPCRequest) GetCompressed() bool {
+	if x != nil {
+		return x.Compressed
+	}
+	return false
+}
+
+func (x *NanoRPCRequest) GetHistory() uint32 {
+	if x != nil {
+		return x.History
+	}
+	return 0
+}
+
+func (x *NanoRPCRequest) GetAcknowledged() bool {
+	if x != nil {
+		return x.Acknowledged
+	}
+	return false
+}
+
+func (x *NanoRPCRequest) GetAckSequence() uint64 {
+	if x != nil {
+		return x.AckSequence
+	}
+	return 0
+}
+
+func (x *NanoRPCRequest) GetMetadata() []*NanoRPCMetadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *NanoRPCRequest) GetTimeoutMs() uint32 {
+	if x != nil {
+		return x.TimeoutMs
+	}
+	return 0
+}
+
 func (x *NanoRPCRequest) GetData() []byte {
 	if x != nil {
 		return x.Data
@@ -412,8 +507,29 @@ type NanoRPCResponse struct {
 	// Human-readable status message, typically used for errors.
 	// Optional field, may be empty for successful operations.
 	ResponseMessage string `protobuf:"bytes,4,opt,name=response_message,json=responseMessage,proto3" json:"response_message,omitempty"`
+	// Server-side processing timestamps, only present when the server has
+	// timestamping enabled. Lets clients tell server processing time apart
+	// from network time.
+	Timestamps *NanoRPCTimestamps `protobuf:"bytes,5,opt,name=timestamps,proto3" json:"timestamps,omitempty"`
+	// Per-path sequence number of a TYPE_UPDATE, or the path's current
+	// sequence on a subscription acknowledgement. Zero when the server keeps
+	// no replay buffer for the path.
+	Sequence uint64 `protobuf:"varint,6,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	// Set on the acknowledgement and the update that follows it when a
+	// resumed subscription could not be caught up, meaning updates were
+	// lost and the update carries the latest state instead.
+	Snapshot bool `protobuf:"varint,7,opt,name=snapshot,proto3" json:"snapshot,omitempty"`
+	// Set when data is compressed with DEFLATE (RFC 1951). Only sent to
+	// peers that announced compression in their handshake.
+	Compressed bool `protobuf:"varint,8,opt,name=compressed,proto3" json:"compressed,omitempty"`
+	// For TYPE_UPDATE on a pattern subscription: the path the update was
+	// published on. Empty otherwise.
+	Path string `protobuf:"bytes,9,opt,name=path,proto3" json:"path,omitempty"`
+	// Key-value pairs carried apart from the data, set by the handler. See
+	// NanoRPCRequest.metadata.
+	Metadata []*NanoRPCMetadata `protobuf:"bytes,11,rep,name=metadata,proto3" json:"metadata,omitempty"`
 	// Response payload data. Usage varies by response type:
-	// - TYPE_PONG: unused (should be empty)
+	// - TYPE_PONG: handshake (NanoRPCHello) or empty
 	// - TYPE_RESPONSE: RPC result data or subscription confirmation
 	// - TYPE_UPDATE: subscription update payload
 	// Uses nanopb callback type for zero-copy handling on embedded systems.
@@ -480,6 +596,48 @@ func (x *NanoRPCResponse) GetResponseMessage() string {
 	return ""
 }
 
+func (x *NanoRPCResponse) GetTimestamps() *NanoRPCTimestamps {
+	if x != nil {
+		return x.Timestamps
+	}
+	return nil
+}
+
+func (x *NanoRPCResponse) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *NanoRPCResponse) GetSnapshot() bool {
+	if x != nil {
+		return x.Snapshot
+	}
+	return false
+}
+
+func (x *NanoRPCResponse) GetCompressed() bool {
+	if x != nil {
+		return x.Compressed
+	}
+	return false
+}
+
+func (x *NanoRPCResponse) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *NanoRPCResponse) GetMetadata() []*NanoRPCMetadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
 func (x *NanoRPCResponse) GetData() []byte {
 	if x != nil {
 		return x.Data
@@ -487,6 +645,63 @@ func (x *NanoRPCResponse) GetData() []byte {
 	return nil
 }
 
+// Key-value pair of the metadata of a request or response. Keys are
+// lowercase, e.g. "authorization" or "content-type".
+type NanoRPCMetadata struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Key   string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (x *NanoRPCMetadata) Reset() {
+	*x = NanoRPCMetadata{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_nanorpc_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NanoRPCMetadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NanoRPCMetadata) ProtoMessage() {}
+
+func (x *NanoRPCMetadata) ProtoReflect() protoreflect.Message {
+	mi := &file_nanorpc_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NanoRPCMetadata.ProtoReflect.Descriptor instead.
+func (*NanoRPCMetadata) Descriptor() ([]byte, []int) {
+	return file_nanorpc_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *NanoRPCMetadata) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *NanoRPCMetadata) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
 // NanoRPC-specific options for gRPC method definitions.
 // Enables declarative request path specification in protobuf service definitions.
 // This allows gateway services to translate between NanoRPC and gRPC seamlessly.
@@ -504,7 +719,7 @@ type NanoRPCMethodOptions struct {
 func (x *NanoRPCMethodOptions) Reset() {
 	*x = NanoRPCMethodOptions{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_nanorpc_proto_msgTypes[2]
+		mi := &file_nanorpc_proto_msgTypes[3]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -517,7 +732,7 @@ func (x *NanoRPCMethodOptions) String() string {
 func (*NanoRPCMethodOptions) ProtoMessage() {}
 
 func (x *NanoRPCMethodOptions) ProtoReflect() protoreflect.Message {
-	mi := &file_nanorpc_proto_msgTypes[2]
+	mi := &file_nanorpc_proto_msgTypes[3]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -530,7 +745,7 @@ func (x *NanoRPCMethodOptions) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use NanoRPCMethodOptions.ProtoReflect.Descriptor instead.
 func (*NanoRPCMethodOptions) Descriptor() ([]byte, []int) {
-	return file_nanorpc_proto_rawDescGZIP(), []int{2}
+	return file_nanorpc_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *NanoRPCMethodOptions) GetRequestPath() string {
@@ -540,6 +755,142 @@ func (x *NanoRPCMethodOptions) GetRequestPath() string {
 	return ""
 }
 
+// Server-side timestamps attached to responses, in microseconds since the
+// Unix epoch as seen by the server clock. Only their difference is
+// meaningful to clients, so clock skew does not matter.
+type NanoRPCTimestamps struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ReceivedUs  uint64 `protobuf:"varint,1,opt,name=received_us,json=receivedUs,proto3" json:"received_us,omitempty"`    // Request decoded by the server
+	ProcessedUs uint64 `protobuf:"varint,2,opt,name=processed_us,json=processedUs,proto3" json:"processed_us,omitempty"` // Response handed to the transport
+}
+
+func (x *NanoRPCTimestamps) Reset() {
+	*x = NanoRPCTimestamps{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_nanorpc_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NanoRPCTimestamps) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NanoRPCTimestamps) ProtoMessage() {}
+
+func (x *NanoRPCTimestamps) ProtoReflect() protoreflect.Message {
+	mi := &file_nanorpc_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NanoRPCTimestamps.ProtoReflect.Descriptor instead.
+func (*NanoRPCTimestamps) Descriptor() ([]byte, []int) {
+	return file_nanorpc_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *NanoRPCTimestamps) GetReceivedUs() uint64 {
+	if x != nil {
+		return x.ReceivedUs
+	}
+	return 0
+}
+
+func (x *NanoRPCTimestamps) GetProcessedUs() uint64 {
+	if x != nil {
+		return x.ProcessedUs
+	}
+	return 0
+}
+
+// Handshake payload of TYPE_PING and TYPE_PONG messages, exchanging the
+// protocol version and optional features supported by each peer. Peers
+// predating the handshake send pings without data, and answer with empty
+// pongs.
+type NanoRPCHello struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Version  uint32 `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`   // Protocol version, 1 or later
+	Features uint32 `protobuf:"varint,2,opt,name=features,proto3" json:"features,omitempty"` // Bitmask of supported features, see NANORPC_PROTOCOL.md
+	// Last will of a client: published by the server on will_path, with
+	// will_data, when the session ends without the client handshaking
+	// again without one first. Ignored in server handshakes.
+	WillPath string `protobuf:"bytes,3,opt,name=will_path,json=willPath,proto3" json:"will_path,omitempty"`
+	WillData []byte `protobuf:"bytes,4,opt,name=will_data,json=willData,proto3" json:"will_data,omitempty"`
+}
+
+func (x *NanoRPCHello) Reset() {
+	*x = NanoRPCHello{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_nanorpc_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NanoRPCHello) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NanoRPCHello) ProtoMessage() {}
+
+func (x *NanoRPCHello) ProtoReflect() protoreflect.Message {
+	mi := &file_nanorpc_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NanoRPCHello.ProtoReflect.Descriptor instead.
+func (*NanoRPCHello) Descriptor() ([]byte, []int) {
+	return file_nanorpc_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *NanoRPCHello) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *NanoRPCHello) GetFeatures() uint32 {
+	if x != nil {
+		return x.Features
+	}
+	return 0
+}
+
+func (x *NanoRPCHello) GetWillPath() string {
+	if x != nil {
+		return x.WillPath
+	}
+	return ""
+}
+
+func (x *NanoRPCHello) GetWillData() []byte {
+	if x != nil {
+		return x.WillData
+	}
+	return nil
+}
+
 var file_nanorpc_proto_extTypes = []protoimpl.ExtensionInfo{
 	{
 		ExtendedType:  (*descriptorpb.MethodOptions)(nil),
@@ -564,7 +915,7 @@ var file_nanorpc_proto_rawDesc = []byte{
 	0x20, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
 	0x2f, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x6f, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74,
 	0x6f, 0x1a, 0x0c, 0x6e, 0x61, 0x6e, 0x6f, 0x70, 0x62, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22,
-	0xa0, 0x02, 0x0a, 0x0e, 0x4e, 0x61, 0x6e, 0x6f, 0x52, 0x50, 0x43, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0xa6, 0x04, 0x0a, 0x0e, 0x4e, 0x61, 0x6e, 0x6f, 0x52, 0x50, 0x43, 0x52, 0x65, 0x71, 0x75, 0x65,
 	0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x69, 0x64,
 	0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49,
 	0x64, 0x12, 0x37, 0x0a, 0x0c, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x74, 0x79, 0x70,
@@ -574,56 +925,112 @@ var file_nanorpc_proto_rawDesc = []byte{
 	0x74, 0x68, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x48, 0x00, 0x52,
 	0x08, 0x70, 0x61, 0x74, 0x68, 0x48, 0x61, 0x73, 0x68, 0x12, 0x1b, 0x0a, 0x04, 0x70, 0x61, 0x74,
 	0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x42, 0x05, 0x92, 0x3f, 0x02, 0x08, 0x32, 0x48, 0x00,
-	0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x19, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x0a,
-	0x20, 0x01, 0x28, 0x0c, 0x42, 0x05, 0x92, 0x3f, 0x02, 0x18, 0x01, 0x52, 0x04, 0x64, 0x61, 0x74,
-	0x61, 0x22, 0x51, 0x0a, 0x04, 0x54, 0x79, 0x70, 0x65, 0x12, 0x14, 0x0a, 0x10, 0x54, 0x59, 0x50,
-	0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12,
-	0x0d, 0x0a, 0x09, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x50, 0x49, 0x4e, 0x47, 0x10, 0x01, 0x12, 0x10,
-	0x0a, 0x0c, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x52, 0x45, 0x51, 0x55, 0x45, 0x53, 0x54, 0x10, 0x02,
-	0x12, 0x12, 0x0a, 0x0e, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x53, 0x55, 0x42, 0x53, 0x43, 0x52, 0x49,
-	0x42, 0x45, 0x10, 0x03, 0x42, 0x0c, 0x0a, 0x0a, 0x70, 0x61, 0x74, 0x68, 0x5f, 0x6f, 0x6e, 0x65,
-	0x6f, 0x66, 0x22, 0xc2, 0x03, 0x0a, 0x0f, 0x4e, 0x61, 0x6e, 0x6f, 0x52, 0x50, 0x43, 0x52, 0x65,
-	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x49, 0x64, 0x12, 0x3a, 0x0a, 0x0d, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x15, 0x2e, 0x4e,
-	0x61, 0x6e, 0x6f, 0x52, 0x50, 0x43, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x54,
-	0x79, 0x70, 0x65, 0x52, 0x0c, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x54, 0x79, 0x70,
-	0x65, 0x12, 0x40, 0x0a, 0x0f, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x5f, 0x73, 0x74,
-	0x61, 0x74, 0x75, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x17, 0x2e, 0x4e, 0x61, 0x6e,
-	0x6f, 0x52, 0x50, 0x43, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x53, 0x74, 0x61,
-	0x74, 0x75, 0x73, 0x52, 0x0e, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x53, 0x74, 0x61,
-	0x74, 0x75, 0x73, 0x12, 0x29, 0x0a, 0x10, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x5f,
-	0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x72,
-	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x19,
+	0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x21, 0x0a, 0x0c, 0x72, 0x65, 0x73, 0x75, 0x6d, 0x65,
+	0x5f, 0x61, 0x66, 0x74, 0x65, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0b, 0x72, 0x65,
+	0x73, 0x75, 0x6d, 0x65, 0x41, 0x66, 0x74, 0x65, 0x72, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x6f, 0x6d,
+	0x70, 0x72, 0x65, 0x73, 0x73, 0x65, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x63,
+	0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x65, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x68, 0x69, 0x73,
+	0x74, 0x6f, 0x72, 0x79, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x68, 0x69, 0x73, 0x74,
+	0x6f, 0x72, 0x79, 0x12, 0x22, 0x0a, 0x0c, 0x61, 0x63, 0x6b, 0x6e, 0x6f, 0x77, 0x6c, 0x65, 0x64,
+	0x67, 0x65, 0x64, 0x18, 0x08, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0c, 0x61, 0x63, 0x6b, 0x6e, 0x6f,
+	0x77, 0x6c, 0x65, 0x64, 0x67, 0x65, 0x64, 0x12, 0x21, 0x0a, 0x0c, 0x61, 0x63, 0x6b, 0x5f, 0x73,
+	0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0b, 0x61,
+	0x63, 0x6b, 0x53, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x33, 0x0a, 0x08, 0x6d, 0x65,
+	0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x0b, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x4e,
+	0x61, 0x6e, 0x6f, 0x52, 0x50, 0x43, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x42, 0x05,
+	0x92, 0x3f, 0x02, 0x18, 0x01, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12,
+	0x1d, 0x0a, 0x0a, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x5f, 0x6d, 0x73, 0x18, 0x0c, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x4d, 0x73, 0x12, 0x19,
 	0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0c, 0x42, 0x05, 0x92, 0x3f,
-	0x02, 0x18, 0x01, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x22, 0x4f, 0x0a, 0x04, 0x54, 0x79, 0x70,
+	0x02, 0x18, 0x01, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x22, 0x5f, 0x0a, 0x04, 0x54, 0x79, 0x70,
 	0x65, 0x12, 0x14, 0x0a, 0x10, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43,
 	0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0d, 0x0a, 0x09, 0x54, 0x59, 0x50, 0x45, 0x5f,
-	0x50, 0x4f, 0x4e, 0x47, 0x10, 0x01, 0x12, 0x11, 0x0a, 0x0d, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x52,
-	0x45, 0x53, 0x50, 0x4f, 0x4e, 0x53, 0x45, 0x10, 0x02, 0x12, 0x0f, 0x0a, 0x0b, 0x54, 0x59, 0x50,
-	0x45, 0x5f, 0x55, 0x50, 0x44, 0x41, 0x54, 0x45, 0x10, 0x03, 0x22, 0x7b, 0x0a, 0x06, 0x53, 0x74,
-	0x61, 0x74, 0x75, 0x73, 0x12, 0x16, 0x0a, 0x12, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x55,
-	0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0d, 0x0a, 0x09,
-	0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x4f, 0x4b, 0x10, 0x01, 0x12, 0x14, 0x0a, 0x10, 0x53,
-	0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x4e, 0x4f, 0x54, 0x5f, 0x46, 0x4f, 0x55, 0x4e, 0x44, 0x10,
-	0x02, 0x12, 0x19, 0x0a, 0x15, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x4e, 0x4f, 0x54, 0x5f,
-	0x41, 0x55, 0x54, 0x48, 0x4f, 0x52, 0x49, 0x5a, 0x45, 0x44, 0x10, 0x03, 0x12, 0x19, 0x0a, 0x15,
-	0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x49, 0x4e, 0x54, 0x45, 0x52, 0x4e, 0x41, 0x4c, 0x5f,
-	0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x04, 0x22, 0x4f, 0x0a, 0x14, 0x4e, 0x61, 0x6e, 0x6f, 0x52,
-	0x50, 0x43, 0x4d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12,
-	0x26, 0x0a, 0x0c, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x70, 0x61, 0x74, 0x68, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x0b, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x50, 0x61, 0x74, 0x68, 0x88, 0x01, 0x01, 0x42, 0x0f, 0x0a, 0x0d, 0x5f, 0x72, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x5f, 0x70, 0x61, 0x74, 0x68, 0x3a, 0x50, 0x0a, 0x07, 0x6e, 0x61, 0x6e, 0x6f,
-	0x72, 0x70, 0x63, 0x12, 0x1e, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x4f, 0x70, 0x74, 0x69,
-	0x6f, 0x6e, 0x73, 0x18, 0x9c, 0x27, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x4e, 0x61, 0x6e,
-	0x6f, 0x52, 0x50, 0x43, 0x4d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e,
-	0x73, 0x52, 0x07, 0x6e, 0x61, 0x6e, 0x6f, 0x72, 0x70, 0x63, 0x42, 0x27, 0x92, 0x3f, 0x02, 0x20,
-	0x00, 0x5a, 0x20, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x6d, 0x63, 0x70, 0x2e, 0x6f, 0x72, 0x67, 0x2f,
-	0x6e, 0x61, 0x6e, 0x6f, 0x72, 0x70, 0x63, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x6e, 0x61, 0x6e, 0x6f,
-	0x72, 0x70, 0x63, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x50, 0x49, 0x4e, 0x47, 0x10, 0x01, 0x12, 0x10, 0x0a, 0x0c, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x52,
+	0x45, 0x51, 0x55, 0x45, 0x53, 0x54, 0x10, 0x02, 0x12, 0x12, 0x0a, 0x0e, 0x54, 0x59, 0x50, 0x45,
+	0x5f, 0x53, 0x55, 0x42, 0x53, 0x43, 0x52, 0x49, 0x42, 0x45, 0x10, 0x03, 0x12, 0x0c, 0x0a, 0x08,
+	0x54, 0x59, 0x50, 0x45, 0x5f, 0x41, 0x43, 0x4b, 0x10, 0x04, 0x42, 0x0c, 0x0a, 0x0a, 0x70, 0x61,
+	0x74, 0x68, 0x5f, 0x6f, 0x6e, 0x65, 0x6f, 0x66, 0x22, 0x81, 0x06, 0x0a, 0x0f, 0x4e, 0x61, 0x6e,
+	0x6f, 0x52, 0x50, 0x43, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1d, 0x0a, 0x0a,
+	0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x12, 0x3a, 0x0a, 0x0d, 0x72,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0e, 0x32, 0x15, 0x2e, 0x4e, 0x61, 0x6e, 0x6f, 0x52, 0x50, 0x43, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x54, 0x79, 0x70, 0x65, 0x52, 0x0c, 0x72, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x40, 0x0a, 0x0f, 0x72, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e,
+	0x32, 0x17, 0x2e, 0x4e, 0x61, 0x6e, 0x6f, 0x52, 0x50, 0x43, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x0e, 0x72, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x29, 0x0a, 0x10, 0x72, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0f, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x4d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x12, 0x32, 0x0a, 0x0a, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x4e, 0x61, 0x6e, 0x6f, 0x52,
+	0x50, 0x43, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x73, 0x52, 0x0a, 0x74, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x65, 0x71, 0x75,
+	0x65, 0x6e, 0x63, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x73, 0x65, 0x71, 0x75,
+	0x65, 0x6e, 0x63, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74,
+	0x18, 0x07, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x73, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74,
+	0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x65, 0x64, 0x18, 0x08,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x65, 0x64,
+	0x12, 0x19, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x42, 0x05,
+	0x92, 0x3f, 0x02, 0x08, 0x32, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x33, 0x0a, 0x08, 0x6d,
+	0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x0b, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10, 0x2e,
+	0x4e, 0x61, 0x6e, 0x6f, 0x52, 0x50, 0x43, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x42,
+	0x05, 0x92, 0x3f, 0x02, 0x18, 0x01, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61,
+	0x12, 0x19, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0c, 0x42, 0x05,
+	0x92, 0x3f, 0x02, 0x18, 0x01, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x22, 0x4f, 0x0a, 0x04, 0x54,
+	0x79, 0x70, 0x65, 0x12, 0x14, 0x0a, 0x10, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50,
+	0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0d, 0x0a, 0x09, 0x54, 0x59, 0x50,
+	0x45, 0x5f, 0x50, 0x4f, 0x4e, 0x47, 0x10, 0x01, 0x12, 0x11, 0x0a, 0x0d, 0x54, 0x59, 0x50, 0x45,
+	0x5f, 0x52, 0x45, 0x53, 0x50, 0x4f, 0x4e, 0x53, 0x45, 0x10, 0x02, 0x12, 0x0f, 0x0a, 0x0b, 0x54,
+	0x59, 0x50, 0x45, 0x5f, 0x55, 0x50, 0x44, 0x41, 0x54, 0x45, 0x10, 0x03, 0x22, 0xdd, 0x01, 0x0a,
+	0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x16, 0x0a, 0x12, 0x53, 0x54, 0x41, 0x54, 0x55,
+	0x53, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12,
+	0x0d, 0x0a, 0x09, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x4f, 0x4b, 0x10, 0x01, 0x12, 0x14,
+	0x0a, 0x10, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x4e, 0x4f, 0x54, 0x5f, 0x46, 0x4f, 0x55,
+	0x4e, 0x44, 0x10, 0x02, 0x12, 0x19, 0x0a, 0x15, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x4e,
+	0x4f, 0x54, 0x5f, 0x41, 0x55, 0x54, 0x48, 0x4f, 0x52, 0x49, 0x5a, 0x45, 0x44, 0x10, 0x03, 0x12,
+	0x19, 0x0a, 0x15, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x49, 0x4e, 0x54, 0x45, 0x52, 0x4e,
+	0x41, 0x4c, 0x5f, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x04, 0x12, 0x16, 0x0a, 0x12, 0x53, 0x54,
+	0x41, 0x54, 0x55, 0x53, 0x5f, 0x42, 0x41, 0x44, 0x5f, 0x52, 0x45, 0x51, 0x55, 0x45, 0x53, 0x54,
+	0x10, 0x05, 0x12, 0x16, 0x0a, 0x12, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x55, 0x4e, 0x41,
+	0x56, 0x41, 0x49, 0x4c, 0x41, 0x42, 0x4c, 0x45, 0x10, 0x06, 0x12, 0x12, 0x0a, 0x0e, 0x53, 0x54,
+	0x41, 0x54, 0x55, 0x53, 0x5f, 0x54, 0x49, 0x4d, 0x45, 0x4f, 0x55, 0x54, 0x10, 0x07, 0x12, 0x1c,
+	0x0a, 0x18, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x54, 0x4f, 0x4f, 0x5f, 0x4d, 0x41, 0x4e,
+	0x59, 0x5f, 0x52, 0x45, 0x51, 0x55, 0x45, 0x53, 0x54, 0x53, 0x10, 0x08, 0x22, 0x48, 0x0a, 0x0f,
+	0x4e, 0x61, 0x6e, 0x6f, 0x52, 0x50, 0x43, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12,
+	0x17, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x42, 0x05, 0x92, 0x3f,
+	0x02, 0x08, 0x20, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x1c, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x42, 0x06, 0x92, 0x3f, 0x03, 0x08, 0x80, 0x01, 0x52,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x4f, 0x0a, 0x14, 0x4e, 0x61, 0x6e, 0x6f, 0x52, 0x50,
+	0x43, 0x4d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x26,
+	0x0a, 0x0c, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x70, 0x61, 0x74, 0x68, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x0b, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x50,
+	0x61, 0x74, 0x68, 0x88, 0x01, 0x01, 0x42, 0x0f, 0x0a, 0x0d, 0x5f, 0x72, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x5f, 0x70, 0x61, 0x74, 0x68, 0x22, 0x57, 0x0a, 0x11, 0x4e, 0x61, 0x6e, 0x6f, 0x52,
+	0x50, 0x43, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x73, 0x12, 0x1f, 0x0a, 0x0b,
+	0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x5f, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x0a, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x55, 0x73, 0x12, 0x21, 0x0a,
+	0x0c, 0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x65, 0x64, 0x5f, 0x75, 0x73, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x0b, 0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x65, 0x64, 0x55, 0x73,
+	0x22, 0x8c, 0x01, 0x0a, 0x0c, 0x4e, 0x61, 0x6e, 0x6f, 0x52, 0x50, 0x43, 0x48, 0x65, 0x6c, 0x6c,
+	0x6f, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x66,
+	0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x66,
+	0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x12, 0x22, 0x0a, 0x09, 0x77, 0x69, 0x6c, 0x6c, 0x5f,
+	0x70, 0x61, 0x74, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x42, 0x05, 0x92, 0x3f, 0x02, 0x08,
+	0x32, 0x52, 0x08, 0x77, 0x69, 0x6c, 0x6c, 0x50, 0x61, 0x74, 0x68, 0x12, 0x22, 0x0a, 0x09, 0x77,
+	0x69, 0x6c, 0x6c, 0x5f, 0x64, 0x61, 0x74, 0x61, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x42, 0x05,
+	0x92, 0x3f, 0x02, 0x18, 0x01, 0x52, 0x08, 0x77, 0x69, 0x6c, 0x6c, 0x44, 0x61, 0x74, 0x61, 0x3a,
+	0x50, 0x0a, 0x07, 0x6e, 0x61, 0x6e, 0x6f, 0x72, 0x70, 0x63, 0x12, 0x1e, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4d, 0x65, 0x74,
+	0x68, 0x6f, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x9c, 0x27, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x15, 0x2e, 0x4e, 0x61, 0x6e, 0x6f, 0x52, 0x50, 0x43, 0x4d, 0x65, 0x74, 0x68, 0x6f,
+	0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x07, 0x6e, 0x61, 0x6e, 0x6f, 0x72, 0x70,
+	0x63, 0x42, 0x27, 0x92, 0x3f, 0x02, 0x20, 0x00, 0x5a, 0x20, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x6d,
+	0x63, 0x70, 0x2e, 0x6f, 0x72, 0x67, 0x2f, 0x6e, 0x61, 0x6e, 0x6f, 0x72, 0x70, 0x63, 0x2f, 0x70,
+	0x6b, 0x67, 0x2f, 0x6e, 0x61, 0x6e, 0x6f, 0x72, 0x70, 0x63, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
 }
 
 var (
@@ -639,27 +1046,33 @@ func file_nanorpc_proto_rawDescGZIP() []byte {
 }
 
 var file_nanorpc_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
-var file_nanorpc_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_nanorpc_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
 var file_nanorpc_proto_goTypes = []interface{}{
 	(NanoRPCRequest_Type)(0),           // 0: NanoRPCRequest.Type
 	(NanoRPCResponse_Type)(0),          // 1: NanoRPCResponse.Type
 	(NanoRPCResponse_Status)(0),        // 2: NanoRPCResponse.Status
 	(*NanoRPCRequest)(nil),             // 3: NanoRPCRequest
 	(*NanoRPCResponse)(nil),            // 4: NanoRPCResponse
-	(*NanoRPCMethodOptions)(nil),       // 5: NanoRPCMethodOptions
-	(*descriptorpb.MethodOptions)(nil), // 6: google.protobuf.MethodOptions
+	(*NanoRPCMetadata)(nil),            // 5: NanoRPCMetadata
+	(*NanoRPCMethodOptions)(nil),       // 6: NanoRPCMethodOptions
+	(*NanoRPCTimestamps)(nil),          // 7: NanoRPCTimestamps
+	(*NanoRPCHello)(nil),               // 8: NanoRPCHello
+	(*descriptorpb.MethodOptions)(nil), // 9: google.protobuf.MethodOptions
 }
 var file_nanorpc_proto_depIdxs = []int32{
 	0, // 0: NanoRPCRequest.request_type:type_name -> NanoRPCRequest.Type
-	1, // 1: NanoRPCResponse.response_type:type_name -> NanoRPCResponse.Type
-	2, // 2: NanoRPCResponse.response_status:type_name -> NanoRPCResponse.Status
-	6, // 3: nanorpc:extendee -> google.protobuf.MethodOptions
-	5, // 4: nanorpc:type_name -> NanoRPCMethodOptions
-	5, // [5:5] is the sub-list for method output_type
-	5, // [5:5] is the sub-list for method input_type
-	4, // [4:5] is the sub-list for extension type_name
-	3, // [3:4] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	5, // 1: NanoRPCRequest.metadata:type_name -> NanoRPCMetadata
+	1, // 2: NanoRPCResponse.response_type:type_name -> NanoRPCResponse.Type
+	2, // 3: NanoRPCResponse.response_status:type_name -> NanoRPCResponse.Status
+	7, // 4: NanoRPCResponse.timestamps:type_name -> NanoRPCTimestamps
+	5, // 5: NanoRPCResponse.metadata:type_name -> NanoRPCMetadata
+	9, // 6: nanorpc:extendee -> google.protobuf.MethodOptions
+	6, // 7: nanorpc:type_name -> NanoRPCMethodOptions
+	8, // [8:8] is the sub-list for method output_type
+	8, // [8:8] is the sub-list for method input_type
+	7, // [7:8] is the sub-list for extension type_name
+	6, // [6:7] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
 }
 
 func init() { file_nanorpc_proto_init() }
@@ -693,6 +1106,18 @@ func file_nanorpc_proto_init() {
 			}
 		}
 		file_nanorpc_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*NanoRPCMetadata); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_nanorpc_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*NanoRPCMethodOptions); i {
 			case 0:
 				return &v.state
@@ -704,19 +1129,43 @@ func file_nanorpc_proto_init() {
 				return nil
 			}
 		}
+		file_nanorpc_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*NanoRPCTimestamps); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_nanorpc_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*NanoRPCHello); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	file_nanorpc_proto_msgTypes[0].OneofWrappers = []interface{}{
 		(*NanoRPCRequest_PathHash)(nil),
 		(*NanoRPCRequest_Path)(nil),
 	}
-	file_nanorpc_proto_msgTypes[2].OneofWrappers = []interface{}{}
+	file_nanorpc_proto_msgTypes[3].OneofWrappers = []interface{}{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_nanorpc_proto_rawDesc,
 			NumEnums:      3,
-			NumMessages:   3,
+			NumMessages:   6,
 			NumExtensions: 1,
 			NumServices:   0,
 		},
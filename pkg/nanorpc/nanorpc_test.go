@@ -567,3 +567,80 @@ func protoMessageTestCases() []protoMessageTestCase {
 func TestProtoMessageMethods(t *testing.T) {
 	core.RunTestCases(t, protoMessageTestCases())
 }
+
+var _ core.TestCase = splitMaxTestCase{}
+
+type splitMaxTestCase struct {
+	name    string
+	data    []byte
+	maxSize int
+	advance int
+	wantErr bool
+}
+
+func (tc splitMaxTestCase) Name() string {
+	return tc.name
+}
+
+func (tc splitMaxTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	advance, msg, err := SplitMax(tc.maxSize)(tc.data, false)
+	if tc.wantErr {
+		core.AssertErrorIs(t, err, ErrMessageTooLarge, "SplitMax")
+		core.AssertEqual(t, 0, advance, "advance")
+		return
+	}
+
+	core.AssertNoError(t, err, "SplitMax")
+	core.AssertEqual(t, tc.advance, advance, "advance")
+	core.AssertEqual(t, tc.advance, len(msg), "message")
+}
+
+func newSplitMaxTestCase(name string, data []byte, maxSize, advance int, wantErr bool) splitMaxTestCase {
+	return splitMaxTestCase{
+		name:    name,
+		data:    data,
+		maxSize: maxSize,
+		advance: advance,
+		wantErr: wantErr,
+	}
+}
+
+func splitMaxTestCases() []splitMaxTestCase {
+	msg := []byte{4, 'a', 'b', 'c', 'd'}
+	// a prefix declaring a megabyte, with none of it buffered yet
+	large := []byte{0x80, 0x80, 0x40}
+
+	return core.S(
+		newSplitMaxTestCase("within", msg, 4, 5, false),
+		newSplitMaxTestCase("unlimited", msg, 0, 5, false),
+		newSplitMaxTestCase("too_large", msg, 3, 0, true),
+		newSplitMaxTestCase("too_large_early", large, DefaultMaxMessageSize, 0, true),
+		newSplitMaxTestCase("incomplete", msg[:3], 4, 0, false),
+	)
+}
+
+func TestSplitMax(t *testing.T) {
+	core.RunTestCases(t, splitMaxTestCases())
+}
+
+func TestDecodeRequestMax(t *testing.T) {
+	data, err := EncodeRequest(&NanoRPCRequest{
+		RequestId:   1,
+		RequestType: NanoRPCRequest_TYPE_REQUEST,
+		Data:        make([]byte, 32),
+	}, nil)
+	core.AssertMustNoError(t, err, "EncodeRequest")
+
+	_, _, err = DecodeRequestMax(data, 16)
+	core.AssertErrorIs(t, err, ErrMessageTooLarge, "DecodeRequestMax")
+
+	req, n, err := DecodeRequestMax(data, len(data))
+	core.AssertNoError(t, err, "DecodeRequestMax within")
+	core.AssertEqual(t, len(data), n, "length")
+	core.AssertEqual(t, int32(1), req.GetRequestId(), "request_id")
+
+	_, _, err = DecodeResponseMax(data, 16)
+	core.AssertErrorIs(t, err, ErrMessageTooLarge, "DecodeResponseMax")
+}
@@ -0,0 +1,19 @@
+// Package nanorpctest provides in-process transports connecting a NanoRPC
+// client to a server without sockets, for integration tests and for
+// embedding both in the same process.
+//
+// An [InprocListener] is served like any other listener, and clients
+// attach to it through connections backed by [net.Pipe]:
+//
+//	ln := nanorpctest.NewInprocListener("test")
+//	srv := server.NewDefaultServer(ln, handler, nil)
+//	go srv.Serve(ctx)
+//
+//	if err := ln.Attach(ctx, c); err != nil {
+//	    return err
+//	}
+//
+// [net.Pipe] connections are synchronous, every write waits for the other
+// end to read it, so both peers need to be reading for messages to flow,
+// as NanoRPC clients and servers always are.
+package nanorpctest
@@ -0,0 +1,155 @@
+package nanorpctest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc/client"
+	"protomcp.org/nanorpc/pkg/nanorpc/server"
+)
+
+var (
+	_ net.Addr        = InprocAddr("")
+	_ net.Conn        = (*pipeConn)(nil)
+	_ net.Listener    = (*InprocListener)(nil)
+	_ server.Listener = (*InprocListener)(nil)
+)
+
+// InprocAddr is the address of an end of an in-process connection.
+type InprocAddr string
+
+// Network returns "inproc".
+func (InprocAddr) Network() string { return "inproc" }
+
+// String returns the name of the end.
+func (a InprocAddr) String() string { return string(a) }
+
+// pipeConn is an end of a [net.Pipe] with addresses of its own.
+type pipeConn struct {
+	net.Conn
+
+	local  InprocAddr
+	remote InprocAddr
+}
+
+func (c *pipeConn) LocalAddr() net.Addr  { return c.local }
+func (c *pipeConn) RemoteAddr() net.Addr { return c.remote }
+
+// Pipe returns the two ends of an in-process connection, as [net.Pipe]
+// does, the client one to pass to [client.Client.Attach] and the server
+// one to be served.
+func Pipe() (clientConn, serverConn net.Conn) {
+	return newPipe("client", "server")
+}
+
+func newPipe(clientName, serverName InprocAddr) (clientConn, serverConn net.Conn) {
+	c, s := net.Pipe()
+	clientConn = &pipeConn{Conn: c, local: clientName, remote: serverName}
+	serverConn = &pipeConn{Conn: s, local: serverName, remote: clientName}
+	return clientConn, serverConn
+}
+
+// InprocListener is a [server.Listener], and a [net.Listener], accepting
+// in-process connections made by its Dial and Attach methods instead of
+// sockets, so no port is allocated.
+type InprocListener struct {
+	accept chan net.Conn
+	done   chan struct{}
+	addr   InprocAddr
+	once   sync.Once
+	count  atomic.Uint64
+}
+
+// NewInprocListener creates an [InprocListener] with name as its address.
+func NewInprocListener(name string) *InprocListener {
+	return &InprocListener{
+		accept: make(chan net.Conn),
+		done:   make(chan struct{}),
+		addr:   InprocAddr(name),
+	}
+}
+
+// Accept waits for the next connection dialled to the listener.
+func (l *InprocListener) Accept() (net.Conn, error) {
+	if l == nil {
+		return nil, core.ErrNilReceiver
+	}
+
+	select {
+	case conn := <-l.accept:
+		return conn, nil
+	case <-l.done:
+		return nil, l.errClosed("accept")
+	}
+}
+
+// Close stops accepting connections. Those already accepted stay open.
+func (l *InprocListener) Close() error {
+	if l == nil {
+		return core.ErrNilReceiver
+	}
+
+	l.once.Do(func() { close(l.done) })
+	return nil
+}
+
+// Addr returns the address of the listener.
+func (l *InprocListener) Addr() net.Addr {
+	if l == nil {
+		return nil
+	}
+	return l.addr
+}
+
+// Dial returns the client end of a new connection, once the listener
+// accepts the other end.
+func (l *InprocListener) Dial(ctx context.Context) (net.Conn, error) {
+	if l == nil {
+		return nil, core.ErrNilReceiver
+	}
+
+	name := InprocAddr(fmt.Sprintf("%s-%d", l.addr, l.count.Add(1)))
+	clientConn, serverConn := newPipe(name, l.addr)
+
+	select {
+	case l.accept <- serverConn:
+		return clientConn, nil
+	case <-l.done:
+		return nil, closePipe(clientConn, serverConn, l.errClosed("dial"))
+	case <-ctx.Done():
+		return nil, closePipe(clientConn, serverConn, ctx.Err())
+	}
+}
+
+// errClosed is the error of an operation on a closed listener, the same
+// a [net.Listener] returns.
+func (l *InprocListener) errClosed(op string) error {
+	return &net.OpError{Op: op, Net: l.addr.Network(), Addr: l.addr, Err: net.ErrClosed}
+}
+
+// closePipe closes both ends of an unused connection and returns err.
+func closePipe(clientConn, serverConn net.Conn, err error) error {
+	_ = clientConn.Close()
+	_ = serverConn.Close()
+	return err
+}
+
+// Attach dials the listener and runs a session of c over the connection,
+// see [client.Client.Attach].
+func (l *InprocListener) Attach(ctx context.Context, c *client.Client) error {
+	conn, err := l.Dial(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := c.Attach(ctx, conn); err != nil {
+		_ = conn.Close()
+		return err
+	}
+	return nil
+}
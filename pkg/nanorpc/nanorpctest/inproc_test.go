@@ -0,0 +1,111 @@
+package nanorpctest_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/client"
+	"protomcp.org/nanorpc/pkg/nanorpc/nanorpctest"
+	"protomcp.org/nanorpc/pkg/nanorpc/server"
+)
+
+const testTimeout = 2 * time.Second
+
+// startInprocServer serves an echo handler on an [nanorpctest.InprocListener].
+func startInprocServer(t *testing.T) *nanorpctest.InprocListener {
+	t.Helper()
+
+	handler := server.NewDefaultMessageHandler(nil)
+	err := handler.RegisterHandlerFunc("/echo",
+		func(_ context.Context, req *server.RequestContext) error {
+			return req.SendOK(req.GetData())
+		})
+	core.AssertMustNoError(t, err, "register handler")
+
+	ln := nanorpctest.NewInprocListener("test")
+	srv := server.NewDefaultServer(ln, handler, nil)
+	go func() { _ = srv.Serve(context.Background()) }()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+		defer cancel()
+		_ = srv.Shutdown(ctx)
+	})
+	return ln
+}
+
+// TestInprocListener_Attach drives a request from a client attached to a
+// server through an [nanorpctest.InprocListener].
+func TestInprocListener_Attach(t *testing.T) {
+	ln := startInprocServer(t)
+
+	c, err := (&client.Config{
+		Context: context.Background(),
+		Remote:  "127.0.0.1:1",
+	}).New()
+	core.AssertMustNoError(t, err, "cfg.New")
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	core.AssertMustNoError(t, ln.Attach(ctx, c), "Attach")
+	core.AssertMustNoError(t, c.WaitConnected(ctx), "WaitConnected")
+
+	responses := make(chan *nanorpc.NanoRPCResponse, 1)
+	_, err = c.Request("/echo", nil, func(_ context.Context, _ int32, resp *nanorpc.NanoRPCResponse) error {
+		responses <- resp
+		return nil
+	})
+	core.AssertMustNoError(t, err, "Request")
+
+	select {
+	case resp := <-responses:
+		core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_OK, resp.ResponseStatus, "status")
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the response")
+	}
+}
+
+func TestInprocListener_Close(t *testing.T) {
+	ln := nanorpctest.NewInprocListener("test")
+	core.AssertEqual(t, "inproc", ln.Addr().Network(), "network")
+	core.AssertNoError(t, ln.Close(), "close")
+	core.AssertNoError(t, ln.Close(), "close again")
+
+	_, err := ln.Accept()
+	core.AssertErrorIs(t, err, net.ErrClosed, "accept after close")
+
+	_, err = ln.Dial(context.Background())
+	core.AssertErrorIs(t, err, net.ErrClosed, "dial after close")
+}
+
+func TestInprocListener_DialCancelled(t *testing.T) {
+	ln := nanorpctest.NewInprocListener("test")
+	defer ln.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := ln.Dial(ctx)
+	core.AssertErrorIs(t, err, context.Canceled, "cancelled")
+}
+
+func TestPipe(t *testing.T) {
+	clientConn, serverConn := nanorpctest.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	core.AssertEqual(t, "server", clientConn.RemoteAddr().String(), "client peer")
+	core.AssertEqual(t, "client", serverConn.RemoteAddr().String(), "server peer")
+
+	go func() { _, _ = clientConn.Write([]byte("ping")) }()
+
+	buf := make([]byte, 4)
+	n, err := serverConn.Read(buf)
+	core.AssertMustNoError(t, err, "read")
+	core.AssertEqual(t, "ping", string(buf[:n]), "data")
+}
@@ -0,0 +1,231 @@
+package nanorpc
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"darvaza.org/core"
+)
+
+var (
+	_ net.Conn = (*SerialConn)(nil)
+	_ net.Addr = SerialAddr("")
+)
+
+// SerialAddr is the address of a serial port, e.g. "/dev/ttyUSB0".
+type SerialAddr string
+
+// Network returns "serial".
+func (SerialAddr) Network() string { return "serial" }
+
+// String returns the name of the port.
+func (a SerialAddr) String() string { return string(a) }
+
+// serialDeadlines is implemented by ports supporting deadlines, like
+// an [os.File] of a terminal device.
+type serialDeadlines interface {
+	SetDeadline(t time.Time) error
+	SetReadDeadline(t time.Time) error
+	SetWriteDeadline(t time.Time) error
+}
+
+// SerialConn adapts a serial port, or any [io.ReadWriteCloser] of a link
+// like UART or RS-485, to the byte stream NanoRPC sessions expect. On the
+// link, messages are framed by [COBSCodec]; every wrapped message written
+// is sent as a frame, and received frames are read back-to-back as
+// wrapped messages. Frames that can't be decoded, or are larger than
+// [DefaultMaxMessageSize] allows, are discarded, so line noise never
+// desynchronises the stream.
+type SerialConn struct {
+	rwc  io.ReadWriteCloser
+	r    *bufio.Reader
+	addr SerialAddr
+
+	rbuf []byte // unread part of the current message
+	rmem []byte // backing array of rbuf
+	wbuf []byte // incomplete message being written
+	wmem []byte // frame being written
+	rmu  sync.Mutex
+	wmu  sync.Mutex
+
+	discard bool // dropping the rest of an oversized frame
+}
+
+// NewSerialConn wraps a serial port, owned by the [SerialConn] from then
+// on, with name as its address. Returns nil if rwc is nil.
+func NewSerialConn(rwc io.ReadWriteCloser, name string) *SerialConn {
+	if rwc == nil {
+		return nil
+	}
+
+	maxFrame := COBSCodec{}.MaxFrameSize(DefaultMaxMessageSize)
+	return &SerialConn{
+		rwc:  rwc,
+		r:    bufio.NewReaderSize(rwc, maxFrame),
+		addr: SerialAddr(name),
+	}
+}
+
+// Read reads the messages of received frames as a stream.
+func (sc *SerialConn) Read(p []byte) (int, error) {
+	if sc == nil || sc.rwc == nil {
+		return 0, core.ErrNilReceiver
+	}
+
+	sc.rmu.Lock()
+	defer sc.rmu.Unlock()
+
+	for len(sc.rbuf) == 0 {
+		if err := sc.unsafeReadFrame(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, sc.rbuf)
+	sc.rbuf = sc.rbuf[n:]
+	return n, nil
+}
+
+// unsafeReadFrame reads the next frame, leaving rbuf empty if it's empty,
+// oversized or corrupt.
+func (sc *SerialConn) unsafeReadFrame() error {
+	frame, err := sc.r.ReadSlice(0)
+	switch {
+	case err == bufio.ErrBufferFull:
+		// dropped, along with the rest of it
+		sc.discard = true
+		return nil
+	case err != nil:
+		return err
+	case sc.discard:
+		// end of an oversized frame
+		sc.discard = false
+		return nil
+	}
+
+	msg, err := COBSCodec{}.Unframe(frame[:len(frame)-1])
+	if err != nil || len(msg) == 0 || !isWellFormed(msg) {
+		return nil
+	}
+
+	sc.rmem = LengthPrefixCodec{}.AppendFrame(sc.rmem[:0], msg)
+	sc.rbuf = sc.rmem
+	return nil
+}
+
+// isWellFormed tells if msg is a sequence of valid protobuf fields.
+func isWellFormed(msg []byte) bool {
+	for len(msg) > 0 {
+		num, typ, n := protowire.ConsumeTag(msg)
+		if n < 0 {
+			return false
+		}
+		m := protowire.ConsumeFieldValue(num, typ, msg[n:])
+		if m < 0 {
+			return false
+		}
+		msg = msg[n+m:]
+	}
+	return true
+}
+
+// Write buffers p and sends every complete wrapped message in it as a
+// frame. Partial messages wait for the rest to be written.
+func (sc *SerialConn) Write(p []byte) (int, error) {
+	if sc == nil || sc.rwc == nil {
+		return 0, core.ErrNilReceiver
+	}
+
+	sc.wmu.Lock()
+	defer sc.wmu.Unlock()
+
+	sc.wbuf = append(sc.wbuf, p...)
+	if err := sc.unsafeFlush(); err != nil {
+		sc.wbuf = nil
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// unsafeFlush sends the complete messages buffered by Write.
+func (sc *SerialConn) unsafeFlush() error {
+	for len(sc.wbuf) > 0 {
+		prefixLen, total, err := DecodeSplit(sc.wbuf)
+		switch {
+		case err == io.ErrUnexpectedEOF:
+			// wait for the rest of the message
+			return nil
+		case err != nil:
+			return err
+		}
+
+		sc.wmem = COBSCodec{}.AppendFrame(sc.wmem[:0], sc.wbuf[prefixLen:total])
+		if _, err := sc.rwc.Write(sc.wmem); err != nil {
+			return err
+		}
+		sc.wbuf = sc.wbuf[total:]
+	}
+	return nil
+}
+
+// Close closes the port.
+func (sc *SerialConn) Close() error {
+	if sc == nil || sc.rwc == nil {
+		return core.ErrNilReceiver
+	}
+	return sc.rwc.Close()
+}
+
+// LocalAddr returns the name of the port.
+func (sc *SerialConn) LocalAddr() net.Addr {
+	if sc == nil {
+		return nil
+	}
+	return sc.addr
+}
+
+// RemoteAddr returns the name of the port, as the device at the other
+// end has no address of its own.
+func (sc *SerialConn) RemoteAddr() net.Addr {
+	return sc.LocalAddr()
+}
+
+// SetDeadline sets the deadlines of the port, if it supports them, and
+// is ignored otherwise.
+func (sc *SerialConn) SetDeadline(t time.Time) error {
+	if d, ok := sc.deadlines(); ok {
+		return d.SetDeadline(t)
+	}
+	return nil
+}
+
+// SetReadDeadline sets the read deadline of the port, if it supports
+// deadlines, and is ignored otherwise.
+func (sc *SerialConn) SetReadDeadline(t time.Time) error {
+	if d, ok := sc.deadlines(); ok {
+		return d.SetReadDeadline(t)
+	}
+	return nil
+}
+
+// SetWriteDeadline sets the write deadline of the port, if it supports
+// deadlines, and is ignored otherwise.
+func (sc *SerialConn) SetWriteDeadline(t time.Time) error {
+	if d, ok := sc.deadlines(); ok {
+		return d.SetWriteDeadline(t)
+	}
+	return nil
+}
+
+func (sc *SerialConn) deadlines() (serialDeadlines, bool) {
+	if sc == nil {
+		return nil, false
+	}
+	d, ok := sc.rwc.(serialDeadlines)
+	return d, ok
+}
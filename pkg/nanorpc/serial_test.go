@@ -0,0 +1,95 @@
+package nanorpc
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"darvaza.org/core"
+)
+
+// newSerialPipe returns a [SerialConn] over one end of a [net.Pipe],
+// standing for a serial link.
+func newSerialPipe(t *testing.T) (sc *SerialConn, peer net.Conn) {
+	t.Helper()
+
+	a, b := net.Pipe()
+	t.Cleanup(func() {
+		_ = a.Close()
+		_ = b.Close()
+	})
+	return NewSerialConn(a, "/dev/ttyTEST"), b
+}
+
+func TestSerialConn_WriteFramesMessages(t *testing.T) {
+	sc, peer := newSerialPipe(t)
+
+	first := mustEncodeRequest(t, 1, "/first")
+	second := mustEncodeRequest(t, 2, "/second")
+	stream := cat(first, second)
+
+	go func() {
+		// first message and a half, then the rest
+		cut := len(first) + len(second)/2
+		_, _ = sc.Write(stream[:cut])
+		_, _ = sc.Write(stream[cut:])
+	}()
+
+	buf := make([]byte, 1024)
+	for _, want := range [][]byte{first, second} {
+		n, err := peer.Read(buf)
+		core.AssertMustNoError(t, err, "read frame")
+		core.AssertEqual(t, byte(0), buf[n-1], "delimiter")
+
+		msg, err := COBSCodec{}.Unframe(buf[:n-1])
+		core.AssertMustNoError(t, err, "Unframe")
+		core.AssertSliceEqual(t, want, LengthPrefixCodec{}.AppendFrame(nil, msg), "message")
+	}
+}
+
+func TestSerialConn_ReadDropsInvalid(t *testing.T) {
+	sc, peer := newSerialPipe(t)
+
+	valid := mustEncodeRequest(t, 3, "/valid")
+	msg, err := LengthPrefixCodec{}.Unframe(valid)
+	core.AssertMustNoError(t, err, "Unframe")
+
+	go func() {
+		_, _ = peer.Write([]byte{0, 0})                                  // empty frames
+		_, _ = peer.Write([]byte{5, 1, 2, 0})                            // short block
+		_, _ = peer.Write(COBSCodec{}.AppendFrame(nil, []byte{0xff, 1})) // not protobuf
+		_, _ = peer.Write(COBSCodec{}.AppendFrame(nil, msg))
+	}()
+
+	buf := make([]byte, len(valid))
+	n, err := sc.Read(buf)
+	core.AssertMustNoError(t, err, "read")
+	core.AssertSliceEqual(t, valid, buf[:n], "message")
+}
+
+func TestSerialConn_ReadDropsOversized(t *testing.T) {
+	sc, peer := newSerialPipe(t)
+
+	valid := mustEncodeRequest(t, 4, "/valid")
+	msg, err := LengthPrefixCodec{}.Unframe(valid)
+	core.AssertMustNoError(t, err, "Unframe")
+
+	go func() {
+		big := bytes.Repeat([]byte{1}, 2*DefaultMaxMessageSize)
+		_, _ = peer.Write(COBSCodec{}.AppendFrame(nil, big))
+		_, _ = peer.Write(COBSCodec{}.AppendFrame(nil, msg))
+	}()
+
+	buf := make([]byte, len(valid))
+	n, err := sc.Read(buf)
+	core.AssertMustNoError(t, err, "read")
+	core.AssertSliceEqual(t, valid, buf[:n], "message")
+}
+
+func TestSerialConn_Addr(t *testing.T) {
+	sc, _ := newSerialPipe(t)
+
+	core.AssertEqual(t, "serial", sc.LocalAddr().Network(), "network")
+	core.AssertEqual(t, "/dev/ttyTEST", sc.RemoteAddr().String(), "name")
+	core.AssertNil(t, NewSerialConn(nil, "x"), "nil port")
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"runtime/debug"
+	"time"
+
+	"darvaza.org/core"
+)
+
+// Delays between retries of the accept loop after temporary errors,
+// doubling from acceptBackoffMin up to acceptBackoffMax.
+const (
+	acceptBackoffMin = 5 * time.Millisecond
+	acceptBackoffMax = time.Second
+)
+
+var (
+	_ error            = ListenerPanicError{}
+	_ core.Unwrappable = ListenerPanicError{}
+)
+
+// ListenerErrorHandler is called with the errors of the accept loop of a
+// [Server], see [WithOnListenerError]. Temporary errors are retried, the
+// others stop the server.
+type ListenerErrorHandler func(err error, temporary bool)
+
+// WithOnListenerError sets a function called with every error of the
+// accept loop but those caused by stopping the server, e.g. to alert when
+// the process runs out of file descriptors. It's called from the accept
+// loop, so it shouldn't block.
+func WithOnListenerError(fn ListenerErrorHandler) ServerOption {
+	return func(s *Server) {
+		s.onListenerError = fn
+	}
+}
+
+// ListenerPanicError reports a panic recovered in the accept loop of a
+// [Server], accepting or setting up a connection. The loop carries on.
+type ListenerPanicError struct {
+	// Value is what the accept loop panicked with.
+	Value any
+	// Stack is the stack trace of the panic.
+	Stack []byte
+}
+
+func (e ListenerPanicError) Error() string {
+	return fmt.Sprintf("accept loop panicked: %v", e.Value)
+}
+
+// Unwrap returns the value the accept loop panicked with, if it's an
+// error.
+func (e ListenerPanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// IsTemporaryAcceptError tells if an error of [Listener.Accept] is worth
+// retrying, as timeouts, aborted connections and running out of file
+// descriptors are, or is a [ListenerPanicError].
+func IsTemporaryAcceptError(err error) bool {
+	var pe ListenerPanicError
+	var ne net.Error
+	var te interface{ Temporary() bool }
+
+	switch {
+	case err == nil:
+		return false
+	case errors.As(err, &pe):
+		return true
+	case errors.As(err, &ne) && ne.Timeout():
+		return true
+	default:
+		return errors.As(err, &te) && te.Temporary()
+	}
+}
+
+// acceptOnce accepts and handles a connection, recovering panics as a
+// [ListenerPanicError].
+func (s *Server) acceptOnce(ctx context.Context, listener Listener) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = ListenerPanicError{Value: v, Stack: debug.Stack()}
+			s.LogError(err, nil, "Accept loop panicked")
+		}
+	}()
+
+	conn, err := listener.Accept()
+	if err != nil {
+		return err
+	}
+
+	// Check for cancellation after successful accept
+	select {
+	case <-ctx.Done():
+		_ = conn.Close()
+		return ctx.Err()
+	default:
+		s.handleNewConnection(ctx, conn)
+		return nil
+	}
+}
+
+// retryAccept tells if the accept loop carries on after err, reporting it
+// unless caused by stopping the server.
+func (s *Server) retryAccept(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || s.isExpectedAcceptError(err) {
+		return false
+	}
+
+	temporary := IsTemporaryAcceptError(err)
+	if s.onListenerError != nil {
+		s.onListenerError(err, temporary)
+	}
+	return temporary
+}
+
+// nextAcceptDelay doubles the delay before retrying to accept, within
+// [acceptBackoffMin, acceptBackoffMax].
+func nextAcceptDelay(delay time.Duration) time.Duration {
+	return min(max(2*delay, acceptBackoffMin), acceptBackoffMax)
+}
+
+// sleepAccept waits for d, or until ctx is cancelled.
+func sleepAccept(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc/utils/testutils"
+)
+
+var errAcceptFatal = errors.New("listener broken")
+
+// temporaryError is a [net.Error] worth retrying.
+type temporaryError struct{}
+
+func (temporaryError) Error() string   { return "temporary failure" }
+func (temporaryError) Timeout() bool   { return false }
+func (temporaryError) Temporary() bool { return true }
+
+// listenerErrorRecorder records the calls of a [ListenerErrorHandler].
+type listenerErrorRecorder struct {
+	errs      []error
+	temporary []bool
+	mu        sync.Mutex
+}
+
+func (r *listenerErrorRecorder) OnListenerError(err error, temporary bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.errs = append(r.errs, err)
+	r.temporary = append(r.temporary, temporary)
+}
+
+func (r *listenerErrorRecorder) Get() ([]error, []bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.errs, r.temporary
+}
+
+func newMockListenerServer(l *testutils.MockListener, r *listenerErrorRecorder) *Server {
+	handler := NewDefaultMessageHandler(nil)
+	return NewServer(l, NewDefaultSessionManager(handler, nil), handler, nil,
+		WithOnListenerError(r.OnListenerError))
+}
+
+// startServe runs s.Serve in the background, returning its error once
+// done.
+func startServe(ctx context.Context, s *Server) <-chan error {
+	errCh := make(chan error, 1)
+	go func() { errCh <- s.Serve(ctx) }()
+	return errCh
+}
+
+// waitServe waits for the error of a background Serve.
+func waitServe(t *testing.T, errCh <-chan error) error {
+	t.Helper()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-time.After(time.Second):
+		t.Fatal("Serve didn't return within 1s")
+		return nil
+	}
+}
+
+// TestServer_acceptLoop_retry verifies temporary errors and panics are
+// retried, and reported, until a fatal error stops the server.
+func TestServer_acceptLoop_retry(t *testing.T) {
+	l := testutils.NewMockListener("127.0.0.1:8080",
+		testutils.MockAccept{Err: temporaryError{}},
+		testutils.MockAccept{Panic: "boom"},
+		testutils.MockAccept{Err: errAcceptFatal},
+	)
+	var r listenerErrorRecorder
+
+	err := waitServe(t, startServe(context.Background(), newMockListenerServer(l, &r)))
+	core.AssertErrorIs(t, err, errAcceptFatal, "Serve")
+	core.AssertEqual(t, 3, l.Calls(), "accept calls")
+
+	errs, temporary := r.Get()
+	if !core.AssertEqual(t, 3, len(errs), "reported") {
+		return
+	}
+	core.AssertSliceEqual(t, []bool{true, true, false}, temporary, "temporary")
+
+	var pe ListenerPanicError
+	core.AssertTrue(t, errors.As(errs[1], &pe), "ListenerPanicError")
+	core.AssertEqual(t, any("boom"), pe.Value, "panic value")
+	core.AssertNotEqual(t, 0, len(pe.Stack), "stack")
+}
+
+// TestServer_acceptLoop_shutdown verifies closing the listener while
+// retrying stops the server without reporting it.
+func TestServer_acceptLoop_shutdown(t *testing.T) {
+	l := testutils.NewMockListener("127.0.0.1:8080",
+		testutils.MockAccept{Err: temporaryError{}},
+	)
+	var r listenerErrorRecorder
+	s := newMockListenerServer(l, &r)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-s.Ready()
+		for l.Calls() < 2 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+
+	err := waitServe(t, startServe(ctx, s))
+	if err != nil {
+		core.AssertErrorIs(t, err, context.Canceled, "Serve")
+	}
+
+	errs, _ := r.Get()
+	core.AssertEqual(t, 1, len(errs), "reported")
+}
+
+var _ core.TestCase = temporaryAcceptErrorTestCase{}
+
+type temporaryAcceptErrorTestCase struct {
+	err  error
+	name string
+	want bool
+}
+
+func (tc temporaryAcceptErrorTestCase) Name() string { return tc.name }
+
+func (tc temporaryAcceptErrorTestCase) Test(t *testing.T) {
+	t.Helper()
+	core.AssertEqual(t, tc.want, IsTemporaryAcceptError(tc.err), "IsTemporaryAcceptError")
+}
+
+func newTemporaryAcceptErrorTestCase(name string, err error, want bool) temporaryAcceptErrorTestCase {
+	return temporaryAcceptErrorTestCase{err: err, name: name, want: want}
+}
+
+func temporaryAcceptErrorTestCases() []temporaryAcceptErrorTestCase {
+	opErr := func(err error) error {
+		return &net.OpError{Op: "accept", Net: "tcp", Err: err}
+	}
+
+	return []temporaryAcceptErrorTestCase{
+		newTemporaryAcceptErrorTestCase("nil", nil, false),
+		newTemporaryAcceptErrorTestCase("temporary", temporaryError{}, true),
+		newTemporaryAcceptErrorTestCase("panic", ListenerPanicError{Value: "boom"}, true),
+		newTemporaryAcceptErrorTestCase("timeout", opErr(os.ErrDeadlineExceeded), true),
+		newTemporaryAcceptErrorTestCase("emfile", opErr(os.NewSyscallError("accept4", syscall.EMFILE)), true),
+		newTemporaryAcceptErrorTestCase("closed", opErr(net.ErrClosed), false),
+		newTemporaryAcceptErrorTestCase("fatal", errAcceptFatal, false),
+	}
+}
+
+func TestIsTemporaryAcceptError(t *testing.T) {
+	core.RunTestCases(t, temporaryAcceptErrorTestCases())
+}
+
+func TestNextAcceptDelay(t *testing.T) {
+	delay := nextAcceptDelay(0)
+	core.AssertEqual(t, acceptBackoffMin, delay, "first")
+	core.AssertEqual(t, 2*acceptBackoffMin, nextAcceptDelay(delay), "second")
+	core.AssertEqual(t, acceptBackoffMax, nextAcceptDelay(acceptBackoffMax), "max")
+}
@@ -0,0 +1,253 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// DefaultAckTimeout is how long a [DefaultMessageHandler] waits for the
+// updates of an acknowledged subscription to be acknowledged before
+// sending them again.
+const DefaultAckTimeout = time.Second
+
+// AckStats counts the updates of acknowledged subscriptions sent again,
+// see [DefaultMessageHandler.AckStats].
+type AckStats struct {
+	// Gaps counts the gaps clients reported, acknowledging the same
+	// update twice while newer ones were sent.
+	Gaps uint64
+	// Timeouts counts the times updates went unacknowledged for the
+	// acknowledgement timeout.
+	Timeouts uint64
+	// Resent counts the updates sent again.
+	Resent uint64
+}
+
+// ackCounters accumulates [AckStats] safely for concurrent use.
+type ackCounters struct {
+	gaps     atomic.Uint64
+	timeouts atomic.Uint64
+	resent   atomic.Uint64
+}
+
+// ackTracker follows the updates sent to an acknowledged subscription and
+// those the client acknowledged.
+type ackTracker struct {
+	timer    *time.Timer
+	timeout  time.Duration
+	acked    uint64 // sequence of the last update acknowledged
+	sent     uint64 // sequence of the last update sent
+	mu       sync.Mutex
+	reported bool // the gap after acked was resent already
+}
+
+// track records the sequence of an update sent, arming the timer sending
+// it again unless acknowledged in time. Nil trackers ignore it.
+func (t *ackTracker) track(h *DefaultMessageHandler, sub *ActiveSubscription, seq uint64) {
+	if t == nil || seq == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.sent = max(t.sent, seq)
+	t.unsafeArm(h, sub)
+}
+
+// acknowledge records the sequence acknowledged by the client, telling if
+// it reports the updates after it missing. Only the first repeat of a
+// sequence does, later ones are left to the timer.
+func (t *ackTracker) acknowledge(h *DefaultMessageHandler, sub *ActiveSubscription, seq uint64) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch {
+	case seq > t.acked:
+		// progress, the timer starts over
+		t.acked = min(seq, t.sent)
+		t.reported = false
+		t.unsafeStop()
+		t.unsafeArm(h, sub)
+		return false
+	case seq == t.acked && seq < t.sent && !t.reported:
+		t.reported = true
+		return true
+	default:
+		// stale or repeated
+		return false
+	}
+}
+
+// unsafeArm starts the timer if updates await their acknowledgement.
+// t.mu must be held.
+func (t *ackTracker) unsafeArm(h *DefaultMessageHandler, sub *ActiveSubscription) {
+	if t.timer == nil && t.acked < t.sent {
+		t.timer = time.AfterFunc(t.timeout, func() { h.expireAck(sub) })
+	}
+}
+
+// unsafeStop stops the timer. t.mu must be held.
+func (t *ackTracker) unsafeStop() {
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+}
+
+// expired clears the timer that fired, returning the sequence of the last
+// update acknowledged, and if any sent after it is still unacknowledged.
+func (t *ackTracker) expired() (uint64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.timer = nil
+	return t.acked, t.acked < t.sent
+}
+
+// rearm starts the timer again after the unacknowledged updates were
+// resent.
+func (t *ackTracker) rearm(h *DefaultMessageHandler, sub *ActiveSubscription) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.unsafeArm(h, sub)
+}
+
+// unsafeNewAckTracker turns a subscription into an acknowledged one when
+// asked and possible, on paths with a replay buffer and not by pattern.
+// Acknowledged subscriptions aren't filtered, as the client can't tell an
+// update filtered out from a lost one. The caller must hold the lock.
+func (h *DefaultMessageHandler) unsafeNewAckTracker(sub *ActiveSubscription, req *nanorpc.NanoRPCRequest,
+	pattern *routePattern) {
+	buf := h.replay[sub.PathHash]
+	if !req.Acknowledged || pattern != nil || buf == nil {
+		return
+	}
+
+	timeout := h.ackTimeout
+	if timeout <= 0 {
+		timeout = DefaultAckTimeout
+	}
+
+	// updates up to the acknowledgement aren't tracked
+	seq := buf.sequence()
+	sub.ack = &ackTracker{timeout: timeout, acked: seq, sent: seq}
+	sub.Filter = nil
+}
+
+// handleAck processes TYPE_ACK messages, sending again the updates an
+// acknowledged subscription reports missing. They aren't answered, and
+// those not matching an acknowledged subscription of the session are
+// ignored.
+func (h *DefaultMessageHandler) handleAck(_ context.Context, session Session, req *nanorpc.NanoRPCRequest) error {
+	_, pathHash, err := h.hashCache.ResolvePath(req)
+	if err != nil || pathHash == 0 {
+		return nil
+	}
+
+	sub := h.findAckSubscription(session.ID(), req.RequestId, pathHash)
+	if sub != nil && sub.ack.acknowledge(h, sub, req.AckSequence) {
+		h.ackStats.gaps.Add(1)
+		h.resendUnacked(sub, req.AckSequence)
+	}
+	return nil
+}
+
+// findAckSubscription returns the acknowledged subscription of a session
+// with the given request ID, if any.
+func (h *DefaultMessageHandler) findAckSubscription(sessionID string, requestID int32,
+	pathHash uint32) *ActiveSubscription {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, sub := range h.unsafeSessionSubscriptions(sessionID, pathHash) {
+		if sub.RequestID == requestID && sub.ack != nil {
+			return sub
+		}
+	}
+	return nil
+}
+
+// expireAck sends again the updates of an acknowledged subscription not
+// acknowledged in time, for as long as it's subscribed.
+func (h *DefaultMessageHandler) expireAck(sub *ActiveSubscription) {
+	after, pending := sub.ack.expired()
+	if !pending || !h.subscribed(sub) {
+		return
+	}
+
+	h.ackStats.timeouts.Add(1)
+	h.resendUnacked(sub, after)
+	sub.ack.rearm(h, sub)
+}
+
+// subscribed tells if a subscription is still live.
+func (h *DefaultMessageHandler) subscribed(sub *ActiveSubscription) bool {
+	var found bool
+	h.subscriptions.ForEach(sub.PathHash, func(s *ActiveSubscription) bool {
+		found = s == sub
+		return !found
+	})
+	return found
+}
+
+// resendUnacked sends a subscription the updates published after the
+// given sequence still held by the replay buffer of its path, or the
+// latest one as a snapshot when the buffer no longer covers them.
+func (h *DefaultMessageHandler) resendUnacked(sub *ActiveSubscription, after uint64) {
+	h.mu.RLock()
+	buf := h.replay[sub.PathHash]
+	h.mu.RUnlock()
+
+	if buf == nil {
+		return
+	}
+
+	_, entries, covered := buf.since(after)
+	updates := make([]pendingUpdate, 0, len(entries))
+	for _, entry := range entries {
+		message := newUpdateResponse(sub.RequestID, entry.data, entry.seq)
+		message.Snapshot = !covered
+		updates = append(updates, pendingUpdate{session: sub.Session, message: message})
+	}
+
+	h.ackStats.resent.Add(uint64(len(updates)))
+	// failures are reported to the error handler
+	_ = h.sendUpdates(sub.PathHash, updates)
+}
+
+// SetAckTimeout sets how long the updates of acknowledged subscriptions
+// have to be acknowledged before they are sent again. Zero uses
+// [DefaultAckTimeout]. It applies to subscriptions made from now on.
+func (h *DefaultMessageHandler) SetAckTimeout(d time.Duration) error {
+	if h == nil {
+		return core.ErrNilReceiver
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.ackTimeout = d
+	return nil
+}
+
+// AckStats returns the counts of updates of acknowledged subscriptions
+// sent again so far, telling how lossy the links to the clients are.
+func (h *DefaultMessageHandler) AckStats() AckStats {
+	if h == nil {
+		return AckStats{}
+	}
+
+	return AckStats{
+		Gaps:     h.ackStats.gaps.Load(),
+		Timeouts: h.ackStats.timeouts.Load(),
+		Resent:   h.ackStats.resent.Load(),
+	}
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+func newAckedRequest(requestID int32, filter []byte) *nanorpc.NanoRPCRequest {
+	req := newTestSubscribeRequest(requestID, replayTestPath, filter)
+	req.Acknowledged = true
+	return req
+}
+
+func newAckRequest(requestID int32, seq uint64) *nanorpc.NanoRPCRequest {
+	return &nanorpc.NanoRPCRequest{
+		RequestId:   requestID,
+		RequestType: nanorpc.NanoRPCRequest_TYPE_ACK,
+		PathOneof:   nanorpc.GetPathOneOfString(replayTestPath),
+		AckSequence: seq,
+	}
+}
+
+// updateData returns the data of the updates received after the first n
+// responses.
+func updateData(session *mockSession, n int) []string {
+	var data []string
+	for _, res := range session.GetAllResponses()[n:] {
+		data = append(data, string(res.Data))
+	}
+	return data
+}
+
+func TestAcknowledgedGap(t *testing.T) {
+	ctx := context.Background()
+	h := newReplayTestHandler(t, 8)
+	core.AssertMustNoError(t, h.SetAckTimeout(time.Hour), "SetAckTimeout")
+	core.AssertMustNoError(t, h.SetFilterEvaluator(FilterFunc(func(_, _ []byte) (bool, error) {
+		return false, nil
+	})), "SetFilterEvaluator")
+
+	session := newTestSession("acked", 1001)
+	core.AssertMustNoError(t, h.HandleMessage(ctx, session, newAckedRequest(1, []byte("x"))), "subscribe")
+	base := session.GetLastResponse().Sequence
+
+	for _, s := range []string{"a", "b", "c"} {
+		core.AssertNoError(t, h.Publish(replayTestPath, []byte(s)), "publish")
+	}
+	core.AssertSliceEqual(t, []string{"a", "b", "c"}, updateData(session, 1), "unfiltered updates")
+
+	// "b" and "c" reported missing, once
+	for range 3 {
+		core.AssertNoError(t, h.HandleMessage(ctx, session, newAckRequest(1, base+1)), "ack")
+	}
+	core.AssertSliceEqual(t, []string{"b", "c"}, updateData(session, 4), "resent")
+	core.AssertEqual(t, AckStats{Gaps: 1, Resent: 2}, h.AckStats(), "stats")
+
+	// progress, then the same gap again for another subscription is ignored
+	core.AssertNoError(t, h.HandleMessage(ctx, session, newAckRequest(1, base+3)), "ack")
+	core.AssertNoError(t, h.HandleMessage(ctx, session, newAckRequest(2, base+1)), "ack unknown")
+	core.AssertEqual(t, 6, len(session.GetAllResponses()), "responses")
+}
+
+func TestAcknowledgedTimeout(t *testing.T) {
+	ctx := context.Background()
+	h := newReplayTestHandler(t, 8)
+	core.AssertMustNoError(t, h.SetAckTimeout(10*time.Millisecond), "SetAckTimeout")
+
+	session := newTestSession("acked", 1001)
+	core.AssertMustNoError(t, h.HandleMessage(ctx, session, newAckedRequest(1, nil)), "subscribe")
+	core.AssertNoError(t, h.Publish(replayTestPath, []byte("a")), "publish")
+	seq := session.GetLastResponse().Sequence
+
+	deadline := time.Now().Add(time.Second)
+	for len(session.GetAllResponses()) < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	data := updateData(session, 1)
+	core.AssertMustTrue(t, len(data) > 1, "resent")
+	for _, s := range data {
+		core.AssertEqual(t, "a", s, "data")
+	}
+	core.AssertEqual(t, seq, session.GetLastResponse().Sequence, "sequence")
+
+	// a timer firing as acknowledged still resends once
+	core.AssertNoError(t, h.HandleMessage(ctx, session, newAckRequest(1, seq)), "ack")
+	time.Sleep(20 * time.Millisecond)
+	resent := h.AckStats().Resent
+	time.Sleep(30 * time.Millisecond)
+	core.AssertEqual(t, resent, h.AckStats().Resent, "resent after ack")
+	core.AssertTrue(t, h.AckStats().Timeouts > 0, "timeouts")
+}
+
+func TestAcknowledgedUnsupported(t *testing.T) {
+	ctx := context.Background()
+	h := NewDefaultMessageHandler(nil)
+
+	// without replay, a plain subscription
+	session := newTestSession("plain", 1001)
+	core.AssertMustNoError(t, h.HandleMessage(ctx, session, newAckedRequest(1, nil)), "subscribe")
+	core.AssertNoError(t, h.Publish(replayTestPath, []byte("a")), "publish")
+	core.AssertNoError(t, h.HandleMessage(ctx, session, newAckRequest(1, 0)), "ack")
+	core.AssertNoError(t, h.HandleMessage(ctx, session, newAckRequest(1, 0)), "ack again")
+
+	core.AssertSliceEqual(t, []string{"a"}, updateData(session, 1), "updates")
+	core.AssertEqual(t, uint64(0), session.GetLastResponse().Sequence, "unnumbered")
+	core.AssertEqual(t, AckStats{}, h.AckStats(), "stats")
+}
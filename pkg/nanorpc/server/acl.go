@@ -0,0 +1,184 @@
+package server
+
+import (
+	"slices"
+
+	"darvaza.org/core"
+)
+
+// Identity is who a session authenticated as. The access rules of a path,
+// see [RequireRole], are checked against the identity of the session.
+type Identity struct {
+	Name  string
+	Roles []string
+}
+
+// HasRole reports whether the identity holds the given role.
+func (id *Identity) HasRole(role string) bool {
+	return id != nil && slices.Contains(id.Roles, role)
+}
+
+// AccessOption adds an access rule to a path, see
+// [DefaultMessageHandler.RegisterHandler] and
+// [DefaultMessageHandler.SetAccess].
+type AccessOption func(*accessRule)
+
+// RequireRole restricts a path to sessions whose [Identity] holds the
+// role. Combined options require every role.
+func RequireRole(role string) AccessOption {
+	return func(rule *accessRule) {
+		if role != "" && !slices.Contains(rule.roles, role) {
+			rule.roles = append(rule.roles, role)
+		}
+	}
+}
+
+// accessRule holds the requirements of a path.
+type accessRule struct {
+	roles []string
+}
+
+// newAccessRule applies options, returning nil when they require nothing.
+func newAccessRule(opts []AccessOption) *accessRule {
+	rule := new(accessRule)
+	for _, opt := range opts {
+		if opt != nil {
+			opt(rule)
+		}
+	}
+
+	if len(rule.roles) == 0 {
+		return nil
+	}
+	return rule
+}
+
+// allows reports whether the rule lets an identity in. A nil rule allows
+// everyone.
+func (rule *accessRule) allows(id *Identity) bool {
+	if rule == nil {
+		return true
+	}
+
+	for _, role := range rule.roles {
+		if !id.HasRole(role) {
+			return false
+		}
+	}
+	return true
+}
+
+// SetAccess replaces the access rules of a path, for requests, forwarded
+// requests, subscriptions and the delivery of published updates. Denied
+// requests and subscriptions are answered with STATUS_NOT_AUTHORIZED, and
+// denied subscribers skipped by [DefaultMessageHandler.Publish]. Without
+// options the path is open to every session.
+func (h *DefaultMessageHandler) SetAccess(path string, opts ...AccessOption) error {
+	if h == nil {
+		return core.ErrNilReceiver
+	}
+
+	pathHash, err := h.hashCache.Hash(path)
+	if err != nil {
+		return core.Wrapf(err, "failed to hash path %q", path)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.unsafeSetAccess(pathHash, newAccessRule(opts))
+	return nil
+}
+
+func (h *DefaultMessageHandler) unsafeSetAccess(pathHash uint32, rule *accessRule) {
+	switch {
+	case rule != nil && h.access == nil:
+		h.access = map[uint32]*accessRule{pathHash: rule}
+	case rule != nil:
+		h.access[pathHash] = rule
+	default:
+		delete(h.access, pathHash)
+	}
+}
+
+// SetSessionIdentity records who a session authenticated as, typically
+// from an [Authenticator] or a login handler. A nil identity forgets it.
+// Identities are forgotten when their session is removed.
+//
+// The name of the identity is bound in the [IdentityIndex], if any, and
+// the identity isn't recorded if that fails, e.g. with [ErrIdentityInUse].
+func (h *DefaultMessageHandler) SetSessionIdentity(sessionID string, id *Identity) error {
+	if h == nil {
+		return core.ErrNilReceiver
+	}
+
+	if err := h.indexIdentity(sessionID, id); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	switch {
+	case id == nil:
+		delete(h.identities, sessionID)
+	case h.identities == nil:
+		h.identities = map[string]*Identity{sessionID: id}
+	default:
+		h.identities[sessionID] = id
+	}
+	return nil
+}
+
+// SessionIdentity returns the identity recorded for a session, or nil.
+func (h *DefaultMessageHandler) SessionIdentity(sessionID string) *Identity {
+	if h == nil {
+		return nil
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.identities[sessionID]
+}
+
+// allowed reports whether a session may use a path.
+func (h *DefaultMessageHandler) allowed(session Session, pathHash uint32) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.unsafeAllowed(session, pathHash)
+}
+
+func (h *DefaultMessageHandler) unsafeAllowed(session Session, pathHash uint32) bool {
+	rule := h.access[pathHash]
+	if rule == nil {
+		return true
+	}
+	if session == nil {
+		return false
+	}
+	return rule.allows(h.identities[session.ID()])
+}
+
+// SetIdentity records who the session of the request authenticated as,
+// see [DefaultMessageHandler.SetSessionIdentity].
+func (rc *RequestContext) SetIdentity(id *Identity) error {
+	switch {
+	case rc == nil:
+		return core.ErrNilReceiver
+	case rc.handler == nil, rc.Session == nil:
+		return ErrIdentityUnavailable
+	default:
+		return rc.handler.SetSessionIdentity(rc.Session.ID(), id)
+	}
+}
+
+// Identity returns who the session of the request authenticated as, or
+// nil.
+func (rc *RequestContext) Identity() *Identity {
+	if rc == nil || rc.handler == nil || rc.Session == nil {
+		return nil
+	}
+	return rc.handler.SessionIdentity(rc.Session.ID())
+}
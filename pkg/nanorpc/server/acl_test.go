@@ -0,0 +1,186 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+const (
+	pathAdmin  = "/api/admin"
+	pathLogin  = "/api/login"
+	pathAlerts = "/events/alerts"
+)
+
+// newACLHandler returns a handler with pathEcho open and pathAdmin
+// requiring the admin role, counting the calls to the latter.
+func newACLHandler(t *testing.T, calls *int) *DefaultMessageHandler {
+	t.Helper()
+
+	h := NewDefaultMessageHandler(nil)
+	core.AssertMustNoError(t, h.RegisterHandlerFunc(pathEcho, echoChainHandler), "register echo")
+	core.AssertMustNoError(t, h.RegisterHandlerFunc(pathAdmin, func(_ context.Context, rc *RequestContext) error {
+		*calls++
+		return rc.SendOK(nil)
+	}, RequireRole("admin")), "register admin")
+	return h
+}
+
+var _ core.TestCase = aclRequestTestCase{}
+
+type aclRequestTestCase struct {
+	identity *Identity
+	name     string
+	path     any
+	status   nanorpc.NanoRPCResponse_Status
+	calls    int
+}
+
+func (tc aclRequestTestCase) Name() string { return tc.name }
+
+func (tc aclRequestTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	var calls int
+	h := newACLHandler(t, &calls)
+	if tc.identity != nil {
+		core.AssertMustNoError(t, h.SetSessionIdentity(sessionID1, tc.identity), "SetSessionIdentity")
+	}
+
+	session := newTestSession(sessionID1, 0)
+	err := h.HandleMessage(context.Background(), session, newTestRequest(1, tc.path))
+	core.AssertNoError(t, err, "HandleMessage")
+
+	core.AssertEqual(t, tc.calls, calls, "handler calls")
+	if resp := session.GetLastResponse(); core.AssertNotNil(t, resp, "response") {
+		core.AssertEqual(t, tc.status, resp.ResponseStatus, "status")
+	}
+}
+
+func newACLRequestTestCase(name string, identity *Identity, path any,
+	status nanorpc.NanoRPCResponse_Status, calls int) aclRequestTestCase {
+	return aclRequestTestCase{
+		identity: identity,
+		name:     name,
+		path:     path,
+		status:   status,
+		calls:    calls,
+	}
+}
+
+func aclRequestTestCases() []aclRequestTestCase {
+	const ok, denied = nanorpc.NanoRPCResponse_STATUS_OK, nanorpc.NanoRPCResponse_STATUS_NOT_AUTHORIZED
+
+	adminHash, _ := new(nanorpc.HashCache).Hash(pathAdmin)
+	admin := &Identity{Name: "root", Roles: []string{"user", "admin"}}
+	user := &Identity{Name: "alice", Roles: []string{"user"}}
+
+	return []aclRequestTestCase{
+		newACLRequestTestCase("admin", admin, pathAdmin, ok, 1),
+		newACLRequestTestCase("admin by hash", admin, adminHash, ok, 1),
+		newACLRequestTestCase("missing role", user, pathAdmin, denied, 0),
+		newACLRequestTestCase("missing role by hash", user, adminHash, denied, 0),
+		newACLRequestTestCase("anonymous", nil, pathAdmin, denied, 0),
+		newACLRequestTestCase("open path", nil, pathEcho, ok, 0),
+	}
+}
+
+func TestDefaultMessageHandler_RequireRole(t *testing.T) {
+	core.RunTestCases(t, aclRequestTestCases())
+}
+
+func TestRequireRole_all(t *testing.T) {
+	rule := newAccessRule([]AccessOption{RequireRole("a"), nil, RequireRole("b"), RequireRole("a")})
+	core.AssertSliceEqual(t, []string{"a", "b"}, rule.roles, "roles")
+
+	core.AssertTrue(t, rule.allows(&Identity{Roles: []string{"b", "a"}}), "both")
+	core.AssertFalse(t, rule.allows(&Identity{Roles: []string{"a"}}), "one")
+	core.AssertFalse(t, rule.allows(nil), "none")
+
+	core.AssertNil(t, newAccessRule([]AccessOption{RequireRole("")}), "empty role")
+	core.AssertTrue(t, (*accessRule)(nil).allows(nil), "no rule")
+}
+
+// TestDefaultMessageHandler_SetAccess_subscriptions verifies subscriptions
+// are refused without the role, and that updates stop reaching
+// subscribers that lose it.
+func TestDefaultMessageHandler_SetAccess_subscriptions(t *testing.T) {
+	h := NewDefaultMessageHandler(nil)
+	core.AssertMustNoError(t, h.SetAccess(pathAlerts, RequireRole("ops")), "SetAccess")
+	ops := &Identity{Name: "bob", Roles: []string{"ops"}}
+	core.AssertMustNoError(t, h.SetSessionIdentity(sessionID1, ops), "identity")
+
+	allowed := newTestSession(sessionID1, 0)
+	denied := newTestSession(sessionID2, 0)
+	for _, session := range []*mockSession{allowed, denied} {
+		err := h.HandleMessage(context.Background(), session, newTestSubscribeRequest(5, pathAlerts, nil))
+		core.AssertMustNoError(t, err, "subscribe")
+	}
+
+	core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_OK,
+		allowed.GetLastResponse().ResponseStatus, "allowed ack")
+	core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_NOT_AUTHORIZED,
+		denied.GetLastResponse().ResponseStatus, "denied ack")
+
+	core.AssertNoError(t, h.Publish(pathAlerts, []byte("first")), "publish first")
+	core.AssertEqual(t, 2, len(allowed.GetAllResponses()), "allowed after first")
+	core.AssertEqual(t, 1, len(denied.GetAllResponses()), "denied after first")
+
+	// revoking the identity stops the delivery
+	core.AssertNoError(t, h.SetSessionIdentity(sessionID1, nil), "revoke")
+	core.AssertNoError(t, h.Publish(pathAlerts, []byte("second")), "publish second")
+	core.AssertEqual(t, 2, len(allowed.GetAllResponses()), "allowed after second")
+
+	// clearing the rules opens the path again
+	core.AssertNoError(t, h.SetAccess(pathAlerts), "clear")
+	core.AssertNoError(t, h.Publish(pathAlerts, []byte("third")), "publish third")
+	core.AssertEqual(t, 3, len(allowed.GetAllResponses()), "allowed after third")
+}
+
+// TestRequestContext_SetIdentity verifies a login handler can grant the
+// roles another path requires.
+func TestRequestContext_SetIdentity(t *testing.T) {
+	var calls int
+	h := newACLHandler(t, &calls)
+	core.AssertMustNoError(t, h.RegisterHandlerFunc(pathLogin, func(_ context.Context, rc *RequestContext) error {
+		if err := rc.SetIdentity(&Identity{Name: "root", Roles: []string{"admin"}}); err != nil {
+			return err
+		}
+		return rc.SendOK(nil)
+	}), "register login")
+
+	session := newTestSession(sessionID1, 0)
+	ctx := context.Background()
+	core.AssertNoError(t, h.HandleMessage(ctx, session, newTestRequest(1, pathLogin)), "login")
+	core.AssertNoError(t, h.HandleMessage(ctx, session, newTestRequest(2, pathAdmin)), "admin")
+	core.AssertEqual(t, 1, calls, "admin calls")
+
+	rc := &RequestContext{Session: session, handler: h}
+	if id := rc.Identity(); core.AssertNotNil(t, id, "identity") {
+		core.AssertEqual(t, "root", id.Name, "name")
+	}
+
+	// identities go away with their session
+	h.RemoveSubscriptionsForSession(sessionID1)
+	core.AssertNil(t, h.SessionIdentity(sessionID1), "removed")
+
+	orphan := &RequestContext{Session: session}
+	core.AssertErrorIs(t, orphan.SetIdentity(nil), ErrIdentityUnavailable, "orphan")
+}
+
+func TestRequestContext_Forward_accessDenied(t *testing.T) {
+	var calls int
+	h := newACLHandler(t, &calls)
+	core.AssertMustNoError(t, h.RegisterHandlerFunc(pathLogin, func(_ context.Context, rc *RequestContext) error {
+		return rc.Forward(pathAdmin)
+	}), "register forwarder")
+
+	session := newTestSession(sessionID1, 0)
+	err := h.HandleMessage(context.Background(), session, newTestRequest(1, pathLogin))
+	core.AssertErrorIs(t, err, ErrAccessDenied, "forward")
+	core.AssertTrue(t, nanorpc.IsNotAuthorized(err), "IsNotAuthorized")
+	core.AssertEqual(t, 0, calls, "admin calls")
+}
@@ -0,0 +1,229 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// DefaultAsyncTimeout is how long a [DefaultMessageHandler] waits for an
+// asynchronous request to be answered before failing it, see [ErrAsync].
+const DefaultAsyncTimeout = 30 * time.Second
+
+// ErrAsync is returned by handlers that answer their request later, from
+// another goroutine, e.g. once slow hardware replies, so the session can
+// carry on reading. The [DefaultMessageHandler] keeps track of the request
+// until a Send method or [RequestContext.Complete] answers it, failing it
+// with STATUS_INTERNAL_ERROR if that takes longer than the async timeout,
+// or the context of the request is cancelled first.
+var ErrAsync = errors.New("request answered asynchronously")
+
+// ErrAsyncTimeout indicates an asynchronous request was answered after it
+// had already failed for taking too long, see [ErrAsync].
+var ErrAsyncTimeout = core.QuietWrap(context.DeadlineExceeded, "asynchronous request timed out")
+
+// replyState tracks whether a request has been answered. It's shared by
+// the contexts the request is forwarded through.
+type replyState struct {
+	h       *DefaultMessageHandler
+	timer   *time.Timer
+	stop    func() bool        // stops watching the context
+	release context.CancelFunc // ends the detached handler context
+	mu      sync.Mutex
+	async   bool
+	done    bool
+	expired bool
+}
+
+// answer records a request as answered, failing if it had timed out.
+func (r *replyState) answer() error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.expired {
+		return ErrAsyncTimeout
+	}
+	r.done = true
+	if r.async {
+		r.unsafeUntrack()
+	}
+	return nil
+}
+
+// answered tells if the request has been answered, or failed.
+func (r *replyState) answered() bool {
+	if r == nil {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.done
+}
+
+// expire fails an asynchronous request not answered in time, telling if
+// it did.
+func (r *replyState) expire() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.done {
+		return false
+	}
+	r.done = true
+	r.expired = true
+	r.unsafeUntrack()
+	return true
+}
+
+func (r *replyState) unsafeUntrack() {
+	r.timer.Stop()
+	r.stop()
+	r.release()
+	r.h.untrackAsync(r)
+}
+
+// trackAsync waits for a request whose handler returned [ErrAsync] to be
+// answered, failing it on timeout or when its context is cancelled.
+func (h *DefaultMessageHandler) trackAsync(ctx context.Context, rc *RequestContext) {
+	r := rc.reply
+	release := detachContext(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.done {
+		// answered before the handler returned
+		release()
+		return
+	}
+
+	h.mu.Lock()
+	if h.pending == nil {
+		h.pending = make(map[*replyState]struct{})
+	}
+	h.pending[r] = struct{}{}
+	timeout := h.asyncTimeout
+	h.mu.Unlock()
+
+	if timeout <= 0 {
+		timeout = DefaultAsyncTimeout
+	}
+
+	expire := func() { h.expireAsync(rc) }
+	r.async = true
+	r.release = release
+	r.timer = time.AfterFunc(timeout, expire)
+	r.stop = context.AfterFunc(ctx, expire)
+}
+
+func (h *DefaultMessageHandler) untrackAsync(r *replyState) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.pending, r)
+}
+
+// expireAsync fails an asynchronous request not answered in time.
+func (h *DefaultMessageHandler) expireAsync(rc *RequestContext) {
+	if !rc.reply.expire() {
+		return
+	}
+
+	err := sendErrorResponse(rc.Session, rc.Request,
+		nanorpc.NanoRPCResponse_STATUS_TIMEOUT, "request timed out")
+	if err != nil {
+		h.onError(err, rc.Session, nil, "Failed to fail expired request")
+	}
+}
+
+// SetAsyncTimeout sets how long asynchronous requests have to be answered,
+// see [ErrAsync]. Zero uses [DefaultAsyncTimeout]. It applies to requests
+// going asynchronous from now on.
+func (h *DefaultMessageHandler) SetAsyncTimeout(d time.Duration) error {
+	if h == nil {
+		return core.ErrNilReceiver
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.asyncTimeout = d
+	return nil
+}
+
+// PendingAsync returns the number of asynchronous requests waiting to be
+// answered.
+func (h *DefaultMessageHandler) PendingAsync() int {
+	if h == nil {
+		return 0
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.pending)
+}
+
+// Complete answers a request with data, or with the error status matching
+// err as [RequestContext.SendErr] does: STATUS_NOT_FOUND for
+// [core.ErrNotExists], STATUS_NOT_AUTHORIZED for [io/fs.ErrPermission], the
+// status of a [nanorpc.Error], and STATUS_INTERNAL_ERROR otherwise. It's
+// meant for handlers that returned [ErrAsync], e.g.
+// rc.Complete(device.Read()).
+func (rc *RequestContext) Complete(data []byte, err error) error {
+	if rc == nil {
+		return core.ErrNilReceiver
+	}
+
+	if err == nil {
+		return rc.SendOK(data)
+	}
+	return rc.SendErr(err)
+}
+
+// handlerScopeKey is the context key of the [handlerScope] of a request.
+type handlerScopeKey struct{}
+
+// handlerScope ends the context a session gave a handler once the handler
+// returns, and whoever it was handed over to is done with it, see
+// [detachContext].
+type handlerScope struct {
+	cancel context.CancelFunc
+	holds  atomic.Int32
+}
+
+func (hs *handlerScope) release() {
+	if hs.holds.Add(-1) == 0 {
+		hs.cancel()
+	}
+}
+
+// withHandlerScope returns a context ended by end once its handler
+// returns, unless detached.
+func withHandlerScope(ctx context.Context, cancel context.CancelFunc) (context.Context, func()) {
+	hs := &handlerScope{cancel: cancel}
+	hs.holds.Store(1)
+	return context.WithValue(ctx, handlerScopeKey{}, hs), hs.release
+}
+
+// detachContext keeps the context of a handler alive after it returns,
+// until the returned function is called.
+func detachContext(ctx context.Context) context.CancelFunc {
+	if hs, ok := ctx.Value(handlerScopeKey{}).(*handlerScope); ok {
+		hs.holds.Add(1)
+		return hs.release
+	}
+	return func() {}
+}
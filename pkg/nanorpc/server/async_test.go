@@ -0,0 +1,144 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// newAsyncHandler returns a handler whose pathEcho handler answers from
+// another goroutine with the data received from reply, if any.
+func newAsyncHandler(t *testing.T, reply <-chan []byte) *DefaultMessageHandler {
+	t.Helper()
+
+	h := NewDefaultMessageHandler(nil)
+	core.AssertMustNoError(t, h.RegisterHandlerFunc(pathEcho, func(_ context.Context, rc *RequestContext) error {
+		go func() {
+			if data, ok := <-reply; ok {
+				_ = rc.Complete(data, nil)
+			}
+		}()
+		return ErrAsync
+	}), "register")
+	return h
+}
+
+// waitResponse waits for the session to receive a response.
+func waitResponse(t *testing.T, session *mockSession) *nanorpc.NanoRPCResponse {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if resp := session.GetLastResponse(); resp != nil {
+			return resp
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("no response")
+	return nil
+}
+
+func TestDefaultMessageHandler_async(t *testing.T) {
+	reply := make(chan []byte)
+	defer close(reply)
+	h := newAsyncHandler(t, reply)
+
+	session := newTestSession("", 0)
+	err := h.HandleMessage(context.Background(), session, newTestRequest(1, pathEcho))
+	core.AssertMustNoError(t, err, "HandleMessage")
+	core.AssertNil(t, session.GetLastResponse(), "no response yet")
+	core.AssertEqual(t, 1, h.PendingAsync(), "pending")
+
+	reply <- []byte("done")
+	resp := waitResponse(t, session)
+	core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_OK, resp.ResponseStatus, "status")
+	core.AssertEqual(t, "done", string(resp.Data), "data")
+	core.AssertEqual(t, 0, h.PendingAsync(), "pending after answer")
+}
+
+func TestDefaultMessageHandler_asyncTimeout(t *testing.T) {
+	var rc *RequestContext
+	h := NewDefaultMessageHandler(nil)
+	core.AssertMustNoError(t, h.SetAsyncTimeout(10*time.Millisecond), "SetAsyncTimeout")
+	core.AssertMustNoError(t, h.RegisterHandlerFunc(pathEcho, func(_ context.Context, r *RequestContext) error {
+		rc = r
+		return ErrAsync
+	}), "register")
+
+	session := newTestSession("", 0)
+	err := h.HandleMessage(context.Background(), session, newTestRequest(1, pathEcho))
+	core.AssertMustNoError(t, err, "HandleMessage")
+
+	resp := waitResponse(t, session)
+	core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_TIMEOUT, resp.ResponseStatus, "status")
+	core.AssertEqual(t, 0, h.PendingAsync(), "pending after timeout")
+
+	err = rc.SendOK(nil)
+	core.AssertErrorIs(t, err, ErrAsyncTimeout, "late answer")
+	core.AssertEqual(t, 1, len(session.GetAllResponses()), "responses")
+}
+
+// TestDefaultMessageHandler_asyncCancelled verifies asynchronous requests
+// fail when their context is cancelled, e.g. by the session closing.
+func TestDefaultMessageHandler_asyncCancelled(t *testing.T) {
+	reply := make(chan []byte)
+	defer close(reply)
+	h := newAsyncHandler(t, reply)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	session := newTestSession("", 0)
+	err := h.HandleMessage(ctx, session, newTestRequest(1, pathEcho))
+	core.AssertMustNoError(t, err, "HandleMessage")
+
+	cancel()
+	resp := waitResponse(t, session)
+	core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_TIMEOUT, resp.ResponseStatus, "status")
+	core.AssertEqual(t, 0, h.PendingAsync(), "pending after cancel")
+}
+
+var _ core.TestCase = completeTestCase{}
+
+type completeTestCase struct {
+	err    error
+	name   string
+	status nanorpc.NanoRPCResponse_Status
+}
+
+func (tc completeTestCase) Name() string { return tc.name }
+
+func (tc completeTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	session := newTestSession("", 0)
+	rc := &RequestContext{Session: session, Request: newTestRequest(1, pathEcho)}
+	core.AssertMustNoError(t, rc.Complete([]byte("data"), tc.err), "Complete")
+
+	resp := session.GetLastResponse()
+	if core.AssertNotNil(t, resp, "response") {
+		core.AssertEqual(t, tc.status, resp.ResponseStatus, "status")
+	}
+}
+
+func newCompleteTestCase(name string, err error, status nanorpc.NanoRPCResponse_Status) completeTestCase {
+	return completeTestCase{err: err, name: name, status: status}
+}
+
+func completeTestCases() []completeTestCase {
+	return []completeTestCase{
+		newCompleteTestCase("ok", nil, nanorpc.NanoRPCResponse_STATUS_OK),
+		newCompleteTestCase("not found", core.ErrNotExists, nanorpc.NanoRPCResponse_STATUS_NOT_FOUND),
+		newCompleteTestCase("denied", ErrAccessDenied, nanorpc.NanoRPCResponse_STATUS_NOT_AUTHORIZED),
+		newCompleteTestCase("permission", fs.ErrPermission, nanorpc.NanoRPCResponse_STATUS_NOT_AUTHORIZED),
+		newCompleteTestCase("other", errors.New("device offline"), nanorpc.NanoRPCResponse_STATUS_INTERNAL_ERROR),
+	}
+}
+
+func TestRequestContext_Complete(t *testing.T) {
+	core.RunTestCases(t, completeTestCases())
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"context"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// Authenticator decides whether the request of a session may reach the
+// handlers marked with [RequireAuth]. Any error denies the request, which
+// is answered with STATUS_NOT_AUTHORIZED.
+//
+// Authenticate is called for every such request, so implementations
+// checking credentials once per session should remember the outcome by
+// [Session.ID].
+type Authenticator interface {
+	Authenticate(ctx context.Context, session Session, req *nanorpc.NanoRPCRequest) error
+}
+
+// AuthenticatorFunc is an adapter to allow ordinary functions to be used
+// as Authenticators.
+type AuthenticatorFunc func(context.Context, Session, *nanorpc.NanoRPCRequest) error
+
+// Authenticate calls the function with the given context, session and
+// request.
+func (f AuthenticatorFunc) Authenticate(ctx context.Context, session Session, req *nanorpc.NanoRPCRequest) error {
+	if f == nil {
+		return core.ErrNilReceiver
+	}
+
+	return f(ctx, session, req)
+}
+
+// SetAuthenticator sets the [Authenticator] checking requests to handlers
+// marked with [RequireAuth]. A nil Authenticator denies them all.
+func (h *DefaultMessageHandler) SetAuthenticator(auth Authenticator) error {
+	if h == nil {
+		return core.ErrNilReceiver
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.auth = auth
+	return nil
+}
+
+// authenticate checks a request against the [Authenticator], failing with
+// [ErrNoAuthenticator] if there is none.
+func (h *DefaultMessageHandler) authenticate(ctx context.Context, session Session,
+	req *nanorpc.NanoRPCRequest) error {
+	if h == nil {
+		return ErrNoAuthenticator
+	}
+
+	h.mu.RLock()
+	auth := h.auth
+	h.mu.RUnlock()
+
+	if core.IsNil(auth) {
+		return ErrNoAuthenticator
+	}
+	return auth.Authenticate(ctx, session, req)
+}
+
+// RequireAuth marks a handler as requiring authentication. Requests
+// reaching it through a [DefaultMessageHandler] are first passed to its
+// [Authenticator], and answered with STATUS_NOT_AUTHORIZED when denied.
+// Returns nil if handler is nil.
+func RequireAuth(handler RequestHandler) RequestHandler {
+	if core.IsNil(handler) {
+		return nil
+	}
+	return authRequiredHandler{next: handler}
+}
+
+// authRequiredHandler is a [RequestHandler] authenticating requests before
+// passing them to the next one.
+type authRequiredHandler struct {
+	next RequestHandler
+}
+
+func (ah authRequiredHandler) Handle(ctx context.Context, rc *RequestContext) error {
+	if rc == nil {
+		return core.ErrNilReceiver
+	}
+
+	if err := rc.handler.authenticate(ctx, rc.Session, rc.Request); err != nil {
+		return rc.SendUnauthorized("")
+	}
+	return ah.next.Handle(ctx, rc)
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+const pathSecret = "/api/secret"
+
+var errDenied = errors.New("denied")
+
+// allowSession returns an [Authenticator] accepting only the given session.
+func allowSession(id string) Authenticator {
+	return AuthenticatorFunc(func(_ context.Context, session Session, _ *nanorpc.NanoRPCRequest) error {
+		if session.ID() != id {
+			return errDenied
+		}
+		return nil
+	})
+}
+
+var _ core.TestCase = authTestCase{}
+
+type authTestCase struct {
+	auth    Authenticator
+	name    string
+	path    string
+	session string
+	status  nanorpc.NanoRPCResponse_Status
+	called  bool
+}
+
+func (tc authTestCase) Name() string { return tc.name }
+
+func (tc authTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	var called bool
+	fn := RequestHandlerFunc(func(_ context.Context, rc *RequestContext) error {
+		called = true
+		return rc.SendOK(nil)
+	})
+
+	h := NewDefaultMessageHandler(nil)
+	core.AssertMustNoError(t, h.RegisterHandler(pathSecret, RequireAuth(fn)), "register secret")
+	core.AssertMustNoError(t, h.RegisterHandler(pathEcho, fn), "register echo")
+	if tc.auth != nil {
+		core.AssertMustNoError(t, h.SetAuthenticator(tc.auth), "SetAuthenticator")
+	}
+
+	session := newTestSession(tc.session, 0)
+	err := h.HandleMessage(context.Background(), session, newTestRequest(1, tc.path))
+	core.AssertNoError(t, err, "HandleMessage")
+
+	core.AssertEqual(t, tc.called, called, "handler called")
+	if resp := session.GetLastResponse(); core.AssertNotNil(t, resp, "response") {
+		core.AssertEqual(t, tc.status, resp.ResponseStatus, "status")
+	}
+}
+
+//revive:disable-next-line:argument-limit
+func newAuthTestCase(name string, auth Authenticator, path, session string,
+	status nanorpc.NanoRPCResponse_Status, called bool) authTestCase {
+	return authTestCase{
+		auth:    auth,
+		name:    name,
+		path:    path,
+		session: session,
+		status:  status,
+		called:  called,
+	}
+}
+
+func authTestCases() []authTestCase {
+	const ok, denied = nanorpc.NanoRPCResponse_STATUS_OK, nanorpc.NanoRPCResponse_STATUS_NOT_AUTHORIZED
+
+	return []authTestCase{
+		newAuthTestCase("allowed", allowSession(sessionID1), pathSecret, sessionID1, ok, true),
+		newAuthTestCase("denied", allowSession(sessionID1), pathSecret, sessionID2, denied, false),
+		newAuthTestCase("no authenticator", nil, pathSecret, sessionID1, denied, false),
+		newAuthTestCase("unmarked handler", allowSession(sessionID1), pathEcho, sessionID2, ok, true),
+	}
+}
+
+func TestRequireAuth(t *testing.T) {
+	core.RunTestCases(t, authTestCases())
+}
+
+func TestRequireAuth_nil(t *testing.T) {
+	core.AssertNil(t, RequireAuth(nil), "RequireAuth(nil)")
+}
+
+func TestDefaultMessageHandler_SetAuthenticator_remove(t *testing.T) {
+	h := NewDefaultMessageHandler(nil)
+	session := newTestSession(sessionID1, 0)
+	req := newTestRequest(1, pathSecret)
+
+	core.AssertNoError(t, h.SetAuthenticator(allowSession(sessionID1)), "set")
+	core.AssertNoError(t, h.authenticate(context.Background(), session, req), "allowed")
+
+	core.AssertNoError(t, h.SetAuthenticator(nil), "remove")
+	core.AssertErrorIs(t, h.authenticate(context.Background(), session, req),
+		ErrNoAuthenticator, "removed")
+}
@@ -0,0 +1,40 @@
+package server
+
+import "sync"
+
+const (
+	// bufferSize is the initial capacity of pooled encoding buffers.
+	bufferSize = 512
+	// maxPooledBuffer is the capacity above which encoding buffers
+	// aren't reused, so a burst of large updates doesn't pin memory.
+	maxPooledBuffer = 64 << 10
+)
+
+// bufferPool holds the buffers subscription updates are encoded into,
+// reused once written instead of allocated for every subscriber.
+var bufferPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, bufferSize)
+		return &b
+	},
+}
+
+// getBuffer returns an empty encoding buffer from the pool.
+func getBuffer() []byte {
+	p, _ := bufferPool.Get().(*[]byte)
+	if p == nil {
+		return make([]byte, 0, bufferSize)
+	}
+	return (*p)[:0]
+}
+
+// putBuffer returns an encoding buffer to the pool. It must no longer be
+// referenced.
+func putBuffer(b []byte) {
+	if cap(b) == 0 || cap(b) > maxPooledBuffer {
+		return
+	}
+
+	b = b[:0]
+	bufferPool.Put(&b)
+}
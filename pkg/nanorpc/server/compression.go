@@ -0,0 +1,24 @@
+package server
+
+import "protomcp.org/nanorpc/pkg/nanorpc"
+
+// compressResponse compresses the data of a response as
+// SessionConfig.CompressionThreshold says, if the session negotiated
+// compression.
+func (s *DefaultSession) compressResponse(response *nanorpc.NanoRPCResponse) error {
+	if !s.compresses() {
+		return nil
+	}
+	return nanorpc.CompressResponse(response, s.config.CompressionThreshold)
+}
+
+// compresses tells if the session compresses the data of its responses,
+// having a CompressionThreshold and negotiated compression.
+func (s *DefaultSession) compresses() bool {
+	if s.config.CompressionThreshold <= 0 {
+		return false
+	}
+
+	fn, ok := s.handler.(featureNegotiator)
+	return ok && fn.SessionFeatures(s.id).Has(nanorpc.FeatureCompression)
+}
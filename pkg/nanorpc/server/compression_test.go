@@ -0,0 +1,109 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+var _ core.TestCase = sessionCompressionTestCase{}
+
+// sessionCompressionTestCase verifies compressed requests are accepted,
+// and responses compressed only for sessions that negotiated it.
+type sessionCompressionTestCase struct {
+	name      string
+	threshold int
+	handshake bool
+	want      bool
+}
+
+func (tc sessionCompressionTestCase) Name() string { return tc.name }
+
+func (tc sessionCompressionTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	handler := NewDefaultMessageHandler(nil)
+	core.AssertMustNoError(t, handler.RegisterHandlerFunc(pathEcho, echoChainHandler), "register")
+
+	sm := NewDefaultSessionManager(handler, nil)
+	cfg := SessionConfig{CompressionThreshold: tc.threshold}
+	core.AssertMustNoError(t, sm.SetSessionConfig(cfg), "config")
+
+	payload := bytes.Repeat([]byte("sample "), 64)
+	conn := &mockConn{remoteAddr: "127.0.0.1:12345", data: tc.frames(t, payload)}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_ = sm.AddSession(conn).Handle(ctx)
+
+	resp := lastResponse(t, conn.writeData)
+	core.AssertEqual(t, tc.want, resp.Compressed, "compressed")
+	core.AssertMustNoError(t, nanorpc.DecompressResponse(resp, 0), "DecompressResponse")
+	// echoChainHandler appends the length of the chain, 1 when direct
+	core.AssertSliceEqual(t, append(payload, '1'), resp.Data, "data")
+}
+
+// frames encodes the optional handshake and a compressed echo request.
+func (tc sessionCompressionTestCase) frames(t *testing.T, payload []byte) []byte {
+	t.Helper()
+
+	var out []byte
+	if tc.handshake {
+		ping := &nanorpc.NanoRPCRequest{RequestId: 1, RequestType: nanorpc.NanoRPCRequest_TYPE_PING}
+		b, err := nanorpc.EncodeRequest(ping, nanorpc.NewHello(nanorpc.FeatureCompression))
+		core.AssertMustNoError(t, err, "encode handshake")
+		out = append(out, b...)
+	}
+
+	req := &nanorpc.NanoRPCRequest{
+		RequestId:   2,
+		RequestType: nanorpc.NanoRPCRequest_TYPE_REQUEST,
+		PathOneof:   nanorpc.GetPathOneOfString(pathEcho),
+		Data:        bytes.Clone(payload),
+	}
+	core.AssertMustNoError(t, nanorpc.CompressRequest(req, 1), "CompressRequest")
+	b, err := nanorpc.EncodeRequest(req, nil)
+	core.AssertMustNoError(t, err, "encode request")
+	return append(out, b...)
+}
+
+// lastResponse decodes the last of the responses written.
+func lastResponse(t *testing.T, data []byte) *nanorpc.NanoRPCResponse {
+	t.Helper()
+
+	var resp *nanorpc.NanoRPCResponse
+	for len(data) > 0 {
+		r, n, err := nanorpc.DecodeResponse(data)
+		core.AssertMustNoError(t, err, "decode")
+		resp, data = r, data[n:]
+	}
+	core.AssertMustNotNil(t, resp, "response")
+	return resp
+}
+
+func newSessionCompressionTestCase(name string, threshold int, handshake,
+	want bool) sessionCompressionTestCase {
+	return sessionCompressionTestCase{
+		name:      name,
+		threshold: threshold,
+		handshake: handshake,
+		want:      want,
+	}
+}
+
+func sessionCompressionTestCases() []sessionCompressionTestCase {
+	return []sessionCompressionTestCase{
+		newSessionCompressionTestCase("negotiated", 64, true, true),
+		newSessionCompressionTestCase("below threshold", 4096, true, false),
+		newSessionCompressionTestCase("no handshake", 64, false, false),
+		newSessionCompressionTestCase("disabled", 0, true, false),
+	}
+}
+
+func TestSessionConfig_CompressionThreshold(t *testing.T) {
+	core.RunTestCases(t, sessionCompressionTestCases())
+}
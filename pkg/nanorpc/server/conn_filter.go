@@ -0,0 +1,158 @@
+package server
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc/utils"
+)
+
+// ConnFilter decides if a connection accepted by a [Server] is served.
+// An error closes the connection before a session is created for it, so
+// unexpected peers don't reach the handlers. See [WithConnFilter].
+type ConnFilter func(net.Conn) error
+
+// WithConnFilter adds filters run, in order, on every accepted
+// connection, the first error rejecting it. They are called from the
+// accept loop, so they shouldn't block.
+func WithConnFilter(filters ...ConnFilter) ServerOption {
+	return func(s *Server) {
+		for _, fn := range filters {
+			if fn != nil {
+				s.connFilters = append(s.connFilters, fn)
+			}
+		}
+	}
+}
+
+// filterConn runs the connection filters on conn.
+func (s *Server) filterConn(conn net.Conn) error {
+	for _, fn := range s.connFilters {
+		if err := fn(conn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rejectConn closes a connection refused by a filter.
+func (s *Server) rejectConn(conn net.Conn, err error) {
+	if l, ok := s.WithDebug(); ok {
+		l = utils.WithError(utils.WithConnAddrs(l, conn), err)
+		l.Print("connection rejected")
+	}
+	_ = conn.Close()
+}
+
+// IPFilter is a CIDR allow and deny list of peer addresses. Denied
+// addresses are rejected, and with an allow list only the addresses it
+// contains are accepted. Peers without IP address, e.g. over unix
+// sockets, are always accepted.
+type IPFilter struct {
+	allow []netip.Prefix
+	deny  []netip.Prefix
+}
+
+// NewIPFilter creates an [IPFilter] from lists of CIDR prefixes or single
+// addresses, e.g. "10.0.0.0/8" or "192.0.2.1". IPv4 addresses also match
+// their IPv4-mapped IPv6 form. Malformed entries fail with
+// [ErrInvalidIPFilter].
+func NewIPFilter(allow, deny []string) (*IPFilter, error) {
+	a, err := parsePrefixes(allow)
+	if err != nil {
+		return nil, err
+	}
+	d, err := parsePrefixes(deny)
+	if err != nil {
+		return nil, err
+	}
+	return &IPFilter{allow: a, deny: d}, nil
+}
+
+// Allowed tells if the filter accepts an address.
+func (f *IPFilter) Allowed(addr netip.Addr) bool {
+	if f == nil {
+		return true
+	}
+
+	addr = addr.Unmap().WithZone("")
+	if containsAddr(f.deny, addr) {
+		return false
+	}
+	return len(f.allow) == 0 || containsAddr(f.allow, addr)
+}
+
+// Filter is a [ConnFilter] rejecting the connections of peers the filter
+// doesn't accept with [ErrConnRejected].
+func (f *IPFilter) Filter(conn net.Conn) error {
+	addr, ok := remoteIP(conn)
+	if !ok || f.Allowed(addr) {
+		return nil
+	}
+	return core.QuietWrap(ErrConnRejected, "peer %s", addr)
+}
+
+// remoteIP returns the IP address of the peer of conn, if it has one,
+// taking it from addresses of other types than TCP and UDP written as
+// host:port.
+func remoteIP(conn net.Conn) (netip.Addr, bool) {
+	switch addr := conn.RemoteAddr().(type) {
+	case nil:
+		return netip.Addr{}, false
+	case *net.TCPAddr:
+		return addr.AddrPort().Addr(), true
+	case *net.UDPAddr:
+		return addr.AddrPort().Addr(), true
+	default:
+		ap, err := netip.ParseAddrPort(addr.String())
+		return ap.Addr(), err == nil
+	}
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, p := range prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func parsePrefixes(entries []string) ([]netip.Prefix, error) {
+	out := make([]netip.Prefix, 0, len(entries))
+	for _, s := range entries {
+		p, err := parsePrefix(strings.TrimSpace(s))
+		if err != nil {
+			return nil, core.QuietWrap(ErrInvalidIPFilter, "%q", s)
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// parsePrefix parses a CIDR prefix, or an address as a prefix of its
+// full length.
+func parsePrefix(s string) (netip.Prefix, error) {
+	if !strings.Contains(s, "/") {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		addr = addr.Unmap()
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+
+	p, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	if p.Addr().Is4In6() {
+		// match the addresses Allowed unmaps
+		bits := max(p.Bits()-96, 0)
+		p = netip.PrefixFrom(p.Addr().Unmap(), bits)
+	}
+	return p.Masked(), nil
+}
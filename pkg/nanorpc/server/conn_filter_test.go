@@ -0,0 +1,116 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc/utils/testutils"
+)
+
+var _ core.TestCase = ipFilterTestCase{}
+
+type ipFilterTestCase struct {
+	name  string
+	allow []string
+	deny  []string
+	addr  string
+	want  bool
+}
+
+func (tc ipFilterTestCase) Name() string { return tc.name }
+
+func (tc ipFilterTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	f, err := NewIPFilter(tc.allow, tc.deny)
+	core.AssertMustNoError(t, err, "NewIPFilter")
+	core.AssertEqual(t, tc.want, f.Allowed(netip.MustParseAddr(tc.addr)), "Allowed")
+}
+
+func newIPFilterTestCase(name string, allow, deny []string, addr string, want bool) ipFilterTestCase {
+	return ipFilterTestCase{name: name, allow: allow, deny: deny, addr: addr, want: want}
+}
+
+func ipFilterTestCases() []ipFilterTestCase {
+	private := core.S("10.0.0.0/8", "fd00::/8")
+	return core.S(
+		newIPFilterTestCase("empty", nil, nil, "192.0.2.1", true),
+		newIPFilterTestCase("allowed", private, nil, "10.1.2.3", true),
+		newIPFilterTestCase("not allowed", private, nil, "192.0.2.1", false),
+		newIPFilterTestCase("allowed v6", private, nil, "fd00::1", true),
+		newIPFilterTestCase("mapped v4", private, nil, "::ffff:10.1.2.3", true),
+		newIPFilterTestCase("denied", nil, core.S("192.0.2.0/24"), "192.0.2.1", false),
+		newIPFilterTestCase("deny wins", private, core.S("10.0.0.66"), "10.0.0.66", false),
+		newIPFilterTestCase("single address", core.S("192.0.2.7"), nil, "192.0.2.7", true),
+		newIPFilterTestCase("mapped prefix", core.S("::ffff:10.0.0.0/104"), nil, "10.1.2.3", true),
+		newIPFilterTestCase("zone", core.S("fe80::/10"), nil, "fe80::1%eth0", true),
+	)
+}
+
+func TestIPFilter_Allowed(t *testing.T) {
+	core.RunTestCases(t, ipFilterTestCases())
+
+	var f *IPFilter
+	core.AssertTrue(t, f.Allowed(netip.MustParseAddr("192.0.2.1")), "nil filter")
+}
+
+func TestNewIPFilter_invalid(t *testing.T) {
+	for _, entry := range []string{"", "10.0.0.0/33", "example.org", "10.0.0.1/"} {
+		_, err := NewIPFilter(core.S(entry), nil)
+		core.AssertErrorIs(t, err, ErrInvalidIPFilter, "allow %q", entry)
+		_, err = NewIPFilter(nil, core.S(entry))
+		core.AssertErrorIs(t, err, ErrInvalidIPFilter, "deny %q", entry)
+	}
+}
+
+func TestIPFilter_Filter(t *testing.T) {
+	f, err := NewIPFilter(core.S("127.0.0.0/8"), nil)
+	core.AssertMustNoError(t, err, "NewIPFilter")
+
+	err = f.Filter(&testutils.MockConn{Remote: "127.0.0.1:12345"})
+	core.AssertNoError(t, err, "loopback")
+	err = f.Filter(&testutils.MockConn{Remote: "192.0.2.1:12345"})
+	core.AssertErrorIs(t, err, ErrConnRejected, "remote")
+	core.AssertNoError(t, f.Filter(&testutils.MockConn{Remote: "/run/admin.sock"}), "no IP")
+}
+
+// TestServer_WithConnFilter verifies rejected connections are closed
+// without a session.
+func TestServer_WithConnFilter(t *testing.T) {
+	errDenied := errors.New("denied")
+	var denied atomic.Bool
+	filter := func(net.Conn) error {
+		if denied.Load() {
+			return errDenied
+		}
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", "localhost:0")
+	core.AssertMustNoError(t, err, "listen")
+	server := NewDefaultServer(listener, nil, nil, WithConnFilter(nil, filter))
+	serverErr := startServe(context.Background(), server)
+	waitServerReady(t, server)
+
+	conn := connectToServer(t, listener.Addr().String())
+	defer conn.Close()
+	sendPingReceivePong(t, conn)
+
+	denied.Store(true)
+	rejected := connectToServer(t, listener.Addr().String())
+	defer rejected.Close()
+	core.AssertMustNoError(t, rejected.SetReadDeadline(time.Now().Add(time.Second)), "deadline")
+	_, err = rejected.Read(make([]byte, 1))
+	core.AssertErrorIs(t, err, io.EOF, "closed")
+	core.AssertEqual(t, 1, len(server.Sessions()), "sessions")
+
+	shutdownServer(t, server, serverErr)
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"errors"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// ContentType returns the content type of the request data, declared by
+// its metadata, or [nanorpc.ContentTypeProtobuf] if none.
+func (rc *RequestContext) ContentType() string {
+	return rc.Metadata().ContentType()
+}
+
+// UnmarshalRequest decodes the request data into v by its content type,
+// letting a handler serve clients encoding protobuf, JSON or CBOR alike.
+// Content types it can't decode into v fail as bad requests, see
+// [nanorpc.Unmarshal].
+func (rc *RequestContext) UnmarshalRequest(v any) error {
+	if rc == nil {
+		return core.ErrNilReceiver
+	}
+
+	if len(rc.Request.Data) == 0 {
+		return errors.New("request has no data")
+	}
+
+	if err := nanorpc.Unmarshal(rc.ContentType(), rc.Request.Data, v); err != nil {
+		return core.Wrapf(err, "failed to unmarshal %s request", rc.ContentType())
+	}
+
+	return nil
+}
+
+// SendEncoded encodes v in the content type of the request and sends it as
+// a successful response, declaring that content type in its metadata
+// unless it's protobuf, the default.
+func (rc *RequestContext) SendEncoded(v any) error {
+	if rc == nil {
+		return core.ErrNilReceiver
+	}
+
+	ct := rc.ContentType()
+	data, err := nanorpc.Marshal(ct, v)
+	if err != nil {
+		return core.Wrapf(err, "failed to marshal %s response", ct)
+	}
+
+	if ct != nanorpc.ContentTypeProtobuf {
+		if err := rc.SetResponseMetadata(nanorpc.MetadataContentType, ct); err != nil {
+			return err
+		}
+	}
+	return rc.SendOK(data)
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+var _ core.TestCase = contentTypeTestCase{}
+
+// contentTypeTestCase verifies a handler decodes a request and answers in
+// its content type.
+type contentTypeTestCase struct {
+	name        string
+	contentType string
+	declared    string
+}
+
+func (tc contentTypeTestCase) Name() string { return tc.name }
+
+func (tc contentTypeTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	data, err := nanorpc.Marshal(tc.contentType, testData{Name: "temp", Value: 21})
+	core.AssertMustNoError(t, err, "Marshal")
+
+	session := newTestSession("", 0)
+	rc := &RequestContext{
+		Session: session,
+		Request: &nanorpc.NanoRPCRequest{
+			RequestId: 1,
+			Metadata:  nanorpc.NewMetadata(nanorpc.MetadataContentType, tc.contentType),
+			Data:      data,
+		},
+	}
+	core.AssertEqual(t, tc.contentType, rc.ContentType(), "ContentType")
+
+	var in testData
+	core.AssertMustNoError(t, rc.UnmarshalRequest(&in), "UnmarshalRequest")
+	core.AssertEqual(t, "temp", in.Name, "name")
+
+	in.Value++
+	core.AssertMustNoError(t, rc.SendEncoded(in), "SendEncoded")
+
+	res := session.GetLastResponse()
+	declared, _ := nanorpc.Metadata(res.Metadata).Get(nanorpc.MetadataContentType)
+	core.AssertEqual(t, tc.declared, declared, "declared")
+
+	var out testData
+	core.AssertMustNoError(t, nanorpc.Unmarshal(tc.contentType, res.Data, &out), "Unmarshal")
+	core.AssertEqual(t, 22, out.Value, "value")
+}
+
+func newContentTypeTestCase(name, contentType, declared string) contentTypeTestCase {
+	return contentTypeTestCase{name: name, contentType: contentType, declared: declared}
+}
+
+func contentTypeTestCases() []contentTypeTestCase {
+	return []contentTypeTestCase{
+		newContentTypeTestCase("json", nanorpc.ContentTypeJSON, nanorpc.ContentTypeJSON),
+		newContentTypeTestCase("cbor", nanorpc.ContentTypeCBOR, nanorpc.ContentTypeCBOR),
+	}
+}
+
+func TestRequestContext_ContentType(t *testing.T) {
+	core.RunTestCases(t, contentTypeTestCases())
+
+	rc := &RequestContext{
+		Session: newTestSession("", 0),
+		Request: &nanorpc.NanoRPCRequest{RequestId: 1, Data: []byte{0x08, 0x01}},
+	}
+	core.AssertEqual(t, nanorpc.ContentTypeProtobuf, rc.ContentType(), "default")
+
+	var out testData
+	err := rc.UnmarshalRequest(&out)
+	core.AssertErrorIs(t, err, nanorpc.ErrUnsupportedContentType, "protobuf into struct")
+	core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_BAD_REQUEST, nanorpc.StatusOf(err), "status")
+
+	hello := new(nanorpc.NanoRPCHello)
+	core.AssertMustNoError(t, rc.UnmarshalRequest(hello), "protobuf")
+	core.AssertEqual(t, uint32(1), hello.Version, "version")
+	core.AssertMustNoError(t, rc.SendEncoded(hello), "SendEncoded protobuf")
+	core.AssertEqual(t, 0, len(rc.ResponseMetadata()), "protobuf undeclared")
+
+	var nilRC *RequestContext
+	core.AssertErrorIs(t, nilRC.UnmarshalRequest(&out), core.ErrNilReceiver, "nil UnmarshalRequest")
+	core.AssertErrorIs(t, nilRC.SendEncoded(out), core.ErrNilReceiver, "nil SendEncoded")
+}
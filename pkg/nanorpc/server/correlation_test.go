@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"darvaza.org/core"
+	"darvaza.org/slog/handlers/mock"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/utils"
+)
+
+var _ core.TestCase = sessionCorrelationTestCase{}
+
+// sessionCorrelationTestCase verifies the correlation IDs handlers see and
+// responses echo, given by the client or the session.
+type sessionCorrelationTestCase struct {
+	name    string
+	given   string
+	enabled bool
+}
+
+func (tc sessionCorrelationTestCase) Name() string { return tc.name }
+
+func (tc sessionCorrelationTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	var seen string
+	h := NewDefaultMessageHandler(nil)
+	core.AssertMustNoError(t, h.RegisterHandlerFunc(pathEcho, func(_ context.Context, rc *RequestContext) error {
+		seen = rc.CorrelationID()
+		return rc.SendOK(nil)
+	}), "register")
+
+	s, conn := newSharedTestSession(h)
+	s.config.CorrelationIDs = tc.enabled
+
+	req := newTestRequest(7, pathEcho)
+	if tc.given != "" {
+		req.Metadata = nanorpc.NewMetadata(nanorpc.MetadataCorrelationID, tc.given)
+	}
+	core.AssertMustNoError(t, s.decodeAndHandle(context.Background(), encodeTestFrame(t, req), 0), "handle")
+
+	resp, _, err := nanorpc.DecodeResponse(conn.writeData)
+	core.AssertMustNoError(t, err, "decode")
+	echoed := nanorpc.Metadata(resp.GetMetadata()).CorrelationID()
+
+	switch {
+	case !tc.enabled:
+		core.AssertEqual(t, tc.given, seen, "handler")
+		core.AssertEqual(t, "", echoed, "not echoed")
+	case tc.given != "":
+		core.AssertEqual(t, tc.given, seen, "handler")
+		core.AssertEqual(t, tc.given, echoed, "echoed")
+	default:
+		core.AssertEqual(t, 16, len(seen), "generated")
+		core.AssertEqual(t, seen, echoed, "echoed")
+	}
+}
+
+func newSessionCorrelationTestCase(name, given string, enabled bool) sessionCorrelationTestCase {
+	return sessionCorrelationTestCase{name: name, given: given, enabled: enabled}
+}
+
+func sessionCorrelationTestCases() []sessionCorrelationTestCase {
+	return []sessionCorrelationTestCase{
+		newSessionCorrelationTestCase("generated", "", true),
+		newSessionCorrelationTestCase("accepted", "abc", true),
+		newSessionCorrelationTestCase("disabled", "", false),
+		newSessionCorrelationTestCase("disabled_given", "abc", false),
+	}
+}
+
+func TestSessionConfig_CorrelationIDs(t *testing.T) {
+	core.RunTestCases(t, sessionCorrelationTestCases())
+}
+
+// TestSessionConfig_CorrelationIDsLogged verifies handler errors are
+// logged with the correlation ID of their request.
+func TestSessionConfig_CorrelationIDsLogged(t *testing.T) {
+	h := NewDefaultMessageHandler(nil)
+	core.AssertMustNoError(t, h.RegisterHandlerFunc(pathEcho, func(context.Context, *RequestContext) error {
+		return errors.New("failed")
+	}), "register")
+
+	logger := mock.NewLogger()
+	s := NewDefaultSession(&mockConn{remoteAddr: "127.0.0.1:12345"}, h, logger)
+	s.config.CorrelationIDs = true
+
+	req := newTestRequest(7, pathEcho)
+	req.Metadata = nanorpc.NewMetadata(nanorpc.MetadataCorrelationID, "abc")
+	core.AssertMustNoError(t, s.decodeAndHandle(context.Background(), encodeTestFrame(t, req), 0), "handle")
+
+	var found bool
+	for _, msg := range logger.GetMessages() {
+		if msg.Message == "Handler error" {
+			found = true
+			core.AssertEqual[any](t, "abc", msg.Fields[utils.FieldCorrelationID], "correlation ID")
+			core.AssertEqual[any](t, int32(7), msg.Fields[utils.FieldRequestID], "request ID")
+		}
+	}
+	core.AssertTrue(t, found, "handler error logged")
+}
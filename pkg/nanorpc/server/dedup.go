@@ -0,0 +1,159 @@
+package server
+
+import (
+	"sync"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/utils"
+)
+
+// DedupPolicy tells what a session does with a request repeating one of
+// the latest it received, see [SessionConfig].DedupWindow.
+type DedupPolicy int
+
+const (
+	// DedupDrop ignores the duplicate, leaving the response to the
+	// original to answer it.
+	DedupDrop DedupPolicy = iota
+	// DedupReplay answers the duplicate with the response sent to the
+	// original, or ignores it while the original is in progress.
+	DedupReplay
+	// DedupReject answers the duplicate with STATUS_BAD_REQUEST.
+	DedupReject
+)
+
+// dedupKey identifies a request within a [dedupWindow]. Request IDs are
+// reused by unsubscribes and acknowledgements, so the type and path tell
+// them apart.
+type dedupKey struct {
+	path string
+	hash uint32
+	id   int32
+	typ  nanorpc.NanoRPCRequest_Type
+}
+
+func newDedupKey(req *nanorpc.NanoRPCRequest) dedupKey {
+	return dedupKey{
+		path: req.GetPath(),
+		hash: req.GetPathHash(),
+		id:   req.GetRequestId(),
+		typ:  req.GetRequestType(),
+	}
+}
+
+// dedupWindow remembers the latest requests of a session, with the
+// encoded response sent to each when replayed.
+type dedupWindow struct {
+	seen map[dedupKey][]byte
+	keys []dedupKey // ring, next is the oldest once full
+	next int
+	mu   sync.Mutex
+}
+
+func newDedupWindow(size int) *dedupWindow {
+	return &dedupWindow{
+		seen: make(map[dedupKey][]byte, size),
+		keys: make([]dedupKey, 0, size),
+	}
+}
+
+// observe tells if key is in the window, returning the response kept for
+// it, and adds it otherwise, forgetting the oldest when full.
+func (w *dedupWindow) observe(key dedupKey) ([]byte, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if data, ok := w.seen[key]; ok {
+		return data, true
+	}
+
+	if len(w.keys) < cap(w.keys) {
+		w.keys = append(w.keys, key)
+	} else {
+		delete(w.seen, w.keys[w.next])
+		w.keys[w.next] = key
+		w.next = (w.next + 1) % len(w.keys)
+	}
+	w.seen[key] = nil
+	return nil, false
+}
+
+// keep stores a copy of the response to the request of key, if still in
+// the window.
+func (w *dedupWindow) keep(key dedupKey, data []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, ok := w.seen[key]; ok {
+		w.seen[key] = append([]byte(nil), data...)
+	}
+}
+
+// getDedup returns the dedup window of the session when DedupWindow is
+// set, or nil.
+func (s *DefaultSession) getDedup() *dedupWindow {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.dedup == nil && s.config.DedupWindow > 0 {
+		s.dedup = newDedupWindow(s.config.DedupWindow)
+	}
+	return s.dedup
+}
+
+// isDedupable tells if a request is checked for duplicates: requests and
+// subscriptions with an ID, not deemed idempotent by the configuration.
+func (s *DefaultSession) isDedupable(req *nanorpc.NanoRPCRequest) bool {
+	switch req.GetRequestType() {
+	case nanorpc.NanoRPCRequest_TYPE_REQUEST, nanorpc.NanoRPCRequest_TYPE_SUBSCRIBE:
+	default:
+		return false
+	}
+
+	if req.GetRequestId() == 0 {
+		return false
+	}
+	return s.config.Idempotent == nil || !s.config.Idempotent(req)
+}
+
+// dedupRequest tells if req repeats one of the latest requests of the
+// session, dealing with it as the DedupPolicy says.
+func (s *DefaultSession) dedupRequest(req *nanorpc.NanoRPCRequest) bool {
+	w := s.getDedup()
+	if w == nil || !s.isDedupable(req) {
+		return false
+	}
+
+	data, dup := w.observe(newDedupKey(req))
+	if !dup {
+		return false
+	}
+
+	s.getLogger().Debug().
+		WithFields(utils.RequestFields(req)).
+		WithField(utils.FieldRequestType, req.GetRequestType().String()).
+		Print("Duplicate request")
+
+	switch {
+	case s.config.DedupPolicy == DedupReplay && data != nil:
+		_ = s.write(data)
+	case s.config.DedupPolicy == DedupReject:
+		_ = s.SendResponse(req, &nanorpc.NanoRPCResponse{
+			ResponseType:    nanorpc.NanoRPCResponse_TYPE_RESPONSE,
+			ResponseStatus:  nanorpc.NanoRPCResponse_STATUS_BAD_REQUEST,
+			ResponseMessage: "duplicate request",
+		})
+	}
+	return true
+}
+
+// keepResponse remembers the encoded final response to a request, to
+// replay it to duplicates.
+func (s *DefaultSession) keepResponse(req *nanorpc.NanoRPCRequest, data []byte) {
+	if s.config.DedupPolicy != DedupReplay {
+		return
+	}
+	if w := s.getDedup(); w != nil && s.isDedupable(req) {
+		w.keep(newDedupKey(req), data)
+	}
+}
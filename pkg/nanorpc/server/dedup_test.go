@@ -0,0 +1,115 @@
+package server
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+var _ core.TestCase = dedupTestCase{}
+
+// dedupTestCase sends a session a sequence of requests, counting the
+// handler calls and checking the statuses of the responses.
+type dedupTestCase struct {
+	idempotent func(*nanorpc.NanoRPCRequest) bool
+	name       string
+	requests   []*nanorpc.NanoRPCRequest
+	statuses   []nanorpc.NanoRPCResponse_Status
+	window     int
+	calls      int32
+	policy     DedupPolicy
+}
+
+func (tc dedupTestCase) Name() string { return tc.name }
+
+func (tc dedupTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	var calls atomic.Int32
+	h := NewDefaultMessageHandler(nil)
+	core.AssertMustNoError(t, h.RegisterHandlerFunc(pathEcho, func(_ context.Context, rc *RequestContext) error {
+		n := calls.Add(1)
+		return rc.SendOK([]byte(strconv.Itoa(int(n))))
+	}), "register")
+
+	sm := NewDefaultSessionManager(h, nil)
+	core.AssertMustNoError(t, sm.SetSessionConfig(SessionConfig{
+		DedupWindow: tc.window,
+		DedupPolicy: tc.policy,
+		Idempotent:  tc.idempotent,
+	}), "config")
+
+	var data []byte
+	for _, req := range tc.requests {
+		b, err := nanorpc.EncodeRequest(req, nil)
+		core.AssertMustNoError(t, err, "encode")
+		data = append(data, b...)
+	}
+
+	conn := &mockConn{remoteAddr: "127.0.0.1:12345", data: data}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_ = sm.AddSession(conn).Handle(ctx)
+
+	core.AssertEqual(t, tc.calls, calls.Load(), "handler calls")
+	responses := decodeResponses(t, conn.writeData)
+	core.AssertMustEqual(t, len(tc.statuses), len(responses), "responses")
+	for i, status := range tc.statuses {
+		core.AssertEqual(t, status, responses[i].ResponseStatus, "status %d", i)
+	}
+}
+
+func dedupTestCases() []dedupTestCase {
+	ok, bad := nanorpc.NanoRPCResponse_STATUS_OK, nanorpc.NanoRPCResponse_STATUS_BAD_REQUEST
+	first, other := newTestRequest(7, pathEcho), newTestRequest(8, pathEcho)
+	always := func(*nanorpc.NanoRPCRequest) bool { return true }
+
+	return []dedupTestCase{
+		{name: "disabled", requests: core.S(first, first), calls: 2, statuses: core.S(ok, ok)},
+		{name: "drop", window: 4, requests: core.S(first, first, other), calls: 2, statuses: core.S(ok, ok)},
+		{name: "replay", window: 4, policy: DedupReplay, requests: core.S(first, first),
+			calls: 1, statuses: core.S(ok, ok)},
+		{name: "reject", window: 4, policy: DedupReject, requests: core.S(first, first),
+			calls: 1, statuses: core.S(ok, bad)},
+		{name: "idempotent", window: 4, idempotent: always, requests: core.S(first, first),
+			calls: 2, statuses: core.S(ok, ok)},
+		{name: "slid out", window: 1, requests: core.S(first, other, first),
+			calls: 3, statuses: core.S(ok, ok, ok)},
+		{name: "no id", window: 4, requests: core.S(newTestRequest(0, pathEcho), newTestRequest(0, pathEcho)),
+			calls: 2, statuses: core.S(ok, ok)},
+	}
+}
+
+func TestSessionConfig_DedupWindow(t *testing.T) {
+	core.RunTestCases(t, dedupTestCases())
+}
+
+// TestSessionConfig_DedupReplay verifies duplicates get the very response
+// sent to the original.
+func TestSessionConfig_DedupReplay(t *testing.T) {
+	h := NewDefaultMessageHandler(nil)
+	core.AssertMustNoError(t, h.RegisterHandlerFunc(pathEcho, func(_ context.Context, rc *RequestContext) error {
+		return rc.SendOK([]byte(time.Now().String()))
+	}), "register")
+
+	s := NewDefaultSession(&mockConn{remoteAddr: "127.0.0.1:12345"}, h, nil)
+	s.config = SessionConfig{DedupWindow: 2, DedupPolicy: DedupReplay}
+	data, err := nanorpc.EncodeRequest(newTestRequest(7, pathEcho), nil)
+	core.AssertMustNoError(t, err, "encode")
+
+	for range 2 {
+		core.AssertMustNoError(t, s.decodeAndHandle(context.Background(), data, 0), "handle")
+	}
+
+	conn, ok := s.conn.(*mockConn)
+	core.AssertMustTrue(t, ok, "conn")
+	responses := decodeResponses(t, conn.writeData)
+	core.AssertMustEqual(t, 2, len(responses), "responses")
+	core.AssertEqual(t, string(responses[0].Data), string(responses[1].Data), "replayed")
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+
+	"darvaza.org/core"
+)
+
+// ListenDualStack listens on port over TCP on all the IPv6 and IPv4
+// addresses of the host, with a listener per family, and returns them as
+// a [MultiListener]. A zero port gets one chosen by the system, shared
+// by both families.
+//
+// When the host lacks one of the families, e.g. an IPv6-only mesh, only
+// the other is listened on. It fails if neither can be, or on any other
+// error like the port being in use.
+func ListenDualStack(ctx context.Context, port string) (*MultiListener, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var lc net.ListenConfig
+	l6, err6 := lc.Listen(ctx, "tcp6", net.JoinHostPort("::", port))
+	if err6 != nil && !isFamilyUnavailable(err6) {
+		return nil, err6
+	}
+
+	if l6 != nil && isZeroPort(port) {
+		// share the port chosen for IPv6
+		_, port, _ = net.SplitHostPort(l6.Addr().String())
+	}
+
+	l4, err4 := lc.Listen(ctx, "tcp4", net.JoinHostPort("0.0.0.0", port))
+	switch {
+	case err4 == nil:
+		return NewMultiListener(l6, l4), nil
+	case !isFamilyUnavailable(err4):
+		if l6 != nil {
+			_ = l6.Close()
+		}
+		return nil, err4
+	case l6 != nil:
+		return NewMultiListener(l6), nil
+	default:
+		return nil, core.Wrap(errors.Join(err6, err4), "no address family available")
+	}
+}
+
+// isFamilyUnavailable tells errors caused by the host not supporting an
+// address family from other listening errors.
+func isFamilyUnavailable(err error) bool {
+	return errors.Is(err, syscall.EAFNOSUPPORT) ||
+		errors.Is(err, syscall.EPROTONOSUPPORT) ||
+		errors.Is(err, syscall.EADDRNOTAVAIL)
+}
+
+func isZeroPort(port string) bool {
+	return port == "" || port == "0"
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"darvaza.org/core"
+)
+
+// loopbackAddr returns the loopback address of the family of a listener
+// bound to all addresses.
+func loopbackAddr(addr net.Addr) string {
+	tcp := addr.(*net.TCPAddr)
+	if tcp.IP.To4() != nil {
+		return (&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: tcp.Port}).String()
+	}
+	return (&net.TCPAddr{IP: net.IPv6loopback, Port: tcp.Port}).String()
+}
+
+func TestMultiListener(t *testing.T) {
+	l1, err := net.Listen("tcp", "127.0.0.1:0")
+	core.AssertMustNoError(t, err, "listen 1")
+	l2, err := net.Listen("tcp", "127.0.0.1:0")
+	core.AssertMustNoError(t, err, "listen 2")
+
+	ml := NewMultiListener(nil, l1, l2)
+	core.AssertMustNotNil(t, ml, "NewMultiListener")
+	core.AssertSliceEqual(t, []net.Addr{l1.Addr(), l2.Addr()}, ml.Addrs(), "Addrs")
+	core.AssertEqual(t, l1.Addr(), ml.Addr(), "Addr")
+
+	for _, addr := range ml.Addrs() {
+		conn, err := net.Dial("tcp", addr.String())
+		core.AssertMustNoError(t, err, "dial")
+		defer conn.Close()
+
+		accepted, err := ml.Accept()
+		core.AssertMustNoError(t, err, "accept")
+		core.AssertEqual(t, conn.LocalAddr().String(), accepted.RemoteAddr().String(), "peer")
+		_ = accepted.Close()
+	}
+
+	core.AssertNoError(t, ml.Close(), "close")
+	core.AssertNoError(t, ml.Close(), "close again")
+
+	_, err = ml.Accept()
+	core.AssertErrorIs(t, err, net.ErrClosed, "accept after close")
+	core.AssertTrue(t, new(Server).isExpectedAcceptError(err), "expected accept error")
+
+	core.AssertNil(t, NewMultiListener(), "no listeners")
+	core.AssertNil(t, NewMultiListener(nil), "nil listener")
+}
+
+func TestListenDualStack(t *testing.T) {
+	ml, err := ListenDualStack(context.Background(), "0")
+	core.AssertMustNoError(t, err, "ListenDualStack")
+	defer ml.Close()
+
+	addrs := ml.Addrs()
+	core.AssertTrue(t, len(addrs) > 0 && len(addrs) <= 2, "listeners")
+
+	port := addrs[0].(*net.TCPAddr).Port
+	for _, addr := range addrs {
+		core.AssertEqual(t, port, addr.(*net.TCPAddr).Port, "shared port")
+
+		conn, err := net.Dial("tcp", loopbackAddr(addr))
+		if err != nil {
+			// listening on a family doesn't guarantee a loopback
+			t.Logf("dial %s: %v", addr, err)
+			continue
+		}
+		accepted, err := ml.Accept()
+		core.AssertNoError(t, err, "accept")
+		_ = accepted.Close()
+		_ = conn.Close()
+	}
+
+	// the port is taken now
+	_, err = ListenDualStack(context.Background(), strconv.Itoa(port))
+	core.AssertError(t, err, "port in use")
+}
+
+func TestIsFamilyUnavailable(t *testing.T) {
+	opErr := &net.OpError{Op: "listen", Err: &net.AddrError{}}
+	core.AssertFalse(t, isFamilyUnavailable(opErr), "address error")
+	core.AssertFalse(t, isFamilyUnavailable(syscall.EADDRINUSE), "in use")
+
+	for _, errno := range []syscall.Errno{syscall.EAFNOSUPPORT, syscall.EPROTONOSUPPORT, syscall.EADDRNOTAVAIL} {
+		err := &net.OpError{Op: "listen", Err: &os.SyscallError{Syscall: "bind", Err: errno}}
+		core.AssertTrue(t, isFamilyUnavailable(err), errno.Error())
+	}
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"errors"
+	"io/fs"
+
+	"darvaza.org/core"
+)
+
+// Invalid-argument sentinels for the server package. Each wraps
+// [core.ErrInvalid], so a caller can match a specific cause or the whole
+// family via [IsInvalid]. Call sites add dynamic context by wrapping the
+// sentinel, e.g. core.QuietWrap(ErrForwardLoop, "path %q", path).
+var (
+	// ErrForwardLoop indicates a forwarded request would revisit a path
+	// already in its forwarding chain, or exceed [MaxForwardDepth].
+	ErrForwardLoop = core.QuietWrap(core.ErrInvalid, "request forwarding loop")
+
+	// ErrForwardUnavailable indicates Forward was called on a
+	// [RequestContext] not created by a [DefaultMessageHandler].
+	ErrForwardUnavailable = core.QuietWrap(core.ErrInvalid, "request forwarding unavailable")
+
+	// ErrIdentityUnavailable indicates SetIdentity was called on a
+	// [RequestContext] not created by a [DefaultMessageHandler].
+	ErrIdentityUnavailable = core.QuietWrap(core.ErrInvalid, "session identity unavailable")
+
+	// ErrInvalidManifest indicates a route manifest that cannot be
+	// applied: malformed, duplicated paths, or unknown templates.
+	ErrInvalidManifest = core.QuietWrap(core.ErrInvalid, "invalid manifest")
+
+	// ErrInvalidIPFilter indicates a malformed CIDR prefix or address
+	// passed to [NewIPFilter].
+	ErrInvalidIPFilter = core.QuietWrap(core.ErrInvalid, "invalid IP filter")
+
+	// ErrInvalidPattern indicates a handler path with malformed
+	// parameters or wildcard, see [DefaultMessageHandler.RegisterHandler].
+	ErrInvalidPattern = core.QuietWrap(core.ErrInvalid, "invalid path pattern")
+
+	// ErrMissingListener indicates a nil listener was passed to
+	// [Server.AddListener], or [Server.Serve] was called on a server
+	// without listeners.
+	ErrMissingListener = core.QuietWrap(core.ErrInvalid, "listener missing")
+
+	// ErrMissingInterceptor indicates a nil [Interceptor] was passed to Use.
+	ErrMissingInterceptor = core.QuietWrap(core.ErrInvalid, "interceptor missing")
+
+	// ErrInterceptorsUnsupported indicates [Server.Use] was called on a
+	// server whose [MessageHandler] doesn't take interceptors.
+	ErrInterceptorsUnsupported = core.QuietWrap(core.ErrInvalid, "message handler doesn't support interceptors")
+
+	// ErrInvalidTLSConfig indicates TLS settings that cannot be used to
+	// serve connections.
+	ErrInvalidTLSConfig = core.QuietWrap(core.ErrInvalid, "invalid TLS configuration")
+
+	// ErrNoAuthenticator indicates a request reached a handler marked with
+	// [RequireAuth] on a [DefaultMessageHandler] without [Authenticator].
+	ErrNoAuthenticator = core.QuietWrap(core.ErrInvalid, "authenticator missing")
+
+	// ErrSendToUnsupported indicates [Server.SendTo] was called on a
+	// server whose [SessionManager] isn't an [IdentityIndex], or whose
+	// [MessageHandler] can't publish to a single session.
+	ErrSendToUnsupported = core.QuietWrap(core.ErrInvalid, "server doesn't support targeted sends")
+
+	// ErrUnsubscribeUnsupported indicates [Server.Unsubscribe] was called
+	// on a server whose [MessageHandler] can't force unsubscriptions.
+	ErrUnsubscribeUnsupported = core.QuietWrap(core.ErrInvalid, "message handler doesn't support forced unsubscription")
+
+	// ErrMissingHandler indicates a nil function was passed to
+	// [RegisterTyped], [MemoryBus.Subscribe] or
+	// [DefaultMessageHandler.ReplaceHandler].
+	ErrMissingHandler = core.QuietWrap(core.ErrInvalid, "handler missing")
+
+	// ErrMissingTransform indicates a nil [PublishTransform] was passed to
+	// PublishFunc.
+	ErrMissingTransform = core.QuietWrap(core.ErrInvalid, "publish transform missing")
+
+	// ErrInvalidFilter indicates a subscription filter a [FilterEvaluator]
+	// can't decode.
+	ErrInvalidFilter = core.QuietWrap(core.ErrInvalid, "invalid subscription filter")
+
+	// ErrSessionRegistryUnsupported indicates [Server.CloseSession] was
+	// called on a server whose [SessionManager] isn't a
+	// [SessionRegistry].
+	ErrSessionRegistryUnsupported = core.QuietWrap(core.ErrInvalid, "session manager doesn't support closing sessions")
+)
+
+// ErrNoSubscription indicates a forced unsubscription, or an
+// [EventBus] one, matched no subscription. It wraps [core.ErrNotExists].
+var ErrNoSubscription = core.QuietWrap(core.ErrNotExists, "no matching subscription")
+
+// ErrUnknownIdentity indicates no session is bound to an identity. It
+// wraps [core.ErrNotExists].
+var ErrUnknownIdentity = core.QuietWrap(core.ErrNotExists, "identity not connected")
+
+// ErrUnknownSession indicates no open session has an ID. It wraps
+// [core.ErrNotExists].
+var ErrUnknownSession = core.QuietWrap(core.ErrNotExists, "session not found")
+
+// ErrIdentityInUse indicates an identity is bound to another session,
+// see [IdentityRejectNew]. It wraps [core.ErrExists].
+var ErrIdentityInUse = core.QuietWrap(core.ErrExists, "identity in use")
+
+// ErrOutboundOverflow indicates a subscription update found the outbound
+// queue of its session full, and the session was closed, see
+// [OverflowDisconnect].
+var ErrOutboundOverflow = errors.New("outbound queue overflow")
+
+// ErrAccessDenied indicates the access rules of a path, see
+// [DefaultMessageHandler.SetAccess], deny it to a session. It wraps
+// [fs.ErrPermission], as [nanorpc.IsNotAuthorized] expects.
+var ErrAccessDenied = core.QuietWrap(fs.ErrPermission, "access denied")
+
+// ErrConnRejected indicates an [IPFilter] refused the connection of a
+// peer. It wraps [fs.ErrPermission].
+var ErrConnRejected = core.QuietWrap(fs.ErrPermission, "connection rejected")
+
+// IsInvalid reports whether err is an invalid-argument error. It matches
+// [core.ErrInvalid] — the base the package's sentinels wrap — anywhere in
+// the chain.
+func IsInvalid(err error) bool {
+	return core.IsErrorFn(checkIsInvalid, err)
+}
+
+func checkIsInvalid(err error) bool {
+	return err == core.ErrInvalid
+}
@@ -0,0 +1,220 @@
+package server
+
+import (
+	"slices"
+	"sync"
+
+	"darvaza.org/core"
+)
+
+// EventHandler receives the updates of an [EventBus] subscription. It's
+// called from the goroutine publishing them, so it must not block.
+type EventHandler func(path string, data []byte)
+
+// EventBus carries updates from publishers to subscribers by path, so
+// code publishing them needn't hold the [DefaultMessageHandler] delivering
+// them to sessions, see [DefaultMessageHandler.SetEventBus], and other
+// implementations, sharded or persistent, can be swapped in.
+// Implementations must be safe for concurrent use.
+type EventBus interface {
+	Publisher
+
+	// Subscribe calls fn with every update published from now on on the
+	// paths matching pattern, with the syntax of subscription patterns,
+	// until the subscription is ended with the returned ID. An empty
+	// pattern matches every path.
+	Subscribe(pattern string, fn EventHandler) (uint64, error)
+
+	// Unsubscribe ends a subscription. Unknown IDs fail with
+	// [ErrNoSubscription].
+	Unsubscribe(id uint64) error
+
+	// Snapshot returns the last update published on every path.
+	Snapshot() map[string][]byte
+}
+
+var _ EventBus = (*MemoryBus)(nil)
+
+// MemoryBus is an in-process [EventBus]. Subscribers are called in the
+// order they subscribed, and empty updates, used as heartbeats, don't
+// replace the last one of a path in the Snapshot.
+type MemoryBus struct {
+	last map[string][]byte
+	subs []busSubscription
+	next uint64
+	mu   sync.Mutex
+}
+
+// busSubscription is a subscription to a [MemoryBus].
+type busSubscription struct {
+	fn      EventHandler
+	pattern *routePattern // nil for exact paths
+	path    string
+	id      uint64
+}
+
+// matches tells if an update published on path is for the subscription.
+func (s busSubscription) matches(path string) bool {
+	switch {
+	case s.path == "":
+		return true
+	case s.pattern != nil:
+		_, ok := s.pattern.match(path)
+		return ok
+	default:
+		return s.path == path
+	}
+}
+
+// NewMemoryBus creates an empty [MemoryBus].
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{
+		last: make(map[string][]byte),
+	}
+}
+
+// Publish calls the subscribers of path with data, outside the lock.
+func (b *MemoryBus) Publish(path string, data []byte) error {
+	if b == nil {
+		return core.ErrNilReceiver
+	}
+
+	for _, fn := range b.collect(path, data) {
+		fn(path, data)
+	}
+	return nil
+}
+
+// collect records data as the last update of path and returns the
+// subscribers to call.
+func (b *MemoryBus) collect(path string, data []byte) []EventHandler {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(data) > 0 {
+		if b.last == nil {
+			b.last = make(map[string][]byte)
+		}
+		b.last[path] = slices.Clone(data)
+	}
+
+	var fns []EventHandler
+	for _, s := range b.subs {
+		if s.matches(path) {
+			fns = append(fns, s.fn)
+		}
+	}
+	return fns
+}
+
+// Subscribe calls fn with every update published on the paths matching
+// pattern, see [EventBus]. Malformed patterns fail with
+// [ErrInvalidPattern], and a nil fn with [ErrMissingHandler].
+func (b *MemoryBus) Subscribe(pattern string, fn EventHandler) (uint64, error) {
+	switch {
+	case b == nil:
+		return 0, core.ErrNilReceiver
+	case fn == nil:
+		return 0, ErrMissingHandler
+	}
+
+	p, err := newSubscriptionPattern(pattern, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.next++
+	b.subs = append(b.subs, busSubscription{
+		fn:      fn,
+		pattern: p,
+		path:    pattern,
+		id:      b.next,
+	})
+	return b.next, nil
+}
+
+// Unsubscribe ends a subscription, see [EventBus].
+func (b *MemoryBus) Unsubscribe(id uint64) error {
+	if b == nil {
+		return core.ErrNilReceiver
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	n := len(b.subs)
+	b.subs = slices.DeleteFunc(b.subs, func(s busSubscription) bool { return s.id == id })
+	if len(b.subs) == n {
+		return core.QuietWrap(ErrNoSubscription, "bus subscription %d", id)
+	}
+	return nil
+}
+
+// Snapshot returns a copy of the last non-empty update published on every
+// path.
+func (b *MemoryBus) Snapshot() map[string][]byte {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	out := make(map[string][]byte, len(b.last))
+	for path, data := range b.last {
+		out[path] = slices.Clone(data)
+	}
+	return out
+}
+
+// SetEventBus has the handler deliver the updates published on bus to its
+// subscriptions, as [DefaultMessageHandler.Publish] does, so publishers
+// only need the bus. The previous bus, if any, is detached, and a nil bus
+// only detaches it.
+func (h *DefaultMessageHandler) SetEventBus(bus EventBus) error {
+	if h == nil {
+		return core.ErrNilReceiver
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.bus != nil {
+		if err := h.bus.Unsubscribe(h.busID); err != nil {
+			return core.Wrap(err, "failed to detach event bus")
+		}
+		h.bus, h.busID = nil, 0
+	}
+
+	if core.IsNil(bus) {
+		return nil
+	}
+
+	id, err := bus.Subscribe("", h.publishEvent)
+	if err != nil {
+		return core.Wrap(err, "failed to attach event bus")
+	}
+	h.bus, h.busID = bus, id
+	return nil
+}
+
+// publishEvent is the [EventHandler] delivering the updates of the
+// [EventBus] to the subscriptions.
+func (h *DefaultMessageHandler) publishEvent(path string, data []byte) {
+	// failures to send are reported to the error handler
+	_ = h.Publish(path, data)
+}
+
+// WithEventBus makes the server's [DefaultMessageHandler] deliver the
+// updates published on bus, see [DefaultMessageHandler.SetEventBus].
+// Other message handlers are left untouched.
+func WithEventBus(bus EventBus) ServerOption {
+	return func(s *Server) {
+		if h, ok := s.messageHandler.(*DefaultMessageHandler); ok {
+			_ = h.SetEventBus(bus)
+		}
+	}
+}
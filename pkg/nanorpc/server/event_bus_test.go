@@ -0,0 +1,101 @@
+package server
+
+import (
+	"testing"
+
+	"darvaza.org/core"
+)
+
+// busRecorder records the updates received by an [EventBus] subscription.
+type busRecorder struct {
+	paths []string
+	data  []string
+}
+
+func (r *busRecorder) handle(path string, data []byte) {
+	r.paths = append(r.paths, path)
+	r.data = append(r.data, string(data))
+}
+
+func mustBusSubscribe(t *testing.T, b *MemoryBus, pattern string, r *busRecorder) uint64 {
+	t.Helper()
+
+	id, err := b.Subscribe(pattern, r.handle)
+	core.AssertMustNoError(t, err, "Subscribe %q", pattern)
+	return id
+}
+
+func TestMemoryBus(t *testing.T) {
+	b := NewMemoryBus()
+
+	var exact, pattern, all busRecorder
+	mustBusSubscribe(t, b, "/sensors/lab/temp", &exact)
+	id := mustBusSubscribe(t, b, "/sensors/+/temp", &pattern)
+	mustBusSubscribe(t, b, "", &all)
+
+	core.AssertNoError(t, b.Publish("/sensors/lab/temp", []byte("21")), "Publish")
+	core.AssertNoError(t, b.Publish("/sensors/hall/temp", []byte("19")), "Publish")
+	core.AssertNoError(t, b.Publish("/status", []byte("up")), "Publish")
+
+	core.AssertSliceEqual(t, []string{"21"}, exact.data, "exact")
+	core.AssertSliceEqual(t, []string{"/sensors/lab/temp", "/sensors/hall/temp"}, pattern.paths, "pattern")
+	core.AssertSliceEqual(t, []string{"21", "19", "up"}, all.data, "all")
+
+	core.AssertNoError(t, b.Unsubscribe(id), "Unsubscribe")
+	core.AssertNoError(t, b.Publish("/sensors/lab/temp", []byte("22")), "Publish")
+	core.AssertEqual(t, 2, len(pattern.data), "unsubscribed")
+	core.AssertSliceEqual(t, []string{"21", "22"}, exact.data, "exact after")
+
+	core.AssertErrorIs(t, b.Unsubscribe(id), ErrNoSubscription, "Unsubscribe unknown")
+}
+
+func TestMemoryBus_Subscribe_invalid(t *testing.T) {
+	b := NewMemoryBus()
+
+	_, err := b.Subscribe("/sensors/*/temp", func(string, []byte) {})
+	core.AssertErrorIs(t, err, ErrInvalidPattern, "inner wildcard")
+
+	_, err = b.Subscribe("/sensors", nil)
+	core.AssertErrorIs(t, err, ErrMissingHandler, "nil handler")
+}
+
+func TestMemoryBus_Snapshot(t *testing.T) {
+	b := NewMemoryBus()
+
+	var all busRecorder
+	mustBusSubscribe(t, b, "", &all)
+
+	core.AssertNoError(t, b.Publish("/a", []byte("1")), "Publish")
+	core.AssertNoError(t, b.Publish("/b", []byte("2")), "Publish")
+	core.AssertNoError(t, b.Publish("/a", nil), "Publish heartbeat")
+
+	snap := b.Snapshot()
+	core.AssertEqual(t, 2, len(snap), "paths")
+	core.AssertEqual(t, "1", string(snap["/a"]), "/a")
+	core.AssertEqual(t, "2", string(snap["/b"]), "/b")
+	core.AssertEqual(t, 3, len(all.data), "heartbeat delivered")
+
+	// copies, not the bus' own
+	snap["/a"][0] = 'x'
+	core.AssertEqual(t, "1", string(b.Snapshot()["/a"]), "copy")
+}
+
+func TestDefaultMessageHandler_SetEventBus(t *testing.T) {
+	h := NewDefaultMessageHandler(nil)
+	session := newPublishTestSession(t, h)
+
+	first, second := NewMemoryBus(), NewMemoryBus()
+	core.AssertMustNoError(t, h.SetEventBus(first), "SetEventBus")
+	core.AssertNoError(t, first.Publish(pathSensors, []byte("a")), "Publish")
+	core.AssertEqual(t, "a", string(session.GetLastResponse().Data), "delivered")
+
+	// replacing detaches the first bus
+	core.AssertMustNoError(t, h.SetEventBus(second), "SetEventBus replace")
+	core.AssertNoError(t, first.Publish(pathSensors, []byte("b")), "Publish detached")
+	core.AssertNoError(t, second.Publish(pathSensors, []byte("c")), "Publish")
+	core.AssertSliceEqual(t, []string{"a", "c"}, updateData(session, 0), "updates")
+
+	core.AssertMustNoError(t, h.SetEventBus(nil), "SetEventBus nil")
+	core.AssertNoError(t, second.Publish(pathSensors, []byte("d")), "Publish detached")
+	core.AssertEqual(t, 2, len(session.GetAllResponses()), "updates after detach")
+}
@@ -0,0 +1,205 @@
+package server
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"darvaza.org/core"
+	"darvaza.org/slog"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"protomcp.org/nanorpc/pkg/nanorpc/utils"
+)
+
+// FilterEvaluator decides whether an update published on a path is
+// delivered to a subscription, given the filter the subscription was made
+// with, the data of its TYPE_SUBSCRIBE request. Subscriptions without
+// filter receive every update. An error skips the subscription and is
+// reported to the error handler.
+type FilterEvaluator interface {
+	Match(filter, data []byte) (bool, error)
+}
+
+// FilterFunc adapts a function into a [FilterEvaluator].
+type FilterFunc func(filter, data []byte) (bool, error)
+
+// Match calls the function.
+func (fn FilterFunc) Match(filter, data []byte) (bool, error) {
+	return fn(filter, data)
+}
+
+var (
+	_ FilterEvaluator = FilterFunc(nil)
+	_ FilterEvaluator = JSONFieldFilter{}
+	_ FilterEvaluator = ProtoFieldFilter{}
+)
+
+// JSONFieldFilter matches JSON updates against JSON filters. The filter is
+// an object, and matches updates holding equal values in every field it
+// names; nested objects are matched the same way, so the filter
+// {"sensor":{"room":"lab"}} ignores the other fields of "sensor".
+type JSONFieldFilter struct{}
+
+// Match tells if the JSON data holds the fields of the JSON filter.
+func (JSONFieldFilter) Match(filter, data []byte) (bool, error) {
+	var want, got map[string]any
+	if err := json.Unmarshal(filter, &want); err != nil {
+		return false, core.QuietWrap(ErrInvalidFilter, "%v", err)
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		return false, core.Wrap(err, "update")
+	}
+	return matchJSONFields(want, got), nil
+}
+
+func matchJSONFields(want, got map[string]any) bool {
+	for k, w := range want {
+		g, ok := got[k]
+		if !ok || !matchJSONValue(w, g) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchJSONValue(want, got any) bool {
+	wm, ok := want.(map[string]any)
+	if !ok {
+		return reflect.DeepEqual(want, got)
+	}
+
+	gm, ok := got.(map[string]any)
+	return ok && matchJSONFields(wm, gm)
+}
+
+// ProtoFieldFilter matches protobuf updates against filters of the same
+// message type, created by New. The filter matches updates holding equal
+// values in every field it sets; nested messages are matched the same
+// way. As proto3 doesn't encode zero values, a filter can't require them.
+type ProtoFieldFilter struct {
+	New func() proto.Message
+}
+
+// Match tells if the data holds the fields set in the filter.
+func (f ProtoFieldFilter) Match(filter, data []byte) (bool, error) {
+	if f.New == nil {
+		return false, core.QuietWrap(ErrInvalidFilter, "message factory missing")
+	}
+
+	want, got := f.New(), f.New()
+	if err := proto.Unmarshal(filter, want); err != nil {
+		return false, core.QuietWrap(ErrInvalidFilter, "%v", err)
+	}
+	if err := proto.Unmarshal(data, got); err != nil {
+		return false, core.Wrap(err, "update")
+	}
+	return matchProtoFields(want.ProtoReflect(), got.ProtoReflect()), nil
+}
+
+func matchProtoFields(want, got protoreflect.Message) bool {
+	match := true
+	want.Range(func(fd protoreflect.FieldDescriptor, w protoreflect.Value) bool {
+		switch {
+		case !got.Has(fd):
+			match = false
+		case fd.Message() != nil && fd.Cardinality() != protoreflect.Repeated:
+			match = matchProtoFields(w.Message(), got.Get(fd).Message())
+		default:
+			match = w.Equal(got.Get(fd))
+		}
+		return match
+	})
+	return match
+}
+
+// SetFilterEvaluator sets how the filters of subscriptions are evaluated
+// on paths without an evaluator of their own, see
+// [DefaultMessageHandler.SetPathFilterEvaluator]. With none, the default,
+// filters are ignored and every subscriber receives every update.
+func (h *DefaultMessageHandler) SetFilterEvaluator(fe FilterEvaluator) error {
+	if h == nil {
+		return core.ErrNilReceiver
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.filter = nil
+	if !core.IsNil(fe) {
+		h.filter = fe
+	}
+	return nil
+}
+
+// SetPathFilterEvaluator sets how the filters of subscriptions to path
+// are evaluated. A nil evaluator falls back to the one set by
+// [DefaultMessageHandler.SetFilterEvaluator].
+func (h *DefaultMessageHandler) SetPathFilterEvaluator(path string, fe FilterEvaluator) error {
+	if h == nil {
+		return core.ErrNilReceiver
+	}
+
+	pathHash, err := h.hashCache.Hash(path)
+	if err != nil {
+		return core.Wrapf(err, "failed to hash path %q", path)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	switch {
+	case core.IsNil(fe):
+		delete(h.filters, pathHash)
+	case h.filters == nil:
+		h.filters = map[uint32]FilterEvaluator{pathHash: fe}
+	default:
+		h.filters[pathHash] = fe
+	}
+	return nil
+}
+
+// getFilterEvaluator returns the evaluator of the filters of a path
+// hash, if any.
+func (h *DefaultMessageHandler) getFilterEvaluator(pathHash uint32) FilterEvaluator {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if fe, ok := h.filters[pathHash]; ok {
+		return fe
+	}
+	return h.filter
+}
+
+// filterUpdates drops the updates whose subscription filter doesn't match
+// their data, reporting the filters that fail to evaluate.
+func (h *DefaultMessageHandler) filterUpdates(pathHash uint32, updates []pendingUpdate) []pendingUpdate {
+	fe := h.getFilterEvaluator(pathHash)
+	if fe == nil {
+		return updates
+	}
+
+	out := updates[:0]
+	for _, update := range updates {
+		if h.matchFilter(fe, pathHash, update) {
+			out = append(out, update)
+		}
+	}
+	return out
+}
+
+func (h *DefaultMessageHandler) matchFilter(fe FilterEvaluator, pathHash uint32, update pendingUpdate) bool {
+	if len(update.filter) == 0 {
+		return true
+	}
+
+	ok, err := fe.Match(update.filter, update.message.Data)
+	if err != nil {
+		fields := slog.Fields{
+			utils.FieldPathHash:     pathHash,
+			utils.FieldSessionShard: utils.LogSessionShard(update.session.ID()),
+		}
+		h.onError(err, update.session, fields, "failed to evaluate subscription filter")
+	}
+	return ok && err == nil
+}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"darvaza.org/core"
+	"darvaza.org/slog"
+	"google.golang.org/protobuf/proto"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+const pathSensors = "/sensors"
+
+var _ core.TestCase = filterTestCase{}
+
+type filterTestCase struct {
+	fe      FilterEvaluator
+	name    string
+	filter  []byte
+	data    []byte
+	want    bool
+	wantErr bool
+}
+
+func (tc filterTestCase) Name() string { return tc.name }
+
+func (tc filterTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	got, err := tc.fe.Match(tc.filter, tc.data)
+	if tc.wantErr {
+		core.AssertError(t, err, "Match")
+		core.AssertFalse(t, got, "match")
+		return
+	}
+	core.AssertNoError(t, err, "Match")
+	core.AssertEqual(t, tc.want, got, "match")
+}
+
+//revive:disable-next-line:argument-limit
+func newFilterTestCase(name string, fe FilterEvaluator, filter, data []byte,
+	want, wantErr bool) filterTestCase {
+	return filterTestCase{
+		fe:      fe,
+		name:    name,
+		filter:  filter,
+		data:    data,
+		want:    want,
+		wantErr: wantErr,
+	}
+}
+
+func mustMarshal(t *testing.T, msg proto.Message) []byte {
+	t.Helper()
+
+	data, err := proto.Marshal(msg)
+	core.AssertMustNoError(t, err, "marshal")
+	return data
+}
+
+func jsonFilterTestCases() []filterTestCase {
+	var fe JSONFieldFilter
+	data := []byte(`{"room":"lab","level":3,"sensor":{"id":7,"kind":"temp"}}`)
+	return []filterTestCase{
+		newFilterTestCase("json_field", fe, []byte(`{"room":"lab"}`), data, true, false),
+		newFilterTestCase("json_fields", fe, []byte(`{"room":"lab","level":3}`), data, true, false),
+		newFilterTestCase("json_nested", fe, []byte(`{"sensor":{"kind":"temp"}}`), data, true, false),
+		newFilterTestCase("json_empty_object", fe, []byte(`{}`), data, true, false),
+		newFilterTestCase("json_mismatch", fe, []byte(`{"room":"hall"}`), data, false, false),
+		newFilterTestCase("json_missing", fe, []byte(`{"floor":1}`), data, false, false),
+		newFilterTestCase("json_nested_mismatch", fe, []byte(`{"sensor":{"id":8}}`), data, false, false),
+		newFilterTestCase("json_invalid_filter", fe, []byte(`room=lab`), data, false, true),
+		newFilterTestCase("json_invalid_data", fe, []byte(`{"room":"lab"}`), []byte(`lab`), false, true),
+	}
+}
+
+func protoFilterTestCases(t *testing.T) []filterTestCase {
+	fe := ProtoFieldFilter{New: func() proto.Message { return new(nanorpc.NanoRPCRequest) }}
+	data := mustMarshal(t, &nanorpc.NanoRPCRequest{
+		RequestId:   7,
+		RequestType: nanorpc.NanoRPCRequest_TYPE_REQUEST,
+		PathOneof:   nanorpc.GetPathOneOfString(pathSensors),
+	})
+	filter := func(req *nanorpc.NanoRPCRequest) []byte { return mustMarshal(t, req) }
+
+	return []filterTestCase{
+		newFilterTestCase("proto_field", fe, filter(&nanorpc.NanoRPCRequest{RequestId: 7}), data, true, false),
+		newFilterTestCase("proto_oneof", fe, filter(&nanorpc.NanoRPCRequest{
+			PathOneof: nanorpc.GetPathOneOfString(pathSensors),
+		}), data, true, false),
+		newFilterTestCase("proto_mismatch", fe, filter(&nanorpc.NanoRPCRequest{RequestId: 8}), data, false, false),
+		newFilterTestCase("proto_missing", fe, filter(&nanorpc.NanoRPCRequest{
+			PathOneof: nanorpc.GetPathOneOfHash(1),
+		}), data, false, false),
+		newFilterTestCase("proto_invalid_filter", fe, []byte{0xff}, data, false, true),
+		newFilterTestCase("proto_no_factory", ProtoFieldFilter{}, data, data, false, true),
+	}
+}
+
+func TestJSONFieldFilter(t *testing.T) {
+	core.RunTestCases(t, jsonFilterTestCases())
+}
+
+func TestProtoFieldFilter(t *testing.T) {
+	core.RunTestCases(t, protoFilterTestCases(t))
+}
+
+// TestPublishByHash_filter verifies updates only reach the subscribers
+// whose filter matches them, and those without filter.
+func TestPublishByHash_filter(t *testing.T) {
+	h := NewDefaultMessageHandler(nil)
+	lab := newTestSession(sessionID1, 1001)
+	hall := newTestSession(sessionID2, 1002)
+	all := newTestSession(sessionID3, 1003)
+
+	ctx := context.Background()
+	for _, s := range []struct {
+		session *mockSession
+		filter  string
+	}{{lab, `{"room":"lab"}`}, {hall, `{"room":"hall"}`}, {all, ""}} {
+		core.AssertMustNoError(t, h.Subscribe(ctx, s.session,
+			newTestSubscribeRequest(1, pathSensors, []byte(s.filter))), "Subscribe")
+		s.session.ClearResponses()
+	}
+
+	// ignored without evaluator
+	core.AssertMustNoError(t, h.Publish(pathSensors, []byte(`{"room":"lab"}`)), "Publish")
+	core.AssertEqual(t, 1, len(hall.GetAllResponses()), "unfiltered")
+	hall.ClearResponses()
+
+	core.AssertMustNoError(t, h.SetPathFilterEvaluator(pathSensors, JSONFieldFilter{}), "SetPathFilterEvaluator")
+	core.AssertMustNoError(t, h.Publish(pathSensors, []byte(`{"room":"lab"}`)), "Publish")
+	core.AssertEqual(t, 2, len(lab.GetAllResponses()), "lab")
+	core.AssertEqual(t, 0, len(hall.GetAllResponses()), "hall")
+	core.AssertEqual(t, 2, len(all.GetAllResponses()), "no filter")
+
+	// unparsable updates reach only the subscribers without filter
+	var reported int
+	h.callOnError = func(error, Session, slog.Fields, string, ...any) { reported++ }
+	core.AssertMustNoError(t, h.Publish(pathSensors, []byte("raw")), "Publish raw")
+	core.AssertEqual(t, 2, reported, "reported")
+	core.AssertEqual(t, 0, len(hall.GetAllResponses()), "hall raw")
+	core.AssertEqual(t, 3, len(all.GetAllResponses()), "no filter raw")
+
+	// the path evaluator overrides the global one
+	core.AssertMustNoError(t, h.SetFilterEvaluator(FilterFunc(func(_, _ []byte) (bool, error) {
+		return false, nil
+	})), "SetFilterEvaluator")
+	core.AssertMustNoError(t, h.Publish(pathSensors, []byte(`{"room":"hall"}`)), "Publish")
+	core.AssertEqual(t, 1, len(hall.GetAllResponses()), "hall")
+
+	core.AssertMustNoError(t, h.SetPathFilterEvaluator(pathSensors, nil), "reset")
+	core.AssertMustNoError(t, h.Publish(pathSensors, []byte(`{"room":"hall"}`)), "Publish")
+	core.AssertEqual(t, 1, len(hall.GetAllResponses()), "global")
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"slices"
+
+	"darvaza.org/core"
+)
+
+// MaxForwardDepth limits how many times a single request can be forwarded
+// before [RequestContext.Forward] fails with [ErrForwardLoop].
+const MaxForwardDepth = 8
+
+// Forward re-dispatches the current request to the handler registered for
+// path, within the same session and without a network round trip. The
+// target handler answers the original request, so the caller must not
+// respond itself when Forward succeeds. A handler may adjust Request.Data
+// before forwarding, e.g. to fill in defaults when /v1 forwards to /v2.
+//
+// Forwarding is loop-protected: revisiting a path already in the chain, or
+// exceeding [MaxForwardDepth], fails with [ErrForwardLoop]. An unregistered
+// target fails with [core.ErrNotExists], and one the session may not use,
+// see [DefaultMessageHandler.SetAccess], with [ErrAccessDenied]. In all
+// cases nothing is sent and the caller decides how to respond.
+func (rc *RequestContext) Forward(path string) error {
+	if rc == nil {
+		return core.ErrNilReceiver
+	}
+	if rc.handler == nil {
+		return ErrForwardUnavailable
+	}
+
+	next, err := rc.newForward(path)
+	if err != nil {
+		return err
+	}
+
+	r, ok := rc.handler.getRoute(path, next.PathHash)
+	if !ok {
+		return core.Wrapf(core.ErrNotExists, "forward to %q", path)
+	}
+	if !rc.handler.allowed(rc.Session, r.hash) {
+		return core.QuietWrap(ErrAccessDenied, "forward to %q", path)
+	}
+
+	next.params = r.params
+	return r.handler.Handle(next.context(), next)
+}
+
+// ForwardChain returns the paths this request has been dispatched to, in
+// order, starting with the path it was originally addressed to.
+func (rc *RequestContext) ForwardChain() []string {
+	if rc == nil {
+		return nil
+	}
+	return slices.Clone(rc.forwardChain())
+}
+
+func (rc *RequestContext) forwardChain() []string {
+	if len(rc.forwarded) > 0 {
+		return rc.forwarded
+	}
+	return []string{rc.Path}
+}
+
+func (rc *RequestContext) newForward(path string) (*RequestContext, error) {
+	chain := rc.forwardChain()
+	switch {
+	case len(chain) > MaxForwardDepth:
+		return nil, core.QuietWrap(ErrForwardLoop, "depth %d exceeded", MaxForwardDepth)
+	case slices.Contains(chain, path):
+		return nil, core.QuietWrap(ErrForwardLoop, "path %q already visited", path)
+	}
+
+	pathHash, err := rc.handler.hashCache.Hash(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RequestContext{
+		Session:   rc.Session,
+		Request:   rc.Request,
+		ctx:       rc.ctx,
+		handler:   rc.handler,
+		reply:     rc.reply,
+		Path:      path,
+		forwarded: append(slices.Clip(chain), path),
+		metadata:  rc.metadata,
+		chunks:    rc.chunks,
+		PathHash:  pathHash,
+	}, nil
+}
+
+func (rc *RequestContext) context() context.Context {
+	if rc.ctx != nil {
+		return rc.ctx
+	}
+	return context.Background()
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+var _ core.TestCase = forwardTestCase{}
+
+// forwardTestCase registers a set of handlers that forward along a map of
+// path -> target, dispatches a request to start, and checks the outcome.
+type forwardTestCase struct {
+	forwards   map[string]string
+	wantErr    error
+	name       string
+	start      string
+	wantData   string
+	wantStatus nanorpc.NanoRPCResponse_Status
+}
+
+func (tc forwardTestCase) Name() string { return tc.name }
+
+func (tc forwardTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	handler := NewDefaultMessageHandler(nil)
+	for from, to := range tc.forwards {
+		core.AssertMustNoError(t, handler.RegisterHandlerFunc(from, newForwardingHandler(to)),
+			"register %s", from)
+	}
+	core.AssertMustNoError(t, handler.RegisterHandlerFunc(pathEcho, echoChainHandler), "register echo")
+
+	session := newTestSession("", 0)
+	req := newTestRequest(7, tc.start)
+	req.Data = []byte("in")
+
+	err := handler.HandleMessage(context.Background(), session, req)
+	if tc.wantErr != nil {
+		core.AssertErrorIs(t, err, tc.wantErr, "forward error")
+		core.AssertNil(t, session.GetLastResponse(), "no response on failure")
+		return
+	}
+
+	core.AssertMustNoError(t, err, "forward")
+	resp := session.GetLastResponse()
+	if !core.AssertNotNil(t, resp, "response") {
+		return
+	}
+	core.AssertEqual(t, int32(7), resp.RequestId, "request id")
+	core.AssertEqual(t, tc.wantStatus, resp.ResponseStatus, "status")
+	core.AssertEqual(t, tc.wantData, string(resp.Data), "data")
+}
+
+func newForwardTestCase(name, start string, forwards map[string]string,
+	wantData string, wantErr error) forwardTestCase {
+	return forwardTestCase{
+		forwards:   forwards,
+		wantErr:    wantErr,
+		name:       name,
+		start:      start,
+		wantData:   wantData,
+		wantStatus: nanorpc.NanoRPCResponse_STATUS_OK,
+	}
+}
+
+// newForwardingHandler returns a handler that appends a marker to the
+// request data and forwards to target.
+func newForwardingHandler(target string) RequestHandlerFunc {
+	return func(_ context.Context, rc *RequestContext) error {
+		rc.Request.Data = append(rc.Request.Data, '>')
+		return rc.Forward(target)
+	}
+}
+
+// echoChainHandler answers with the request data followed by the number of
+// paths the request visited.
+func echoChainHandler(_ context.Context, rc *RequestContext) error {
+	data := append(rc.GetData(), byte('0'+len(rc.ForwardChain())))
+	return rc.SendOK(data)
+}
+
+func forwardTestCases() []forwardTestCase {
+	return []forwardTestCase{
+		newForwardTestCase("direct", pathEcho, nil, "in1", nil),
+		newForwardTestCase("single_hop", "/v1/echo",
+			map[string]string{"/v1/echo": pathEcho}, "in>2", nil),
+		newForwardTestCase("two_hops", "/v0/echo",
+			map[string]string{"/v0/echo": "/v1/echo", "/v1/echo": pathEcho}, "in>>3", nil),
+		newForwardTestCase("self_loop", "/loop",
+			map[string]string{"/loop": "/loop"}, "", ErrForwardLoop),
+		newForwardTestCase("cycle", "/a",
+			map[string]string{"/a": "/b", "/b": "/a"}, "", ErrForwardLoop),
+		newForwardTestCase("unregistered_target", "/v1/missing",
+			map[string]string{"/v1/missing": pathUnregistered}, "", core.ErrNotExists),
+	}
+}
+
+// TestRequestContext_Forward exercises forwarding chains, loop protection
+// and unregistered targets.
+func TestRequestContext_Forward(t *testing.T) {
+	core.RunTestCases(t, forwardTestCases())
+}
+
+// TestRequestContext_ForwardDepth verifies the chain length limit applies
+// even when every hop targets a distinct path.
+func TestRequestContext_ForwardDepth(t *testing.T) {
+	handler := NewDefaultMessageHandler(nil)
+	for i := 0; i <= MaxForwardDepth+1; i++ {
+		path := "/hop/" + string(rune('a'+i))
+		next := "/hop/" + string(rune('a'+i+1))
+		core.AssertMustNoError(t, handler.RegisterHandlerFunc(path, newForwardingHandler(next)),
+			"register %s", path)
+	}
+
+	session := newTestSession("", 0)
+	err := handler.HandleMessage(context.Background(), session, newTestRequest(1, "/hop/a"))
+	core.AssertErrorIs(t, err, ErrForwardLoop, "depth limit")
+	core.AssertTrue(t, IsInvalid(err), "IsInvalid")
+}
+
+// TestRequestContext_ForwardUnavailable verifies contexts built outside a
+// DefaultMessageHandler cannot forward.
+func TestRequestContext_ForwardUnavailable(t *testing.T) {
+	var nilRC *RequestContext
+	core.AssertErrorIs(t, nilRC.Forward(pathEcho), core.ErrNilReceiver, "nil receiver")
+
+	rc := &RequestContext{Session: newTestSession("", 0), Request: newTestRequest(1, pathEcho)}
+	core.AssertErrorIs(t, rc.Forward(pathEcho), ErrForwardUnavailable, "no dispatcher")
+	core.AssertSliceEqual(t, []string{""}, rc.ForwardChain(), "chain")
+}
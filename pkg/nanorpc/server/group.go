@@ -0,0 +1,163 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+var (
+	_ HandlerRegistry     = (*Group)(nil)
+	_ MessageTypeRegistry = (*Group)(nil)
+)
+
+// Group registers handlers on a [DefaultMessageHandler] under a shared path
+// prefix, wrapped by interceptors of their own, to keep the handlers of
+// large servers organised, e.g.
+//
+//	v1 := handler.Group("/api/v1")
+//	_ = v1.Use(requireSession)
+//	_ = v1.HandleFunc("/status", statusHandler) // serves /api/v1/status
+//
+// Paths are registered, and hashed, with the prefix. Group interceptors
+// run inside those of [DefaultMessageHandler.Use], parents' before their
+// subgroups', and also for requests forwarded to the group's handlers.
+type Group struct {
+	h            *DefaultMessageHandler
+	parent       *Group
+	prefix       string
+	interceptors []Interceptor // outermost first
+	mu           sync.RWMutex
+}
+
+// Group returns a [Group] registering handlers under the given prefix.
+func (h *DefaultMessageHandler) Group(prefix string) *Group {
+	if h == nil {
+		return nil
+	}
+	return &Group{h: h, prefix: prefix}
+}
+
+// Group returns a subgroup whose prefix extends the group's, and whose
+// handlers are wrapped by the group's interceptors too.
+func (g *Group) Group(prefix string) *Group {
+	if g == nil {
+		return nil
+	}
+	return &Group{h: g.h, parent: g, prefix: joinPath(g.prefix, prefix)}
+}
+
+// Prefix returns the path prefix of the group's handlers.
+func (g *Group) Prefix() string {
+	if g == nil {
+		return ""
+	}
+	return g.prefix
+}
+
+// Path returns the path a handler registered by the group for path serves.
+func (g *Group) Path(path string) string {
+	if g == nil {
+		return path
+	}
+	return joinPath(g.prefix, path)
+}
+
+// Use appends interceptors to the chain wrapping the group's handlers,
+// including those already registered. The first one added is the
+// outermost.
+func (g *Group) Use(interceptors ...Interceptor) error {
+	if g == nil {
+		return core.ErrNilReceiver
+	}
+
+	for _, ic := range interceptors {
+		if core.IsNil(ic) {
+			return ErrMissingInterceptor
+		}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.interceptors = append(g.interceptors, interceptors...)
+	return nil
+}
+
+// Handle registers a handler for the path under the group's prefix, see
+// [DefaultMessageHandler.RegisterHandler]. If handler is nil, the path is
+// unregistered instead.
+func (g *Group) Handle(path string, handler RequestHandler, opts ...AccessOption) error {
+	if g == nil {
+		return core.ErrNilReceiver
+	}
+
+	if handler != nil {
+		handler = groupHandler{g: g, next: handler}
+	}
+	return g.h.RegisterHandler(g.Path(path), handler, opts...)
+}
+
+// HandleFunc registers a handler function for the path under the group's
+// prefix.
+func (g *Group) HandleFunc(path string, fn RequestHandlerFunc, opts ...AccessOption) error {
+	if fn == nil {
+		return g.Handle(path, nil, opts...)
+	}
+	return g.Handle(path, fn, opts...)
+}
+
+// RegisterHandlerFunc is [Group.HandleFunc], making the group a
+// [HandlerRegistry] generated services can be registered on.
+func (g *Group) RegisterHandlerFunc(path string, fn RequestHandlerFunc, opts ...AccessOption) error {
+	return g.HandleFunc(path, fn, opts...)
+}
+
+// SetMessageTypes records the messages the handler of the path under the
+// group's prefix takes and returns, see
+// [DefaultMessageHandler.SetMessageTypes].
+func (g *Group) SetMessageTypes(path string, request, response protoreflect.FullName) error {
+	if g == nil {
+		return core.ErrNilReceiver
+	}
+	return g.h.SetMessageTypes(g.Path(path), request, response)
+}
+
+// wrap wraps handler with the interceptors of the group and its parents.
+func (g *Group) wrap(handler RequestHandler) RequestHandler {
+	for cur := g; cur != nil; cur = cur.parent {
+		cur.mu.RLock()
+		interceptors := cur.interceptors
+		cur.mu.RUnlock()
+
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			handler = interceptedHandler{ic: interceptors[i], next: handler}
+		}
+	}
+	return handler
+}
+
+// groupHandler is a [RequestHandler] registered by a [Group], calling
+// the group's interceptors before the handler.
+type groupHandler struct {
+	g    *Group
+	next RequestHandler
+}
+
+func (gh groupHandler) Handle(ctx context.Context, rc *RequestContext) error {
+	return gh.g.wrap(gh.next).Handle(ctx, rc)
+}
+
+// joinPath appends path to prefix, with a single slash between them.
+func joinPath(prefix, path string) string {
+	switch {
+	case path == "":
+		return prefix
+	case prefix == "":
+		return path
+	}
+	return strings.TrimSuffix(prefix, "/") + "/" + strings.TrimPrefix(path, "/")
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+const pathGroupReset = "/api/v1/admin/reset"
+
+// newGroupHandler returns a handler with pathGroupReset registered by
+// nested groups, each interceptor recording itself in the trace.
+func newGroupHandler(t *testing.T, trace *[]string) (*DefaultMessageHandler, *Group) {
+	t.Helper()
+
+	h := NewDefaultMessageHandler(nil)
+	core.AssertMustNoError(t, h.Use(recordingInterceptor("global", trace)), "use global")
+
+	v1 := h.Group("/api/v1")
+	admin := v1.Group("admin/")
+	core.AssertMustNoError(t, admin.Use(recordingInterceptor("admin", trace)), "use admin")
+	core.AssertMustNoError(t, admin.HandleFunc("/reset", func(_ context.Context, rc *RequestContext) error {
+		*trace = append(*trace, rc.Path)
+		return rc.SendOK(nil)
+	}), "register reset")
+
+	// added after registering, still wrapping the handlers
+	core.AssertMustNoError(t, v1.Use(recordingInterceptor("v1", trace)), "use v1")
+	return h, admin
+}
+
+func TestGroup_Handle(t *testing.T) {
+	var trace []string
+	h, admin := newGroupHandler(t, &trace)
+	core.AssertEqual(t, "/api/v1/admin/", admin.Prefix(), "prefix")
+	core.AssertEqual(t, pathGroupReset, admin.Path("reset"), "path")
+
+	session := newTestSession("", 0)
+	err := h.HandleMessage(context.Background(), session, newTestRequest(1, pathGroupReset))
+	core.AssertMustNoError(t, err, "HandleMessage")
+
+	core.AssertSliceEqual(t, []string{
+		"global>", "v1>", "admin>", pathGroupReset, "<admin", "<v1", "<global",
+	}, trace, "trace")
+	if resp := session.GetLastResponse(); core.AssertNotNil(t, resp, "response") {
+		core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_OK, resp.ResponseStatus, "status")
+	}
+
+	// the full path is hashed on registration
+	trace = nil
+	pathHash, err := new(nanorpc.HashCache).Hash(pathGroupReset)
+	core.AssertMustNoError(t, err, "Hash")
+	err = h.HandleMessage(context.Background(), session, newTestRequest(2, pathHash))
+	core.AssertMustNoError(t, err, "HandleMessage by hash")
+	core.AssertEqual(t, 7, len(trace), "trace by hash")
+}
+
+func TestGroup_HandleFunc_unregister(t *testing.T) {
+	var trace []string
+	h, admin := newGroupHandler(t, &trace)
+
+	core.AssertMustNoError(t, admin.HandleFunc("/reset", nil), "unregister")
+	err := admin.HandleFunc("/reset", nil)
+	core.AssertErrorIs(t, err, core.ErrNotExists, "unregister again")
+
+	session := newTestSession("", 0)
+	err = h.HandleMessage(context.Background(), session, newTestRequest(1, pathGroupReset))
+	core.AssertMustNoError(t, err, "HandleMessage")
+	if resp := session.GetLastResponse(); core.AssertNotNil(t, resp, "response") {
+		core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_NOT_FOUND, resp.ResponseStatus, "status")
+	}
+	core.AssertEqual(t, 0, len(trace), "trace")
+}
+
+func TestGroup_Use_missingInterceptor(t *testing.T) {
+	g := NewDefaultMessageHandler(nil).Group("/api")
+
+	err := g.Use(nil)
+	core.AssertErrorIs(t, err, ErrMissingInterceptor, "Use")
+	core.AssertTrue(t, IsInvalid(err), "IsInvalid")
+}
+
+var _ core.TestCase = joinPathTestCase{}
+
+type joinPathTestCase struct {
+	name   string
+	prefix string
+	path   string
+	want   string
+}
+
+func (tc joinPathTestCase) Name() string { return tc.name }
+
+func (tc joinPathTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	core.AssertEqual(t, tc.want, joinPath(tc.prefix, tc.path), "joinPath")
+}
+
+func newJoinPathTestCase(name, prefix, path, want string) joinPathTestCase {
+	return joinPathTestCase{name: name, prefix: prefix, path: path, want: want}
+}
+
+func joinPathTestCases() []joinPathTestCase {
+	return []joinPathTestCase{
+		newJoinPathTestCase("plain", "/api", "/status", "/api/status"),
+		newJoinPathTestCase("trailing slash", "/api/", "/status", "/api/status"),
+		newJoinPathTestCase("relative", "/api", "status", "/api/status"),
+		newJoinPathTestCase("pattern", "/devices", "{id}/status", "/devices/{id}/status"),
+		newJoinPathTestCase("no path", "/api", "", "/api"),
+		newJoinPathTestCase("no prefix", "", "/status", "/status"),
+	}
+}
+
+func TestJoinPath(t *testing.T) {
+	core.RunTestCases(t, joinPathTestCases())
+}
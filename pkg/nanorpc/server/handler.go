@@ -2,12 +2,15 @@ package server
 
 import (
 	"context"
+	"errors"
 	"sync"
+	"time"
 
 	"darvaza.org/core"
 	"darvaza.org/slog"
 
 	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/server/metrics"
 )
 
 // SessionErrorHandler is a callback for handling errors in the message handler
@@ -15,21 +18,54 @@ type SessionErrorHandler func(err error, session Session, fields slog.Fields, fo
 
 // RequestContext provides request information and response utilities
 type RequestContext struct {
-	Session  Session
-	Request  *nanorpc.NanoRPCRequest
-	Path     string // Resolved path (from string or hash)
-	PathHash uint32 // The hash of the path (computed or provided)
+	Session   Session
+	Request   *nanorpc.NanoRPCRequest
+	ctx       context.Context
+	handler   *DefaultMessageHandler // dispatcher, used by Forward
+	reply     *replyState            // answered yet, see ErrAsync
+	params    map[string]string      // path parameters, see Param
+	Path      string                 // Resolved path (from string or hash)
+	forwarded []string               // forwarding chain, see ForwardChain
+	metadata  nanorpc.Metadata       // of the responses, see SetResponseMetadata
+	chunks    uint64                 // chunks streamed, see SendChunk
+	PathHash  uint32                 // The hash of the path (computed or provided)
 }
 
+var _ HandlerRegistry = (*DefaultMessageHandler)(nil)
+
 // DefaultMessageHandler implements MessageHandler interface with hash-based path resolution.
 // It maintains an internal HashCache to enable efficient hash-to-path mapping for
 // embedded clients that send hash-based requests instead of string paths.
-// It also manages subscriptions using intrusive lists for efficient removal.
+// It also manages subscriptions using intrusive lists for efficient removal,
+// sharded by path hash, see [SubscriptionMap].
 type DefaultMessageHandler struct {
 	handlers      map[string]RequestHandler
+	patterns      []*routePattern // registration order
 	hashCache     *nanorpc.HashCache
-	subscriptions SubscriptionMap // PathHash -> subscription list
+	subscriptions *SubscriptionMap                 // PathHash -> subscription list
+	subPatterns   map[uint32]*routePattern         // PathHash -> subscription pattern
+	replay        map[uint32]*replayBuffer         // PathHash -> recent updates
+	retained      map[uint32]*retainedValue        // PathHash -> last update
+	access        map[uint32]*accessRule           // PathHash -> access rules
+	filters       map[uint32]FilterEvaluator       // PathHash -> filter evaluator
+	identities    map[string]*Identity             // SessionID -> identity
+	hellos        map[string]*nanorpc.NanoRPCHello // SessionID -> handshake
+	types         map[string]messageTypes          // path -> message types
+	identityIndex IdentityIndex
 	callOnError   SessionErrorHandler
+	auth          Authenticator
+	filter        FilterEvaluator
+	metrics       metrics.Collector
+	bus           EventBus                 // see SetEventBus
+	store         SubscriptionStore        // see SetSubscriptionStore
+	interceptors  []Interceptor            // outermost first
+	node          string                   // see SetSubscriptionStore
+	pending       map[*replyState]struct{} // asynchronous requests
+	workers       *workerPool
+	asyncTimeout  time.Duration
+	ackTimeout    time.Duration
+	busID         uint64
+	ackStats      ackCounters
 	mu            sync.RWMutex
 }
 
@@ -42,15 +78,16 @@ func NewDefaultMessageHandler(hashCache *nanorpc.HashCache) *DefaultMessageHandl
 	return &DefaultMessageHandler{
 		handlers:      make(map[string]RequestHandler),
 		hashCache:     hashCache,
-		subscriptions: make(SubscriptionMap),
+		subscriptions: NewSubscriptionMap(),
 	}
 }
 
 // RegisterHandlerFunc registers a handler function for a specific path.
 // The path is automatically added to the internal hash cache for hash-based requests.
 // Hash collisions during registration are extremely unlikely but would cause registration to fail.
-func (h *DefaultMessageHandler) RegisterHandlerFunc(path string, fn RequestHandlerFunc) error {
-	return h.RegisterHandler(path, fn)
+// The path can be a pattern, see [DefaultMessageHandler.RegisterHandler].
+func (h *DefaultMessageHandler) RegisterHandlerFunc(path string, fn RequestHandlerFunc, opts ...AccessOption) error {
+	return h.RegisterHandler(path, fn, opts...)
 }
 
 // onError calls the error handler if it's set
@@ -64,7 +101,19 @@ func (h *DefaultMessageHandler) onError(err error, session Session, fields slog.
 // The path is automatically added to the internal hash cache for hash-based requests.
 // Hash collisions during registration are extremely unlikely but would cause registration to fail.
 // If handler is nil, the path is unregistered instead.
-func (h *DefaultMessageHandler) RegisterHandler(path string, handler RequestHandler) error {
+//
+// The path can be a pattern with {name} segments, matching any non-empty
+// segment, and ending in a * segment, matching one or more, e.g.
+// /devices/{id}/status or /files/*. Handlers read what they matched with
+// [RequestContext.Param]. Exact paths take precedence, then patterns in
+// the order they were registered. Malformed patterns fail with
+// [ErrInvalidPattern]. Hash-only requests to a path matched by a pattern
+// resolve once the HashCache knows the path, e.g. by
+// [nanorpc.HashCache.Hash].
+//
+// Options, e.g. [RequireRole], replace the access rules of the path as
+// [DefaultMessageHandler.SetAccess] does; without them the rules are kept.
+func (h *DefaultMessageHandler) RegisterHandler(path string, handler RequestHandler, opts ...AccessOption) error {
 	if h == nil {
 		return core.ErrNilReceiver
 	}
@@ -80,39 +129,82 @@ func (h *DefaultMessageHandler) RegisterHandler(path string, handler RequestHand
 		return h.doUnregister(path)
 	}
 
-	return h.doRegister(path, handler)
+	return h.doRegister(path, handler, opts)
 }
 
 func (h *DefaultMessageHandler) doUnregister(path string) error {
+	if isPathPattern(path) {
+		delete(h.types, path)
+		return h.unsafeUnregisterPattern(path)
+	}
 	if _, exists := h.handlers[path]; exists {
 		delete(h.handlers, path)
+		delete(h.types, path)
 		return nil
 	}
 	return core.ErrNotExists
 }
 
-func (h *DefaultMessageHandler) doRegister(path string, handler RequestHandler) error {
+func (h *DefaultMessageHandler) doRegister(path string, handler RequestHandler, opts []AccessOption) error {
+	var pathHash uint32
+	var err error
+
+	if isPathPattern(path) {
+		pathHash, err = h.unsafeRegisterPattern(path, handler)
+	} else {
+		pathHash, err = h.unsafeRegisterPath(path, handler)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(opts) > 0 {
+		h.unsafeSetAccess(pathHash, newAccessRule(opts))
+	}
+	return nil
+}
+
+func (h *DefaultMessageHandler) unsafeRegisterPath(path string, handler RequestHandler) (uint32, error) {
 	if _, exists := h.handlers[path]; exists {
-		return core.ErrExists
+		return 0, core.ErrExists
 	}
 
 	// Populate the hash cache with this path. This ensures that the hash
 	// is computed and cached for future hash-based requests. Hash collisions
 	// are extremely unlikely due to FNV-1a properties, but if they occur,
-	// the cache will maintain the first registered mapping.
-	if _, err := h.hashCache.Hash(path); err != nil {
-		return err
+	// the cache will maintain the first registered mapping. Pinned, so a
+	// size-limited cache never evicts it.
+	pathHash, err := h.hashCache.Pin(path)
+	if err != nil {
+		return 0, err
 	}
 
 	h.handlers[path] = handler
-	return nil
+	return pathHash, nil
 }
 
 // HandleMessage processes a decoded request.
 // For hash-based requests, it attempts to resolve the hash to a registered path.
 // If the hash is unknown (not in cache), the request returns STATUS_NOT_FOUND.
 // String-based requests are handled directly if a matching handler exists.
+// With workers, see [DefaultMessageHandler.SetWorkers], requests other than
+// pings are queued instead, and their handlers' errors reported to the
+// error handler.
 func (h *DefaultMessageHandler) HandleMessage(ctx context.Context, session Session, req *nanorpc.NanoRPCRequest) error {
+	if h == nil {
+		return core.ErrNilReceiver
+	}
+
+	if req.RequestType != nanorpc.NanoRPCRequest_TYPE_PING {
+		if p := h.getWorkers(); p != nil {
+			return p.enqueue(ctx, session, req)
+		}
+	}
+	return h.dispatch(ctx, session, req)
+}
+
+// dispatch processes a decoded request by type.
+func (h *DefaultMessageHandler) dispatch(ctx context.Context, session Session, req *nanorpc.NanoRPCRequest) error {
 	switch req.RequestType {
 	case nanorpc.NanoRPCRequest_TYPE_PING:
 		return h.handlePing(ctx, session, req)
@@ -120,6 +212,8 @@ func (h *DefaultMessageHandler) HandleMessage(ctx context.Context, session Sessi
 		return h.handleRequest(ctx, session, req)
 	case nanorpc.NanoRPCRequest_TYPE_SUBSCRIBE:
 		return h.Subscribe(ctx, session, req)
+	case nanorpc.NanoRPCRequest_TYPE_ACK:
+		return h.handleAck(ctx, session, req)
 	default:
 		// Ignore unsupported request types for now
 		return nil
@@ -138,14 +232,24 @@ func sendErrorResponse(session Session, req *nanorpc.NanoRPCRequest,
 	return session.SendResponse(req, response)
 }
 
-// handlePing processes ping requests and sends pong responses
-func (*DefaultMessageHandler) handlePing(_ context.Context, session Session, req *nanorpc.NanoRPCRequest) error {
+// handlePing processes ping requests and sends pong responses. Pings
+// carrying a handshake are answered with the handler's own, see
+// [nanorpc.NanoRPCHello].
+func (h *DefaultMessageHandler) handlePing(_ context.Context, session Session, req *nanorpc.NanoRPCRequest) error {
 	response := &nanorpc.NanoRPCResponse{
 		RequestId:      req.RequestId,
 		ResponseType:   nanorpc.NanoRPCResponse_TYPE_PONG,
 		ResponseStatus: nanorpc.NanoRPCResponse_STATUS_OK,
 	}
 
+	if hello, ok := nanorpc.RequestHello(req); ok {
+		data, err := h.handshake(session, hello)
+		if err != nil {
+			return err
+		}
+		response.Data = data
+	}
+
 	return session.SendResponse(req, response)
 }
 
@@ -204,25 +308,47 @@ func (h *DefaultMessageHandler) handleRequest(ctx context.Context, session Sessi
 	}
 
 	// Look up handler
-	h.mu.RLock()
-	handler, exists := h.handlers[path]
-	h.mu.RUnlock()
-
-	if !exists || handler == nil || path == "" {
-		// No handler registered or path couldn't be resolved
+	r, exists := h.getRoute(path, pathHash)
+	session = h.observeRequest(session, r.path, exists)
+	switch {
+	case !exists: // No handler registered or path couldn't be resolved
 		return sendErrorResponse(session, req,
 			nanorpc.NanoRPCResponse_STATUS_NOT_FOUND,
 			"no handler registered for path")
+	case !h.allowed(session, r.hash):
+		return sendErrorResponse(session, req,
+			nanorpc.NanoRPCResponse_STATUS_NOT_AUTHORIZED, "not authorized")
 	}
 
-	// Create request context
-	reqCtx := &RequestContext{
+	return h.callHandler(ctx, r, &RequestContext{
 		Session:  session,
 		Request:  req,
 		Path:     path,
 		PathHash: pathHash,
-	}
+		ctx:      ctx,
+		handler:  h,
+		reply:    &replyState{h: h},
+		params:   r.params,
+	})
+}
 
-	// Call the handler
-	return handler.Handle(ctx, reqCtx)
+// callHandler calls the handler of a route through the interceptors,
+// tracking the request if it's answered asynchronously, and answering it
+// with the [nanorpc.Error] the handler returned if it didn't.
+func (h *DefaultMessageHandler) callHandler(ctx context.Context, r route, rc *RequestContext) error {
+	err := h.intercept(r.handler).Handle(ctx, rc)
+
+	var e *nanorpc.Error
+	switch {
+	case errors.Is(err, ErrAsync):
+		if k, ok := rc.Session.(requestKeeper); ok {
+			k.keepRequest(rc.Request)
+		}
+		h.trackAsync(ctx, rc)
+		return nil
+	case errors.As(err, &e) && !rc.reply.answered():
+		return rc.SendErr(err)
+	default:
+		return err
+	}
 }
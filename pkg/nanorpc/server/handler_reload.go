@@ -0,0 +1,67 @@
+package server
+
+import "darvaza.org/core"
+
+// UnregisterHandler removes the handler of a path or pattern, failing with
+// [core.ErrNotExists] if none is registered. Requests already dispatched
+// finish on it, later ones get STATUS_NOT_FOUND. Subscriptions to the
+// path, its access rules and its hash are kept, so registering it again
+// resumes serving them.
+func (h *DefaultMessageHandler) UnregisterHandler(path string) error {
+	if h == nil {
+		return core.ErrNilReceiver
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.doUnregister(path)
+}
+
+// ReplaceHandler swaps the handler of a registered path or pattern,
+// failing with [core.ErrNotExists] if none is, or [ErrMissingHandler] if
+// handler is nil. The swap is atomic: requests already dispatched finish
+// on the old handler, later ones reach the new one, and none is answered
+// STATUS_NOT_FOUND in between. Subscriptions, access rules and the
+// precedence of a pattern are kept.
+func (h *DefaultMessageHandler) ReplaceHandler(path string, handler RequestHandler) error {
+	switch {
+	case h == nil:
+		return core.ErrNilReceiver
+	case handler == nil:
+		return ErrMissingHandler
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if isPathPattern(path) {
+		return h.unsafeReplacePattern(path, handler)
+	}
+	if _, exists := h.handlers[path]; !exists {
+		return core.ErrNotExists
+	}
+	h.handlers[path] = handler
+	return nil
+}
+
+// ReplaceHandlerFunc swaps the handler of a registered path or pattern
+// for a function, see [DefaultMessageHandler.ReplaceHandler].
+func (h *DefaultMessageHandler) ReplaceHandlerFunc(path string, fn RequestHandlerFunc) error {
+	if fn == nil {
+		// a nil func would be a non-nil RequestHandler
+		return h.ReplaceHandler(path, nil)
+	}
+	return h.ReplaceHandler(path, fn)
+}
+
+// unsafeReplacePattern swaps the handler of a path pattern, keeping its
+// position. h.mu must be held.
+func (h *DefaultMessageHandler) unsafeReplacePattern(path string, handler RequestHandler) error {
+	i := h.unsafePatternIndex(path)
+	if i < 0 {
+		return core.ErrNotExists
+	}
+	h.patterns[i].handler = handler
+	return nil
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// sendHandler returns a handler answering with the given data.
+func sendHandler(data string) RequestHandlerFunc {
+	return func(_ context.Context, rc *RequestContext) error {
+		return rc.SendOK([]byte(data))
+	}
+}
+
+// assertHandledBy verifies a request to path is answered with data.
+func assertHandledBy(t *testing.T, h *DefaultMessageHandler, path, data string) {
+	t.Helper()
+
+	session := newTestSession("", 0)
+	err := h.HandleMessage(context.Background(), session, newTestRequest(1, path))
+	core.AssertMustNoError(t, err, "HandleMessage %s", path)
+	if resp := session.GetLastResponse(); core.AssertNotNil(t, resp, "response %s", path) {
+		core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_OK, resp.ResponseStatus, "status %s", path)
+		core.AssertEqual(t, data, string(resp.Data), "data %s", path)
+	}
+}
+
+func TestDefaultMessageHandler_ReplaceHandler(t *testing.T) {
+	h := newRouterHandler(t)
+
+	core.AssertMustNoError(t, h.ReplaceHandlerFunc(pathEcho, sendHandler("new")), "replace")
+	assertHandledBy(t, h, pathEcho, "new")
+
+	core.AssertMustNoError(t, h.ReplaceHandlerFunc(pathDeviceStatus, sendHandler("status")), "replace pattern")
+	assertHandledBy(t, h, "/devices/42/status", "status")
+	assertHandledBy(t, h, "/devices/42/name", "42")
+
+	err := h.ReplaceHandlerFunc("/missing", sendHandler("new"))
+	core.AssertErrorIs(t, err, core.ErrNotExists, "missing")
+	err = h.ReplaceHandlerFunc("/missing/{id}", sendHandler("new"))
+	core.AssertErrorIs(t, err, core.ErrNotExists, "missing pattern")
+	core.AssertErrorIs(t, h.ReplaceHandlerFunc(pathEcho, nil), ErrMissingHandler, "nil func")
+	core.AssertErrorIs(t, h.ReplaceHandler(pathEcho, nil), ErrMissingHandler, "nil handler")
+}
+
+// TestDefaultMessageHandler_ReplaceHandler_inFlight verifies a request
+// dispatched before a replacement finishes on the old handler.
+func TestDefaultMessageHandler_ReplaceHandler_inFlight(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	h := NewDefaultMessageHandler(nil)
+	err := h.RegisterHandlerFunc(pathEcho, func(_ context.Context, rc *RequestContext) error {
+		close(started)
+		<-release
+		return rc.SendOK([]byte("old"))
+	})
+	core.AssertMustNoError(t, err, "register")
+
+	session := newTestSession("", 0)
+	done := make(chan error, 1)
+	go func() {
+		done <- h.HandleMessage(context.Background(), session, newTestRequest(1, pathEcho))
+	}()
+	<-started
+
+	core.AssertMustNoError(t, h.ReplaceHandlerFunc(pathEcho, sendHandler("new")), "replace")
+	assertHandledBy(t, h, pathEcho, "new")
+
+	close(release)
+	core.AssertMustNoError(t, <-done, "in-flight request")
+	if resp := session.GetLastResponse(); core.AssertNotNil(t, resp, "in-flight response") {
+		core.AssertEqual(t, "old", string(resp.Data), "in-flight data")
+	}
+}
+
+// TestDefaultMessageHandler_UnregisterHandler verifies unregistering
+// keeps the subscriptions of the path, and registering again serves it.
+func TestDefaultMessageHandler_UnregisterHandler(t *testing.T) {
+	h := NewDefaultMessageHandler(nil)
+	pathHash, err := h.hashCache.Hash(testSubscriptionPath)
+	core.AssertMustNoError(t, err, "Hash")
+	core.AssertMustNoError(t, h.RegisterHandlerFunc(testSubscriptionPath, sendHandler("old")), "register")
+
+	session := newTestSession("", 0)
+	err = h.HandleMessage(context.Background(), session, newTestSubscribeRequest(7, testSubscriptionPath, nil))
+	core.AssertMustNoError(t, err, "subscribe")
+
+	core.AssertMustNoError(t, h.UnregisterHandler(testSubscriptionPath), "unregister")
+	core.AssertErrorIs(t, h.UnregisterHandler(testSubscriptionPath), core.ErrNotExists, "unregister again")
+	core.AssertEqual(t, 1, countSubscriptions(t, h, pathHash), "subscriptions")
+
+	err = h.HandleMessage(context.Background(), session, newTestRequest(1, pathHash))
+	core.AssertMustNoError(t, err, "HandleMessage")
+	verifyResponse(t, session.GetLastResponse(), nanorpc.NanoRPCResponse_STATUS_NOT_FOUND, "")
+
+	core.AssertMustNoError(t, h.RegisterHandlerFunc(testSubscriptionPath, sendHandler("new")), "register again")
+	assertHandledBy(t, h, testSubscriptionPath, "new")
+	core.AssertEqual(t, 1, countSubscriptions(t, h, pathHash), "subscriptions kept")
+}
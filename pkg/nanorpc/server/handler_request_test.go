@@ -2,8 +2,11 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
+	"darvaza.org/core"
+
 	"protomcp.org/nanorpc/pkg/nanorpc"
 )
 
@@ -38,8 +41,8 @@ func (tc *handleRequestTestCase) test(t *testing.T) {
 
 	// Create mock session
 	session := &mockSession{
-		id:         "test-session",
-		remoteAddr: "127.0.0.1:12345",
+		SessionID: "test-session",
+		Remote:    "127.0.0.1:12345",
 	}
 
 	// Create request
@@ -64,12 +67,12 @@ func (tc *handleRequestTestCase) test(t *testing.T) {
 	}
 
 	// Check response (simplified based on PR feedback)
-	if session.lastResponse == nil {
+	if session.GetLastResponse() == nil {
 		t.Fatal("expected response but got none")
 	}
-	if session.lastResponse.ResponseStatus != tc.expectResponse {
+	if session.GetLastResponse().ResponseStatus != tc.expectResponse {
 		t.Fatalf("expected status %v, got %v",
-			tc.expectResponse, session.lastResponse.ResponseStatus)
+			tc.expectResponse, session.GetLastResponse().ResponseStatus)
 	}
 }
 
@@ -96,3 +99,32 @@ func TestDefaultMessageHandler_HandleRequest(t *testing.T) {
 		t.Run(tt.name, tt.test)
 	}
 }
+
+// TestDefaultMessageHandler_HandleRequest_error tests handlers failing with
+// a [nanorpc.Error] are answered with its status.
+func TestDefaultMessageHandler_HandleRequest_error(t *testing.T) {
+	handler := NewDefaultMessageHandler(nil)
+	core.AssertMustNoError(t, handler.RegisterHandlerFunc(pathEcho, func(context.Context, *RequestContext) error {
+		return fmt.Errorf("lookup: %w", nanorpc.NotFoundf("sensor %d", 7).WithDetail([]byte("7")))
+	}), "register")
+	core.AssertMustNoError(t, handler.RegisterHandlerFunc(pathUnregistered, func(_ context.Context,
+		rc *RequestContext) error {
+		_ = rc.SendOK(nil)
+		return nanorpc.InternalErrorf("after the response")
+	}), "register answered")
+
+	session := newTestSession("", 0)
+	err := handler.HandleMessage(context.Background(), session, newTestRequest(1, pathEcho))
+	core.AssertNoError(t, err, "answered")
+
+	res := session.GetLastResponse()
+	core.AssertMustNotNil(t, res, "response")
+	core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_NOT_FOUND, res.ResponseStatus, "status")
+	core.AssertEqual(t, "sensor 7", res.ResponseMessage, "message")
+	core.AssertEqual(t, "7", string(res.Data), "detail")
+
+	// already answered, the error is returned instead
+	err = handler.HandleMessage(context.Background(), session, newTestRequest(2, pathUnregistered))
+	core.AssertErrorIs(t, err, nanorpc.ErrInternalServerError, "returned")
+	core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_OK, session.GetLastResponse().ResponseStatus, "status")
+}
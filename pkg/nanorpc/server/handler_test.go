@@ -117,7 +117,7 @@ func (tc *hashCacheTestCase) test(t *testing.T) {
 	if !tc.expectFound {
 		expectedStatus = nanorpc.NanoRPCResponse_STATUS_NOT_FOUND
 	}
-	verifyResponse(t, session.lastResponse, expectedStatus, "")
+	verifyResponse(t, session.GetLastResponse(), expectedStatus, "")
 }
 
 func TestDefaultMessageHandler_HashCache(t *testing.T) {
@@ -167,8 +167,8 @@ func (tc *requestContextPathHashTestCase) test(t *testing.T) {
 	*tc.capturedCtx = nil
 
 	session := &mockSession{
-		id:         "test-session",
-		remoteAddr: "127.0.0.1:12345",
+		SessionID: "test-session",
+		Remote:    "127.0.0.1:12345",
 	}
 
 	req := &nanorpc.NanoRPCRequest{
@@ -282,9 +282,9 @@ func (tc *pathResolutionTestCase) test(t *testing.T) {
 
 	// Verify
 	if tc.expectFound {
-		verifyResponse(t, session.lastResponse, nanorpc.NanoRPCResponse_STATUS_OK, tc.expectPath)
+		verifyResponse(t, session.GetLastResponse(), nanorpc.NanoRPCResponse_STATUS_OK, tc.expectPath)
 	} else {
-		verifyResponse(t, session.lastResponse, nanorpc.NanoRPCResponse_STATUS_NOT_FOUND, "")
+		verifyResponse(t, session.GetLastResponse(), nanorpc.NanoRPCResponse_STATUS_NOT_FOUND, "")
 	}
 }
 
@@ -351,7 +351,7 @@ func TestDefaultMessageHandler_HashCollision(t *testing.T) {
 	err = handler.HandleMessage(context.Background(), session, req)
 	core.AssertNoError(t, err, "request")
 
-	verifyResponse(t, session.lastResponse, nanorpc.NanoRPCResponse_STATUS_OK, "path1")
+	verifyResponse(t, session.GetLastResponse(), nanorpc.NanoRPCResponse_STATUS_OK, "path1")
 }
 
 // Test factories for unsubscribe protocol
@@ -369,10 +369,8 @@ func newUnsubscribeRequest(requestID int32) *nanorpc.NanoRPCRequest {
 
 func countSubscriptions(t core.T, handler *DefaultMessageHandler, pathHash uint32) int {
 	t.Helper()
-	handler.mu.RLock()
-	defer handler.mu.RUnlock()
 
-	subs := handler.subscriptions[pathHash]
+	subs := handler.subscriptions.GetSubscribers(pathHash)
 	if subs == nil {
 		return 0
 	}
@@ -401,7 +399,7 @@ func testUnsubscribeRemovesSubscription(t *testing.T) {
 	// Verify removed
 	core.AssertEqual(t, 0, countSubscriptions(t, handler, pathHash),
 		"subscriptions")
-	verifyResponse(t, session.lastResponse, nanorpc.NanoRPCResponse_STATUS_OK, "")
+	verifyResponse(t, session.GetLastResponse(), nanorpc.NanoRPCResponse_STATUS_OK, "")
 }
 
 // testUnsubscribeWithoutSubscription verifies behaviour when no subscription exists
@@ -415,7 +413,7 @@ func testUnsubscribeWithoutSubscription(t *testing.T) {
 	core.AssertNoError(t, err, "unsubscribe")
 
 	// Should get NOT_FOUND since no handler registered
-	verifyResponse(t, session.lastResponse, nanorpc.NanoRPCResponse_STATUS_NOT_FOUND, "")
+	verifyResponse(t, session.GetLastResponse(), nanorpc.NanoRPCResponse_STATUS_NOT_FOUND, "")
 }
 
 // testUnsubscribeIsSessionSpecific verifies only the session's subscriptions are removed
@@ -488,7 +486,7 @@ func testUnsubscribeWithHashBasedRequest(t *testing.T) {
 
 	// Verify subscription removed
 	core.AssertEqual(t, 0, countSubscriptions(t, handler, pathHash), "subscriptions")
-	verifyResponse(t, session.lastResponse, nanorpc.NanoRPCResponse_STATUS_OK, "")
+	verifyResponse(t, session.GetLastResponse(), nanorpc.NanoRPCResponse_STATUS_OK, "")
 }
 
 // testUnsubscribeNonExistentSubscription tests unsubscribe when no subscription exists
@@ -511,7 +509,7 @@ func testUnsubscribeNonExistentSubscription(t *testing.T) {
 
 	// Should succeed with OK status (normal request handling)
 	core.AssertEqual(t, 0, countSubscriptions(t, handler, pathHash), "subscriptions")
-	verifyResponse(t, session.lastResponse, nanorpc.NanoRPCResponse_STATUS_OK, "")
+	verifyResponse(t, session.GetLastResponse(), nanorpc.NanoRPCResponse_STATUS_OK, "")
 }
 
 // testUnsubscribeWithMismatchedRequestID tests unsubscribe with wrong request ID
@@ -534,7 +532,7 @@ func testUnsubscribeWithMismatchedRequestID(t *testing.T) {
 
 	// Original subscription should remain (request ID mismatch)
 	core.AssertEqual(t, 1, countSubscriptions(t, handler, pathHash), "subscriptions")
-	verifyResponse(t, session.lastResponse, nanorpc.NanoRPCResponse_STATUS_NOT_FOUND, "")
+	verifyResponse(t, session.GetLastResponse(), nanorpc.NanoRPCResponse_STATUS_NOT_FOUND, "")
 }
 
 // testUnsubscribeWithZeroPathHash tests unsubscribe with zero path hash
@@ -554,7 +552,7 @@ func testUnsubscribeWithZeroPathHash(t *testing.T) {
 
 	// Should return NOT_FOUND (no handler for empty path)
 	core.AssertEqual(t, 0, countSubscriptions(t, handler, 0), "subscriptions")
-	verifyResponse(t, session.lastResponse, nanorpc.NanoRPCResponse_STATUS_NOT_FOUND, "")
+	verifyResponse(t, session.GetLastResponse(), nanorpc.NanoRPCResponse_STATUS_NOT_FOUND, "")
 }
 
 // TestUnsubscribeConcurrency tests concurrent unsubscribe operations
@@ -701,3 +699,50 @@ func testUnsubscribeByRequestIDNonExistentHash(t *testing.T, handler *DefaultMes
 	removed := handler.unsubscribeByRequestID("session", 123, 999999)
 	core.AssertEqual(t, false, removed, "removed")
 }
+
+// BenchmarkDefaultMessageHandler_HandleMessage dispatches requests by
+// string path, by path hash, to unknown paths, and pings, answering
+// through a session discarding what it writes.
+func BenchmarkDefaultMessageHandler_HandleMessage(b *testing.B) {
+	ctx := context.Background()
+	h := NewDefaultMessageHandler(nil)
+	if err := h.RegisterHandlerFunc("/bench", func(_ context.Context, rc *RequestContext) error {
+		return rc.SendOK(rc.Request.Data)
+	}); err != nil {
+		b.Fatal(err)
+	}
+	hash, err := h.hashCache.Hash("/bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	data := make([]byte, 64)
+	for _, bc := range []struct {
+		req  *nanorpc.NanoRPCRequest
+		name string
+	}{
+		{name: "path", req: newBenchRequest(nanorpc.GetPathOneOfString("/bench"), data)},
+		{name: "hash", req: newBenchRequest(nanorpc.GetPathOneOfHash(hash), data)},
+		{name: "not-found", req: newBenchRequest(nanorpc.GetPathOneOfString("/missing"), data)},
+		{name: "ping", req: &nanorpc.NanoRPCRequest{RequestId: 1, RequestType: nanorpc.NanoRPCRequest_TYPE_PING}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			s := NewDefaultSession(&discardConn{mockConn{remoteAddr: "127.0.0.1:12345"}}, h, nil)
+			b.ReportAllocs()
+			for b.Loop() {
+				if err := h.HandleMessage(ctx, s, bc.req); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func newBenchRequest(path nanorpc.PathOneOf, data []byte) *nanorpc.NanoRPCRequest {
+	return &nanorpc.NanoRPCRequest{
+		RequestId:   1,
+		RequestType: nanorpc.NanoRPCRequest_TYPE_REQUEST,
+		PathOneof:   path,
+		Data:        data,
+	}
+}
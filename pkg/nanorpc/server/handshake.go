@@ -0,0 +1,81 @@
+package server
+
+import (
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// Features returns the optional protocol features the handler announces
+// to clients that handshake: streaming, compression, extended statuses,
+// and authentication once an [Authenticator] is set.
+func (h *DefaultMessageHandler) Features() nanorpc.Features {
+	if h == nil {
+		return 0
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.unsafeFeatures()
+}
+
+func (h *DefaultMessageHandler) unsafeFeatures() nanorpc.Features {
+	features := nanorpc.FeatureStreaming | nanorpc.FeatureCompression | nanorpc.FeatureExtendedStatus
+	if !core.IsNil(h.auth) {
+		features |= nanorpc.FeatureAuth
+	}
+	return features
+}
+
+// featureNegotiator is a [MessageHandler] keeping the features negotiated
+// with every session, as [DefaultMessageHandler] does.
+type featureNegotiator interface {
+	SessionFeatures(sessionID string) nanorpc.Features
+}
+
+// SessionHello returns the handshake payload a session sent, or nil if it
+// didn't handshake, as clients predating it don't. Handshakes are
+// forgotten when their session is removed.
+func (h *DefaultMessageHandler) SessionHello(sessionID string) *nanorpc.NanoRPCHello {
+	if h == nil {
+		return nil
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.hellos[sessionID]
+}
+
+// SessionFeatures returns the features both the handler and a session
+// support, none if the session didn't handshake.
+func (h *DefaultMessageHandler) SessionFeatures(sessionID string) nanorpc.Features {
+	if h == nil {
+		return 0
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	local := nanorpc.NewHello(h.unsafeFeatures())
+	_, features := nanorpc.Negotiate(local, h.hellos[sessionID])
+	return features
+}
+
+// handshake records the handshake payload sent by a session, and returns
+// the one answering it.
+func (h *DefaultMessageHandler) handshake(session Session, hello *nanorpc.NanoRPCHello) ([]byte, error) {
+	h.mu.Lock()
+	if session != nil {
+		if h.hellos == nil {
+			h.hellos = make(map[string]*nanorpc.NanoRPCHello)
+		}
+		h.hellos[session.ID()] = hello
+	}
+	local := nanorpc.NewHello(h.unsafeFeatures())
+	h.mu.Unlock()
+
+	return proto.Marshal(local)
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+var _ core.TestCase = handshakeTestCase{}
+
+type handshakeTestCase struct {
+	hello    *nanorpc.NanoRPCHello
+	name     string
+	features nanorpc.Features
+	auth     bool
+}
+
+func (tc handshakeTestCase) Name() string { return tc.name }
+
+func (tc handshakeTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	h := NewDefaultMessageHandler(nil)
+	if tc.auth {
+		core.AssertMustNoError(t, h.SetAuthenticator(AuthenticatorFunc(allowAll)), "SetAuthenticator")
+	}
+	session := newTestSession("", 0)
+
+	req := &nanorpc.NanoRPCRequest{RequestId: 1, RequestType: nanorpc.NanoRPCRequest_TYPE_PING}
+	if tc.hello != nil {
+		data, err := proto.Marshal(tc.hello)
+		core.AssertMustNoError(t, err, "Marshal")
+		req.Data = data
+	}
+	core.AssertMustNoError(t, h.HandleMessage(context.Background(), session, req), "HandleMessage")
+
+	res := session.GetLastResponse()
+	core.AssertMustNotNil(t, res, "pong")
+	core.AssertEqual(t, nanorpc.NanoRPCResponse_TYPE_PONG, res.ResponseType, "response type")
+
+	hello, ok := nanorpc.ResponseHello(res)
+	core.AssertEqual(t, tc.hello != nil, ok, "handshake answered")
+	if ok {
+		core.AssertEqual(t, uint32(h.Features()), hello.Features, "announced features")
+	}
+	core.AssertEqual(t, tc.features, h.SessionFeatures(session.ID()), "session features")
+}
+
+func newHandshakeTestCase(name string, hello *nanorpc.NanoRPCHello, auth bool,
+	features nanorpc.Features) handshakeTestCase {
+	return handshakeTestCase{
+		name:     name,
+		hello:    hello,
+		auth:     auth,
+		features: features,
+	}
+}
+
+func handshakeTestCases() []handshakeTestCase {
+	all := nanorpc.NewHello(nanorpc.FeatureStreaming | nanorpc.FeatureCompression | nanorpc.FeatureAuth)
+
+	return []handshakeTestCase{
+		newHandshakeTestCase("legacy", nil, false, 0),
+		newHandshakeTestCase("streaming", nanorpc.NewHello(nanorpc.FeatureStreaming), false,
+			nanorpc.FeatureStreaming),
+		newHandshakeTestCase("no auth", all, false, nanorpc.FeatureStreaming|nanorpc.FeatureCompression),
+		newHandshakeTestCase("auth", all, true, nanorpc.FeatureStreaming|nanorpc.FeatureCompression|
+			nanorpc.FeatureAuth),
+		newHandshakeTestCase("client without features", nanorpc.NewHello(0), true, 0),
+	}
+}
+
+func TestDefaultMessageHandler_Handshake(t *testing.T) {
+	core.RunTestCases(t, handshakeTestCases())
+}
+
+func TestDefaultMessageHandler_SessionHello(t *testing.T) {
+	h := NewDefaultMessageHandler(nil)
+	session := newTestSession("", 0)
+
+	data, err := proto.Marshal(nanorpc.NewHello(nanorpc.FeatureStreaming))
+	core.AssertMustNoError(t, err, "Marshal")
+	req := &nanorpc.NanoRPCRequest{RequestId: 1, RequestType: nanorpc.NanoRPCRequest_TYPE_PING, Data: data}
+	core.AssertMustNoError(t, h.HandleMessage(context.Background(), session, req), "HandleMessage")
+
+	hello := h.SessionHello(session.ID())
+	if core.AssertNotNil(t, hello, "recorded") {
+		core.AssertEqual(t, uint32(nanorpc.ProtocolVersion), hello.Version, "version")
+	}
+
+	h.RemoveSubscriptionsForSession(session.ID())
+	core.AssertNil(t, h.SessionHello(session.ID()), "forgotten")
+}
+
+func allowAll(context.Context, Session, *nanorpc.NanoRPCRequest) error {
+	return nil
+}
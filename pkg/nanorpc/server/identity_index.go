@@ -0,0 +1,224 @@
+package server
+
+import (
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc/utils"
+)
+
+// IdentityConflict decides what happens when a session authenticates as
+// an identity already bound to another session, e.g. a device
+// reconnecting before its old connection timed out.
+type IdentityConflict int
+
+const (
+	// IdentityCloseOld binds the identity to the new session and closes
+	// the old one. This is the default.
+	IdentityCloseOld IdentityConflict = iota
+
+	// IdentityRejectNew keeps the identity bound to the old session, and
+	// fails binding the new one with [ErrIdentityInUse].
+	IdentityRejectNew
+
+	// IdentityReplace binds the identity to the new session, leaving the
+	// old one open but no longer reachable by identity.
+	IdentityReplace
+)
+
+// IdentityIndex finds sessions by the identity they authenticated as, for
+// [Server.SendTo]. [DefaultSessionManager] implements it, and is fed by
+// [DefaultMessageHandler.SetSessionIdentity] when both serve the same
+// [Server].
+type IdentityIndex interface {
+	// BindIdentity binds an identity to a session.
+	BindIdentity(sessionID, identity string) error
+	// UnbindIdentity forgets the identity bound to a session.
+	UnbindIdentity(sessionID string)
+	// SessionByIdentity returns the session bound to an identity, or nil.
+	SessionByIdentity(identity string) Session
+}
+
+var _ IdentityIndex = (*DefaultSessionManager)(nil)
+
+// SetIdentityConflict sets how [DefaultSessionManager.BindIdentity]
+// resolves an identity already bound to another session.
+func (sm *DefaultSessionManager) SetIdentityConflict(policy IdentityConflict) error {
+	if sm == nil {
+		return core.ErrNilReceiver
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	sm.conflict = policy
+	return nil
+}
+
+// BindIdentity binds an identity to an open session, replacing any other
+// identity of the session, and resolving conflicts as set by
+// [DefaultSessionManager.SetIdentityConflict].
+func (sm *DefaultSessionManager) BindIdentity(sessionID, identity string) error {
+	if sm == nil {
+		return core.ErrNilReceiver
+	}
+
+	old, err := sm.bindIdentity(sessionID, identity)
+	if err != nil || old == nil {
+		return err
+	}
+
+	if err := old.Close(); err != nil {
+		if l, ok := sm.WithError(err); ok {
+			l = utils.WithSessionID(l, old.ID())
+			l.Print("Failed to close session replaced by identity")
+		}
+	}
+	return nil
+}
+
+// bindIdentity updates the index, returning the old session to close.
+func (sm *DefaultSessionManager) bindIdentity(sessionID, identity string) (Session, error) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if _, ok := sm.sessions[sessionID]; !ok {
+		return nil, core.Wrapf(core.ErrNotExists, "session %q", sessionID)
+	}
+
+	oldID, taken := sm.byIdentity[identity]
+	conflict := taken && oldID != sessionID
+	if conflict && sm.conflict == IdentityRejectNew {
+		return nil, core.QuietWrap(ErrIdentityInUse, "identity %q", identity)
+	}
+
+	sm.unsafeUnbindIdentity(oldID)
+	sm.unsafeUnbindIdentity(sessionID)
+	sm.unsafeSetIdentity(sessionID, identity)
+
+	if conflict && sm.conflict == IdentityCloseOld {
+		return sm.sessions[oldID], nil
+	}
+	return nil, nil
+}
+
+func (sm *DefaultSessionManager) unsafeSetIdentity(sessionID, identity string) {
+	if sm.byIdentity == nil {
+		sm.byIdentity = make(map[string]string)
+		sm.identityOf = make(map[string]string)
+	}
+	sm.byIdentity[identity] = sessionID
+	sm.identityOf[sessionID] = identity
+}
+
+// UnbindIdentity forgets the identity bound to a session.
+func (sm *DefaultSessionManager) UnbindIdentity(sessionID string) {
+	if sm == nil {
+		return
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	sm.unsafeUnbindIdentity(sessionID)
+}
+
+func (sm *DefaultSessionManager) unsafeUnbindIdentity(sessionID string) {
+	if identity, ok := sm.identityOf[sessionID]; ok {
+		delete(sm.identityOf, sessionID)
+		delete(sm.byIdentity, identity)
+	}
+}
+
+// SessionByIdentity returns the session bound to an identity, or nil.
+func (sm *DefaultSessionManager) SessionByIdentity(identity string) Session {
+	if sm == nil {
+		return nil
+	}
+
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	if sessionID, ok := sm.byIdentity[identity]; ok {
+		return sm.sessions[sessionID]
+	}
+	return nil
+}
+
+// SetIdentityIndex sets the [IdentityIndex] fed with the name of the
+// identities recorded by [DefaultMessageHandler.SetSessionIdentity]. A nil
+// index stops feeding it.
+func (h *DefaultMessageHandler) SetIdentityIndex(idx IdentityIndex) error {
+	if h == nil {
+		return core.ErrNilReceiver
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.identityIndex = nil
+	if !core.IsNil(idx) {
+		h.identityIndex = idx
+	}
+	return nil
+}
+
+// indexIdentity feeds the [IdentityIndex], if any, with the identity of a
+// session.
+func (h *DefaultMessageHandler) indexIdentity(sessionID string, id *Identity) error {
+	h.mu.RLock()
+	idx := h.identityIndex
+	h.mu.RUnlock()
+
+	switch {
+	case idx == nil:
+		return nil
+	case id == nil, id.Name == "":
+		idx.UnbindIdentity(sessionID)
+		return nil
+	default:
+		return idx.BindIdentity(sessionID, id.Name)
+	}
+}
+
+// targetedPublisher is a [MessageHandler] publishing to a single session,
+// like [DefaultMessageHandler.PublishTo].
+type targetedPublisher interface {
+	PublishTo(sessionID, path string, data []byte) error
+}
+
+// SendTo sends a one-off TYPE_UPDATE with data to the subscriptions to
+// path of the session bound to an identity, see [IdentityIndex] and
+// [DefaultMessageHandler.PublishTo]. Fails with [ErrUnknownIdentity] if
+// no session is bound to it.
+func (s *Server) SendTo(identity, path string, data []byte) error {
+	if s == nil {
+		return core.ErrNilReceiver
+	}
+
+	idx, ok := s.sessionManager.(IdentityIndex)
+	if !ok {
+		return ErrSendToUnsupported
+	}
+	mh, ok := s.messageHandler.(targetedPublisher)
+	if !ok {
+		return ErrSendToUnsupported
+	}
+
+	session := idx.SessionByIdentity(identity)
+	if session == nil {
+		return core.QuietWrap(ErrUnknownIdentity, "identity %q", identity)
+	}
+	return mh.PublishTo(session.ID(), path, data)
+}
+
+// linkIdentityIndex feeds the session manager's [IdentityIndex] with the
+// identities recorded by a [DefaultMessageHandler].
+func (s *Server) linkIdentityIndex() {
+	h, ok := s.messageHandler.(*DefaultMessageHandler)
+	if !ok {
+		return
+	}
+	if idx, ok := s.sessionManager.(IdentityIndex); ok {
+		_ = h.SetIdentityIndex(idx)
+	}
+}
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+const deviceID = "device-42"
+
+// decodeWritten decodes every response written to a mock connection.
+func decodeWritten(t *testing.T, conn *mockConn) []*nanorpc.NanoRPCResponse {
+	t.Helper()
+
+	var out []*nanorpc.NanoRPCResponse
+	for data := conn.writeData; len(data) > 0; {
+		resp, n, err := nanorpc.DecodeResponse(data)
+		core.AssertMustNoError(t, err, "decode")
+		out = append(out, resp)
+		data = data[n:]
+	}
+	return out
+}
+
+var _ core.TestCase = identityConflictTestCase{}
+
+// identityConflictTestCase binds an identity to a session and then to
+// another, checking how the policy resolves it.
+type identityConflictTestCase struct {
+	wantErr   error
+	name      string
+	policy    IdentityConflict
+	wantNew   bool
+	oldClosed bool
+}
+
+func (tc identityConflictTestCase) Name() string { return tc.name }
+
+func (tc identityConflictTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	sm := NewDefaultSessionManager(NewDefaultMessageHandler(nil), nil)
+	core.AssertMustNoError(t, sm.SetIdentityConflict(tc.policy), "SetIdentityConflict")
+
+	oldConn := &mockConn{remoteAddr: "127.0.0.1:12345"}
+	oldSession := sm.AddSession(oldConn)
+	newSession := sm.AddSession(&mockConn{remoteAddr: "127.0.0.1:12346"})
+
+	core.AssertMustNoError(t, sm.BindIdentity(oldSession.ID(), deviceID), "bind old")
+	core.AssertMustNoError(t, sm.BindIdentity(oldSession.ID(), deviceID), "bind old again")
+
+	err := sm.BindIdentity(newSession.ID(), deviceID)
+	if tc.wantErr != nil {
+		core.AssertErrorIs(t, err, tc.wantErr, "bind new")
+	} else {
+		core.AssertNoError(t, err, "bind new")
+	}
+
+	want := oldSession
+	if tc.wantNew {
+		want = newSession
+	}
+	core.AssertSame(t, want, sm.SessionByIdentity(deviceID), "bound session")
+	core.AssertEqual(t, tc.oldClosed, oldConn.closed, "old session closed")
+}
+
+func newIdentityConflictTestCase(name string, policy IdentityConflict, wantErr error,
+	wantNew, oldClosed bool) identityConflictTestCase {
+	return identityConflictTestCase{
+		name:      name,
+		policy:    policy,
+		wantErr:   wantErr,
+		wantNew:   wantNew,
+		oldClosed: oldClosed,
+	}
+}
+
+func identityConflictTestCases() []identityConflictTestCase {
+	return []identityConflictTestCase{
+		newIdentityConflictTestCase("close old", IdentityCloseOld, nil, true, true),
+		newIdentityConflictTestCase("reject new", IdentityRejectNew, ErrIdentityInUse, false, false),
+		newIdentityConflictTestCase("replace", IdentityReplace, nil, true, false),
+	}
+}
+
+func TestDefaultSessionManager_BindIdentity_conflict(t *testing.T) {
+	core.RunTestCases(t, identityConflictTestCases())
+}
+
+func TestDefaultSessionManager_BindIdentity(t *testing.T) {
+	sm := NewDefaultSessionManager(NewDefaultMessageHandler(nil), nil)
+	session := sm.AddSession(&mockConn{remoteAddr: "127.0.0.1:12345"})
+
+	core.AssertErrorIs(t, sm.BindIdentity("unknown", deviceID), core.ErrNotExists, "unknown session")
+
+	core.AssertNoError(t, sm.BindIdentity(session.ID(), "old-name"), "bind")
+	core.AssertNoError(t, sm.BindIdentity(session.ID(), deviceID), "rename")
+	core.AssertNil(t, sm.SessionByIdentity("old-name"), "old name")
+	core.AssertSame(t, session, sm.SessionByIdentity(deviceID), "new name")
+
+	sm.UnbindIdentity(session.ID())
+	core.AssertNil(t, sm.SessionByIdentity(deviceID), "unbound")
+
+	core.AssertNoError(t, sm.BindIdentity(session.ID(), deviceID), "bind again")
+	sm.RemoveSession(session.ID())
+	core.AssertNil(t, sm.SessionByIdentity(deviceID), "removed")
+}
+
+// TestServer_SendTo drives a targeted update from the identity a login
+// handler records to the subscription of the device.
+func TestServer_SendTo(t *testing.T) {
+	h := NewDefaultMessageHandler(nil)
+	sm := NewDefaultSessionManager(h, nil)
+	srv := NewServer(nil, sm, h, nil)
+
+	conn := &mockConn{remoteAddr: "127.0.0.1:12345"}
+	device := sm.AddSession(conn)
+	other := sm.AddSession(&mockConn{remoteAddr: "127.0.0.1:12346"})
+
+	ctx := context.Background()
+	core.AssertMustNoError(t, h.Subscribe(ctx, device, newTestSubscribeRequest(9, pathEcho, nil)), "subscribe")
+	core.AssertMustNoError(t, h.Subscribe(ctx, other, newTestSubscribeRequest(9, pathEcho, nil)), "subscribe other")
+
+	core.AssertErrorIs(t, srv.SendTo(deviceID, pathEcho, nil), ErrUnknownIdentity, "before login")
+
+	core.AssertMustNoError(t, h.SetSessionIdentity(device.ID(), &Identity{Name: deviceID}), "login")
+	core.AssertNoError(t, srv.SendTo(deviceID, pathEcho, []byte("reboot")), "SendTo")
+	core.AssertErrorIs(t, srv.SendTo(deviceID, "/api/other", nil), ErrNoSubscription, "not subscribed")
+
+	responses := decodeWritten(t, conn)
+	if core.AssertEqual(t, 2, len(responses), "responses") {
+		update := responses[1]
+		core.AssertEqual(t, nanorpc.NanoRPCResponse_TYPE_UPDATE, update.ResponseType, "type")
+		core.AssertEqual(t, int32(9), update.RequestId, "request_id")
+		core.AssertEqual(t, "reboot", string(update.Data), "data")
+	}
+
+	// logging out unbinds the identity
+	core.AssertNoError(t, h.SetSessionIdentity(device.ID(), nil), "logout")
+	core.AssertErrorIs(t, srv.SendTo(deviceID, pathEcho, nil), ErrUnknownIdentity, "after logout")
+}
+
+func TestDefaultMessageHandler_SetSessionIdentity_rejected(t *testing.T) {
+	h := NewDefaultMessageHandler(nil)
+	sm := NewDefaultSessionManager(h, nil)
+	core.AssertMustNoError(t, sm.SetIdentityConflict(IdentityRejectNew), "policy")
+	core.AssertMustNoError(t, h.SetIdentityIndex(sm), "SetIdentityIndex")
+
+	first := sm.AddSession(&mockConn{remoteAddr: "127.0.0.1:12345"})
+	second := sm.AddSession(&mockConn{remoteAddr: "127.0.0.1:12346"})
+
+	core.AssertNoError(t, h.SetSessionIdentity(first.ID(), &Identity{Name: deviceID}), "first")
+	err := h.SetSessionIdentity(second.ID(), &Identity{Name: deviceID})
+	core.AssertErrorIs(t, err, ErrIdentityInUse, "second")
+	core.AssertNil(t, h.SessionIdentity(second.ID()), "second not recorded")
+}
+
+func TestServer_SendTo_unsupported(t *testing.T) {
+	srv := NewServer(nil, nil, NewDefaultMessageHandler(nil), nil)
+	core.AssertErrorIs(t, srv.SendTo(deviceID, pathEcho, nil), ErrSendToUnsupported, "SendTo")
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+
+	"darvaza.org/core"
+)
+
+// Interceptor wraps the handling of the requests a [DefaultMessageHandler]
+// dispatches to registered handlers, for cross-cutting concerns such as
+// authorisation, metrics, tracing or rate limiting. It may inspect the
+// [RequestContext], answer the request itself without calling next, or
+// call next and act on its result.
+//
+// Interceptors run once per request: pings, subscriptions, unsubscriptions
+// and requests to unknown paths aren't intercepted, and neither are
+// requests re-dispatched by [RequestContext.Forward].
+type Interceptor interface {
+	Intercept(ctx context.Context, rc *RequestContext, next RequestHandler) error
+}
+
+// InterceptorFunc is an adapter to allow ordinary functions to be used as
+// Interceptors.
+type InterceptorFunc func(context.Context, *RequestContext, RequestHandler) error
+
+// Intercept calls the function with the given context, request and next
+// handler.
+func (f InterceptorFunc) Intercept(ctx context.Context, rc *RequestContext, next RequestHandler) error {
+	if f == nil {
+		return core.ErrNilReceiver
+	}
+
+	return f(ctx, rc, next)
+}
+
+// Use appends interceptors to the chain wrapping every registered handler.
+// The first interceptor added is the outermost, running first and seeing
+// the outcome of all others.
+func (h *DefaultMessageHandler) Use(interceptors ...Interceptor) error {
+	if h == nil {
+		return core.ErrNilReceiver
+	}
+
+	for _, ic := range interceptors {
+		if core.IsNil(ic) {
+			return ErrMissingInterceptor
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.interceptors = append(h.interceptors, interceptors...)
+	return nil
+}
+
+// intercept wraps handler with the interceptors in use.
+func (h *DefaultMessageHandler) intercept(handler RequestHandler) RequestHandler {
+	h.mu.RLock()
+	interceptors := h.interceptors
+	h.mu.RUnlock()
+
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		handler = interceptedHandler{ic: interceptors[i], next: handler}
+	}
+	return handler
+}
+
+// interceptedHandler is a [RequestHandler] calling an [Interceptor] with
+// the rest of the chain.
+type interceptedHandler struct {
+	ic   Interceptor
+	next RequestHandler
+}
+
+func (ih interceptedHandler) Handle(ctx context.Context, rc *RequestContext) error {
+	return ih.ic.Intercept(ctx, rc, ih.next)
+}
+
+// Use appends interceptors to the server's [DefaultMessageHandler], or to
+// any [MessageHandler] providing a Use method of the same signature.
+func (s *Server) Use(interceptors ...Interceptor) error {
+	if s == nil {
+		return core.ErrNilReceiver
+	}
+
+	if mh, ok := s.messageHandler.(interface{ Use(...Interceptor) error }); ok {
+		return mh.Use(interceptors...)
+	}
+	return ErrInterceptorsUnsupported
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// recordingInterceptor appends its name to a trace before and after
+// calling the rest of the chain.
+func recordingInterceptor(name string, trace *[]string) Interceptor {
+	return InterceptorFunc(func(ctx context.Context, rc *RequestContext, next RequestHandler) error {
+		*trace = append(*trace, name+">")
+		err := next.Handle(ctx, rc)
+		*trace = append(*trace, "<"+name)
+		return err
+	})
+}
+
+// ignoringMessageHandler is a [MessageHandler] without interceptor support.
+type ignoringMessageHandler struct{}
+
+func (ignoringMessageHandler) HandleMessage(context.Context, Session, *nanorpc.NanoRPCRequest) error {
+	return nil
+}
+
+func newInterceptedHandler(t *testing.T, trace *[]string) *DefaultMessageHandler {
+	t.Helper()
+
+	h := NewDefaultMessageHandler(nil)
+	core.AssertMustNoError(t, h.RegisterHandlerFunc(pathEcho,
+		func(_ context.Context, rc *RequestContext) error {
+			*trace = append(*trace, rc.Path)
+			return rc.SendOK(rc.GetData())
+		}), "register")
+	return h
+}
+
+func TestDefaultMessageHandler_Use(t *testing.T) {
+	var trace []string
+	h := newInterceptedHandler(t, &trace)
+
+	core.AssertMustNoError(t, h.Use(recordingInterceptor("outer", &trace)), "use outer")
+	core.AssertMustNoError(t, h.Use(recordingInterceptor("inner", &trace)), "use inner")
+
+	session := newTestSession("intercepted", 12345)
+	err := h.HandleMessage(context.Background(), session, newTestRequest(1, pathEcho))
+	core.AssertMustNoError(t, err, "HandleMessage")
+
+	core.AssertSliceEqual(t, []string{"outer>", "inner>", pathEcho, "<inner", "<outer"}, trace, "trace")
+	core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_OK, session.GetLastResponse().ResponseStatus, "status")
+
+	// unknown paths don't reach interceptors
+	trace = nil
+	err = h.HandleMessage(context.Background(), session, newTestRequest(2, pathUnregistered))
+	core.AssertMustNoError(t, err, "HandleMessage unregistered")
+	core.AssertEqual(t, 0, len(trace), "trace")
+}
+
+func TestDefaultMessageHandler_UseShortCircuit(t *testing.T) {
+	var trace []string
+	h := newInterceptedHandler(t, &trace)
+
+	deny := InterceptorFunc(func(_ context.Context, rc *RequestContext, _ RequestHandler) error {
+		return rc.SendUnauthorized("")
+	})
+	core.AssertMustNoError(t, h.Use(deny), "use")
+
+	session := newTestSession("denied", 12345)
+	err := h.HandleMessage(context.Background(), session, newTestRequest(1, pathEcho))
+	core.AssertMustNoError(t, err, "HandleMessage")
+
+	core.AssertEqual(t, 0, len(trace), "handler skipped")
+	core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_NOT_AUTHORIZED,
+		session.GetLastResponse().ResponseStatus, "status")
+}
+
+func TestDefaultMessageHandler_UseMissing(t *testing.T) {
+	h := NewDefaultMessageHandler(nil)
+
+	core.AssertErrorIs(t, h.Use(nil), ErrMissingInterceptor, "nil")
+	core.AssertErrorIs(t, h.Use(InterceptorFunc(nil)), ErrMissingInterceptor, "nil func")
+	core.AssertTrue(t, IsInvalid(h.Use(nil)), "invalid")
+	core.AssertEqual(t, 0, len(h.interceptors), "nothing added")
+}
+
+func TestServer_Use(t *testing.T) {
+	var trace []string
+	h := newInterceptedHandler(t, &trace)
+	s := NewServer(nil, NewDefaultSessionManager(h, nil), h, nil)
+
+	core.AssertNoError(t, s.Use(recordingInterceptor("server", &trace)), "default handler")
+	core.AssertEqual(t, 1, len(h.interceptors), "interceptors")
+
+	custom := NewServer(nil, nil, ignoringMessageHandler{}, nil)
+	core.AssertErrorIs(t, custom.Use(recordingInterceptor("custom", &trace)),
+		ErrInterceptorsUnsupported, "custom handler")
+}
@@ -57,6 +57,19 @@ type RequestHandler interface {
 	Handle(ctx context.Context, req *RequestContext) error
 }
 
+// HandlerRegistry registers request handlers by path, as
+// [DefaultMessageHandler] does. The service registration functions
+// generated by protoc-gen-go-nanorpc take it.
+type HandlerRegistry interface {
+	RegisterHandlerFunc(path string, fn RequestHandlerFunc, opts ...AccessOption) error
+}
+
+// Publisher publishes updates by path, as [DefaultMessageHandler] does.
+// [Publish] takes it.
+type Publisher interface {
+	Publish(path string, data []byte) error
+}
+
 // RequestHandlerFunc is an adapter to allow ordinary functions to be used as RequestHandlers
 type RequestHandlerFunc func(context.Context, *RequestContext) error
 
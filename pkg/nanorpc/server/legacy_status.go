@@ -0,0 +1,14 @@
+package server
+
+import "protomcp.org/nanorpc/pkg/nanorpc"
+
+// downgradeStatus replaces the status of a response with its
+// [nanorpc.LegacyStatus], unless the session negotiated
+// [nanorpc.FeatureExtendedStatus].
+func (s *DefaultSession) downgradeStatus(response *nanorpc.NanoRPCResponse) {
+	if fn, ok := s.handler.(featureNegotiator); ok &&
+		fn.SessionFeatures(s.id).Has(nanorpc.FeatureExtendedStatus) {
+		return
+	}
+	response.ResponseStatus = nanorpc.LegacyStatus(response.ResponseStatus)
+}
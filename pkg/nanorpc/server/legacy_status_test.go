@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+var _ core.TestCase = legacyStatusTestCase{}
+
+// legacyStatusTestCase verifies sessions send extended statuses as
+// STATUS_INTERNAL_ERROR only to clients that didn't negotiate them.
+type legacyStatusTestCase struct {
+	name     string
+	features nanorpc.Features
+	want     nanorpc.NanoRPCResponse_Status
+	legacy   bool
+	hello    bool
+}
+
+func (tc legacyStatusTestCase) Name() string { return tc.name }
+
+func (tc legacyStatusTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	handler := NewDefaultMessageHandler(nil)
+	err := handler.RegisterHandlerFunc(pathEcho, func(_ context.Context, rc *RequestContext) error {
+		return rc.SendBadRequest("missing field")
+	})
+	core.AssertMustNoError(t, err, "register")
+
+	sm := NewDefaultSessionManager(handler, nil)
+	core.AssertMustNoError(t, sm.SetSessionConfig(SessionConfig{LegacyStatus: tc.legacy}), "config")
+
+	conn := &mockConn{remoteAddr: "127.0.0.1:12345", data: tc.frames(t)}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_ = sm.AddSession(conn).Handle(ctx)
+
+	resp := lastResponse(t, conn.writeData)
+	core.AssertEqual(t, tc.want, resp.ResponseStatus, "status")
+	core.AssertEqual(t, "missing field", resp.ResponseMessage, "message")
+}
+
+// frames encodes the optional handshake and a request to pathEcho.
+func (tc legacyStatusTestCase) frames(t *testing.T) []byte {
+	t.Helper()
+
+	var out []byte
+	if tc.hello {
+		ping := &nanorpc.NanoRPCRequest{RequestId: 1, RequestType: nanorpc.NanoRPCRequest_TYPE_PING}
+		b, err := nanorpc.EncodeRequest(ping, nanorpc.NewHello(tc.features))
+		core.AssertMustNoError(t, err, "encode handshake")
+		out = append(out, b...)
+	}
+
+	b, err := nanorpc.EncodeRequest(newTestRequest(2, pathEcho), nil)
+	core.AssertMustNoError(t, err, "encode request")
+	return append(out, b...)
+}
+
+func newLegacyStatusTestCase(name string, legacy, hello bool, features nanorpc.Features,
+	want nanorpc.NanoRPCResponse_Status) legacyStatusTestCase {
+	return legacyStatusTestCase{
+		name:     name,
+		legacy:   legacy,
+		hello:    hello,
+		features: features,
+		want:     want,
+	}
+}
+
+func legacyStatusTestCases() []legacyStatusTestCase {
+	const (
+		bad      = nanorpc.NanoRPCResponse_STATUS_BAD_REQUEST
+		internal = nanorpc.NanoRPCResponse_STATUS_INTERNAL_ERROR
+	)
+	return []legacyStatusTestCase{
+		newLegacyStatusTestCase("disabled", false, false, 0, bad),
+		newLegacyStatusTestCase("negotiated", true, true, nanorpc.FeatureExtendedStatus, bad),
+		newLegacyStatusTestCase("not announced", true, true, nanorpc.FeatureCompression, internal),
+		newLegacyStatusTestCase("no handshake", true, false, 0, internal),
+	}
+}
+
+func TestSessionConfig_LegacyStatus(t *testing.T) {
+	core.RunTestCases(t, legacyStatusTestCases())
+}
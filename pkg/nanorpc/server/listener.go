@@ -1,12 +1,25 @@
 package server
 
-import "net"
+import (
+	"context"
+	"net"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc/utils"
+)
 
 // ListenerAdapter wraps net.Listener to implement our Listener interface
 type ListenerAdapter struct {
 	net.Listener
 }
 
+// errListenerClosed is the error Accept returns once a listener on addr
+// is closed, the same a [net.Listener] returns.
+func errListenerClosed(addr net.Addr) error {
+	return &net.OpError{Op: "accept", Net: addr.Network(), Addr: addr, Err: net.ErrClosed}
+}
+
 // NewListenerAdapter creates a new listener adapter
 func NewListenerAdapter(listener net.Listener) *ListenerAdapter {
 	if listener == nil {
@@ -14,3 +27,102 @@ func NewListenerAdapter(listener net.Listener) *ListenerAdapter {
 	}
 	return &ListenerAdapter{Listener: listener}
 }
+
+// AddListener makes the server accept connections from l too, sharing its
+// [SessionManager] and [MessageHandler], e.g. to serve operators over a
+// unix socket and remote clients over TLS. It can be called before or
+// while serving, and the server owns l from then on, closing it when
+// stopped. If it fails, l is left to the caller.
+//
+// Options like [WithTLS] only apply to the listener given to [NewServer];
+// wrap l with [NewTLSListener] to serve it over TLS. Like that one, l
+// retries temporary accept errors, and any other stops the server.
+func (s *Server) AddListener(l Listener) error {
+	switch {
+	case s == nil:
+		return core.ErrNilReceiver
+	case core.IsNil(l):
+		return ErrMissingListener
+	}
+
+	serving, err := s.addListener(l)
+	if err == nil && serving {
+		s.logListener(l, "Listener added")
+	}
+	return err
+}
+
+// addListener appends l to the listeners, starting its accept loop if
+// the server is serving.
+func (s *Server) addListener(l Listener) (serving bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.serving {
+		if err := s.unsafeServeListener(l); err != nil {
+			return true, err
+		}
+	}
+	s.listeners = append(s.listeners, l)
+	return s.serving, nil
+}
+
+// startListeners starts the accept loops of all the listeners, failing
+// if there are none.
+func (s *Server) startListeners() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	listeners := s.unsafeListeners()
+	if len(listeners) == 0 {
+		return ErrMissingListener
+	}
+
+	s.serving = true
+	for _, l := range listeners {
+		if err := s.unsafeServeListener(l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unsafeServeListener starts the accept loop of l. s.mu must be held.
+func (s *Server) unsafeServeListener(l Listener) error {
+	return s.wg.GoCatch(func(ctx context.Context) error {
+		return s.acceptLoop(ctx, l)
+	}, s.catchAcceptError)
+}
+
+// unsafeListeners returns the listener given to [NewServer], if any, and
+// the added ones. s.mu must be held.
+func (s *Server) unsafeListeners() []Listener {
+	out := make([]Listener, 0, len(s.listeners)+1)
+	if !core.IsNil(s.listener) {
+		out = append(out, s.listener)
+	}
+	return append(out, s.listeners...)
+}
+
+// closeListeners closes all the listeners, logging failures with msg.
+func (s *Server) closeListeners(msg string) {
+	s.mu.RLock()
+	listeners := s.unsafeListeners()
+	s.mu.RUnlock()
+
+	for _, l := range listeners {
+		if err := l.Close(); err != nil {
+			if lg, ok := s.WithWarn(err); ok {
+				lg.Print(msg)
+			}
+		}
+	}
+}
+
+// logListener logs msg at info level with the address of l.
+func (s *Server) logListener(l Listener, msg string) {
+	if lg, ok := s.WithInfo(); ok {
+		lg = utils.WithLocalAddr(lg, l.Addr())
+		lg.Print(msg)
+	}
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+)
+
+// TestServer_AddListener verifies listeners added before and while
+// serving share the sessions of the server, and are closed with it.
+func TestServer_AddListener(t *testing.T) {
+	tcp, err := net.Listen("tcp", "localhost:0")
+	core.AssertMustNoError(t, err, "listen tcp")
+	unix, err := net.Listen("unix", filepath.Join(t.TempDir(), "admin.sock"))
+	core.AssertMustNoError(t, err, "listen unix")
+	late, err := net.Listen("tcp", "localhost:0")
+	core.AssertMustNoError(t, err, "listen late")
+
+	server := NewDefaultServer(tcp, nil, nil)
+	core.AssertMustNoError(t, server.AddListener(NewListenerAdapter(unix)), "add before serving")
+
+	serverErr := make(chan error, 1)
+	go func() { serverErr <- server.Serve(context.Background()) }()
+	waitServerReady(t, server)
+	core.AssertMustNoError(t, server.AddListener(NewListenerAdapter(late)), "add while serving")
+
+	for _, addr := range []net.Addr{tcp.Addr(), unix.Addr(), late.Addr()} {
+		conn, err := net.Dial(addr.Network(), addr.String())
+		core.AssertMustNoError(t, err, "dial %s", addr)
+		defer conn.Close()
+		sendPingReceivePong(t, conn)
+	}
+	core.AssertEqual(t, 3, len(server.Sessions()), "shared sessions")
+
+	shutdownServer(t, server, serverErr)
+
+	_, err = unix.Accept()
+	core.AssertErrorIs(t, err, net.ErrClosed, "unix closed")
+	_, err = late.Accept()
+	core.AssertErrorIs(t, err, net.ErrClosed, "late closed")
+
+	extra, err := net.Listen("tcp", "localhost:0")
+	core.AssertMustNoError(t, err, "listen extra")
+	defer extra.Close()
+	core.AssertError(t, server.AddListener(NewListenerAdapter(extra)), "add after shutdown")
+}
+
+func TestServer_AddListener_missing(t *testing.T) {
+	handler := NewDefaultMessageHandler(nil)
+	server := NewServer(nil, NewDefaultSessionManager(handler, nil), handler, nil)
+
+	core.AssertErrorIs(t, server.AddListener(nil), ErrMissingListener, "nil listener")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	core.AssertErrorIs(t, server.Serve(ctx), ErrMissingListener, "no listeners")
+}
@@ -0,0 +1,326 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"darvaza.org/core"
+	"darvaza.org/slog"
+	"darvaza.org/slog/handlers/discard"
+
+	"protomcp.org/nanorpc/pkg/nanorpc/utils"
+)
+
+// DefaultManifestPollInterval is how often [ManifestLoader.Watch] checks
+// the manifest file for changes when no interval is given.
+const DefaultManifestPollInterval = 2 * time.Second
+
+// Manifest describes a set of path to handler-template mappings that can
+// be installed on a [DefaultMessageHandler] at runtime.
+//
+//	{"routes": [
+//	  {"path": "/status", "template": "static", "params": {"text": "ok"}},
+//	  {"path": "/v1/status", "template": "forward", "params": {"target": "/status"}}
+//	]}
+type Manifest struct {
+	Routes []ManifestRoute `json:"routes"`
+}
+
+// ManifestRoute maps a path to a named handler template and its
+// template-specific parameters.
+type ManifestRoute struct {
+	Params   json.RawMessage `json:"params,omitempty"`
+	Path     string          `json:"path"`
+	Template string          `json:"template"`
+}
+
+// HandlerTemplate builds a [RequestHandler] from the parameters of a
+// [ManifestRoute]. Templates must validate their parameters, as errors
+// abort the whole manifest.
+type HandlerTemplate func(params json.RawMessage) (RequestHandler, error)
+
+// ManifestLoader installs [Manifest] routes on a [DefaultMessageHandler].
+// Each manifest replaces the routes installed by the previous one
+// atomically: either every route is installed and stale ones removed, or
+// nothing changes. Paths registered by other means are never touched, and
+// a manifest claiming one of them is rejected.
+//
+// Built-in templates are "static", "error" and "forward". Others, such as
+// proxies or scripted responders, are added with RegisterTemplate.
+type ManifestLoader struct {
+	handler   *DefaultMessageHandler
+	templates map[string]HandlerTemplate
+	owned     map[string]struct{}
+	logger    slog.Logger
+	mu        sync.Mutex
+}
+
+// NewManifestLoader creates a loader installing routes on h. If logger is
+// nil, a discard logger is used.
+func NewManifestLoader(h *DefaultMessageHandler, logger slog.Logger) *ManifestLoader {
+	if logger == nil {
+		logger = discard.New()
+	}
+
+	return &ManifestLoader{
+		handler: h,
+		templates: map[string]HandlerTemplate{
+			"static":  newStaticTemplate,
+			"error":   newErrorTemplate,
+			"forward": newForwardTemplate,
+		},
+		owned:  make(map[string]struct{}),
+		logger: utils.WithComponent(logger, utils.ComponentManifestLoader),
+	}
+}
+
+// RegisterTemplate adds a named handler template, or replaces it if
+// already present. A nil template removes it.
+func (l *ManifestLoader) RegisterTemplate(name string, tpl HandlerTemplate) error {
+	switch {
+	case l == nil:
+		return core.ErrNilReceiver
+	case name == "":
+		return core.QuietWrap(ErrInvalidManifest, "empty template name")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if tpl == nil {
+		delete(l.templates, name)
+	} else {
+		l.templates[name] = tpl
+	}
+	return nil
+}
+
+// Routes returns the paths currently installed by the loader, sorted.
+func (l *ManifestLoader) Routes() []string {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	paths := make([]string, 0, len(l.owned))
+	for path := range l.owned {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+// Load parses a JSON manifest and applies it.
+func (l *ManifestLoader) Load(data []byte) error {
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return core.Wrap(ErrInvalidManifest, err.Error())
+	}
+	return l.Apply(&m)
+}
+
+// LoadFile reads a JSON manifest from the named file and applies it.
+func (l *ManifestLoader) LoadFile(name string) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	return l.Load(data)
+}
+
+// Apply installs the routes of m, replacing those of the previous
+// manifest. The change is atomic and logged.
+func (l *ManifestLoader) Apply(m *Manifest) error {
+	switch {
+	case l == nil:
+		return core.ErrNilReceiver
+	case l.handler == nil:
+		return core.QuietWrap(ErrInvalidManifest, "no message handler")
+	case m == nil:
+		return core.QuietWrap(ErrInvalidManifest, "nil manifest")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	routes, err := l.unsafeBuild(m)
+	if err == nil {
+		err = l.unsafeInstall(routes)
+	}
+	if err != nil {
+		l.logWarn(err, nil, "manifest rejected")
+	}
+	return err
+}
+
+func (l *ManifestLoader) unsafeBuild(m *Manifest) (map[string]RequestHandler, error) {
+	routes := make(map[string]RequestHandler, len(m.Routes))
+	for _, route := range m.Routes {
+		if _, dup := routes[route.Path]; dup || route.Path == "" {
+			return nil, core.QuietWrap(ErrInvalidManifest, "invalid or duplicate path %q", route.Path)
+		}
+
+		tpl, ok := l.templates[route.Template]
+		if !ok {
+			return nil, core.QuietWrap(ErrInvalidManifest, "%q: unknown template %q",
+				route.Path, route.Template)
+		}
+
+		handler, err := tpl(route.Params)
+		if err != nil {
+			return nil, core.Wrapf(err, "%q", route.Path)
+		}
+
+		// detect hash collisions before touching the handler table
+		if _, err := l.handler.hashCache.Hash(route.Path); err != nil {
+			return nil, err
+		}
+		routes[route.Path] = handler
+	}
+	return routes, nil
+}
+
+func (l *ManifestLoader) unsafeInstall(routes map[string]RequestHandler) error {
+	added, removed, err := l.handler.swapRoutes(l.owned, routes)
+	if err != nil {
+		return err
+	}
+
+	l.owned = make(map[string]struct{}, len(routes))
+	for path := range routes {
+		l.owned[path] = struct{}{}
+	}
+
+	l.logInfo(slog.Fields{
+		utils.FieldRoutesAdded:   added,
+		utils.FieldRoutesRemoved: removed,
+		utils.FieldRouteCount:    len(routes),
+	}, "manifest applied")
+	return nil
+}
+
+// Watch polls the named manifest file every interval and applies it
+// whenever its modification time changes, until ctx is cancelled. Invalid
+// manifests are logged and skipped until the file changes again.
+func (l *ManifestLoader) Watch(ctx context.Context, name string, interval time.Duration) error {
+	if l == nil {
+		return core.ErrNilReceiver
+	}
+	if interval <= 0 {
+		interval = DefaultManifestPollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last time.Time
+	for {
+		last = l.reloadIfChanged(name, last)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (l *ManifestLoader) reloadIfChanged(name string, last time.Time) time.Time {
+	fields := slog.Fields{utils.FieldManifest: name}
+
+	fi, err := os.Stat(name)
+	if err != nil {
+		l.logWarn(err, fields, "manifest unavailable")
+		return last
+	}
+
+	if modTime := fi.ModTime(); !modTime.Equal(last) {
+		if err := l.LoadFile(name); err != nil {
+			l.logWarn(err, fields, "manifest reload failed")
+		} else {
+			l.logInfo(fields, "manifest reloaded")
+		}
+		return modTime
+	}
+	return last
+}
+
+// AdminHandler returns a handler that applies the JSON manifest carried in
+// the request data and answers with the installed routes. It should be
+// registered on a path only trusted peers can reach.
+func (l *ManifestLoader) AdminHandler() RequestHandler {
+	if l == nil {
+		return nil
+	}
+
+	return RequestHandlerFunc(func(_ context.Context, rc *RequestContext) error {
+		if err := l.Load(rc.GetData()); err != nil {
+			return rc.SendBadRequest(err.Error())
+		}
+		return rc.SendJSON(map[string][]string{"routes": l.Routes()})
+	})
+}
+
+// swapRoutes replaces the routes in owned with routes under a single
+// lock, refusing paths registered by other means.
+func (h *DefaultMessageHandler) swapRoutes(owned map[string]struct{},
+	routes map[string]RequestHandler) (added, removed int, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.handlers == nil {
+		h.handlers = make(map[string]RequestHandler)
+	}
+
+	if err := h.unsafeCheckRoutes(owned, routes); err != nil {
+		return 0, 0, err
+	}
+
+	for path := range owned {
+		if _, keep := routes[path]; !keep {
+			delete(h.handlers, path)
+			removed++
+		}
+	}
+
+	for path, handler := range routes {
+		if _, mine := owned[path]; !mine {
+			added++
+		}
+		h.handlers[path] = handler
+	}
+	return added, removed, nil
+}
+
+func (h *DefaultMessageHandler) unsafeCheckRoutes(owned map[string]struct{},
+	routes map[string]RequestHandler) error {
+	for path := range routes {
+		_, mine := owned[path]
+		if _, taken := h.handlers[path]; taken && !mine {
+			return core.Wrapf(core.ErrExists, "path %q", path)
+		}
+	}
+	return nil
+}
+
+func (l *ManifestLoader) logInfo(fields slog.Fields, msg string) {
+	if log, ok := l.logger.Info().WithEnabled(); ok {
+		log.WithFields(fields).Print(msg)
+	}
+}
+
+func (l *ManifestLoader) logWarn(err error, fields slog.Fields, msg string) {
+	if log, ok := l.logger.Warn().WithEnabled(); ok {
+		log = utils.WithError(log, err)
+		if fields != nil {
+			log = log.WithFields(fields)
+		}
+		log.Print(msg)
+	}
+}
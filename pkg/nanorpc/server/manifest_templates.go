@@ -0,0 +1,96 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// staticParams are the parameters of the "static" template. Data is
+// base64 encoded in JSON; Text is used when Data is empty.
+type staticParams struct {
+	Data []byte `json:"data,omitempty"`
+	Text string `json:"text,omitempty"`
+}
+
+// errorParams are the parameters of the "error" template. Status is a
+// response status name, with or without the STATUS_ prefix.
+type errorParams struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// forwardParams are the parameters of the "forward" template.
+type forwardParams struct {
+	Target string `json:"target"`
+}
+
+func decodeTemplateParams(params json.RawMessage, v any) error {
+	if len(params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return core.Wrap(ErrInvalidManifest, err.Error())
+	}
+	return nil
+}
+
+// newStaticTemplate answers every request with fixed data.
+func newStaticTemplate(params json.RawMessage) (RequestHandler, error) {
+	var p staticParams
+	if err := decodeTemplateParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	data := p.Data
+	if len(data) == 0 && p.Text != "" {
+		data = []byte(p.Text)
+	}
+
+	return RequestHandlerFunc(func(_ context.Context, rc *RequestContext) error {
+		return rc.SendOK(data)
+	}), nil
+}
+
+// newErrorTemplate answers every request with a fixed error status.
+func newErrorTemplate(params json.RawMessage) (RequestHandler, error) {
+	var p errorParams
+	if err := decodeTemplateParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	name := strings.ToUpper(p.Status)
+	if !strings.HasPrefix(name, "STATUS_") {
+		name = "STATUS_" + name
+	}
+
+	// neither OK nor UNSPECIFIED, which clients read as success
+	v, ok := nanorpc.NanoRPCResponse_Status_value[name]
+	status := nanorpc.NanoRPCResponse_Status(v)
+	if !ok || status == nanorpc.NanoRPCResponse_STATUS_OK || status == nanorpc.NanoRPCResponse_STATUS_UNSPECIFIED {
+		return nil, core.QuietWrap(ErrInvalidManifest, "invalid error status %q", p.Status)
+	}
+
+	return RequestHandlerFunc(func(_ context.Context, rc *RequestContext) error {
+		return rc.SendError(status, p.Message)
+	}), nil
+}
+
+// newForwardTemplate forwards every request to another path.
+func newForwardTemplate(params json.RawMessage) (RequestHandler, error) {
+	var p forwardParams
+	if err := decodeTemplateParams(params, &p); err != nil {
+		return nil, err
+	}
+	if p.Target == "" {
+		return nil, core.QuietWrap(ErrInvalidManifest, "missing forward target")
+	}
+
+	return RequestHandlerFunc(func(_ context.Context, rc *RequestContext) error {
+		return rc.Forward(p.Target)
+	}), nil
+}
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/utils/testutils"
+)
+
+const testManifest = `{"routes": [
+	{"path": "/status", "template": "static", "params": {"text": "ok"}},
+	{"path": "/v1/status", "template": "forward", "params": {"target": "/status"}},
+	{"path": "/broken", "template": "error", "params": {"status": "not_found", "message": "gone"}}
+]}`
+
+var _ core.TestCase = manifestRejectTestCase{}
+
+// manifestRejectTestCase verifies invalid manifests are rejected without
+// changing the installed routes.
+type manifestRejectTestCase struct {
+	name     string
+	manifest string
+}
+
+func (tc manifestRejectTestCase) Name() string { return tc.name }
+
+func (tc manifestRejectTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	handler := NewDefaultMessageHandler(nil)
+	core.AssertMustNoError(t, handler.RegisterHandlerFunc(pathEcho, echoChainHandler), "register")
+
+	loader := NewManifestLoader(handler, nil)
+	core.AssertMustNoError(t, loader.Load([]byte(testManifest)), "initial manifest")
+
+	err := loader.Load([]byte(tc.manifest))
+	core.AssertError(t, err, "rejected")
+	core.AssertSliceEqual(t, []string{"/broken", "/status", "/v1/status"}, loader.Routes(), "routes kept")
+	assertManifestStatus(t, handler, "/status", nanorpc.NanoRPCResponse_STATUS_OK)
+}
+
+func newManifestRejectTestCase(name, manifest string) manifestRejectTestCase {
+	return manifestRejectTestCase{name: name, manifest: manifest}
+}
+
+func manifestRejectTestCases() []manifestRejectTestCase {
+	return []manifestRejectTestCase{
+		newManifestRejectTestCase("malformed", `{"routes": [`),
+		newManifestRejectTestCase("unknown_template",
+			`{"routes": [{"path": "/x", "template": "script"}]}`),
+		newManifestRejectTestCase("duplicate_path",
+			`{"routes": [{"path": "/x", "template": "static"}, {"path": "/x", "template": "static"}]}`),
+		newManifestRejectTestCase("empty_path",
+			`{"routes": [{"path": "", "template": "static"}]}`),
+		newManifestRejectTestCase("bad_status",
+			`{"routes": [{"path": "/x", "template": "error", "params": {"status": "ok"}}]}`),
+		newManifestRejectTestCase("unspecified_status",
+			`{"routes": [{"path": "/x", "template": "error", "params": {"status": "unspecified"}}]}`),
+		newManifestRejectTestCase("missing_target",
+			`{"routes": [{"path": "/x", "template": "forward"}]}`),
+		newManifestRejectTestCase("foreign_path",
+			`{"routes": [{"path": "/status", "template": "static"}, {"path": "`+pathEcho+`", "template": "static"}]}`),
+	}
+}
+
+// TestManifestLoader_Reject exercises atomic rejection of invalid manifests.
+func TestManifestLoader_Reject(t *testing.T) {
+	core.RunTestCases(t, manifestRejectTestCases())
+}
+
+// TestManifestLoader_Apply verifies templates are installed, replaced and
+// removed across manifests.
+func TestManifestLoader_Apply(t *testing.T) {
+	handler := NewDefaultMessageHandler(nil)
+	loader := NewManifestLoader(handler, nil)
+
+	core.AssertMustNoError(t, loader.Load([]byte(testManifest)), "load")
+	assertManifestStatus(t, handler, "/status", nanorpc.NanoRPCResponse_STATUS_OK)
+	assertManifestStatus(t, handler, "/v1/status", nanorpc.NanoRPCResponse_STATUS_OK)
+	assertManifestStatus(t, handler, "/broken", nanorpc.NanoRPCResponse_STATUS_NOT_FOUND)
+
+	next := `{"routes": [{"path": "/status", "template": "error", "params": {"status": "INTERNAL_ERROR"}}]}`
+	core.AssertMustNoError(t, loader.Load([]byte(next)), "reload")
+	core.AssertSliceEqual(t, []string{"/status"}, loader.Routes(), "routes")
+	assertManifestStatus(t, handler, "/status", nanorpc.NanoRPCResponse_STATUS_INTERNAL_ERROR)
+	assertManifestStatus(t, handler, "/v1/status", nanorpc.NanoRPCResponse_STATUS_NOT_FOUND)
+}
+
+// TestManifestLoader_AdminHandler verifies manifests can be pushed over
+// the protocol.
+func TestManifestLoader_AdminHandler(t *testing.T) {
+	handler := NewDefaultMessageHandler(nil)
+	loader := NewManifestLoader(handler, nil)
+	core.AssertMustNoError(t, handler.RegisterHandler("/admin/manifest", loader.AdminHandler()), "register")
+
+	session := newTestSession("", 0)
+	req := newTestRequest(3, "/admin/manifest")
+	req.Data = []byte(testManifest)
+	core.AssertMustNoError(t, handler.HandleMessage(context.Background(), session, req), "admin")
+	core.AssertEqual(t, `{"routes":["/broken","/status","/v1/status"]}`,
+		string(session.GetLastResponse().Data), "admin response")
+
+	req.Data = []byte(`{`)
+	core.AssertMustNoError(t, handler.HandleMessage(context.Background(), session, req), "admin")
+	core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_BAD_REQUEST,
+		session.GetLastResponse().ResponseStatus, "bad manifest")
+}
+
+// TestManifestLoader_Watch verifies file changes are picked up.
+func TestManifestLoader_Watch(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "manifest.json")
+	core.AssertMustNoError(t, os.WriteFile(name, []byte(testManifest), 0o600), "write")
+
+	handler := NewDefaultMessageHandler(nil)
+	loader := NewManifestLoader(handler, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- loader.Watch(ctx, name, 10*time.Millisecond) }()
+
+	testutils.AssertWaitForCondition(t, func() bool {
+		return len(loader.Routes()) == 3
+	}, time.Second, "initial load")
+
+	next := []byte(`{"routes": [{"path": "/only", "template": "static"}]}`)
+	core.AssertMustNoError(t, os.WriteFile(name, next, 0o600), "rewrite")
+	later := time.Now().Add(time.Second)
+	core.AssertMustNoError(t, os.Chtimes(name, later, later), "touch")
+
+	testutils.AssertWaitForCondition(t, func() bool {
+		return len(loader.Routes()) == 1
+	}, time.Second, "reload")
+
+	cancel()
+	core.AssertErrorIs(t, <-done, context.Canceled, "watch stop")
+}
+
+func assertManifestStatus(t *testing.T, handler *DefaultMessageHandler, path string,
+	want nanorpc.NanoRPCResponse_Status) {
+	t.Helper()
+
+	session := newTestSession("", 0)
+	err := handler.HandleMessage(context.Background(), session, newTestRequest(1, path))
+	core.AssertNoError(t, err, "handle %s", path)
+	if resp := session.GetLastResponse(); core.AssertNotNil(t, resp, "response %s", path) {
+		core.AssertEqual(t, want, resp.ResponseStatus, "status %s", path)
+	}
+}
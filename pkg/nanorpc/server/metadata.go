@@ -0,0 +1,55 @@
+package server
+
+import (
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// Metadata returns the metadata of the request, see [nanorpc.Metadata].
+func (rc *RequestContext) Metadata() nanorpc.Metadata {
+	if rc == nil || rc.Request == nil {
+		return nil
+	}
+	return rc.Request.Metadata
+}
+
+// CorrelationID returns the correlation ID of the request, see
+// [nanorpc.MetadataCorrelationID], given by the client or, with
+// [SessionConfig] CorrelationIDs, the session. Handlers calling further
+// servers pass it on, e.g. with client.WithMetadata, and log it as
+// utils.FieldCorrelationID, see utils.RequestFields.
+func (rc *RequestContext) CorrelationID() string {
+	return rc.Metadata().CorrelationID()
+}
+
+// SetResponseMetadata sets a key of the metadata carried by the responses
+// sent from now on, replacing its values.
+func (rc *RequestContext) SetResponseMetadata(key, value string) error {
+	if rc == nil {
+		return core.ErrNilReceiver
+	}
+
+	rc.metadata = rc.metadata.Clone().Set(key, value)
+	return nil
+}
+
+// AddResponseMetadata appends a key-value pair to the metadata carried by
+// the responses sent from now on.
+func (rc *RequestContext) AddResponseMetadata(key, value string) error {
+	if rc == nil {
+		return core.ErrNilReceiver
+	}
+
+	rc.metadata = rc.metadata.Clone().Add(key, value)
+	return nil
+}
+
+// ResponseMetadata returns the metadata carried by the responses sent from
+// now on.
+func (rc *RequestContext) ResponseMetadata() nanorpc.Metadata {
+	if rc == nil {
+		return nil
+	}
+	return rc.metadata
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+func TestRequestContext_Metadata(t *testing.T) {
+	handler := NewDefaultMessageHandler(nil)
+	err := handler.RegisterHandlerFunc(pathEcho, func(_ context.Context, rc *RequestContext) error {
+		trace, _ := rc.Metadata().Get("trace-id")
+		if err := rc.SetResponseMetadata("trace-id", trace); err != nil {
+			return err
+		}
+		if err := rc.AddResponseMetadata("server", "test"); err != nil {
+			return err
+		}
+		if err := rc.SendChunk([]byte("chunk")); err != nil {
+			return err
+		}
+		return rc.SendOK(nil)
+	})
+	core.AssertMustNoError(t, err, "register")
+
+	session := newTestSession("", 0)
+	req := newTestRequest(1, pathEcho)
+	req.Metadata = nanorpc.NewMetadata("trace-id", "42")
+	core.AssertMustNoError(t, handler.HandleMessage(context.Background(), session, req), "HandleMessage")
+
+	responses := session.GetAllResponses()
+	core.AssertMustEqual(t, 2, len(responses), "responses")
+	for _, res := range responses {
+		md := nanorpc.Metadata(res.GetMetadata())
+		trace, _ := md.Get("trace-id")
+		core.AssertEqual(t, "42", trace, "trace-id")
+		name, _ := md.Get("server")
+		core.AssertEqual(t, "test", name, "server")
+	}
+
+	var rc *RequestContext
+	core.AssertNil(t, rc.Metadata(), "nil metadata")
+	core.AssertErrorIs(t, rc.SetResponseMetadata("a", "b"), core.ErrNilReceiver, "nil set")
+	core.AssertErrorIs(t, rc.AddResponseMetadata("a", "b"), core.ErrNilReceiver, "nil add")
+}
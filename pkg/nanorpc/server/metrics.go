@@ -0,0 +1,126 @@
+package server
+
+import (
+	"sync/atomic"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/server/metrics"
+)
+
+// WithMetrics reports the metrics of the server to c, through its
+// [DefaultMessageHandler] and [DefaultSessionManager]. Other handlers and
+// session managers are left untouched.
+func WithMetrics(c metrics.Collector) ServerOption {
+	return func(s *Server) {
+		if h, ok := s.messageHandler.(*DefaultMessageHandler); ok {
+			_ = h.SetMetrics(c)
+		}
+		if sm, ok := s.sessionManager.(*DefaultSessionManager); ok {
+			_ = sm.SetMetrics(c)
+		}
+	}
+}
+
+// SetMetrics sets the [metrics.Collector] receiving the requests answered,
+// the live subscriptions and the publications of the handler. A nil
+// Collector stops reporting.
+func (h *DefaultMessageHandler) SetMetrics(c metrics.Collector) error {
+	if h == nil {
+		return core.ErrNilReceiver
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.metrics = nil
+	if !core.IsNil(c) {
+		h.metrics = c
+		h.unsafeReportSubscriptions()
+	}
+	return nil
+}
+
+func (h *DefaultMessageHandler) getMetrics() metrics.Collector {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.metrics
+}
+
+// unsafeReportSubscriptions reports the number of live subscriptions.
+func (h *DefaultMessageHandler) unsafeReportSubscriptions() {
+	if h.metrics == nil {
+		return
+	}
+
+	h.metrics.SetActiveSubscriptions(h.subscriptions.Len())
+}
+
+// observeRequest wraps the session of a request to report its response.
+// Requests to unregistered paths are reported without path, to keep
+// arbitrary client input out of the metric labels.
+func (h *DefaultMessageHandler) observeRequest(session Session, path string, registered bool) Session {
+	c := h.getMetrics()
+	if c == nil {
+		return session
+	}
+	if !registered {
+		path = ""
+	}
+	return &observedSession{Session: session, metrics: c, path: path, start: time.Now()}
+}
+
+// observePublish reports an update sent to its subscribers.
+func (h *DefaultMessageHandler) observePublish(pathHash uint32, subscribers int, start time.Time) {
+	if c := h.getMetrics(); c != nil {
+		path, _ := h.hashCache.Path(pathHash)
+		c.ObservePublish(path, subscribers, time.Since(start))
+	}
+}
+
+// observedSession is a [Session] reporting the first TYPE_RESPONSE sent
+// through it to a [metrics.Collector].
+type observedSession struct {
+	Session
+	metrics metrics.Collector
+	start   time.Time
+	path    string
+	done    atomic.Bool
+}
+
+// SendResponse sends the response through the wrapped session.
+func (s *observedSession) SendResponse(req *nanorpc.NanoRPCRequest, response *nanorpc.NanoRPCResponse) error {
+	err := s.Session.SendResponse(req, response)
+	if response.GetResponseType() == nanorpc.NanoRPCResponse_TYPE_RESPONSE && s.done.CompareAndSwap(false, true) {
+		s.metrics.ObserveRequest(s.path, response.GetResponseStatus(), time.Since(s.start))
+	}
+	return err
+}
+
+// SetMetrics sets the [metrics.Collector] receiving the number of open
+// sessions. A nil Collector stops reporting.
+func (sm *DefaultSessionManager) SetMetrics(c metrics.Collector) error {
+	if sm == nil {
+		return core.ErrNilReceiver
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	sm.metrics = nil
+	if !core.IsNil(c) {
+		sm.metrics = c
+		sm.unsafeReportSessions()
+	}
+	return nil
+}
+
+// unsafeReportSessions reports the number of open sessions.
+func (sm *DefaultSessionManager) unsafeReportSessions() {
+	if sm.metrics != nil {
+		sm.metrics.SetActiveSessions(len(sm.sessions))
+	}
+}
@@ -0,0 +1,17 @@
+// Package metrics defines how a NanoRPC server reports what it is doing,
+// and provides an in-memory implementation exposed in the Prometheus text
+// format.
+//
+// A [Collector] receives the events of a server: requests answered by
+// path and status, active sessions and subscriptions, and how long
+// publishing an update to every subscriber takes. It is attached with
+// server.WithMetrics:
+//
+//	reg := metrics.NewRegistry()
+//	srv := server.NewDefaultServer(listener, handler, logger,
+//		server.WithMetrics(reg))
+//	http.Handle("/metrics", reg)
+//
+// The [Registry] has no dependencies; other monitoring systems, or the
+// Prometheus client library, are plugged in by implementing [Collector].
+package metrics
@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"time"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// Collector receives the metrics of a NanoRPC server. Implementations
+// must be safe for concurrent use, and quick, as they are called inline.
+type Collector interface {
+	// ObserveRequest records a TYPE_REQUEST answered with status, and how
+	// long it took since it reached the message handler. Requests to
+	// unregistered paths are recorded with an empty path.
+	ObserveRequest(path string, status nanorpc.NanoRPCResponse_Status, duration time.Duration)
+
+	// SetActiveSessions records the number of open sessions.
+	SetActiveSessions(n int)
+
+	// SetActiveSubscriptions records the number of live subscriptions.
+	SetActiveSubscriptions(n int)
+
+	// ObservePublish records an update published on a path, the number of
+	// subscribers it was sent to, and how long sending it to them took.
+	ObservePublish(path string, subscribers int, duration time.Duration)
+}
+
+// Discard is a [Collector] ignoring everything.
+type Discard struct{}
+
+var _ Collector = Discard{}
+
+// ObserveRequest does nothing.
+func (Discard) ObserveRequest(string, nanorpc.NanoRPCResponse_Status, time.Duration) {}
+
+// SetActiveSessions does nothing.
+func (Discard) SetActiveSessions(int) {}
+
+// SetActiveSubscriptions does nothing.
+func (Discard) SetActiveSubscriptions(int) {}
+
+// ObservePublish does nothing.
+func (Discard) ObservePublish(string, int, time.Duration) {}
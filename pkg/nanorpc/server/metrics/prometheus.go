@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// Metric names exposed by [Registry.WritePrometheus].
+const (
+	RequestsTotal            = "nanorpc_requests_total"
+	RequestDuration          = "nanorpc_request_duration_seconds"
+	ActiveSessionsGauge      = "nanorpc_active_sessions"
+	ActiveSubscriptionsGauge = "nanorpc_active_subscriptions"
+	PublishDuration          = "nanorpc_publish_duration_seconds"
+	PublishUpdatesTotal      = "nanorpc_publish_updates_total"
+)
+
+// ContentType is the media type of the Prometheus text format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WritePrometheus writes the metrics in the Prometheus text format, sorted
+// by name and labels.
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	var buf strings.Builder
+	if r != nil {
+		r.mu.Lock()
+		r.unsafeWrite(&buf)
+		r.mu.Unlock()
+	}
+
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
+
+// ServeHTTP answers scrapes with [Registry.WritePrometheus].
+func (r *Registry) ServeHTTP(rw http.ResponseWriter, _ *http.Request) {
+	rw.Header().Set("Content-Type", ContentType)
+	_ = r.WritePrometheus(rw)
+}
+
+func (r *Registry) unsafeWrite(buf *strings.Builder) {
+	writeHeader(buf, RequestsTotal, "counter", "Requests answered, by path and status.")
+	keys := slices.SortedFunc(maps.Keys(r.requests), compareRequestKeys)
+	for _, k := range keys {
+		fmt.Fprintf(buf, "%s{path=\"%s\",status=\"%s\"} %d\n",
+			RequestsTotal, escapeLabel(k.path), statusLabel(k.status), r.requests[k])
+	}
+
+	writeHistograms(buf, RequestDuration, "Request handling time, by path.", r.durations)
+
+	writeHeader(buf, ActiveSessionsGauge, "gauge", "Open sessions.")
+	fmt.Fprintf(buf, "%s %d\n", ActiveSessionsGauge, r.sessions)
+
+	writeHeader(buf, ActiveSubscriptionsGauge, "gauge", "Live subscriptions.")
+	fmt.Fprintf(buf, "%s %d\n", ActiveSubscriptionsGauge, r.subscriptions)
+
+	writeHistograms(buf, PublishDuration, "Time to send a published update to every subscriber, by path.",
+		r.publishes)
+
+	writeHeader(buf, PublishUpdatesTotal, "counter", "Updates sent to subscribers, by path.")
+	for _, path := range slices.Sorted(maps.Keys(r.updates)) {
+		fmt.Fprintf(buf, "%s{path=\"%s\"} %d\n", PublishUpdatesTotal, escapeLabel(path), r.updates[path])
+	}
+}
+
+func writeHeader(buf *strings.Builder, name, kind, help string) {
+	fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func writeHistograms(buf *strings.Builder, name, help string, m map[string]*histogram) {
+	writeHeader(buf, name, "histogram", help)
+	for _, path := range slices.Sorted(maps.Keys(m)) {
+		h, label := m[path], escapeLabel(path)
+
+		var cumulative uint64
+		for i, le := range durationBuckets {
+			cumulative += h.buckets[i]
+			fmt.Fprintf(buf, "%s_bucket{path=\"%s\",le=\"%s\"} %d\n", name, label, formatFloat(le), cumulative)
+		}
+		fmt.Fprintf(buf, "%s_bucket{path=\"%s\",le=\"+Inf\"} %d\n", name, label, h.count)
+		fmt.Fprintf(buf, "%s_sum{path=\"%s\"} %s\n", name, label, formatFloat(h.sum))
+		fmt.Fprintf(buf, "%s_count{path=\"%s\"} %d\n", name, label, h.count)
+	}
+}
+
+// statusLabel names a status without its STATUS_ prefix, or by number
+// when unknown.
+func statusLabel(status nanorpc.NanoRPCResponse_Status) string {
+	return strings.TrimPrefix(status.String(), "STATUS_")
+}
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func compareRequestKeys(a, b requestKey) int {
+	if c := strings.Compare(a.path, b.path); c != 0 {
+		return c
+	}
+	return int(a.status) - int(b.status)
+}
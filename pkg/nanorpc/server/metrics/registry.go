@@ -0,0 +1,186 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// durationBuckets are the upper bounds, in seconds, of the duration
+// histograms of a [Registry].
+var durationBuckets = [...]float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5}
+
+var _ Collector = (*Registry)(nil)
+
+// Registry is a [Collector] keeping the metrics in memory, to be scraped
+// in the Prometheus text format through [Registry.WritePrometheus] or as
+// an [http.Handler]. The zero value is ready to use, and methods on a nil
+// Registry do nothing.
+type Registry struct {
+	requests      map[requestKey]uint64
+	durations     map[string]*histogram // path -> request durations
+	publishes     map[string]*histogram // path -> fan-out durations
+	updates       map[string]uint64     // path -> updates sent
+	sessions      int
+	subscriptions int
+	mu            sync.Mutex
+}
+
+// requestKey identifies a request counter.
+type requestKey struct {
+	path   string
+	status nanorpc.NanoRPCResponse_Status
+}
+
+// NewRegistry creates an empty [Registry].
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// ObserveRequest counts a request by path and status, and records its
+// duration by path.
+func (r *Registry) ObserveRequest(path string, status nanorpc.NanoRPCResponse_Status, duration time.Duration) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.requests == nil {
+		r.requests = make(map[requestKey]uint64)
+	}
+	r.requests[requestKey{path: path, status: status}]++
+	r.durations = observe(r.durations, path, duration)
+}
+
+// SetActiveSessions records the number of open sessions.
+func (r *Registry) SetActiveSessions(n int) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sessions = n
+}
+
+// SetActiveSubscriptions records the number of live subscriptions.
+func (r *Registry) SetActiveSubscriptions(n int) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.subscriptions = n
+}
+
+// ObservePublish counts the updates sent for a path, and records how long
+// sending them took.
+func (r *Registry) ObservePublish(path string, subscribers int, duration time.Duration) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.updates == nil {
+		r.updates = make(map[string]uint64)
+	}
+	r.updates[path] += uint64(max(subscribers, 0))
+	r.publishes = observe(r.publishes, path, duration)
+}
+
+// RequestCount returns the number of requests to a path answered with a
+// status.
+func (r *Registry) RequestCount(path string, status nanorpc.NanoRPCResponse_Status) uint64 {
+	if r == nil {
+		return 0
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.requests[requestKey{path: path, status: status}]
+}
+
+// ActiveSessions returns the last number of open sessions recorded.
+func (r *Registry) ActiveSessions() int {
+	if r == nil {
+		return 0
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.sessions
+}
+
+// ActiveSubscriptions returns the last number of live subscriptions
+// recorded.
+func (r *Registry) ActiveSubscriptions() int {
+	if r == nil {
+		return 0
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.subscriptions
+}
+
+// PublishCount returns the number of updates published on a path, and
+// the number of subscribers they were sent to.
+func (r *Registry) PublishCount(path string) (published, sent uint64) {
+	if r == nil {
+		return 0, 0
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if h := r.publishes[path]; h != nil {
+		published = h.count
+	}
+	return published, r.updates[path]
+}
+
+// histogram counts observations by duration bucket.
+type histogram struct {
+	buckets [len(durationBuckets)]uint64 // not cumulative
+	sum     float64                      // seconds
+	count   uint64
+}
+
+func (h *histogram) observe(d time.Duration) {
+	v := d.Seconds()
+	for i, le := range durationBuckets {
+		if v <= le {
+			h.buckets[i]++
+			break
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// observe records a duration in the histogram of a path, creating the map
+// and the histogram as needed.
+func observe(m map[string]*histogram, path string, d time.Duration) map[string]*histogram {
+	if m == nil {
+		m = make(map[string]*histogram)
+	}
+
+	h := m[path]
+	if h == nil {
+		h = new(histogram)
+		m[path] = h
+	}
+	h.observe(d)
+	return m
+}
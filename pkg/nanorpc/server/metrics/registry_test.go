@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+const (
+	statusOK       = nanorpc.NanoRPCResponse_STATUS_OK
+	statusNotFound = nanorpc.NanoRPCResponse_STATUS_NOT_FOUND
+)
+
+func TestRegistry_counts(t *testing.T) {
+	r := NewRegistry()
+	r.ObserveRequest("/a", statusOK, time.Millisecond)
+	r.ObserveRequest("/a", statusOK, 2*time.Millisecond)
+	r.ObserveRequest("", statusNotFound, 0)
+	r.SetActiveSessions(3)
+	r.SetActiveSubscriptions(5)
+	r.ObservePublish("/events", 4, time.Millisecond)
+	r.ObservePublish("/events", 0, 0)
+
+	core.AssertEqual(t, uint64(2), r.RequestCount("/a", statusOK), "/a OK")
+	core.AssertEqual(t, uint64(0), r.RequestCount("/a", statusNotFound), "/a NOT_FOUND")
+	core.AssertEqual(t, uint64(1), r.RequestCount("", statusNotFound), "unknown NOT_FOUND")
+	core.AssertEqual(t, 3, r.ActiveSessions(), "sessions")
+	core.AssertEqual(t, 5, r.ActiveSubscriptions(), "subscriptions")
+
+	published, sent := r.PublishCount("/events")
+	core.AssertEqual(t, uint64(2), published, "published")
+	core.AssertEqual(t, uint64(4), sent, "sent")
+}
+
+var _ core.TestCase = prometheusTestCase{}
+
+// prometheusTestCase verifies a line of the text exposition.
+type prometheusTestCase struct {
+	name string
+	line string
+}
+
+func (tc prometheusTestCase) Name() string { return tc.name }
+
+func (tc prometheusTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	var buf strings.Builder
+	core.AssertMustNoError(t, newScrapedRegistry().WritePrometheus(&buf), "WritePrometheus")
+
+	core.AssertContains(t, "\n"+buf.String(), "\n"+tc.line+"\n", tc.line)
+}
+
+func newPrometheusTestCase(name, line string) prometheusTestCase {
+	return prometheusTestCase{name: name, line: line}
+}
+
+func prometheusTestCases() []prometheusTestCase {
+	return []prometheusTestCase{
+		newPrometheusTestCase("requests type", "# TYPE nanorpc_requests_total counter"),
+		newPrometheusTestCase("requests", `nanorpc_requests_total{path="/a",status="OK"} 3`),
+		newPrometheusTestCase("requests escaped", `nanorpc_requests_total{path="/q\"\\\n",status="NOT_FOUND"} 1`),
+		newPrometheusTestCase("requests unknown status", `nanorpc_requests_total{path="/a",status="42"} 1`),
+		newPrometheusTestCase("bucket", `nanorpc_request_duration_seconds_bucket{path="/a",le="0.001"} 1`),
+		newPrometheusTestCase("bucket cumulative", `nanorpc_request_duration_seconds_bucket{path="/a",le="0.005"} 3`),
+		newPrometheusTestCase("bucket inf", `nanorpc_request_duration_seconds_bucket{path="/a",le="+Inf"} 4`),
+		newPrometheusTestCase("count", `nanorpc_request_duration_seconds_count{path="/a"} 4`),
+		newPrometheusTestCase("sessions", "nanorpc_active_sessions 2"),
+		newPrometheusTestCase("subscriptions", "nanorpc_active_subscriptions 7"),
+		newPrometheusTestCase("publish count", `nanorpc_publish_duration_seconds_count{path="/events"} 1`),
+		newPrometheusTestCase("publish updates", `nanorpc_publish_updates_total{path="/events"} 3`),
+	}
+}
+
+func newScrapedRegistry() *Registry {
+	r := NewRegistry()
+	r.ObserveRequest("/a", statusOK, time.Millisecond)
+	r.ObserveRequest("/a", statusOK, 2*time.Millisecond)
+	r.ObserveRequest("/a", nanorpc.NanoRPCResponse_Status(42), 3*time.Millisecond)
+	r.ObserveRequest("/a", statusOK, time.Minute)
+	r.ObserveRequest("/q\"\\\n", statusNotFound, 0)
+	r.SetActiveSessions(2)
+	r.SetActiveSubscriptions(7)
+	r.ObservePublish("/events", 3, time.Millisecond)
+	return r
+}
+
+func TestRegistry_WritePrometheus(t *testing.T) {
+	core.RunTestCases(t, prometheusTestCases())
+}
+
+func TestRegistry_WritePrometheus_sorted(t *testing.T) {
+	var a, b strings.Builder
+	r := newScrapedRegistry()
+	core.AssertMustNoError(t, r.WritePrometheus(&a), "first")
+	core.AssertMustNoError(t, r.WritePrometheus(&b), "second")
+	core.AssertEqual(t, a.String(), b.String(), "stable output")
+	core.AssertTrue(t, strings.Index(a.String(), `path="/a"`) < strings.Index(a.String(), `path="/q`), "order")
+}
+
+func TestRegistry_ServeHTTP(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newScrapedRegistry().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	core.AssertEqual(t, ContentType, rec.Header().Get("Content-Type"), "content type")
+	core.AssertContains(t, rec.Body.String(), "nanorpc_active_sessions 2", "body")
+}
+
+func TestRegistry_nil(t *testing.T) {
+	var r *Registry
+	r.ObserveRequest("/a", statusOK, 0)
+	r.SetActiveSessions(1)
+	r.SetActiveSubscriptions(1)
+	r.ObservePublish("/a", 1, 0)
+
+	core.AssertEqual(t, uint64(0), r.RequestCount("/a", statusOK), "requests")
+	core.AssertEqual(t, 0, r.ActiveSessions(), "sessions")
+	core.AssertEqual(t, 0, r.ActiveSubscriptions(), "subscriptions")
+
+	var buf strings.Builder
+	core.AssertNoError(t, r.WritePrometheus(&buf), "WritePrometheus")
+	core.AssertEqual(t, "", buf.String(), "output")
+}
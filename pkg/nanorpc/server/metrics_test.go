@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/server/metrics"
+)
+
+func TestDefaultMessageHandler_SetMetrics_requests(t *testing.T) {
+	reg := metrics.NewRegistry()
+	h := NewDefaultMessageHandler(nil)
+	core.AssertMustNoError(t, h.RegisterHandlerFunc(pathEcho, echoChainHandler), "register")
+	core.AssertMustNoError(t, h.SetMetrics(reg), "SetMetrics")
+
+	session := newTestSession(sessionID1, 0)
+	ctx := context.Background()
+	core.AssertNoError(t, h.HandleMessage(ctx, session, newTestRequest(1, pathEcho)), "echo")
+	core.AssertNoError(t, h.HandleMessage(ctx, session, newTestRequest(2, pathEcho)), "echo again")
+	core.AssertNoError(t, h.HandleMessage(ctx, session, newTestRequest(3, "/no/such/path")), "unknown")
+
+	core.AssertEqual(t, uint64(2), reg.RequestCount(pathEcho, nanorpc.NanoRPCResponse_STATUS_OK), "echo")
+	core.AssertEqual(t, uint64(1), reg.RequestCount("", nanorpc.NanoRPCResponse_STATUS_NOT_FOUND), "unknown")
+	core.AssertEqual(t, uint64(0), reg.RequestCount("/no/such/path",
+		nanorpc.NanoRPCResponse_STATUS_NOT_FOUND), "unknown by path")
+
+	// pings aren't requests
+	ping := &nanorpc.NanoRPCRequest{RequestId: 4, RequestType: nanorpc.NanoRPCRequest_TYPE_PING}
+	core.AssertNoError(t, h.HandleMessage(ctx, session, ping), "ping")
+	core.AssertEqual(t, uint64(2), reg.RequestCount(pathEcho, nanorpc.NanoRPCResponse_STATUS_OK), "after ping")
+}
+
+func TestDefaultMessageHandler_SetMetrics_subscriptions(t *testing.T) {
+	reg := metrics.NewRegistry()
+	h := NewDefaultMessageHandler(nil)
+	ctx := context.Background()
+
+	s1, s2 := newTestSession(sessionID1, 0), newTestSession(sessionID2, 0)
+	core.AssertMustNoError(t, h.Subscribe(ctx, s1, newTestSubscribeRequest(1, pathEcho, nil)), "subscribe 1")
+
+	// setting the collector reports the subscriptions made before
+	core.AssertMustNoError(t, h.SetMetrics(reg), "SetMetrics")
+	core.AssertEqual(t, 1, reg.ActiveSubscriptions(), "before")
+
+	core.AssertMustNoError(t, h.Subscribe(ctx, s2, newTestSubscribeRequest(1, pathEcho, nil)), "subscribe 2")
+	core.AssertEqual(t, 2, reg.ActiveSubscriptions(), "subscribed")
+
+	core.AssertNoError(t, h.Publish(pathEcho, []byte("data")), "Publish")
+	published, sent := reg.PublishCount(pathEcho)
+	core.AssertEqual(t, uint64(1), published, "published")
+	core.AssertEqual(t, uint64(2), sent, "sent")
+
+	core.AssertNoError(t, h.ForceUnsubscribe(sessionID1, pathEcho), "ForceUnsubscribe")
+	core.AssertEqual(t, 1, reg.ActiveSubscriptions(), "forced")
+
+	h.RemoveSubscriptionsForSession(sessionID2)
+	core.AssertEqual(t, 0, reg.ActiveSubscriptions(), "removed")
+
+	core.AssertNoError(t, h.SetMetrics(nil), "unset")
+	core.AssertNoError(t, h.Publish(pathEcho, nil), "Publish unobserved")
+	published, _ = reg.PublishCount(pathEcho)
+	core.AssertEqual(t, uint64(1), published, "unobserved")
+}
+
+func TestDefaultSessionManager_SetMetrics(t *testing.T) {
+	reg := metrics.NewRegistry()
+	sm := NewDefaultSessionManager(NewDefaultMessageHandler(nil), nil)
+	core.AssertMustNoError(t, sm.SetMetrics(reg), "SetMetrics")
+
+	a := sm.AddSession(&mockConn{remoteAddr: "127.0.0.1:12345"})
+	b := sm.AddSession(&mockConn{remoteAddr: "127.0.0.1:12346"})
+	core.AssertEqual(t, 2, reg.ActiveSessions(), "added")
+
+	sm.RemoveSession(a.ID())
+	sm.RemoveSession(a.ID())
+	core.AssertEqual(t, 1, reg.ActiveSessions(), "removed")
+
+	sm.RemoveSession(b.ID())
+	core.AssertEqual(t, 0, reg.ActiveSessions(), "empty")
+}
+
+// TestWithMetrics verifies the server option reaches the default handler
+// and session manager.
+func TestWithMetrics(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	core.AssertMustNoError(t, err, "listen")
+	defer listener.Close()
+
+	reg := metrics.NewRegistry()
+	srv := NewDefaultServer(listener, nil, nil, WithMetrics(reg))
+
+	h, ok := srv.messageHandler.(*DefaultMessageHandler)
+	core.AssertMustTrue(t, ok, "default message handler")
+	core.AssertSame(t, metrics.Collector(reg), h.getMetrics(), "handler metrics")
+
+	sm, ok := srv.sessionManager.(*DefaultSessionManager)
+	core.AssertMustTrue(t, ok, "default session manager")
+	core.AssertSame(t, metrics.Collector(reg), sm.metrics, "session manager metrics")
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"errors"
+	"net"
+	"sync"
+
+	"darvaza.org/core"
+)
+
+// MultiListener accepts connections from several [net.Listener]s, e.g.
+// the IPv4 and IPv6 ones of [ListenDualStack], so a single [Server]
+// serves them all. It's also a [net.Listener], to be passed to
+// [NewDefaultServer].
+//
+// Accept errors are returned by Accept. A listener keeps accepting after
+// temporary errors, see [IsTemporaryAcceptError], and stops after the
+// others, which also stop the [Server].
+type MultiListener struct {
+	listeners []net.Listener
+	accept    chan acceptResult
+	done      chan struct{}
+	once      sync.Once
+}
+
+// acceptResult is the outcome of an Accept call on one of the listeners
+// of a [MultiListener].
+type acceptResult struct {
+	conn net.Conn
+	err  error
+}
+
+// NewMultiListener creates a [MultiListener] accepting from the given
+// listeners, which it owns from then on. Nil listeners are skipped, and
+// nil is returned if none is left.
+func NewMultiListener(listeners ...net.Listener) *MultiListener {
+	var ls []net.Listener
+	for _, l := range listeners {
+		if !core.IsNil(l) {
+			ls = append(ls, l)
+		}
+	}
+	if len(ls) == 0 {
+		return nil
+	}
+
+	ml := &MultiListener{
+		listeners: ls,
+		accept:    make(chan acceptResult),
+		done:      make(chan struct{}),
+	}
+	for _, l := range ls {
+		go ml.acceptLoop(l)
+	}
+	return ml
+}
+
+// Accept waits for the next connection on any of the listeners.
+func (ml *MultiListener) Accept() (net.Conn, error) {
+	if ml == nil {
+		return nil, core.ErrNilReceiver
+	}
+
+	select {
+	case r := <-ml.accept:
+		return r.conn, r.err
+	case <-ml.done:
+		return nil, errListenerClosed(ml.Addr())
+	}
+}
+
+// Close closes all the listeners.
+func (ml *MultiListener) Close() error {
+	if ml == nil {
+		return core.ErrNilReceiver
+	}
+
+	var errs []error
+	ml.once.Do(func() {
+		close(ml.done)
+		for _, l := range ml.listeners {
+			if err := l.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// Addr returns the address of the first listener, see
+// [MultiListener.Addrs].
+func (ml *MultiListener) Addr() net.Addr {
+	if ml == nil {
+		return nil
+	}
+	return ml.listeners[0].Addr()
+}
+
+// Addrs returns the addresses of all the listeners.
+func (ml *MultiListener) Addrs() []net.Addr {
+	if ml == nil {
+		return nil
+	}
+
+	out := make([]net.Addr, len(ml.listeners))
+	for i, l := range ml.listeners {
+		out[i] = l.Addr()
+	}
+	return out
+}
+
+// acceptLoop hands the connections of l to Accept until l fails.
+func (ml *MultiListener) acceptLoop(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		select {
+		case ml.accept <- acceptResult{conn: conn, err: err}:
+			if err != nil && !IsTemporaryAcceptError(err) {
+				return
+			}
+		case <-ml.done:
+			if conn != nil {
+				_ = conn.Close()
+			}
+			return
+		}
+	}
+}
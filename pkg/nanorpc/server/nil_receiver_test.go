@@ -0,0 +1,450 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+var _ core.TestCase = nilReceiverTestCase{}
+
+// nilReceiverTestCase calls an exported method on a nil receiver. Methods
+// returning an error must report core.ErrNilReceiver; the others are
+// adapted through zeroResult and must return zero values. Neither may
+// panic.
+type nilReceiverTestCase struct {
+	call func() error
+	name string
+}
+
+func (tc nilReceiverTestCase) Name() string { return tc.name }
+
+func (tc nilReceiverTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	err := core.Catch(tc.call)
+	core.AssertErrorIs(t, err, core.ErrNilReceiver, tc.name)
+}
+
+func newNilReceiverTestCase(name string, call func() error) nilReceiverTestCase {
+	return nilReceiverTestCase{call: call, name: name}
+}
+
+// errNonZero reports a method on a nil receiver returned a non-zero value.
+var errNonZero = errors.New("non-zero result on nil receiver")
+
+// zeroResult adapts a non-error method, mapping "returned zero values" to
+// core.ErrNilReceiver.
+func zeroResult(isZero bool) error {
+	if isZero {
+		return core.ErrNilReceiver
+	}
+	return errNonZero
+}
+
+func nilServerTestCases() []nilReceiverTestCase {
+	var s *Server
+	return []nilReceiverTestCase{
+		newNilReceiverTestCase("Server.Serve", func() error { return s.Serve(context.Background()) }),
+		newNilReceiverTestCase("Server.Shutdown", func() error { return s.Shutdown(context.Background()) }),
+		newNilReceiverTestCase("Server.AddListener", func() error { return s.AddListener(nil) }),
+		newNilReceiverTestCase("Server.Ready", func() error { return zeroResult(s.Ready() == nil) }),
+		newNilReceiverTestCase("Server.ReadStats", func() error { return zeroResult(s.ReadStats() == ReadStats{}) }),
+		newNilReceiverTestCase("Server.Use", func() error { return s.Use() }),
+		newNilReceiverTestCase("Server.Unsubscribe", func() error { return s.Unsubscribe("a", "/x") }),
+		newNilReceiverTestCase("Server.Sessions", func() error { return zeroResult(s.Sessions() == nil) }),
+		newNilReceiverTestCase("Server.CloseSession", func() error { return s.CloseSession("a") }),
+		newNilReceiverTestCase("Server.SendTo", func() error { return s.SendTo("a", "/x", nil) }),
+		newNilReceiverTestCase("TLSListener.Accept", func() error {
+			var l *TLSListener
+			_, err := l.Accept()
+			return err
+		}),
+		newNilReceiverTestCase("UDPListener.Accept", func() error {
+			var l *UDPListener
+			_, err := l.Accept()
+			return err
+		}),
+		newNilReceiverTestCase("UDPListener.Close", func() error {
+			var l *UDPListener
+			return l.Close()
+		}),
+		newNilReceiverTestCase("UDPListener.Addr", func() error {
+			var l *UDPListener
+			return zeroResult(l.Addr() == nil)
+		}),
+		newNilReceiverTestCase("SerialListener.Accept", func() error {
+			var l *SerialListener
+			_, err := l.Accept()
+			return err
+		}),
+		newNilReceiverTestCase("SerialListener.Close", func() error {
+			var l *SerialListener
+			return l.Close()
+		}),
+		newNilReceiverTestCase("SerialListener.Addr", func() error {
+			var l *SerialListener
+			return zeroResult(l.Addr() == nil)
+		}),
+		newNilReceiverTestCase("MultiListener.Accept", func() error {
+			var l *MultiListener
+			_, err := l.Accept()
+			return err
+		}),
+		newNilReceiverTestCase("MultiListener.Close", func() error {
+			var l *MultiListener
+			return l.Close()
+		}),
+		newNilReceiverTestCase("MultiListener.Addr", func() error {
+			var l *MultiListener
+			return zeroResult(l.Addr() == nil && l.Addrs() == nil)
+		}),
+		newNilReceiverTestCase("Server.LogInfo", func() error {
+			s.LogInfo(nil, "ignored")
+			_, ok := s.WithError(nil)
+			return zeroResult(!ok)
+		}),
+	}
+}
+
+func nilSessionTestCases() []nilReceiverTestCase {
+	var s *DefaultSession
+	return []nilReceiverTestCase{
+		newNilReceiverTestCase("DefaultSession.ID", func() error { return zeroResult(s.ID() == "") }),
+		newNilReceiverTestCase("DefaultSession.RemoteAddr", func() error { return zeroResult(s.RemoteAddr() == "") }),
+		newNilReceiverTestCase("DefaultSession.Handle", func() error { return s.Handle(context.Background()) }),
+		newNilReceiverTestCase("DefaultSession.Close", s.Close),
+		newNilReceiverTestCase("DefaultSession.SendResponse", func() error {
+			return s.SendResponse(nil, &nanorpc.NanoRPCResponse{})
+		}),
+		newNilReceiverTestCase("DefaultSession.TLSConnectionState", func() error {
+			_, ok := s.TLSConnectionState()
+			return zeroResult(!ok)
+		}),
+		newNilReceiverTestCase("DefaultSession.ReadStats", func() error {
+			return zeroResult(s.ReadStats() == ReadStats{})
+		}),
+		newNilReceiverTestCase("DefaultSession.LastActivity", func() error {
+			return zeroResult(s.ConnectedAt().IsZero() && s.LastActivity().IsZero())
+		}),
+		newNilReceiverTestCase("DefaultSession.DroppedUpdates", func() error {
+			return zeroResult(s.DroppedUpdates() == 0)
+		}),
+		newNilReceiverTestCase("DefaultSession.LogWarn", func() error {
+			s.LogWarn(nil, nil, "ignored")
+			_, ok := s.WithDebug()
+			return zeroResult(!ok)
+		}),
+	}
+}
+
+func nilSessionManagerTestCases() []nilReceiverTestCase {
+	var sm *DefaultSessionManager
+	return []nilReceiverTestCase{
+		newNilReceiverTestCase("DefaultSessionManager.SetSessionStore", func() error {
+			return sm.SetSessionStore(nil, "")
+		}),
+		newNilReceiverTestCase("DefaultSessionManager.AddSession", func() error {
+			return zeroResult(sm.AddSession(nil) == nil)
+		}),
+		newNilReceiverTestCase("DefaultSessionManager.GetSession", func() error {
+			return zeroResult(sm.GetSession("x") == nil)
+		}),
+		newNilReceiverTestCase("DefaultSessionManager.RemoveSession", func() error {
+			sm.RemoveSession("x")
+			return zeroResult(true)
+		}),
+		newNilReceiverTestCase("DefaultSessionManager.Shutdown", func() error {
+			return sm.Shutdown(context.Background())
+		}),
+		newNilReceiverTestCase("DefaultSessionManager.SetSessionConfig", func() error {
+			return sm.SetSessionConfig(SessionConfig{})
+		}),
+		newNilReceiverTestCase("DefaultSessionManager.SetMetrics", func() error {
+			return sm.SetMetrics(nil)
+		}),
+		newNilReceiverTestCase("DefaultSessionManager.SetIdentityConflict", func() error {
+			return sm.SetIdentityConflict(IdentityRejectNew)
+		}),
+		newNilReceiverTestCase("DefaultSessionManager.BindIdentity", func() error {
+			return sm.BindIdentity("x", "y")
+		}),
+		newNilReceiverTestCase("DefaultSessionManager.UnbindIdentity", func() error {
+			sm.UnbindIdentity("x")
+			return zeroResult(true)
+		}),
+		newNilReceiverTestCase("DefaultSessionManager.SessionByIdentity", func() error {
+			return zeroResult(sm.SessionByIdentity("y") == nil)
+		}),
+		newNilReceiverTestCase("DefaultSessionManager.ReadStats", func() error {
+			return zeroResult(sm.ReadStats() == ReadStats{})
+		}),
+		newNilReceiverTestCase("DefaultSessionManager.Sessions", func() error {
+			return zeroResult(sm.Sessions() == nil)
+		}),
+		newNilReceiverTestCase("DefaultSessionManager.CloseSession", func() error {
+			return sm.CloseSession("a")
+		}),
+	}
+}
+
+func nilMessageHandlerTestCases() []nilReceiverTestCase {
+	var h *DefaultMessageHandler
+	return []nilReceiverTestCase{
+		newNilReceiverTestCase("DefaultMessageHandler.SetSubscriptionStore", func() error {
+			return h.SetSubscriptionStore(nil, "")
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.RegisterHandler", func() error {
+			return h.RegisterHandler("/x", nil)
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.RegisterHandlerFunc", func() error {
+			return h.RegisterHandlerFunc("/x", nil)
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.UnregisterHandler", func() error {
+			return h.UnregisterHandler("/x")
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.ReplaceHandler", func() error {
+			return h.ReplaceHandler("/x", RequestHandlerFunc(paramHandler))
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.SetMessageTypes", func() error {
+			return h.SetMessageTypes("/x", "a.Req", "a.Resp")
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.Routes", func() error {
+			return zeroResult(h.Routes() == nil)
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.SetAsyncTimeout", func() error {
+			return h.SetAsyncTimeout(0)
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.SetWorkers", func() error {
+			return h.SetWorkers(1, 1)
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.SubscriptionCount", func() error {
+			return zeroResult(h.SubscriptionCount("a") == 0)
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.Features", func() error {
+			return zeroResult(h.Features() == 0)
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.SessionHello", func() error {
+			return zeroResult(h.SessionHello("a") == nil)
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.SessionFeatures", func() error {
+			return zeroResult(h.SessionFeatures("a") == 0)
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.PendingAsync", func() error {
+			return zeroResult(h.PendingAsync() == 0)
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.Group", func() error {
+			return zeroResult(h.Group("/x") == nil)
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.HandleMessage", func() error {
+			return h.HandleMessage(context.Background(), nil, newTestRequest(1, "/x"))
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.Subscribe", func() error {
+			return h.Subscribe(context.Background(), nil, newTestRequest(1, "/x"))
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.Publish", func() error { return h.Publish("/x", nil) }),
+		newNilReceiverTestCase("DefaultMessageHandler.PublishByHash", func() error { return h.PublishByHash(1, nil) }),
+		newNilReceiverTestCase("DefaultMessageHandler.PublishProtobuf", func() error {
+			return h.PublishProtobuf("/x", nil)
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.PublishJSON", func() error { return h.PublishJSON("/x", nil) }),
+		newNilReceiverTestCase("DefaultMessageHandler.PublishFunc", func() error {
+			return h.PublishFunc("/x", func(*ActiveSubscription) ([]byte, bool) { return nil, true })
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.PublishFuncByHash", func() error {
+			return h.PublishFuncByHash(1, func(*ActiveSubscription) ([]byte, bool) { return nil, true })
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.ForceUnsubscribe", func() error {
+			return h.ForceUnsubscribe("a", "/x")
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.ForceUnsubscribeByHash", func() error {
+			return h.ForceUnsubscribeByHash("a", 1)
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.EnableReplay", func() error {
+			return h.EnableReplay("/x", 1)
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.EnableRetain", func() error {
+			return h.EnableRetain("/x", true)
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.SetAckTimeout", func() error {
+			return h.SetAckTimeout(0)
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.SetEventBus", func() error {
+			return h.SetEventBus(nil)
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.AckStats", func() error {
+			return zeroResult(h.AckStats() == AckStats{})
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.SetFilterEvaluator", func() error {
+			return h.SetFilterEvaluator(JSONFieldFilter{})
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.SetPathFilterEvaluator", func() error {
+			return h.SetPathFilterEvaluator("/x", JSONFieldFilter{})
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.Use", func() error { return h.Use() }),
+		newNilReceiverTestCase("InterceptorFunc.Intercept", func() error {
+			var f InterceptorFunc
+			return f.Intercept(context.Background(), nil, nil)
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.SetAuthenticator", func() error {
+			return h.SetAuthenticator(nil)
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.SetMetrics", func() error {
+			return h.SetMetrics(nil)
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.SetIdentityIndex", func() error {
+			return h.SetIdentityIndex(nil)
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.PublishTo", func() error {
+			return h.PublishTo("x", "/x", nil)
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.SetAccess", func() error {
+			return h.SetAccess("/x", RequireRole("admin"))
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.SetSessionIdentity", func() error {
+			return h.SetSessionIdentity("x", nil)
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.SessionIdentity", func() error {
+			return zeroResult(h.SessionIdentity("x") == nil)
+		}),
+		newNilReceiverTestCase("Identity.HasRole", func() error {
+			var id *Identity
+			return zeroResult(!id.HasRole("admin"))
+		}),
+		newNilReceiverTestCase("AuthenticatorFunc.Authenticate", func() error {
+			var f AuthenticatorFunc
+			return f.Authenticate(context.Background(), nil, nil)
+		}),
+		newNilReceiverTestCase("DefaultMessageHandler.RemoveSubscriptionsForSession", func() error {
+			h.RemoveSubscriptionsForSession("x")
+			return zeroResult(true)
+		}),
+	}
+}
+
+func nilRequestContextTestCases() []nilReceiverTestCase {
+	var rc *RequestContext
+	return []nilReceiverTestCase{
+		newNilReceiverTestCase("RequestContext.SendOK", func() error { return rc.SendOK(nil) }),
+		newNilReceiverTestCase("RequestContext.SendError", func() error {
+			return rc.SendError(nanorpc.NanoRPCResponse_STATUS_NOT_FOUND, "")
+		}),
+		newNilReceiverTestCase("RequestContext.SendNotFound", func() error { return rc.SendNotFound("") }),
+		newNilReceiverTestCase("RequestContext.SendBadRequest", func() error { return rc.SendBadRequest("") }),
+		newNilReceiverTestCase("RequestContext.SendUnauthorized", func() error { return rc.SendUnauthorized("") }),
+		newNilReceiverTestCase("RequestContext.SendInternalError", func() error { return rc.SendInternalError("") }),
+		newNilReceiverTestCase("RequestContext.SendJSON", func() error { return rc.SendJSON(nil) }),
+		newNilReceiverTestCase("RequestContext.SendProtobuf", func() error { return rc.SendProtobuf(nil) }),
+		newNilReceiverTestCase("RequestContext.SendCBOR", func() error { return rc.SendCBOR(nil) }),
+		newNilReceiverTestCase("RequestContext.SendChunk", func() error { return rc.SendChunk(nil) }),
+		newNilReceiverTestCase("RequestContext.CloseStream", rc.CloseStream),
+		newNilReceiverTestCase("RequestContext.UnmarshalRequestJSON", func() error {
+			return rc.UnmarshalRequestJSON(nil)
+		}),
+		newNilReceiverTestCase("RequestContext.UnmarshalRequestProtobuf", func() error {
+			return rc.UnmarshalRequestProtobuf(nil)
+		}),
+		newNilReceiverTestCase("RequestContext.UnmarshalRequestCBOR", func() error {
+			return rc.UnmarshalRequestCBOR(nil)
+		}),
+		newNilReceiverTestCase("RequestContext.Complete", func() error { return rc.Complete(nil, nil) }),
+		newNilReceiverTestCase("RequestContext.Forward", func() error { return rc.Forward("/x") }),
+		newNilReceiverTestCase("RequestContext.SetIdentity", func() error { return rc.SetIdentity(nil) }),
+		newNilReceiverTestCase("RequestContext.getters", func() error {
+			return zeroResult(rc.GetRequestID() == 0 && rc.GetData() == nil &&
+				!rc.HasData() && rc.ForwardChain() == nil && rc.Identity() == nil &&
+				rc.Param("id") == "")
+		}),
+	}
+}
+
+func nilManifestLoaderTestCases() []nilReceiverTestCase {
+	var l *ManifestLoader
+	return []nilReceiverTestCase{
+		newNilReceiverTestCase("ManifestLoader.RegisterTemplate", func() error {
+			return l.RegisterTemplate("x", nil)
+		}),
+		newNilReceiverTestCase("ManifestLoader.Apply", func() error { return l.Apply(&Manifest{}) }),
+		newNilReceiverTestCase("ManifestLoader.Load", func() error { return l.Load([]byte(`{}`)) }),
+		newNilReceiverTestCase("ManifestLoader.Watch", func() error {
+			return l.Watch(context.Background(), "x", 0)
+		}),
+		newNilReceiverTestCase("ManifestLoader.Routes", func() error {
+			return zeroResult(l.Routes() == nil && l.AdminHandler() == nil)
+		}),
+	}
+}
+
+func nilGroupTestCases() []nilReceiverTestCase {
+	var g *Group
+	return []nilReceiverTestCase{
+		newNilReceiverTestCase("Group.Use", func() error { return g.Use() }),
+		newNilReceiverTestCase("Group.Handle", func() error { return g.Handle("/x", nil) }),
+		newNilReceiverTestCase("Group.HandleFunc", func() error { return g.HandleFunc("/x", nil) }),
+		newNilReceiverTestCase("Group.SetMessageTypes", func() error {
+			return g.SetMessageTypes("/x", "a.Req", "a.Resp")
+		}),
+		newNilReceiverTestCase("Group.RegisterHandlerFunc", func() error {
+			return g.RegisterHandlerFunc("/x", nil)
+		}),
+		newNilReceiverTestCase("Group.getters", func() error {
+			return zeroResult(g.Group("/y") == nil && g.Prefix() == "" && g.Path("/x") == "/x")
+		}),
+	}
+}
+
+func nilMemoryBusTestCases() []nilReceiverTestCase {
+	var b *MemoryBus
+	return []nilReceiverTestCase{
+		newNilReceiverTestCase("MemoryBus.Publish", func() error { return b.Publish("/x", nil) }),
+		newNilReceiverTestCase("MemoryBus.Subscribe", func() error {
+			_, err := b.Subscribe("/x", func(string, []byte) {})
+			return err
+		}),
+		newNilReceiverTestCase("MemoryBus.Unsubscribe", func() error { return b.Unsubscribe(1) }),
+		newNilReceiverTestCase("MemoryBus.Snapshot", func() error { return zeroResult(b.Snapshot() == nil) }),
+	}
+}
+
+func nilMemoryStoreTestCases() []nilReceiverTestCase {
+	var s *MemoryStore
+	return []nilReceiverTestCase{
+		newNilReceiverTestCase("MemoryStore.PutSession", func() error { return s.PutSession(SessionRecord{}) }),
+		newNilReceiverTestCase("MemoryStore.DeleteSession", func() error { return s.DeleteSession("x") }),
+		newNilReceiverTestCase("MemoryStore.Sessions", func() error {
+			_, err := s.Sessions()
+			return err
+		}),
+		newNilReceiverTestCase("MemoryStore.AddSubscription", func() error {
+			return s.AddSubscription(SubscriptionRecord{})
+		}),
+		newNilReceiverTestCase("MemoryStore.RemoveSubscription", func() error {
+			return s.RemoveSubscription(SubscriptionRecord{})
+		}),
+		newNilReceiverTestCase("MemoryStore.RemoveSessionSubscriptions", func() error {
+			return s.RemoveSessionSubscriptions("x")
+		}),
+		newNilReceiverTestCase("MemoryStore.Subscriptions", func() error {
+			_, err := s.Subscriptions(1)
+			return err
+		}),
+	}
+}
+
+// TestNilReceivers exercises the nil-receiver contract of every exported
+// type in the package.
+func TestNilReceivers(t *testing.T) {
+	t.Run("Server", func(t *testing.T) { core.RunTestCases(t, nilServerTestCases()) })
+	t.Run("DefaultSession", func(t *testing.T) { core.RunTestCases(t, nilSessionTestCases()) })
+	t.Run("DefaultSessionManager", func(t *testing.T) { core.RunTestCases(t, nilSessionManagerTestCases()) })
+	t.Run("DefaultMessageHandler", func(t *testing.T) { core.RunTestCases(t, nilMessageHandlerTestCases()) })
+	t.Run("RequestContext", func(t *testing.T) { core.RunTestCases(t, nilRequestContextTestCases()) })
+	t.Run("ManifestLoader", func(t *testing.T) { core.RunTestCases(t, nilManifestLoaderTestCases()) })
+	t.Run("Group", func(t *testing.T) { core.RunTestCases(t, nilGroupTestCases()) })
+	t.Run("MemoryBus", func(t *testing.T) { core.RunTestCases(t, nilMemoryBusTestCases()) })
+	t.Run("MemoryStore", func(t *testing.T) { core.RunTestCases(t, nilMemoryStoreTestCases()) })
+}
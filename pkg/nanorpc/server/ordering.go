@@ -0,0 +1,217 @@
+package server
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// DefaultStrictOrderTimeout is how long a session in strict order mode
+// holds back later responses waiting for that of an earlier request, when
+// [SessionConfig] doesn't set one.
+const DefaultStrictOrderTimeout = 5 * time.Second
+
+// orderedRequest is a request whose responses are held back until those of
+// the requests received before it are sent.
+type orderedRequest struct {
+	req      *nanorpc.NanoRPCRequest
+	deadline time.Time
+	pending  [][]byte
+	answered bool
+}
+
+// responseOrder sends the responses of a session in the order their
+// requests were received. Messages for requests not being tracked, such as
+// subscription updates after their acknowledgement, are sent right away.
+type responseOrder struct {
+	write    func([]byte) error
+	timer    *time.Timer
+	requests []*orderedRequest
+	timeout  time.Duration
+	mu       sync.Mutex
+}
+
+func newResponseOrder(timeout time.Duration, write func([]byte) error) *responseOrder {
+	if timeout <= 0 {
+		timeout = DefaultStrictOrderTimeout
+	}
+	return &responseOrder{write: write, timeout: timeout}
+}
+
+// track queues a request as it's received, before it's dispatched.
+func (ro *responseOrder) track(req *nanorpc.NanoRPCRequest) {
+	ro.mu.Lock()
+	defer ro.mu.Unlock()
+
+	ro.requests = append(ro.requests, &orderedRequest{
+		req:      req,
+		deadline: time.Now().Add(ro.timeout),
+	})
+}
+
+// send writes an encoded message for req, or holds it back until the
+// responses of earlier requests are sent. final indicates the message
+// answers req.
+func (ro *responseOrder) send(req *nanorpc.NanoRPCRequest, final bool, data []byte) error {
+	ro.mu.Lock()
+	defer ro.mu.Unlock()
+
+	i := slices.IndexFunc(ro.requests, func(r *orderedRequest) bool { return r.req == req })
+	if i < 0 {
+		return ro.write(data)
+	}
+
+	r := ro.requests[i]
+	r.pending = append(r.pending, data)
+	r.answered = r.answered || final
+	return ro.unsafeFlush()
+}
+
+// unsafeFlush writes the held back messages of the oldest requests, up to
+// the first one still unanswered, and arms the timer if any wait behind
+// it. ro.mu must be held.
+func (ro *responseOrder) unsafeFlush() error {
+	for len(ro.requests) > 0 {
+		r := ro.requests[0]
+		if err := ro.unsafeWritePending(r); err != nil {
+			return err
+		}
+
+		if !r.answered {
+			break
+		}
+		ro.requests = ro.requests[1:]
+	}
+
+	if len(ro.requests) > 1 {
+		ro.unsafeArm(time.Until(ro.requests[0].deadline))
+	}
+	return nil
+}
+
+// unsafeWritePending writes the messages held back for r.
+// ro.mu must be held.
+func (ro *responseOrder) unsafeWritePending(r *orderedRequest) error {
+	for len(r.pending) > 0 {
+		data := r.pending[0]
+		r.pending = r.pending[1:]
+		if err := ro.write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ro *responseOrder) unsafeArm(d time.Duration) {
+	if ro.timer == nil {
+		ro.timer = time.AfterFunc(d, ro.expire)
+	} else {
+		ro.timer.Reset(d)
+	}
+}
+
+// expire gives up on the oldest requests left unanswered past their
+// deadline, releasing the responses waiting behind them. Their late
+// responses are sent as they come.
+func (ro *responseOrder) expire() {
+	ro.mu.Lock()
+	defer ro.mu.Unlock()
+
+	now := time.Now()
+	for len(ro.requests) > 0 && !ro.requests[0].answered && now.After(ro.requests[0].deadline) {
+		ro.requests[0].answered = true
+		if err := ro.unsafeFlush(); err != nil {
+			return
+		}
+	}
+}
+
+// release gives up on req, letting the responses waiting behind it go.
+func (ro *responseOrder) release(req *nanorpc.NanoRPCRequest) error {
+	ro.mu.Lock()
+	defer ro.mu.Unlock()
+
+	i := slices.IndexFunc(ro.requests, func(r *orderedRequest) bool { return r.req == req })
+	if i < 0 {
+		return nil
+	}
+
+	ro.requests[i].answered = true
+	return ro.unsafeFlush()
+}
+
+// stop releases the timer when the session ends.
+func (ro *responseOrder) stop() {
+	ro.mu.Lock()
+	defer ro.mu.Unlock()
+
+	if ro.timer != nil {
+		ro.timer.Stop()
+	}
+	ro.requests = nil
+}
+
+// getOrder returns the response order of the session in StrictOrder mode,
+// or nil.
+func (s *DefaultSession) getOrder() *responseOrder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.order == nil && s.config.StrictOrder {
+		s.order = newResponseOrder(s.config.StrictOrderTimeout, s.write)
+	}
+	return s.order
+}
+
+// orderRequest holds back the responses of later requests until req is
+// answered, in StrictOrder mode.
+func (s *DefaultSession) orderRequest(req *nanorpc.NanoRPCRequest) {
+	if ro := s.getOrder(); ro != nil && expectsResponse(req) {
+		ro.track(req)
+	}
+}
+
+// releaseRequest stops holding back responses for req, whose handler
+// failed, in StrictOrder mode.
+func (s *DefaultSession) releaseRequest(req *nanorpc.NanoRPCRequest) {
+	if ro := s.getOrder(); ro != nil {
+		_ = ro.release(req)
+	}
+}
+
+// stopOrder discards the held back responses of a closing session.
+func (s *DefaultSession) stopOrder() {
+	s.mu.Lock()
+	ro := s.order
+	s.mu.Unlock()
+
+	if ro != nil {
+		ro.stop()
+	}
+}
+
+// expectsResponse tells if a request type is answered by the
+// [DefaultMessageHandler], and so holds back later responses until it is.
+func expectsResponse(req *nanorpc.NanoRPCRequest) bool {
+	switch req.GetRequestType() {
+	case nanorpc.NanoRPCRequest_TYPE_PING,
+		nanorpc.NanoRPCRequest_TYPE_REQUEST,
+		nanorpc.NanoRPCRequest_TYPE_SUBSCRIBE:
+		return true
+	default:
+		return false
+	}
+}
+
+// isFinalResponse tells if a message answers its request, as opposed to
+// subscription updates.
+func isFinalResponse(response *nanorpc.NanoRPCResponse) bool {
+	switch response.GetResponseType() {
+	case nanorpc.NanoRPCResponse_TYPE_PONG, nanorpc.NanoRPCResponse_TYPE_RESPONSE:
+		return true
+	default:
+		return false
+	}
+}
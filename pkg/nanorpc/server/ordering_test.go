@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// orderRecorder collects the messages written by a responseOrder.
+type orderRecorder struct {
+	written []string
+	mu      sync.Mutex
+}
+
+func (r *orderRecorder) write(data []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.written = append(r.written, string(data))
+	return nil
+}
+
+func (r *orderRecorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string(nil), r.written...)
+}
+
+func newOrderedRequests(n int) []*nanorpc.NanoRPCRequest {
+	out := make([]*nanorpc.NanoRPCRequest, n)
+	for i := range out {
+		out[i] = &nanorpc.NanoRPCRequest{RequestId: int32(i + 1)}
+	}
+	return out
+}
+
+func TestResponseOrder_HoldsBackLaterResponses(t *testing.T) {
+	rec := &orderRecorder{}
+	ro := newResponseOrder(time.Minute, rec.write)
+	defer ro.stop()
+
+	reqs := newOrderedRequests(3)
+	for _, req := range reqs {
+		ro.track(req)
+	}
+
+	core.AssertNoError(t, ro.send(reqs[1], true, []byte("b")), "send b")
+	core.AssertSliceEqual(t, []string(nil), rec.get(), "b held back")
+
+	core.AssertNoError(t, ro.send(reqs[0], false, []byte("a-update")), "send a update")
+	core.AssertNoError(t, ro.send(nil, false, []byte("untracked")), "send untracked")
+	core.AssertSliceEqual(t, []string{"a-update", "untracked"}, rec.get(), "not held back")
+
+	core.AssertNoError(t, ro.send(reqs[0], true, []byte("a")), "send a")
+	core.AssertNoError(t, ro.send(reqs[2], true, []byte("c")), "send c")
+	core.AssertSliceEqual(t, []string{"a-update", "untracked", "a", "b", "c"}, rec.get(), "in order")
+
+	core.AssertNoError(t, ro.send(reqs[0], false, []byte("a-late")), "send after flush")
+	core.AssertEqual(t, "a-late", rec.get()[5], "sent once flushed")
+}
+
+func TestResponseOrder_Release(t *testing.T) {
+	rec := &orderRecorder{}
+	ro := newResponseOrder(time.Minute, rec.write)
+	defer ro.stop()
+
+	reqs := newOrderedRequests(2)
+	ro.track(reqs[0])
+	ro.track(reqs[1])
+
+	core.AssertNoError(t, ro.send(reqs[1], true, []byte("b")), "send b")
+	core.AssertNoError(t, ro.release(reqs[0]), "release a")
+	core.AssertSliceEqual(t, []string{"b"}, rec.get(), "released")
+}
+
+func TestResponseOrder_Timeout(t *testing.T) {
+	const timeout = 20 * time.Millisecond
+
+	rec := &orderRecorder{}
+	ro := newResponseOrder(timeout, rec.write)
+	defer ro.stop()
+
+	reqs := newOrderedRequests(2)
+	ro.track(reqs[0])
+	ro.track(reqs[1])
+
+	core.AssertNoError(t, ro.send(reqs[1], true, []byte("b")), "send b")
+	core.AssertSliceEqual(t, []string(nil), rec.get(), "held back")
+
+	time.Sleep(5 * timeout)
+	core.AssertSliceEqual(t, []string{"b"}, rec.get(), "released after timeout")
+
+	core.AssertNoError(t, ro.send(reqs[0], true, []byte("a")), "late a")
+	core.AssertSliceEqual(t, []string{"b", "a"}, rec.get(), "late response sent")
+}
+
+// TestDefaultSession_StrictOrder pipelines a request answered from a
+// goroutine and one answered right away, and expects their responses in
+// the order they were sent.
+func TestDefaultSession_StrictOrder(t *testing.T) {
+	handler := NewDefaultMessageHandler(nil)
+	core.AssertMustNoError(t, handler.RegisterHandlerFunc("/slow",
+		func(_ context.Context, rc *RequestContext) error {
+			time.AfterFunc(30*time.Millisecond, func() { _ = rc.SendOK([]byte("slow")) })
+			return nil
+		}), "register slow")
+	core.AssertMustNoError(t, handler.RegisterHandlerFunc("/fast",
+		func(_ context.Context, rc *RequestContext) error {
+			return rc.SendOK([]byte("fast"))
+		}), "register fast")
+
+	server, client := net.Pipe()
+	defer client.Close()
+
+	sm := NewDefaultSessionManager(handler, nil)
+	core.AssertMustNoError(t, sm.SetSessionConfig(SessionConfig{StrictOrder: true}), "config")
+	session := sm.AddSession(server)
+	go func() { _ = session.Handle(context.Background()) }()
+
+	for i, path := range []string{"/slow", "/fast"} {
+		data, err := nanorpc.EncodeRequest(&nanorpc.NanoRPCRequest{
+			RequestId:   int32(i + 1),
+			RequestType: nanorpc.NanoRPCRequest_TYPE_REQUEST,
+			PathOneof:   nanorpc.GetPathOneOfString(path),
+		}, nil)
+		core.AssertMustNoError(t, err, "encode")
+		_, err = client.Write(data)
+		core.AssertMustNoError(t, err, "write %s", path)
+	}
+
+	for _, want := range []string{"slow", "fast"} {
+		response := readResponse(t, client)
+		core.AssertEqual(t, want, string(response.Data), "response")
+	}
+}
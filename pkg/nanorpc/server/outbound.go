@@ -0,0 +1,174 @@
+package server
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"darvaza.org/core"
+	"darvaza.org/slog"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/utils"
+)
+
+// OverflowPolicy tells what a session does with a subscription update when
+// its outbound queue is full, see [SessionConfig].OutboundQueueSize.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits for the queue to have room, holding back the
+	// publisher. The default.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest discards the oldest update queued to make room,
+	// for subscribers that only care about the latest values.
+	OverflowDropOldest
+	// OverflowDisconnect closes the session, failing the update with
+	// [ErrOutboundOverflow].
+	OverflowDisconnect
+)
+
+// outboundQueue holds the encoded subscription updates of a session until
+// a writer goroutine sends them, so slow subscribers don't hold back
+// publishers. It owns the buffers queued, returning them to the pool once
+// written or dropped.
+type outboundQueue struct {
+	s       *DefaultSession
+	queue   chan []byte
+	done    chan struct{}
+	dropped atomic.Uint64
+	policy  OverflowPolicy
+	once    sync.Once
+}
+
+func newOutboundQueue(s *DefaultSession, size int, policy OverflowPolicy) *outboundQueue {
+	q := &outboundQueue{
+		s:      s,
+		queue:  make(chan []byte, size),
+		done:   make(chan struct{}),
+		policy: policy,
+	}
+	go q.run()
+	return q
+}
+
+// run writes the queued updates until the queue is stopped or a write
+// fails, which closes the session.
+func (q *outboundQueue) run() {
+	for {
+		select {
+		case data := <-q.queue:
+			if err := q.s.write(data); err != nil {
+				q.s.LogError(err, nil, "Failed to write subscription update")
+				_ = q.s.Close()
+				return
+			}
+			putBuffer(data)
+		case <-q.done:
+			return
+		}
+	}
+}
+
+// enqueue queues an encoded update, applying the overflow policy if full.
+func (q *outboundQueue) enqueue(data []byte) error {
+	select {
+	case <-q.done:
+		return nanorpc.ErrSessionClosed
+	default:
+	}
+
+	select {
+	case q.queue <- data:
+		return nil
+	default:
+	}
+
+	switch q.policy {
+	case OverflowDropOldest:
+		return q.dropOldest(data)
+	case OverflowDisconnect:
+		q.s.LogWarn(nil, slog.Fields{utils.FieldQueueSize: cap(q.queue)},
+			"Outbound queue full, disconnecting")
+		_ = q.s.Close()
+		return core.QuietWrap(ErrOutboundOverflow, "outbound queue overflow: %d updates queued", cap(q.queue))
+	default:
+		return q.wait(data)
+	}
+}
+
+// wait queues an update once there is room.
+func (q *outboundQueue) wait(data []byte) error {
+	select {
+	case q.queue <- data:
+		return nil
+	case <-q.done:
+		return nanorpc.ErrSessionClosed
+	}
+}
+
+// dropOldest queues an update discarding the oldest ones until there is
+// room.
+func (q *outboundQueue) dropOldest(data []byte) error {
+	for {
+		select {
+		case <-q.done:
+			return nanorpc.ErrSessionClosed
+		case q.queue <- data:
+			return nil
+		default:
+		}
+
+		select {
+		case old := <-q.queue:
+			q.dropped.Add(1)
+			putBuffer(old)
+		default:
+		}
+	}
+}
+
+// stop ends the writer, discarding the updates still queued.
+func (q *outboundQueue) stop() {
+	q.once.Do(func() { close(q.done) })
+}
+
+// getOutbound returns the outbound queue of the session when
+// OutboundQueueSize is set, or nil.
+func (s *DefaultSession) getOutbound() *outboundQueue {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.outbound == nil && s.config.OutboundQueueSize > 0 && !s.closed {
+		s.outbound = newOutboundQueue(s, s.config.OutboundQueueSize, s.config.OverflowPolicy)
+	}
+	return s.outbound
+}
+
+// stopOutbound discards the queued updates of a closing session.
+func (s *DefaultSession) stopOutbound() {
+	s.mu.Lock()
+	q := s.outbound
+	s.closed = true
+	s.mu.Unlock()
+
+	if q != nil {
+		q.stop()
+	}
+}
+
+// DroppedUpdates returns the number of subscription updates discarded by
+// the [OverflowDropOldest] policy.
+func (s *DefaultSession) DroppedUpdates() uint64 {
+	if s == nil {
+		return 0
+	}
+
+	s.mu.Lock()
+	q := s.outbound
+	s.mu.Unlock()
+
+	if q == nil {
+		return 0
+	}
+	return q.dropped.Load()
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// newOutboundTestSession returns a session with an outbound queue, and
+// the client end of its connection, which only moves when read.
+func newOutboundTestSession(t *testing.T, size int, policy OverflowPolicy) (*DefaultSession, net.Conn) {
+	t.Helper()
+
+	server, client := net.Pipe()
+	s := NewDefaultSession(server, nil, nil)
+	s.config = SessionConfig{OutboundQueueSize: size, OverflowPolicy: policy}
+	t.Cleanup(func() {
+		_ = s.Close()
+		_ = client.Close()
+	})
+	return s, client
+}
+
+func sendTestUpdate(s *DefaultSession, i int) error {
+	return s.SendResponse(nil, newUpdateResponse(1, []byte{byte(i)}, uint64(i)))
+}
+
+// TestOutboundQueue_DropOldest verifies a subscriber not reading doesn't
+// hold back updates, and gets the latest one once it does.
+func TestOutboundQueue_DropOldest(t *testing.T) {
+	s, client := newOutboundTestSession(t, 2, OverflowDropOldest)
+
+	for i := 1; i <= 5; i++ {
+		core.AssertMustNoError(t, sendTestUpdate(s, i), "update %d", i)
+	}
+
+	dropped := s.DroppedUpdates()
+	core.AssertTrue(t, dropped >= 2, "dropped %d", dropped)
+
+	var last uint64
+	for range 5 - int(dropped) {
+		last = readResponse(t, client).Sequence
+	}
+	core.AssertEqual(t, uint64(5), last, "latest update")
+}
+
+// TestOutboundQueue_Disconnect verifies a full queue closes the session.
+func TestOutboundQueue_Disconnect(t *testing.T) {
+	s, client := newOutboundTestSession(t, 1, OverflowDisconnect)
+
+	var err error
+	for i := 1; err == nil && i <= 3; i++ {
+		err = sendTestUpdate(s, i)
+	}
+	core.AssertErrorIs(t, err, ErrOutboundOverflow, "overflow")
+	core.AssertErrorIs(t, sendTestUpdate(s, 4), nanorpc.ErrSessionClosed, "after overflow")
+
+	// an update may have been in flight
+	for range 2 {
+		if _, err = client.Read(make([]byte, 1024)); err != nil {
+			break
+		}
+	}
+	core.AssertErrorIs(t, err, io.EOF, "connection closed")
+}
+
+// TestOutboundQueue_Block verifies publishers wait for room in the queue.
+func TestOutboundQueue_Block(t *testing.T) {
+	s, client := newOutboundTestSession(t, 1, OverflowBlock)
+
+	done := make(chan error, 1)
+	go func() {
+		var err error
+		for i := 1; err == nil && i <= 3; i++ {
+			err = sendTestUpdate(s, i)
+		}
+		done <- err
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("updates sent without room in the queue")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	for i := 1; i <= 3; i++ {
+		core.AssertEqual(t, uint64(i), readResponse(t, client).Sequence, "update %d", i)
+	}
+
+	select {
+	case err := <-done:
+		core.AssertNoError(t, err, "updates")
+	case <-time.After(time.Second):
+		t.Fatal("publisher still blocked")
+	}
+	core.AssertEqual(t, uint64(0), s.DroppedUpdates(), "dropped")
+}
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"context"
+)
+
+// AdminPublishRequest is the JSON request data of the handler returned by
+// [DefaultMessageHandler.AdminPublishHandler]. Data is encoded in base64,
+// as encoding/json does with byte slices.
+type AdminPublishRequest struct {
+	Path string `json:"path"`
+	Data []byte `json:"data,omitempty"`
+}
+
+// AdminPublishHandler returns a [RequestHandler] publishing the update
+// described by an [AdminPublishRequest] to the subscribers of its path,
+// for operators to register on an admin path, e.g. to publish from the
+// nanorpc-cli command. It answers STATUS_OK once published. It doesn't
+// check who's asking; wrap it with [RequireAuth] or protect the path with
+// an [Interceptor].
+func (h *DefaultMessageHandler) AdminPublishHandler() RequestHandler {
+	return RequestHandlerFunc(func(_ context.Context, rc *RequestContext) error {
+		var req AdminPublishRequest
+		if err := rc.UnmarshalRequestJSON(&req); err != nil {
+			return rc.SendBadRequest(err.Error())
+		}
+		if req.Path == "" {
+			return rc.SendBadRequest("missing path")
+		}
+
+		if err := h.Publish(req.Path, req.Data); err != nil {
+			return rc.SendInternalError(err.Error())
+		}
+		return rc.SendOK(nil)
+	})
+}
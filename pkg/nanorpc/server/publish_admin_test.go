@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+const pathAdminPublish = "/admin/publish"
+
+var _ core.TestCase = adminPublishTestCase{}
+
+type adminPublishTestCase struct {
+	name    string
+	data    string
+	update  string
+	status  nanorpc.NanoRPCResponse_Status
+	updates int
+}
+
+func (tc adminPublishTestCase) Name() string { return tc.name }
+
+func (tc adminPublishTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	h := NewDefaultMessageHandler(nil)
+	session := newPublishTestSession(t, h)
+	core.AssertMustNoError(t, h.RegisterHandler(pathAdminPublish, h.AdminPublishHandler()),
+		"RegisterHandler")
+
+	admin := newTestSession("admin", 0)
+	req := newTestRequest(7, pathAdminPublish)
+	req.Data = []byte(tc.data)
+	core.AssertNoError(t, h.HandleMessage(context.Background(), admin, req), "HandleMessage")
+
+	resp := admin.GetLastResponse()
+	if core.AssertNotNil(t, resp, "response") {
+		core.AssertEqual(t, tc.status, resp.ResponseStatus, "status")
+	}
+
+	updates := session.GetAllResponses()
+	if core.AssertEqual(t, tc.updates, len(updates), "updates") && tc.updates > 0 {
+		core.AssertEqual(t, tc.update, string(updates[0].Data), "data")
+	}
+}
+
+func newAdminPublishTestCase(name, data string, status nanorpc.NanoRPCResponse_Status,
+	update string, updates int) adminPublishTestCase {
+	return adminPublishTestCase{name: name, data: data, status: status, update: update, updates: updates}
+}
+
+func adminPublishTestCases() []adminPublishTestCase {
+	return []adminPublishTestCase{
+		// "eyJ2YWx1ZSI6MjF9" is base64 for {"value":21}
+		newAdminPublishTestCase("published",
+			`{"path":"/sensors","data":"eyJ2YWx1ZSI6MjF9"}`, nanorpc.NanoRPCResponse_STATUS_OK,
+			`{"value":21}`, 1),
+		newAdminPublishTestCase("no subscribers",
+			`{"path":"/other"}`, nanorpc.NanoRPCResponse_STATUS_OK, "", 0),
+		newAdminPublishTestCase("missing path",
+			`{"data":"eyJ2YWx1ZSI6MjF9"}`, nanorpc.NanoRPCResponse_STATUS_BAD_REQUEST, "", 0),
+		newAdminPublishTestCase("invalid",
+			`{`, nanorpc.NanoRPCResponse_STATUS_BAD_REQUEST, "", 0),
+	}
+}
+
+func TestDefaultMessageHandler_AdminPublishHandler(t *testing.T) {
+	core.RunTestCases(t, adminPublishTestCases())
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"time"
+
+	"darvaza.org/core"
+)
+
+// PublishTransform returns the data of an update for a subscription, or
+// false to skip it, see [DefaultMessageHandler.PublishFunc].
+type PublishTransform func(sub *ActiveSubscription) ([]byte, bool)
+
+// PublishFunc sends an update to the subscribers of a given path with the
+// data fn returns for each, e.g. to convert units, localise, or redact it
+// by the permissions of the session, instead of broadcasting the same
+// bytes. Subscription filters are evaluated against the data of each
+// subscriber. As the data isn't shared, these updates aren't kept for
+// replay nor numbered.
+func (h *DefaultMessageHandler) PublishFunc(path string, fn PublishTransform) error {
+	if h == nil {
+		return core.ErrNilReceiver
+	}
+
+	pathHash, err := h.hashCache.Hash(path)
+	if err != nil {
+		return core.Wrapf(err, "failed to hash path %q", path)
+	}
+
+	return h.PublishFuncByHash(pathHash, fn)
+}
+
+// PublishFuncByHash sends an update to the subscribers of a given path
+// hash with the data fn returns for each, see
+// [DefaultMessageHandler.PublishFunc]. fn is called without holding the
+// lock of the handler, in the order the subscriptions were made.
+func (h *DefaultMessageHandler) PublishFuncByHash(pathHash uint32, fn PublishTransform) error {
+	switch {
+	case h == nil:
+		return core.ErrNilReceiver
+	case fn == nil:
+		return ErrMissingTransform
+	}
+
+	start := time.Now()
+	updates := transformUpdates(h.collectSubscribers(pathHash), fn)
+	updates = h.filterUpdates(pathHash, updates)
+
+	err := h.sendUpdates(pathHash, updates)
+	h.observePublish(pathHash, len(updates), start)
+	return err
+}
+
+// collectSubscribers returns the subscriptions to a path hash the access
+// rules allow.
+func (h *DefaultMessageHandler) collectSubscribers(pathHash uint32) []*ActiveSubscription {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var subs []*ActiveSubscription
+	h.subscriptions.ForEach(pathHash, func(sub *ActiveSubscription) bool {
+		if sub.Session != nil && h.unsafeAllowed(sub.Session, pathHash) {
+			subs = append(subs, sub)
+		}
+		return true
+	})
+	return subs
+}
+
+// transformUpdates builds the updates of the subscriptions fn doesn't
+// skip.
+func transformUpdates(subs []*ActiveSubscription, fn PublishTransform) []pendingUpdate {
+	updates := make([]pendingUpdate, 0, len(subs))
+	for _, sub := range subs {
+		if data, ok := fn(sub); ok {
+			updates = append(updates, pendingUpdate{
+				session: sub.Session,
+				message: newUpdateResponse(sub.RequestID, data, 0),
+				filter:  sub.Filter,
+			})
+		}
+	}
+	return updates
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"darvaza.org/core"
+)
+
+// TestPublishFunc verifies each subscriber receives the data produced
+// for it, and none when skipped.
+func TestPublishFunc(t *testing.T) {
+	h := NewDefaultMessageHandler(nil)
+	celsius := newTestSession(sessionID1, 1001)
+	fahrenheit := newTestSession(sessionID2, 1002)
+	skipped := newTestSession(sessionID3, 1003)
+
+	for i, session := range []*mockSession{celsius, fahrenheit, skipped} {
+		core.AssertMustNoError(t, h.Subscribe(context.Background(), session,
+			newTestSubscribeRequest(int32(i+1), pathSensors, nil)), "Subscribe")
+		session.ClearResponses()
+	}
+
+	var calls int
+	err := h.PublishFunc(pathSensors, func(sub *ActiveSubscription) ([]byte, bool) {
+		calls++
+		switch sub.Session.ID() {
+		case sessionID1:
+			return []byte("20C"), true
+		case sessionID2:
+			return []byte("68F"), true
+		default:
+			return nil, false
+		}
+	})
+	core.AssertMustNoError(t, err, "PublishFunc")
+	core.AssertEqual(t, 3, calls, "calls")
+
+	for _, tc := range []struct {
+		session *mockSession
+		want    string
+		id      int32
+	}{{celsius, "20C", 1}, {fahrenheit, "68F", 2}} {
+		resp := tc.session.GetLastResponse()
+		core.AssertMustNotNil(t, resp, "update")
+		core.AssertEqual(t, tc.id, resp.RequestId, "request_id")
+		core.AssertEqual(t, tc.want, string(resp.Data), "data")
+		core.AssertEqual(t, uint64(0), resp.Sequence, "sequence")
+	}
+	core.AssertEqual(t, 0, len(skipped.GetAllResponses()), "skipped")
+}
+
+// TestPublishFunc_filter verifies filters are evaluated against the data
+// produced for each subscriber.
+func TestPublishFunc_filter(t *testing.T) {
+	h := NewDefaultMessageHandler(nil)
+	core.AssertMustNoError(t, h.SetPathFilterEvaluator(pathSensors, JSONFieldFilter{}), "SetPathFilterEvaluator")
+
+	lab := newTestSession(sessionID1, 1001)
+	hall := newTestSession(sessionID2, 1002)
+	for _, session := range []*mockSession{lab, hall} {
+		core.AssertMustNoError(t, h.Subscribe(context.Background(), session,
+			newTestSubscribeRequest(1, pathSensors, []byte(`{"room":"lab"}`))), "Subscribe")
+		session.ClearResponses()
+	}
+
+	err := h.PublishFunc(pathSensors, func(sub *ActiveSubscription) ([]byte, bool) {
+		if sub.Session.ID() == sessionID1 {
+			return []byte(`{"room":"lab"}`), true
+		}
+		return []byte(`{"room":"hall"}`), true
+	})
+	core.AssertMustNoError(t, err, "PublishFunc")
+	core.AssertEqual(t, 1, len(lab.GetAllResponses()), "lab")
+	core.AssertEqual(t, 0, len(hall.GetAllResponses()), "hall")
+}
+
+func TestPublishFunc_missingTransform(t *testing.T) {
+	h := NewDefaultMessageHandler(nil)
+
+	err := h.PublishFunc(pathSensors, nil)
+	core.AssertErrorIs(t, err, ErrMissingTransform, "PublishFunc")
+	core.AssertTrue(t, IsInvalid(err), "IsInvalid")
+}
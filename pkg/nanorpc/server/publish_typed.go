@@ -0,0 +1,61 @@
+package server
+
+import (
+	"encoding/json"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+)
+
+var _ Publisher = (*DefaultMessageHandler)(nil)
+
+// PublishProtobuf marshals the protobuf message and sends it as an update
+// to all subscribers of a given path, see [DefaultMessageHandler.Publish].
+func (h *DefaultMessageHandler) PublishProtobuf(path string, msg proto.Message) error {
+	if h == nil {
+		return core.ErrNilReceiver
+	}
+
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return core.Wrapf(err, "failed to marshal protobuf update")
+	}
+
+	return h.Publish(path, data)
+}
+
+// PublishJSON marshals the value as JSON and sends it as an update to all
+// subscribers of a given path, see [DefaultMessageHandler.Publish].
+func (h *DefaultMessageHandler) PublishJSON(path string, v any) error {
+	if h == nil {
+		return core.ErrNilReceiver
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return core.Wrapf(err, "failed to marshal JSON update")
+	}
+
+	return h.Publish(path, data)
+}
+
+// Publish marshals v and publishes it on path through p, as protobuf when
+// it's a [proto.Message] and as JSON otherwise, so publishers deal with
+// values instead of their encoding:
+//
+//	err := server.Publish(h, "/sensors/temperature", &pb.Reading{Value: 21.5})
+func Publish[T any](p Publisher, path string, v T) error {
+	var data []byte
+	var err error
+
+	if msg, ok := any(v).(proto.Message); ok {
+		data, err = proto.Marshal(msg)
+	} else {
+		data, err = json.Marshal(v)
+	}
+	if err != nil {
+		return core.Wrapf(err, "failed to marshal update")
+	}
+
+	return p.Publish(path, data)
+}
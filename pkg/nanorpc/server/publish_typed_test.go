@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+type testReading struct {
+	Sensor string  `json:"sensor"`
+	Value  float64 `json:"value"`
+}
+
+// newPublishTestSession subscribes a session to pathSensors.
+func newPublishTestSession(t *testing.T, h *DefaultMessageHandler) *mockSession {
+	t.Helper()
+
+	session := newTestSession(sessionID1, 1001)
+	core.AssertMustNoError(t, h.Subscribe(context.Background(), session,
+		newTestSubscribeRequest(1, pathSensors, nil)), "Subscribe")
+	session.ClearResponses()
+	return session
+}
+
+// lastProtobufUpdate decodes the last update received by a session.
+func lastProtobufUpdate(t *testing.T, session *mockSession) *nanorpc.NanoRPCHello {
+	t.Helper()
+
+	resp := session.GetLastResponse()
+	core.AssertMustNotNil(t, resp, "update")
+	core.AssertEqual(t, nanorpc.NanoRPCResponse_TYPE_UPDATE, resp.ResponseType, "type")
+
+	got := &nanorpc.NanoRPCHello{}
+	core.AssertMustNoError(t, proto.Unmarshal(resp.Data, got), "Unmarshal")
+	return got
+}
+
+// lastJSONUpdate decodes the last update received by a session.
+func lastJSONUpdate(t *testing.T, session *mockSession) testReading {
+	t.Helper()
+
+	resp := session.GetLastResponse()
+	core.AssertMustNotNil(t, resp, "update")
+
+	var got testReading
+	core.AssertMustNoError(t, json.Unmarshal(resp.Data, &got), "Unmarshal")
+	return got
+}
+
+func TestPublishProtobuf(t *testing.T) {
+	h := NewDefaultMessageHandler(nil)
+	session := newPublishTestSession(t, h)
+
+	want := &nanorpc.NanoRPCHello{Version: 1, WillPath: "/bye"}
+	core.AssertMustNoError(t, h.PublishProtobuf(pathSensors, want), "PublishProtobuf")
+	core.AssertTrue(t, proto.Equal(want, lastProtobufUpdate(t, session)), "data")
+}
+
+func TestPublishJSON(t *testing.T) {
+	h := NewDefaultMessageHandler(nil)
+	session := newPublishTestSession(t, h)
+
+	want := testReading{Sensor: "lab", Value: 21.5}
+	core.AssertMustNoError(t, h.PublishJSON(pathSensors, want), "PublishJSON")
+	core.AssertEqual(t, want, lastJSONUpdate(t, session), "data")
+
+	err := h.PublishJSON(pathSensors, make(chan int))
+	core.AssertError(t, err, "PublishJSON unsupported")
+	core.AssertEqual(t, 1, len(session.GetAllResponses()), "updates")
+}
+
+func TestPublish(t *testing.T) {
+	h := NewDefaultMessageHandler(nil)
+	session := newPublishTestSession(t, h)
+
+	hello := &nanorpc.NanoRPCHello{Version: 2}
+	core.AssertMustNoError(t, Publish(h, pathSensors, hello), "Publish protobuf")
+	core.AssertTrue(t, proto.Equal(hello, lastProtobufUpdate(t, session)), "protobuf")
+
+	reading := testReading{Sensor: "hall", Value: 19}
+	core.AssertMustNoError(t, Publish(h, pathSensors, reading), "Publish JSON")
+	core.AssertEqual(t, reading, lastJSONUpdate(t, session), "JSON")
+
+	core.AssertError(t, Publish(h, pathSensors, func() {}), "Publish unsupported")
+	core.AssertEqual(t, 2, len(session.GetAllResponses()), "updates")
+}
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// ReadStats splits the time the read loop of a [DefaultSession] spends on
+// each request frame, telling latency on the link apart from protobuf
+// decoding and from the handlers.
+type ReadStats struct {
+	// Receive is the accumulated time between the first bytes of a frame
+	// arriving and the frame being complete. Frames found whole in the
+	// read buffer take no time, and the idle time between frames isn't
+	// counted.
+	Receive time.Duration
+	// Decode is the accumulated time spent decoding requests.
+	Decode time.Duration
+	// Dispatch is the accumulated time spent in the message handler.
+	Dispatch time.Duration
+	// MaxReceive is the longest Receive time of a single frame.
+	MaxReceive time.Duration
+	// MaxDecode is the longest Decode time of a single frame.
+	MaxDecode time.Duration
+	// MaxDispatch is the longest Dispatch time of a single frame.
+	MaxDispatch time.Duration
+	// Frames counts the requests decoded and dispatched.
+	Frames uint64
+}
+
+// AvgReceive returns the mean time receiving a frame.
+func (s ReadStats) AvgReceive() time.Duration {
+	return average(s.Receive, s.Frames)
+}
+
+// AvgDecode returns the mean time decoding a request.
+func (s ReadStats) AvgDecode() time.Duration {
+	return average(s.Decode, s.Frames)
+}
+
+// AvgDispatch returns the mean time handling a request.
+func (s ReadStats) AvgDispatch() time.Duration {
+	return average(s.Dispatch, s.Frames)
+}
+
+// Add returns the combined statistics of s and other.
+func (s ReadStats) Add(other ReadStats) ReadStats {
+	return ReadStats{
+		Receive:     s.Receive + other.Receive,
+		Decode:      s.Decode + other.Decode,
+		Dispatch:    s.Dispatch + other.Dispatch,
+		MaxReceive:  max(s.MaxReceive, other.MaxReceive),
+		MaxDecode:   max(s.MaxDecode, other.MaxDecode),
+		MaxDispatch: max(s.MaxDispatch, other.MaxDispatch),
+		Frames:      s.Frames + other.Frames,
+	}
+}
+
+func average(total time.Duration, n uint64) time.Duration {
+	if n == 0 {
+		return 0
+	}
+	return total / time.Duration(n)
+}
+
+// readStats accumulates [ReadStats] safely for concurrent use.
+type readStats struct {
+	s  ReadStats
+	mu sync.Mutex
+}
+
+// observe accounts the timing of a frame.
+func (rs *readStats) observe(receive, decode, dispatch time.Duration) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	rs.s = rs.s.Add(ReadStats{
+		Receive:     receive,
+		Decode:      decode,
+		Dispatch:    dispatch,
+		MaxReceive:  receive,
+		MaxDecode:   decode,
+		MaxDispatch: dispatch,
+		Frames:      1,
+	})
+}
+
+func (rs *readStats) get() ReadStats {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	return rs.s
+}
+
+// timedReader records when the first bytes of a frame are read.
+type timedReader struct {
+	first time.Time
+	r     io.Reader
+}
+
+func (tr *timedReader) Read(p []byte) (int, error) {
+	n, err := tr.r.Read(p)
+	if n > 0 && tr.first.IsZero() {
+		tr.first = time.Now()
+	}
+	return n, err
+}
+
+// receiveTime returns the time since the first bytes read for the frame
+// completed at now, and starts timing the next frame.
+func (tr *timedReader) receiveTime(now time.Time) time.Duration {
+	var d time.Duration
+	if !tr.first.IsZero() {
+		d = now.Sub(tr.first)
+	}
+	tr.first = time.Time{}
+	return d
+}
+
+// ReadStats returns the timing of the requests read by the session.
+func (s *DefaultSession) ReadStats() ReadStats {
+	if s == nil {
+		return ReadStats{}
+	}
+	return s.stats.get()
+}
+
+// ReadStats returns the timing of the requests read by all the sessions
+// of the manager, current and removed.
+func (sm *DefaultSessionManager) ReadStats() ReadStats {
+	if sm == nil {
+		return ReadStats{}
+	}
+
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	out := sm.removed
+	for _, session := range sm.sessions {
+		out = out.Add(sessionReadStats(session))
+	}
+	return out
+}
+
+// ReadStats returns the timing of the requests read by the server's
+// sessions, when its session manager keeps it.
+func (s *Server) ReadStats() ReadStats {
+	if s == nil {
+		return ReadStats{}
+	}
+
+	if sm, ok := s.sessionManager.(interface{ ReadStats() ReadStats }); ok {
+		return sm.ReadStats()
+	}
+	return ReadStats{}
+}
+
+// sessionReadStats returns the [ReadStats] of sessions providing them.
+func sessionReadStats(session Session) ReadStats {
+	if s, ok := session.(interface{ ReadStats() ReadStats }); ok {
+		return s.ReadStats()
+	}
+	return ReadStats{}
+}
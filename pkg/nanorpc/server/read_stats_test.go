@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+var _ core.TestCase = readStatsAddTestCase{}
+
+type readStatsAddTestCase struct {
+	name     string
+	a        ReadStats
+	b        ReadStats
+	expected ReadStats
+}
+
+func (tc readStatsAddTestCase) Name() string { return tc.name }
+
+func (tc readStatsAddTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	core.AssertEqual(t, tc.expected, tc.a.Add(tc.b), "a+b")
+	core.AssertEqual(t, tc.expected, tc.b.Add(tc.a), "b+a")
+}
+
+func newReadStatsAddTestCase(name string, a, b, expected ReadStats) readStatsAddTestCase {
+	return readStatsAddTestCase{name: name, a: a, b: b, expected: expected}
+}
+
+func readStatsAddTestCases() []readStatsAddTestCase {
+	one := ReadStats{
+		Receive: 3 * time.Millisecond, Decode: time.Microsecond, Dispatch: 8 * time.Millisecond,
+		MaxReceive: 2 * time.Millisecond, MaxDecode: time.Microsecond, MaxDispatch: 5 * time.Millisecond,
+		Frames: 2,
+	}
+	two := ReadStats{
+		Receive: time.Millisecond, Decode: 4 * time.Microsecond, Dispatch: 2 * time.Millisecond,
+		MaxReceive: time.Millisecond, MaxDecode: 3 * time.Microsecond, MaxDispatch: 2 * time.Millisecond,
+		Frames: 2,
+	}
+	return []readStatsAddTestCase{
+		newReadStatsAddTestCase("empty", ReadStats{}, ReadStats{}, ReadStats{}),
+		newReadStatsAddTestCase("identity", one, ReadStats{}, one),
+		newReadStatsAddTestCase("combined", one, two, ReadStats{
+			Receive: 4 * time.Millisecond, Decode: 5 * time.Microsecond, Dispatch: 10 * time.Millisecond,
+			MaxReceive: 2 * time.Millisecond, MaxDecode: 3 * time.Microsecond, MaxDispatch: 5 * time.Millisecond,
+			Frames: 4,
+		}),
+	}
+}
+
+func TestReadStats_Add(t *testing.T) {
+	core.RunTestCases(t, readStatsAddTestCases())
+}
+
+func TestReadStats_Averages(t *testing.T) {
+	var empty ReadStats
+	core.AssertEqual(t, time.Duration(0), empty.AvgReceive(), "empty receive")
+
+	s := ReadStats{
+		Receive:  4 * time.Millisecond,
+		Decode:   8 * time.Microsecond,
+		Dispatch: 20 * time.Millisecond,
+		Frames:   4,
+	}
+	core.AssertEqual(t, time.Millisecond, s.AvgReceive(), "receive")
+	core.AssertEqual(t, 2*time.Microsecond, s.AvgDecode(), "decode")
+	core.AssertEqual(t, 5*time.Millisecond, s.AvgDispatch(), "dispatch")
+}
+
+// TestDefaultSession_ReadStats splits a frame across two writes and
+// handles it slowly, checking both delays land in their own bucket and
+// survive the session's removal from its manager.
+func TestDefaultSession_ReadStats(t *testing.T) {
+	const delay = 20 * time.Millisecond
+
+	handler := NewDefaultMessageHandler(nil)
+	core.AssertMustNoError(t, handler.RegisterHandlerFunc("/slow",
+		func(_ context.Context, rc *RequestContext) error {
+			time.Sleep(delay)
+			return rc.SendOK(nil)
+		}), "register")
+
+	server, client := net.Pipe()
+	defer client.Close()
+
+	sm := NewDefaultSessionManager(handler, nil)
+	session := sm.AddSession(server).(*DefaultSession)
+	done := make(chan error, 1)
+	go func() { done <- session.Handle(context.Background()) }()
+
+	data, err := nanorpc.EncodeRequest(&nanorpc.NanoRPCRequest{
+		RequestId:   1,
+		RequestType: nanorpc.NanoRPCRequest_TYPE_REQUEST,
+		PathOneof:   nanorpc.GetPathOneOfString("/slow"),
+	}, nil)
+	core.AssertMustNoError(t, err, "encode")
+
+	half := len(data) / 2
+	_, err = client.Write(data[:half])
+	core.AssertMustNoError(t, err, "write head")
+	time.Sleep(delay)
+	_, err = client.Write(data[half:])
+	core.AssertMustNoError(t, err, "write tail")
+
+	buf := make([]byte, 256)
+	_, err = client.Read(buf)
+	core.AssertMustNoError(t, err, "read response")
+	_ = client.Close()
+	<-done
+
+	stats := session.ReadStats()
+	core.AssertEqual(t, uint64(1), stats.Frames, "frames")
+	core.AssertTrue(t, stats.MaxReceive >= delay, "receive %v", stats.MaxReceive)
+	core.AssertTrue(t, stats.MaxDispatch >= delay, "dispatch %v", stats.MaxDispatch)
+	core.AssertTrue(t, stats.Decode < delay, "decode %v", stats.Decode)
+
+	sm.RemoveSession(session.ID())
+	core.AssertEqual(t, stats, sm.ReadStats(), "manager after removal")
+}
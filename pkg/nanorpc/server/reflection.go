@@ -0,0 +1,131 @@
+package server
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// RouteInfo describes a registered path, as listed by
+// [DefaultMessageHandler.Routes].
+type RouteInfo struct {
+	// Path is the registered path or pattern.
+	Path string `json:"path"`
+	// RequestType is the full name of the protobuf message the path
+	// takes, if known.
+	RequestType string `json:"request_type,omitempty"`
+	// ResponseType is the full name of the protobuf message the path
+	// returns, if known.
+	ResponseType string `json:"response_type,omitempty"`
+	// Hash is the hash of Path, to make requests by hash.
+	Hash uint32 `json:"hash"`
+	// Pattern tells if Path is a pattern, see
+	// [DefaultMessageHandler.RegisterHandler].
+	Pattern bool `json:"pattern,omitempty"`
+}
+
+// MessageTypeRegistry records the protobuf messages registered paths take
+// and return, as [DefaultMessageHandler] does, for reflection. [HandlerRegistry]
+// implementations may implement it, see [DescribeTypes].
+type MessageTypeRegistry interface {
+	SetMessageTypes(path string, request, response protoreflect.FullName) error
+}
+
+var _ MessageTypeRegistry = (*DefaultMessageHandler)(nil)
+
+// messageTypes are the protobuf messages a path takes and returns.
+type messageTypes struct {
+	request  protoreflect.FullName
+	response protoreflect.FullName
+}
+
+// DescribeTypes records the messages the handler of path takes and
+// returns on r, if r is a [MessageTypeRegistry], doing nothing otherwise.
+// [RegisterTyped] and the service registration functions generated by
+// protoc-gen-go-nanorpc call it after registering the handler.
+func DescribeTypes(r HandlerRegistry, path string, request, response proto.Message) error {
+	tr, ok := r.(MessageTypeRegistry)
+	if !ok || request == nil || response == nil {
+		return nil
+	}
+
+	reqName := request.ProtoReflect().Descriptor().FullName()
+	respName := response.ProtoReflect().Descriptor().FullName()
+	return tr.SetMessageTypes(path, reqName, respName)
+}
+
+// SetMessageTypes records the protobuf messages the handler of a
+// registered path or pattern takes and returns, listed by
+// [DefaultMessageHandler.Routes], failing with [core.ErrNotExists] if the
+// path isn't registered. They are forgotten when the path is
+// unregistered.
+func (h *DefaultMessageHandler) SetMessageTypes(path string, request, response protoreflect.FullName) error {
+	if h == nil {
+		return core.ErrNilReceiver
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.handlers[path] == nil && h.unsafePatternIndex(path) < 0 {
+		return core.ErrNotExists
+	}
+
+	if h.types == nil {
+		h.types = make(map[string]messageTypes)
+	}
+	h.types[path] = messageTypes{request: request, response: response}
+	return nil
+}
+
+// Routes returns the registered paths and patterns sorted by path, with
+// their hashes and, if known, message types.
+func (h *DefaultMessageHandler) Routes() []RouteInfo {
+	if h == nil {
+		return nil
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	out := make([]RouteInfo, 0, len(h.handlers)+len(h.patterns))
+	for path := range h.handlers {
+		// pinned when registered, so known
+		hash, _ := h.hashCache.Hash(path)
+		out = append(out, h.unsafeRouteInfo(path, hash, false))
+	}
+	for _, p := range h.patterns {
+		out = append(out, h.unsafeRouteInfo(p.path, p.hash, true))
+	}
+
+	slices.SortFunc(out, func(a, b RouteInfo) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return out
+}
+
+func (h *DefaultMessageHandler) unsafeRouteInfo(path string, hash uint32, pattern bool) RouteInfo {
+	types := h.types[path]
+	return RouteInfo{
+		Path:         path,
+		RequestType:  string(types.request),
+		ResponseType: string(types.response),
+		Hash:         hash,
+		Pattern:      pattern,
+	}
+}
+
+// ReflectionHandler returns a [RequestHandler] answering with the JSON
+// list of [DefaultMessageHandler.Routes], for operators to register on an
+// admin path, e.g. /admin/routes for the nanorpc-cli routes command. It
+// doesn't check who's asking; wrap it with [RequireAuth] or protect the
+// path with an [Interceptor].
+func (h *DefaultMessageHandler) ReflectionHandler() RequestHandler {
+	return RequestHandlerFunc(func(_ context.Context, rc *RequestContext) error {
+		return rc.SendJSON(h.Routes())
+	})
+}
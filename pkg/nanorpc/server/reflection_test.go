@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+const helloType = "NanoRPCHello"
+
+func TestDefaultMessageHandler_Routes(t *testing.T) {
+	h := newRouterHandler(t)
+	core.AssertMustNoError(t, RegisterTyped(h, pathTyped, typedEcho), "RegisterTyped")
+
+	routes := h.Routes()
+	core.AssertMustEqual(t, 5, len(routes), "routes")
+	for i, path := range []string{pathEcho, pathTyped, pathDeviceStatus, pathDeviceAny, pathFiles} {
+		core.AssertEqual(t, path, routes[i].Path, "path %d", i)
+
+		hash, err := h.hashCache.Hash(path)
+		core.AssertNoError(t, err, "Hash %s", path)
+		core.AssertEqual(t, hash, routes[i].Hash, "hash %s", path)
+		core.AssertEqual(t, isPathPattern(path), routes[i].Pattern, "pattern %s", path)
+	}
+
+	core.AssertEqual(t, "", routes[0].RequestType, "untyped")
+	core.AssertEqual(t, helloType, routes[1].RequestType, "request type")
+	core.AssertEqual(t, helloType, routes[1].ResponseType, "response type")
+
+	core.AssertMustNoError(t, h.UnregisterHandler(pathTyped), "unregister")
+	core.AssertMustNoError(t, h.RegisterHandlerFunc(pathTyped, paramHandler), "register untyped")
+	core.AssertEqual(t, "", h.Routes()[1].RequestType, "types forgotten")
+}
+
+func TestDefaultMessageHandler_SetMessageTypes(t *testing.T) {
+	h := newRouterHandler(t)
+
+	core.AssertNoError(t, h.SetMessageTypes(pathFiles, helloType, helloType), "pattern")
+	err := h.SetMessageTypes("/missing", helloType, helloType)
+	core.AssertErrorIs(t, err, core.ErrNotExists, "missing")
+
+	core.AssertMustNoError(t, RegisterTyped(h.Group("/v1"), "/hello", typedEcho), "RegisterTyped group")
+	routes := h.Routes()
+	core.AssertMustEqual(t, "/v1/hello", routes[len(routes)-1].Path, "group path")
+	core.AssertEqual(t, helloType, routes[len(routes)-1].RequestType, "group request type")
+
+	var hello nanorpc.NanoRPCHello
+	core.AssertNoError(t, DescribeTypes(plainRegistry{}, "/x", &hello, &hello), "unsupported")
+}
+
+// plainRegistry is a [HandlerRegistry] not keeping message types.
+type plainRegistry struct{}
+
+func (plainRegistry) RegisterHandlerFunc(string, RequestHandlerFunc, ...AccessOption) error {
+	return nil
+}
+
+func TestDefaultMessageHandler_ReflectionHandler(t *testing.T) {
+	h := newRouterHandler(t)
+	core.AssertMustNoError(t, h.RegisterHandler("/admin/routes", h.ReflectionHandler()), "register")
+
+	session := newTestSession("", 0)
+	err := h.HandleMessage(context.Background(), session, newTestRequest(1, "/admin/routes"))
+	core.AssertMustNoError(t, err, "HandleMessage")
+	resp := session.GetLastResponse()
+	core.AssertMustNotNil(t, resp, "response")
+	core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_OK, resp.ResponseStatus, "status")
+
+	var routes []RouteInfo
+	core.AssertMustNoError(t, json.Unmarshal(resp.Data, &routes), "decode")
+	core.AssertMustEqual(t, 5, len(routes), "routes")
+	core.AssertEqual(t, "/admin/routes", routes[0].Path, "first")
+	core.AssertEqual(t, pathFiles, routes[4].Path, "last")
+	core.AssertTrue(t, routes[4].Pattern, "pattern")
+}
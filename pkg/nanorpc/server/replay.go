@@ -0,0 +1,168 @@
+package server
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// replayEntry is a published update kept for catch-up.
+type replayEntry struct {
+	data []byte
+	seq  uint64
+}
+
+// replayBuffer is a bounded ring of the latest updates published on a
+// path, used to catch up subscriptions resumed after a reconnect.
+//
+// Sequences start from the creation time in microseconds, so those issued
+// by a previous run of the server never look resumable.
+type replayBuffer struct {
+	entries []replayEntry
+	seq     uint64 // sequence of the last published update
+	next    int    // oldest entry once the ring is full
+	mu      sync.Mutex
+}
+
+func newReplayBuffer(size int, seq uint64) *replayBuffer {
+	if seq == 0 {
+		seq = uint64(time.Now().UnixMicro())
+	}
+	return &replayBuffer{
+		entries: make([]replayEntry, 0, size),
+		seq:     seq,
+	}
+}
+
+// push records data as the next update on the path and returns its
+// sequence.
+func (b *replayBuffer) push(data []byte) uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	entry := replayEntry{data: slices.Clone(data), seq: b.seq}
+	if len(b.entries) < cap(b.entries) {
+		b.entries = append(b.entries, entry)
+	} else {
+		b.entries[b.next] = entry
+		b.next = (b.next + 1) % len(b.entries)
+	}
+	return b.seq
+}
+
+// since returns the current sequence and the updates published after the
+// given one, oldest first. When the buffer no longer holds all of them,
+// covered is false and only the latest update is returned, as a snapshot.
+func (b *replayBuffer) since(after uint64) (current uint64, entries []replayEntry, covered bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ordered := slices.Concat(b.entries[b.next:], b.entries[:b.next])
+	switch {
+	case after == b.seq:
+		return b.seq, nil, true
+	case after < b.seq && len(ordered) > 0 && ordered[0].seq <= after+1:
+		return b.seq, ordered[after+1-ordered[0].seq:], true
+	case len(ordered) > 0:
+		return b.seq, ordered[len(ordered)-1:], false
+	default:
+		return b.seq, nil, false
+	}
+}
+
+// latest returns the last n updates held as updates for a subscription,
+// oldest first.
+func (b *replayBuffer) latest(requestID int32, n int) []*nanorpc.NanoRPCResponse {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ordered := slices.Concat(b.entries[b.next:], b.entries[:b.next])
+	if n < len(ordered) {
+		ordered = ordered[len(ordered)-n:]
+	}
+
+	updates := make([]*nanorpc.NanoRPCResponse, 0, len(ordered))
+	for _, entry := range ordered {
+		updates = append(updates, newUpdateResponse(requestID, entry.data, entry.seq))
+	}
+	return updates
+}
+
+// catchUp fills the sequence fields of a subscription acknowledgement and
+// returns the updates a subscription resuming after req.ResumeAfter
+// missed, or a snapshot of the latest one when they are no longer held.
+func (b *replayBuffer) catchUp(req *nanorpc.NanoRPCRequest,
+	ack *nanorpc.NanoRPCResponse) []*nanorpc.NanoRPCResponse {
+	current, entries, covered := b.since(req.ResumeAfter)
+
+	ack.Sequence = current
+	if req.ResumeAfter == 0 {
+		// fresh subscription, nothing to catch up
+		return nil
+	}
+
+	ack.Snapshot = !covered
+	updates := make([]*nanorpc.NanoRPCResponse, 0, len(entries))
+	for _, entry := range entries {
+		update := newUpdateResponse(req.RequestId, entry.data, entry.seq)
+		update.Snapshot = !covered
+		updates = append(updates, update)
+	}
+	return updates
+}
+
+// EnableReplay keeps the last size updates published on path, so
+// subscriptions resumed with a resume_after sequence after a reconnect
+// are caught up, or sent the latest update as a snapshot when the gap is
+// larger than the buffer. Updates on the path carry their sequence from
+// then on. A size of zero or less disables replay for the path.
+func (h *DefaultMessageHandler) EnableReplay(path string, size int) error {
+	if h == nil {
+		return core.ErrNilReceiver
+	}
+
+	pathHash, err := h.hashCache.Hash(path)
+	if err != nil {
+		return core.Wrapf(err, "failed to hash path %q", path)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	old := h.replay[pathHash]
+	switch {
+	case size <= 0:
+		delete(h.replay, pathHash)
+	case old == nil:
+		if h.replay == nil {
+			h.replay = make(map[uint32]*replayBuffer)
+		}
+		h.replay[pathHash] = newReplayBuffer(size, 0)
+	case cap(old.entries) != size:
+		// resized buffers start empty but keep counting
+		h.replay[pathHash] = newReplayBuffer(size, old.sequence())
+	}
+	return nil
+}
+
+// sequence returns the sequence of the last published update.
+func (b *replayBuffer) sequence() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.seq
+}
+
+// unsafeRecordUpdate adds data to the replay buffer of the path, if any,
+// and returns its sequence. The caller must hold at least a read lock.
+func (h *DefaultMessageHandler) unsafeRecordUpdate(pathHash uint32, data []byte) uint64 {
+	if buf := h.replay[pathHash]; buf != nil {
+		return buf.push(data)
+	}
+	return 0
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+const replayTestPath = "/sensors/replay"
+
+var _ core.TestCase = replaySinceTestCase{}
+
+type replaySinceTestCase struct {
+	name     string
+	expected []string
+	after    uint64 // offset from the buffer's base sequence
+	covered  bool
+}
+
+func (tc replaySinceTestCase) Name() string { return tc.name }
+
+func (tc replaySinceTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	const base = 1000
+	b := newReplayBuffer(3, base)
+	for _, s := range []string{"a", "b", "c", "d", "e"} {
+		b.push([]byte(s))
+	}
+
+	current, entries, covered := b.since(base + tc.after)
+	core.AssertEqual(t, uint64(base+5), current, "current")
+	core.AssertEqual(t, tc.covered, covered, "covered")
+
+	var data []string // nil when up to date, as expected
+	for i, entry := range entries {
+		data = append(data, string(entry.data))
+		if i > 0 {
+			core.AssertEqual(t, entries[i-1].seq+1, entry.seq, "sequence")
+		}
+	}
+	core.AssertSliceEqual(t, tc.expected, data, "entries")
+}
+
+func newReplaySinceTestCase(name string, after uint64, covered bool, expected ...string) replaySinceTestCase {
+	return replaySinceTestCase{
+		name:     name,
+		expected: expected,
+		after:    after,
+		covered:  covered,
+	}
+}
+
+func replaySinceTestCases() []replaySinceTestCase {
+	return []replaySinceTestCase{
+		newReplaySinceTestCase("up to date", 5, true),
+		newReplaySinceTestCase("one missed", 4, true, "e"),
+		newReplaySinceTestCase("whole buffer", 2, true, "c", "d", "e"),
+		newReplaySinceTestCase("gap too large", 1, false, "e"),
+		newReplaySinceTestCase("ahead of server", 9, false, "e"),
+	}
+}
+
+func TestReplayBufferSince(t *testing.T) {
+	core.RunTestCases(t, replaySinceTestCases())
+}
+
+func newReplayTestHandler(t *testing.T, size int) *DefaultMessageHandler {
+	t.Helper()
+
+	h := NewDefaultMessageHandler(nil)
+	core.AssertMustNoError(t, h.EnableReplay(replayTestPath, size), "EnableReplay")
+	return h
+}
+
+func newResumeRequest(requestID int32, after uint64) *nanorpc.NanoRPCRequest {
+	req := newTestSubscribeRequest(requestID, replayTestPath, nil)
+	req.ResumeAfter = after
+	return req
+}
+
+func TestSubscribeCatchUp(t *testing.T) {
+	h := newReplayTestHandler(t, 4)
+	first := newTestSession("first", 1001)
+
+	core.AssertMustNoError(t, h.Subscribe(context.Background(), first, newResumeRequest(1, 0)), "subscribe")
+	ack := first.GetLastResponse()
+	core.AssertFalse(t, ack.Snapshot, "fresh snapshot")
+	last := ack.Sequence
+
+	for _, s := range []string{"a", "b", "c"} {
+		core.AssertNoError(t, h.Publish(replayTestPath, []byte(s)), "publish")
+	}
+	updates := first.GetAllResponses()[1:]
+	core.AssertEqual(t, 3, len(updates), "live updates")
+	core.AssertEqual(t, last+1, updates[0].Sequence, "first sequence")
+
+	// resume after the first update on a new session
+	second := newTestSession("second", 1002)
+	err := h.Subscribe(context.Background(), second, newResumeRequest(2, updates[0].Sequence))
+	core.AssertMustNoError(t, err, "resume")
+
+	responses := second.GetAllResponses()
+	core.AssertEqual(t, 3, len(responses), "ack and catch-up")
+	core.AssertEqual(t, nanorpc.NanoRPCResponse_TYPE_RESPONSE, responses[0].ResponseType, "ack type")
+	core.AssertEqual(t, updates[2].Sequence, responses[0].Sequence, "ack sequence")
+	core.AssertFalse(t, responses[0].Snapshot, "ack snapshot")
+	core.AssertEqual(t, "b", string(responses[1].Data), "replayed")
+	core.AssertEqual(t, int32(2), responses[2].RequestId, "replay request ID")
+	core.AssertEqual(t, updates[2].Sequence, responses[2].Sequence, "replay sequence")
+}
+
+func TestSubscribeCatchUpSnapshot(t *testing.T) {
+	h := newReplayTestHandler(t, 2)
+	for _, s := range []string{"a", "b", "c", "d"} {
+		core.AssertNoError(t, h.Publish(replayTestPath, []byte(s)), "publish")
+	}
+
+	session := newTestSession("late", 1003)
+	err := h.Subscribe(context.Background(), session, newResumeRequest(1, 1))
+	core.AssertMustNoError(t, err, "resume")
+
+	responses := session.GetAllResponses()
+	core.AssertEqual(t, 2, len(responses), "ack and snapshot")
+	core.AssertTrue(t, responses[0].Snapshot, "ack snapshot")
+	core.AssertTrue(t, responses[1].Snapshot, "update snapshot")
+	core.AssertEqual(t, "d", string(responses[1].Data), "snapshot data")
+	core.AssertEqual(t, responses[0].Sequence, responses[1].Sequence, "snapshot sequence")
+}
+
+func TestEnableReplayDisable(t *testing.T) {
+	h := newReplayTestHandler(t, 2)
+	core.AssertNoError(t, h.EnableReplay(replayTestPath, 0), "disable")
+
+	session := newTestSession("plain", 1004)
+	core.AssertMustNoError(t, h.Subscribe(context.Background(), session, newResumeRequest(1, 5)), "subscribe")
+	core.AssertNoError(t, h.Publish(replayTestPath, []byte("x")), "publish")
+
+	responses := session.GetAllResponses()
+	core.AssertEqual(t, 2, len(responses), "ack and update")
+	core.AssertEqual(t, uint64(0), responses[0].Sequence, "ack sequence")
+	core.AssertEqual(t, uint64(0), responses[1].Sequence, "update sequence")
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 
@@ -8,6 +9,7 @@ import (
 	"google.golang.org/protobuf/proto"
 
 	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/cbor"
 )
 
 // SendOK sends a successful response with optional data
@@ -15,11 +17,16 @@ func (rc *RequestContext) SendOK(data []byte) error {
 	if rc == nil {
 		return core.ErrNilReceiver
 	}
+	if err := rc.reply.answer(); err != nil {
+		return err
+	}
 
 	response := &nanorpc.NanoRPCResponse{
 		RequestId:      rc.Request.RequestId,
 		ResponseType:   nanorpc.NanoRPCResponse_TYPE_RESPONSE,
 		ResponseStatus: nanorpc.NanoRPCResponse_STATUS_OK,
+		Sequence:       rc.chunks,
+		Metadata:       rc.metadata,
 		Data:           data,
 	}
 
@@ -31,6 +38,30 @@ func (rc *RequestContext) SendError(status nanorpc.NanoRPCResponse_Status, messa
 	if rc == nil {
 		return core.ErrNilReceiver
 	}
+	return rc.sendError(status, message, nil)
+}
+
+// SendErr answers with the status, message and detail of err as
+// [nanorpc.AsError] maps it: those of the [nanorpc.Error] it wraps, if
+// any, or STATUS_NOT_FOUND, STATUS_NOT_AUTHORIZED or STATUS_INTERNAL_ERROR
+// by the sentinel it wraps, with its text as message. A nil err answers
+// STATUS_OK without data.
+func (rc *RequestContext) SendErr(err error) error {
+	if rc == nil {
+		return core.ErrNilReceiver
+	}
+
+	e := nanorpc.AsError(err)
+	if e == nil {
+		return rc.SendOK(nil)
+	}
+	return rc.sendError(e.Status, e.Msg, e.Detail)
+}
+
+func (rc *RequestContext) sendError(status nanorpc.NanoRPCResponse_Status, message string, detail []byte) error {
+	if err := rc.reply.answer(); err != nil {
+		return err
+	}
 
 	// Ensure we don't use STATUS_OK for errors
 	if status == nanorpc.NanoRPCResponse_STATUS_OK {
@@ -42,6 +73,9 @@ func (rc *RequestContext) SendError(status nanorpc.NanoRPCResponse_Status, messa
 		ResponseType:    nanorpc.NanoRPCResponse_TYPE_RESPONSE,
 		ResponseStatus:  status,
 		ResponseMessage: message,
+		Sequence:        rc.chunks,
+		Metadata:        rc.metadata,
+		Data:            detail,
 	}
 
 	return rc.Session.SendResponse(rc.Request, response)
@@ -55,13 +89,12 @@ func (rc *RequestContext) SendNotFound(message string) error {
 	return rc.SendError(nanorpc.NanoRPCResponse_STATUS_NOT_FOUND, message)
 }
 
-// SendBadRequest sends a STATUS_INTERNAL_ERROR response (treating as bad request)
-// Note: The protocol doesn't have STATUS_BAD_REQUEST, so we use INTERNAL_ERROR
+// SendBadRequest sends a STATUS_BAD_REQUEST response
 func (rc *RequestContext) SendBadRequest(message string) error {
 	if message == "" {
 		message = "bad request"
 	}
-	return rc.SendError(nanorpc.NanoRPCResponse_STATUS_INTERNAL_ERROR, message)
+	return rc.SendError(nanorpc.NanoRPCResponse_STATUS_BAD_REQUEST, message)
 }
 
 // SendUnauthorized sends a STATUS_NOT_AUTHORIZED response
@@ -80,7 +113,8 @@ func (rc *RequestContext) SendInternalError(message string) error {
 	return rc.SendError(nanorpc.NanoRPCResponse_STATUS_INTERNAL_ERROR, message)
 }
 
-// SendJSON marshals the value as JSON and sends it as a successful response
+// SendJSON marshals the value as JSON and sends it as a successful response,
+// declaring its content type
 func (rc *RequestContext) SendJSON(v any) error {
 	if rc == nil {
 		return core.ErrNilReceiver
@@ -90,6 +124,9 @@ func (rc *RequestContext) SendJSON(v any) error {
 	if err != nil {
 		return core.Wrapf(err, "failed to marshal JSON response")
 	}
+	if err := rc.SetResponseMetadata(nanorpc.MetadataContentType, nanorpc.ContentTypeJSON); err != nil {
+		return err
+	}
 
 	return rc.SendOK(data)
 }
@@ -108,6 +145,52 @@ func (rc *RequestContext) SendProtobuf(msg proto.Message) error {
 	return rc.SendOK(data)
 }
 
+// SendCBOR marshals the value as CBOR and sends it as a successful response,
+// declaring its content type
+func (rc *RequestContext) SendCBOR(v any) error {
+	if rc == nil {
+		return core.ErrNilReceiver
+	}
+
+	data, err := cbor.Marshal(v)
+	if err != nil {
+		return core.Wrapf(err, "failed to marshal CBOR response")
+	}
+	if err := rc.SetResponseMetadata(nanorpc.MetadataContentType, nanorpc.ContentTypeCBOR); err != nil {
+		return err
+	}
+
+	return rc.SendOK(data)
+}
+
+// SendChunk streams a chunk of the response as a TYPE_UPDATE carrying the
+// request_id, numbered by sequence from 1. The stream is ended by
+// CloseStream, or any other Send method, whose TYPE_RESPONSE carries the
+// number of chunks sent as its sequence. SendChunk calls must not overlap.
+func (rc *RequestContext) SendChunk(data []byte) error {
+	if rc == nil {
+		return core.ErrNilReceiver
+	}
+
+	rc.chunks++
+	response := &nanorpc.NanoRPCResponse{
+		RequestId:      rc.Request.RequestId,
+		ResponseType:   nanorpc.NanoRPCResponse_TYPE_UPDATE,
+		ResponseStatus: nanorpc.NanoRPCResponse_STATUS_OK,
+		Sequence:       rc.chunks,
+		Metadata:       rc.metadata,
+		Data:           data,
+	}
+
+	return rc.Session.SendResponse(rc.Request, response)
+}
+
+// CloseStream ends a response streamed by SendChunk with a successful
+// TYPE_RESPONSE without data.
+func (rc *RequestContext) CloseStream() error {
+	return rc.SendOK(nil)
+}
+
 // UnmarshalRequestJSON decodes the request data as JSON into v
 func (rc *RequestContext) UnmarshalRequestJSON(v any) error {
 	if rc == nil {
@@ -142,6 +225,23 @@ func (rc *RequestContext) UnmarshalRequestProtobuf(msg proto.Message) error {
 	return nil
 }
 
+// UnmarshalRequestCBOR decodes the request data as CBOR into v
+func (rc *RequestContext) UnmarshalRequestCBOR(v any) error {
+	if rc == nil {
+		return core.ErrNilReceiver
+	}
+
+	if len(rc.Request.Data) == 0 {
+		return errors.New("request has no data")
+	}
+
+	if err := cbor.Unmarshal(rc.Request.Data, v); err != nil {
+		return core.Wrapf(err, "failed to unmarshal CBOR request")
+	}
+
+	return nil
+}
+
 // GetRequestID returns the request ID
 func (rc *RequestContext) GetRequestID() int32 {
 	if rc == nil || rc.Request == nil {
@@ -162,3 +262,23 @@ func (rc *RequestContext) GetData() []byte {
 func (rc *RequestContext) HasData() bool {
 	return rc != nil && rc.Request != nil && len(rc.Request.Data) > 0
 }
+
+// Param returns a parameter of the path pattern the request matched, e.g.
+// "id" of /devices/{id}/status, or [WildcardParam] for what a trailing
+// wildcard matched. It returns "" when there is no such parameter.
+func (rc *RequestContext) Param(name string) string {
+	if rc == nil {
+		return ""
+	}
+	return rc.params[name]
+}
+
+// Context returns the context of the request, cancelled when the session
+// closes or the handler times out, see [SessionConfig].HandlerTimeout and
+// the timeout_ms of the request.
+func (rc *RequestContext) Context() context.Context {
+	if rc == nil {
+		return context.Background()
+	}
+	return rc.context()
+}
@@ -3,12 +3,14 @@ package server
 import (
 	"encoding/json"
 	"errors"
+	"io/fs"
 	"testing"
 
 	"darvaza.org/core"
 	"google.golang.org/protobuf/proto"
 
 	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/cbor"
 )
 
 // testData is a test struct for JSON marshaling tests
@@ -67,8 +69,8 @@ func (tc *sendOKTestCase) test(t *testing.T) {
 func verifyOKResponse(t *testing.T, rc *RequestContext, expectedData []byte) {
 	t.Helper()
 	session := getSessionFromContext(t, rc)
-	core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_OK, session.lastResponse.ResponseStatus, "status")
-	core.AssertEqual(t, string(expectedData), string(session.lastResponse.Data), "data")
+	core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_OK, session.GetLastResponse().ResponseStatus, "status")
+	core.AssertEqual(t, string(expectedData), string(session.GetLastResponse().Data), "data")
 }
 
 // getSessionFromContext safely extracts the mock session from RequestContext
@@ -157,8 +159,8 @@ func verifyErrorResponse(t *testing.T, rc *RequestContext,
 		expectedStatus = nanorpc.NanoRPCResponse_STATUS_INTERNAL_ERROR
 	}
 
-	core.AssertEqual(t, expectedStatus, session.lastResponse.ResponseStatus, "status")
-	core.AssertEqual(t, expectedMessage, session.lastResponse.ResponseMessage, "message")
+	core.AssertEqual(t, expectedStatus, session.GetLastResponse().ResponseStatus, "status")
+	core.AssertEqual(t, expectedMessage, session.GetLastResponse().ResponseMessage, "message")
 }
 
 // TestRequestContext_SendError tests the SendError method
@@ -180,6 +182,32 @@ func TestRequestContext_SendError(t *testing.T) {
 	}
 }
 
+// TestRequestContext_SendErr tests SendErr answers with the status of the
+// error, and its detail as data
+func TestRequestContext_SendErr(t *testing.T) {
+	var nilRC *RequestContext
+	core.AssertErrorIs(t, nilRC.SendErr(nil), core.ErrNilReceiver, "nil receiver")
+
+	rc := newSendErrorTestCase("").withRequestContext(42).rc
+	err := rc.SendErr(nanorpc.NotAuthorizedf("token expired").WithDetail([]byte("exp")))
+	core.AssertMustNoError(t, err, "SendErr")
+
+	res := getSessionFromContext(t, rc).GetLastResponse()
+	core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_NOT_AUTHORIZED, res.ResponseStatus, "status")
+	core.AssertEqual(t, "token expired", res.ResponseMessage, "message")
+	core.AssertEqual(t, "exp", string(res.Data), "detail")
+
+	rc = newSendErrorTestCase("").withRequestContext(43).rc
+	core.AssertMustNoError(t, rc.SendErr(fs.ErrNotExist), "SendErr fs")
+	res = getSessionFromContext(t, rc).GetLastResponse()
+	core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_NOT_FOUND, res.ResponseStatus, "fs status")
+
+	rc = newSendErrorTestCase("").withRequestContext(44).rc
+	core.AssertMustNoError(t, rc.SendErr(nil), "SendErr nil")
+	res = getSessionFromContext(t, rc).GetLastResponse()
+	core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_OK, res.ResponseStatus, "nil status")
+}
+
 // specificErrorTestCase tests specific error helper methods
 type specificErrorTestCase struct {
 	name           string
@@ -231,13 +259,13 @@ func (tc *specificErrorTestCase) test(t *testing.T) {
 	if !ok {
 		t.Fatal("expected Session to be *mockSession")
 	}
-	core.AssertEqual(t, tc.expectedStatus, session.lastResponse.ResponseStatus, "status")
+	core.AssertEqual(t, tc.expectedStatus, session.GetLastResponse().ResponseStatus, "status")
 
 	expectedMessage := tc.message
 	if expectedMessage == "" && tc.defaultMessage != "" {
 		expectedMessage = tc.defaultMessage
 	}
-	core.AssertEqual(t, expectedMessage, session.lastResponse.ResponseMessage, "message")
+	core.AssertEqual(t, expectedMessage, session.GetLastResponse().ResponseMessage, "message")
 }
 
 // TestRequestContext_SpecificErrors tests specific error helper methods
@@ -255,12 +283,12 @@ func TestRequestContext_SpecificErrors(t *testing.T) {
 		newSpecificErrorTestCase("SendBadRequest with message").
 			withMethod((*RequestContext).SendBadRequest).
 			withMessage("invalid input").
-			expectingStatus(nanorpc.NanoRPCResponse_STATUS_INTERNAL_ERROR),
+			expectingStatus(nanorpc.NanoRPCResponse_STATUS_BAD_REQUEST),
 		newSpecificErrorTestCase("SendBadRequest without message").
 			withMethod((*RequestContext).SendBadRequest).
 			withMessage("").
 			withDefaultMessage("bad request").
-			expectingStatus(nanorpc.NanoRPCResponse_STATUS_INTERNAL_ERROR),
+			expectingStatus(nanorpc.NanoRPCResponse_STATUS_BAD_REQUEST),
 		newSpecificErrorTestCase("SendUnauthorized with message").
 			withMethod((*RequestContext).SendUnauthorized).
 			withMessage("invalid token").
@@ -346,10 +374,12 @@ func (tc *sendJSONTestCase) test(t *testing.T) {
 func verifyJSONResponse(t *testing.T, tc *sendJSONTestCase) {
 	t.Helper()
 	session := getSessionFromContext(t, tc.rc)
-	core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_OK, session.lastResponse.ResponseStatus, "status")
+	core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_OK, session.GetLastResponse().ResponseStatus, "status")
+	md := nanorpc.Metadata(session.GetLastResponse().Metadata)
+	core.AssertEqual(t, nanorpc.ContentTypeJSON, md.ContentType(), "content type")
 
 	if tc.checkStruct {
-		verifyJSONData(t, session.lastResponse.Data, tc.value)
+		verifyJSONData(t, session.GetLastResponse().Data, tc.value)
 	}
 }
 
@@ -443,11 +473,11 @@ func (tc *sendProtobufTestCase) test(t *testing.T) {
 func verifyProtobufResponse(t *testing.T, rc *RequestContext) {
 	t.Helper()
 	session := getSessionFromContext(t, rc)
-	core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_OK, session.lastResponse.ResponseStatus, "status")
+	core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_OK, session.GetLastResponse().ResponseStatus, "status")
 
 	// Verify protobuf data can be unmarshaled
 	var decoded nanorpc.NanoRPCRequest
-	core.AssertNoError(t, proto.Unmarshal(session.lastResponse.Data, &decoded), "unmarshal")
+	core.AssertNoError(t, proto.Unmarshal(session.GetLastResponse().Data, &decoded), "unmarshal")
 }
 
 // TestRequestContext_SendProtobuf tests the SendProtobuf method
@@ -753,8 +783,8 @@ func newErrorPropagationTestCase(name string) *errorPropagationTestCase {
 func (tc *errorPropagationTestCase) withSessionError(err error) *errorPropagationTestCase {
 	tc.sessionErr = err
 	tc.rc = &RequestContext{
-		Session: &mockSessionWithError{
-			sendError: err,
+		Session: &mockSession{
+			SendErr: err,
 		},
 		Request: &nanorpc.NanoRPCRequest{
 			RequestId: 800,
@@ -791,3 +821,71 @@ func TestRequestContext_ErrorPropagation(t *testing.T) {
 
 	t.Run(tc.name, tc.test)
 }
+
+// TestRequestContext_SendChunk streams two chunks and checks they are
+// numbered, and that the responses ending the stream carry their count.
+func TestRequestContext_SendChunk(t *testing.T) {
+	session := newTestSession(sessionID1, 0)
+	rc := &RequestContext{Session: session, Request: newTestRequest(7, pathEcho)}
+
+	core.AssertMustNoError(t, rc.SendChunk([]byte("a")), "first chunk")
+	core.AssertMustNoError(t, rc.SendChunk([]byte("b")), "second chunk")
+	core.AssertMustNoError(t, rc.CloseStream(), "CloseStream")
+	core.AssertMustNoError(t, rc.SendInternalError(""), "late error")
+
+	want := []struct {
+		data     string
+		typ      nanorpc.NanoRPCResponse_Type
+		sequence uint64
+	}{
+		{"a", nanorpc.NanoRPCResponse_TYPE_UPDATE, 1},
+		{"b", nanorpc.NanoRPCResponse_TYPE_UPDATE, 2},
+		{"", nanorpc.NanoRPCResponse_TYPE_RESPONSE, 2},
+		{"", nanorpc.NanoRPCResponse_TYPE_RESPONSE, 2},
+	}
+
+	responses := session.GetAllResponses()
+	if !core.AssertEqual(t, len(want), len(responses), "responses") {
+		return
+	}
+	for i, w := range want {
+		resp := responses[i]
+		core.AssertEqual(t, int32(7), resp.RequestId, "request_id %d", i)
+		core.AssertEqual(t, w.typ, resp.ResponseType, "type %d", i)
+		core.AssertEqual(t, w.sequence, resp.Sequence, "sequence %d", i)
+		core.AssertEqual(t, w.data, string(resp.Data), "data %d", i)
+	}
+
+	// plain responses carry no sequence
+	plain := &RequestContext{Session: session, Request: newTestRequest(8, pathEcho)}
+	core.AssertMustNoError(t, plain.SendOK(nil), "SendOK")
+	core.AssertEqual(t, uint64(0), session.GetLastResponse().Sequence, "plain sequence")
+}
+
+// TestRequestContext_CBOR tests UnmarshalRequestCBOR and SendCBOR round
+// trip a value, named by its JSON tags.
+func TestRequestContext_CBOR(t *testing.T) {
+	in := testData{Name: "test", Value: 42}
+	data, err := cbor.Marshal(in)
+	core.AssertMustNoError(t, err, "Marshal")
+
+	rc := &RequestContext{
+		Session: &mockSession{},
+		Request: &nanorpc.NanoRPCRequest{RequestId: 300, Data: data},
+	}
+
+	var decoded testData
+	core.AssertMustNoError(t, rc.UnmarshalRequestCBOR(&decoded), "UnmarshalRequestCBOR")
+	core.AssertEqual(t, in, decoded, "request")
+	core.AssertMustNoError(t, rc.SendCBOR(decoded), "SendCBOR")
+
+	session := getSessionFromContext(t, rc)
+	core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_OK, session.GetLastResponse().ResponseStatus, "status")
+	var out testData
+	core.AssertMustNoError(t, cbor.Unmarshal(session.GetLastResponse().Data, &out), "Unmarshal")
+	core.AssertEqual(t, in, out, "response")
+
+	rc.Request.Data = nil
+	core.AssertError(t, rc.UnmarshalRequestCBOR(&decoded), "no data")
+	core.AssertError(t, rc.SendCBOR(make(chan int)), "unsupported type")
+}
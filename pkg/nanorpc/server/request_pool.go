@@ -0,0 +1,145 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// requestPool holds the requests sessions decode into when
+// [SessionConfig] ReuseRequests is set, reused once handled.
+var requestPool = sync.Pool{
+	New: func() any { return new(nanorpc.NanoRPCRequest) },
+}
+
+// requestRetainer is implemented by handlers that may keep requests after
+// HandleMessage returns, telling when they do. Sessions don't reuse the
+// requests of handlers retaining them.
+type requestRetainer interface {
+	retainsRequests() bool
+}
+
+// retainsRequests tells if requests are handed to workers, outliving
+// HandleMessage.
+func (h *DefaultMessageHandler) retainsRequests() bool {
+	return h.getWorkers() != nil
+}
+
+// requestKeeper is implemented by sessions that recycle requests, told
+// when a handler keeps one after returning, see [ErrAsync].
+type requestKeeper interface {
+	keepRequest(req *nanorpc.NanoRPCRequest)
+}
+
+// keepRequest marks req as still in use once its handler returns, so
+// it's left out of the pool.
+func (s *DefaultSession) keepRequest(req *nanorpc.NanoRPCRequest) {
+	s.kept.Store(req)
+}
+
+// doneRequest finishes with a request once handled, unless its handler
+// still uses it or a worker handles it, see finishRequest.
+func (s *DefaultSession) doneRequest(req *nanorpc.NanoRPCRequest, pooled bool) {
+	if s.handsOffRequests() || s.kept.CompareAndSwap(req, nil) {
+		// finished by the worker, or forgotten once answered
+		return
+	}
+	s.forgetRequest(req, pooled)
+}
+
+// finishRequest finishes with a request once a worker handled it,
+// releasing the responses held back for it if its handler failed, and
+// forgetting it unless its handler still uses it.
+func (s *DefaultSession) finishRequest(req *nanorpc.NanoRPCRequest, err error) {
+	if err != nil {
+		s.releaseRequest(req)
+	}
+	if !s.kept.CompareAndSwap(req, nil) {
+		// requests handed off are never pooled, see reusesRequests
+		s.forgetRequest(req, false)
+	}
+}
+
+// forgetRequest forgets when a handled request was received, recycling it
+// if pooled.
+func (s *DefaultSession) forgetRequest(req *nanorpc.NanoRPCRequest, pooled bool) {
+	if s.tracksReceived() {
+		s.setReceived(req, time.Time{})
+	}
+	if pooled {
+		recycleRequest(req)
+	}
+}
+
+// handsOffRequests tells if the handler passes requests on, to be
+// handled after HandleMessage returns.
+func (s *DefaultSession) handsOffRequests() bool {
+	r, ok := s.handler.(requestRetainer)
+	return ok && r.retainsRequests()
+}
+
+// decodeRequest unframes, decodes and decompresses a request, into a
+// pooled message if asked, to be recycled once handled. Malformed frames
+// fail, never panic.
+func (cfg *SessionConfig) decodeRequest(frame []byte, pooled bool) (*nanorpc.NanoRPCRequest, error) {
+	var req *nanorpc.NanoRPCRequest
+	if pooled {
+		req, _ = requestPool.Get().(*nanorpc.NanoRPCRequest)
+	}
+	if req == nil {
+		req = new(nanorpc.NanoRPCRequest)
+	}
+
+	if err := cfg.unmarshalRequest(frame, req); err != nil {
+		if pooled {
+			recycleRequest(req)
+		}
+		return nil, err
+	}
+	return req, nil
+}
+
+// unmarshalRequest unframes, decodes and decompresses a frame into req,
+// reporting panics as [nanorpc.ErrInvalidFrame].
+func (cfg *SessionConfig) unmarshalRequest(frame []byte, req *nanorpc.NanoRPCRequest) (err error) {
+	defer recoverDecode(&err)
+
+	msg, err := cfg.codec().Unframe(frame)
+	if err == nil {
+		err = nanorpc.UnmarshalRequestTo(msg, req)
+	}
+	if err == nil {
+		err = nanorpc.DecompressRequest(req, cfg.maxMessageSize())
+	}
+	return err
+}
+
+// recoverDecode reports a panic decoding a malformed frame as
+// [nanorpc.ErrInvalidFrame], so it fails the session and not the server.
+func recoverDecode(err *error) {
+	if v := recover(); v != nil {
+		*err = core.QuietWrap(nanorpc.ErrInvalidFrame, "decode panicked: %v", v)
+	}
+}
+
+// recycleRequest returns a pooled request to the pool once handled. It
+// must no longer be referenced.
+func recycleRequest(req *nanorpc.NanoRPCRequest) {
+	if cap(req.Data) > maxPooledBuffer {
+		return
+	}
+	requestPool.Put(req)
+}
+
+// reusesRequests tells if requests are decoded into pooled messages, as
+// ReuseRequests asks unless responses are held back in StrictOrder mode,
+// keyed by request, or the handler retains them.
+func (s *DefaultSession) reusesRequests() bool {
+	if !s.config.ReuseRequests || s.config.StrictOrder {
+		return false
+	}
+	return !s.handsOffRequests()
+}
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+var _ core.TestCase = reusesRequestsTestCase{}
+
+type reusesRequestsTestCase struct {
+	name    string
+	config  SessionConfig
+	workers int
+	want    bool
+}
+
+func (tc reusesRequestsTestCase) Name() string { return tc.name }
+
+func (tc reusesRequestsTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	h := NewDefaultMessageHandler(nil)
+	core.AssertMustNoError(t, h.SetWorkers(tc.workers, 0), "SetWorkers")
+	defer func() { _ = h.SetWorkers(0, 0) }()
+
+	s, _ := newSharedTestSession(h)
+	s.config = tc.config
+	core.AssertEqual(t, tc.want, s.reusesRequests(), "reusesRequests")
+}
+
+func newReusesRequestsTestCase(name string, config SessionConfig, workers int,
+	want bool) reusesRequestsTestCase {
+	return reusesRequestsTestCase{
+		name:    name,
+		config:  config,
+		workers: workers,
+		want:    want,
+	}
+}
+
+func reusesRequestsTestCases() []reusesRequestsTestCase {
+	return []reusesRequestsTestCase{
+		newReusesRequestsTestCase("default", SessionConfig{}, 0, false),
+		newReusesRequestsTestCase("reuse", SessionConfig{ReuseRequests: true}, 0, true),
+		newReusesRequestsTestCase("strict order",
+			SessionConfig{ReuseRequests: true, StrictOrder: true}, 0, false),
+		newReusesRequestsTestCase("workers", SessionConfig{ReuseRequests: true}, 2, false),
+	}
+}
+
+func TestDefaultSession_reusesRequests(t *testing.T) {
+	core.RunTestCases(t, reusesRequestsTestCases())
+}
+
+// encodeTestFrame encodes a request for a session to read.
+func encodeTestFrame(t testing.TB, req *nanorpc.NanoRPCRequest) []byte {
+	t.Helper()
+
+	data, err := nanorpc.EncodeRequest(req, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestDefaultSession_ReuseRequests(t *testing.T) {
+	ctx := context.Background()
+	h := NewDefaultMessageHandler(nil)
+	s, _ := newSharedTestSession(h)
+	s.config.ReuseRequests = true
+
+	var paths []string
+	core.AssertMustNoError(t, h.RegisterHandlerFunc("/echo", func(_ context.Context, rc *RequestContext) error {
+		paths = append(paths, rc.Path)
+		return nil
+	}), "RegisterHandlerFunc")
+
+	sub := newTestSubscribeRequest(1, pathSensors, []byte("filter"))
+	core.AssertMustNoError(t, s.decodeAndHandle(ctx, encodeTestFrame(t, sub), 0), "subscribe")
+	for i := range 3 {
+		req := newTestSubscribeRequest(int32(i+2), "/echo", []byte("overwrite"))
+		req.RequestType = nanorpc.NanoRPCRequest_TYPE_REQUEST
+		core.AssertMustNoError(t, s.decodeAndHandle(ctx, encodeTestFrame(t, req), 0), "request")
+	}
+
+	// subscriptions don't share the data of reused requests
+	core.AssertSliceEqual(t, []string{"/echo", "/echo", "/echo"}, paths, "handled")
+	pathHash, err := h.hashCache.Hash(pathSensors)
+	core.AssertMustNoError(t, err, "Hash")
+	h.subscriptions.ForEach(pathHash, func(sub *ActiveSubscription) bool {
+		core.AssertEqual(t, "filter", string(sub.Filter), "filter")
+		return true
+	})
+
+	core.AssertError(t, s.decodeAndHandle(ctx, []byte{2, 8, 0x80}, 0), "malformed")
+}
+
+func TestDefaultSession_ReuseRequestsAsync(t *testing.T) {
+	ctx := context.Background()
+	h := NewDefaultMessageHandler(nil)
+	s, _ := newSharedTestSession(h)
+	s.config.ReuseRequests = true
+
+	var pending *RequestContext
+	core.AssertMustNoError(t, h.RegisterHandlerFunc("/async", func(_ context.Context, rc *RequestContext) error {
+		if pending == nil {
+			pending = rc
+			return ErrAsync
+		}
+		return nil
+	}), "RegisterHandlerFunc")
+
+	for i := range 3 {
+		req := newTestSubscribeRequest(int32(i+1), "/async", []byte("data"))
+		req.RequestType = nanorpc.NanoRPCRequest_TYPE_REQUEST
+		core.AssertMustNoError(t, s.decodeAndHandle(ctx, encodeTestFrame(t, req), 0), "request")
+	}
+
+	// the pending request isn't recycled into the later ones
+	if core.AssertNotNil(t, pending, "pending") {
+		core.AssertEqual(t, int32(1), pending.Request.GetRequestId(), "request ID")
+		core.AssertNil(t, s.kept.Load(), "kept")
+		core.AssertNoError(t, pending.SendOK(nil), "SendOK")
+	}
+}
+
+// BenchmarkDefaultSession_decodeAndHandle reads requests handled by a
+// no-op handler, with and without reusing them.
+func BenchmarkDefaultSession_decodeAndHandle(b *testing.B) {
+	for _, reuse := range []bool{false, true} {
+		name := "decode"
+		if reuse {
+			name = "reuse"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			ctx := context.Background()
+			h := NewDefaultMessageHandler(nil)
+			if err := h.RegisterHandlerFunc("/bench", func(context.Context, *RequestContext) error {
+				return nil
+			}); err != nil {
+				b.Fatal(err)
+			}
+
+			s := NewDefaultSession(&discardConn{mockConn{remoteAddr: "127.0.0.1:12345"}}, h, nil)
+			s.config.ReuseRequests = reuse
+			req := newTestSubscribeRequest(1, "/bench", make([]byte, 64))
+			req.RequestType = nanorpc.NanoRPCRequest_TYPE_REQUEST
+			data := encodeTestFrame(b, req)
+
+			b.ReportAllocs()
+			for b.Loop() {
+				if err := s.decodeAndHandle(ctx, data, 0); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// panicCodec is a [nanorpc.Codec] panicking on Unframe, standing for
+// decoding bugs triggered by malformed frames.
+type panicCodec struct{ nanorpc.LengthPrefixCodec }
+
+func (panicCodec) Unframe([]byte) ([]byte, error) { panic("malformed") }
+
+func TestSessionConfig_decodeRequestRecovers(t *testing.T) {
+	for _, pooled := range []bool{false, true} {
+		cfg := SessionConfig{Codec: panicCodec{}}
+		req, err := cfg.decodeRequest([]byte{0}, pooled)
+		core.AssertErrorIs(t, err, nanorpc.ErrInvalidFrame, "error")
+		core.AssertNil(t, req, "request")
+	}
+}
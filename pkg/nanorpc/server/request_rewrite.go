@@ -0,0 +1,48 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/utils"
+)
+
+// RequestRewriter adjusts a decoded request in place before it's
+// dispatched, e.g. moving a legacy path, filling defaults or translating
+// old fields, so older clients can be served without touching the
+// handlers. An error drops the request as a handler error would.
+type RequestRewriter func(ctx context.Context, session Session, req *nanorpc.NanoRPCRequest) error
+
+// rewriteRequest applies the configured [RequestRewriter], if any,
+// logging the paths of requests it moves.
+func (s *DefaultSession) rewriteRequest(ctx context.Context, req *nanorpc.NanoRPCRequest) error {
+	rewrite := s.config.OnRequestDecoded
+	if rewrite == nil {
+		return nil
+	}
+
+	before := requestPathName(req)
+	if err := rewrite(ctx, s, req); err != nil {
+		return core.Wrap(err, "rewrite")
+	}
+
+	if after := requestPathName(req); after != before {
+		fields := utils.RequestFields(req)
+		fields[utils.FieldPathBefore] = utils.LogPath(before)
+		fields[utils.FieldPathAfter] = utils.LogPath(after)
+		s.LogInfo(fields, "Request path rewritten")
+	}
+	return nil
+}
+
+// requestPathName returns the path of a request, or its hash as 0x%08x
+// when sent as path_hash.
+func requestPathName(req *nanorpc.NanoRPCRequest) string {
+	if oneof, ok := req.GetPathOneof().(*nanorpc.NanoRPCRequest_PathHash); ok {
+		return fmt.Sprintf("0x%08x", oneof.PathHash)
+	}
+	return req.GetPath()
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+const pathLegacyEcho = "/v1/echo"
+
+var errRewriteRejected = errors.New("rejected")
+
+// migrateLegacyEcho moves requests for [pathLegacyEcho] to [pathEcho],
+// rejecting those sent as path_hash.
+func migrateLegacyEcho(_ context.Context, _ Session, req *nanorpc.NanoRPCRequest) error {
+	switch {
+	case req.GetPathHash() != 0:
+		return errRewriteRejected
+	case req.GetPath() == pathLegacyEcho:
+		req.PathOneof = nanorpc.GetPathOneOfString(pathEcho)
+	}
+	return nil
+}
+
+// handleRewritten runs a request through a session rewriting it with
+// [migrateLegacyEcho], returning what was written back.
+func handleRewritten(t *testing.T, req *nanorpc.NanoRPCRequest) []byte {
+	t.Helper()
+
+	handler := NewDefaultMessageHandler(nil)
+	core.AssertMustNoError(t, handler.RegisterHandlerFunc(pathEcho, echoChainHandler), "register")
+
+	sm := NewDefaultSessionManager(handler, nil)
+	core.AssertMustNoError(t, sm.SetSessionConfig(SessionConfig{OnRequestDecoded: migrateLegacyEcho}), "config")
+
+	data, err := nanorpc.EncodeRequest(req, nil)
+	core.AssertMustNoError(t, err, "encode")
+
+	conn := &mockConn{remoteAddr: "127.0.0.1:12345", data: data}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_ = sm.AddSession(conn).Handle(ctx)
+	return conn.writeData
+}
+
+func TestSessionConfig_OnRequestDecoded(t *testing.T) {
+	for _, path := range []string{pathLegacyEcho, pathEcho} {
+		resp, _, err := nanorpc.DecodeResponse(handleRewritten(t, newTestRequest(7, path)))
+		core.AssertMustNoError(t, err, "decode %s", path)
+		core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_OK, resp.ResponseStatus, "status %s", path)
+	}
+
+	// rejected requests aren't dispatched
+	written := handleRewritten(t, newTestRequest(8, uint32(42)))
+	core.AssertEqual(t, 0, len(written), "rejected response")
+}
+
+func TestRequestPathName(t *testing.T) {
+	core.AssertEqual(t, pathEcho, requestPathName(newTestRequest(1, pathEcho)), "path")
+	core.AssertEqual(t, "0x0000002a", requestPathName(newTestRequest(1, uint32(42))), "hash")
+	core.AssertEqual(t, "", requestPathName(&nanorpc.NanoRPCRequest{}), "none")
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"slices"
+	"sync"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// retainedValue is the last update published on a path, sent to new
+// subscriptions.
+type retainedValue struct {
+	data []byte
+	seq  uint64
+	mu   sync.Mutex
+	set  bool
+}
+
+// store replaces the retained update. Empty updates are liveness
+// heartbeats and leave it unchanged.
+func (v *retainedValue) store(data []byte, seq uint64) {
+	if len(data) == 0 {
+		return
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.data = slices.Clone(data)
+	v.seq = seq
+	v.set = true
+}
+
+// update returns the retained update for a subscription, or nil if
+// nothing has been published yet.
+func (v *retainedValue) update(requestID int32) *nanorpc.NanoRPCResponse {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if !v.set {
+		return nil
+	}
+	return newUpdateResponse(requestID, v.data, v.seq)
+}
+
+// EnableRetain keeps the last non-empty update published on path and
+// sends it to every new subscription right after the acknowledgement,
+// like MQTT retained messages, so subscribers start from the current
+// state. Subscriptions asking for history on a path with replay enabled
+// receive the buffered updates instead, see
+// [DefaultMessageHandler.EnableReplay]. Disabling forgets the value.
+func (h *DefaultMessageHandler) EnableRetain(path string, enable bool) error {
+	if h == nil {
+		return core.ErrNilReceiver
+	}
+
+	pathHash, err := h.hashCache.Hash(path)
+	if err != nil {
+		return core.Wrapf(err, "failed to hash path %q", path)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	switch {
+	case !enable:
+		delete(h.retained, pathHash)
+	case h.retained[pathHash] == nil:
+		if h.retained == nil {
+			h.retained = make(map[uint32]*retainedValue)
+		}
+		h.retained[pathHash] = &retainedValue{}
+	}
+	return nil
+}
+
+// unsafeRetain keeps data as the retained update of the path, if enabled.
+// The caller must hold at least a read lock.
+func (h *DefaultMessageHandler) unsafeRetain(pathHash uint32, data []byte, seq uint64) {
+	if v := h.retained[pathHash]; v != nil {
+		v.store(data, seq)
+	}
+}
+
+// unsafeHistory returns the updates a fresh subscription is sent after
+// its acknowledgement: the latest req.History buffered on the path, or
+// else its retained update. The caller must hold at least a read lock.
+func (h *DefaultMessageHandler) unsafeHistory(req *nanorpc.NanoRPCRequest,
+	pathHash uint32) []*nanorpc.NanoRPCResponse {
+	if buf := h.replay[pathHash]; buf != nil && req.History > 0 {
+		return buf.latest(req.RequestId, int(req.History))
+	}
+
+	if v := h.retained[pathHash]; v != nil {
+		if update := v.update(req.RequestId); update != nil {
+			return []*nanorpc.NanoRPCResponse{update}
+		}
+	}
+	return nil
+}
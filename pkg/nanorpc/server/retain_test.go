@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+const retainTestPath = "/sensors/retained"
+
+func newRetainTestHandler(t *testing.T) *DefaultMessageHandler {
+	t.Helper()
+
+	h := NewDefaultMessageHandler(nil)
+	core.AssertMustNoError(t, h.EnableRetain(retainTestPath, true), "EnableRetain")
+	return h
+}
+
+// responseData returns the data of the responses following the
+// acknowledgement.
+func responseData(responses []*nanorpc.NanoRPCResponse) []string {
+	var data []string
+	for _, res := range responses[1:] {
+		data = append(data, string(res.Data))
+	}
+	return data
+}
+
+func TestSubscribeRetained(t *testing.T) {
+	h := newRetainTestHandler(t)
+
+	empty := newTestSession("empty", 1001)
+	req := newTestSubscribeRequest(1, retainTestPath, nil)
+	core.AssertMustNoError(t, h.Subscribe(context.Background(), empty, req), "subscribe before publishing")
+	core.AssertEqual(t, 1, len(empty.GetAllResponses()), "ack only")
+
+	for _, s := range []string{"a", "b", ""} {
+		core.AssertNoError(t, h.Publish(retainTestPath, []byte(s)), "publish")
+	}
+
+	session := newTestSession("late", 1002)
+	req = newTestSubscribeRequest(2, retainTestPath, nil)
+	core.AssertMustNoError(t, h.Subscribe(context.Background(), session, req), "subscribe")
+
+	responses := session.GetAllResponses()
+	core.AssertSliceEqual(t, []string{"b"}, responseData(responses), "retained")
+	core.AssertEqual(t, nanorpc.NanoRPCResponse_TYPE_UPDATE, responses[1].ResponseType, "type")
+	core.AssertEqual(t, int32(2), responses[1].RequestId, "request ID")
+}
+
+func TestSubscribeHistory(t *testing.T) {
+	h := newReplayTestHandler(t, 3)
+	for _, s := range []string{"a", "b", "c", "d", "e"} {
+		core.AssertNoError(t, h.Publish(replayTestPath, []byte(s)), "publish")
+	}
+
+	for i, tc := range []struct {
+		expected []string
+		history  uint32
+	}{
+		{history: 0},
+		{history: 2, expected: []string{"d", "e"}},
+		{history: 9, expected: []string{"c", "d", "e"}},
+	} {
+		session := newTestSession("history", 1003)
+		req := newTestSubscribeRequest(int32(i+1), replayTestPath, nil)
+		req.History = tc.history
+		core.AssertMustNoError(t, h.Subscribe(context.Background(), session, req), "subscribe %d", tc.history)
+
+		responses := session.GetAllResponses()
+		core.AssertSliceEqual(t, tc.expected, responseData(responses), "history %d", tc.history)
+		if n := len(responses); n > 1 {
+			core.AssertEqual(t, responses[0].Sequence, responses[n-1].Sequence, "latest sequence")
+		}
+	}
+}
+
+func TestSubscribeHistoryRetained(t *testing.T) {
+	h := newRetainTestHandler(t)
+	core.AssertNoError(t, h.Publish(retainTestPath, []byte("a")), "publish")
+
+	// without a replay buffer the history is the retained update
+	session := newTestSession("history", 1004)
+	req := newTestSubscribeRequest(1, retainTestPath, nil)
+	req.History = 4
+	core.AssertMustNoError(t, h.Subscribe(context.Background(), session, req), "subscribe")
+	core.AssertSliceEqual(t, []string{"a"}, responseData(session.GetAllResponses()), "retained")
+}
+
+func TestEnableRetainDisable(t *testing.T) {
+	h := newRetainTestHandler(t)
+	core.AssertNoError(t, h.Publish(retainTestPath, []byte("a")), "publish")
+	core.AssertNoError(t, h.EnableRetain(retainTestPath, false), "disable")
+
+	session := newTestSession("plain", 1005)
+	req := newTestSubscribeRequest(1, retainTestPath, nil)
+	core.AssertMustNoError(t, h.Subscribe(context.Background(), session, req), "subscribe")
+	core.AssertEqual(t, 1, len(session.GetAllResponses()), "ack only")
+}
@@ -0,0 +1,194 @@
+package server
+
+import (
+	"strings"
+
+	"darvaza.org/core"
+)
+
+// WildcardParam is the name of the parameter holding what a trailing
+// wildcard matched, see [RequestContext.Param].
+const WildcardParam = "*"
+
+// routePattern is a handler registered for a path with parameters, like
+// /devices/{id}/status, or ending in a wildcard, like /files/*.
+type routePattern struct {
+	handler  RequestHandler
+	path     string
+	segments []string
+	hash     uint32 // of path, keying its access rules
+	wildcard bool
+}
+
+// route is what a request path resolves to.
+type route struct {
+	handler RequestHandler
+	params  map[string]string
+	path    string // as registered
+	hash    uint32 // keying its access rules
+}
+
+// isPathPattern tells if a path has parameters or a wildcard segment.
+func isPathPattern(path string) bool {
+	for _, s := range strings.Split(path, "/") {
+		if s == WildcardParam || strings.ContainsAny(s, "{}") {
+			return true
+		}
+	}
+	return false
+}
+
+// newRoutePattern parses a path pattern. Parameters take whole segments,
+// named once each, and the wildcard can only be the last segment.
+func newRoutePattern(path string, handler RequestHandler) (*routePattern, error) {
+	segments := strings.Split(path, "/")
+
+	last := len(segments) - 1
+	wildcard := segments[last] == WildcardParam
+	if wildcard {
+		segments = segments[:last]
+	}
+
+	if s, ok := invalidPatternSegment(segments); ok {
+		return nil, core.QuietWrap(ErrInvalidPattern, "path %q: segment %q", path, s)
+	}
+
+	return &routePattern{
+		handler:  handler,
+		path:     path,
+		segments: segments,
+		wildcard: wildcard,
+	}, nil
+}
+
+// invalidPatternSegment returns the first segment that is neither literal
+// nor a parameter, or repeats the name of a previous parameter.
+func invalidPatternSegment(segments []string) (string, bool) {
+	seen := make(map[string]bool)
+	for _, s := range segments {
+		name, ok := paramName(s)
+		switch {
+		case !ok && strings.ContainsAny(s, "{}*"):
+			return s, true
+		case !ok:
+			continue
+		case name == "" || seen[name]:
+			return s, true
+		}
+		seen[name] = true
+	}
+	return "", false
+}
+
+// paramName returns the name of a {name} segment.
+func paramName(segment string) (string, bool) {
+	name, ok := strings.CutPrefix(segment, "{")
+	if !ok {
+		return "", false
+	}
+	name, ok = strings.CutSuffix(name, "}")
+	if !ok || strings.ContainsAny(name, "{}*") {
+		return "", false
+	}
+	return name, true
+}
+
+// match tells if a path matches the pattern, returning its parameters.
+// Parameters match non-empty segments, and the wildcard one or more.
+func (p *routePattern) match(path string) (map[string]string, bool) {
+	segments, ok := p.split(path)
+	if !ok {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for i, want := range p.segments {
+		got := segments[i]
+		if name, ok := paramName(want); ok && got != "" {
+			params[name] = got
+		} else if got != want {
+			return nil, false
+		}
+	}
+	if p.wildcard {
+		params[WildcardParam] = segments[len(p.segments)]
+	}
+	return params, true
+}
+
+// split splits a path into the segments of the pattern, and what the
+// wildcard matches, if it could match.
+func (p *routePattern) split(path string) ([]string, bool) {
+	n := len(p.segments)
+	segments := strings.SplitN(path, "/", n+1)
+	switch {
+	case len(segments) < n:
+		return nil, false
+	case p.wildcard:
+		return segments, len(segments) > n && segments[n] != ""
+	default:
+		return segments, len(segments) == n
+	}
+}
+
+// getRoute resolves a path to its handler, exact paths first, then
+// patterns in the order they were registered. pathHash is that of path.
+func (h *DefaultMessageHandler) getRoute(path string, pathHash uint32) (route, bool) {
+	if path == "" {
+		return route{}, false
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if handler := h.handlers[path]; handler != nil {
+		return route{handler: handler, path: path, hash: pathHash}, true
+	}
+
+	for _, p := range h.patterns {
+		if params, ok := p.match(path); ok {
+			return route{handler: p.handler, params: params, path: p.path, hash: p.hash}, true
+		}
+	}
+	return route{}, false
+}
+
+// unsafeRegisterPattern adds a path pattern, returning the hash keying its
+// access rules. h.mu must be held.
+func (h *DefaultMessageHandler) unsafeRegisterPattern(path string, handler RequestHandler) (uint32, error) {
+	if h.unsafePatternIndex(path) >= 0 {
+		return 0, core.ErrExists
+	}
+
+	p, err := newRoutePattern(path, handler)
+	if err != nil {
+		return 0, err
+	}
+
+	p.hash, err = h.hashCache.Pin(path)
+	if err != nil {
+		return 0, err
+	}
+
+	h.patterns = append(h.patterns, p)
+	return p.hash, nil
+}
+
+// unsafeUnregisterPattern removes a path pattern. h.mu must be held.
+func (h *DefaultMessageHandler) unsafeUnregisterPattern(path string) error {
+	i := h.unsafePatternIndex(path)
+	if i < 0 {
+		return core.ErrNotExists
+	}
+	h.patterns = append(h.patterns[:i:i], h.patterns[i+1:]...)
+	return nil
+}
+
+func (h *DefaultMessageHandler) unsafePatternIndex(path string) int {
+	for i, p := range h.patterns {
+		if p.path == path {
+			return i
+		}
+	}
+	return -1
+}
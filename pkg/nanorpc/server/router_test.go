@@ -0,0 +1,205 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+const (
+	pathDeviceStatus = "/devices/{id}/status"
+	pathDeviceAny    = "/devices/{id}/{attr}"
+	pathFiles        = "/files/*"
+)
+
+// newRouterHandler returns a handler with pathEcho, pathDeviceStatus,
+// pathDeviceAny and pathFiles registered, answering with the value of
+// the "id" parameter.
+func newRouterHandler(t *testing.T, opts ...AccessOption) *DefaultMessageHandler {
+	t.Helper()
+
+	h := NewDefaultMessageHandler(nil)
+	for _, path := range []string{pathEcho, pathDeviceStatus, pathDeviceAny, pathFiles} {
+		core.AssertMustNoError(t, h.RegisterHandlerFunc(path, paramHandler, opts...), "register %s", path)
+	}
+	return h
+}
+
+func paramHandler(_ context.Context, rc *RequestContext) error {
+	return rc.SendOK([]byte(rc.Param("id")))
+}
+
+var _ core.TestCase = routeTestCase{}
+
+type routeTestCase struct {
+	params  map[string]string
+	name    string
+	path    string
+	pattern string
+}
+
+func (tc routeTestCase) Name() string { return tc.name }
+
+func (tc routeTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	h := newRouterHandler(t)
+	r, ok := h.getRoute(tc.path, 0)
+	if tc.pattern == "" {
+		core.AssertFalse(t, ok, "no route")
+		return
+	}
+
+	if !core.AssertTrue(t, ok, "route") {
+		return
+	}
+	core.AssertEqual(t, tc.pattern, r.path, "pattern")
+	core.AssertEqual(t, len(tc.params), len(r.params), "params")
+	for name, want := range tc.params {
+		core.AssertEqual(t, want, r.params[name], "param %s", name)
+	}
+}
+
+func newRouteTestCase(name, path, pattern string, params map[string]string) routeTestCase {
+	return routeTestCase{
+		params:  params,
+		name:    name,
+		path:    path,
+		pattern: pattern,
+	}
+}
+
+func routeTestCases() []routeTestCase {
+	return []routeTestCase{
+		newRouteTestCase("exact", pathEcho, pathEcho, nil),
+		newRouteTestCase("first pattern wins", "/devices/42/status", pathDeviceStatus,
+			map[string]string{"id": "42"}),
+		newRouteTestCase("second pattern", "/devices/42/name", pathDeviceAny,
+			map[string]string{"id": "42", "attr": "name"}),
+		newRouteTestCase("empty parameter", "/devices//status", "", nil),
+		newRouteTestCase("too short", "/devices/42", "", nil),
+		newRouteTestCase("too long", "/devices/42/status/x", "", nil),
+		newRouteTestCase("wildcard", "/files/a", pathFiles,
+			map[string]string{WildcardParam: "a"}),
+		newRouteTestCase("wildcard nested", "/files/a/b/c", pathFiles,
+			map[string]string{WildcardParam: "a/b/c"}),
+		newRouteTestCase("wildcard empty", "/files/", "", nil),
+		newRouteTestCase("wildcard missing", "/files", "", nil),
+		newRouteTestCase("unregistered", pathUnregistered, "", nil),
+		newRouteTestCase("empty", "", "", nil),
+	}
+}
+
+func TestDefaultMessageHandler_getRoute(t *testing.T) {
+	core.RunTestCases(t, routeTestCases())
+}
+
+var _ core.TestCase = invalidPatternTestCase{}
+
+type invalidPatternTestCase struct {
+	name string
+	path string
+}
+
+func (tc invalidPatternTestCase) Name() string { return tc.name }
+
+func (tc invalidPatternTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	h := NewDefaultMessageHandler(nil)
+	err := h.RegisterHandlerFunc(tc.path, paramHandler)
+	core.AssertErrorIs(t, err, ErrInvalidPattern, "register %s", tc.path)
+	core.AssertTrue(t, IsInvalid(err), "IsInvalid")
+
+	_, ok := h.getRoute(tc.path, 0)
+	core.AssertFalse(t, ok, "not registered")
+}
+
+func newInvalidPatternTestCase(name, path string) invalidPatternTestCase {
+	return invalidPatternTestCase{name: name, path: path}
+}
+
+func invalidPatternTestCases() []invalidPatternTestCase {
+	return []invalidPatternTestCase{
+		newInvalidPatternTestCase("unnamed parameter", "/devices/{}/status"),
+		newInvalidPatternTestCase("repeated parameter", "/devices/{id}/{id}"),
+		newInvalidPatternTestCase("unclosed parameter", "/devices/{id/status"),
+		newInvalidPatternTestCase("partial parameter", "/devices/x{id}/status"),
+		newInvalidPatternTestCase("inner wildcard", "/files/*/name"),
+	}
+}
+
+func TestDefaultMessageHandler_RegisterHandler_invalidPattern(t *testing.T) {
+	core.RunTestCases(t, invalidPatternTestCases())
+}
+
+// TestDefaultMessageHandler_patternRequest verifies requests reach pattern
+// handlers by path, and by hash once the path is known.
+func TestDefaultMessageHandler_patternRequest(t *testing.T) {
+	h := newRouterHandler(t)
+
+	session := newTestSession("", 0)
+	err := h.HandleMessage(context.Background(), session, newTestRequest(1, "/devices/42/status"))
+	core.AssertMustNoError(t, err, "HandleMessage")
+	resp := session.GetLastResponse()
+	if core.AssertNotNil(t, resp, "response") {
+		core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_OK, resp.ResponseStatus, "status")
+		core.AssertEqual(t, "42", string(resp.Data), "id")
+	}
+
+	pathHash, err := h.hashCache.Hash("/devices/7/status")
+	core.AssertMustNoError(t, err, "Hash")
+	err = h.HandleMessage(context.Background(), session, newTestRequest(2, pathHash))
+	core.AssertMustNoError(t, err, "HandleMessage by hash")
+	resp = session.GetLastResponse()
+	if core.AssertNotNil(t, resp, "response by hash") {
+		core.AssertEqual(t, "7", string(resp.Data), "id by hash")
+	}
+}
+
+// TestDefaultMessageHandler_patternAccess verifies the access rules of a
+// pattern apply to the paths it matches.
+func TestDefaultMessageHandler_patternAccess(t *testing.T) {
+	h := newRouterHandler(t, RequireRole("admin"))
+
+	session := newTestSession(sessionID1, 0)
+	err := h.HandleMessage(context.Background(), session, newTestRequest(1, "/devices/42/status"))
+	core.AssertMustNoError(t, err, "HandleMessage")
+	if resp := session.GetLastResponse(); core.AssertNotNil(t, resp, "response") {
+		core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_NOT_AUTHORIZED, resp.ResponseStatus, "status")
+	}
+}
+
+func TestDefaultMessageHandler_RegisterHandler_pattern(t *testing.T) {
+	h := newRouterHandler(t)
+
+	err := h.RegisterHandlerFunc(pathDeviceStatus, paramHandler)
+	core.AssertErrorIs(t, err, core.ErrExists, "register again")
+
+	core.AssertNoError(t, h.RegisterHandler(pathDeviceStatus, nil), "unregister")
+	r, ok := h.getRoute("/devices/42/status", 0)
+	if core.AssertTrue(t, ok, "falls to the next pattern") {
+		core.AssertEqual(t, pathDeviceAny, r.path, "pattern")
+	}
+
+	err = h.RegisterHandler(pathDeviceStatus, nil)
+	core.AssertErrorIs(t, err, core.ErrNotExists, "unregister again")
+}
+
+// TestRequestContext_Forward_pattern verifies forwarding to a path matched
+// by a pattern passes on its parameters.
+func TestRequestContext_Forward_pattern(t *testing.T) {
+	h := newRouterHandler(t)
+	core.AssertMustNoError(t, h.RegisterHandlerFunc("/v1/status", newForwardingHandler("/devices/9/status")),
+		"register forward")
+
+	session := newTestSession("", 0)
+	err := h.HandleMessage(context.Background(), session, newTestRequest(1, "/v1/status"))
+	core.AssertMustNoError(t, err, "HandleMessage")
+	if resp := session.GetLastResponse(); core.AssertNotNil(t, resp, "response") {
+		core.AssertEqual(t, "9", string(resp.Data), "id")
+	}
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"io"
+	"net"
+	"sync"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+var (
+	_ Listener     = (*SerialListener)(nil)
+	_ net.Listener = (*SerialListener)(nil)
+)
+
+// SerialListener adapts a serial port, or any [io.ReadWriteCloser] of a
+// link like UART or RS-485, to the [Listener] interface so a [Server] can
+// serve NanoRPC to the device at the other end. It's also a
+// [net.Listener], to be passed to [NewDefaultServer]. The port is served
+// as a single session, over a [nanorpc.SerialConn] framing messages with
+// COBS; once it ends, Accept waits for the listener to be closed.
+type SerialListener struct {
+	conn *nanorpc.SerialConn
+	done chan struct{}
+	once sync.Once
+	mu   sync.Mutex
+
+	accepted bool
+}
+
+// NewSerialListener creates a [SerialListener] serving rwc, which it owns
+// from then on, with name as its address. Returns nil if rwc is nil.
+func NewSerialListener(rwc io.ReadWriteCloser, name string) *SerialListener {
+	if rwc == nil {
+		return nil
+	}
+
+	return &SerialListener{
+		conn: nanorpc.NewSerialConn(rwc, name),
+		done: make(chan struct{}),
+	}
+}
+
+// Accept returns the connection of the port the first time, and waits
+// for the listener to be closed after that.
+func (l *SerialListener) Accept() (net.Conn, error) {
+	if l == nil {
+		return nil, core.ErrNilReceiver
+	}
+
+	l.mu.Lock()
+	first := !l.accepted
+	l.accepted = true
+	l.mu.Unlock()
+
+	if first {
+		select {
+		case <-l.done:
+		default:
+			return l.conn, nil
+		}
+	}
+
+	<-l.done
+	return nil, errListenerClosed(l.Addr())
+}
+
+// Close stops accepting, closing the port unless it was accepted, as the
+// session then owns it.
+func (l *SerialListener) Close() error {
+	if l == nil {
+		return core.ErrNilReceiver
+	}
+
+	var err error
+	l.once.Do(func() {
+		close(l.done)
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if !l.accepted {
+			l.accepted = true
+			err = l.conn.Close()
+		}
+	})
+	return err
+}
+
+// Addr returns the name of the port.
+func (l *SerialListener) Addr() net.Addr {
+	if l == nil {
+		return nil
+	}
+	return l.conn.LocalAddr()
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// TestServer_Serial verifies a port is served as a single session, with
+// line noise between requests ignored.
+func TestServer_Serial(t *testing.T) {
+	port, device := net.Pipe()
+	defer device.Close()
+
+	listener := NewSerialListener(port, "/dev/ttyTEST")
+	core.AssertEqual(t, "serial", listener.Addr().Network(), "network")
+
+	server := NewDefaultServer(listener, nil, nil)
+	serverErr := make(chan error, 1)
+	go func() { serverErr <- server.Serve(context.Background()) }()
+	waitServerReady(t, server)
+	defer shutdownServer(t, server, serverErr)
+
+	conn := nanorpc.NewSerialConn(device, "device")
+	sendPingReceivePong(t, conn)
+
+	_, err := device.Write([]byte{0x55, 0xAA, 0})
+	core.AssertMustNoError(t, err, "write noise")
+
+	sendPingReceivePong(t, conn)
+}
+
+func TestSerialListener_Close(t *testing.T) {
+	port, device := net.Pipe()
+	defer device.Close()
+
+	listener := NewSerialListener(port, "/dev/ttyTEST")
+	core.AssertNoError(t, listener.Close(), "close")
+	core.AssertNoError(t, listener.Close(), "close again")
+
+	_, err := listener.Accept()
+	core.AssertErrorIs(t, err, net.ErrClosed, "accept after close")
+
+	// not accepted, so closed along with the listener
+	_, err = port.Write([]byte{0})
+	core.AssertErrorIs(t, err, io.ErrClosedPipe, "port closed")
+	core.AssertNil(t, NewSerialListener(nil, "x"), "NewSerialListener(nil)")
+}
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"net"
 	"sync"
+	"time"
 
+	"darvaza.org/core"
 	"darvaza.org/slog"
 	"darvaza.org/slog/handlers/discard"
 	"darvaza.org/x/sync/workgroup"
@@ -15,28 +17,55 @@ import (
 
 // Server represents a decoupled NanoRPC server
 type Server struct {
-	listener       Listener
-	sessionManager SessionManager
-	messageHandler MessageHandler
-	logger         slog.Logger
-	ready          chan struct{}
-	wg             workgroup.Group
-	mu             sync.RWMutex
+	listener        Listener
+	listeners       []Listener   // added, see AddListener
+	connFilters     []ConnFilter // see WithConnFilter
+	sessionManager  SessionManager
+	messageHandler  MessageHandler
+	logger          slog.Logger
+	onListenerError ListenerErrorHandler
+	ready           chan struct{}
+	wg              workgroup.Group
+	mu              sync.RWMutex
+	serving         bool
+	workers         bool // started by WithWorkers
+}
+
+// ServerOption configures optional [Server] behaviour at construction.
+type ServerOption func(*Server)
+
+// WithSessionConfig applies cfg to the sessions created by the server when
+// it uses a [DefaultSessionManager]. Other session managers are left
+// untouched.
+func WithSessionConfig(cfg SessionConfig) ServerOption {
+	return func(s *Server) {
+		if sm, ok := s.sessionManager.(*DefaultSessionManager); ok {
+			_ = sm.SetSessionConfig(cfg)
+		}
+	}
 }
 
 // NewServer creates a new decoupled server
 func NewServer(listener Listener, sessionManager SessionManager,
-	messageHandler MessageHandler, logger slog.Logger) *Server {
+	messageHandler MessageHandler, logger slog.Logger, opts ...ServerOption) *Server {
 	// Add server component field to logger using common helper
 	logger = utils.WithComponent(logger, utils.ComponentServer)
 
-	return &Server{
+	s := &Server{
 		listener:       listener,
 		sessionManager: sessionManager,
 		messageHandler: messageHandler,
 		logger:         logger,
 		ready:          make(chan struct{}),
 	}
+	s.linkIdentityIndex()
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(s)
+		}
+	}
+	return s
 }
 
 // Ready returns a channel that is closed once [Server.Serve] has reached the
@@ -45,6 +74,9 @@ func NewServer(listener Listener, sessionManager SessionManager,
 // cancellation signal, since the channel does not close if Serve never
 // progresses past start-up.
 func (s *Server) Ready() <-chan struct{} {
+	if s == nil {
+		return nil
+	}
 	return s.ready
 }
 
@@ -64,18 +96,22 @@ func (s *Server) signalReady() {
 // nil a fresh [DefaultMessageHandler] is constructed; pass an existing one
 // to pre-register paths before [Server.Serve] starts.
 func NewDefaultServer(netListener net.Listener, handler *DefaultMessageHandler,
-	logger slog.Logger) *Server {
+	logger slog.Logger, opts ...ServerOption) *Server {
 	listener := NewListenerAdapter(netListener)
 	if handler == nil {
 		handler = NewDefaultMessageHandler(nil) // Creates new HashCache internally
 	}
 	sessionManager := NewDefaultSessionManager(handler, logger)
 
-	return NewServer(listener, sessionManager, handler, logger)
+	return NewServer(listener, sessionManager, handler, logger, opts...)
 }
 
 // getLogger returns the configured logger or lazily initializes a discard logger
 func (s *Server) getLogger() slog.Logger {
+	if s == nil {
+		return discard.New()
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -85,24 +121,31 @@ func (s *Server) getLogger() slog.Logger {
 	return s.logger
 }
 
-// Serve starts serving requests
+// Serve starts serving requests, accepting connections from the listener
+// given to [NewServer] and those added by [Server.AddListener]. It fails
+// with [ErrMissingListener] if there are none.
 func (s *Server) Serve(ctx context.Context) error {
+	if s == nil {
+		return core.ErrNilReceiver
+	}
+
 	// Configure workgroup
 	s.wg.Parent = ctx
 	s.wg.OnCancel = s.onGroupCancel
 
-	if l, ok := s.WithInfo(); ok {
+	if l, ok := s.WithInfo(); ok && s.listener != nil {
 		l = utils.WithLocalAddr(l, s.listener.Addr())
 		l.Print("Server started")
 	}
 
-	// Start accept loop in workgroup with error catching
-	if err := s.wg.GoCatch(s.acceptLoop, s.catchAcceptError); err != nil {
+	// Start accept loops in workgroup with error catching
+	if err := s.startListeners(); err != nil {
 		return err
 	}
 
 	// Wait for completion or cancellation
 	err := s.wg.Wait()
+	s.stopWorkers()
 
 	// Log final status
 	if err != nil && err != context.Canceled {
@@ -114,22 +157,27 @@ func (s *Server) Serve(ctx context.Context) error {
 	return err
 }
 
-// acceptLoop runs the connection acceptance loop
-func (s *Server) acceptLoop(ctx context.Context) error {
+// acceptLoop runs the connection acceptance loop of a listener, retrying
+// temporary errors after a growing delay.
+func (s *Server) acceptLoop(ctx context.Context, listener Listener) error {
 	s.signalReady()
+
+	var delay time.Duration
 	for {
-		conn, err := s.listener.Accept()
-		if err != nil {
+		err := s.acceptOnce(ctx, listener)
+		if err == nil {
+			delay = 0
+			continue
+		}
+
+		if !s.retryAccept(ctx, err) {
 			return err
 		}
 
-		// Check for cancellation after successful accept
-		select {
-		case <-ctx.Done():
-			_ = conn.Close()
-			return ctx.Err()
-		default:
-			s.handleNewConnection(ctx, conn)
+		delay = nextAcceptDelay(delay)
+		s.LogWarn(err, slog.Fields{utils.FieldDuration: delay.Milliseconds()}, "Accept failed, retrying")
+		if err := sleepAccept(ctx, delay); err != nil {
+			return err
 		}
 	}
 }
@@ -138,6 +186,11 @@ func (s *Server) acceptLoop(ctx context.Context) error {
 func (s *Server) handleNewConnection(_ context.Context, conn net.Conn) {
 	s.logAccept(conn)
 
+	if err := s.filterConn(conn); err != nil {
+		s.rejectConn(conn, err)
+		return
+	}
+
 	session := s.sessionManager.AddSession(conn)
 
 	// Handle session in workgroup with error catching
@@ -155,14 +208,14 @@ func (s *Server) handleNewConnection(_ context.Context, conn net.Conn) {
 
 // Shutdown gracefully shuts down the server
 func (s *Server) Shutdown(ctx context.Context) error {
+	if s == nil {
+		return core.ErrNilReceiver
+	}
+
 	s.LogInfo(nil, "Server shutting down")
 
-	// Close listener to stop accepting new connections
-	if err := s.listener.Close(); err != nil {
-		if l, ok := s.WithWarn(err); ok {
-			l.Print("Failed to close listener")
-		}
-	}
+	// Close listeners to stop accepting new connections
+	s.closeListeners("Failed to close listener")
 
 	// Cancel workgroup to signal all goroutines to stop
 	s.wg.Cancel(context.Canceled)
@@ -178,6 +231,7 @@ func (s *Server) Shutdown(ctx context.Context) error {
 	done := s.wg.Done()
 	select {
 	case <-done:
+		s.stopWorkers()
 		s.LogInfo(nil, "Server shutdown complete")
 		return nil
 	case <-ctx.Done():
@@ -190,12 +244,8 @@ func (s *Server) onGroupCancel(_ context.Context, err error) {
 	if err != nil && err != context.Canceled {
 		s.LogError(err, nil, "Server cancelled with error")
 	}
-	// Ensure listener is closed on cancel
-	if closeErr := s.listener.Close(); closeErr != nil {
-		if l, ok := s.WithWarn(closeErr); ok {
-			l.Print("Failed to close listener during cancel")
-		}
-	}
+	// Ensure listeners are closed on cancel
+	s.closeListeners("Failed to close listener during cancel")
 }
 
 // catchAcceptError filters accept loop errors
@@ -3,11 +3,11 @@ package server
 import (
 	"bufio"
 	"context"
-	"encoding/hex"
 	"fmt"
 	"net"
-	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"darvaza.org/core"
 	"darvaza.org/slog"
@@ -21,11 +21,23 @@ import (
 
 // DefaultSession implements Session interface
 type DefaultSession struct {
-	conn    net.Conn
-	handler MessageHandler
-	logger  slog.Logger
-	id      string
-	mu      sync.Mutex
+	conn     net.Conn
+	handler  MessageHandler
+	logger   slog.Logger
+	received map[*nanorpc.NanoRPCRequest]time.Time
+	id       string
+	order    *responseOrder
+	outbound *outboundQueue
+	dedup    *dedupWindow
+	cancel   context.CancelCauseFunc // of the context of Handle
+	created  time.Time
+	config   SessionConfig
+	stats    readStats
+	kept     atomic.Pointer[nanorpc.NanoRPCRequest] // by its handler, see ErrAsync
+	active   atomic.Int64                           // UnixNano of the last request received
+	mu       sync.Mutex
+	writeMu  sync.Mutex
+	closed   bool
 }
 
 // NewDefaultSession creates a new session
@@ -45,11 +57,16 @@ func NewDefaultSession(conn net.Conn, handler MessageHandler, logger slog.Logger
 		conn:    conn,
 		handler: handler,
 		logger:  sessionLogger,
+		created: time.Now(),
 	}
 }
 
 // getLogger returns the configured logger or lazily initializes a discard logger
 func (s *DefaultSession) getLogger() slog.Logger {
+	if s == nil {
+		return discard.New()
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -61,27 +78,41 @@ func (s *DefaultSession) getLogger() slog.Logger {
 
 // ID returns the session identifier
 func (s *DefaultSession) ID() string {
+	if s == nil {
+		return ""
+	}
 	return s.id
 }
 
 // RemoteAddr returns the remote address
 func (s *DefaultSession) RemoteAddr() string {
-	if s.conn != nil && s.conn.RemoteAddr() != nil {
+	if s != nil && s.conn != nil && s.conn.RemoteAddr() != nil {
 		return s.conn.RemoteAddr().String()
 	}
 	return ""
 }
 
-// Handle processes messages for this session
+// Handle processes messages for this session. Handlers get a context
+// cancelled when the session closes, with [nanorpc.ErrSessionClosed] as
+// cause, and after [SessionConfig].HandlerTimeout or the timeout of the
+// request, whichever is shorter, if set.
 func (s *DefaultSession) Handle(ctx context.Context) error {
+	if s == nil {
+		return core.ErrNilReceiver
+	}
+
+	ctx = s.withContext(ctx)
 	defer s.Close()
 
-	scanner := bufio.NewScanner(s.conn)
-	scanner.Split(nanorpc.Split)
+	tr := &timedReader{r: s.conn}
+	codec, maxSize := s.config.codec(), s.config.maxMessageSize()
+	scanner := bufio.NewScanner(tr)
+	scanner.Buffer(nil, codec.MaxFrameSize(maxSize))
+	scanner.Split(codec.Split(maxSize))
 
 	for {
-		if err := s.processNextMessage(ctx, scanner); err != nil {
-			if err == nanorpc.ErrSessionClosed {
+		if err := s.processNextMessage(ctx, scanner, tr); err != nil {
+			if err == nanorpc.ErrSessionClosed || context.Cause(ctx) == nanorpc.ErrSessionClosed {
 				return nil
 			}
 			return err
@@ -89,8 +120,35 @@ func (s *DefaultSession) Handle(ctx context.Context) error {
 	}
 }
 
+// withContext returns a context cancelled when the session closes.
+func (s *DefaultSession) withContext(ctx context.Context) context.Context {
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	s.mu.Lock()
+	closed := s.closed
+	s.cancel = cancel
+	s.mu.Unlock()
+
+	if closed {
+		cancel(nanorpc.ErrSessionClosed)
+	}
+	return ctx
+}
+
+// cancelContext cancels the context handlers of the session were given.
+func (s *DefaultSession) cancelContext() {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+
+	if cancel != nil {
+		cancel(nanorpc.ErrSessionClosed)
+	}
+}
+
 // processNextMessage reads and processes a single message
-func (s *DefaultSession) processNextMessage(ctx context.Context, scanner *bufio.Scanner) error {
+func (s *DefaultSession) processNextMessage(ctx context.Context, scanner *bufio.Scanner,
+	tr *timedReader) error {
 	// Check context cancellation
 	select {
 	case <-ctx.Done():
@@ -114,90 +172,249 @@ func (s *DefaultSession) processNextMessage(ctx context.Context, scanner *bufio.
 	}
 
 	// Decode and handle
-	return s.decodeAndHandle(ctx, scanner.Bytes())
+	receive := tr.receiveTime(time.Now())
+	return s.decodeAndHandle(ctx, scanner.Bytes(), receive)
 }
 
-// decodeAndHandle decodes a request and passes it to the handler
-func (s *DefaultSession) decodeAndHandle(ctx context.Context, data []byte) error {
-	req, _, err := nanorpc.DecodeRequest(data)
+// decodeAndHandle decodes a request and passes it to the handler,
+// accounting the time spent on each to the session's [ReadStats].
+func (s *DefaultSession) decodeAndHandle(ctx context.Context, data []byte, receive time.Duration) error {
+	start := time.Now()
+	s.touch(start)
+	pooled := s.reusesRequests()
+	req, err := s.config.decodeRequest(data, pooled)
+	decoded := time.Now()
 	if err != nil {
 		s.getLogger().Error().
 			WithField(utils.FieldError, err).
-			WithField("data_length", len(data)).
-			WithField("data_preview", hexDump(data, 32)).
+			WithField(utils.FieldDataSize, utils.LogSizeBucket(len(data))).
+			WithField("data_preview", utils.HexDump(data, 32)).
 			Print("Failed to decode request")
 		return core.Wrap(err, "decode")
 	}
+	defer s.doneRequest(req, pooled)
 
-	if err := s.handler.HandleMessage(ctx, s, req); err != nil {
-		s.getLogger().Error().
-			WithField(utils.FieldRequestID, req.GetRequestId()).
-			WithField(utils.FieldError, err).
-			Print("Handler error")
-		return nil // Continue on handler errors
+	if s.config.CorrelationIDs {
+		nanorpc.EnsureCorrelationID(req)
+	}
+	s.logRequest(req)
+	if s.dedupRequest(req) {
+		return nil
+	}
+	if s.tracksReceived() {
+		s.setReceived(req, decoded)
+	}
+
+	s.orderRequest(req)
+	err = s.rewriteRequest(ctx, req)
+	if err == nil {
+		err = s.dispatch(ctx, req)
+	}
+	s.stats.observe(receive, decoded.Sub(start), time.Since(decoded))
+
+	if err != nil {
+		s.releaseRequest(req)
+		s.LogError(err, utils.RequestFields(req), "Handler error")
+	}
+	return nil // Continue on handler errors
+}
+
+// dispatch passes a request to the handler, within HandlerTimeout or the
+// timeout of the request, whichever is shorter, if set. The timeout
+// context ends when the handler returns, unless it answers asynchronously,
+// see [ErrAsync].
+func (s *DefaultSession) dispatch(ctx context.Context, req *nanorpc.NanoRPCRequest) error {
+	if d := s.handlerTimeout(req); d > 0 {
+		var end func()
+		ctx, end = withHandlerScope(context.WithTimeout(ctx, d))
+		defer end()
 	}
+	return s.handler.HandleMessage(ctx, s, req)
+}
 
-	return nil
+// handlerTimeout returns how long the handler of a request has to answer,
+// the shorter of HandlerTimeout and the timeout the client gave it, or
+// zero if neither is set.
+func (s *DefaultSession) handlerTimeout(req *nanorpc.NanoRPCRequest) time.Duration {
+	d := s.config.HandlerTimeout
+	if ms := req.GetTimeoutMs(); ms > 0 {
+		if t := time.Duration(ms) * time.Millisecond; d <= 0 || t < d {
+			d = t
+		}
+	}
+	return d
 }
 
-// Close closes the session
+// Close closes the session, cancelling the context of its handlers.
 func (s *DefaultSession) Close() error {
+	if s == nil {
+		return core.ErrNilReceiver
+	}
+
+	s.stopOrder()
+	s.stopOutbound()
+	s.cancelContext()
 	return s.conn.Close()
 }
 
 // SendResponse sends a NanoRPC response to the client
 func (s *DefaultSession) SendResponse(req *nanorpc.NanoRPCRequest, response *nanorpc.NanoRPCResponse) error {
+	if s == nil {
+		return core.ErrNilReceiver
+	}
+
 	// Fill envelope fields from request if provided
 	if req != nil && response.RequestId == 0 {
 		response.RequestId = req.RequestId
 	}
 
+	s.prepareResponse(req, response)
+	if err := s.compressResponse(response); err != nil {
+		return err
+	}
+
+	if req == nil {
+		// Subscription updates, encoded into a pooled buffer
+		data, err := nanorpc.AppendResponse(getBuffer(), response)
+		if err != nil {
+			return err
+		}
+		return s.sendBuffer(data)
+	}
+
 	// Encode the response
 	data, err := nanorpc.EncodeResponse(response, nil)
 	if err != nil {
 		return err
 	}
 
-	// Send to client
+	final := isFinalResponse(response)
+	if final {
+		s.keepResponse(req, data)
+	}
+	return s.send(req, final, data)
+}
+
+// sendBuffer sends a subscription update encoded into a buffer from the
+// pool, returning it to the pool once written.
+func (s *DefaultSession) sendBuffer(data []byte) error {
+	if q := s.getOutbound(); q != nil {
+		// returned by the writer
+		return q.enqueue(data)
+	}
+
+	defer putBuffer(data)
+	return s.send(nil, false, data)
+}
+
+// send passes an encoded response to the outbound queue if it's a
+// subscription update, to the ordering in StrictOrder mode, or writes it.
+func (s *DefaultSession) send(req *nanorpc.NanoRPCRequest, final bool, data []byte) error {
+	if req == nil {
+		if q := s.getOutbound(); q != nil {
+			return q.enqueue(data)
+		}
+	}
+	if ro := s.getOrder(); ro != nil {
+		return ro.send(req, final, data)
+	}
+	return s.write(data)
+}
+
+// write sends an encoded message to the client, framed by the Codec of
+// the session. It has a lock of its own so a slow connection doesn't hold
+// back the rest of the session.
+func (s *DefaultSession) write(data []byte) error {
+	if codec := s.config.Codec; !nanorpc.IsLengthPrefixed(codec) {
+		frame, err := nanorpc.AppendFramed(codec, getBuffer(), data)
+		if err != nil {
+			return err
+		}
+		defer putBuffer(frame)
+		data = frame
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	_, err := s.conn.Write(data)
+	return err
+}
+
+// setReceived records when req was decoded, or forgets it when t is zero.
+func (s *DefaultSession) setReceived(req *nanorpc.NanoRPCRequest, t time.Time) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	_, err = s.conn.Write(data)
-	return err
+	switch {
+	case t.IsZero():
+		delete(s.received, req)
+	case s.received == nil:
+		s.received = map[*nanorpc.NanoRPCRequest]time.Time{req: t}
+	default:
+		s.received[req] = t
+	}
 }
 
-// NewSessionID creates a unique session identifier using rs/xid
-func NewSessionID(conn net.Conn) string {
-	id := xid.New().String()
-	if conn != nil {
-		if addr := conn.RemoteAddr(); addr != nil {
-			return fmt.Sprintf("%s@%s", id, addr.String())
-		}
+// prepareResponse applies the [SessionConfig] to a response before it's
+// logged, then logs it.
+func (s *DefaultSession) prepareResponse(req *nanorpc.NanoRPCRequest, response *nanorpc.NanoRPCResponse) {
+	received := s.takeReceived(req, response)
+	if s.config.Timestamps {
+		stampResponse(response, received)
 	}
-	return id
+	if s.config.CorrelationIDs {
+		nanorpc.EchoCorrelationID(req, response)
+	}
+	if s.config.LegacyStatus {
+		s.downgradeStatus(response)
+	}
+	if s.config.OmitErrorData {
+		NormaliseErrorResponse(response)
+	}
+	s.logResponse(req, response, received)
 }
 
-// hexDump returns a hex dump of data up to maxBytes, space-delimited
-func hexDump(data []byte, maxBytes int) string {
-	preview := data
-	if len(preview) > maxBytes {
-		preview = preview[:maxBytes]
+// takeReceived returns when the request a final response answers was
+// decoded, forgetting it, or the zero time if untracked. Updates don't
+// answer any.
+func (s *DefaultSession) takeReceived(req *nanorpc.NanoRPCRequest, response *nanorpc.NanoRPCResponse) time.Time {
+	if req == nil || !isFinalResponse(response) || !s.tracksReceived() {
+		return time.Time{}
 	}
 
-	hexStr := strings.ToUpper(hex.EncodeToString(preview))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	received := s.received[req]
+	delete(s.received, req)
+	return received
+}
 
-	// Add spaces between bytes
-	var spaced strings.Builder
-	for i := 0; i < len(hexStr); i += 2 {
-		if i > 0 {
-			_ = spaced.WriteByte(' ')
+// tracksReceived tells if the session records when requests were
+// decoded, for Timestamps or WireLog.
+func (s *DefaultSession) tracksReceived() bool {
+	return s.config.Timestamps || s.config.WireLog != nil
+}
+
+// stampResponse attaches the server-side timestamps to a response to a
+// request decoded by this session at received. Updates are not stamped.
+func stampResponse(response *nanorpc.NanoRPCResponse, received time.Time) {
+	if !received.IsZero() {
+		response.Timestamps = &nanorpc.NanoRPCTimestamps{
+			ReceivedUs:  uint64(received.UnixMicro()),
+			ProcessedUs: uint64(time.Now().UnixMicro()),
 		}
-		_, _ = spaced.WriteString(hexStr[i : i+2])
 	}
+}
 
-	if len(data) > maxBytes {
-		_, _ = spaced.WriteString(" ...")
+// NewSessionID creates a unique session identifier using rs/xid
+func NewSessionID(conn net.Conn) string {
+	id := xid.New().String()
+	if conn != nil {
+		if addr := conn.RemoteAddr(); addr != nil {
+			return fmt.Sprintf("%s@%s", id, addr.String())
+		}
 	}
-
-	return spaced.String()
+	return id
 }
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"strings"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/utils"
+)
+
+// SessionConfig holds optional behaviour applied to every [DefaultSession]
+// created by a [DefaultSessionManager]. The zero value keeps the protocol
+// defaults.
+type SessionConfig struct {
+	// OnRequestDecoded, if set, may rewrite every request after it's
+	// decoded and before it's dispatched. Requests whose path changes are
+	// logged with both paths. See [RequestRewriter].
+	OnRequestDecoded RequestRewriter
+
+	// WireLog, if set, logs every request received and response sent
+	// at debug level, decoded, as [utils.WireLog] says, to debug the
+	// traffic of a session without a capture.
+	WireLog *utils.WireLog
+
+	// Codec frames the messages of the session on its connection, e.g.
+	// [nanorpc.COBSCodec] or [nanorpc.SLIPCodec] to match a serial link.
+	// Nil uses the length prefix of NanoRPC, [nanorpc.LengthPrefixCodec].
+	Codec nanorpc.Codec
+
+	// Idempotent, if set, tells the requests that can be handled twice,
+	// exempting them from DedupWindow.
+	Idempotent func(req *nanorpc.NanoRPCRequest) bool
+
+	// StrictOrderTimeout is how long an unanswered request holds back
+	// later responses in StrictOrder mode. Zero uses
+	// [DefaultStrictOrderTimeout].
+	StrictOrderTimeout time.Duration
+
+	// HandlerTimeout, when positive, is how long handlers have to answer
+	// a request before the context they were given is cancelled, so they
+	// can abort. Requests carrying a shorter timeout_ms are given that
+	// instead. Handlers' contexts are also cancelled when the session
+	// closes, whether or not it's set.
+	HandlerTimeout time.Duration
+
+	// OutboundQueueSize, when positive, queues up to that many
+	// subscription updates per session, written by a goroutine of their
+	// own, so publishing doesn't wait for slow subscribers. When the queue
+	// is full, OverflowPolicy decides; see [OverflowPolicy]. Zero writes
+	// updates as they're published.
+	OutboundQueueSize int
+
+	// OverflowPolicy is what sessions do with updates that find their
+	// outbound queue full.
+	OverflowPolicy OverflowPolicy
+
+	// MaxMessageSize is the largest request accepted, length prefix
+	// excluded. A session receiving a larger one is closed with
+	// [nanorpc.ErrMessageTooLarge] before buffering it. Zero uses
+	// [nanorpc.DefaultMaxMessageSize].
+	MaxMessageSize int
+
+	// CompressionThreshold, when positive, compresses the data of
+	// responses and updates at least that many bytes long, for sessions
+	// that negotiated [nanorpc.FeatureCompression] in their handshake.
+	// Compressed requests are accepted whether or not it's set. Zero
+	// doesn't compress.
+	CompressionThreshold int
+
+	// DedupWindow, when positive, remembers that many of the latest
+	// requests and subscriptions of each session by request ID, type and
+	// path, so a retransmission, e.g. over a flaky link, doesn't run a
+	// non-idempotent handler twice. DedupPolicy decides what a repeated
+	// one gets; see [DedupPolicy]. Requests with ID zero aren't checked.
+	DedupWindow int
+
+	// DedupPolicy is what sessions do with requests repeating one in
+	// their DedupWindow.
+	DedupPolicy DedupPolicy
+
+	// Timestamps attaches server-side received and processed times to
+	// TYPE_PONG and TYPE_RESPONSE messages, so clients can tell server
+	// processing time apart from network time.
+	Timestamps bool
+
+	// CorrelationIDs gives requests whose metadata carries no
+	// [nanorpc.MetadataCorrelationID] a new one as they're decoded, and
+	// echoes the correlation ID of requests in their responses, so the
+	// logs of every hop a request takes can be stitched together.
+	CorrelationIDs bool
+
+	// StrictOrder sends TYPE_PONG and TYPE_RESPONSE messages in the order
+	// their requests were received, holding back those of handlers that
+	// complete early, for clients that match responses to requests by
+	// position. Handlers must answer every request; one left unanswered
+	// holds back the others for StrictOrderTimeout.
+	StrictOrder bool
+
+	// OmitErrorData drops the data of responses and updates with an error
+	// status, and normalises their message, whatever the handler sent, for
+	// embedded decoders that misbehave when error responses carry payloads.
+	// See [NormaliseErrorResponse].
+	OmitErrorData bool
+
+	// LegacyStatus sends the statuses added with
+	// [nanorpc.FeatureExtendedStatus], like STATUS_BAD_REQUEST, as
+	// STATUS_INTERNAL_ERROR to sessions that didn't negotiate it in their
+	// handshake, for clients predating them. See [nanorpc.LegacyStatus].
+	LegacyStatus bool
+
+	// ReuseRequests decodes requests into pooled messages, reused once
+	// HandleMessage returns, to cut per-message allocations on sessions
+	// with high message rates. Handlers, interceptors and rewriters must
+	// not keep the request or its Data after returning, except requests
+	// answered asynchronously, see [ErrAsync], which are left out of the
+	// pool. It's ignored in StrictOrder mode and while the
+	// [DefaultMessageHandler] hands requests to workers.
+	ReuseRequests bool
+}
+
+// SetSessionConfig sets the configuration used by sessions created from
+// now on. Existing sessions keep the configuration they were created with.
+func (sm *DefaultSessionManager) SetSessionConfig(cfg SessionConfig) error {
+	if sm == nil {
+		return core.ErrNilReceiver
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	sm.config = cfg
+	return nil
+}
+
+// sessionConfig returns the configuration for new sessions.
+func (sm *DefaultSessionManager) sessionConfig() SessionConfig {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	return sm.config
+}
+
+// maxMessageSize returns the largest request a session reads.
+func (cfg *SessionConfig) maxMessageSize() int {
+	if cfg.MaxMessageSize > 0 {
+		return cfg.MaxMessageSize
+	}
+	return nanorpc.DefaultMaxMessageSize
+}
+
+// codec returns the framing of the messages of a session.
+func (cfg *SessionConfig) codec() nanorpc.Codec {
+	if cfg.Codec != nil {
+		return cfg.Codec
+	}
+	return nanorpc.LengthPrefixCodec{}
+}
+
+// NormaliseErrorResponse removes the data of a response with an error
+// status and trims its message, using the lowercase status name, e.g.
+// "not found", when left empty. Responses with STATUS_OK or
+// STATUS_UNSPECIFIED are left untouched.
+func NormaliseErrorResponse(response *nanorpc.NanoRPCResponse) {
+	switch response.GetResponseStatus() {
+	case nanorpc.NanoRPCResponse_STATUS_OK, nanorpc.NanoRPCResponse_STATUS_UNSPECIFIED:
+		return
+	}
+
+	response.Data = nil
+	response.ResponseMessage = strings.TrimSpace(response.ResponseMessage)
+	if response.ResponseMessage == "" {
+		response.ResponseMessage = statusMessage(response.ResponseStatus)
+	}
+}
+
+// statusMessage returns the default message of a status.
+func statusMessage(status nanorpc.NanoRPCResponse_Status) string {
+	name, ok := strings.CutPrefix(status.String(), "STATUS_")
+	if !ok {
+		// unknown status, String() gives the number
+		return "status " + name
+	}
+	return strings.ToLower(strings.ReplaceAll(name, "_", " "))
+}
@@ -0,0 +1,306 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+var _ core.TestCase = sessionTimestampsTestCase{}
+
+// sessionTimestampsTestCase verifies responses carry timestamps only when
+// the session configuration asks for them.
+type sessionTimestampsTestCase struct {
+	name    string
+	reqType nanorpc.NanoRPCRequest_Type
+	enabled bool
+}
+
+func (tc sessionTimestampsTestCase) Name() string { return tc.name }
+
+func (tc sessionTimestampsTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	handler := NewDefaultMessageHandler(nil)
+	core.AssertMustNoError(t, handler.RegisterHandlerFunc(pathEcho, echoChainHandler), "register")
+
+	sm := NewDefaultSessionManager(handler, nil)
+	core.AssertMustNoError(t, sm.SetSessionConfig(SessionConfig{Timestamps: tc.enabled}), "config")
+
+	req := &nanorpc.NanoRPCRequest{
+		RequestId:   7,
+		RequestType: tc.reqType,
+		PathOneof:   nanorpc.GetPathOneOfString(pathEcho),
+	}
+	data, err := nanorpc.EncodeRequest(req, nil)
+	core.AssertMustNoError(t, err, "encode")
+
+	conn := &mockConn{remoteAddr: "127.0.0.1:12345", data: data}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_ = sm.AddSession(conn).Handle(ctx)
+
+	resp, _, err := nanorpc.DecodeResponse(conn.writeData)
+	core.AssertMustNoError(t, err, "decode")
+
+	ts := resp.GetTimestamps()
+	if !tc.enabled {
+		core.AssertNil(t, ts, "timestamps")
+		return
+	}
+	if core.AssertNotNil(t, ts, "timestamps") {
+		core.AssertNotEqual(t, uint64(0), ts.ReceivedUs, "received")
+		core.AssertTrue(t, ts.ProcessedUs >= ts.ReceivedUs, "processed after received")
+	}
+}
+
+func newSessionTimestampsTestCase(name string, reqType nanorpc.NanoRPCRequest_Type,
+	enabled bool) sessionTimestampsTestCase {
+	return sessionTimestampsTestCase{name: name, reqType: reqType, enabled: enabled}
+}
+
+func sessionTimestampsTestCases() []sessionTimestampsTestCase {
+	return []sessionTimestampsTestCase{
+		newSessionTimestampsTestCase("ping_enabled", nanorpc.NanoRPCRequest_TYPE_PING, true),
+		newSessionTimestampsTestCase("request_enabled", nanorpc.NanoRPCRequest_TYPE_REQUEST, true),
+		newSessionTimestampsTestCase("request_disabled", nanorpc.NanoRPCRequest_TYPE_REQUEST, false),
+	}
+}
+
+func TestSessionConfig_Timestamps(t *testing.T) {
+	core.RunTestCases(t, sessionTimestampsTestCases())
+}
+
+// TestSessionConfig_TimestampsAsync verifies requests answered after
+// their handler returns are stamped too, and forgotten once answered.
+func TestSessionConfig_TimestampsAsync(t *testing.T) {
+	h := NewDefaultMessageHandler(nil)
+	s, conn := newSharedTestSession(h)
+	s.config.Timestamps = true
+
+	var pending *RequestContext
+	core.AssertMustNoError(t, h.RegisterHandlerFunc(pathEcho, func(_ context.Context, rc *RequestContext) error {
+		pending = rc
+		return ErrAsync
+	}), "register")
+
+	req := newTestSubscribeRequest(7, pathEcho, nil)
+	req.RequestType = nanorpc.NanoRPCRequest_TYPE_REQUEST
+	core.AssertMustNoError(t, s.decodeAndHandle(context.Background(), encodeTestFrame(t, req), 0), "handle")
+	core.AssertMustNotNil(t, pending, "pending")
+	core.AssertMustNoError(t, pending.SendOK(nil), "SendOK")
+
+	resp, _, err := nanorpc.DecodeResponse(conn.writeData)
+	core.AssertMustNoError(t, err, "decode")
+	core.AssertNotNil(t, resp.GetTimestamps(), "timestamps")
+	core.AssertEqual(t, 0, len(s.received), "received")
+}
+
+// TestWithSessionConfig verifies the server option reaches the default
+// session manager.
+func TestWithSessionConfig(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	core.AssertMustNoError(t, err, "listen")
+	defer listener.Close()
+
+	srv := NewDefaultServer(listener, nil, nil, WithSessionConfig(SessionConfig{Timestamps: true}))
+	sm, ok := srv.sessionManager.(*DefaultSessionManager)
+	core.AssertMustTrue(t, ok, "default session manager")
+	core.AssertTrue(t, sm.sessionConfig().Timestamps, "timestamps")
+}
+
+var _ core.TestCase = normaliseErrorResponseTestCase{}
+
+type normaliseErrorResponseTestCase struct {
+	name        string
+	message     string
+	wantMessage string
+	data        []byte
+	wantData    []byte
+	status      nanorpc.NanoRPCResponse_Status
+}
+
+func (tc normaliseErrorResponseTestCase) Name() string { return tc.name }
+
+func (tc normaliseErrorResponseTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	resp := &nanorpc.NanoRPCResponse{
+		RequestId:       7,
+		ResponseType:    nanorpc.NanoRPCResponse_TYPE_RESPONSE,
+		ResponseStatus:  tc.status,
+		ResponseMessage: tc.message,
+		Data:            tc.data,
+	}
+	NormaliseErrorResponse(resp)
+
+	core.AssertEqual(t, tc.status, resp.ResponseStatus, "status")
+	core.AssertEqual(t, tc.wantMessage, resp.ResponseMessage, "message")
+	core.AssertSliceEqual(t, tc.wantData, resp.Data, "data")
+}
+
+//revive:disable-next-line:argument-limit
+func newNormaliseErrorResponseTestCase(name string, status nanorpc.NanoRPCResponse_Status,
+	message string, data []byte, wantMessage string, wantData []byte) normaliseErrorResponseTestCase {
+	return normaliseErrorResponseTestCase{
+		name:        name,
+		status:      status,
+		message:     message,
+		data:        data,
+		wantMessage: wantMessage,
+		wantData:    wantData,
+	}
+}
+
+func normaliseErrorResponseTestCases() []normaliseErrorResponseTestCase {
+	payload := []byte("payload")
+	return []normaliseErrorResponseTestCase{
+		newNormaliseErrorResponseTestCase("ok_kept", nanorpc.NanoRPCResponse_STATUS_OK,
+			" done ", payload, " done ", payload),
+		newNormaliseErrorResponseTestCase("unspecified_kept", nanorpc.NanoRPCResponse_STATUS_UNSPECIFIED,
+			"", payload, "", payload),
+		newNormaliseErrorResponseTestCase("not_found_default", nanorpc.NanoRPCResponse_STATUS_NOT_FOUND,
+			"", payload, "not found", nil),
+		newNormaliseErrorResponseTestCase("not_authorized_blank", nanorpc.NanoRPCResponse_STATUS_NOT_AUTHORIZED,
+			"  ", nil, "not authorized", nil),
+		newNormaliseErrorResponseTestCase("internal_error_trimmed", nanorpc.NanoRPCResponse_STATUS_INTERNAL_ERROR,
+			" disk full\n", payload, "disk full", nil),
+		newNormaliseErrorResponseTestCase("unknown_status", nanorpc.NanoRPCResponse_Status(42),
+			"", payload, "status 42", nil),
+	}
+}
+
+func TestNormaliseErrorResponse(t *testing.T) {
+	core.RunTestCases(t, normaliseErrorResponseTestCases())
+}
+
+// TestSessionConfig_OmitErrorData verifies the session strips the payload
+// a handler attaches to an error response.
+func TestSessionConfig_OmitErrorData(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		resp := sendErrorWithData(t, SessionConfig{OmitErrorData: enabled})
+		core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_NOT_FOUND, resp.ResponseStatus, "status")
+		if enabled {
+			core.AssertEqual(t, 0, len(resp.Data), "data")
+			core.AssertEqual(t, "not found", resp.ResponseMessage, "message")
+		} else {
+			core.AssertEqual(t, "payload", string(resp.Data), "data")
+			core.AssertEqual(t, "", resp.ResponseMessage, "message")
+		}
+	}
+}
+
+// sendErrorWithData runs a request through a session answered with
+// STATUS_NOT_FOUND and a payload, returning the response on the wire.
+func sendErrorWithData(t *testing.T, cfg SessionConfig) *nanorpc.NanoRPCResponse {
+	t.Helper()
+
+	handler := NewDefaultMessageHandler(nil)
+	err := handler.RegisterHandlerFunc(pathEcho, func(_ context.Context, rc *RequestContext) error {
+		return rc.Session.SendResponse(rc.Request, &nanorpc.NanoRPCResponse{
+			ResponseType:   nanorpc.NanoRPCResponse_TYPE_RESPONSE,
+			ResponseStatus: nanorpc.NanoRPCResponse_STATUS_NOT_FOUND,
+			Data:           []byte("payload"),
+		})
+	})
+	core.AssertMustNoError(t, err, "register")
+
+	sm := NewDefaultSessionManager(handler, nil)
+	core.AssertMustNoError(t, sm.SetSessionConfig(cfg), "config")
+
+	data, err := nanorpc.EncodeRequest(newTestRequest(7, pathEcho), nil)
+	core.AssertMustNoError(t, err, "encode")
+
+	conn := &mockConn{remoteAddr: "127.0.0.1:12345", data: data}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_ = sm.AddSession(conn).Handle(ctx)
+
+	resp, _, err := nanorpc.DecodeResponse(conn.writeData)
+	core.AssertMustNoError(t, err, "decode")
+	return resp
+}
+
+// TestSessionConfig_MaxMessageSize verifies a session receiving a request
+// larger than allowed is closed without dispatching it.
+func TestSessionConfig_MaxMessageSize(t *testing.T) {
+	handler := NewDefaultMessageHandler(nil)
+	core.AssertMustNoError(t, handler.RegisterHandlerFunc(pathEcho, echoChainHandler), "register")
+
+	sm := NewDefaultSessionManager(handler, nil)
+	core.AssertMustNoError(t, sm.SetSessionConfig(SessionConfig{MaxMessageSize: 64}), "config")
+
+	req := newTestRequest(7, pathEcho)
+	req.Data = make([]byte, 128)
+	data, err := nanorpc.EncodeRequest(req, nil)
+	core.AssertMustNoError(t, err, "encode")
+
+	conn := &mockConn{remoteAddr: "127.0.0.1:12345", data: data}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	err = sm.AddSession(conn).Handle(ctx)
+	core.AssertErrorIs(t, err, nanorpc.ErrMessageTooLarge, "Handle")
+	core.AssertEqual(t, 0, len(conn.writeData), "response")
+	core.AssertTrue(t, conn.closed, "closed")
+}
+
+// TestSessionConfig_Codec verifies sessions read and write the frames of
+// their codec.
+func TestSessionConfig_Codec(t *testing.T) {
+	for _, codec := range []nanorpc.Codec{nil, nanorpc.COBSCodec{}, nanorpc.SLIPCodec{}} {
+		t.Run(fmt.Sprintf("%T", codec), func(t *testing.T) {
+			testSessionCodec(t, codec)
+		})
+	}
+}
+
+func testSessionCodec(t *testing.T, codec nanorpc.Codec) {
+	t.Helper()
+
+	handler := NewDefaultMessageHandler(nil)
+	core.AssertMustNoError(t, handler.RegisterHandlerFunc(pathEcho, echoChainHandler), "register")
+
+	var data []byte
+	for _, req := range []*nanorpc.NanoRPCRequest{
+		{RequestId: 1, RequestType: nanorpc.NanoRPCRequest_TYPE_PING},
+		newTestRequest(2, pathEcho),
+	} {
+		wrapped, err := nanorpc.EncodeRequest(req, nil)
+		core.AssertMustNoError(t, err, "encode")
+		data, err = nanorpc.AppendFramed(codec, data, wrapped)
+		core.AssertMustNoError(t, err, "frame")
+	}
+
+	conn := &mockConn{remoteAddr: "127.0.0.1:12345", data: data}
+	session := NewDefaultSession(conn, handler, nil)
+	session.config.Codec = codec
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_ = session.Handle(ctx)
+
+	if codec == nil {
+		codec = nanorpc.LengthPrefixCodec{}
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(conn.writeData))
+	scanner.Split(codec.Split(0))
+
+	var ids []int32
+	for scanner.Scan() {
+		msg, err := codec.Unframe(scanner.Bytes())
+		core.AssertMustNoError(t, err, "unframe")
+		resp := new(nanorpc.NanoRPCResponse)
+		core.AssertMustNoError(t, proto.Unmarshal(msg, resp), "decode")
+		ids = append(ids, resp.RequestId)
+	}
+	core.AssertSliceEqual(t, []int32{1, 2}, ids, "responses")
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// newWaitingHandler returns a handler whose pathEcho handler reports it
+// started, waits for its context to be cancelled, and answers with the
+// cause.
+func newWaitingHandler(t *testing.T, started chan<- struct{}) *DefaultMessageHandler {
+	t.Helper()
+
+	h := NewDefaultMessageHandler(nil)
+	core.AssertMustNoError(t, h.RegisterHandlerFunc(pathEcho, func(ctx context.Context, rc *RequestContext) error {
+		started <- struct{}{}
+		<-rc.Context().Done()
+		return rc.SendInternalError(context.Cause(ctx).Error())
+	}), "register")
+	return h
+}
+
+func encodeTestRequest(t *testing.T, id int32, path string) []byte {
+	t.Helper()
+
+	data, err := nanorpc.EncodeRequest(newTestRequest(id, path), nil)
+	core.AssertMustNoError(t, err, "encode")
+	return data
+}
+
+// TestSessionConfig_HandlerTimeout verifies handlers' contexts expire after
+// HandlerTimeout.
+func TestSessionConfig_HandlerTimeout(t *testing.T) {
+	sm := NewDefaultSessionManager(newWaitingHandler(t, make(chan struct{}, 1)), nil)
+	core.AssertMustNoError(t, sm.SetSessionConfig(SessionConfig{HandlerTimeout: 10 * time.Millisecond}), "config")
+
+	conn := &mockConn{remoteAddr: "127.0.0.1:12345", data: encodeTestRequest(t, 7, pathEcho)}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_ = sm.AddSession(conn).Handle(ctx)
+
+	resp, _, err := nanorpc.DecodeResponse(conn.writeData)
+	core.AssertMustNoError(t, err, "decode")
+	core.AssertEqual(t, context.DeadlineExceeded.Error(), resp.ResponseMessage, "cause")
+}
+
+// TestDefaultSession_requestTimeout verifies handlers' contexts expire
+// after the timeout of the request when shorter than HandlerTimeout.
+func TestDefaultSession_requestTimeout(t *testing.T) {
+	sm := NewDefaultSessionManager(newWaitingHandler(t, make(chan struct{}, 1)), nil)
+	core.AssertMustNoError(t, sm.SetSessionConfig(SessionConfig{HandlerTimeout: time.Minute}), "config")
+
+	req := newTestRequest(7, pathEcho)
+	req.TimeoutMs = 10
+	data, err := nanorpc.EncodeRequest(req, nil)
+	core.AssertMustNoError(t, err, "encode")
+
+	conn := &mockConn{remoteAddr: "127.0.0.1:12345", data: data}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	_ = sm.AddSession(conn).Handle(ctx)
+	core.AssertTrue(t, time.Since(start) < 500*time.Millisecond, "expired in time")
+
+	resp, _, err := nanorpc.DecodeResponse(conn.writeData)
+	core.AssertMustNoError(t, err, "decode")
+	core.AssertEqual(t, context.DeadlineExceeded.Error(), resp.ResponseMessage, "cause")
+}
+
+// TestDefaultSession_Close_cancelsHandlers verifies closing a session
+// cancels the context of the handlers still running.
+func TestDefaultSession_Close_cancelsHandlers(t *testing.T) {
+	started := make(chan struct{}, 1)
+	sm := NewDefaultSessionManager(newWaitingHandler(t, started), nil)
+
+	server, client := net.Pipe()
+	defer client.Close()
+
+	session := sm.AddSession(server)
+	done := make(chan error, 1)
+	go func() { done <- session.Handle(context.Background()) }()
+
+	_, err := client.Write(encodeTestRequest(t, 7, pathEcho))
+	core.AssertMustNoError(t, err, "write")
+
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("handler not called")
+	}
+	core.AssertMustNoError(t, session.Close(), "Close")
+
+	select {
+	case err = <-done:
+		core.AssertNoError(t, err, "Handle")
+	case <-time.After(time.Second):
+		t.Fatal("Handle didn't return")
+	}
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"darvaza.org/core"
+)
+
+// SessionInfo describes an open session, see [SessionRegistry].
+type SessionInfo struct {
+	// ConnectedAt is when the session was created.
+	ConnectedAt time.Time
+	// LastActivity is when the session last received a request, or
+	// ConnectedAt if none.
+	LastActivity time.Time
+	// ID is the session identifier.
+	ID string
+	// RemoteAddr is the address of the client.
+	RemoteAddr string
+	// Identity is the identity the session authenticated as, if any, see
+	// [IdentityIndex].
+	Identity string
+	// Subscriptions counts the live subscriptions of the session, when
+	// the [MessageHandler] keeps them.
+	Subscriptions int
+}
+
+// SessionRegistry enumerates the open sessions and closes them on demand,
+// for admin and diagnostics tools. [DefaultSessionManager] implements it.
+type SessionRegistry interface {
+	// Sessions describes the open sessions, oldest first.
+	Sessions() []SessionInfo
+	// CloseSession closes a session by ID.
+	CloseSession(sessionID string) error
+}
+
+var _ SessionRegistry = (*DefaultSessionManager)(nil)
+
+// sessionActivity is a [Session] that knows when it was created and last
+// received a request, as [DefaultSession] does.
+type sessionActivity interface {
+	ConnectedAt() time.Time
+	LastActivity() time.Time
+}
+
+// subscriptionCounter is a [MessageHandler] counting the subscriptions of
+// a session, as [DefaultMessageHandler] does.
+type subscriptionCounter interface {
+	SubscriptionCount(sessionID string) int
+}
+
+// ConnectedAt returns when the session was created.
+func (s *DefaultSession) ConnectedAt() time.Time {
+	if s == nil {
+		return time.Time{}
+	}
+	return s.created
+}
+
+// LastActivity returns when the session last received a request, or when
+// it was created if none.
+func (s *DefaultSession) LastActivity() time.Time {
+	if s == nil {
+		return time.Time{}
+	}
+	if t := s.active.Load(); t != 0 {
+		return time.Unix(0, t)
+	}
+	return s.created
+}
+
+// touch records a request was received.
+func (s *DefaultSession) touch(t time.Time) {
+	s.active.Store(t.UnixNano())
+}
+
+// Sessions describes the open sessions, oldest first.
+func (sm *DefaultSessionManager) Sessions() []SessionInfo {
+	if sm == nil {
+		return nil
+	}
+
+	sm.mu.RLock()
+	out := make([]SessionInfo, 0, len(sm.sessions))
+	for id, session := range sm.sessions {
+		out = append(out, SessionInfo{
+			ID:         id,
+			RemoteAddr: session.RemoteAddr(),
+			Identity:   sm.identityOf[id],
+		})
+		if sa, ok := session.(sessionActivity); ok {
+			out[len(out)-1].ConnectedAt = sa.ConnectedAt()
+			out[len(out)-1].LastActivity = sa.LastActivity()
+		}
+	}
+	sm.mu.RUnlock()
+
+	if sc, ok := sm.handler.(subscriptionCounter); ok {
+		for i := range out {
+			out[i].Subscriptions = sc.SubscriptionCount(out[i].ID)
+		}
+	}
+
+	slices.SortFunc(out, func(a, b SessionInfo) int {
+		return cmp.Or(a.ConnectedAt.Compare(b.ConnectedAt), cmp.Compare(a.ID, b.ID))
+	})
+	return out
+}
+
+// CloseSession closes a session by ID, which is removed once its read
+// loop ends. Fails with [ErrUnknownSession] if there is no such session.
+func (sm *DefaultSessionManager) CloseSession(sessionID string) error {
+	if sm == nil {
+		return core.ErrNilReceiver
+	}
+
+	session := sm.GetSession(sessionID)
+	if session == nil {
+		return core.QuietWrap(ErrUnknownSession, "session %q", sessionID)
+	}
+	return session.Close()
+}
+
+// SubscriptionCount returns the number of live subscriptions of a session.
+func (h *DefaultMessageHandler) SubscriptionCount(sessionID string) int {
+	if h == nil {
+		return 0
+	}
+
+	var n int
+	h.subscriptions.Range(func(sub *ActiveSubscription) bool {
+		if sub.Session != nil && sub.Session.ID() == sessionID {
+			n++
+		}
+		return true
+	})
+	return n
+}
+
+// Sessions describes the open sessions of the server, when its
+// [SessionManager] is a [SessionRegistry].
+func (s *Server) Sessions() []SessionInfo {
+	if s == nil {
+		return nil
+	}
+
+	if reg, ok := s.sessionManager.(SessionRegistry); ok {
+		return reg.Sessions()
+	}
+	return nil
+}
+
+// CloseSession closes a session of the server by ID, through its
+// [SessionRegistry]. Fails with [ErrSessionRegistryUnsupported] if its
+// [SessionManager] isn't one.
+func (s *Server) CloseSession(sessionID string) error {
+	if s == nil {
+		return core.ErrNilReceiver
+	}
+
+	if reg, ok := s.sessionManager.(SessionRegistry); ok {
+		return reg.CloseSession(sessionID)
+	}
+	return ErrSessionRegistryUnsupported
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"darvaza.org/core"
+)
+
+// TestDefaultSessionManager_Sessions verifies the open sessions are
+// described oldest first, with their identity and subscriptions.
+func TestDefaultSessionManager_Sessions(t *testing.T) {
+	h := NewDefaultMessageHandler(nil)
+	sm := NewDefaultSessionManager(h, nil)
+
+	first := sm.AddSession(&mockConn{remoteAddr: "127.0.0.1:1001"})
+	second := sm.AddSession(&mockConn{remoteAddr: "127.0.0.1:1002"})
+	core.AssertMustNoError(t, sm.BindIdentity(second.ID(), "device-2"), "BindIdentity")
+
+	for id := range int32(2) {
+		req := newTestSubscribeRequest(id+1, "/events", nil)
+		core.AssertMustNoError(t, h.Subscribe(context.Background(), second, req), "Subscribe")
+	}
+
+	infos := sm.Sessions()
+	core.AssertMustEqual(t, 2, len(infos), "sessions")
+	core.AssertEqual(t, first.ID(), infos[0].ID, "oldest first")
+	core.AssertEqual(t, "127.0.0.1:1001", infos[0].RemoteAddr, "remote address")
+	core.AssertFalse(t, infos[0].ConnectedAt.IsZero(), "connected at")
+	core.AssertEqual(t, infos[0].ConnectedAt, infos[0].LastActivity, "no activity")
+	core.AssertEqual(t, 0, infos[0].Subscriptions, "no subscriptions")
+
+	core.AssertEqual(t, "device-2", infos[1].Identity, "identity")
+	core.AssertEqual(t, 2, infos[1].Subscriptions, "subscriptions")
+}
+
+func TestDefaultSessionManager_CloseSession(t *testing.T) {
+	sm := NewDefaultSessionManager(NewDefaultMessageHandler(nil), nil)
+	conn := &mockConn{remoteAddr: "127.0.0.1:1001"}
+	session := sm.AddSession(conn)
+
+	core.AssertNoError(t, sm.CloseSession(session.ID()), "CloseSession")
+	core.AssertTrue(t, conn.closed, "closed")
+
+	err := sm.CloseSession("unknown")
+	core.AssertErrorIs(t, err, ErrUnknownSession, "unknown session")
+	core.AssertErrorIs(t, err, core.ErrNotExists, "not exists")
+}
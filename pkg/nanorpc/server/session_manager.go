@@ -5,9 +5,11 @@ import (
 	"net"
 	"sync"
 
+	"darvaza.org/core"
 	"darvaza.org/slog"
 	"darvaza.org/slog/handlers/discard"
 
+	"protomcp.org/nanorpc/pkg/nanorpc/server/metrics"
 	"protomcp.org/nanorpc/pkg/nanorpc/utils"
 )
 
@@ -15,8 +17,17 @@ import (
 type DefaultSessionManager struct {
 	handler  MessageHandler
 	logger   slog.Logger
+	metrics  metrics.Collector
+	store    SessionStore
 	sessions map[string]Session
-	mu       sync.RWMutex
+	// identity index, see IdentityIndex
+	byIdentity map[string]string // identity -> SessionID
+	identityOf map[string]string // SessionID -> identity
+	node       string            // see SetSessionStore
+	config     SessionConfig
+	removed    ReadStats
+	conflict   IdentityConflict
+	mu         sync.RWMutex
 }
 
 // NewDefaultSessionManager creates a new session manager
@@ -33,6 +44,10 @@ func NewDefaultSessionManager(handler MessageHandler, logger slog.Logger) *Defau
 
 // getLogger returns the configured logger or lazily initializes a discard logger
 func (sm *DefaultSessionManager) getLogger() slog.Logger {
+	if sm == nil {
+		return discard.New()
+	}
+
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
@@ -44,6 +59,10 @@ func (sm *DefaultSessionManager) getLogger() slog.Logger {
 
 // AddSession creates a new session for the connection
 func (sm *DefaultSessionManager) AddSession(conn net.Conn) Session {
+	if sm == nil {
+		return nil
+	}
+
 	// Create the session first
 	session := NewDefaultSession(conn, sm.handler, nil)
 	sessionID := session.ID()
@@ -53,13 +72,17 @@ func (sm *DefaultSessionManager) AddSession(conn net.Conn) Session {
 	sessionLogger = utils.WithRemoteAddr(sessionLogger, conn.RemoteAddr())
 	sessionLogger = utils.WithComponent(sessionLogger, utils.ComponentSession)
 
-	// Update session with the logger
+	// Update session with the logger and configuration
 	session.logger = sessionLogger
+	session.config = sm.sessionConfig()
 
 	sm.mu.Lock()
 	sm.sessions[sessionID] = session
+	sm.unsafeReportSessions()
 	sm.mu.Unlock()
 
+	sm.storeSession(session)
+
 	// Log session creation using common helpers
 	if l, ok := sm.WithInfo(); ok {
 		l = utils.WithSessionID(l, sessionID)
@@ -72,10 +95,21 @@ func (sm *DefaultSessionManager) AddSession(conn net.Conn) Session {
 
 // RemoveSession removes a session by ID
 func (sm *DefaultSessionManager) RemoveSession(sessionID string) {
+	if sm == nil {
+		return
+	}
+
 	sm.mu.Lock()
-	delete(sm.sessions, sessionID)
+	if session, ok := sm.sessions[sessionID]; ok {
+		sm.removed = sm.removed.Add(sessionReadStats(session))
+		delete(sm.sessions, sessionID)
+		sm.unsafeReportSessions()
+	}
+	sm.unsafeUnbindIdentity(sessionID)
 	sm.mu.Unlock()
 
+	sm.forgetSession(sessionID)
+
 	// Clean up subscriptions for this session
 	if subMgr, ok := sm.handler.(SubscriptionManager); ok {
 		subMgr.RemoveSubscriptionsForSession(sessionID)
@@ -90,6 +124,10 @@ func (sm *DefaultSessionManager) RemoveSession(sessionID string) {
 
 // GetSession retrieves a session by ID
 func (sm *DefaultSessionManager) GetSession(sessionID string) Session {
+	if sm == nil {
+		return nil
+	}
+
 	sm.mu.RLock()
 	session := sm.sessions[sessionID]
 	sm.mu.RUnlock()
@@ -98,12 +136,17 @@ func (sm *DefaultSessionManager) GetSession(sessionID string) Session {
 
 // Shutdown gracefully closes all sessions
 func (sm *DefaultSessionManager) Shutdown(_ context.Context) error {
+	if sm == nil {
+		return core.ErrNilReceiver
+	}
+
 	sm.mu.Lock()
 	sessions := make([]Session, 0, len(sm.sessions))
 	for _, session := range sm.sessions {
 		sessions = append(sessions, session)
 	}
 	sm.sessions = make(map[string]Session)
+	sm.byIdentity, sm.identityOf = nil, nil
 	sm.mu.Unlock()
 
 	// Close all sessions
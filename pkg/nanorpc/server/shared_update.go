@@ -0,0 +1,65 @@
+package server
+
+import (
+	"sync"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// sharedUpdate is the update of a publication, encoded once for all the
+// subscriptions it's sent to, see [nanorpc.SharedResponse].
+type sharedUpdate struct {
+	message *nanorpc.NanoRPCResponse // without request ID
+	enc     *nanorpc.SharedResponse
+	err     error
+	once    sync.Once
+}
+
+// newSharedUpdate creates the shared update of a publication.
+func newSharedUpdate(pub publication) *sharedUpdate {
+	message := newUpdateResponse(0, pub.data, pub.seq)
+	message.Path = pub.path
+	return &sharedUpdate{message: message}
+}
+
+// encoded returns the update encoded, encoding it on first use.
+func (u *sharedUpdate) encoded() (*nanorpc.SharedResponse, error) {
+	u.once.Do(func() {
+		u.enc, u.err = nanorpc.NewSharedResponse(u.message)
+	})
+	return u.enc, u.err
+}
+
+// sharedSender is implemented by sessions able to send a [sharedUpdate]
+// as encoded for every subscription.
+type sharedSender interface {
+	sendShared(update *sharedUpdate, requestID int32) (bool, error)
+}
+
+var _ sharedSender = (*DefaultSession)(nil)
+
+// sendShared sends a subscription the update encoded once for all of
+// them, copied into a pooled buffer, unless the session compresses it.
+// It reports whether it did.
+func (s *DefaultSession) sendShared(update *sharedUpdate, requestID int32) (bool, error) {
+	if s.compresses() {
+		return false, nil
+	}
+
+	enc, err := update.encoded()
+	if err != nil {
+		return true, err
+	}
+	return true, s.sendBuffer(enc.AppendTo(getBuffer(), requestID))
+}
+
+// send sends a collected update, as encoded for every subscription when
+// the session can take it.
+func (u pendingUpdate) send() error {
+	if ss, ok := u.session.(sharedSender); ok && u.shared != nil {
+		if sent, err := ss.sendShared(u.shared, u.message.RequestId); sent {
+			return err
+		}
+	}
+	return u.session.SendResponse(nil, u.message)
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// decodeResponses decodes the responses written to a connection.
+func decodeResponses(t *testing.T, data []byte) []*nanorpc.NanoRPCResponse {
+	t.Helper()
+
+	var out []*nanorpc.NanoRPCResponse
+	for len(data) > 0 {
+		r, n, err := nanorpc.DecodeResponse(data)
+		core.AssertMustNoError(t, err, "decode")
+		out, data = append(out, r), data[n:]
+	}
+	return out
+}
+
+func newSharedTestSession(h *DefaultMessageHandler) (*DefaultSession, *mockConn) {
+	conn := &mockConn{remoteAddr: "127.0.0.1:12345"}
+	return NewDefaultSession(conn, h, nil), conn
+}
+
+func TestPublish_shared(t *testing.T) {
+	ctx := context.Background()
+	h := NewDefaultMessageHandler(nil)
+
+	var conns []*mockConn
+	for i := range 3 {
+		s, conn := newSharedTestSession(h)
+		req := newTestSubscribeRequest(int32(10+i), pathSensors, nil)
+		core.AssertMustNoError(t, h.Subscribe(ctx, s, req), "Subscribe")
+		conns = append(conns, conn)
+	}
+
+	core.AssertNoError(t, h.Publish(pathSensors, []byte("a")), "Publish")
+	core.AssertNoError(t, h.Publish(pathSensors, []byte("bb")), "Publish")
+
+	for i, conn := range conns {
+		responses := decodeResponses(t, conn.writeData)
+		core.AssertMustEqual(t, 3, len(responses), "responses %d", i)
+		for j, want := range []string{"a", "bb"} {
+			update := responses[j+1]
+			core.AssertEqual(t, int32(10+i), update.RequestId, "request_id")
+			core.AssertEqual(t, nanorpc.NanoRPCResponse_TYPE_UPDATE, update.ResponseType, "type")
+			core.AssertEqual(t, want, string(update.Data), "data")
+		}
+	}
+}
+
+func TestDefaultSession_sendShared(t *testing.T) {
+	ctx := context.Background()
+	h := NewDefaultMessageHandler(nil)
+	update := newSharedUpdate(publication{data: []byte("x"), path: "/a/b", seq: 3})
+
+	s, conn := newSharedTestSession(h)
+	sent, err := s.sendShared(update, 5)
+	core.AssertTrue(t, sent, "sent")
+	core.AssertNoError(t, err, "sendShared")
+
+	want, err := nanorpc.EncodeResponse(newTestUpdate(5, update), nil)
+	core.AssertMustNoError(t, err, "EncodeResponse")
+	core.AssertSliceEqual(t, want, conn.writeData, "encoded")
+
+	// compressing sessions encode their own
+	s, conn = newSharedTestSession(h)
+	s.config.CompressionThreshold = 1
+	ping := &nanorpc.NanoRPCRequest{RequestId: 1, RequestType: nanorpc.NanoRPCRequest_TYPE_PING}
+	_, err = nanorpc.EncodeRequest(ping, nanorpc.NewHello(nanorpc.FeatureCompression))
+	core.AssertMustNoError(t, err, "EncodeRequest")
+	core.AssertMustNoError(t, h.HandleMessage(ctx, s, ping), "handshake")
+	conn.writeData = nil
+
+	sent, err = s.sendShared(update, 5)
+	core.AssertFalse(t, sent, "compressed")
+	core.AssertNoError(t, err, "sendShared")
+	core.AssertEqual(t, 0, len(conn.writeData), "written")
+}
+
+// newTestUpdate returns the message of a shared update for a request.
+func newTestUpdate(requestID int32, update *sharedUpdate) *nanorpc.NanoRPCResponse {
+	message := newUpdateResponse(requestID, update.message.Data, update.message.Sequence)
+	message.Path = update.message.Path
+	return message
+}
+
+func TestBufferPool(t *testing.T) {
+	b := append(getBuffer(), "data"...)
+	putBuffer(b)
+	core.AssertEqual(t, 0, len(getBuffer()), "reused empty")
+
+	// not kept, but harmless
+	putBuffer(make([]byte, 0, maxPooledBuffer+1))
+	putBuffer(nil)
+}
+
+// discardConn is a connection dropping what's written to it.
+type discardConn struct {
+	mockConn
+}
+
+func (*discardConn) Write(b []byte) (int, error) { return len(b), nil }
+
+// BenchmarkPublish_fanout publishes to many sessions, encoding the update
+// once and reusing pooled buffers.
+func BenchmarkPublish_fanout(b *testing.B) {
+	ctx := context.Background()
+	h := NewDefaultMessageHandler(nil)
+	for i := range 1000 {
+		s := NewDefaultSession(&discardConn{mockConn{remoteAddr: "127.0.0.1:12345"}}, h, nil)
+		if err := h.Subscribe(ctx, s, newTestSubscribeRequest(int32(i+1), pathSensors, nil)); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	data := make([]byte, 256)
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := h.Publish(pathSensors, data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package server
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"darvaza.org/core"
+)
+
+// SessionRecord describes a session kept by a [SessionStore].
+type SessionRecord struct {
+	// ConnectedAt is when the session was created.
+	ConnectedAt time.Time
+	// ID is the ID of the session, see [Session].
+	ID string
+	// Node names the server instance holding the connection.
+	Node string
+	// RemoteAddr is the address of the peer.
+	RemoteAddr string
+}
+
+// SubscriptionRecord describes a subscription kept by a
+// [SubscriptionStore].
+type SubscriptionRecord struct {
+	// SessionID is the ID of the subscribed session.
+	SessionID string
+	// Node names the server instance holding the session.
+	Node string
+	// RequestID is the ID of the subscription request.
+	RequestID int32
+	// PathHash is the hash of the path, or pattern, subscribed to.
+	PathHash uint32
+}
+
+// SessionStore keeps the sessions of the server instances of a
+// deployment, so a clustered one can back them with a shared store and
+// look them up from any instance, see [DefaultSessionManager.SetSessionStore].
+//
+// PutSession saves a session, replacing any of the same ID. DeleteSession
+// forgets a session, whether saved or not. Sessions returns those saved.
+// Implementations must be safe for concurrent use.
+type SessionStore interface {
+	PutSession(SessionRecord) error
+	DeleteSession(id string) error
+	Sessions() ([]SessionRecord, error)
+}
+
+// SubscriptionStore keeps the subscriptions of the server instances of a
+// deployment, so a publication on one can reach the instances holding
+// subscribers, see [DefaultMessageHandler.SetSubscriptionStore] and
+// [SubscribedNodes].
+//
+// AddSubscription saves a subscription. RemoveSubscription forgets the
+// one of the same session, request ID and path hash, and
+// RemoveSessionSubscriptions all those of a session, whether saved or
+// not. Subscriptions returns those to a path hash. Implementations must
+// be safe for concurrent use.
+type SubscriptionStore interface {
+	AddSubscription(SubscriptionRecord) error
+	RemoveSubscription(SubscriptionRecord) error
+	RemoveSessionSubscriptions(sessionID string) error
+	Subscriptions(pathHash uint32) ([]SubscriptionRecord, error)
+}
+
+// Store keeps both sessions and subscriptions, see [WithStore].
+type Store interface {
+	SessionStore
+	SubscriptionStore
+}
+
+// SubscribedNodes returns the sorted names of the nodes holding
+// subscribers of a path hash.
+func SubscribedNodes(store SubscriptionStore, pathHash uint32) ([]string, error) {
+	if core.IsNil(store) {
+		return nil, core.ErrNilReceiver
+	}
+
+	subs, err := store.Subscriptions(pathHash)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]string, 0, len(subs))
+	for _, sub := range subs {
+		nodes = append(nodes, sub.Node)
+	}
+	slices.Sort(nodes)
+	return slices.Compact(nodes), nil
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// MemoryStore is an in-process [Store], for single instance deployments
+// and tests.
+type MemoryStore struct {
+	sessions map[string]SessionRecord
+	subs     map[uint32][]SubscriptionRecord
+	mu       sync.RWMutex
+}
+
+// NewMemoryStore creates an empty [MemoryStore].
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		sessions: make(map[string]SessionRecord),
+		subs:     make(map[uint32][]SubscriptionRecord),
+	}
+}
+
+// PutSession saves a session, replacing any of the same ID.
+func (s *MemoryStore) PutSession(r SessionRecord) error {
+	if s == nil {
+		return core.ErrNilReceiver
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.sessions == nil {
+		s.sessions = make(map[string]SessionRecord)
+	}
+	s.sessions[r.ID] = r
+	return nil
+}
+
+// DeleteSession forgets a session.
+func (s *MemoryStore) DeleteSession(id string) error {
+	if s == nil {
+		return core.ErrNilReceiver
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, id)
+	return nil
+}
+
+// Sessions returns the sessions saved, sorted by ID.
+func (s *MemoryStore) Sessions() ([]SessionRecord, error) {
+	if s == nil {
+		return nil, core.ErrNilReceiver
+	}
+
+	s.mu.RLock()
+	out := make([]SessionRecord, 0, len(s.sessions))
+	for _, r := range s.sessions {
+		out = append(out, r)
+	}
+	s.mu.RUnlock()
+
+	slices.SortFunc(out, func(a, b SessionRecord) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return out, nil
+}
+
+// AddSubscription saves a subscription.
+func (s *MemoryStore) AddSubscription(r SubscriptionRecord) error {
+	if s == nil {
+		return core.ErrNilReceiver
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.subs == nil {
+		s.subs = make(map[uint32][]SubscriptionRecord)
+	}
+	s.subs[r.PathHash] = append(s.subs[r.PathHash], r)
+	return nil
+}
+
+// RemoveSubscription forgets the subscriptions of the same session,
+// request ID and path hash.
+func (s *MemoryStore) RemoveSubscription(r SubscriptionRecord) error {
+	if s == nil {
+		return core.ErrNilReceiver
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.unsafeRemove(r.PathHash, func(sub SubscriptionRecord) bool {
+		return sub.SessionID == r.SessionID && sub.RequestID == r.RequestID
+	})
+	return nil
+}
+
+// RemoveSessionSubscriptions forgets the subscriptions of a session.
+func (s *MemoryStore) RemoveSessionSubscriptions(sessionID string) error {
+	if s == nil {
+		return core.ErrNilReceiver
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for pathHash := range s.subs {
+		s.unsafeRemove(pathHash, func(sub SubscriptionRecord) bool {
+			return sub.SessionID == sessionID
+		})
+	}
+	return nil
+}
+
+// Subscriptions returns the subscriptions to a path hash, in the order
+// they were saved.
+func (s *MemoryStore) Subscriptions(pathHash uint32) ([]SubscriptionRecord, error) {
+	if s == nil {
+		return nil, core.ErrNilReceiver
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.subs[pathHash]), nil
+}
+
+func (s *MemoryStore) unsafeRemove(pathHash uint32, fn func(SubscriptionRecord) bool) {
+	subs := slices.DeleteFunc(s.subs[pathHash], fn)
+	if len(subs) == 0 {
+		delete(s.subs, pathHash)
+	} else {
+		s.subs[pathHash] = subs
+	}
+}
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"darvaza.org/core"
+	"darvaza.org/slog"
+
+	"protomcp.org/nanorpc/pkg/nanorpc/utils"
+)
+
+// WithStore has the server's [DefaultSessionManager] and
+// [DefaultMessageHandler] save their sessions and subscriptions in store
+// as those of node, see [DefaultSessionManager.SetSessionStore] and
+// [DefaultMessageHandler.SetSubscriptionStore]. Other session managers
+// and message handlers are left untouched.
+func WithStore(store Store, node string) ServerOption {
+	return func(s *Server) {
+		if sm, ok := s.sessionManager.(*DefaultSessionManager); ok {
+			_ = sm.SetSessionStore(store, node)
+		}
+		if h, ok := s.messageHandler.(*DefaultMessageHandler); ok {
+			_ = h.SetSubscriptionStore(store, node)
+		}
+	}
+}
+
+// SetSubscriptionStore has the handler save the subscriptions of its
+// sessions in store as those of node, and forget them when they end, so
+// other instances of a clustered deployment can tell where the
+// subscribers of a path are. Subscriptions made before aren't saved, so
+// it should be set before serving. A nil store stops saving them.
+// Failures of the store are reported to the error handler and don't
+// affect the subscriptions.
+func (h *DefaultMessageHandler) SetSubscriptionStore(store SubscriptionStore, node string) error {
+	if h == nil {
+		return core.ErrNilReceiver
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if core.IsNil(store) {
+		h.store, h.node = nil, ""
+	} else {
+		h.store, h.node = store, node
+	}
+	return nil
+}
+
+func (h *DefaultMessageHandler) getStore() (SubscriptionStore, string) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.store, h.node
+}
+
+// subscriptionRecord returns the record of a subscription made on node.
+func subscriptionRecord(sub *ActiveSubscription, node string) SubscriptionRecord {
+	return SubscriptionRecord{
+		SessionID: sub.Session.ID(),
+		Node:      node,
+		RequestID: sub.RequestID,
+		PathHash:  sub.PathHash,
+	}
+}
+
+// storeSubscription saves a new subscription in the store, if any.
+func (h *DefaultMessageHandler) storeSubscription(sub *ActiveSubscription) {
+	if store, node := h.getStore(); store != nil {
+		err := store.AddSubscription(subscriptionRecord(sub, node))
+		h.onStoreError(err, sub.Session, sub.PathHash, "failed to save subscription")
+	}
+}
+
+// forgetSubscriptions removes ended subscriptions from the store, if any.
+func (h *DefaultMessageHandler) forgetSubscriptions(subs []*ActiveSubscription) {
+	store, node := h.getStore()
+	if store == nil {
+		return
+	}
+
+	for _, sub := range subs {
+		err := store.RemoveSubscription(subscriptionRecord(sub, node))
+		h.onStoreError(err, sub.Session, sub.PathHash, "failed to forget subscription")
+	}
+}
+
+// forgetSessionSubscriptions removes the subscriptions of a closed
+// session from the store, if any.
+func (h *DefaultMessageHandler) forgetSessionSubscriptions(sessionID string) {
+	if store, _ := h.getStore(); store != nil {
+		if err := store.RemoveSessionSubscriptions(sessionID); err != nil {
+			fields := slog.Fields{utils.FieldSessionShard: utils.LogSessionShard(sessionID)}
+			h.onError(err, nil, fields, "failed to forget session subscriptions")
+		}
+	}
+}
+
+func (h *DefaultMessageHandler) onStoreError(err error, session Session, pathHash uint32, msg string) {
+	if err != nil {
+		h.onError(err, session, slog.Fields{utils.FieldPathHash: pathHash}, msg)
+	}
+}
+
+// SetSessionStore has the manager save its sessions in store as those of
+// node, and forget them when removed, so other instances of a clustered
+// deployment can look them up. Sessions added before aren't saved, so it
+// should be set before serving. A nil store stops saving them. Failures
+// of the store are logged and don't affect the sessions.
+func (sm *DefaultSessionManager) SetSessionStore(store SessionStore, node string) error {
+	if sm == nil {
+		return core.ErrNilReceiver
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if core.IsNil(store) {
+		sm.store, sm.node = nil, ""
+	} else {
+		sm.store, sm.node = store, node
+	}
+	return nil
+}
+
+func (sm *DefaultSessionManager) getStore() (SessionStore, string) {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	return sm.store, sm.node
+}
+
+// storeSession saves a new session in the store, if any.
+func (sm *DefaultSessionManager) storeSession(session *DefaultSession) {
+	store, node := sm.getStore()
+	if store == nil {
+		return
+	}
+
+	err := store.PutSession(SessionRecord{
+		ConnectedAt: session.ConnectedAt(),
+		ID:          session.ID(),
+		Node:        node,
+		RemoteAddr:  session.RemoteAddr(),
+	})
+	sm.onStoreError(err, session.ID(), "failed to save session")
+}
+
+// forgetSession removes a session from the store, if any.
+func (sm *DefaultSessionManager) forgetSession(sessionID string) {
+	if store, _ := sm.getStore(); store != nil {
+		sm.onStoreError(store.DeleteSession(sessionID), sessionID, "failed to forget session")
+	}
+}
+
+func (sm *DefaultSessionManager) onStoreError(err error, sessionID, msg string) {
+	if err == nil {
+		return
+	}
+
+	if l, ok := sm.WithWarn(err); ok {
+		l = utils.WithSessionID(l, sessionID)
+		l.Print(msg)
+	}
+}
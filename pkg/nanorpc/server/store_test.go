@@ -0,0 +1,178 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+	"darvaza.org/slog"
+)
+
+func TestMemoryStore_Sessions(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+
+	core.AssertMustNoError(t, store.PutSession(SessionRecord{ID: "b", Node: "node1"}), "put b")
+	core.AssertMustNoError(t, store.PutSession(SessionRecord{ID: "a", Node: "node1"}), "put a")
+	core.AssertMustNoError(t, store.PutSession(SessionRecord{ID: "b", Node: "node2", ConnectedAt: now}),
+		"replace b")
+	core.AssertMustNoError(t, store.DeleteSession("missing"), "delete missing")
+
+	sessions, err := store.Sessions()
+	core.AssertMustNoError(t, err, "Sessions")
+	core.AssertMustEqual(t, 2, len(sessions), "sessions")
+	core.AssertEqual(t, "a", sessions[0].ID, "sorted")
+	core.AssertEqual(t, "node2", sessions[1].Node, "replaced")
+	core.AssertTrue(t, sessions[1].ConnectedAt.Equal(now), "connected at")
+
+	core.AssertMustNoError(t, store.DeleteSession("a"), "delete a")
+	sessions, err = store.Sessions()
+	core.AssertMustNoError(t, err, "Sessions")
+	core.AssertEqual(t, 1, len(sessions), "deleted")
+}
+
+func TestMemoryStore_Subscriptions(t *testing.T) {
+	var store MemoryStore // zero value is usable
+	records := core.S(
+		SubscriptionRecord{SessionID: "s1", Node: "node2", RequestID: 1, PathHash: 7},
+		SubscriptionRecord{SessionID: "s1", Node: "node2", RequestID: 2, PathHash: 7},
+		SubscriptionRecord{SessionID: "s2", Node: "node1", RequestID: 1, PathHash: 7},
+		SubscriptionRecord{SessionID: "s2", Node: "node1", RequestID: 3, PathHash: 9},
+	)
+	for _, r := range records {
+		core.AssertMustNoError(t, store.AddSubscription(r), "add")
+	}
+
+	nodes, err := SubscribedNodes(&store, 7)
+	core.AssertMustNoError(t, err, "SubscribedNodes")
+	core.AssertSliceEqual(t, core.S("node1", "node2"), nodes, "nodes")
+
+	core.AssertMustNoError(t, store.RemoveSubscription(records[0]), "remove")
+	subs, err := store.Subscriptions(7)
+	core.AssertMustNoError(t, err, "Subscriptions")
+	core.AssertSliceEqual(t, records[1:3], subs, "after remove")
+
+	core.AssertMustNoError(t, store.RemoveSessionSubscriptions("s2"), "remove session")
+	nodes, err = SubscribedNodes(&store, 7)
+	core.AssertMustNoError(t, err, "SubscribedNodes")
+	core.AssertSliceEqual(t, core.S("node2"), nodes, "after remove session")
+	nodes, err = SubscribedNodes(&store, 9)
+	core.AssertMustNoError(t, err, "SubscribedNodes")
+	core.AssertEqual(t, 0, len(nodes), "none left")
+
+	_, err = SubscribedNodes(nil, 7)
+	core.AssertErrorIs(t, err, core.ErrNilReceiver, "nil store")
+}
+
+// TestDefaultMessageHandler_SetSubscriptionStore verifies subscriptions
+// are saved, and forgotten however they end.
+func TestDefaultMessageHandler_SetSubscriptionStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	h := NewDefaultMessageHandler(nil)
+	core.AssertMustNoError(t, h.SetSubscriptionStore(store, "node1"), "SetSubscriptionStore")
+	pathHash, err := h.hashCache.Hash(testSubscriptionPath)
+	core.AssertMustNoError(t, err, "hash")
+
+	s1 := newTestSession(sessionID1, 0)
+	s2 := newTestSession(sessionID2, 0)
+	for _, s := range core.S(s1, s2) {
+		err := h.HandleMessage(ctx, s, newTestSubscribeRequest(42, testSubscriptionPath, nil))
+		core.AssertMustNoError(t, err, "subscribe %s", s.ID())
+	}
+	core.AssertMustNoError(t, h.HandleMessage(ctx, s1, newTestSubscribeRequest(43, testSubscriptionPath, nil)),
+		"subscribe again")
+
+	subs, err := store.Subscriptions(pathHash)
+	core.AssertMustNoError(t, err, "Subscriptions")
+	core.AssertEqual(t, 3, len(subs), "subscribed")
+	core.AssertEqual(t, SubscriptionRecord{SessionID: sessionID1, Node: "node1", RequestID: 42, PathHash: pathHash},
+		subs[0], "record")
+
+	core.AssertMustNoError(t, h.HandleMessage(ctx, s1, newUnsubscribeRequest(42)), "unsubscribe")
+	assertStoredSubscriptions(t, store, pathHash, 2, "unsubscribed")
+
+	core.AssertMustNoError(t, h.ForceUnsubscribeByHash(sessionID1, pathHash), "ForceUnsubscribeByHash")
+	assertStoredSubscriptions(t, store, pathHash, 1, "forced")
+
+	h.RemoveSubscriptionsForSession(sessionID2)
+	assertStoredSubscriptions(t, store, pathHash, 0, "session removed")
+
+	// without store nothing is saved
+	core.AssertMustNoError(t, h.SetSubscriptionStore(nil, "node1"), "clear store")
+	core.AssertMustNoError(t, h.HandleMessage(ctx, s1, newTestSubscribeRequest(44, testSubscriptionPath, nil)),
+		"subscribe without store")
+	assertStoredSubscriptions(t, store, pathHash, 0, "not saved")
+}
+
+func assertStoredSubscriptions(t *testing.T, store SubscriptionStore, pathHash uint32, want int, name string) {
+	t.Helper()
+
+	subs, err := store.Subscriptions(pathHash)
+	core.AssertMustNoError(t, err, "%s: Subscriptions", name)
+	core.AssertEqual(t, want, len(subs), name)
+}
+
+// failingStore is a [Store] failing every call.
+type failingStore struct{ MemoryStore }
+
+var errStoreDown = errors.New("store down")
+
+func (*failingStore) PutSession(SessionRecord) error           { return errStoreDown }
+func (*failingStore) AddSubscription(SubscriptionRecord) error { return errStoreDown }
+
+// TestSubscriptionStore_failure verifies store failures are reported
+// without refusing the subscription.
+func TestSubscriptionStore_failure(t *testing.T) {
+	h := NewDefaultMessageHandler(nil)
+	core.AssertMustNoError(t, h.SetSubscriptionStore(&failingStore{}, "node1"), "SetSubscriptionStore")
+	var reported []error
+	h.callOnError = func(err error, _ Session, _ slog.Fields, _ string, _ ...any) {
+		reported = append(reported, err)
+	}
+
+	session := newTestSession("", 0)
+	err := h.HandleMessage(context.Background(), session, newTestSubscribeRequest(1, testSubscriptionPath, nil))
+	core.AssertMustNoError(t, err, "subscribe")
+	core.AssertEqual(t, 1, h.SubscriptionCount(session.ID()), "subscribed")
+	core.AssertMustEqual(t, 1, len(reported), "reported")
+	core.AssertErrorIs(t, reported[0], errStoreDown, "error")
+}
+
+// TestDefaultSessionManager_SetSessionStore verifies sessions are saved
+// while open, and [WithStore] sets both stores.
+func TestDefaultSessionManager_SetSessionStore(t *testing.T) {
+	store := NewMemoryStore()
+	handler := NewDefaultMessageHandler(nil)
+	sm := NewDefaultSessionManager(handler, nil)
+	_ = NewServer(nil, sm, handler, nil, WithStore(store, "node1"))
+	pathHash, err := handler.hashCache.Hash(testSubscriptionPath)
+	core.AssertMustNoError(t, err, "hash")
+
+	session := sm.AddSession(&mockConn{remoteAddr: "127.0.0.1:12345"})
+	sessions, err := store.Sessions()
+	core.AssertMustNoError(t, err, "Sessions")
+	core.AssertMustEqual(t, 1, len(sessions), "saved")
+	core.AssertEqual(t, session.ID(), sessions[0].ID, "ID")
+	core.AssertEqual(t, "node1", sessions[0].Node, "Node")
+	core.AssertEqual(t, "127.0.0.1:12345", sessions[0].RemoteAddr, "RemoteAddr")
+	core.AssertFalse(t, sessions[0].ConnectedAt.IsZero(), "ConnectedAt")
+
+	err = handler.HandleMessage(context.Background(), session, newTestSubscribeRequest(1, testSubscriptionPath, nil))
+	core.AssertMustNoError(t, err, "subscribe")
+	nodes, err := SubscribedNodes(store, pathHash)
+	core.AssertMustNoError(t, err, "SubscribedNodes")
+	core.AssertSliceEqual(t, core.S("node1"), nodes, "nodes")
+
+	sm.RemoveSession(session.ID())
+	sessions, err = store.Sessions()
+	core.AssertMustNoError(t, err, "Sessions")
+	core.AssertEqual(t, 0, len(sessions), "forgotten")
+	assertStoredSubscriptions(t, store, pathHash, 0, "subscriptions")
+
+	// failures are logged only
+	core.AssertMustNoError(t, sm.SetSessionStore(&failingStore{}, "node1"), "failing store")
+	core.AssertNotNil(t, sm.AddSession(&mockConn{remoteAddr: "127.0.0.1:12346"}), "added")
+}
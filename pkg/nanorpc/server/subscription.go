@@ -2,81 +2,57 @@ package server
 
 import (
 	"context"
+	"slices"
 	"time"
 
 	"darvaza.org/core"
 	"darvaza.org/slog"
-	"darvaza.org/x/container/list"
 
 	"protomcp.org/nanorpc/pkg/nanorpc"
 	"protomcp.org/nanorpc/pkg/nanorpc/utils"
 )
 
-// SubscriptionMap manages subscriptions organized by path hash
-type SubscriptionMap map[uint32]*list.List[*ActiveSubscription]
-
-// AddSubscription adds a subscription to the map
-func (sm SubscriptionMap) AddSubscription(pathHash uint32, sub *ActiveSubscription) {
-	subList := sm[pathHash]
-	if subList == nil {
-		subList = list.New[*ActiveSubscription]()
-		sm[pathHash] = subList
-	}
-	subList.PushBack(sub)
-}
-
-// GetSubscribers returns the list of subscribers for a path hash
-func (sm SubscriptionMap) GetSubscribers(pathHash uint32) *list.List[*ActiveSubscription] {
-	return sm[pathHash]
-}
-
-// RemoveForSession removes all subscriptions for a given session ID
-func (sm SubscriptionMap) RemoveForSession(sessionID string) {
-	for pathHash, subList := range sm {
-		if subList == nil {
-			continue
-		}
-
-		subList.DeleteMatchFn(func(sub *ActiveSubscription) bool {
-			return sub.Session != nil && sub.Session.ID() == sessionID
-		})
-
-		// Remove empty lists to prevent memory leaks
-		if subList.Len() == 0 {
-			delete(sm, pathHash)
-		}
-	}
-}
-
 // ActiveSubscription tracks a live subscription in a session
 type ActiveSubscription struct {
 	// Session identification (8-byte aligned fields first)
-	Session   Session   // Reference to client session
-	CreatedAt time.Time // When subscription was created
-	Filter    []byte    // Request data used as filter criteria
+	Session   Session     // Reference to client session
+	CreatedAt time.Time   // When subscription was created
+	ack       *ackTracker // Acknowledged updates, if asked for
+	Filter    []byte      // Request data used as filter criteria, see FilterEvaluator
 
 	// 4-byte aligned fields
 	RequestID int32  // Client's original request ID for correlation
 	PathHash  uint32 // FNV-1a hash of path (primary lookup key)
 }
 
-// Subscribe adds a new subscription for the given path and request
+// Subscribe adds a new subscription for the given path and request.
+// On paths with replay enabled, a request resuming after a sequence is
+// caught up right after the acknowledgement, see
+// [DefaultMessageHandler.EnableReplay]. Otherwise the acknowledgement is
+// followed by the history requested or the retained update, see
+// [DefaultMessageHandler.EnableRetain].
+//
+// Paths with the syntax of handler patterns, or [SingleLevelWildcard]
+// segments, subscribe to every path they match, as in
+// /sensors/+/temperature or /sensors/*. Updates published on those paths
+// carry the path they were published on, see [nanorpc.NanoRPCResponse].
+//
+// Requests asking for acknowledged updates on a path with replay get the
+// updates the client doesn't acknowledge with a TYPE_ACK sent again,
+// unfiltered, see [DefaultMessageHandler.SetAckTimeout].
 func (h *DefaultMessageHandler) Subscribe(_ context.Context, session Session, req *nanorpc.NanoRPCRequest) error {
 	if h == nil {
 		return core.ErrNilReceiver
 	}
 
-	// Resolve path from hash or string using existing logic
-	_, pathHash, err := h.hashCache.ResolvePath(req)
-	if err != nil {
-		return sendErrorResponse(session, req, nanorpc.NanoRPCResponse_STATUS_INTERNAL_ERROR,
-			"failed to resolve subscription path")
+	pathHash, pattern, refused := h.resolveSubscription(req)
+	if refused != nil {
+		return sendErrorResponse(session, req, refused.Status, refused.Msg)
 	}
 
-	// Validate that we have a valid path
-	if pathHash == 0 {
-		return sendErrorResponse(session, req, nanorpc.NanoRPCResponse_STATUS_INTERNAL_ERROR,
-			"invalid subscription path")
+	if !h.allowed(session, pathHash) {
+		return sendErrorResponse(session, req, nanorpc.NanoRPCResponse_STATUS_NOT_AUTHORIZED,
+			"not authorized")
 	}
 
 	// Create subscription
@@ -85,24 +61,111 @@ func (h *DefaultMessageHandler) Subscribe(_ context.Context, session Session, re
 		RequestID: req.RequestId,
 		PathHash:  pathHash,
 		CreatedAt: time.Now(),
-		Filter:    req.Data, // Use request data as filter criteria
+		Filter:    slices.Clone(req.Data), // Use request data as filter criteria
 	}
 
-	// Add to subscription list
-	h.mu.Lock()
-	defer h.mu.Unlock()
+	err := h.addSubscription(req, subscription, pattern)
+	// saved even if the acknowledgement failed, as it stays until the
+	// session is removed
+	h.storeSubscription(subscription)
+	return err
+}
+
+// addSubscription adds a subscription to the shard of its path, holding
+// its lock until acknowledged so no update precedes the acknowledgement.
+func (h *DefaultMessageHandler) addSubscription(req *nanorpc.NanoRPCRequest, subscription *ActiveSubscription,
+	pattern *routePattern) error {
+	pathHash, session := subscription.PathHash, subscription.Session
+
+	unlock := h.lockSubscribe(pattern)
+	h.unsafeAddSubscriptionPattern(pattern)
+
+	shard := h.subscriptions.shard(pathHash)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	h.unsafeNewAckTracker(subscription, req, pattern)
+	shard.unsafeAdd(pathHash, subscription)
+	h.unsafeReportSubscriptions()
+	responses := h.unsafeAcknowledgement(req, pathHash)
+	unlock()
+
+	return sendAcknowledgement(session, req, responses)
+}
 
-	// Add subscription using the map's method
-	h.subscriptions.AddSubscription(pathHash, subscription)
+// lockSubscribe takes the handler lock a new subscription needs, returning
+// the function releasing it. Subscriptions to patterns change those
+// published paths are matched against and take the write lock, others
+// only the read lock, leaving them to the lock of their shard, see
+// [SubscriptionMap].
+func (h *DefaultMessageHandler) lockSubscribe(pattern *routePattern) func() {
+	if pattern != nil {
+		h.mu.Lock()
+		return h.mu.Unlock
+	}
+
+	h.mu.RLock()
+	return h.mu.RUnlock
+}
+
+// resolveSubscription resolves the path of a subscription request, and
+// parses it if it's a pattern, returning why it's refused otherwise.
+func (h *DefaultMessageHandler) resolveSubscription(req *nanorpc.NanoRPCRequest) (uint32, *routePattern,
+	*nanorpc.Error) {
+	// Resolve path from hash or string using existing logic
+	path, pathHash, err := h.hashCache.ResolvePath(req)
+	if err != nil {
+		return 0, nil, nanorpc.InternalErrorf("failed to resolve subscription path")
+	}
+
+	// Validate that we have a valid path
+	if pathHash == 0 {
+		return 0, nil, nanorpc.BadRequestf("invalid subscription path")
+	}
+
+	pattern, err := newSubscriptionPattern(path, pathHash)
+	if err != nil {
+		return 0, nil, nanorpc.BadRequestf("invalid subscription pattern")
+	}
+	return pathHash, pattern, nil
+}
 
-	// Send acknowledgment response
+// unsafeAcknowledgement returns the subscription acknowledgement followed
+// by any catch-up, history or retained updates. h.mu must be held.
+func (h *DefaultMessageHandler) unsafeAcknowledgement(req *nanorpc.NanoRPCRequest,
+	pathHash uint32) []*nanorpc.NanoRPCResponse {
 	response := &nanorpc.NanoRPCResponse{
 		RequestId:      req.RequestId,
 		ResponseType:   nanorpc.NanoRPCResponse_TYPE_RESPONSE,
 		ResponseStatus: nanorpc.NanoRPCResponse_STATUS_OK,
 	}
 
-	return session.SendResponse(req, response)
+	var updates []*nanorpc.NanoRPCResponse
+	if buf := h.replay[pathHash]; buf != nil {
+		updates = buf.catchUp(req, response)
+	}
+	if req.ResumeAfter == 0 {
+		updates = h.unsafeHistory(req, pathHash)
+	}
+	return append([]*nanorpc.NanoRPCResponse{response}, updates...)
+}
+
+// sendAcknowledgement sends the acknowledgement of a subscription and the
+// updates following it. Called holding the write lock of the shard of
+// the path, but not h.mu, it keeps them ahead of concurrent publications
+// on it without holding back the rest.
+func sendAcknowledgement(session Session, req *nanorpc.NanoRPCRequest,
+	responses []*nanorpc.NanoRPCResponse) error {
+	if err := session.SendResponse(req, responses[0]); err != nil {
+		return err
+	}
+
+	for _, update := range responses[1:] {
+		if err := session.SendResponse(nil, update); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Publish sends an update to all subscribers of a given path
@@ -121,22 +184,33 @@ func (h *DefaultMessageHandler) Publish(path string, data []byte) error {
 }
 
 // PublishByHash sends an update to all subscribers of a given path hash
+// whose filter matches it, see [FilterEvaluator].
 func (h *DefaultMessageHandler) PublishByHash(pathHash uint32, data []byte) error {
 	if h == nil {
 		return core.ErrNilReceiver
 	}
 
 	// Collect updates while holding the lock
+	start := time.Now()
 	updates := h.collectPendingUpdates(pathHash, data)
+	updates = h.filterUpdates(pathHash, updates)
 
 	// Send all updates outside the lock to prevent blocking
+	err := h.sendUpdates(pathHash, updates)
+	h.observePublish(pathHash, len(updates), start)
+	return err
+}
+
+// sendUpdates sends collected updates, reporting failures through the
+// error handler and returning the first.
+func (h *DefaultMessageHandler) sendUpdates(pathHash uint32, updates []pendingUpdate) error {
 	var firstErr error
 	for _, update := range updates {
-		if err := update.session.SendResponse(nil, update.message); err != nil {
+		if err := update.send(); err != nil {
 			// Report error via callback
 			fields := slog.Fields{
-				utils.FieldPathHash:  pathHash,
-				utils.FieldSessionID: update.session.ID(),
+				utils.FieldPathHash:     pathHash,
+				utils.FieldSessionShard: utils.LogSessionShard(update.session.ID()),
 			}
 			h.onError(err, update.session, fields, "failed to send subscription update")
 			if firstErr == nil {
@@ -144,14 +218,76 @@ func (h *DefaultMessageHandler) PublishByHash(pathHash uint32, data []byte) erro
 			}
 		}
 	}
-
 	return firstErr
 }
 
+// PublishTo sends a one-off update to the subscriptions of a single
+// session to a path, e.g. to push a command to one device. It isn't kept
+// for replay nor numbered. Returns [ErrNoSubscription] if the session
+// isn't subscribed to the path, or [ErrAccessDenied] if the access rules
+// of the path no longer allow it.
+func (h *DefaultMessageHandler) PublishTo(sessionID, path string, data []byte) error {
+	if h == nil {
+		return core.ErrNilReceiver
+	}
+
+	pathHash, err := h.hashCache.Hash(path)
+	if err != nil {
+		return core.Wrapf(err, "failed to hash path %q", path)
+	}
+
+	updates, err := h.collectSessionUpdates(sessionID, pathHash, data)
+	if err != nil {
+		return err
+	}
+	return h.sendUpdates(pathHash, updates)
+}
+
+// collectSessionUpdates gathers the updates for the subscriptions of a
+// session to a path hash.
+func (h *DefaultMessageHandler) collectSessionUpdates(sessionID string, pathHash uint32,
+	data []byte) ([]pendingUpdate, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	subs := h.unsafeSessionSubscriptions(sessionID, pathHash)
+	switch {
+	case len(subs) == 0:
+		return nil, core.QuietWrap(ErrNoSubscription, "session %q", sessionID)
+	case !h.unsafeAllowed(subs[0].Session, pathHash):
+		return nil, core.QuietWrap(ErrAccessDenied, "session %q", sessionID)
+	}
+
+	updates := make([]pendingUpdate, 0, len(subs))
+	for _, sub := range subs {
+		updates = append(updates, pendingUpdate{
+			session: sub.Session,
+			message: newUpdateResponse(sub.RequestID, data, 0),
+		})
+	}
+	return updates, nil
+}
+
+// unsafeSessionSubscriptions returns the subscriptions of a session to a
+// path hash.
+func (h *DefaultMessageHandler) unsafeSessionSubscriptions(sessionID string,
+	pathHash uint32) []*ActiveSubscription {
+	var subs []*ActiveSubscription
+	h.subscriptions.ForEach(pathHash, func(sub *ActiveSubscription) bool {
+		if sub.Session != nil && sub.Session.ID() == sessionID {
+			subs = append(subs, sub)
+		}
+		return true
+	})
+	return subs
+}
+
 // pendingUpdate represents an update ready to be sent to a subscriber
 type pendingUpdate struct {
 	session Session
 	message *nanorpc.NanoRPCResponse
+	shared  *sharedUpdate // encoded once for every subscription, if set
+	filter  []byte
 }
 
 // collectPendingUpdates gathers all updates for a path hash while holding the lock
@@ -159,37 +295,42 @@ func (h *DefaultMessageHandler) collectPendingUpdates(pathHash uint32, data []by
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
-	subList := h.subscriptions.GetSubscribers(pathHash)
-	if subList == nil || subList.Len() == 0 {
-		return nil
-	}
+	// Subscriptions to the path first, then to patterns matching it,
+	// skipping those the access rules no longer allow
+	updates, pub := h.unsafeCollectPath(pathHash, data)
+	return h.unsafeCollectPatterns(updates, pub)
+}
 
-	// Start with no pre-allocation to avoid memory waste
-	// List may contain expired sessions
-	var updates []pendingUpdate
+// unsafeCollectPath records an update and gathers those for the
+// subscriptions to its path, holding the read lock of their shard so new
+// subscriptions are acknowledged either before or after it. h.mu must be
+// held.
+func (h *DefaultMessageHandler) unsafeCollectPath(pathHash uint32, data []byte) ([]pendingUpdate, publication) {
+	shard := h.subscriptions.shard(pathHash)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
 
-	// Iterate through all subscriptions for this path
-	subList.ForEach(func(sub *ActiveSubscription) bool {
-		if sub.Session != nil {
-			// Create update message
-			update := &nanorpc.NanoRPCResponse{
-				RequestId:      sub.RequestID, // Use original request ID for correlation
-				ResponseType:   nanorpc.NanoRPCResponse_TYPE_UPDATE,
-				ResponseStatus: nanorpc.NanoRPCResponse_STATUS_OK,
-				Data:           data,
-			}
-			updates = append(updates, pendingUpdate{
-				session: sub.Session,
-				message: update,
-			})
-		}
-		return true
-	})
+	seq := h.unsafeRecordUpdate(pathHash, data)
+	h.unsafeRetain(pathHash, data, seq)
+
+	pub := publication{data: data, seq: seq, hash: pathHash}
+	return h.unsafeCollect(nil, shard.unsafeGet(pathHash), pub), pub
+}
 
-	return updates
+// newUpdateResponse creates a TYPE_UPDATE message for a subscription.
+func newUpdateResponse(requestID int32, data []byte, seq uint64) *nanorpc.NanoRPCResponse {
+	return &nanorpc.NanoRPCResponse{
+		RequestId:      requestID,
+		ResponseType:   nanorpc.NanoRPCResponse_TYPE_UPDATE,
+		ResponseStatus: nanorpc.NanoRPCResponse_STATUS_OK,
+		Sequence:       seq,
+		Data:           data,
+	}
 }
 
-// RemoveSubscriptionsForSession removes all subscriptions for a given session
+// RemoveSubscriptionsForSession removes all subscriptions for a given session,
+// and forgets its identity and handshake, publishing the last will the
+// handshake carried, if any, see [nanorpc.NanoRPCHello].
 // This should be called when a session disconnects
 func (h *DefaultMessageHandler) RemoveSubscriptionsForSession(sessionID string) {
 	if h == nil {
@@ -197,10 +338,22 @@ func (h *DefaultMessageHandler) RemoveSubscriptionsForSession(sessionID string)
 	}
 
 	h.mu.Lock()
-	defer h.mu.Unlock()
+	pathHash, will, ok := h.unsafeSessionWill(sessionID)
 
 	// Use the map's method to remove subscriptions
 	h.subscriptions.RemoveForSession(sessionID)
+	delete(h.identities, sessionID)
+	delete(h.hellos, sessionID)
+	h.unsafeReportSubscriptions()
+	h.mu.Unlock()
+
+	h.forgetSessionSubscriptions(sessionID)
+
+	if ok {
+		// once the session no longer receives updates, failures are
+		// reported to the error handler
+		_ = h.PublishByHash(pathHash, will)
+	}
 }
 
 // unsubscribeByRequestID removes a specific subscription identified by
@@ -211,24 +364,19 @@ func (h *DefaultMessageHandler) unsubscribeByRequestID(sessionID string,
 		return false
 	}
 
-	h.mu.Lock()
-	defer h.mu.Unlock()
-
-	subList := h.subscriptions[pathHash]
-	if subList == nil {
-		return false
-	}
-
+	h.mu.RLock()
 	// Remove the subscription with matching session and request ID
-	var removed bool
-	subList.DeleteMatchFn(func(sub *ActiveSubscription) bool {
-		match := sub.Session != nil &&
+	removed := h.subscriptions.Remove(pathHash, func(sub *ActiveSubscription) bool {
+		return sub.Session != nil &&
 			sub.Session.ID() == sessionID &&
 			sub.RequestID == requestID
-		if match {
-			removed = true
-		}
-		return match
 	})
-	return removed
+
+	if len(removed) > 0 {
+		h.unsafeReportSubscriptions()
+	}
+	h.mu.RUnlock()
+
+	h.forgetSubscriptions(removed)
+	return len(removed) > 0
 }
@@ -0,0 +1,208 @@
+package server
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"darvaza.org/x/container/list"
+)
+
+// subscriptionShards is the number of shards of a [SubscriptionMap], a
+// power of two.
+const subscriptionShards = 32
+
+// SubscriptionMap manages subscriptions organized by path hash. It's
+// sharded by path hash, each shard with its own lock, so subscriptions,
+// unsubscriptions and publications on different paths don't contend.
+// The zero value is ready to use, and it's safe for concurrent use.
+type SubscriptionMap struct {
+	shards [subscriptionShards]subscriptionShard
+}
+
+// subscriptionShard holds the subscriptions to the path hashes of one
+// shard of a [SubscriptionMap].
+type subscriptionShard struct {
+	lists map[uint32]*list.List[*ActiveSubscription] // PathHash -> subscription list
+	n     atomic.Int64                               // subscriptions held
+	mu    sync.RWMutex
+	_     [24]byte // pad to a cache line, so shards don't contend
+}
+
+// NewSubscriptionMap creates an empty [SubscriptionMap].
+func NewSubscriptionMap() *SubscriptionMap {
+	return &SubscriptionMap{}
+}
+
+// shard returns the shard holding the subscriptions to a path hash.
+func (sm *SubscriptionMap) shard(pathHash uint32) *subscriptionShard {
+	return &sm.shards[pathHash&(subscriptionShards-1)]
+}
+
+// AddSubscription adds a subscription to the map
+func (sm *SubscriptionMap) AddSubscription(pathHash uint32, sub *ActiveSubscription) {
+	s := sm.shard(pathHash)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.unsafeAdd(pathHash, sub)
+}
+
+// GetSubscribers returns a copy of the list of subscribers for a path
+// hash, or nil if there are none.
+func (sm *SubscriptionMap) GetSubscribers(pathHash uint32) *list.List[*ActiveSubscription] {
+	s := sm.shard(pathHash)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	subList := s.unsafeGet(pathHash)
+	if subList == nil {
+		return nil
+	}
+
+	out := list.New[*ActiveSubscription]()
+	subList.ForEach(func(sub *ActiveSubscription) bool {
+		out.PushBack(sub)
+		return true
+	})
+	return out
+}
+
+// ForEach calls fn with the subscribers for a path hash, in the order
+// they subscribed, until it returns false. fn is called holding the lock
+// of the shard and must not modify the map.
+func (sm *SubscriptionMap) ForEach(pathHash uint32, fn func(*ActiveSubscription) bool) {
+	s := sm.shard(pathHash)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if subList := s.unsafeGet(pathHash); subList != nil {
+		subList.ForEach(fn)
+	}
+}
+
+// Range calls fn with every subscription in the map, a shard at a time,
+// until it returns false. fn is called holding the lock of the shard and
+// must not modify the map.
+func (sm *SubscriptionMap) Range(fn func(*ActiveSubscription) bool) {
+	for i := range sm.shards {
+		if !sm.shards[i].rangeAll(fn) {
+			return
+		}
+	}
+}
+
+// Remove removes the subscriptions for a path hash fn matches, returning
+// them.
+func (sm *SubscriptionMap) Remove(pathHash uint32, fn func(*ActiveSubscription) bool) []*ActiveSubscription {
+	s := sm.shard(pathHash)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.unsafeRemove(pathHash, fn)
+}
+
+// RemoveForSession removes all subscriptions for a given session ID
+func (sm *SubscriptionMap) RemoveForSession(sessionID string) {
+	match := func(sub *ActiveSubscription) bool {
+		return sub.Session != nil && sub.Session.ID() == sessionID
+	}
+
+	for i := range sm.shards {
+		sm.shards[i].removeAll(match)
+	}
+}
+
+// Len returns the number of subscriptions in the map.
+func (sm *SubscriptionMap) Len() int {
+	var n int64
+	for i := range sm.shards {
+		n += sm.shards[i].n.Load()
+	}
+	return int(n)
+}
+
+// has tells if there are subscriptions to a path hash.
+func (sm *SubscriptionMap) has(pathHash uint32) bool {
+	s := sm.shard(pathHash)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.unsafeGet(pathHash) != nil
+}
+
+// unsafeGet returns the subscriptions to a path hash. s.mu must be held.
+func (s *subscriptionShard) unsafeGet(pathHash uint32) *list.List[*ActiveSubscription] {
+	return s.lists[pathHash]
+}
+
+// unsafeAdd adds a subscription to a path hash. s.mu must be held for
+// writing.
+func (s *subscriptionShard) unsafeAdd(pathHash uint32, sub *ActiveSubscription) {
+	subList := s.lists[pathHash]
+	if subList == nil {
+		if s.lists == nil {
+			s.lists = make(map[uint32]*list.List[*ActiveSubscription])
+		}
+		subList = list.New[*ActiveSubscription]()
+		s.lists[pathHash] = subList
+	}
+	subList.PushBack(sub)
+	s.n.Add(1)
+}
+
+// unsafeRemove removes and returns the subscriptions to a path hash fn
+// matches, forgetting the path hash once it has none. s.mu must be held
+// for writing.
+func (s *subscriptionShard) unsafeRemove(pathHash uint32,
+	fn func(*ActiveSubscription) bool) []*ActiveSubscription {
+	subList := s.lists[pathHash]
+	if subList == nil {
+		return nil
+	}
+
+	var removed []*ActiveSubscription
+	subList.DeleteMatchFn(func(sub *ActiveSubscription) bool {
+		match := fn(sub)
+		if match {
+			removed = append(removed, sub)
+		}
+		return match
+	})
+
+	// Remove empty lists to prevent memory leaks
+	if subList.Len() == 0 {
+		delete(s.lists, pathHash)
+	}
+	s.n.Add(-int64(len(removed)))
+	return removed
+}
+
+// removeAll removes the subscriptions fn matches from every path hash of
+// the shard.
+func (s *subscriptionShard) removeAll(fn func(*ActiveSubscription) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for pathHash := range s.lists {
+		s.unsafeRemove(pathHash, fn)
+	}
+}
+
+// rangeAll calls fn with every subscription of the shard, returning
+// false if fn stopped it.
+func (s *subscriptionShard) rangeAll(fn func(*ActiveSubscription) bool) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, subList := range s.lists {
+		more := true
+		subList.ForEach(func(sub *ActiveSubscription) bool {
+			more = fn(sub)
+			return more
+		})
+		if !more {
+			return false
+		}
+	}
+	return true
+}
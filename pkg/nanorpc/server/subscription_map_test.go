@@ -0,0 +1,213 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"darvaza.org/core"
+	"darvaza.org/x/container/list"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+func TestSubscriptionMap_shards(t *testing.T) {
+	var sm SubscriptionMap // zero value ready to use
+
+	// path hashes spread over every shard, and sharing them
+	for i := range 2 * subscriptionShards {
+		sm.AddSubscription(uint32(i), newTestSubscription(sessionID1, int32(i), uint32(i)))
+		sm.AddSubscription(uint32(i), newTestSubscription(sessionID2, int32(i), uint32(i)))
+	}
+	core.AssertEqual(t, 4*subscriptionShards, sm.Len(), "len")
+
+	var ids []int32
+	sm.ForEach(3, func(sub *ActiveSubscription) bool {
+		ids = append(ids, sub.RequestID)
+		return sub.Session.ID() != sessionID1
+	})
+	core.AssertSliceEqual(t, []int32{3}, ids, "stopped")
+
+	var n int
+	sm.Range(func(sub *ActiveSubscription) bool {
+		if sub.Session.ID() == sessionID2 {
+			n++
+		}
+		return true
+	})
+	core.AssertEqual(t, 2*subscriptionShards, n, "range")
+
+	removed := sm.Remove(3, func(sub *ActiveSubscription) bool {
+		return sub.Session.ID() == sessionID1
+	})
+	core.AssertEqual(t, 1, len(removed), "removed")
+	core.AssertEqual(t, 1, sm.GetSubscribers(3).Len(), "left")
+	core.AssertEqual(t, 0, len(sm.Remove(99, func(*ActiveSubscription) bool { return true })), "unknown")
+
+	sm.RemoveForSession(sessionID2)
+	core.AssertNil(t, sm.GetSubscribers(3), "path removed")
+	core.AssertFalse(t, sm.has(3), "has")
+	core.AssertEqual(t, 2*subscriptionShards-1, sm.Len(), "len after")
+}
+
+func TestSubscriptionMap_GetSubscribers_copy(t *testing.T) {
+	sm := NewSubscriptionMap()
+	sm.AddSubscription(1, newTestSubscription(sessionID1, 1, 1))
+
+	subList := sm.GetSubscribers(1)
+	subList.PushBack(newTestSubscription(sessionID2, 2, 1))
+	core.AssertEqual(t, 1, sm.GetSubscribers(1).Len(), "unchanged")
+}
+
+func TestSubscriptionMap_concurrent(t *testing.T) {
+	var sm SubscriptionMap
+	var wg sync.WaitGroup
+
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			pathHash := uint32(i % 3) // some shared, some not
+			sub := newTestSubscription(sessionID1, int32(i), pathHash)
+			for range 100 {
+				sm.AddSubscription(pathHash, sub)
+				sm.ForEach(pathHash, func(*ActiveSubscription) bool { return true })
+				sm.Range(func(*ActiveSubscription) bool { return true })
+				sm.Remove(pathHash, func(s *ActiveSubscription) bool { return s == sub })
+			}
+		}()
+	}
+	wg.Wait()
+
+	core.AssertEqual(t, 0, sm.Len(), "len")
+}
+
+// lockedSubscriptionMap is a SubscriptionMap behind a single lock, as
+// before sharding, for comparison.
+type lockedSubscriptionMap struct {
+	lists map[uint32]*list.List[*ActiveSubscription]
+	mu    sync.RWMutex
+}
+
+func (m *lockedSubscriptionMap) AddSubscription(pathHash uint32, sub *ActiveSubscription) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	subList := m.lists[pathHash]
+	if subList == nil {
+		subList = list.New[*ActiveSubscription]()
+		m.lists[pathHash] = subList
+	}
+	subList.PushBack(sub)
+}
+
+func (m *lockedSubscriptionMap) ForEach(pathHash uint32, fn func(*ActiveSubscription) bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if subList := m.lists[pathHash]; subList != nil {
+		subList.ForEach(fn)
+	}
+}
+
+func (m *lockedSubscriptionMap) Remove(pathHash uint32, fn func(*ActiveSubscription) bool) []*ActiveSubscription {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	subList := m.lists[pathHash]
+	if subList == nil {
+		return nil
+	}
+
+	var removed []*ActiveSubscription
+	subList.DeleteMatchFn(func(sub *ActiveSubscription) bool {
+		match := fn(sub)
+		if match {
+			removed = append(removed, sub)
+		}
+		return match
+	})
+	if subList.Len() == 0 {
+		delete(m.lists, pathHash)
+	}
+	return removed
+}
+
+// benchSubscriptionMap is what the subscription map benchmarks use.
+type benchSubscriptionMap interface {
+	AddSubscription(pathHash uint32, sub *ActiveSubscription)
+	ForEach(pathHash uint32, fn func(*ActiveSubscription) bool)
+	Remove(pathHash uint32, fn func(*ActiveSubscription) bool) []*ActiveSubscription
+}
+
+// benchmarkSubscriptionChurn subscribes, publishes to and unsubscribes
+// from a path per goroutine, as many clients coming and going would.
+func benchmarkSubscriptionChurn(b *testing.B, sm benchSubscriptionMap) {
+	var next atomic.Uint32
+	b.RunParallel(func(pb *testing.PB) {
+		pathHash := next.Add(1) * 2654435761 // spread as FNV-1a would
+		sub := newTestSubscription(sessionID1, 1, pathHash)
+		match := func(s *ActiveSubscription) bool { return s == sub }
+
+		for pb.Next() {
+			sm.AddSubscription(pathHash, sub)
+			for range 4 {
+				sm.ForEach(pathHash, func(*ActiveSubscription) bool { return true })
+			}
+			sm.Remove(pathHash, match)
+		}
+	})
+}
+
+// BenchmarkSubscriptionMap compares the sharded map against a single
+// lock under concurrent churn, e.g. with -cpu 1,4,16.
+func BenchmarkSubscriptionMap(b *testing.B) {
+	b.Run("sharded", func(b *testing.B) {
+		benchmarkSubscriptionChurn(b, NewSubscriptionMap())
+	})
+	b.Run("single-lock", func(b *testing.B) {
+		benchmarkSubscriptionChurn(b, &lockedSubscriptionMap{
+			lists: make(map[uint32]*list.List[*ActiveSubscription]),
+		})
+	})
+}
+
+// discardSession is a session dropping what it's sent.
+type discardSession struct {
+	*mockSession
+}
+
+func (*discardSession) SendResponse(*nanorpc.NanoRPCRequest, *nanorpc.NanoRPCResponse) error {
+	return nil
+}
+
+// BenchmarkDefaultMessageHandler_churn subscribes, publishes to and
+// unsubscribes from a path per goroutine through the handler.
+func BenchmarkDefaultMessageHandler_churn(b *testing.B) {
+	ctx := context.Background()
+	h := NewDefaultMessageHandler(nil)
+
+	var next atomic.Int32
+	b.RunParallel(func(pb *testing.PB) {
+		id := next.Add(1)
+		session := &discardSession{newTestSession(fmt.Sprintf("bench-%d", id), 0)}
+		path := fmt.Sprintf("/bench/%d", id)
+		pathHash, err := h.hashCache.Hash(path)
+		if err != nil {
+			b.Error(err)
+			return
+		}
+		req := newTestSubscribeRequest(id, path, nil)
+
+		for pb.Next() {
+			_ = h.Subscribe(ctx, session, req)
+			for range 4 {
+				_ = h.PublishByHash(pathHash, []byte("x"))
+			}
+			h.unsubscribeByRequestID(session.ID(), id, pathHash)
+		}
+	})
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"darvaza.org/x/container/list"
+)
+
+// SingleLevelWildcard is a segment of a subscription pattern matching any
+// one segment of the published path, as in /sensors/+/temperature.
+const SingleLevelWildcard = "+"
+
+// isSubscriptionPattern tells if a subscription path is a pattern, using
+// the syntax of handler paths or [SingleLevelWildcard] segments.
+func isSubscriptionPattern(path string) bool {
+	if isPathPattern(path) {
+		return true
+	}
+	for _, s := range strings.Split(path, "/") {
+		if s == SingleLevelWildcard {
+			return true
+		}
+	}
+	return false
+}
+
+// newSubscriptionPattern parses a subscription pattern, returning nil if
+// path isn't one. [SingleLevelWildcard] segments are unnamed parameters.
+func newSubscriptionPattern(path string, pathHash uint32) (*routePattern, error) {
+	if !isSubscriptionPattern(path) {
+		return nil, nil
+	}
+
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if s == SingleLevelWildcard {
+			// named after its position
+			segments[i] = fmt.Sprintf("{%s%d}", s, i)
+		}
+	}
+
+	p, err := newRoutePattern(strings.Join(segments, "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	p.path = path
+	p.hash = pathHash
+	return p, nil
+}
+
+// unsafeAddSubscriptionPattern keeps the pattern of a new subscription,
+// forgetting those left without subscriptions. h.mu must be held for
+// writing, and no shard lock.
+func (h *DefaultMessageHandler) unsafeAddSubscriptionPattern(p *routePattern) {
+	if p == nil {
+		return
+	}
+
+	for hash := range h.subPatterns {
+		if !h.subscriptions.has(hash) {
+			delete(h.subPatterns, hash)
+		}
+	}
+
+	if h.subPatterns == nil {
+		h.subPatterns = make(map[uint32]*routePattern)
+	}
+	h.subPatterns[p.hash] = p
+}
+
+// publication is an update being published on a path.
+type publication struct {
+	data []byte
+	path string // set for pattern subscriptions
+	seq  uint64
+	hash uint32
+}
+
+// unsafeCollect appends the updates for the subscriptions in subList the
+// access rules of the published path allow. The caller must hold at least
+// a read lock.
+func (h *DefaultMessageHandler) unsafeCollect(updates []pendingUpdate,
+	subList *list.List[*ActiveSubscription], pub publication) []pendingUpdate {
+	if subList == nil {
+		return updates
+	}
+
+	// List may contain expired sessions
+	shared := newSharedUpdate(pub)
+	subList.ForEach(func(sub *ActiveSubscription) bool {
+		if sub.Session != nil && h.unsafeAllowed(sub.Session, pub.hash) {
+			// Use original request ID for correlation
+			message := newUpdateResponse(sub.RequestID, pub.data, pub.seq)
+			message.Path = pub.path
+			sub.ack.track(h, sub, pub.seq)
+			updates = append(updates, pendingUpdate{
+				session: sub.Session,
+				message: message,
+				shared:  shared,
+				filter:  sub.Filter,
+			})
+		}
+		return true
+	})
+	return updates
+}
+
+// unsafeCollectPatterns appends the updates for the subscriptions to
+// patterns matching the published path, carrying the path. The caller
+// must hold at least a read lock.
+func (h *DefaultMessageHandler) unsafeCollectPatterns(updates []pendingUpdate,
+	pub publication) []pendingUpdate {
+	if len(h.subPatterns) == 0 {
+		return updates
+	}
+
+	path, ok := h.hashCache.Path(pub.hash)
+	if !ok {
+		return updates
+	}
+
+	pub.path = path
+	for hash, p := range h.subPatterns {
+		if _, ok := p.match(path); ok {
+			updates = h.unsafeCollectShard(updates, hash, pub)
+		}
+	}
+	return updates
+}
+
+// unsafeCollectShard appends the updates for the subscriptions to a
+// pattern, holding the read lock of its shard. h.mu must be held, and no
+// shard lock.
+func (h *DefaultMessageHandler) unsafeCollectShard(updates []pendingUpdate, hash uint32,
+	pub publication) []pendingUpdate {
+	shard := h.subscriptions.shard(hash)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+
+	return h.unsafeCollect(updates, shard.unsafeGet(hash), pub)
+}
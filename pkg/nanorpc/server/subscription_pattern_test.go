@@ -0,0 +1,189 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+const patternTestPath = "/sensors/+/temperature"
+
+var _ core.TestCase = subscriptionPatternTestCase{}
+
+type subscriptionPatternTestCase struct {
+	name    string
+	pattern string
+	path    string
+	match   bool
+}
+
+func (tc subscriptionPatternTestCase) Name() string { return tc.name }
+
+func (tc subscriptionPatternTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	p, err := newSubscriptionPattern(tc.pattern, 1)
+	core.AssertMustNoError(t, err, "newSubscriptionPattern")
+	core.AssertMustNotNil(t, p, "pattern")
+
+	_, ok := p.match(tc.path)
+	core.AssertEqual(t, tc.match, ok, "match %q", tc.path)
+}
+
+func newSubscriptionPatternTestCase(name, pattern, path string, match bool) subscriptionPatternTestCase {
+	return subscriptionPatternTestCase{
+		name:    name,
+		pattern: pattern,
+		path:    path,
+		match:   match,
+	}
+}
+
+func subscriptionPatternTestCases() []subscriptionPatternTestCase {
+	return []subscriptionPatternTestCase{
+		newSubscriptionPatternTestCase("single level", patternTestPath, "/sensors/7/temperature", true),
+		newSubscriptionPatternTestCase("other leaf", patternTestPath, "/sensors/7/humidity", false),
+		newSubscriptionPatternTestCase("empty segment", patternTestPath, "/sensors//temperature", false),
+		newSubscriptionPatternTestCase("two levels", "/sensors/+/+", "/sensors/7/humidity", true),
+		newSubscriptionPatternTestCase("wildcard", "/sensors/*", "/sensors/7/humidity", true),
+		newSubscriptionPatternTestCase("wildcard parent", "/sensors/*", "/sensors", false),
+		newSubscriptionPatternTestCase("parameter", "/sensors/{id}/temperature", "/sensors/7/temperature", true),
+	}
+}
+
+func TestSubscriptionPattern(t *testing.T) {
+	core.RunTestCases(t, subscriptionPatternTestCases())
+
+	p, err := newSubscriptionPattern("/sensors/7/temperature", 1)
+	core.AssertNoError(t, err, "plain path")
+	core.AssertNil(t, p, "plain path pattern")
+
+	_, err = newSubscriptionPattern("/sensors/*/temperature", 1)
+	core.AssertErrorIs(t, err, ErrInvalidPattern, "inner wildcard")
+}
+
+func TestSubscribePattern(t *testing.T) {
+	h := NewDefaultMessageHandler(nil)
+	ctx := context.Background()
+
+	watcher := newTestSession("watcher", 1001)
+	core.AssertMustNoError(t, h.Subscribe(ctx, watcher, newTestSubscribeRequest(1, patternTestPath, nil)),
+		"subscribe pattern")
+	exact := newTestSession("exact", 1002)
+	core.AssertMustNoError(t, h.Subscribe(ctx, exact, newTestSubscribeRequest(2, "/sensors/7/temperature", nil)),
+		"subscribe exact")
+
+	for _, path := range []string{"/sensors/7/temperature", "/sensors/7/humidity", "/sensors/8/temperature"} {
+		core.AssertNoError(t, h.Publish(path, []byte(path)), "publish %q", path)
+	}
+
+	responses := watcher.GetAllResponses()
+	core.AssertMustEqual(t, 3, len(responses), "ack and matching updates")
+	for i, path := range []string{"/sensors/7/temperature", "/sensors/8/temperature"} {
+		res := responses[i+1]
+		core.AssertEqual(t, int32(1), res.RequestId, "request ID")
+		core.AssertEqual(t, path, res.Path, "path")
+		core.AssertEqual(t, path, string(res.Data), "data")
+	}
+
+	responses = exact.GetAllResponses()
+	core.AssertMustEqual(t, 2, len(responses), "ack and update")
+	core.AssertEqual(t, "", responses[1].Path, "exact path")
+}
+
+func TestSubscribePattern_Unsubscribe(t *testing.T) {
+	h := NewDefaultMessageHandler(nil)
+	ctx := context.Background()
+
+	session := newTestSession("watcher", 1001)
+	core.AssertMustNoError(t, h.Subscribe(ctx, session, newTestSubscribeRequest(1, patternTestPath, nil)),
+		"subscribe")
+
+	unsubscribe := &nanorpc.NanoRPCRequest{
+		RequestId:   1,
+		RequestType: nanorpc.NanoRPCRequest_TYPE_REQUEST,
+		PathOneof:   nanorpc.GetPathOneOfString(patternTestPath),
+	}
+	core.AssertMustNoError(t, h.HandleMessage(ctx, session, unsubscribe), "unsubscribe")
+	core.AssertNoError(t, h.Publish("/sensors/7/temperature", []byte("x")), "publish")
+
+	core.AssertEqual(t, 2, len(session.GetAllResponses()), "acks only")
+}
+
+func TestSubscribePattern_Invalid(t *testing.T) {
+	h := NewDefaultMessageHandler(nil)
+	session := newTestSession("watcher", 1001)
+
+	req := newTestSubscribeRequest(1, "/sensors/*/temperature", nil)
+	core.AssertMustNoError(t, h.Subscribe(context.Background(), session, req), "subscribe")
+
+	res := session.GetLastResponse()
+	core.AssertMustNotNil(t, res, "response")
+	core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_BAD_REQUEST, res.ResponseStatus, "status")
+}
+
+// stalledSession is a session blocking on SendResponse until released.
+type stalledSession struct {
+	*mockSession
+	sending chan struct{}
+	release chan struct{}
+}
+
+func (s *stalledSession) SendResponse(req *nanorpc.NanoRPCRequest, res *nanorpc.NanoRPCResponse) error {
+	s.sending <- struct{}{}
+	<-s.release
+	return s.mockSession.SendResponse(req, res)
+}
+
+func TestSubscribePattern_SlowAcknowledgement(t *testing.T) {
+	h := NewDefaultMessageHandler(nil)
+	session := &stalledSession{
+		mockSession: newTestSession("slow", 1001),
+		sending:     make(chan struct{}, 1),
+		release:     make(chan struct{}),
+	}
+
+	subscribed := make(chan error, 1)
+	go func() {
+		req := newTestSubscribeRequest(1, patternTestPath, nil)
+		subscribed <- h.Subscribe(context.Background(), session, req)
+	}()
+	<-session.sending
+
+	// publishers on other shards aren't held back by the acknowledgement
+	published := make(chan error, 1)
+	go func() { published <- h.Publish(otherShardPath(t, h, patternTestPath), nil) }()
+	select {
+	case err := <-published:
+		core.AssertNoError(t, err, "publish")
+	case <-time.After(time.Second):
+		t.Error("publish held back by the acknowledgement")
+	}
+
+	close(session.release)
+	core.AssertNoError(t, <-subscribed, "subscribe")
+}
+
+// otherShardPath returns a path whose subscriptions aren't in the shard
+// of path.
+func otherShardPath(t *testing.T, h *DefaultMessageHandler, path string) string {
+	t.Helper()
+
+	hash, err := h.hashCache.Hash(path)
+	core.AssertMustNoError(t, err, "Hash")
+	for i := range 64 {
+		other := fmt.Sprintf("/other/%d", i)
+		otherHash, err := h.hashCache.Hash(other)
+		core.AssertMustNoError(t, err, "Hash")
+		if h.subscriptions.shard(otherHash) != h.subscriptions.shard(hash) {
+			return other
+		}
+	}
+	t.Fatal("no path on another shard")
+	return ""
+}
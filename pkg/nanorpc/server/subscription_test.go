@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"testing"
 	"time"
@@ -61,7 +62,7 @@ const (
 )
 
 func TestSubscriptionMapOperations(t *testing.T) {
-	sm := make(SubscriptionMap)
+	sm := NewSubscriptionMap()
 
 	// Test empty map
 	core.AssertNil(t, sm.GetSubscribers(123), "subscribers")
@@ -267,7 +268,7 @@ func testSubscriptionWithInvalidPath() subscribeTestCase {
 			RequestType: nanorpc.NanoRPCRequest_TYPE_SUBSCRIBE,
 			PathOneof:   nil, // Invalid path
 		},
-		expectedStatus: nanorpc.NanoRPCResponse_STATUS_INTERNAL_ERROR,
+		expectedStatus: nanorpc.NanoRPCResponse_STATUS_BAD_REQUEST,
 	}
 }
 
@@ -501,3 +502,44 @@ func TestPublishByHashLockSafety(t *testing.T) {
 	}
 	core.AssertTrue(t, updateCount > 0, "update count")
 }
+
+// BenchmarkDefaultMessageHandler_PublishByHash publishes to a growing
+// number of sessions subscribed to the same path, while other paths keep
+// subscribers of their own.
+func BenchmarkDefaultMessageHandler_PublishByHash(b *testing.B) {
+	for _, n := range []int{1, 100, 1000} {
+		b.Run(fmt.Sprintf("subscribers=%d", n), func(b *testing.B) {
+			h := NewDefaultMessageHandler(nil)
+			hash := subscribeBenchSessions(b, h, "/bench", n)
+			subscribeBenchSessions(b, h, "/other", n)
+
+			data := make([]byte, 64)
+			b.ReportAllocs()
+			for b.Loop() {
+				if err := h.PublishByHash(hash, data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// subscribeBenchSessions subscribes n sessions discarding their updates
+// to path, returning its hash.
+func subscribeBenchSessions(b *testing.B, h *DefaultMessageHandler, path string, n int) uint32 {
+	b.Helper()
+
+	ctx := context.Background()
+	for i := range n {
+		s := NewDefaultSession(&discardConn{mockConn{remoteAddr: "127.0.0.1:12345"}}, h, nil)
+		if err := h.Subscribe(ctx, s, newTestSubscribeRequest(int32(i+1), path, nil)); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	hash, err := h.hashCache.Hash(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return hash
+}
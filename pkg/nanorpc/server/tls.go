@@ -0,0 +1,93 @@
+package server
+
+import (
+	"crypto/tls"
+	"net"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc/utils"
+)
+
+// TLSListener wraps a [Listener] so accepted connections are served over
+// TLS. The handshake happens on the first read of each session, so slow
+// or hostile peers never block the accept loop.
+type TLSListener struct {
+	Listener
+	config *tls.Config
+}
+
+// NewTLSListener creates a TLS listener accepting from l with cfg. It
+// returns nil if either is nil.
+func NewTLSListener(l Listener, cfg *tls.Config) *TLSListener {
+	if l == nil || cfg == nil {
+		return nil
+	}
+	return &TLSListener{Listener: l, config: cfg}
+}
+
+// Accept waits for the next connection and wraps it as a TLS server
+// connection.
+func (l *TLSListener) Accept() (net.Conn, error) {
+	if l == nil {
+		return nil, core.ErrNilReceiver
+	}
+
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return tls.Server(conn, l.config), nil
+}
+
+// WithTLS makes the server accept TLS connections using cfg. Client
+// certificates are verified according to cfg.ClientAuth. A nil cfg leaves
+// the listener unchanged.
+func WithTLS(cfg *tls.Config) ServerOption {
+	return func(s *Server) {
+		if cfg != nil && s.listener != nil {
+			s.listener = NewTLSListener(s.listener, cfg)
+		}
+	}
+}
+
+// NewTLSConfig builds a server [tls.Config] from PEM encoded files. When
+// clientCAFile is not empty, clients must present a certificate signed by
+// one of its authorities (mTLS).
+func NewTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, core.QuietWrap(ErrInvalidTLSConfig, "certificate and key required")
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, core.Wrap(err, "server certificate")
+	}
+
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if clientCAFile != "" {
+		pool, err := utils.LoadCertPool(clientCAFile)
+		if err != nil {
+			return nil, core.Wrap(err, "client CA")
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
+
+// TLSConnectionState returns the TLS state of the session's connection,
+// and false when the session is not using TLS. Handlers see a completed
+// handshake, including verified client certificates under mTLS.
+func (s *DefaultSession) TLSConnectionState() (tls.ConnectionState, bool) {
+	if s != nil {
+		if tc, ok := s.conn.(*tls.Conn); ok {
+			return tc.ConnectionState(), true
+		}
+	}
+	return tls.ConnectionState{}, false
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/utils/testutils"
+)
+
+const pathWhoAmI = "/whoami"
+
+var _ core.TestCase = serverTLSTestCase{}
+
+// serverTLSTestCase dials a TLS server and checks whether a request
+// succeeds and which client identity the session reports.
+type serverTLSTestCase struct {
+	name       string
+	want       string
+	clientCert bool
+	mTLS       bool
+	ok         bool
+}
+
+func (tc serverTLSTestCase) Name() string { return tc.name }
+
+func (tc serverTLSTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	pki := testutils.NewTestPKI(t)
+	cfg := &tls.Config{Certificates: []tls.Certificate{pki.Server}}
+	if tc.mTLS {
+		cfg.ClientCAs = pki.CertPool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	addr, stop := startTLSTestServer(t, cfg)
+	defer stop()
+
+	clientCfg := &tls.Config{RootCAs: pki.CertPool, ServerName: "localhost"}
+	if tc.clientCert {
+		clientCfg.Certificates = []tls.Certificate{pki.Client}
+	}
+
+	name, err := tlsWhoAmI(addr, clientCfg)
+	core.AssertEqual(t, tc.ok, err == nil, "request succeeded: %v", err)
+	core.AssertEqual(t, tc.want, name, "identity")
+}
+
+func newServerTLSTestCase(name string, mTLS, clientCert, ok bool, want string) serverTLSTestCase {
+	return serverTLSTestCase{name: name, mTLS: mTLS, clientCert: clientCert, ok: ok, want: want}
+}
+
+func serverTLSTestCases() []serverTLSTestCase {
+	return []serverTLSTestCase{
+		newServerTLSTestCase("tls", false, false, true, "anonymous"),
+		newServerTLSTestCase("mtls", true, true, true, "localhost"),
+		newServerTLSTestCase("mtls_without_cert", true, false, false, ""),
+	}
+}
+
+func TestServer_TLS(t *testing.T) {
+	core.RunTestCases(t, serverTLSTestCases())
+}
+
+// TestNewTLSConfig verifies server TLS settings are loaded from PEM files.
+func TestNewTLSConfig(t *testing.T) {
+	files, err := testutils.NewTestPKI(t).WriteFiles(t.TempDir())
+	core.AssertMustNoError(t, err, "write files")
+
+	cfg, err := NewTLSConfig(files.ServerCert, files.ServerKey, "")
+	core.AssertMustNoError(t, err, "tls")
+	core.AssertEqual(t, tls.NoClientCert, cfg.ClientAuth, "client auth")
+
+	cfg, err = NewTLSConfig(files.ServerCert, files.ServerKey, files.CA)
+	core.AssertMustNoError(t, err, "mtls")
+	core.AssertEqual(t, tls.RequireAndVerifyClientCert, cfg.ClientAuth, "client auth")
+	core.AssertNotNil(t, cfg.ClientCAs, "client CAs")
+
+	_, err = NewTLSConfig("", files.ServerKey, "")
+	core.AssertErrorIs(t, err, ErrInvalidTLSConfig, "missing cert")
+
+	_, err = NewTLSConfig(files.ServerCert, files.ServerKey, files.ServerKey)
+	core.AssertTrue(t, IsInvalid(err), "CA file without certificates")
+}
+
+func startTLSTestServer(t *testing.T, cfg *tls.Config) (string, func()) {
+	t.Helper()
+
+	handler := NewDefaultMessageHandler(nil)
+	core.AssertMustNoError(t, handler.RegisterHandlerFunc(pathWhoAmI, whoAmIHandler), "register")
+
+	listener, err := net.Listen("tcp", "localhost:0")
+	core.AssertMustNoError(t, err, "listen")
+
+	server := NewDefaultServer(listener, handler, nil, WithTLS(cfg))
+	serverErr := make(chan error, 1)
+	go func() { serverErr <- server.Serve(context.Background()) }()
+	waitServerReady(t, server)
+
+	return listener.Addr().String(), func() { shutdownServer(t, server, serverErr) }
+}
+
+// whoAmIHandler answers with the common name of the client certificate.
+func whoAmIHandler(_ context.Context, rc *RequestContext) error {
+	name := "anonymous"
+	if s, ok := rc.Session.(*DefaultSession); ok {
+		if state, ok := s.TLSConnectionState(); ok && len(state.PeerCertificates) > 0 {
+			name = state.PeerCertificates[0].Subject.CommonName
+		}
+	}
+	return rc.SendOK([]byte(name))
+}
+
+// tlsWhoAmI calls whoAmIHandler over a TLS connection.
+func tlsWhoAmI(addr string, cfg *tls.Config) (string, error) {
+	conn, err := tls.Dial("tcp", addr, cfg)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	data, err := nanorpc.EncodeRequest(&nanorpc.NanoRPCRequest{
+		RequestId:   1,
+		RequestType: nanorpc.NanoRPCRequest_TYPE_REQUEST,
+		PathOneof:   nanorpc.GetPathOneOfString(pathWhoAmI),
+	}, nil)
+	if err == nil {
+		_, err = conn.Write(data)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	buffer := make([]byte, 1024)
+	n, err := conn.Read(buffer)
+	if err != nil {
+		return "", err
+	}
+	resp, _, err := nanorpc.DecodeResponse(buffer[:n])
+	return string(resp.GetData()), err
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// protoMessage is a pointer to T implementing [proto.Message], as the
+// messages generated by protoc-gen-go are.
+type protoMessage[T any] interface {
+	*T
+	proto.Message
+}
+
+// RegisterTyped registers fn as the handler of path on r, decoding the
+// protobuf request it takes and encoding the response it returns, so it
+// deals with messages instead of the [RequestContext]:
+//
+//	err := server.RegisterTyped(h, "/sensors/read",
+//		func(ctx context.Context, req *pb.ReadRequest) (*pb.Reading, error) {
+//			return sensors.Read(ctx, req.Sensor)
+//		})
+//
+// Requests without data give fn an empty message, and requests that can't
+// be decoded are answered as [RequestContext.SendBadRequest] does. Errors
+// returned by fn are answered as [RequestContext.SendErr] does, with the
+// status, message and detail of a [nanorpc.Error]. The message types are
+// recorded for reflection, see [DescribeTypes]. A nil fn fails with
+// [ErrMissingHandler].
+func RegisterTyped[Req, Resp any, PReq protoMessage[Req], PResp protoMessage[Resp]](r HandlerRegistry,
+	path string, fn func(context.Context, PReq) (PResp, error), opts ...AccessOption) error {
+	if fn == nil {
+		return ErrMissingHandler
+	}
+	if err := r.RegisterHandlerFunc(path, newTypedHandler(fn), opts...); err != nil {
+		return err
+	}
+	return DescribeTypes(r, path, PReq(new(Req)), PResp(new(Resp)))
+}
+
+func newTypedHandler[Req, Resp any, PReq protoMessage[Req], PResp protoMessage[Resp]](
+	fn func(context.Context, PReq) (PResp, error)) RequestHandlerFunc {
+	return func(ctx context.Context, rc *RequestContext) error {
+		req := PReq(new(Req))
+		if rc.HasData() {
+			if err := rc.UnmarshalRequestProtobuf(req); err != nil {
+				return rc.SendBadRequest(err.Error())
+			}
+		}
+
+		resp, err := fn(ctx, req)
+		if err != nil {
+			return rc.SendErr(err)
+		}
+		return rc.SendProtobuf(resp)
+	}
+}
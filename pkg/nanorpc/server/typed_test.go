@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+const pathTyped = "/api/typed"
+
+// typedEcho answers with the version of the request as features, or the
+// error its version asks for.
+func typedEcho(_ context.Context, req *nanorpc.NanoRPCHello) (*nanorpc.NanoRPCHello, error) {
+	switch req.Version {
+	case 404:
+		return nil, core.ErrNotExists
+	case 403:
+		return nil, &nanorpc.ResponseError{Status: nanorpc.NanoRPCResponse_STATUS_NOT_AUTHORIZED, Msg: "denied"}
+	case 500:
+		return nil, errors.New("boom")
+	default:
+		return &nanorpc.NanoRPCHello{Features: req.Version}, nil
+	}
+}
+
+var _ core.TestCase = registerTypedTestCase{}
+
+type registerTypedTestCase struct {
+	name     string
+	data     []byte
+	message  string
+	status   nanorpc.NanoRPCResponse_Status
+	features uint32
+}
+
+func (tc registerTypedTestCase) Name() string { return tc.name }
+
+func (tc registerTypedTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	h := NewDefaultMessageHandler(nil)
+	core.AssertMustNoError(t, RegisterTyped(h, pathTyped, typedEcho), "RegisterTyped")
+
+	session := newTestSession("", 0)
+	req := &nanorpc.NanoRPCRequest{
+		RequestId:   1,
+		RequestType: nanorpc.NanoRPCRequest_TYPE_REQUEST,
+		PathOneof:   nanorpc.GetPathOneOfString(pathTyped),
+		Data:        tc.data,
+	}
+	core.AssertMustNoError(t, h.HandleMessage(context.Background(), session, req), "HandleMessage")
+
+	res := session.GetLastResponse()
+	core.AssertMustNotNil(t, res, "response")
+	core.AssertEqual(t, tc.status, res.ResponseStatus, "status")
+	if tc.status != nanorpc.NanoRPCResponse_STATUS_OK {
+		core.AssertContains(t, res.ResponseMessage, tc.message, "message")
+		return
+	}
+
+	out, _, err := nanorpc.DecodeResponseData(res, new(nanorpc.NanoRPCHello))
+	core.AssertMustNoError(t, err, "DecodeResponseData")
+	core.AssertEqual(t, tc.features, out.Features, "features")
+}
+
+func newRegisterTypedTestCase(name string, version uint32, status nanorpc.NanoRPCResponse_Status,
+	message string, features uint32) registerTypedTestCase {
+	var data []byte
+	if version > 0 {
+		data, _ = proto.Marshal(&nanorpc.NanoRPCHello{Version: version})
+	}
+	return registerTypedTestCase{
+		name:     name,
+		data:     data,
+		status:   status,
+		message:  message,
+		features: features,
+	}
+}
+
+func registerTypedTestCases() []registerTypedTestCase {
+	ok := nanorpc.NanoRPCResponse_STATUS_OK
+
+	undecodable := newRegisterTypedTestCase("undecodable", 0, nanorpc.NanoRPCResponse_STATUS_BAD_REQUEST, "", 0)
+	undecodable.data = []byte{0xff}
+
+	return []registerTypedTestCase{
+		newRegisterTypedTestCase("response", 7, ok, "", 7),
+		newRegisterTypedTestCase("no data", 0, ok, "", 0),
+		newRegisterTypedTestCase("not found", 404, nanorpc.NanoRPCResponse_STATUS_NOT_FOUND, "does not exist", 0),
+		newRegisterTypedTestCase("response error", 403, nanorpc.NanoRPCResponse_STATUS_NOT_AUTHORIZED, "denied", 0),
+		newRegisterTypedTestCase("failure", 500, nanorpc.NanoRPCResponse_STATUS_INTERNAL_ERROR, "boom", 0),
+		undecodable,
+	}
+}
+
+func TestRegisterTyped(t *testing.T) {
+	core.RunTestCases(t, registerTypedTestCases())
+}
+
+func TestRegisterTyped_missingHandler(t *testing.T) {
+	h := NewDefaultMessageHandler(nil)
+	var fn func(context.Context, *nanorpc.NanoRPCHello) (*nanorpc.NanoRPCHello, error)
+
+	err := RegisterTyped(h, pathTyped, fn)
+	core.AssertErrorIs(t, err, ErrMissingHandler, "RegisterTyped")
+}
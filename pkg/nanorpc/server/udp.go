@@ -0,0 +1,261 @@
+package server
+
+import (
+	"io"
+	"net"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// DefaultUDPIdleTimeout is how long a [UDPListener] keeps the session of
+// a silent peer when no idle timeout is given.
+const DefaultUDPIdleTimeout = 2 * time.Minute
+
+// udpPeerQueueSize is the number of datagrams buffered per peer before
+// new ones are dropped.
+const udpPeerQueueSize = 64
+
+var (
+	_ Listener     = (*UDPListener)(nil)
+	_ net.Listener = (*UDPListener)(nil)
+)
+
+// UDPListener adapts a [net.PacketConn] to the [Listener] interface so a
+// [Server] can serve NanoRPC over UDP. It's also a [net.Listener], to be
+// passed to [NewDefaultServer]. Each peer address gets its own
+// session, over a connection carrying one wrapped message per datagram,
+// which ends after the peer stays silent for the idle timeout.
+//
+// UDP doesn't retransmit nor order datagrams. Lost requests and responses
+// are only noticed through client timeouts, so it suits periodic telemetry
+// and idempotent requests on reliable links.
+type UDPListener struct {
+	pc     net.PacketConn
+	peers  map[string]*udpPeer
+	accept chan *udpPeer
+	done   chan struct{}
+	idle   time.Duration
+	once   sync.Once
+	mu     sync.Mutex
+}
+
+// ListenUDP announces on the local UDP address and returns a
+// [UDPListener] for it.
+func ListenUDP(address string, idle time.Duration) (*UDPListener, error) {
+	pc, err := net.ListenPacket("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	return NewUDPListener(pc, idle), nil
+}
+
+// NewUDPListener creates a [UDPListener] reading datagrams from pc, which
+// it owns from then on. A non-positive idle uses [DefaultUDPIdleTimeout].
+func NewUDPListener(pc net.PacketConn, idle time.Duration) *UDPListener {
+	if pc == nil {
+		return nil
+	}
+	if idle <= 0 {
+		idle = DefaultUDPIdleTimeout
+	}
+
+	l := &UDPListener{
+		pc:     pc,
+		peers:  make(map[string]*udpPeer),
+		accept: make(chan *udpPeer),
+		done:   make(chan struct{}),
+		idle:   idle,
+	}
+	go l.readLoop()
+	return l
+}
+
+// Accept waits for a datagram from a new peer and returns the connection
+// of its session.
+func (l *UDPListener) Accept() (net.Conn, error) {
+	if l == nil {
+		return nil, core.ErrNilReceiver
+	}
+
+	select {
+	case p := <-l.accept:
+		return nanorpc.NewDatagramConn(p), nil
+	case <-l.done:
+		return nil, errListenerClosed(l.pc.LocalAddr())
+	}
+}
+
+// Close stops reading datagrams and ends the sessions of all peers.
+func (l *UDPListener) Close() error {
+	if l == nil {
+		return core.ErrNilReceiver
+	}
+
+	var err error
+	l.once.Do(func() {
+		close(l.done)
+		err = l.pc.Close()
+	})
+	return err
+}
+
+// Addr returns the local address of the listener.
+func (l *UDPListener) Addr() net.Addr {
+	if l == nil {
+		return nil
+	}
+	return l.pc.LocalAddr()
+}
+
+func (l *UDPListener) readLoop() {
+	defer func() { _ = l.Close() }()
+
+	buf := make([]byte, nanorpc.MaxDatagramSize)
+	for {
+		n, addr, err := l.pc.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		p, isNew := l.getPeer(addr)
+		if isNew && !l.offer(p) {
+			return
+		}
+		p.deliver(slices.Clone(buf[:n]))
+	}
+}
+
+// getPeer returns the peer for addr, creating it if needed.
+func (l *UDPListener) getPeer(addr net.Addr) (*udpPeer, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := addr.String()
+	if p, ok := l.peers[key]; ok {
+		return p, false
+	}
+
+	p := &udpPeer{
+		l:      l,
+		addr:   addr,
+		in:     make(chan []byte, udpPeerQueueSize),
+		closed: make(chan struct{}),
+	}
+	l.peers[key] = p
+	return p, true
+}
+
+// offer hands a new peer to Accept, reporting false if the listener
+// was closed.
+func (l *UDPListener) offer(p *udpPeer) bool {
+	select {
+	case l.accept <- p:
+		return true
+	case <-l.done:
+		return false
+	}
+}
+
+func (l *UDPListener) removePeer(p *udpPeer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if key := p.addr.String(); l.peers[key] == p {
+		delete(l.peers, key)
+	}
+}
+
+var _ net.Conn = (*udpPeer)(nil)
+
+// udpPeer is the datagram connection of a single peer of a [UDPListener].
+type udpPeer struct {
+	l        *UDPListener
+	addr     net.Addr
+	in       chan []byte
+	closed   chan struct{}
+	deadline time.Time
+	once     sync.Once
+	mu       sync.Mutex
+}
+
+// deliver queues a datagram, dropping it if the session falls behind.
+func (p *udpPeer) deliver(data []byte) {
+	select {
+	case p.in <- data:
+	default:
+	}
+}
+
+// Read returns the next datagram, failing with [os.ErrDeadlineExceeded]
+// once the read deadline passes and [io.EOF] after the idle timeout.
+func (p *udpPeer) Read(b []byte) (int, error) {
+	timer, expired := p.newReadTimer()
+	defer timer.Stop()
+
+	select {
+	case data := <-p.in:
+		return copy(b, data), nil
+	case <-p.closed:
+		return 0, net.ErrClosed
+	case <-p.l.done:
+		return 0, net.ErrClosed
+	case <-timer.C:
+		return 0, expired
+	}
+}
+
+// newReadTimer returns a timer for the read deadline or the idle timeout,
+// whichever comes first, and the error to report when it fires.
+func (p *udpPeer) newReadTimer() (*time.Timer, error) {
+	p.mu.Lock()
+	deadline := p.deadline
+	p.mu.Unlock()
+
+	wait, expired := p.l.idle, error(io.EOF)
+	if !deadline.IsZero() && time.Until(deadline) < wait {
+		wait, expired = time.Until(deadline), os.ErrDeadlineExceeded
+	}
+	return time.NewTimer(wait), expired
+}
+
+// Write sends b to the peer as a single datagram.
+func (p *udpPeer) Write(b []byte) (int, error) {
+	select {
+	case <-p.closed:
+		return 0, net.ErrClosed
+	default:
+		return p.l.pc.WriteTo(b, p.addr)
+	}
+}
+
+// Close ends the peer's connection. Further datagrams from its address
+// start a new session.
+func (p *udpPeer) Close() error {
+	p.once.Do(func() {
+		close(p.closed)
+		p.l.removePeer(p)
+	})
+	return nil
+}
+
+func (p *udpPeer) LocalAddr() net.Addr  { return p.l.pc.LocalAddr() }
+func (p *udpPeer) RemoteAddr() net.Addr { return p.addr }
+
+func (p *udpPeer) SetDeadline(t time.Time) error { return p.SetReadDeadline(t) }
+
+func (p *udpPeer) SetReadDeadline(t time.Time) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.deadline = t
+	return nil
+}
+
+// SetWriteDeadline is a no-op, datagrams are sent without blocking.
+func (*udpPeer) SetWriteDeadline(time.Time) error { return nil }
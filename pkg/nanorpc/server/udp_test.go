@@ -0,0 +1,96 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+)
+
+func startUDPTestServer(t *testing.T, idle time.Duration) (*UDPListener, func()) {
+	t.Helper()
+
+	listener, err := ListenUDP("127.0.0.1:0", idle)
+	core.AssertMustNoError(t, err, "listen")
+
+	server := NewDefaultServer(listener, nil, nil)
+	serverErr := make(chan error, 1)
+	go func() { serverErr <- server.Serve(context.Background()) }()
+	waitServerReady(t, server)
+
+	return listener, func() { shutdownServer(t, server, serverErr) }
+}
+
+func dialUDPTestServer(t *testing.T, listener *UDPListener) net.Conn {
+	t.Helper()
+
+	conn, err := net.Dial("udp", listener.Addr().String())
+	core.AssertMustNoError(t, err, "dial")
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+// TestServer_UDP verifies each datagram is served as a request, and that
+// every peer gets its own session.
+func TestServer_UDP(t *testing.T) {
+	listener, stop := startUDPTestServer(t, 0)
+	defer stop()
+
+	first := dialUDPTestServer(t, listener)
+	second := dialUDPTestServer(t, listener)
+
+	sendPingReceivePong(t, first)
+	sendPingReceivePong(t, second)
+	sendPingReceivePong(t, first)
+
+	listener.mu.Lock()
+	peers := len(listener.peers)
+	listener.mu.Unlock()
+	core.AssertEqual(t, 2, peers, "peers")
+}
+
+// TestServer_UDPIdle verifies a silent peer's session is ended and a new
+// one is started by its next datagram.
+func TestServer_UDPIdle(t *testing.T) {
+	listener, stop := startUDPTestServer(t, 50*time.Millisecond)
+	defer stop()
+
+	conn := dialUDPTestServer(t, listener)
+	sendPingReceivePong(t, conn)
+
+	time.Sleep(200 * time.Millisecond)
+	listener.mu.Lock()
+	peers := len(listener.peers)
+	listener.mu.Unlock()
+	core.AssertEqual(t, 0, peers, "peers after idle")
+
+	sendPingReceivePong(t, conn)
+}
+
+// TestServer_UDPDropsInvalid verifies a corrupt datagram doesn't break
+// the peer's session.
+func TestServer_UDPDropsInvalid(t *testing.T) {
+	listener, stop := startUDPTestServer(t, 0)
+	defer stop()
+
+	conn := dialUDPTestServer(t, listener)
+	_, err := conn.Write([]byte{0xff, 0xff, 0x01})
+	core.AssertMustNoError(t, err, "write garbage")
+
+	sendPingReceivePong(t, conn)
+}
+
+func TestUDPListener_Close(t *testing.T) {
+	listener, err := ListenUDP("127.0.0.1:0", 0)
+	core.AssertMustNoError(t, err, "listen")
+
+	core.AssertNoError(t, listener.Close(), "close")
+	core.AssertNoError(t, listener.Close(), "close again")
+
+	_, err = listener.Accept()
+	core.AssertErrorIs(t, err, net.ErrClosed, "accept after close")
+	core.AssertTrue(t, (*Server)(nil).isExpectedAcceptError(err), "expected on shutdown")
+	core.AssertNil(t, NewUDPListener(nil, 0), "NewUDPListener(nil)")
+}
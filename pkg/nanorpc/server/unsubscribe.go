@@ -0,0 +1,135 @@
+package server
+
+import (
+	"context"
+
+	"darvaza.org/core"
+	"darvaza.org/slog"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/utils"
+)
+
+// ForcedUnsubscribeMessage is the response message of the final update
+// sent to a subscription ended by [DefaultMessageHandler.ForceUnsubscribe].
+const ForcedUnsubscribeMessage = "unsubscribed by server"
+
+// ForceUnsubscribe ends the subscriptions of a session to a path without
+// closing the session, e.g. to stop one flooding a slow client. Each
+// receives a final TYPE_UPDATE with STATUS_NOT_FOUND, see
+// [nanorpc.IsFinalUpdate]. Returns [ErrNoSubscription] if the session
+// isn't subscribed to the path.
+func (h *DefaultMessageHandler) ForceUnsubscribe(sessionID, path string) error {
+	if h == nil {
+		return core.ErrNilReceiver
+	}
+
+	pathHash, err := h.hashCache.Hash(path)
+	if err != nil {
+		return core.Wrapf(err, "failed to hash path %q", path)
+	}
+
+	return h.ForceUnsubscribeByHash(sessionID, pathHash)
+}
+
+// ForceUnsubscribeByHash ends the subscriptions of a session to a path
+// hash, as [DefaultMessageHandler.ForceUnsubscribe] does.
+func (h *DefaultMessageHandler) ForceUnsubscribeByHash(sessionID string, pathHash uint32) error {
+	if h == nil {
+		return core.ErrNilReceiver
+	}
+
+	removed := h.removeSessionSubscriptions(sessionID, pathHash)
+	if len(removed) == 0 {
+		return core.QuietWrap(ErrNoSubscription, "session %q", sessionID)
+	}
+	h.forgetSubscriptions(removed)
+
+	// notify outside the lock, as Publish does
+	var firstErr error
+	for _, sub := range removed {
+		if err := sub.Session.SendResponse(nil, newFinalUpdate(sub.RequestID)); err != nil {
+			fields := slog.Fields{
+				utils.FieldPathHash:     pathHash,
+				utils.FieldSessionShard: utils.LogSessionShard(sessionID),
+			}
+			h.onError(err, sub.Session, fields, "failed to send final subscription update")
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// removeSessionSubscriptions removes and returns the subscriptions of a
+// session to a path hash.
+func (h *DefaultMessageHandler) removeSessionSubscriptions(sessionID string,
+	pathHash uint32) []*ActiveSubscription {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	removed := h.subscriptions.Remove(pathHash, func(sub *ActiveSubscription) bool {
+		return sub.Session != nil && sub.Session.ID() == sessionID
+	})
+
+	h.unsafeReportSubscriptions()
+	return removed
+}
+
+// newFinalUpdate creates the TYPE_UPDATE ending a subscription.
+func newFinalUpdate(requestID int32) *nanorpc.NanoRPCResponse {
+	return &nanorpc.NanoRPCResponse{
+		RequestId:       requestID,
+		ResponseType:    nanorpc.NanoRPCResponse_TYPE_UPDATE,
+		ResponseStatus:  nanorpc.NanoRPCResponse_STATUS_NOT_FOUND,
+		ResponseMessage: ForcedUnsubscribeMessage,
+	}
+}
+
+// AdminUnsubscribeRequest is the JSON request data of the handler returned
+// by [DefaultMessageHandler.AdminUnsubscribeHandler].
+type AdminUnsubscribeRequest struct {
+	SessionID string `json:"session_id"`
+	Path      string `json:"path"`
+}
+
+// AdminUnsubscribeHandler returns a [RequestHandler] forcing the
+// unsubscription described by an [AdminUnsubscribeRequest], for operators
+// to register on an admin path. It answers STATUS_OK once done, or
+// STATUS_NOT_FOUND if the session isn't subscribed to the path. It doesn't
+// check who's asking; wrap it with [RequireAuth] or protect the path with
+// an [Interceptor].
+func (h *DefaultMessageHandler) AdminUnsubscribeHandler() RequestHandler {
+	return RequestHandlerFunc(func(_ context.Context, rc *RequestContext) error {
+		var req AdminUnsubscribeRequest
+		if err := rc.UnmarshalRequestJSON(&req); err != nil {
+			return rc.SendBadRequest(err.Error())
+		}
+
+		err := h.ForceUnsubscribe(req.SessionID, req.Path)
+		switch {
+		case core.IsError(err, ErrNoSubscription):
+			return rc.SendNotFound("no matching subscription")
+		case err != nil:
+			return rc.SendInternalError(err.Error())
+		default:
+			return rc.SendOK(nil)
+		}
+	})
+}
+
+// Unsubscribe ends the subscriptions of a session to a path, through the
+// server's [DefaultMessageHandler] or any [MessageHandler] providing a
+// ForceUnsubscribe method of the same signature. See
+// [DefaultMessageHandler.ForceUnsubscribe].
+func (s *Server) Unsubscribe(sessionID, path string) error {
+	if s == nil {
+		return core.ErrNilReceiver
+	}
+
+	if mh, ok := s.messageHandler.(interface{ ForceUnsubscribe(string, string) error }); ok {
+		return mh.ForceUnsubscribe(sessionID, path)
+	}
+	return ErrUnsubscribeUnsupported
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+const (
+	pathAdminUnsubscribe = "/admin/unsubscribe"
+	pathTelemetry        = "/telemetry"
+)
+
+// newForceUnsubscribeHandler subscribes s1 twice and s2 once to
+// pathTelemetry, and s1 to pathEcho.
+func newForceUnsubscribeHandler(t *testing.T, s1, s2 Session) *DefaultMessageHandler {
+	t.Helper()
+
+	h := NewDefaultMessageHandler(nil)
+	ctx := context.Background()
+	for _, sub := range []struct {
+		session Session
+		path    string
+		id      int32
+	}{
+		{s1, pathTelemetry, 1},
+		{s1, pathTelemetry, 2},
+		{s1, pathEcho, 3},
+		{s2, pathTelemetry, 1},
+	} {
+		req := newTestSubscribeRequest(sub.id, sub.path, nil)
+		core.AssertMustNoError(t, h.Subscribe(ctx, sub.session, req), "Subscribe")
+	}
+	return h
+}
+
+func TestDefaultMessageHandler_ForceUnsubscribe(t *testing.T) {
+	s1, s2 := newTestSession(sessionID1, 0), newTestSession(sessionID2, 0)
+	h := newForceUnsubscribeHandler(t, s1, s2)
+	s1.ClearResponses()
+	s2.ClearResponses()
+
+	core.AssertNoError(t, h.ForceUnsubscribe(sessionID1, pathTelemetry), "ForceUnsubscribe")
+
+	finals := s1.GetAllResponses()
+	if core.AssertEqual(t, 2, len(finals), "final updates") {
+		for i, id := range []int32{1, 2} {
+			core.AssertEqual(t, id, finals[i].RequestId, "request_id")
+			core.AssertTrue(t, nanorpc.IsFinalUpdate(finals[i]), "IsFinalUpdate")
+			core.AssertEqual(t, ForcedUnsubscribeMessage, finals[i].ResponseMessage, "message")
+		}
+	}
+	core.AssertEqual(t, 0, len(s2.GetAllResponses()), "other session notified")
+
+	// only the other session still gets updates on the path
+	s1.ClearResponses()
+	core.AssertNoError(t, h.Publish(pathTelemetry, []byte("x")), "Publish")
+	core.AssertEqual(t, 0, len(s1.GetAllResponses()), "unsubscribed session updated")
+	core.AssertEqual(t, 1, len(s2.GetAllResponses()), "other session updates")
+
+	// other paths of the session are untouched
+	core.AssertNoError(t, h.Publish(pathEcho, []byte("x")), "Publish other path")
+	core.AssertEqual(t, 1, len(s1.GetAllResponses()), "other path updates")
+
+	err := h.ForceUnsubscribe(sessionID1, pathTelemetry)
+	core.AssertErrorIs(t, err, ErrNoSubscription, "repeated")
+	core.AssertErrorIs(t, err, core.ErrNotExists, "repeated family")
+}
+
+func TestDefaultMessageHandler_ForceUnsubscribe_lastSubscriber(t *testing.T) {
+	h := NewDefaultMessageHandler(nil)
+	session := newTestSession(sessionID1, 0)
+	req := newTestSubscribeRequest(1, pathTelemetry, nil)
+	core.AssertMustNoError(t, h.Subscribe(context.Background(), session, req), "Subscribe")
+
+	core.AssertNoError(t, h.ForceUnsubscribe(sessionID1, pathTelemetry), "ForceUnsubscribe")
+	core.AssertEqual(t, 0, h.subscriptions.Len(), "empty lists removed")
+}
+
+var _ core.TestCase = adminUnsubscribeTestCase{}
+
+type adminUnsubscribeTestCase struct {
+	name   string
+	data   string
+	status nanorpc.NanoRPCResponse_Status
+	finals int
+}
+
+func (tc adminUnsubscribeTestCase) Name() string { return tc.name }
+
+func (tc adminUnsubscribeTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	s1, s2 := newTestSession(sessionID1, 0), newTestSession(sessionID2, 0)
+	h := newForceUnsubscribeHandler(t, s1, s2)
+	core.AssertMustNoError(t, h.RegisterHandler(pathAdminUnsubscribe, h.AdminUnsubscribeHandler()),
+		"RegisterHandler")
+	s1.ClearResponses()
+
+	admin := newTestSession("admin", 0)
+	req := newTestRequest(7, pathAdminUnsubscribe)
+	req.Data = []byte(tc.data)
+	core.AssertNoError(t, h.HandleMessage(context.Background(), admin, req), "HandleMessage")
+
+	resp := admin.GetLastResponse()
+	if core.AssertNotNil(t, resp, "response") {
+		core.AssertEqual(t, tc.status, resp.ResponseStatus, "status")
+	}
+	core.AssertEqual(t, tc.finals, len(s1.GetAllResponses()), "final updates")
+}
+
+func newAdminUnsubscribeTestCase(name, data string, status nanorpc.NanoRPCResponse_Status,
+	finals int) adminUnsubscribeTestCase {
+	return adminUnsubscribeTestCase{name: name, data: data, status: status, finals: finals}
+}
+
+func adminUnsubscribeTestCases() []adminUnsubscribeTestCase {
+	return []adminUnsubscribeTestCase{
+		newAdminUnsubscribeTestCase("subscribed",
+			`{"session_id":"session1","path":"/telemetry"}`, nanorpc.NanoRPCResponse_STATUS_OK, 2),
+		newAdminUnsubscribeTestCase("not subscribed",
+			`{"session_id":"session3","path":"/telemetry"}`, nanorpc.NanoRPCResponse_STATUS_NOT_FOUND, 0),
+		newAdminUnsubscribeTestCase("invalid",
+			`{`, nanorpc.NanoRPCResponse_STATUS_BAD_REQUEST, 0),
+	}
+}
+
+func TestDefaultMessageHandler_AdminUnsubscribeHandler(t *testing.T) {
+	core.RunTestCases(t, adminUnsubscribeTestCases())
+}
+
+func TestServer_Unsubscribe(t *testing.T) {
+	s1, s2 := newTestSession(sessionID1, 0), newTestSession(sessionID2, 0)
+	h := newForceUnsubscribeHandler(t, s1, s2)
+	s := NewServer(nil, NewDefaultSessionManager(h, nil), h, nil)
+
+	core.AssertNoError(t, s.Unsubscribe(sessionID2, pathTelemetry), "default handler")
+	core.AssertTrue(t, nanorpc.IsFinalUpdate(s2.GetLastResponse()), "final update")
+
+	custom := NewServer(nil, nil, ignoringMessageHandler{}, nil)
+	core.AssertErrorIs(t, custom.Unsubscribe(sessionID1, pathTelemetry),
+		ErrUnsubscribeUnsupported, "custom handler")
+}
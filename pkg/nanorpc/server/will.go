@@ -0,0 +1,17 @@
+package server
+
+// unsafeSessionWill returns the path hash and data of the last will a
+// session sent in its handshake, if any and the identity of the session
+// may access its path. The caller must hold at least a read lock.
+func (h *DefaultMessageHandler) unsafeSessionWill(sessionID string) (uint32, []byte, bool) {
+	hello := h.hellos[sessionID]
+	if hello.GetWillPath() == "" {
+		return 0, nil, false
+	}
+
+	pathHash, err := h.hashCache.Hash(hello.WillPath)
+	if err != nil || !h.access[pathHash].allows(h.identities[sessionID]) {
+		return 0, nil, false
+	}
+	return pathHash, hello.WillData, true
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+const willTestPath = "/devices/7/online"
+
+// sendWill handshakes on behalf of session with the given last will.
+func sendWill(t *testing.T, h *DefaultMessageHandler, session Session, path, data string) {
+	t.Helper()
+
+	hello := nanorpc.NewHello(0)
+	hello.WillPath = path
+	hello.WillData = []byte(data)
+
+	b, err := proto.Marshal(hello)
+	core.AssertMustNoError(t, err, "Marshal")
+	req := &nanorpc.NanoRPCRequest{RequestId: 1, RequestType: nanorpc.NanoRPCRequest_TYPE_PING, Data: b}
+	core.AssertMustNoError(t, h.HandleMessage(context.Background(), session, req), "handshake")
+}
+
+// newWillWatcher subscribes a session to willTestPath.
+func newWillWatcher(t *testing.T, h *DefaultMessageHandler) *mockSession {
+	t.Helper()
+
+	watcher := newTestSession("watcher", 1001)
+	req := newTestSubscribeRequest(1, willTestPath, nil)
+	core.AssertMustNoError(t, h.Subscribe(context.Background(), watcher, req), "subscribe")
+	return watcher
+}
+
+func TestRemoveSubscriptionsForSession_Will(t *testing.T) {
+	h := NewDefaultMessageHandler(nil)
+	watcher := newWillWatcher(t, h)
+
+	device := newTestSession("device", 1002)
+	sendWill(t, h, device, willTestPath, "offline")
+	// the dying session doesn't receive its own will
+	core.AssertMustNoError(t, h.Subscribe(context.Background(), device,
+		newTestSubscribeRequest(2, willTestPath, nil)), "subscribe device")
+	h.RemoveSubscriptionsForSession(device.ID())
+
+	responses := watcher.GetAllResponses()
+	core.AssertMustEqual(t, 2, len(responses), "ack and will")
+	core.AssertEqual(t, "offline", string(responses[1].Data), "will")
+	core.AssertEqual(t, 2, len(device.GetAllResponses()), "device responses")
+
+	// forgotten with the session
+	h.RemoveSubscriptionsForSession(device.ID())
+	core.AssertEqual(t, 2, len(watcher.GetAllResponses()), "published once")
+}
+
+func TestRemoveSubscriptionsForSession_WillCleared(t *testing.T) {
+	h := NewDefaultMessageHandler(nil)
+	watcher := newWillWatcher(t, h)
+
+	device := newTestSession("device", 1002)
+	sendWill(t, h, device, willTestPath, "offline")
+	sendWill(t, h, device, "", "")
+	h.RemoveSubscriptionsForSession(device.ID())
+
+	core.AssertEqual(t, 1, len(watcher.GetAllResponses()), "ack only")
+}
+
+func TestRemoveSubscriptionsForSession_WillNotAllowed(t *testing.T) {
+	h := NewDefaultMessageHandler(nil)
+	watcher := newWillWatcher(t, h)
+	core.AssertMustNoError(t, h.SetAccess(willTestPath, RequireRole("device")), "SetAccess")
+	core.AssertMustNoError(t, h.SetSessionIdentity(watcher.ID(), &Identity{Roles: []string{"device"}}),
+		"SetSessionIdentity")
+
+	device := newTestSession("device", 1002)
+	sendWill(t, h, device, willTestPath, "offline")
+	h.RemoveSubscriptionsForSession(device.ID())
+
+	core.AssertEqual(t, 1, len(watcher.GetAllResponses()), "ack only")
+}
@@ -0,0 +1,28 @@
+package server
+
+import (
+	"time"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/utils"
+)
+
+// logRequest logs a request received, see [SessionConfig].WireLog.
+func (s *DefaultSession) logRequest(req *nanorpc.NanoRPCRequest) {
+	if wl := s.config.WireLog; wl != nil {
+		wl.LogRequest(s.getLogger(), utils.DirectionReceived, req, req.GetData())
+	}
+}
+
+// logResponse logs a response sent, answering a request received at
+// received, if known, see [SessionConfig].WireLog.
+func (s *DefaultSession) logResponse(req *nanorpc.NanoRPCRequest, response *nanorpc.NanoRPCResponse,
+	received time.Time) {
+	if wl := s.config.WireLog; wl != nil {
+		var elapsed time.Duration
+		if !received.IsZero() {
+			elapsed = time.Since(received)
+		}
+		wl.LogResponse(s.getLogger(), utils.DirectionSent, req, response, elapsed)
+	}
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+	"darvaza.org/slog/handlers/mock"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/utils"
+)
+
+// wireMessages returns the wire log entries a logger recorded.
+func wireMessages(logger *mock.Logger) []mock.Message {
+	var out []mock.Message
+	for _, msg := range logger.GetMessages() {
+		if _, ok := msg.Fields[utils.FieldDirection]; ok {
+			out = append(out, msg)
+		}
+	}
+	return out
+}
+
+// TestSessionConfig_WireLog verifies sessions log the requests they
+// receive and the responses they send, timed.
+func TestSessionConfig_WireLog(t *testing.T) {
+	handler := NewDefaultMessageHandler(nil)
+	err := handler.RegisterHandlerFunc(pathEcho, func(_ context.Context, rc *RequestContext) error {
+		return rc.SendOK(rc.Request.Data)
+	})
+	core.AssertMustNoError(t, err, "register")
+
+	logger := mock.NewLogger()
+	sm := NewDefaultSessionManager(handler, logger)
+	core.AssertMustNoError(t, sm.SetSessionConfig(SessionConfig{
+		WireLog: &utils.WireLog{PayloadBytes: 8},
+	}), "config")
+
+	req := newTestRequest(3, pathEcho)
+	req.Data = []byte("ping")
+	frame, err := nanorpc.EncodeRequest(req, nil)
+	core.AssertMustNoError(t, err, "encode")
+
+	conn := &mockConn{remoteAddr: "127.0.0.1:12345", data: frame}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_ = sm.AddSession(conn).Handle(ctx)
+
+	msgs := wireMessages(logger)
+	core.AssertMustEqual(t, 2, len(msgs), "wire messages")
+
+	received, sent := msgs[0].Fields, msgs[1].Fields
+	core.AssertEqual[any](t, utils.DirectionReceived, received[utils.FieldDirection], "received")
+	core.AssertEqual[any](t, pathEcho, received[utils.FieldPath], "request path")
+	core.AssertEqual[any](t, "70 69 6E 67", received[utils.FieldPayload], "request payload")
+
+	core.AssertEqual[any](t, utils.DirectionSent, sent[utils.FieldDirection], "sent")
+	core.AssertEqual[any](t, pathEcho, sent[utils.FieldPath], "response path")
+	core.AssertEqual[any](t, "STATUS_OK", sent[utils.FieldResponseStatus], "status")
+	_, ok := sent[utils.FieldDuration]
+	core.AssertTrue(t, ok, "duration")
+}
+
+// TestSessionConfig_WireLogForget verifies sessions logging the wire
+// forget the requests left unanswered.
+func TestSessionConfig_WireLogForget(t *testing.T) {
+	handler := NewDefaultMessageHandler(nil)
+	err := handler.RegisterHandlerFunc("/fail", func(context.Context, *RequestContext) error {
+		return errors.New("failed")
+	})
+	core.AssertMustNoError(t, err, "register")
+
+	s, _ := newSharedTestSession(handler)
+	s.config.WireLog = &utils.WireLog{}
+
+	for id := int32(1); id <= 3; id++ {
+		for _, req := range []*nanorpc.NanoRPCRequest{
+			newAckRequest(id, uint64(id)),
+			newTestRequest(id, "/fail"),
+		} {
+			err := s.decodeAndHandle(context.Background(), encodeTestFrame(t, req), 0)
+			core.AssertMustNoError(t, err, "%s %d", req.RequestType, id)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	core.AssertEqual(t, 0, len(s.received), "received")
+}
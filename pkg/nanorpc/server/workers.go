@@ -0,0 +1,184 @@
+package server
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+
+	"darvaza.org/core"
+	"darvaza.org/slog"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/utils"
+)
+
+// WithWorkers makes the server's [DefaultMessageHandler] run handlers on
+// a pool of workers, see [DefaultMessageHandler.SetWorkers], stopped when
+// the server shuts down. Other message handlers are left untouched.
+func WithWorkers(workers, queueSize int) ServerOption {
+	return func(s *Server) {
+		if h, ok := s.messageHandler.(*DefaultMessageHandler); ok {
+			_ = h.SetWorkers(workers, queueSize)
+			s.workers = workers > 0
+		}
+	}
+}
+
+// stopWorkers stops the pool of workers started by [WithWorkers], waiting
+// for the requests already queued.
+func (s *Server) stopWorkers() {
+	if h, ok := s.messageHandler.(*DefaultMessageHandler); ok && s.workers {
+		_ = h.SetWorkers(0, 0)
+	}
+}
+
+// workItem is a request waiting for a worker.
+type workItem struct {
+	ctx     context.Context
+	session Session
+	req     *nanorpc.NanoRPCRequest
+	release context.CancelFunc
+}
+
+// workerPool runs the handlers of a [DefaultMessageHandler]. Each session
+// is served by a single worker, so its requests run in order.
+type workerPool struct {
+	h       *DefaultMessageHandler
+	queues  []chan workItem
+	wg      sync.WaitGroup
+	mu      sync.RWMutex
+	stopped bool
+}
+
+func newWorkerPool(h *DefaultMessageHandler, workers, queueSize int) *workerPool {
+	p := &workerPool{
+		h:      h,
+		queues: make([]chan workItem, workers),
+	}
+	for i := range p.queues {
+		p.queues[i] = make(chan workItem, queueSize)
+		p.wg.Add(1)
+		go p.run(p.queues[i])
+	}
+	return p
+}
+
+// run handles the requests of a queue until it's closed.
+func (p *workerPool) run(queue <-chan workItem) {
+	defer p.wg.Done()
+
+	for item := range queue {
+		p.handle(item)
+	}
+}
+
+// sessionErrorLogger is a [Session] logging errors, as [DefaultSession]
+// does.
+type sessionErrorLogger interface {
+	LogError(err error, fields slog.Fields, msg string, args ...any)
+}
+
+// requestFinisher is a [Session] told when a worker is done with a
+// request, as [DefaultSession] is.
+type requestFinisher interface {
+	finishRequest(req *nanorpc.NanoRPCRequest, err error)
+}
+
+// handle handles a request, finishing with it and reporting handler
+// errors as the read loop of the session would.
+func (p *workerPool) handle(item workItem) {
+	defer item.release()
+
+	err := p.h.dispatch(item.ctx, item.session, item.req)
+	if f, ok := item.session.(requestFinisher); ok {
+		f.finishRequest(item.req, err)
+	}
+	if err == nil {
+		return
+	}
+
+	fields := utils.RequestFields(item.req)
+	if l, ok := item.session.(sessionErrorLogger); ok {
+		l.LogError(err, fields, "Handler error")
+	}
+	p.h.onError(err, item.session, fields, "Handler error")
+}
+
+// enqueue queues a request on the worker of its session, waiting for room
+// if the queue is full.
+func (p *workerPool) enqueue(ctx context.Context, session Session, req *nanorpc.NanoRPCRequest) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.stopped {
+		// stopped while being replaced, see SetWorkers
+		return p.h.dispatch(ctx, session, req)
+	}
+
+	item := workItem{ctx: ctx, session: session, req: req, release: detachContext(ctx)}
+	select {
+	case p.queues[p.shard(session)] <- item:
+		return nil
+	case <-ctx.Done():
+		item.release()
+		return ctx.Err()
+	}
+}
+
+// shard returns the index of the worker of a session.
+func (p *workerPool) shard(session Session) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(session.ID()))
+	return int(h.Sum32() % uint32(len(p.queues)))
+}
+
+// stop waits for the workers to handle the requests already queued.
+func (p *workerPool) stop() {
+	p.mu.Lock()
+	p.stopped = true
+	for _, q := range p.queues {
+		close(q)
+	}
+	p.mu.Unlock()
+
+	p.wg.Wait()
+}
+
+// SetWorkers runs the handlers of requests and subscriptions on a pool of
+// workers, instead of the read loop of their sessions, so a slow handler
+// doesn't hold back pings or the reading of further requests. Each session
+// is served by a single worker, so its requests are still handled in the
+// order they were received. Sessions with queueSize requests waiting stop
+// reading until there is room.
+//
+// Pings are answered by the read loop. Handler errors are logged by the
+// session, if it's a [DefaultSession]. Calling SetWorkers again replaces
+// the pool once the requests already queued are handled, and a workers
+// count of zero or less goes back to running handlers in the read loop.
+func (h *DefaultMessageHandler) SetWorkers(workers, queueSize int) error {
+	if h == nil {
+		return core.ErrNilReceiver
+	}
+
+	var p *workerPool
+	if workers > 0 {
+		p = newWorkerPool(h, workers, max(queueSize, 0))
+	}
+
+	h.mu.Lock()
+	old := h.workers
+	h.workers = p
+	h.mu.Unlock()
+
+	if old != nil {
+		old.stop()
+	}
+	return nil
+}
+
+func (h *DefaultMessageHandler) getWorkers() *workerPool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.workers
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/utils/testutils"
+)
+
+// newWorkersHandler returns a handler running on workers whose pathEcho
+// handler records the IDs of the requests it handles, once released.
+func newWorkersHandler(t *testing.T, release <-chan struct{}, mu *sync.Mutex, ids *[]int32) *DefaultMessageHandler {
+	t.Helper()
+
+	h := NewDefaultMessageHandler(nil)
+	core.AssertMustNoError(t, h.RegisterHandlerFunc(pathEcho, func(_ context.Context, rc *RequestContext) error {
+		<-release
+		mu.Lock()
+		*ids = append(*ids, rc.GetRequestID())
+		mu.Unlock()
+		return rc.SendOK(nil)
+	}), "register")
+	core.AssertMustNoError(t, h.SetWorkers(2, 16), "SetWorkers")
+	t.Cleanup(func() { _ = h.SetWorkers(0, 0) })
+	return h
+}
+
+// TestDefaultMessageHandler_SetWorkers verifies slow handlers don't hold
+// back pings, and the requests of a session are handled in order.
+func TestDefaultMessageHandler_SetWorkers(t *testing.T) {
+	var mu sync.Mutex
+	var ids []int32
+	release := make(chan struct{})
+	h := newWorkersHandler(t, release, &mu, &ids)
+
+	session := newTestSession("", 0)
+	for id := int32(1); id <= 8; id++ {
+		err := h.HandleMessage(context.Background(), session, newTestRequest(id, pathEcho))
+		core.AssertMustNoError(t, err, "HandleMessage %d", id)
+	}
+
+	ping := &nanorpc.NanoRPCRequest{RequestId: 9, RequestType: nanorpc.NanoRPCRequest_TYPE_PING}
+	core.AssertMustNoError(t, h.HandleMessage(context.Background(), session, ping), "ping")
+	if resp := session.GetLastResponse(); core.AssertNotNil(t, resp, "pong") {
+		core.AssertEqual(t, nanorpc.NanoRPCResponse_TYPE_PONG, resp.ResponseType, "type")
+	}
+
+	close(release)
+	// waits for the queued requests
+	core.AssertMustNoError(t, h.SetWorkers(0, 0), "stop workers")
+
+	core.AssertSliceEqual(t, []int32{1, 2, 3, 4, 5, 6, 7, 8}, ids, "order")
+	core.AssertEqual(t, 9, len(session.GetAllResponses()), "responses")
+}
+
+// TestDefaultMessageHandler_SetWorkers_inline verifies stopping the workers
+// goes back to handling requests in the read loop.
+func TestDefaultMessageHandler_SetWorkers_inline(t *testing.T) {
+	var mu sync.Mutex
+	var ids []int32
+	release := make(chan struct{})
+	close(release)
+	h := newWorkersHandler(t, release, &mu, &ids)
+	core.AssertMustNoError(t, h.SetWorkers(0, 0), "stop workers")
+
+	session := newTestSession("", 0)
+	err := h.HandleMessage(context.Background(), session, newTestRequest(1, pathEcho))
+	core.AssertMustNoError(t, err, "HandleMessage")
+	core.AssertNotNil(t, session.GetLastResponse(), "answered inline")
+}
+
+// newFailingWorkersHandler returns a handler running on a worker whose
+// /fail handler fails without answering, and whose /fast handler answers
+// right away.
+func newFailingWorkersHandler(t *testing.T) *DefaultMessageHandler {
+	t.Helper()
+
+	h := NewDefaultMessageHandler(nil)
+	core.AssertMustNoError(t, h.RegisterHandlerFunc("/fail", func(context.Context, *RequestContext) error {
+		return errors.New("failed")
+	}), "register fail")
+	core.AssertMustNoError(t, h.RegisterHandlerFunc("/fast", func(_ context.Context, rc *RequestContext) error {
+		return rc.SendOK([]byte("fast"))
+	}), "register fast")
+	core.AssertMustNoError(t, h.SetWorkers(1, 16), "SetWorkers")
+	t.Cleanup(func() { _ = h.SetWorkers(0, 0) })
+	return h
+}
+
+// TestDefaultSession_WorkersForgetRequests verifies the requests a worker
+// handles without answering are forgotten.
+func TestDefaultSession_WorkersForgetRequests(t *testing.T) {
+	h := newFailingWorkersHandler(t)
+	s, _ := newSharedTestSession(h)
+	s.config.Timestamps = true
+
+	for id := int32(1); id <= 3; id++ {
+		frame := encodeTestFrame(t, newTestRequest(id, "/fail"))
+		core.AssertMustNoError(t, s.decodeAndHandle(context.Background(), frame, 0), "request %d", id)
+	}
+	// waits for the queued requests
+	core.AssertMustNoError(t, h.SetWorkers(0, 0), "stop workers")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	core.AssertEqual(t, 0, len(s.received), "received")
+}
+
+// TestDefaultSession_StrictOrderWorkers verifies a request failing on a
+// worker doesn't hold back the responses of later ones.
+func TestDefaultSession_StrictOrderWorkers(t *testing.T) {
+	h := newFailingWorkersHandler(t)
+
+	server, client := net.Pipe()
+	defer client.Close()
+
+	sm := NewDefaultSessionManager(h, nil)
+	core.AssertMustNoError(t, sm.SetSessionConfig(SessionConfig{StrictOrder: true}), "config")
+	session := sm.AddSession(server)
+	go func() { _ = session.Handle(context.Background()) }()
+
+	for i, path := range []string{"/fail", "/fast"} {
+		_, err := client.Write(encodeTestFrame(t, newTestRequest(int32(i+1), path)))
+		core.AssertMustNoError(t, err, "write %s", path)
+	}
+
+	// well before DefaultStrictOrderTimeout
+	response := readResponse(t, client)
+	core.AssertEqual(t, int32(2), response.RequestId, "request ID")
+	core.AssertEqual(t, "fast", string(response.Data), "response")
+}
+
+// countWorkers returns how many workers are running, of any pool.
+func countWorkers() int {
+	buf := make([]byte, 1<<20)
+	buf = buf[:runtime.Stack(buf, true)]
+	return bytes.Count(buf, []byte(".(*workerPool).run("))
+}
+
+// TestWithWorkers_Shutdown verifies the workers started by WithWorkers
+// don't outlive the server.
+func TestWithWorkers_Shutdown(t *testing.T) {
+	before := countWorkers()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	core.AssertMustNoError(t, err, "listen")
+	srv := NewDefaultServer(ln, nil, nil, WithWorkers(4, 16))
+	testutils.AssertWaitForCondition(t, func() bool {
+		return countWorkers() == before+4
+	}, time.Second, "workers started")
+
+	done := make(chan error, 1)
+	go func() { done <- srv.Serve(context.Background()) }()
+	<-srv.Ready()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	core.AssertMustNoError(t, srv.Shutdown(ctx), "shutdown")
+	<-done
+
+	// stopped, although maybe still returning
+	testutils.AssertWaitForCondition(t, func() bool {
+		return countWorkers() == before
+	}, time.Second, "workers left")
+}
@@ -0,0 +1,67 @@
+package nanorpc
+
+import (
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// requestIDField is the field number of request_id in [NanoRPCResponse].
+const requestIDField protowire.Number = 1
+
+// SharedResponse is a [NanoRPCResponse] encoded once to be sent to many
+// requests, as the update of a publication is to every subscription of
+// its path. Only their request_id differs, and as it's the first field,
+// prepending it gives the same bytes [EncodeResponse] would.
+type SharedResponse struct {
+	body []byte // encoded without request_id
+}
+
+// NewSharedResponse encodes res for any request, ignoring its RequestId.
+func NewSharedResponse(res *NanoRPCResponse) (*SharedResponse, error) {
+	if res.GetRequestId() != 0 {
+		res = proto.CloneOf(res)
+		res.RequestId = 0
+	}
+
+	body, err := proto.Marshal(res)
+	if err != nil {
+		return nil, err
+	}
+	return &SharedResponse{body: body}, nil
+}
+
+// Size returns the length of the wrapped response for requestID. A nil
+// SharedResponse is an empty response.
+func (r *SharedResponse) Size(requestID int32) int {
+	n := r.size(requestID)
+	return protowire.SizeVarint(uint64(n)) + n
+}
+
+// size returns the length of the response for requestID, without the
+// length prefix.
+func (r *SharedResponse) size(requestID int32) int {
+	n := len(r.getBody())
+	if requestID != 0 {
+		n += protowire.SizeTag(requestIDField) + protowire.SizeVarint(uint64(requestID))
+	}
+	return n
+}
+
+// AppendTo appends the wrapped response for requestID to dst, as
+// [EncodeResponse] encodes it.
+func (r *SharedResponse) AppendTo(dst []byte, requestID int32) []byte {
+	dst = protowire.AppendVarint(dst, uint64(r.size(requestID)))
+	if requestID != 0 {
+		dst = protowire.AppendTag(dst, requestIDField, protowire.VarintType)
+		dst = protowire.AppendVarint(dst, uint64(requestID))
+	}
+	return append(dst, r.getBody()...)
+}
+
+// getBody returns the encoded response, empty for a nil one.
+func (r *SharedResponse) getBody() []byte {
+	if r == nil {
+		return nil
+	}
+	return r.body
+}
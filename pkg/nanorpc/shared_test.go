@@ -0,0 +1,90 @@
+package nanorpc
+
+import (
+	"math"
+	"testing"
+
+	"darvaza.org/core"
+	"google.golang.org/protobuf/proto"
+)
+
+var _ core.TestCase = sharedResponseTestCase{}
+
+type sharedResponseTestCase struct {
+	res  *NanoRPCResponse
+	name string
+	ids  []int32
+}
+
+func (tc sharedResponseTestCase) Name() string { return tc.name }
+
+func (tc sharedResponseTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	shared, err := NewSharedResponse(tc.res)
+	core.AssertMustNoError(t, err, "NewSharedResponse")
+
+	for _, id := range tc.ids {
+		res := proto.CloneOf(tc.res)
+		res.RequestId = id
+		want, err := EncodeResponse(res, nil)
+		core.AssertMustNoError(t, err, "EncodeResponse")
+
+		got := shared.AppendTo([]byte("x"), id)
+		core.AssertSliceEqual(t, want, got[1:], "request %d", id)
+		core.AssertEqual(t, len(want), shared.Size(id), "size %d", id)
+	}
+}
+
+func newSharedResponseTestCase(name string, res *NanoRPCResponse) sharedResponseTestCase {
+	return sharedResponseTestCase{
+		name: name,
+		res:  res,
+		ids:  []int32{0, 1, 300, -1, math.MaxInt32, math.MinInt32},
+	}
+}
+
+func sharedResponseTestCases() []sharedResponseTestCase {
+	return []sharedResponseTestCase{
+		newSharedResponseTestCase("update", &NanoRPCResponse{
+			ResponseType: NanoRPCResponse_TYPE_UPDATE,
+			Sequence:     42,
+			Data:         []byte("payload"),
+		}),
+		newSharedResponseTestCase("pattern update", &NanoRPCResponse{
+			RequestId:    7, // ignored
+			ResponseType: NanoRPCResponse_TYPE_UPDATE,
+			Path:         "/sensors/7/temperature",
+			Data:         make([]byte, 300),
+		}),
+		newSharedResponseTestCase("empty", &NanoRPCResponse{}),
+	}
+}
+
+func TestSharedResponse(t *testing.T) {
+	core.RunTestCases(t, sharedResponseTestCases())
+}
+
+func TestSharedResponse_nil(t *testing.T) {
+	var shared *SharedResponse
+
+	want, err := EncodeResponse(&NanoRPCResponse{RequestId: 5}, nil)
+	core.AssertMustNoError(t, err, "EncodeResponse")
+	core.AssertSliceEqual(t, want, shared.AppendTo(nil, 5), "encoded")
+	core.AssertEqual(t, len(want), shared.Size(5), "size")
+}
+
+func TestAppendResponse(t *testing.T) {
+	res := &NanoRPCResponse{
+		RequestId:    12,
+		ResponseType: NanoRPCResponse_TYPE_RESPONSE,
+		Data:         []byte("result"),
+	}
+
+	want, err := EncodeResponse(res, nil)
+	core.AssertMustNoError(t, err, "EncodeResponse")
+
+	got, err := AppendResponse([]byte("x"), res)
+	core.AssertMustNoError(t, err, "AppendResponse")
+	core.AssertSliceEqual(t, want, got[1:], "encoded")
+}
@@ -0,0 +1,15 @@
+package nanorpc
+
+import "time"
+
+// ServerTime returns the server processing time reported by the
+// timestamps of a response, and whether the response carried valid ones.
+func ServerTime(res *NanoRPCResponse) (time.Duration, bool) {
+	ts := res.GetTimestamps()
+	if ts.GetReceivedUs() == 0 || ts.GetProcessedUs() < ts.GetReceivedUs() {
+		return 0, false
+	}
+
+	d := ts.GetProcessedUs() - ts.GetReceivedUs()
+	return time.Duration(d) * time.Microsecond, true
+}
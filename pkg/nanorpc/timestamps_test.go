@@ -0,0 +1,54 @@
+package nanorpc
+
+import (
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+)
+
+var _ core.TestCase = serverTimeTestCase{}
+
+type serverTimeTestCase struct {
+	res  *NanoRPCResponse
+	name string
+	want time.Duration
+	ok   bool
+}
+
+func (tc serverTimeTestCase) Name() string { return tc.name }
+
+func (tc serverTimeTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	got, ok := ServerTime(tc.res)
+	core.AssertEqual(t, tc.ok, ok, "ok")
+	core.AssertEqual(t, tc.want, got, "server time")
+}
+
+func newServerTimeTestCase(name string, received, processed uint64, want time.Duration,
+	ok bool) serverTimeTestCase {
+	return serverTimeTestCase{
+		name: name,
+		res: &NanoRPCResponse{
+			Timestamps: &NanoRPCTimestamps{ReceivedUs: received, ProcessedUs: processed},
+		},
+		want: want,
+		ok:   ok,
+	}
+}
+
+func serverTimeTestCases() []serverTimeTestCase {
+	return []serverTimeTestCase{
+		newServerTimeTestCase("valid", 1000, 1250, 250*time.Microsecond, true),
+		newServerTimeTestCase("instant", 1000, 1000, 0, true),
+		newServerTimeTestCase("unset", 0, 0, 0, false),
+		newServerTimeTestCase("backwards", 1250, 1000, 0, false),
+		{name: "no_timestamps", res: &NanoRPCResponse{}},
+		{name: "nil_response"},
+	}
+}
+
+func TestServerTime(t *testing.T) {
+	core.RunTestCases(t, serverTimeTestCases())
+}
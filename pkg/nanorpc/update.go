@@ -0,0 +1,10 @@
+package nanorpc
+
+// IsFinalUpdate reports whether res is the TYPE_UPDATE a server sends to
+// end a subscription on its own, such as when an operator forces an
+// unsubscription. It carries a non-OK status, and no further messages
+// bearing its request_id follow.
+func IsFinalUpdate(res *NanoRPCResponse) bool {
+	return res.GetResponseType() == NanoRPCResponse_TYPE_UPDATE &&
+		res.GetResponseStatus() != NanoRPCResponse_STATUS_OK
+}
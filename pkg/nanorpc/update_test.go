@@ -0,0 +1,45 @@
+package nanorpc
+
+import (
+	"testing"
+
+	"darvaza.org/core"
+)
+
+var _ core.TestCase = isFinalUpdateTestCase{}
+
+type isFinalUpdateTestCase struct {
+	res  *NanoRPCResponse
+	name string
+	want bool
+}
+
+func (tc isFinalUpdateTestCase) Name() string { return tc.name }
+
+func (tc isFinalUpdateTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	core.AssertEqual(t, tc.want, IsFinalUpdate(tc.res), "IsFinalUpdate")
+}
+
+func newIsFinalUpdateTestCase(name string, rt NanoRPCResponse_Type, st NanoRPCResponse_Status,
+	want bool) isFinalUpdateTestCase {
+	return isFinalUpdateTestCase{
+		name: name,
+		res:  &NanoRPCResponse{ResponseType: rt, ResponseStatus: st},
+		want: want,
+	}
+}
+
+func isFinalUpdateTestCases() []isFinalUpdateTestCase {
+	return []isFinalUpdateTestCase{
+		newIsFinalUpdateTestCase("update", NanoRPCResponse_TYPE_UPDATE, NanoRPCResponse_STATUS_OK, false),
+		newIsFinalUpdateTestCase("final", NanoRPCResponse_TYPE_UPDATE, NanoRPCResponse_STATUS_NOT_FOUND, true),
+		newIsFinalUpdateTestCase("response", NanoRPCResponse_TYPE_RESPONSE, NanoRPCResponse_STATUS_NOT_FOUND, false),
+		{name: "nil_response"},
+	}
+}
+
+func TestIsFinalUpdate(t *testing.T) {
+	core.RunTestCases(t, isFinalUpdateTestCases())
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+// Limits applied by the log field normalisers.
+const (
+	// MaxLogPathLength is the longest path [LogPath] keeps verbatim.
+	MaxLogPathLength = 48
+
+	// LogSessionShards is the number of distinct values [LogSessionShard]
+	// produces.
+	LogSessionShards = 256
+)
+
+// logSizeBuckets are the upper bounds, in bytes, of the [LogSizeBucket]
+// labels, growing by a factor of four.
+var logSizeBuckets = []struct {
+	label string
+	max   int
+}{
+	{"0B", 0},
+	{"<=64B", 64},
+	{"<=256B", 256},
+	{"<=1KiB", 1 << 10},
+	{"<=4KiB", 4 << 10},
+	{"<=16KiB", 16 << 10},
+	{"<=64KiB", 64 << 10},
+	{"<=256KiB", 256 << 10},
+	{"<=1MiB", 1 << 20},
+}
+
+// LogPath normalises a request path for logging. Paths longer than
+// [MaxLogPathLength] are cut back to the last whole segment that fits and
+// marked with a trailing "/...", so IDs embedded at the end of paths don't
+// turn every request into a distinct value.
+func LogPath(path string) string {
+	if len(path) <= MaxLogPathLength {
+		return path
+	}
+
+	cut := path[:MaxLogPathLength]
+	if i := strings.LastIndexByte(cut, '/'); i > 0 {
+		cut = cut[:i]
+	}
+	return cut + "/..."
+}
+
+// LogSizeBucket maps a size in bytes to one of a few labels, such as
+// "<=4KiB", so payload sizes can be logged and grouped as a bounded set.
+func LogSizeBucket(n int) string {
+	for _, b := range logSizeBuckets {
+		if n <= b.max {
+			return b.label
+		}
+	}
+	return ">1MiB"
+}
+
+// LogSessionShard hashes a session ID into one of [LogSessionShards]
+// stable two-digit hex shards. Aggregate logs, such as those of a publish
+// fanning out to many sessions, use it in place of the session ID to stay
+// groupable without one value per session.
+func LogSessionShard(sessionID string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(sessionID))
+	return fmt.Sprintf("%02x", h.Sum32()%LogSessionShards)
+}
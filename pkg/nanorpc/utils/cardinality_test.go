@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"darvaza.org/core"
+)
+
+var _ core.TestCase = logPathTestCase{}
+
+type logPathTestCase struct {
+	name     string
+	path     string
+	expected string
+}
+
+func (tc logPathTestCase) Name() string { return tc.name }
+
+func (tc logPathTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	got := LogPath(tc.path)
+	core.AssertEqual(t, tc.expected, got, "LogPath")
+	core.AssertTrue(t, len(got) <= MaxLogPathLength+len("/..."), "length")
+}
+
+func newLogPathTestCase(name, path, expected string) logPathTestCase {
+	return logPathTestCase{name: name, path: path, expected: expected}
+}
+
+func logPathTestCases() []logPathTestCase {
+	long := "/devices/" + strings.Repeat("a", 30) + "/sensors/" + strings.Repeat("b", 20)
+	noSlash := "/" + strings.Repeat("x", 60)
+	return []logPathTestCase{
+		newLogPathTestCase("empty", "", ""),
+		newLogPathTestCase("short", "/api/status", "/api/status"),
+		newLogPathTestCase("segment boundary", long, "/devices/"+strings.Repeat("a", 30)+"/sensors/..."),
+		newLogPathTestCase("single segment", noSlash, noSlash[:MaxLogPathLength]+"/..."),
+	}
+}
+
+func TestLogPath(t *testing.T) {
+	core.RunTestCases(t, logPathTestCases())
+}
+
+var _ core.TestCase = logSizeBucketTestCase{}
+
+type logSizeBucketTestCase struct {
+	expected string
+	size     int
+}
+
+func (tc logSizeBucketTestCase) Name() string { return fmt.Sprintf("%d", tc.size) }
+
+func (tc logSizeBucketTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	core.AssertEqual(t, tc.expected, LogSizeBucket(tc.size), "LogSizeBucket")
+}
+
+func newLogSizeBucketTestCase(size int, expected string) logSizeBucketTestCase {
+	return logSizeBucketTestCase{expected: expected, size: size}
+}
+
+func logSizeBucketTestCases() []logSizeBucketTestCase {
+	return []logSizeBucketTestCase{
+		newLogSizeBucketTestCase(-1, "0B"),
+		newLogSizeBucketTestCase(0, "0B"),
+		newLogSizeBucketTestCase(1, "<=64B"),
+		newLogSizeBucketTestCase(64, "<=64B"),
+		newLogSizeBucketTestCase(65, "<=256B"),
+		newLogSizeBucketTestCase(4096, "<=4KiB"),
+		newLogSizeBucketTestCase(1<<20, "<=1MiB"),
+		newLogSizeBucketTestCase(1<<20+1, ">1MiB"),
+	}
+}
+
+func TestLogSizeBucket(t *testing.T) {
+	core.RunTestCases(t, logSizeBucketTestCases())
+}
+
+func TestLogSessionShard(t *testing.T) {
+	shards := make(map[string]struct{})
+	for i := 0; i < 4*LogSessionShards; i++ {
+		id := fmt.Sprintf("session-%d", i)
+		shard := LogSessionShard(id)
+
+		core.AssertEqual(t, 2, len(shard), "shard length")
+		core.AssertEqual(t, shard, LogSessionShard(id), "stable")
+		shards[shard] = struct{}{}
+	}
+
+	core.AssertTrue(t, len(shards) <= LogSessionShards, "bounded")
+	core.AssertTrue(t, len(shards) > LogSessionShards/2, "spread")
+}
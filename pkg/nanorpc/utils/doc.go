@@ -34,6 +34,23 @@
 //	// Clear and release the underlying array
 //	responses = utils.ClearAndNilSlice(responses)
 //
+// # Log Field Cardinality
+//
+// Paths, payload sizes and session IDs can take a different value on
+// every message. Hot-path and aggregate logs normalise them into bounded
+// sets so log backends can index them on large fleets:
+//
+//	logger.WithField(utils.FieldPath, utils.LogPath(path)).
+//		WithField(utils.FieldDataSize, utils.LogSizeBucket(len(data))).
+//		WithField(utils.FieldSessionShard, utils.LogSessionShard(sessionID))
+//
+// # TLS
+//
+// LoadCertPool reads PEM encoded CA certificates for verifying peers
+// against private certificate authorities:
+//
+//	pool, err := utils.LoadCertPool("ca.pem")
+//
 // # Sub-packages
 //
 // The testutils subpackage provides testing utilities including mock loggers
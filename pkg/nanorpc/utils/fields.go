@@ -4,6 +4,8 @@ import (
 	"net"
 
 	"darvaza.org/slog"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
 )
 
 // Field name constants for structured logging.
@@ -14,15 +16,20 @@ const (
 	FieldComponent = "component"
 
 	// Session fields
-	FieldSessionID  = "session_id"
-	FieldRemoteAddr = "remote_addr"
-	FieldLocalAddr  = "local_addr"
+	FieldSessionID    = "session_id"
+	FieldSessionShard = "session_shard"
+	FieldRemoteAddr   = "remote_addr"
+	FieldLocalAddr    = "local_addr"
 
 	// Request fields
-	FieldRequestID   = "request_id"
-	FieldRequestType = "request_type"
-	FieldPath        = "path"
-	FieldPathHash    = "path_hash"
+	FieldRequestID     = "request_id"
+	FieldRequestType   = "request_type"
+	FieldCorrelationID = "correlation_id"
+	FieldPath          = "path"
+	FieldPathHash      = "path_hash"
+	FieldPathBefore    = "path_before"
+	FieldPathAfter     = "path_after"
+	FieldDataSize      = "data_size"
 
 	// Response fields
 	FieldResponseType   = "response_type"
@@ -42,16 +49,33 @@ const (
 	FieldState   = "state"
 
 	// Queue fields
-	FieldQueueSize  = "queue_size"
-	FieldQueueDepth = "queue_depth"
+	FieldQueueSize   = "queue_size"
+	FieldQueueDepth  = "queue_depth"
+	FieldMaxInflight = "max_inflight"
 
 	// Handler fields
 	FieldHandlerName = "handler_name"
 	FieldHandlerPath = "handler_path"
 
+	// Manifest fields
+	FieldManifest      = "manifest"
+	FieldRoutesAdded   = "routes_added"
+	FieldRoutesRemoved = "routes_removed"
+	FieldRouteCount    = "route_count"
+
 	// Subscription fields
 	FieldSubscriptionCount = "subscription_count"
 	FieldCallbackCount     = "callback_count"
+
+	// Bridge fields
+	FieldNode  = "node"
+	FieldTopic = "topic"
+
+	// Wire fields
+	FieldDirection = "direction"
+	FieldDataBytes = "data_bytes"
+	FieldMetadata  = "metadata"
+	FieldPayload   = "payload"
 )
 
 // Component name constants for the FieldComponent field
@@ -61,6 +85,7 @@ const (
 	ComponentSessionManager = "session-manager"
 	ComponentMessageHandler = "message-handler"
 	ComponentListener       = "listener"
+	ComponentManifestLoader = "manifest-loader"
 
 	// Client components
 	ComponentClient          = "client"
@@ -72,6 +97,13 @@ const (
 	// Shared components
 	ComponentSession   = "session"
 	ComponentHashCache = "hash-cache"
+
+	// Bridge components
+	ComponentClusterBridge = "cluster-bridge"
+	ComponentMQTTBridge    = "mqtt-bridge"
+
+	// Gateway components
+	ComponentGateway = "gateway"
 )
 
 // State constants for the FieldState field
@@ -140,3 +172,13 @@ func WithError(logger slog.Logger, err error) slog.Logger {
 	}
 	return logger
 }
+
+// RequestFields returns the fields identifying a request in log lines, its
+// ID and, when its metadata carries one, its correlation ID.
+func RequestFields(req *nanorpc.NanoRPCRequest) slog.Fields {
+	fields := slog.Fields{FieldRequestID: req.GetRequestId()}
+	if id := nanorpc.Metadata(req.GetMetadata()).CorrelationID(); id != "" {
+		fields[FieldCorrelationID] = id
+	}
+	return fields
+}
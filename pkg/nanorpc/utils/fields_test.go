@@ -7,6 +7,7 @@ import (
 	"darvaza.org/core"
 	"darvaza.org/slog"
 
+	"protomcp.org/nanorpc/pkg/nanorpc"
 	"protomcp.org/nanorpc/pkg/nanorpc/utils/testutils"
 )
 
@@ -138,3 +139,18 @@ func TestWithError(t *testing.T) {
 	result = WithError(nil, testErr)
 	core.AssertNil(t, result, "nil logger result")
 }
+
+func TestRequestFields(t *testing.T) {
+	req := &nanorpc.NanoRPCRequest{
+		RequestId: 7,
+		Metadata:  nanorpc.NewMetadata(nanorpc.MetadataCorrelationID, "abc"),
+	}
+	fields := RequestFields(req)
+	core.AssertEqual[any](t, int32(7), fields[FieldRequestID], "request ID")
+	core.AssertEqual[any](t, "abc", fields[FieldCorrelationID], "correlation ID")
+
+	fields = RequestFields(&nanorpc.NanoRPCRequest{RequestId: 7})
+	_, ok := fields[FieldCorrelationID]
+	core.AssertFalse(t, ok, "no correlation ID")
+	core.AssertEqual(t, 1, len(RequestFields(nil)), "nil request")
+}
@@ -115,7 +115,68 @@
 //   - Connection state tracking (open/closed)
 //   - Configurable local/remote addresses
 //   - Proper error handling for closed connections
-//   - No-op deadline methods suitable for testing
+//   - Deadlines that time out, and wake up blocked calls when moved
+//   - Partial reads and short writes, with ReadChunk and WriteChunk
+//   - Injected errors, with ReadErr and WriteErr
+//   - Artificial latency on every call, with Latency
+//
+// A read deadline makes Read wait for it, and time out, once Data is
+// consumed, as a quiet peer would:
+//
+//	conn := &MockConn{Data: frame, ReadChunk: 1, Latency: time.Millisecond}
+//	conn.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+//
+// ## MockSession
+//
+// A Session of the server package for handler tests, recording the
+// responses sent and checking them against expectations:
+//
+//	session := &MockSession{SessionID: "s1", Remote: "127.0.0.1:12345"}
+//	session.ExpectResponse(1, nanorpc.NanoRPCResponse_STATUS_OK)
+//
+//	err := handler.HandleMessage(ctx, session, req)
+//	session.AssertExpectations(t)
+//
+// Handle hands the scripted Requests to OnRequest in order, and Events
+// lists them along with the responses, in the order they happened.
+//
+// ## MockListener
+//
+// A net.Listener returning scripted outcomes, to test how accept loops
+// handle errors and panics:
+//
+//	l := NewMockListener("127.0.0.1:8080",
+//		MockAccept{Err: errTemporary},
+//		MockAccept{Panic: "boom"},
+//		MockAccept{Conn: &MockConn{Remote: "192.168.1.1:12345"}},
+//	)
+//
+// Once the outcomes are exhausted Accept blocks until Close, and then
+// fails with net.ErrClosed, as a real listener does.
+//
+// ## TestPKI
+//
+// A throwaway certificate authority issuing a localhost server certificate
+// and a client certificate, for TLS and mTLS tests:
+//
+//	pki := NewTestPKI(t)
+//	cfg := &tls.Config{Certificates: []tls.Certificate{pki.Server}}
+//
+//	// or as PEM files for path-based configuration
+//	files, err := pki.WriteFiles(t.TempDir())
+//
+// ## End-to-End Tests
+//
+// The e2e subpackage runs a real server on a random loopback port, or over
+// in-process pipes, and returns clients connected to it, for full-stack
+// scenarios:
+//
+//	ts := e2e.NewTestServer(t, &e2e.Options{Transport: e2e.Inproc})
+//	ts.Handle("/echo", echoHandler)
+//	c := ts.NewClient(nil)
+//
+// It imports the server and client packages, whose own tests use this one,
+// so it's a package of its own.
 //
 // # Helper Functions
 //
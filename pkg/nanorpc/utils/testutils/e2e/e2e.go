@@ -0,0 +1,213 @@
+// Package e2e runs a real NanoRPC server, and real clients connected to
+// it, for end-to-end tests of full-stack scenarios:
+//
+//	ts := e2e.NewTestServer(t, nil)
+//	ts.Handle("/echo", func(_ context.Context, req *server.RequestContext) error {
+//		return req.SendOK(req.GetData())
+//	})
+//
+//	c := ts.NewClient(nil)
+//
+// Everything is torn down with the test. It's kept apart from testutils,
+// which the server and client packages use in their own tests, so it can
+// import both.
+package e2e
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"darvaza.org/core"
+	"darvaza.org/slog"
+
+	"protomcp.org/nanorpc/pkg/nanorpc/client"
+	"protomcp.org/nanorpc/pkg/nanorpc/nanorpctest"
+	"protomcp.org/nanorpc/pkg/nanorpc/server"
+)
+
+// DefaultTimeout bounds starting, connecting and stopping servers and
+// clients.
+const DefaultTimeout = 2 * time.Second
+
+// inprocRemote is the Remote of clients of in-process servers, never
+// dialled but required by [client.Config].
+const inprocRemote = "127.0.0.1:1"
+
+// Transport is how clients reach a [TestServer].
+type Transport int
+
+const (
+	// TCP serves on a random loopback port.
+	TCP Transport = iota
+	// Inproc serves over in-process pipes, see
+	// [nanorpctest.InprocListener]. Its clients don't reconnect.
+	Inproc
+)
+
+// Options configures a [TestServer]. The zero value serves over TCP.
+type Options struct {
+	// Logger is used by the server, and by clients without one.
+	Logger slog.Logger
+	// ServerOptions are passed to [server.NewDefaultServer].
+	ServerOptions []server.ServerOption
+	// Transport is how clients reach the server.
+	Transport Transport
+}
+
+// TestServer is a [server.Server] serving a [server.DefaultMessageHandler]
+// for the duration of a test.
+type TestServer struct {
+	// Handler is where the paths of the server are registered.
+	Handler *server.DefaultMessageHandler
+	// Server is the running server.
+	Server *server.Server
+
+	t      core.T
+	logger slog.Logger
+	ln     net.Listener
+	inproc *nanorpctest.InprocListener
+	done   chan error
+	once   sync.Once
+}
+
+// NewTestServer starts a [TestServer], nil options using [TCP], and
+// registers its shutdown with t.Cleanup. It fails the test if the server
+// can't start.
+func NewTestServer(t core.T, opts *Options) *TestServer {
+	t.Helper()
+
+	if opts == nil {
+		opts = new(Options)
+	}
+
+	ts := &TestServer{
+		Handler: server.NewDefaultMessageHandler(nil),
+		t:       t,
+		logger:  opts.Logger,
+		done:    make(chan error, 1),
+	}
+
+	if opts.Transport == Inproc {
+		ts.inproc = nanorpctest.NewInprocListener("e2e")
+		ts.ln = ts.inproc
+	} else {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		core.AssertMustNoError(t, err, "listen")
+		ts.ln = ln
+	}
+
+	ts.Server = server.NewDefaultServer(ts.ln, ts.Handler, ts.logger, opts.ServerOptions...)
+	go func() { ts.done <- ts.Server.Serve(context.Background()) }()
+	registerCleanup(t, func() { core.AssertNoError(t, ts.Close(), "test server") })
+
+	select {
+	case <-ts.Server.Ready():
+	case <-time.After(DefaultTimeout):
+		t.Fatal("timed out waiting for the server to start")
+	}
+	return ts
+}
+
+// Addr returns the address of the server, suitable for the Remote of a
+// client when serving over [TCP].
+func (ts *TestServer) Addr() string {
+	return ts.ln.Addr().String()
+}
+
+// Handle registers a handler for a path, failing the test if it can't.
+func (ts *TestServer) Handle(path string, fn server.RequestHandlerFunc, opts ...server.AccessOption) {
+	ts.t.Helper()
+
+	err := ts.Handler.RegisterHandlerFunc(path, fn, opts...)
+	core.AssertMustNoError(ts.t, err, "register %q", path)
+}
+
+// NewClient returns a [client.Client] connected to the server, shut down
+// with the test. cfg, if given, is used as a template; its Remote and
+// Context are replaced.
+func (ts *TestServer) NewClient(cfg *client.Config) *client.Client {
+	ts.t.Helper()
+
+	var cc client.Config
+	if cfg != nil {
+		cc = *cfg
+	}
+	cc.Context = context.Background()
+	cc.Remote = ts.Addr()
+	if ts.inproc != nil {
+		cc.Remote = inprocRemote
+	}
+	if cc.Logger == nil {
+		cc.Logger = ts.logger
+	}
+
+	c, err := cc.New()
+	core.AssertMustNoError(ts.t, err, "client")
+
+	// ends attached sessions, which outlive Shutdown otherwise
+	attachCtx, detach := context.WithCancel(context.Background())
+	registerCleanup(ts.t, func() {
+		detach()
+		shutdownClient(c)
+	})
+
+	core.AssertMustNoError(ts.t, ts.connect(attachCtx, c), "connect")
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	core.AssertMustNoError(ts.t, c.WaitConnected(ctx), "wait connected")
+	return c
+}
+
+// connect dials the server, or attaches c to it for the lifetime of ctx
+// when in-process.
+func (ts *TestServer) connect(ctx context.Context, c *client.Client) error {
+	if ts.inproc != nil {
+		return ts.inproc.Attach(ctx, c)
+	}
+	return c.Connect()
+}
+
+// Close shuts the server down, waiting for it to stop. It is safe to call
+// more than once.
+func (ts *TestServer) Close() error {
+	var err error
+	ts.once.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+		defer cancel()
+
+		err = ts.Server.Shutdown(ctx)
+		select {
+		case serveErr := <-ts.done:
+			if err == nil && !errors.Is(serveErr, context.Canceled) {
+				err = serveErr
+			}
+		case <-ctx.Done():
+			if err == nil {
+				err = ctx.Err()
+			}
+		}
+	})
+	return err
+}
+
+// shutdownClient stops a client created by NewClient.
+func shutdownClient(c *client.Client) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	_ = c.Shutdown(ctx)
+}
+
+// registerCleanup runs fn at test end when t supports cleanup. A
+// *testing.T does; a core.MockT does not, leaving the test to close
+// explicitly.
+func registerCleanup(t core.T, fn func()) {
+	if tc, ok := t.(interface{ Cleanup(func()) }); ok {
+		tc.Cleanup(fn)
+	}
+}
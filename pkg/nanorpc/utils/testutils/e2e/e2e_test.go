@@ -0,0 +1,64 @@
+package e2e_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+	"protomcp.org/nanorpc/pkg/nanorpc/server"
+	"protomcp.org/nanorpc/pkg/nanorpc/utils/testutils/e2e"
+)
+
+var _ core.TestCase = testServerTestCase{}
+
+type testServerTestCase struct {
+	name      string
+	transport e2e.Transport
+}
+
+func (tc testServerTestCase) Name() string { return tc.name }
+
+func (tc testServerTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	ts := e2e.NewTestServer(t, &e2e.Options{Transport: tc.transport})
+	ts.Handle("/echo", func(_ context.Context, req *server.RequestContext) error {
+		return req.SendOK(req.GetData())
+	})
+
+	c := ts.NewClient(nil)
+
+	responses := make(chan *nanorpc.NanoRPCResponse, 1)
+	_, err := c.Request("/echo", nil, func(_ context.Context, _ int32, resp *nanorpc.NanoRPCResponse) error {
+		responses <- resp
+		return nil
+	})
+	core.AssertMustNoError(t, err, "Request")
+
+	select {
+	case resp := <-responses:
+		core.AssertEqual(t, nanorpc.NanoRPCResponse_STATUS_OK, resp.ResponseStatus, "status")
+	case <-time.After(e2e.DefaultTimeout):
+		t.Fatal("timed out waiting for the response")
+	}
+}
+
+func newTestServerTestCase(name string, transport e2e.Transport) testServerTestCase {
+	return testServerTestCase{name: name, transport: transport}
+}
+
+func TestTestServer(t *testing.T) {
+	core.RunTestCases(t, []testServerTestCase{
+		newTestServerTestCase("tcp", e2e.TCP),
+		newTestServerTestCase("inproc", e2e.Inproc),
+	})
+}
+
+func TestTestServer_Close(t *testing.T) {
+	ts := e2e.NewTestServer(t, nil)
+	core.AssertNoError(t, ts.Close(), "close")
+	core.AssertNoError(t, ts.Close(), "close again")
+}
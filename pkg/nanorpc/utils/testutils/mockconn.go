@@ -1,12 +1,29 @@
 package testutils
 
 import (
+	"io"
 	"net"
+	"os"
+	"sync"
 	"time"
 )
 
-// MockConn implements net.Conn for testing
+// MockConn implements net.Conn for testing.
+//
+// Reads return Data and writes collect into WriteData. Deadlines behave as
+// on a real connection, failing calls with [os.ErrDeadlineExceeded] once
+// passed, and waking up blocked ones when moved. Once Data is consumed,
+// Read returns ReadErr, or waits for the read deadline if set, or returns
+// zero bytes otherwise.
 type MockConn struct {
+	// Injected errors
+	ReadErr  error // returned by Read once Data is consumed
+	WriteErr error // returned by Write instead of writing
+
+	// changed is closed, and replaced, when deadlines change or the
+	// connection is closed, to wake up blocked calls
+	changed chan struct{}
+
 	// Connection addresses
 	Remote string
 	Local  string
@@ -15,37 +32,178 @@ type MockConn struct {
 	Data      []byte
 	WriteData []byte
 
+	readDeadline  time.Time
+	writeDeadline time.Time
+
+	// Latency delays every Read and Write, bounded by their deadlines
+	Latency time.Duration
+	// ReadChunk, when positive, is the most a Read returns, for partial
+	// reads
+	ReadChunk int
+	// WriteChunk, when positive, is the most a Write accepts, failing
+	// with [io.ErrShortWrite] on larger ones
+	WriteChunk int
+
 	// State tracking
 	ReadPos int
+	mu      sync.Mutex
 	Closed  bool
 }
 
 // Read implements net.Conn
 func (m *MockConn) Read(b []byte) (int, error) {
-	if m.Closed {
-		return 0, net.ErrClosed
+	if err := m.pause(m.Latency, &m.readDeadline); err != nil {
+		return 0, err
+	}
+
+	n, wait, err := m.read(b)
+	if !wait {
+		return n, err
 	}
-	if m.ReadPos >= len(m.Data) {
-		// Return 0 to simulate EOF for test
-		return 0, nil
+
+	// nothing left to read, until the deadline
+	return 0, m.pause(-1, &m.readDeadline)
+}
+
+// read reads from Data, or tells to wait for the read deadline when
+// there's nothing left.
+func (m *MockConn) read(b []byte) (n int, wait bool, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch {
+	case m.Closed:
+		return 0, false, net.ErrClosed
+	case m.ReadPos < len(m.Data):
+		data := m.Data[m.ReadPos:]
+		if m.ReadChunk > 0 && len(data) > m.ReadChunk {
+			data = data[:m.ReadChunk]
+		}
+		n = copy(b, data)
+		m.ReadPos += n
+		return n, false, nil
+	case m.ReadErr != nil:
+		return 0, false, m.ReadErr
+	default:
+		// Return 0 to simulate EOF for test, unless there's a
+		// deadline to wait for
+		return 0, !m.readDeadline.IsZero(), nil
 	}
-	n := copy(b, m.Data[m.ReadPos:])
-	m.ReadPos += n
-	return n, nil
 }
 
 // Write implements net.Conn
 func (m *MockConn) Write(b []byte) (int, error) {
-	if m.Closed {
+	if err := m.pause(m.Latency, &m.writeDeadline); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch {
+	case m.Closed:
 		return 0, net.ErrClosed
+	case m.WriteErr != nil:
+		return 0, m.WriteErr
+	}
+
+	n := len(b)
+	if m.WriteChunk > 0 && n > m.WriteChunk {
+		n = m.WriteChunk
+	}
+	m.WriteData = append(m.WriteData, b[:n]...)
+	if n < len(b) {
+		return n, io.ErrShortWrite
+	}
+	return n, nil
+}
+
+// pause waits for d, or until interrupted if negative, failing with
+// [os.ErrDeadlineExceeded] if the deadline passes first, or
+// [net.ErrClosed] if the connection is closed.
+func (m *MockConn) pause(d time.Duration, deadline *time.Time) error {
+	var done <-chan time.Time
+	if d > 0 {
+		t := time.NewTimer(d)
+		defer t.Stop()
+		done = t.C
+	}
+
+	for {
+		changed, left, err := m.state(deadline)
+		if err != nil || d == 0 {
+			return err
+		}
+
+		woken, err := waitFor(done, changed, left)
+		if err != nil || !woken {
+			return err
+		}
+		// deadline moved, or closed, check again
+	}
+}
+
+// state returns the channel closed on the next change, and the time left
+// to the deadline, or fails if the connection is closed or the deadline
+// passed.
+func (m *MockConn) state(deadline *time.Time) (<-chan struct{}, time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.Closed {
+		return nil, 0, net.ErrClosed
+	}
+
+	left := time.Duration(-1)
+	if !deadline.IsZero() {
+		left = time.Until(*deadline)
+		if left <= 0 {
+			return nil, 0, os.ErrDeadlineExceeded
+		}
+	}
+
+	if m.changed == nil {
+		m.changed = make(chan struct{})
+	}
+	return m.changed, left, nil
+}
+
+// waitFor waits for done, changed or, unless negative, left to pass,
+// failing with [os.ErrDeadlineExceeded] in the last case. woken tells
+// if it was changed.
+func waitFor(done <-chan time.Time, changed <-chan struct{}, left time.Duration) (woken bool, err error) {
+	var timeout <-chan time.Time
+	if left >= 0 {
+		t := time.NewTimer(left)
+		defer t.Stop()
+		timeout = t.C
+	}
+
+	select {
+	case <-done:
+		return false, nil
+	case <-changed:
+		return true, nil
+	case <-timeout:
+		return false, os.ErrDeadlineExceeded
+	}
+}
+
+// unsafeNotify wakes up blocked calls to reconsider their state.
+func (m *MockConn) unsafeNotify() {
+	if m.changed != nil {
+		close(m.changed)
+		m.changed = nil
 	}
-	m.WriteData = append(m.WriteData, b...)
-	return len(b), nil
 }
 
 // Close implements net.Conn
 func (m *MockConn) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	m.Closed = true
+	m.unsafeNotify()
 	return nil
 }
 
@@ -60,13 +218,34 @@ func (m *MockConn) RemoteAddr() net.Addr {
 }
 
 // SetDeadline implements net.Conn
-func (*MockConn) SetDeadline(_ time.Time) error { return nil }
+func (m *MockConn) SetDeadline(t time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.readDeadline, m.writeDeadline = t, t
+	m.unsafeNotify()
+	return nil
+}
 
 // SetReadDeadline implements net.Conn
-func (*MockConn) SetReadDeadline(_ time.Time) error { return nil }
+func (m *MockConn) SetReadDeadline(t time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.readDeadline = t
+	m.unsafeNotify()
+	return nil
+}
 
 // SetWriteDeadline implements net.Conn
-func (*MockConn) SetWriteDeadline(_ time.Time) error { return nil }
+func (m *MockConn) SetWriteDeadline(t time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.writeDeadline = t
+	m.unsafeNotify()
+	return nil
+}
 
 // MockAddr implements net.Addr for testing
 type MockAddr struct {
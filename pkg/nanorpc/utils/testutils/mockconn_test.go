@@ -1,7 +1,10 @@
 package testutils
 
 import (
+	"errors"
+	"io"
 	"net"
+	"os"
 	"testing"
 	"time"
 
@@ -115,7 +118,7 @@ func TestMockConn_Addresses(t *testing.T) {
 	core.AssertEqual(t, "tcp", remoteAddr.Network(), "RemoteAddr should use tcp network")
 }
 
-// TestMockConn_Deadlines tests deadline methods (should be no-ops)
+// TestMockConn_Deadlines tests deadline methods accept future deadlines
 func TestMockConn_Deadlines(t *testing.T) {
 	conn := &MockConn{}
 	deadline := time.Now().Add(time.Hour)
@@ -176,3 +179,124 @@ func TestMockConn_ReadWriteIntegration(t *testing.T) {
 	core.AssertSliceEqual(t, writeData, conn.WriteData, "WriteData should be unchanged by read")
 	core.AssertEqual(t, 14, conn.ReadPos, "ReadPos should advance correctly")
 }
+
+// TestMockConn_ReadDeadline tests reads time out once the data is consumed
+// and the read deadline passes
+func TestMockConn_ReadDeadline(t *testing.T) {
+	conn := &MockConn{Data: []byte("ab")}
+	core.AssertNoError(t, conn.SetReadDeadline(time.Now().Add(20*time.Millisecond)), "SetReadDeadline")
+
+	buf := make([]byte, 4)
+	n, err := conn.Read(buf)
+	core.AssertNoError(t, err, "read before the deadline")
+	core.AssertEqual(t, 2, n, "bytes read")
+
+	start := time.Now()
+	_, err = conn.Read(buf)
+	core.AssertErrorIs(t, err, os.ErrDeadlineExceeded, "read past the deadline")
+	core.AssertTrue(t, time.Since(start) >= 10*time.Millisecond, "waited for the deadline")
+
+	var netErr net.Error
+	core.AssertTrue(t, errors.As(err, &netErr) && netErr.Timeout(), "timeout error")
+}
+
+// TestMockConn_DeadlineWakesRead tests moving the deadline, or closing,
+// wakes up a blocked read
+func TestMockConn_DeadlineWakesRead(t *testing.T) {
+	conn := &MockConn{}
+	core.AssertNoError(t, conn.SetReadDeadline(time.Now().Add(time.Hour)), "SetReadDeadline")
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := conn.Read(make([]byte, 1))
+		errs <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	core.AssertNoError(t, conn.SetReadDeadline(time.Now()), "SetReadDeadline")
+	core.AssertErrorIs(t, mustReceive(t, errs), os.ErrDeadlineExceeded, "moved deadline")
+
+	core.AssertNoError(t, conn.SetReadDeadline(time.Now().Add(time.Hour)), "SetReadDeadline")
+	go func() {
+		_, err := conn.Read(make([]byte, 1))
+		errs <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	core.AssertNoError(t, conn.Close(), "Close")
+	core.AssertErrorIs(t, mustReceive(t, errs), net.ErrClosed, "closed")
+}
+
+func mustReceive(t *testing.T, errs <-chan error) error {
+	t.Helper()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-time.After(time.Second):
+		t.Fatal("read not woken up")
+		return nil
+	}
+}
+
+// TestMockConn_WriteDeadline tests writes fail once the write deadline
+// passed, and latency beyond it times them out
+func TestMockConn_WriteDeadline(t *testing.T) {
+	conn := &MockConn{}
+	core.AssertNoError(t, conn.SetWriteDeadline(time.Now().Add(-time.Second)), "SetWriteDeadline")
+
+	_, err := conn.Write([]byte("x"))
+	core.AssertErrorIs(t, err, os.ErrDeadlineExceeded, "past deadline")
+
+	conn = &MockConn{Latency: time.Hour}
+	core.AssertNoError(t, conn.SetDeadline(time.Now().Add(10*time.Millisecond)), "SetDeadline")
+
+	_, err = conn.Write([]byte("x"))
+	core.AssertErrorIs(t, err, os.ErrDeadlineExceeded, "latency past deadline")
+	core.AssertEqual(t, 0, len(conn.WriteData), "nothing written")
+}
+
+// TestMockConn_Latency tests reads and writes are delayed
+func TestMockConn_Latency(t *testing.T) {
+	conn := &MockConn{Data: []byte("a"), Latency: 10 * time.Millisecond}
+
+	start := time.Now()
+	_, err := conn.Read(make([]byte, 1))
+	core.AssertNoError(t, err, "Read")
+	_, err = conn.Write([]byte("b"))
+	core.AssertNoError(t, err, "Write")
+	core.AssertTrue(t, time.Since(start) >= 20*time.Millisecond, "delayed")
+}
+
+// TestMockConn_PartialIO tests ReadChunk and WriteChunk
+func TestMockConn_PartialIO(t *testing.T) {
+	conn := &MockConn{Data: []byte("hello"), ReadChunk: 2, WriteChunk: 3}
+
+	buf := make([]byte, 5)
+	n, err := conn.Read(buf)
+	core.AssertNoError(t, err, "Read")
+	core.AssertEqual(t, "he", string(buf[:n]), "partial read")
+
+	n, err = conn.Write([]byte("world"))
+	core.AssertErrorIs(t, err, io.ErrShortWrite, "short write")
+	core.AssertEqual(t, 3, n, "bytes written")
+	core.AssertEqual(t, "wor", string(conn.WriteData), "written data")
+}
+
+// TestMockConn_InjectedErrors tests ReadErr and WriteErr
+func TestMockConn_InjectedErrors(t *testing.T) {
+	errBoom := errors.New("boom")
+	conn := &MockConn{Data: []byte("a"), ReadErr: io.EOF, WriteErr: errBoom}
+
+	buf := make([]byte, 2)
+	n, err := conn.Read(buf)
+	core.AssertNoError(t, err, "data before the error")
+	core.AssertEqual(t, 1, n, "bytes read")
+
+	_, err = conn.Read(buf)
+	core.AssertErrorIs(t, err, io.EOF, "ReadErr")
+
+	_, err = conn.Write([]byte("x"))
+	core.AssertErrorIs(t, err, errBoom, "WriteErr")
+	core.AssertEqual(t, 0, len(conn.WriteData), "nothing written")
+}
@@ -0,0 +1,93 @@
+package testutils
+
+import (
+	"net"
+	"sync"
+)
+
+// MockAccept is a scripted outcome of [MockListener.Accept].
+type MockAccept struct {
+	// Conn and Err are returned by Accept.
+	Conn net.Conn
+	Err  error
+	// Panic, when not nil, makes Accept panic with it instead.
+	Panic any
+}
+
+// MockListener implements net.Listener for testing, returning its
+// scripted outcomes in turn, and then blocking until closed.
+type MockListener struct {
+	done    chan struct{}
+	results []MockAccept
+	local   string
+	calls   int
+	mu      sync.Mutex
+	once    sync.Once
+}
+
+// NewMockListener creates a [MockListener] on the local address,
+// returning the given outcomes.
+func NewMockListener(local string, results ...MockAccept) *MockListener {
+	return &MockListener{
+		done:    make(chan struct{}),
+		results: results,
+		local:   local,
+	}
+}
+
+// Accept implements net.Listener
+func (m *MockListener) Accept() (net.Conn, error) {
+	r, ok := m.next()
+	switch {
+	case !ok:
+		<-m.done
+		return nil, m.errClosed()
+	case r.Panic != nil:
+		panic(r.Panic)
+	default:
+		return r.Conn, r.Err
+	}
+}
+
+func (m *MockListener) next() (MockAccept, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls++
+	select {
+	case <-m.done:
+		return MockAccept{Err: m.errClosed()}, true
+	default:
+	}
+
+	if len(m.results) == 0 {
+		return MockAccept{}, false
+	}
+	r := m.results[0]
+	m.results = m.results[1:]
+	return r, true
+}
+
+// Close implements net.Listener
+func (m *MockListener) Close() error {
+	m.once.Do(func() { close(m.done) })
+	return nil
+}
+
+// Addr implements net.Listener
+func (m *MockListener) Addr() net.Addr {
+	return &MockAddr{Addr: m.local}
+}
+
+// Calls returns the number of times Accept was called.
+func (m *MockListener) Calls() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls
+}
+
+// errClosed is the error Accept returns once closed, the same a
+// net.Listener returns.
+func (m *MockListener) errClosed() error {
+	return &net.OpError{Op: "accept", Net: "tcp", Addr: m.Addr(), Err: net.ErrClosed}
+}
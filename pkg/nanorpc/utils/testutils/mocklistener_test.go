@@ -0,0 +1,56 @@
+package testutils
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"darvaza.org/core"
+)
+
+// TestMockListener_Accept tests the scripted outcomes are returned in order
+func TestMockListener_Accept(t *testing.T) {
+	conn := &MockConn{Remote: "127.0.0.1:12345"}
+	errAccept := errors.New("accept failed")
+	l := NewMockListener("127.0.0.1:8080",
+		MockAccept{Err: errAccept},
+		MockAccept{Conn: conn},
+		MockAccept{Panic: "boom"},
+	)
+
+	_, err := l.Accept()
+	core.AssertErrorIs(t, err, errAccept, "first")
+
+	got, err := l.Accept()
+	core.AssertNoError(t, err, "second")
+	core.AssertSame(t, conn, got, "conn")
+
+	func() {
+		defer func() {
+			core.AssertEqual(t, any("boom"), recover(), "third")
+		}()
+		_, _ = l.Accept()
+	}()
+	core.AssertEqual(t, 3, l.Calls(), "calls")
+}
+
+// TestMockListener_Close tests Accept blocks until the listener is closed
+func TestMockListener_Close(t *testing.T) {
+	l := NewMockListener("127.0.0.1:8080")
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := l.Accept()
+		errCh <- err
+	}()
+
+	core.AssertNoError(t, l.Close(), "Close")
+	core.AssertNoError(t, l.Close(), "Close twice")
+
+	err := <-errCh
+	core.AssertErrorIs(t, err, net.ErrClosed, "blocked Accept")
+
+	_, err = l.Accept()
+	core.AssertErrorIs(t, err, net.ErrClosed, "Accept after Close")
+	core.AssertEqual(t, "127.0.0.1:8080", l.Addr().String(), "Addr")
+}
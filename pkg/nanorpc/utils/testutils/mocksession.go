@@ -0,0 +1,213 @@
+package testutils
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// MockSessionEvent is a request handled, or a response sent, by a
+// [MockSession]. Only one of them is set.
+type MockSessionEvent struct {
+	Request  *nanorpc.NanoRPCRequest
+	Response *nanorpc.NanoRPCResponse
+}
+
+// MockSession implements the Session interface of the server package for
+// handler tests. It records the responses sent, in order along with the
+// requests handled, checks them against expectations, and hands scripted
+// requests to OnRequest when handled.
+type MockSession struct {
+	// SendErr, when set, is returned by SendResponse instead of sending
+	SendErr error
+
+	// OnRequest receives the scripted Requests when Handle is called,
+	// usually passing them to a message handler along with the session
+	OnRequest func(ctx context.Context, req *nanorpc.NanoRPCRequest) error
+
+	// Session identity
+	SessionID string
+	Remote    string
+
+	// Requests are handed to OnRequest, in order, by Handle
+	Requests []*nanorpc.NanoRPCRequest
+
+	responses []*nanorpc.NanoRPCResponse
+	events    []MockSessionEvent
+	expected  []func(*nanorpc.NanoRPCResponse) error
+	failures  []error
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// ID implements Session
+func (m *MockSession) ID() string { return m.SessionID }
+
+// RemoteAddr implements Session
+func (m *MockSession) RemoteAddr() string { return m.Remote }
+
+// Handle hands the scripted Requests to OnRequest, in order, stopping at
+// the first error or when ctx is cancelled.
+func (m *MockSession) Handle(ctx context.Context) error {
+	for _, req := range m.Requests {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		m.record(MockSessionEvent{Request: req})
+		if m.OnRequest == nil {
+			continue
+		}
+		if err := m.OnRequest(ctx, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SendResponse records a response, after filling its RequestId from req
+// if unset and verifying it encodes, and checks it against the next
+// expectation.
+func (m *MockSession) SendResponse(req *nanorpc.NanoRPCRequest, response *nanorpc.NanoRPCResponse) error {
+	if m.SendErr != nil {
+		return m.SendErr
+	}
+
+	if req != nil && response.RequestId == 0 {
+		response.RequestId = req.RequestId
+	}
+
+	if _, err := nanorpc.EncodeResponse(response, nil); err != nil {
+		return err
+	}
+
+	m.record(MockSessionEvent{Response: response})
+	return nil
+}
+
+// record appends an event, and a response to the history, checking it
+// against the next expectation.
+func (m *MockSession) record(ev MockSessionEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.events = append(m.events, ev)
+	if ev.Response == nil {
+		return
+	}
+
+	m.responses = append(m.responses, ev.Response)
+	if len(m.expected) > 0 {
+		check := m.expected[0]
+		m.expected = m.expected[1:]
+		if err := check(ev.Response); err != nil {
+			m.failures = append(m.failures, err)
+		}
+	}
+}
+
+// Close implements Session
+func (m *MockSession) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.closed = true
+	return nil
+}
+
+// IsClosed tells if Close was called.
+func (m *MockSession) IsClosed() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.closed
+}
+
+// Expect adds a check of the next response sent not yet checked.
+// Responses sent when no check is pending aren't checked.
+func (m *MockSession) Expect(check func(*nanorpc.NanoRPCResponse) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.expected = append(m.expected, check)
+}
+
+// ExpectResponse expects the next response sent not yet checked to
+// answer a request with a status.
+func (m *MockSession) ExpectResponse(requestID int32, status nanorpc.NanoRPCResponse_Status) {
+	m.Expect(func(res *nanorpc.NanoRPCResponse) error {
+		if res.RequestId != requestID || res.ResponseStatus != status {
+			return fmt.Errorf("expected response to %d with %s, got %d with %s",
+				requestID, status, res.RequestId, res.ResponseStatus)
+		}
+		return nil
+	})
+}
+
+// AssertExpectations fails the test for every response that didn't
+// meet its expectation, and every expectation no response was sent for.
+func (m *MockSession) AssertExpectations(t core.T) bool {
+	t.Helper()
+
+	m.mu.Lock()
+	failures := m.failures
+	pending := len(m.expected)
+	m.mu.Unlock()
+
+	for _, err := range failures {
+		t.Error(err)
+	}
+	if pending > 0 {
+		t.Errorf("%d expected responses not sent", pending)
+	}
+	return len(failures) == 0 && pending == 0
+}
+
+// GetLastResponse returns the last response sent
+func (m *MockSession) GetLastResponse() *nanorpc.NanoRPCResponse {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.responses) == 0 {
+		return nil
+	}
+	return m.responses[len(m.responses)-1]
+}
+
+// GetAllResponses returns a copy of the responses sent, in order
+func (m *MockSession) GetAllResponses() []*nanorpc.NanoRPCResponse {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.responses == nil {
+		return nil
+	}
+	out := make([]*nanorpc.NanoRPCResponse, len(m.responses))
+	copy(out, m.responses)
+	return out
+}
+
+// ClearResponses clears the response history. Events and expectations
+// are kept.
+func (m *MockSession) ClearResponses() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.responses = nil
+}
+
+// Events returns a copy of the requests handled and the responses sent,
+// in the order they happened
+func (m *MockSession) Events() []MockSessionEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make([]MockSessionEvent, len(m.events))
+	copy(out, m.events)
+	return out
+}
@@ -0,0 +1,98 @@
+package testutils
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// echoRequests answers every request handed to a [MockSession] with OK.
+func echoRequests(m *MockSession) func(context.Context, *nanorpc.NanoRPCRequest) error {
+	return func(_ context.Context, req *nanorpc.NanoRPCRequest) error {
+		return m.SendResponse(req, &nanorpc.NanoRPCResponse{
+			ResponseType:   nanorpc.NanoRPCResponse_TYPE_RESPONSE,
+			ResponseStatus: nanorpc.NanoRPCResponse_STATUS_OK,
+		})
+	}
+}
+
+// TestMockSession_Handle tests scripted requests are handed over in order,
+// and recorded along with their responses
+func TestMockSession_Handle(t *testing.T) {
+	m := &MockSession{SessionID: "s1", Remote: "127.0.0.1:1"}
+	m.Requests = []*nanorpc.NanoRPCRequest{{RequestId: 1}, {RequestId: 2}}
+	m.OnRequest = echoRequests(m)
+	m.ExpectResponse(1, nanorpc.NanoRPCResponse_STATUS_OK)
+	m.ExpectResponse(2, nanorpc.NanoRPCResponse_STATUS_OK)
+
+	core.AssertNoError(t, m.Handle(context.Background()), "Handle")
+	core.AssertTrue(t, m.AssertExpectations(t), "expectations")
+
+	events := m.Events()
+	core.AssertMustEqual(t, 4, len(events), "events")
+	core.AssertEqual(t, int32(1), events[0].Request.GetRequestId(), "first request")
+	core.AssertEqual(t, int32(1), events[1].Response.GetRequestId(), "first response")
+	core.AssertEqual(t, int32(2), events[2].Request.GetRequestId(), "second request")
+	core.AssertEqual(t, int32(2), events[3].Response.GetRequestId(), "second response")
+
+	core.AssertEqual(t, int32(2), m.GetLastResponse().RequestId, "last response")
+	m.ClearResponses()
+	core.AssertNil(t, m.GetLastResponse(), "cleared")
+	core.AssertEqual(t, 4, len(m.Events()), "events kept")
+}
+
+// TestMockSession_Handle_stops tests Handle stops at the first error, and
+// on cancellation
+func TestMockSession_Handle_stops(t *testing.T) {
+	errStop := errors.New("stop")
+	calls := 0
+	m := &MockSession{
+		Requests: []*nanorpc.NanoRPCRequest{{RequestId: 1}, {RequestId: 2}},
+		OnRequest: func(context.Context, *nanorpc.NanoRPCRequest) error {
+			calls++
+			return errStop
+		},
+	}
+	core.AssertErrorIs(t, m.Handle(context.Background()), errStop, "error")
+	core.AssertEqual(t, 1, calls, "calls")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	core.AssertErrorIs(t, m.Handle(ctx), context.Canceled, "cancelled")
+	core.AssertEqual(t, 1, calls, "calls after cancel")
+}
+
+// TestMockSession_AssertExpectations tests unmet and missing expectations
+// fail the test
+func TestMockSession_AssertExpectations(t *testing.T) {
+	m := &MockSession{}
+	m.ExpectResponse(1, nanorpc.NanoRPCResponse_STATUS_OK)
+	m.ExpectResponse(2, nanorpc.NanoRPCResponse_STATUS_OK)
+
+	req := &nanorpc.NanoRPCRequest{RequestId: 1}
+	res := &nanorpc.NanoRPCResponse{ResponseStatus: nanorpc.NanoRPCResponse_STATUS_NOT_FOUND}
+	core.AssertNoError(t, m.SendResponse(req, res), "SendResponse")
+	core.AssertEqual(t, int32(1), res.RequestId, "request_id filled")
+
+	mock := &core.MockT{}
+	core.AssertFalse(t, m.AssertExpectations(mock), "result")
+	core.AssertTrue(t, mock.HasErrors(), "errors")
+}
+
+// TestMockSession_SendErr tests SendErr fails responses without
+// recording them
+func TestMockSession_SendErr(t *testing.T) {
+	errSend := errors.New("send")
+	m := &MockSession{SendErr: errSend}
+
+	err := m.SendResponse(nil, &nanorpc.NanoRPCResponse{})
+	core.AssertErrorIs(t, err, errSend, "SendResponse")
+	core.AssertEqual(t, 0, len(m.GetAllResponses()), "responses")
+
+	core.AssertNoError(t, m.Close(), "Close")
+	core.AssertTrue(t, m.IsClosed(), "closed")
+}
@@ -0,0 +1,164 @@
+package testutils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"darvaza.org/core"
+)
+
+// TestPKI is a throwaway certificate authority with a server certificate
+// valid for localhost and a client certificate, for TLS and mTLS tests.
+type TestPKI struct {
+	// CertPool holds the CA certificate.
+	CertPool *x509.CertPool
+	// Server is valid for "localhost", 127.0.0.1 and ::1.
+	Server tls.Certificate
+	// Client is valid for client authentication.
+	Client tls.Certificate
+
+	caPEM     []byte
+	serverPEM [2][]byte
+	clientPEM [2][]byte
+}
+
+// TestPKIFiles names the PEM files written by [TestPKI.WriteFiles].
+type TestPKIFiles struct {
+	CA         string
+	ServerCert string
+	ServerKey  string
+	ClientCert string
+	ClientKey  string
+}
+
+// NewTestPKI generates a new [TestPKI], failing the test on error.
+func NewTestPKI(t core.T) *TestPKI {
+	t.Helper()
+
+	ca, err := newTestCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &TestPKI{CertPool: x509.NewCertPool(), caPEM: ca.pem}
+	p.CertPool.AddCert(ca.cert)
+
+	p.Server, p.serverPEM, err = ca.newLeaf(x509.ExtKeyUsageServerAuth)
+	if err == nil {
+		p.Client, p.clientPEM, err = ca.newLeaf(x509.ExtKeyUsageClientAuth)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// WriteFiles stores the CA and the server and client key pairs as PEM
+// files in dir.
+func (p *TestPKI) WriteFiles(dir string) (TestPKIFiles, error) {
+	files := TestPKIFiles{
+		CA:         filepath.Join(dir, "ca.pem"),
+		ServerCert: filepath.Join(dir, "server.pem"),
+		ServerKey:  filepath.Join(dir, "server-key.pem"),
+		ClientCert: filepath.Join(dir, "client.pem"),
+		ClientKey:  filepath.Join(dir, "client-key.pem"),
+	}
+
+	for name, data := range map[string][]byte{
+		files.CA:         p.caPEM,
+		files.ServerCert: p.serverPEM[0],
+		files.ServerKey:  p.serverPEM[1],
+		files.ClientCert: p.clientPEM[0],
+		files.ClientKey:  p.clientPEM[1],
+	} {
+		if err := os.WriteFile(name, data, 0o600); err != nil {
+			return TestPKIFiles{}, err
+		}
+	}
+	return files, nil
+}
+
+// testCA is the signing side of a [TestPKI].
+type testCA struct {
+	key  *ecdsa.PrivateKey
+	cert *x509.Certificate
+	pem  []byte
+}
+
+func newTestCA() (*testCA, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	tpl := newTestCertTemplate("nanorpc test CA")
+	tpl.IsCA = true
+	tpl.BasicConstraintsValid = true
+	tpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	return &testCA{
+		key:  key,
+		cert: cert,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}, nil
+}
+
+// newLeaf issues a certificate for localhost with the given usage,
+// returning it together with its PEM encoded certificate and key.
+func (ca *testCA) newLeaf(usage x509.ExtKeyUsage) (tls.Certificate, [2][]byte, error) {
+	var pems [2][]byte
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, pems, err
+	}
+
+	tpl := newTestCertTemplate("localhost")
+	tpl.DNSNames = []string{"localhost"}
+	tpl.IPAddresses = []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback}
+	tpl.KeyUsage = x509.KeyUsageDigitalSignature
+	tpl.ExtKeyUsage = []x509.ExtKeyUsage{usage}
+
+	der, err := x509.CreateCertificate(rand.Reader, tpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		return tls.Certificate{}, pems, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return tls.Certificate{}, pems, err
+	}
+
+	pems[0] = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	pems[1] = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	cert, err := tls.X509KeyPair(pems[0], pems[1])
+	return cert, pems, err
+}
+
+func newTestCertTemplate(cn string) *x509.Certificate {
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+}
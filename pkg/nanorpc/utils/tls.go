@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"crypto/x509"
+	"os"
+
+	"darvaza.org/core"
+)
+
+// LoadCertPool reads PEM encoded certificates from the named files into a
+// new pool, for verifying peers against private certificate authorities.
+// A file without certificates is an error.
+func LoadCertPool(names ...string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	for _, name := range names {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, core.QuietWrap(core.ErrInvalid, "%s: no PEM certificates", name)
+		}
+	}
+	return pool, nil
+}
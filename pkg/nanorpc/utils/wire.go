@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"encoding/hex"
+	"slices"
+	"strings"
+	"time"
+
+	"darvaza.org/slog"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// Directions of the messages logged by a [WireLog], for the
+// FieldDirection field.
+const (
+	DirectionSent     = "sent"
+	DirectionReceived = "received"
+)
+
+// Redacted replaces the values of redacted metadata in wire logs.
+const Redacted = "[redacted]"
+
+// WireLog logs, at debug level, the decoded messages a client or server
+// sends and receives. Their type, request ID, path, status, data size
+// and, for responses to requests, the time since the request are always
+// logged, and so is the correlation ID, see
+// [nanorpc.MetadataCorrelationID], when there is one. Metadata is logged
+// with the values of the RedactMetadata keys replaced by [Redacted], and
+// data is only hex-dumped when PayloadBytes is positive.
+type WireLog struct {
+	// RedactMetadata lists the metadata keys whose values aren't logged,
+	// like those carrying credentials. Keys are case-insensitive.
+	RedactMetadata []string
+
+	// PayloadBytes, when positive, hex-dumps up to that many bytes of
+	// the data of every message.
+	PayloadBytes int
+}
+
+// LogRequest logs a request sent or received, carrying data, its Data
+// unless given already encoded apart.
+func (wl *WireLog) LogRequest(logger slog.Logger, direction string, req *nanorpc.NanoRPCRequest, data []byte) {
+	if wl == nil || logger == nil {
+		return
+	}
+
+	if l, ok := logger.Debug().WithEnabled(); ok {
+		fields := slog.Fields{
+			FieldDirection:   direction,
+			FieldRequestID:   req.GetRequestId(),
+			FieldRequestType: req.GetRequestType().String(),
+		}
+		addPathFields(fields, req)
+		addCorrelationField(fields, req.GetMetadata())
+		wl.addDataFields(fields, req.GetMetadata(), data)
+		l.WithFields(fields).Print("wire request")
+	}
+}
+
+// LogResponse logs a response sent or received, answering req if known,
+// elapsed after it if positive.
+func (wl *WireLog) LogResponse(logger slog.Logger, direction string, req *nanorpc.NanoRPCRequest,
+	res *nanorpc.NanoRPCResponse, elapsed time.Duration) {
+	if wl == nil || logger == nil {
+		return
+	}
+
+	if l, ok := logger.Debug().WithEnabled(); ok {
+		fields := slog.Fields{
+			FieldDirection:      direction,
+			FieldRequestID:      res.GetRequestId(),
+			FieldResponseType:   res.GetResponseType().String(),
+			FieldResponseStatus: res.GetResponseStatus().String(),
+		}
+		addPathFields(fields, req)
+		addCorrelationField(fields, res.GetMetadata(), req.GetMetadata())
+		if elapsed > 0 {
+			fields[FieldDuration] = float64(elapsed.Microseconds()) / 1000
+		}
+		wl.addDataFields(fields, res.GetMetadata(), res.GetData())
+		l.WithFields(fields).Print("wire response")
+	}
+}
+
+func addPathFields(fields slog.Fields, req *nanorpc.NanoRPCRequest) {
+	if path := req.GetPath(); path != "" {
+		fields[FieldPath] = path
+	} else if hash := req.GetPathHash(); hash != 0 {
+		fields[FieldPathHash] = hash
+	}
+}
+
+// addCorrelationField adds the first correlation ID carried by the given
+// metadata.
+func addCorrelationField(fields slog.Fields, mds ...nanorpc.Metadata) {
+	for _, md := range mds {
+		if id := md.CorrelationID(); id != "" {
+			fields[FieldCorrelationID] = id
+			return
+		}
+	}
+}
+
+func (wl *WireLog) addDataFields(fields slog.Fields, md []*nanorpc.NanoRPCMetadata, data []byte) {
+	fields[FieldDataBytes] = len(data)
+	if len(md) > 0 {
+		fields[FieldMetadata] = wl.formatMetadata(md)
+	}
+	if wl.PayloadBytes > 0 && len(data) > 0 {
+		fields[FieldPayload] = HexDump(data, wl.PayloadBytes)
+	}
+}
+
+// formatMetadata returns metadata as comma-separated key=value pairs,
+// redacted.
+func (wl *WireLog) formatMetadata(md []*nanorpc.NanoRPCMetadata) string {
+	var buf strings.Builder
+	for i, kv := range md {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+
+		value := kv.GetValue()
+		if wl.redacts(kv.GetKey()) {
+			value = Redacted
+		}
+		buf.WriteString(kv.GetKey())
+		buf.WriteByte('=')
+		buf.WriteString(value)
+	}
+	return buf.String()
+}
+
+func (wl *WireLog) redacts(key string) bool {
+	return slices.ContainsFunc(wl.RedactMetadata, func(s string) bool {
+		return strings.EqualFold(s, key)
+	})
+}
+
+// HexDump returns a hex dump of data up to maxBytes, space-delimited,
+// ending with " ..." when cut short.
+func HexDump(data []byte, maxBytes int) string {
+	preview := data
+	if len(preview) > maxBytes {
+		preview = preview[:maxBytes]
+	}
+
+	hexStr := strings.ToUpper(hex.EncodeToString(preview))
+
+	// Add spaces between bytes
+	var spaced strings.Builder
+	for i := 0; i < len(hexStr); i += 2 {
+		if i > 0 {
+			_ = spaced.WriteByte(' ')
+		}
+		_, _ = spaced.WriteString(hexStr[i : i+2])
+	}
+
+	if len(data) > maxBytes {
+		_, _ = spaced.WriteString(" ...")
+	}
+
+	return spaced.String()
+}
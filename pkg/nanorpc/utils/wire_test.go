@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+	"darvaza.org/slog"
+	"darvaza.org/slog/handlers/mock"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// lastMessage returns the only message a logger recorded.
+func lastMessage(t *testing.T, logger *mock.Logger) mock.Message {
+	t.Helper()
+
+	msgs := logger.GetMessages()
+	core.AssertMustEqual(t, 1, len(msgs), "messages")
+	return msgs[0]
+}
+
+func TestWireLog_LogRequest(t *testing.T) {
+	logger := mock.NewLogger()
+	wl := &WireLog{RedactMetadata: core.S("Authorization"), PayloadBytes: 2}
+	req := &nanorpc.NanoRPCRequest{
+		RequestId:   7,
+		RequestType: nanorpc.NanoRPCRequest_TYPE_REQUEST,
+		PathOneof:   nanorpc.GetPathOneOfString("/devices/42"),
+		Metadata:    nanorpc.NewMetadata("authorization", "secret", "trace", "abc"),
+	}
+	wl.LogRequest(logger, DirectionSent, req, []byte{1, 2, 3})
+
+	msg := lastMessage(t, logger)
+	core.AssertEqual(t, slog.Debug, msg.Level, "level")
+	core.AssertEqual(t, "wire request", msg.Message, "message")
+	core.AssertEqual[any](t, DirectionSent, msg.Fields[FieldDirection], "direction")
+	core.AssertEqual[any](t, int32(7), msg.Fields[FieldRequestID], "request ID")
+	core.AssertEqual[any](t, "TYPE_REQUEST", msg.Fields[FieldRequestType], "type")
+	core.AssertEqual[any](t, "/devices/42", msg.Fields[FieldPath], "path")
+	core.AssertEqual[any](t, 3, msg.Fields[FieldDataBytes], "size")
+	core.AssertEqual[any](t, "authorization=[redacted], trace=abc", msg.Fields[FieldMetadata], "metadata")
+	core.AssertEqual[any](t, "01 02 ...", msg.Fields[FieldPayload], "payload")
+}
+
+func TestWireLog_LogResponse(t *testing.T) {
+	logger := mock.NewLogger()
+	wl := &WireLog{}
+	req := &nanorpc.NanoRPCRequest{
+		PathOneof: nanorpc.GetPathOneOfHash(0x1234),
+		Metadata:  nanorpc.NewMetadata(nanorpc.MetadataCorrelationID, "abc"),
+	}
+	res := &nanorpc.NanoRPCResponse{
+		RequestId:      7,
+		ResponseType:   nanorpc.NanoRPCResponse_TYPE_RESPONSE,
+		ResponseStatus: nanorpc.NanoRPCResponse_STATUS_OK,
+		Data:           []byte("ok"),
+	}
+	wl.LogResponse(logger, DirectionReceived, req, res, 1500*time.Microsecond)
+
+	msg := lastMessage(t, logger)
+	core.AssertEqual(t, "wire response", msg.Message, "message")
+	core.AssertEqual[any](t, "STATUS_OK", msg.Fields[FieldResponseStatus], "status")
+	core.AssertEqual[any](t, uint32(0x1234), msg.Fields[FieldPathHash], "path hash")
+	core.AssertEqual[any](t, 1.5, msg.Fields[FieldDuration], "duration")
+	core.AssertEqual[any](t, 2, msg.Fields[FieldDataBytes], "size")
+	core.AssertEqual[any](t, "abc", msg.Fields[FieldCorrelationID], "correlation ID of the request")
+
+	_, ok := msg.Fields[FieldPayload]
+	core.AssertFalse(t, ok, "payload not dumped")
+	_, ok = msg.Fields[FieldMetadata]
+	core.AssertFalse(t, ok, "no metadata")
+}
+
+func TestWireLog_disabled(t *testing.T) {
+	var wl *WireLog
+	logger := mock.NewLogger()
+	wl.LogRequest(logger, DirectionSent, &nanorpc.NanoRPCRequest{}, nil)
+	core.AssertEqual(t, 0, len(logger.GetMessages()), "nil WireLog")
+
+	wl = &WireLog{}
+	logger = mock.NewLoggerWithThreshold(slog.Info)
+	wl.LogResponse(logger, DirectionSent, nil, &nanorpc.NanoRPCResponse{}, 0)
+	core.AssertEqual(t, 0, len(logger.GetMessages()), "debug disabled")
+}
+
+var _ core.TestCase = hexDumpTestCase{}
+
+type hexDumpTestCase struct {
+	name string
+	want string
+	data []byte
+	max  int
+}
+
+func (tc hexDumpTestCase) Name() string { return tc.name }
+
+func (tc hexDumpTestCase) Test(t *testing.T) {
+	t.Helper()
+	core.AssertEqual(t, tc.want, HexDump(tc.data, tc.max), "dump")
+}
+
+func newHexDumpTestCase(name string, data []byte, maxBytes int, want string) hexDumpTestCase {
+	return hexDumpTestCase{name: name, data: data, max: maxBytes, want: want}
+}
+
+func hexDumpTestCases() []hexDumpTestCase {
+	return core.S(
+		newHexDumpTestCase("empty", nil, 4, ""),
+		newHexDumpTestCase("short", []byte{0xab, 0x01}, 4, "AB 01"),
+		newHexDumpTestCase("exact", []byte{1, 2}, 2, "01 02"),
+		newHexDumpTestCase("cut", []byte{1, 2, 3}, 2, "01 02 ..."),
+	)
+}
+
+func TestHexDump(t *testing.T) {
+	core.RunTestCases(t, hexDumpTestCases())
+}
@@ -0,0 +1,148 @@
+package vectors
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strings"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// matrixIDs are the request_ids of the matrix, one for every length of
+// their varint encoding that fits them.
+var matrixIDs = []int32{1, 300, math.MaxInt32}
+
+// matrixData are the payloads of the matrix.
+var matrixData = []struct {
+	name string
+	data []byte
+}{
+	{"empty", nil},
+	{"payload", payload},
+	{"large", largeData},
+}
+
+// matrixStatuses are the error statuses of the matrix.
+var matrixStatuses = []nanorpc.NanoRPCResponse_Status{
+	nanorpc.NanoRPCResponse_STATUS_NOT_FOUND,
+	nanorpc.NanoRPCResponse_STATUS_NOT_AUTHORIZED,
+	nanorpc.NanoRPCResponse_STATUS_INTERNAL_ERROR,
+}
+
+// requestShapes are the kinds of requests of the matrix.
+var requestShapes = []struct {
+	new  func(id int32, data []byte) *nanorpc.NanoRPCRequest
+	name string
+}{
+	{newHelloRequest, "ping"},
+	{func(id int32, data []byte) *nanorpc.NanoRPCRequest {
+		return newPathRequest(id, nanorpc.NanoRPCRequest_TYPE_REQUEST, EchoPath, data)
+	}, "request/path"},
+	{func(id int32, data []byte) *nanorpc.NanoRPCRequest {
+		return newHashRequest(id, nanorpc.NanoRPCRequest_TYPE_REQUEST, EchoPathHash, data)
+	}, "request/path_hash"},
+	{func(id int32, data []byte) *nanorpc.NanoRPCRequest {
+		return newPathRequest(id, nanorpc.NanoRPCRequest_TYPE_SUBSCRIBE, EventsPath, data)
+	}, "subscribe/path"},
+	{func(id int32, data []byte) *nanorpc.NanoRPCRequest {
+		return newHashRequest(id, nanorpc.NanoRPCRequest_TYPE_SUBSCRIBE, EchoPathHash, data)
+	}, "subscribe/path_hash"},
+}
+
+// responseShapes are the kinds of successful responses of the matrix.
+var responseShapes = []struct {
+	new  func(id int32, data []byte) *nanorpc.NanoRPCResponse
+	name string
+}{
+	{func(id int32, data []byte) *nanorpc.NanoRPCResponse {
+		return newDataResponse(id, nanorpc.NanoRPCResponse_TYPE_PONG, data)
+	}, "pong"},
+	{func(id int32, data []byte) *nanorpc.NanoRPCResponse {
+		return newDataResponse(id, nanorpc.NanoRPCResponse_TYPE_RESPONSE, data)
+	}, "response"},
+	{func(id int32, data []byte) *nanorpc.NanoRPCResponse {
+		return newUpdate(id, 1, false, data)
+	}, "update"},
+}
+
+// RequestMatrix returns canonical request vectors for every combination
+// of request type, path variant, payload size and request_id length,
+// encoded by [nanorpc.EncodeRequest]. Their names start with "matrix/".
+// The messages are new on every call and can be modified freely.
+func RequestMatrix() ([]Request, error) {
+	var out []Request
+	for _, shape := range requestShapes {
+		for _, d := range matrixData {
+			for _, id := range matrixIDs {
+				name := fmt.Sprintf("matrix/%s/%s/id_%d", shape.name, d.name, id)
+				out = append(out, Request{Name: name, Message: shape.new(id, d.data)})
+			}
+		}
+	}
+
+	for i := range out {
+		b, err := nanorpc.EncodeRequest(out[i].Message, nil)
+		if err != nil {
+			return nil, err
+		}
+		out[i].Hex = hex.EncodeToString(b)
+	}
+	return out, nil
+}
+
+// ResponseMatrix returns canonical response vectors for every combination
+// of response type, payload size and request_id length, and of error
+// status and request_id length for responses and final updates, encoded
+// by [nanorpc.EncodeResponse]. Their names start with "matrix/". The
+// messages are new on every call and can be modified freely.
+func ResponseMatrix() ([]Response, error) {
+	var out []Response
+	for _, shape := range responseShapes {
+		for _, d := range matrixData {
+			for _, id := range matrixIDs {
+				name := fmt.Sprintf("matrix/%s/%s/id_%d", shape.name, d.name, id)
+				out = append(out, Response{Name: name, Message: shape.new(id, d.data)})
+			}
+		}
+	}
+	out = append(out, errorMatrix()...)
+
+	for i := range out {
+		b, err := nanorpc.EncodeResponse(out[i].Message, nil)
+		if err != nil {
+			return nil, err
+		}
+		out[i].Hex = hex.EncodeToString(b)
+	}
+	return out, nil
+}
+
+// errorMatrix returns the unencoded error responses and final updates of
+// the matrix.
+func errorMatrix() []Response {
+	var out []Response
+	for _, rt := range []nanorpc.NanoRPCResponse_Type{
+		nanorpc.NanoRPCResponse_TYPE_RESPONSE,
+		nanorpc.NanoRPCResponse_TYPE_UPDATE,
+	} {
+		for _, st := range matrixStatuses {
+			for _, id := range matrixIDs {
+				out = append(out, newErrorVector(id, rt, st))
+			}
+		}
+	}
+	return out
+}
+
+func newErrorVector(id int32, rt nanorpc.NanoRPCResponse_Type, st nanorpc.NanoRPCResponse_Status) Response {
+	typ := strings.ToLower(strings.TrimPrefix(rt.String(), "TYPE_"))
+	status := strings.ToLower(strings.TrimPrefix(st.String(), "STATUS_"))
+
+	res := newResponse(id, rt, st)
+	res.ResponseMessage = strings.ReplaceAll(status, "_", " ")
+	return Response{
+		Name:    fmt.Sprintf("matrix/%s/%s/id_%d", typ, status, id),
+		Message: res,
+	}
+}
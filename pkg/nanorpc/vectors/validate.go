@@ -0,0 +1,103 @@
+package vectors
+
+import (
+	"encoding/hex"
+
+	"google.golang.org/protobuf/proto"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// RequestDecoder decodes a wrapped request frame, like
+// [nanorpc.DecodeRequest], for [ValidateRequestDecoder].
+type RequestDecoder func(frame []byte) (*nanorpc.NanoRPCRequest, error)
+
+// ResponseDecoder decodes a wrapped response frame, like
+// [nanorpc.DecodeResponse], for [ValidateResponseDecoder].
+type ResponseDecoder func(frame []byte) (*nanorpc.NanoRPCResponse, error)
+
+// ValidateRequestDecoder checks a decoder against the request vectors. It
+// must decode every one of [Requests] and [RequestMatrix] to its message,
+// unknown fields aside, and reject every one of [MalformedFrames].
+// Failures are reported to t, and it returns true if there were none.
+func ValidateRequestDecoder(t core.T, decode RequestDecoder) bool {
+	t.Helper()
+
+	matrix, err := RequestMatrix()
+	if !core.AssertNoError(t, err, "RequestMatrix") {
+		return false
+	}
+
+	ok := true
+	for _, v := range append(Requests(), matrix...) {
+		ok = validateDecode(t, v.Name, v.Hex, v.Message, decode) && ok
+	}
+	return validateMalformed(t, decode) && ok
+}
+
+// ValidateResponseDecoder checks a decoder against the response vectors.
+// It must decode every one of [Responses] and [ResponseMatrix] to its
+// message, unknown fields aside, and reject every one of
+// [MalformedFrames]. Failures are reported to t, and it returns true if
+// there were none.
+func ValidateResponseDecoder(t core.T, decode ResponseDecoder) bool {
+	t.Helper()
+
+	matrix, err := ResponseMatrix()
+	if !core.AssertNoError(t, err, "ResponseMatrix") {
+		return false
+	}
+
+	ok := true
+	for _, v := range append(Responses(), matrix...) {
+		ok = validateDecode(t, v.Name, v.Hex, v.Message, decode) && ok
+	}
+	return validateMalformed(t, decode) && ok
+}
+
+func validateDecode[M proto.Message](t core.T, name, frameHex string, want M,
+	decode func([]byte) (M, error)) bool {
+	t.Helper()
+
+	frame, err := hex.DecodeString(frameHex)
+	if !core.AssertNoError(t, err, "%s: frame", name) {
+		return false
+	}
+
+	got, err := decode(frame)
+	switch {
+	case !core.AssertNoError(t, err, "%s: decode", name):
+		return false
+	case !core.AssertTrue(t, got.ProtoReflect().IsValid(), "%s: decoded nil", name):
+		return false
+	default:
+		return core.AssertTrue(t, equalKnown(want, got), "%s: decoded %v", name, got)
+	}
+}
+
+func validateMalformed[M proto.Message](t core.T, decode func([]byte) (M, error)) bool {
+	t.Helper()
+
+	ok := true
+	for _, v := range MalformedFrames() {
+		frame, err := v.Frame()
+		if !core.AssertNoError(t, err, "%s: frame", v.Name) {
+			ok = false
+			continue
+		}
+
+		_, err = decode(frame)
+		ok = core.AssertError(t, err, "%s: decode", v.Name) && ok
+	}
+	return ok
+}
+
+// equalKnown compares the known fields of two messages, as decoders skip
+// unknown ones.
+func equalKnown(want, got proto.Message) bool {
+	got = proto.Clone(got)
+	got.ProtoReflect().SetUnknown(nil)
+	return proto.Equal(want, got)
+}
@@ -0,0 +1,426 @@
+// Package vectors holds reference encodings of NanoRPC frames, the
+// authoritative compatibility fixture for implementations of the protocol
+// in other languages, such as the nanopb based C firmware.
+//
+// Every frame is a complete wrapped message, varint length prefix
+// included, written in lowercase hex. Canonical frames are exactly what the
+// Go encoders produce for their message. Frames marked DecodeOnly are
+// valid alternative encodings, e.g. with unknown or reordered fields, that
+// every decoder must accept. [Malformed] frames must be rejected by every
+// decoder.
+//
+// Protocol Buffers leave the order of fields to the encoder. The Go
+// encoders write the path oneof after the other fields, while nanopb writes
+// every field in field number order, so requests carrying data or
+// resume_after have a "field_order/" DecodeOnly vector with the nanopb
+// encoding.
+//
+// [RequestMatrix] and [ResponseMatrix] extend them with the canonical
+// encodings of every combination of message type, path variant, payload
+// size and request_id length. [ValidateRequestDecoder] and
+// [ValidateResponseDecoder] check decoders against all of them, and the
+// nanorpc-vectors command writes them out as JSON or a C header for
+// implementations in other languages.
+package vectors
+
+import (
+	"encoding/hex"
+	"math"
+	"slices"
+	"strings"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// Request is a reference encoding of a [nanorpc.NanoRPCRequest].
+type Request struct {
+	Message    *nanorpc.NanoRPCRequest
+	Name       string
+	Hex        string
+	DecodeOnly bool
+}
+
+// Frame returns the bytes of the wrapped frame.
+func (v Request) Frame() ([]byte, error) {
+	return hex.DecodeString(v.Hex)
+}
+
+// Response is a reference encoding of a [nanorpc.NanoRPCResponse].
+type Response struct {
+	Message    *nanorpc.NanoRPCResponse
+	Name       string
+	Hex        string
+	DecodeOnly bool
+}
+
+// Frame returns the bytes of the wrapped frame.
+func (v Response) Frame() ([]byte, error) {
+	return hex.DecodeString(v.Hex)
+}
+
+// Malformed is a frame decoders must reject, as a request and as a
+// response.
+type Malformed struct {
+	Name string
+	Hex  string
+}
+
+// Frame returns the bytes of the frame.
+func (v Malformed) Frame() ([]byte, error) {
+	return hex.DecodeString(v.Hex)
+}
+
+// Path and payloads shared by the vectors.
+const (
+	// EchoPath is the path most vectors address.
+	EchoPath = "/echo"
+	// EchoPathHash is the FNV-1a hash of [EchoPath].
+	EchoPathHash uint32 = 0xc5f7ed3f
+	// EventsPath is the path the subscription vectors address.
+	EventsPath = "/events"
+)
+
+var (
+	// payload is a small message with a string field 1, "hi".
+	payload = []byte{0x0a, 0x02, 'h', 'i'}
+	// filter is a small message with a varint field 1 set to 1.
+	filter = []byte{0x08, 0x01}
+	// clientHello is the handshake of a version 1 peer supporting
+	// streaming.
+	clientHello = []byte{0x08, 0x01, 0x10, 0x01}
+	// serverHello is the handshake of a version 1 peer supporting
+	// streaming and authentication.
+	serverHello = []byte{0x08, 0x01, 0x10, 0x05}
+)
+
+// maxSizePath is a path as long as the nanopb max_size of the path field.
+var maxSizePath = "/" + strings.Repeat("a", 49)
+
+// largeData is a payload pushing the frame past a one-byte length prefix.
+var largeData = []byte(strings.Repeat("x", 200))
+
+// Requests returns the request vectors. The messages are new on every call
+// and can be modified freely.
+func Requests() []Request {
+	return append(canonicalRequests(), decodeOnlyRequests()...)
+}
+
+func canonicalRequests() []Request {
+	return []Request{
+		{
+			Name:    "ping",
+			Message: newRequest(1, nanorpc.NanoRPCRequest_TYPE_PING),
+			Hex:     "0408011001",
+		},
+		{
+			Name:    "request/path",
+			Message: newPathRequest(2, nanorpc.NanoRPCRequest_TYPE_REQUEST, EchoPath, nil),
+			Hex:     "0b0802100222052f6563686f",
+		},
+		{
+			Name:    "request/path_hash",
+			Message: newHashRequest(3, nanorpc.NanoRPCRequest_TYPE_REQUEST, EchoPathHash, nil),
+			Hex:     "0a0803100218bfdadfaf0c",
+		},
+		{
+			Name:    "request/data",
+			Message: newPathRequest(4, nanorpc.NanoRPCRequest_TYPE_REQUEST, EchoPath, payload),
+			Hex:     "110804100252040a02686922052f6563686f",
+		},
+		{
+			Name:    "subscribe/path",
+			Message: newPathRequest(5, nanorpc.NanoRPCRequest_TYPE_SUBSCRIBE, EventsPath, nil),
+			Hex:     "0d0805100322072f6576656e7473",
+		},
+		{
+			Name:    "subscribe/filter",
+			Message: newHashRequest(6, nanorpc.NanoRPCRequest_TYPE_SUBSCRIBE, EchoPathHash, filter),
+			Hex:     "0e080610035202080118bfdadfaf0c",
+		},
+		{
+			Name:    "subscribe/resume_after",
+			Message: newResumeRequest(7, EventsPath, 300),
+			Hex:     "100807100328ac0222072f6576656e7473",
+		},
+		{
+			// same request_id as subscribe/path, no data
+			Name:    "unsubscribe",
+			Message: newPathRequest(5, nanorpc.NanoRPCRequest_TYPE_REQUEST, EventsPath, nil),
+			Hex:     "0d0805100222072f6576656e7473",
+		},
+		{
+			Name:    "ping/handshake",
+			Message: newHelloRequest(1, clientHello),
+			Hex:     "0a08011001520408011001",
+		},
+		{
+			Name:    "edge/request_id_zero",
+			Message: newPathRequest(0, nanorpc.NanoRPCRequest_TYPE_REQUEST, "/", nil),
+			Hex:     "05100222012f",
+		},
+		{
+			Name:    "edge/request_id_max",
+			Message: newRequest(math.MaxInt32, nanorpc.NanoRPCRequest_TYPE_PING),
+			Hex:     "0808ffffffff071001",
+		},
+		{
+			// a oneof member is encoded even when zero
+			Name:    "edge/path_hash_zero",
+			Message: newHashRequest(8, nanorpc.NanoRPCRequest_TYPE_REQUEST, 0, nil),
+			Hex:     "06080810021800",
+		},
+		{
+			Name:    "edge/path_empty",
+			Message: newPathRequest(9, nanorpc.NanoRPCRequest_TYPE_REQUEST, "", nil),
+			Hex:     "06080910022200",
+		},
+		{
+			Name:    "edge/path_max_size",
+			Message: newPathRequest(10, nanorpc.NanoRPCRequest_TYPE_REQUEST, maxSizePath, nil),
+			Hex:     "38080a100222322f" + strings.Repeat("61", 49),
+		},
+		{
+			Name:    "edge/two_byte_length",
+			Message: newPathRequest(11, nanorpc.NanoRPCRequest_TYPE_REQUEST, EchoPath, largeData),
+			Hex:     "d601080b100252c801" + strings.Repeat("78", 200) + "22052f6563686f",
+		},
+	}
+}
+
+func decodeOnlyRequests() []Request {
+	return []Request{
+		{
+			// field 15, varint 1, is skipped
+			Name:       "decode_only/unknown_field",
+			Message:    newRequest(1, nanorpc.NanoRPCRequest_TYPE_PING),
+			Hex:        "06080110017801",
+			DecodeOnly: true,
+		},
+		{
+			Name:       "decode_only/reordered",
+			Message:    newPathRequest(2, nanorpc.NanoRPCRequest_TYPE_REQUEST, EchoPath, nil),
+			Hex:        "0b22052f6563686f10020802",
+			DecodeOnly: true,
+		},
+		{
+			// request_id explicitly encoded as zero
+			Name:       "decode_only/explicit_zero",
+			Message:    newRequest(0, nanorpc.NanoRPCRequest_TYPE_PING),
+			Hex:        "0408001001",
+			DecodeOnly: true,
+		},
+		{
+			// the last request_id wins
+			Name:       "decode_only/repeated_field",
+			Message:    newRequest(2, nanorpc.NanoRPCRequest_TYPE_PING),
+			Hex:        "06080108021001",
+			DecodeOnly: true,
+		},
+		{
+			// the last member of the oneof wins
+			Name:       "decode_only/oneof_last_wins",
+			Message:    newPathRequest(3, nanorpc.NanoRPCRequest_TYPE_REQUEST, EchoPath, nil),
+			Hex:        "110803100218bfdadfaf0c22052f6563686f",
+			DecodeOnly: true,
+		},
+		{
+			// request/data in field number order, as nanopb writes it
+			Name:       "field_order/request/data",
+			Message:    newPathRequest(4, nanorpc.NanoRPCRequest_TYPE_REQUEST, EchoPath, payload),
+			Hex:        "110804100222052f6563686f52040a026869",
+			DecodeOnly: true,
+		},
+		{
+			// subscribe/filter in field number order, as nanopb writes it
+			Name:       "field_order/subscribe/filter",
+			Message:    newHashRequest(6, nanorpc.NanoRPCRequest_TYPE_SUBSCRIBE, EchoPathHash, filter),
+			Hex:        "0e0806100318bfdadfaf0c52020801",
+			DecodeOnly: true,
+		},
+		{
+			// subscribe/resume_after in field number order, as nanopb
+			// writes it
+			Name:       "field_order/subscribe/resume_after",
+			Message:    newResumeRequest(7, EventsPath, 300),
+			Hex:        "100807100322072f6576656e747328ac02",
+			DecodeOnly: true,
+		},
+	}
+}
+
+// Responses returns the response vectors. The messages are new on every
+// call and can be modified freely.
+func Responses() []Response {
+	return append(canonicalResponses(), decodeOnlyResponses()...)
+}
+
+func canonicalResponses() []Response {
+	return []Response{
+		{
+			Name:    "pong",
+			Message: newResponse(1, nanorpc.NanoRPCResponse_TYPE_PONG, nanorpc.NanoRPCResponse_STATUS_OK),
+			Hex:     "06080110011801",
+		},
+		{
+			Name:    "pong/handshake",
+			Message: newDataResponse(1, nanorpc.NanoRPCResponse_TYPE_PONG, serverHello),
+			Hex:     "0c080110011801520408011005",
+		},
+		{
+			Name:    "response/empty",
+			Message: newResponse(2, nanorpc.NanoRPCResponse_TYPE_RESPONSE, nanorpc.NanoRPCResponse_STATUS_OK),
+			Hex:     "06080210021801",
+		},
+		{
+			Name:    "response/data",
+			Message: newDataResponse(3, nanorpc.NanoRPCResponse_TYPE_RESPONSE, payload),
+			Hex:     "0c08031002180152040a026869",
+		},
+		{
+			Name:    "response/not_found",
+			Message: newErrorResponse(4, nanorpc.NanoRPCResponse_STATUS_NOT_FOUND, "not found"),
+			Hex:     "1108041002180222096e6f7420666f756e64",
+		},
+		{
+			Name:    "response/not_authorized",
+			Message: newErrorResponse(5, nanorpc.NanoRPCResponse_STATUS_NOT_AUTHORIZED, ""),
+			Hex:     "06080510021803",
+		},
+		{
+			Name:    "response/internal_error",
+			Message: newErrorResponse(6, nanorpc.NanoRPCResponse_STATUS_INTERNAL_ERROR, "internal error"),
+			Hex:     "16080610021804220e696e7465726e616c206572726f72",
+		},
+		{
+			Name:    "response/timestamps",
+			Message: newTimestampsResponse(7, 1_000_000, 1_000_250),
+			Hex:     "100807100218012a0808c0843d10ba863d",
+		},
+		{
+			Name:    "update",
+			Message: newUpdate(8, 1, false, filter),
+			Hex:     "0c080810031801300152020801",
+		},
+		{
+			Name:    "update/snapshot",
+			Message: newUpdate(8, 300, true, filter),
+			Hex:     "0f08081003180130ac02380152020801",
+		},
+		{
+			Name:    "edge/unspecified",
+			Message: newResponse(0, nanorpc.NanoRPCResponse_TYPE_UNSPECIFIED, nanorpc.NanoRPCResponse_STATUS_UNSPECIFIED),
+			Hex:     "00",
+		},
+		{
+			Name:    "edge/two_byte_length",
+			Message: newDataResponse(9, nanorpc.NanoRPCResponse_TYPE_RESPONSE, largeData),
+			Hex:     "d10108091002180152c801" + strings.Repeat("78", 200),
+		},
+	}
+}
+
+func decodeOnlyResponses() []Response {
+	return []Response{
+		{
+			// field 15, varint 1, is skipped
+			Name:       "decode_only/unknown_field",
+			Message:    newResponse(1, nanorpc.NanoRPCResponse_TYPE_PONG, nanorpc.NanoRPCResponse_STATUS_OK),
+			Hex:        "080801100118017801",
+			DecodeOnly: true,
+		},
+		{
+			Name:       "decode_only/reordered",
+			Message:    newDataResponse(3, nanorpc.NanoRPCResponse_TYPE_RESPONSE, payload),
+			Hex:        "0c52040a026869180110020803",
+			DecodeOnly: true,
+		},
+		{
+			// snapshot explicitly encoded as false
+			Name:       "decode_only/explicit_false",
+			Message:    newUpdate(8, 1, false, filter),
+			Hex:        "0e0808100318013001380052020801",
+			DecodeOnly: true,
+		},
+	}
+}
+
+// MalformedFrames returns the frames both request and response decoders
+// must reject.
+func MalformedFrames() []Malformed {
+	return []Malformed{
+		// length prefix of 5, with 3 bytes following
+		{Name: "truncated_message", Hex: "05080110"},
+		// varint length prefix missing its last byte
+		{Name: "truncated_prefix", Hex: "80"},
+		// varint length prefix longer than ten bytes
+		{Name: "prefix_overflow", Hex: "ffffffffffffffffffff01"},
+		// length prefix of 2^32
+		{Name: "prefix_out_of_range", Hex: "8080808010"},
+		// field 1 with the invalid wire type 7
+		{Name: "invalid_wire_type", Hex: "020f01"},
+		// field number 0
+		{Name: "field_zero", Hex: "020001"},
+		// field 4 declaring 5 bytes, with 1 following
+		{Name: "truncated_field", Hex: "03220541"},
+	}
+}
+
+func newRequest(id int32, rt nanorpc.NanoRPCRequest_Type) *nanorpc.NanoRPCRequest {
+	return &nanorpc.NanoRPCRequest{RequestId: id, RequestType: rt}
+}
+
+func newHelloRequest(id int32, data []byte) *nanorpc.NanoRPCRequest {
+	req := newRequest(id, nanorpc.NanoRPCRequest_TYPE_PING)
+	req.Data = slices.Clone(data)
+	return req
+}
+
+func newPathRequest(id int32, rt nanorpc.NanoRPCRequest_Type, path string, data []byte) *nanorpc.NanoRPCRequest {
+	req := newRequest(id, rt)
+	req.PathOneof = &nanorpc.NanoRPCRequest_Path{Path: path}
+	req.Data = slices.Clone(data)
+	return req
+}
+
+func newHashRequest(id int32, rt nanorpc.NanoRPCRequest_Type, hash uint32, data []byte) *nanorpc.NanoRPCRequest {
+	req := newRequest(id, rt)
+	req.PathOneof = &nanorpc.NanoRPCRequest_PathHash{PathHash: hash}
+	req.Data = slices.Clone(data)
+	return req
+}
+
+func newResumeRequest(id int32, path string, after uint64) *nanorpc.NanoRPCRequest {
+	req := newPathRequest(id, nanorpc.NanoRPCRequest_TYPE_SUBSCRIBE, path, nil)
+	req.ResumeAfter = after
+	return req
+}
+
+func newResponse(id int32, rt nanorpc.NanoRPCResponse_Type,
+	st nanorpc.NanoRPCResponse_Status) *nanorpc.NanoRPCResponse {
+	return &nanorpc.NanoRPCResponse{RequestId: id, ResponseType: rt, ResponseStatus: st}
+}
+
+func newDataResponse(id int32, rt nanorpc.NanoRPCResponse_Type, data []byte) *nanorpc.NanoRPCResponse {
+	res := newResponse(id, rt, nanorpc.NanoRPCResponse_STATUS_OK)
+	res.Data = slices.Clone(data)
+	return res
+}
+
+func newErrorResponse(id int32, st nanorpc.NanoRPCResponse_Status, msg string) *nanorpc.NanoRPCResponse {
+	res := newResponse(id, nanorpc.NanoRPCResponse_TYPE_RESPONSE, st)
+	res.ResponseMessage = msg
+	return res
+}
+
+func newTimestampsResponse(id int32, received, processed uint64) *nanorpc.NanoRPCResponse {
+	res := newResponse(id, nanorpc.NanoRPCResponse_TYPE_RESPONSE, nanorpc.NanoRPCResponse_STATUS_OK)
+	res.Timestamps = &nanorpc.NanoRPCTimestamps{ReceivedUs: received, ProcessedUs: processed}
+	return res
+}
+
+func newUpdate(id int32, seq uint64, snapshot bool, data []byte) *nanorpc.NanoRPCResponse {
+	res := newDataResponse(id, nanorpc.NanoRPCResponse_TYPE_UPDATE, data)
+	res.Sequence = seq
+	res.Snapshot = snapshot
+	return res
+}
@@ -0,0 +1,270 @@
+package vectors
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+
+	"darvaza.org/core"
+
+	"protomcp.org/nanorpc/pkg/nanorpc"
+)
+
+// scanFrames splits data the way sessions read their connections.
+func scanFrames(data []byte) ([][]byte, error) {
+	var frames [][]byte
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if atEOF && len(data) == 0 {
+			// clean end of the stream
+			return 0, nil, nil
+		}
+		return nanorpc.Split(data, atEOF)
+	})
+	for scanner.Scan() {
+		frames = append(frames, bytes.Clone(scanner.Bytes()))
+	}
+	return frames, scanner.Err()
+}
+
+// decodeScanned decodes the single frame scanned from data.
+func decodeScanned[T any](t *testing.T, data []byte, decode func([]byte) (T, int, error)) T {
+	t.Helper()
+
+	var zero T
+	frames, err := scanFrames(data)
+	core.AssertMustNoError(t, err, "scan")
+	if !core.AssertEqual(t, 1, len(frames), "frames") {
+		return zero
+	}
+
+	out, n, err := decode(frames[0])
+	core.AssertNoError(t, err, "decode scanned")
+	core.AssertEqual(t, len(data), n, "scanned length")
+	return out
+}
+
+var _ core.TestCase = requestTestCase{}
+
+type requestTestCase struct {
+	v Request
+}
+
+func (tc requestTestCase) Name() string { return tc.v.Name }
+
+func (tc requestTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	frame, err := tc.v.Frame()
+	core.AssertMustNoError(t, err, "Frame")
+
+	if !tc.v.DecodeOnly {
+		tc.testEncode(t, frame)
+	}
+
+	got, n, err := nanorpc.DecodeRequest(frame)
+	core.AssertMustNoError(t, err, "DecodeRequest")
+	core.AssertEqual(t, len(frame), n, "length")
+	core.AssertTrue(t, equalKnown(tc.v.Message, got), "DecodeRequest: %v", got)
+
+	got = decodeScanned(t, frame, nanorpc.DecodeRequest)
+	core.AssertTrue(t, equalKnown(tc.v.Message, got), "scanned: %v", got)
+}
+
+func (tc requestTestCase) testEncode(t *testing.T, frame []byte) {
+	t.Helper()
+
+	b, err := nanorpc.EncodeRequest(tc.v.Message, nil)
+	core.AssertNoError(t, err, "EncodeRequest")
+	core.AssertSliceEqual(t, frame, b, "EncodeRequest")
+
+	var buf bytes.Buffer
+	n, err := nanorpc.EncodeRequestTo(&buf, tc.v.Message, nil)
+	core.AssertNoError(t, err, "EncodeRequestTo")
+	core.AssertEqual(t, len(frame), n, "EncodeRequestTo length")
+	core.AssertSliceEqual(t, frame, buf.Bytes(), "EncodeRequestTo")
+}
+
+func requestTestCases() []requestTestCase {
+	var out []requestTestCase
+	for _, v := range Requests() {
+		out = append(out, requestTestCase{v: v})
+	}
+	return out
+}
+
+func TestRequests(t *testing.T) {
+	core.RunTestCases(t, requestTestCases())
+}
+
+var _ core.TestCase = responseTestCase{}
+
+type responseTestCase struct {
+	v Response
+}
+
+func (tc responseTestCase) Name() string { return tc.v.Name }
+
+func (tc responseTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	frame, err := tc.v.Frame()
+	core.AssertMustNoError(t, err, "Frame")
+
+	if !tc.v.DecodeOnly {
+		tc.testEncode(t, frame)
+	}
+
+	got, n, err := nanorpc.DecodeResponse(frame)
+	core.AssertMustNoError(t, err, "DecodeResponse")
+	core.AssertEqual(t, len(frame), n, "length")
+	core.AssertTrue(t, equalKnown(tc.v.Message, got), "DecodeResponse: %v", got)
+
+	got = decodeScanned(t, frame, nanorpc.DecodeResponse)
+	core.AssertTrue(t, equalKnown(tc.v.Message, got), "scanned: %v", got)
+}
+
+func (tc responseTestCase) testEncode(t *testing.T, frame []byte) {
+	t.Helper()
+
+	b, err := nanorpc.EncodeResponse(tc.v.Message, nil)
+	core.AssertNoError(t, err, "EncodeResponse")
+	core.AssertSliceEqual(t, frame, b, "EncodeResponse")
+
+	var buf bytes.Buffer
+	n, err := nanorpc.EncodeResponseTo(&buf, tc.v.Message, nil)
+	core.AssertNoError(t, err, "EncodeResponseTo")
+	core.AssertEqual(t, len(frame), n, "EncodeResponseTo length")
+	core.AssertSliceEqual(t, frame, buf.Bytes(), "EncodeResponseTo")
+}
+
+func responseTestCases() []responseTestCase {
+	var out []responseTestCase
+	for _, v := range Responses() {
+		out = append(out, responseTestCase{v: v})
+	}
+	return out
+}
+
+func TestResponses(t *testing.T) {
+	core.RunTestCases(t, responseTestCases())
+}
+
+var _ core.TestCase = malformedTestCase{}
+
+type malformedTestCase struct {
+	v Malformed
+}
+
+func (tc malformedTestCase) Name() string { return tc.v.Name }
+
+func (tc malformedTestCase) Test(t *testing.T) {
+	t.Helper()
+
+	frame, err := tc.v.Frame()
+	core.AssertMustNoError(t, err, "Frame")
+
+	_, _, err = nanorpc.DecodeRequest(frame)
+	core.AssertError(t, err, "DecodeRequest")
+	_, _, err = nanorpc.DecodeResponse(frame)
+	core.AssertError(t, err, "DecodeResponse")
+
+	// the stream is rejected either while splitting or while decoding
+	frames, err := scanFrames(frame)
+	if err == nil {
+		core.AssertNotEqual(t, 0, len(frames), "frames")
+	}
+	for _, f := range frames {
+		_, _, err = nanorpc.DecodeRequest(f)
+		core.AssertError(t, err, "scanned DecodeRequest")
+		_, _, err = nanorpc.DecodeResponse(f)
+		core.AssertError(t, err, "scanned DecodeResponse")
+	}
+}
+
+func malformedTestCases() []malformedTestCase {
+	var out []malformedTestCase
+	for _, v := range MalformedFrames() {
+		out = append(out, malformedTestCase{v: v})
+	}
+	return out
+}
+
+func TestMalformedFrames(t *testing.T) {
+	core.RunTestCases(t, malformedTestCases())
+}
+
+func TestVectorNames(t *testing.T) {
+	seen := make(map[string]bool)
+	check := func(kind, name string) {
+		key := kind + ":" + name
+		core.AssertFalse(t, seen[key], "duplicate %s", key)
+		seen[key] = true
+	}
+
+	for _, v := range Requests() {
+		check("request", v.Name)
+	}
+	for _, v := range Responses() {
+		check("response", v.Name)
+	}
+	for _, v := range MalformedFrames() {
+		check("malformed", v.Name)
+	}
+
+	requests, err := RequestMatrix()
+	core.AssertMustNoError(t, err, "RequestMatrix")
+	for _, v := range requests {
+		check("request", v.Name)
+	}
+	responses, err := ResponseMatrix()
+	core.AssertMustNoError(t, err, "ResponseMatrix")
+	for _, v := range responses {
+		check("response", v.Name)
+	}
+}
+
+func TestRequests_fresh(t *testing.T) {
+	a, b := Requests(), Requests()
+	a[0].Message.RequestId = 99
+	a[3].Message.Data[0] = 0xff
+	core.AssertEqual(t, int32(1), b[0].Message.RequestId, "request_id")
+	core.AssertEqual(t, byte(0x0a), b[3].Message.Data[0], "data")
+}
+
+func TestValidateRequestDecoder(t *testing.T) {
+	core.AssertTrue(t, ValidateRequestDecoder(t, func(frame []byte) (*nanorpc.NanoRPCRequest, error) {
+		req, _, err := nanorpc.DecodeRequest(frame)
+		return req, err
+	}), "DecodeRequest")
+
+	core.AssertTrue(t, ValidateRequestDecoder(t, func(frame []byte) (*nanorpc.NanoRPCRequest, error) {
+		req := new(nanorpc.NanoRPCRequest)
+		_, err := nanorpc.DecodeRequestTo(frame, req)
+		return req, err
+	}), "DecodeRequestTo")
+}
+
+func TestValidateResponseDecoder(t *testing.T) {
+	core.AssertTrue(t, ValidateResponseDecoder(t, func(frame []byte) (*nanorpc.NanoRPCResponse, error) {
+		res, _, err := nanorpc.DecodeResponse(frame)
+		return res, err
+	}), "DecodeResponse")
+}
+
+func TestValidateRequestDecoder_fails(t *testing.T) {
+	// a decoder ignoring the path, and accepting anything
+	broken := func(frame []byte) (*nanorpc.NanoRPCRequest, error) {
+		req, _, err := nanorpc.DecodeRequest(frame)
+		if err != nil {
+			return new(nanorpc.NanoRPCRequest), nil
+		}
+		req.PathOneof = nil
+		return req, nil
+	}
+
+	var mt core.MockT
+	core.AssertFalse(t, ValidateRequestDecoder(&mt, broken), "ValidateRequestDecoder")
+	core.AssertTrue(t, mt.HasErrors(), "errors")
+}